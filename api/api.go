@@ -0,0 +1,9 @@
+// Package api embeds the machine-readable API contracts so they can be served by the application.
+package api
+
+import _ "embed"
+
+// AsyncAPI is the AsyncAPI document describing the events emitted by the service.
+//
+//go:embed asyncapi.json
+var AsyncAPI []byte
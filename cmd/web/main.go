@@ -1,10 +1,13 @@
 package main
 
 import (
-	"fmt"
+	"go-rest-scaffold/internal/buildinfo"
 	"go-rest-scaffold/internal/config"
+	"os"
 
 	_ "go-rest-scaffold/docs"
+
+	"github.com/sirupsen/logrus"
 )
 
 // @title           Golang Clean Architecture API
@@ -27,24 +30,55 @@ import (
 // @name Authorization
 // @description API token authentication. Format: your-token-here (without "Bearer" prefix)
 
+// @securityDefinitions.apikey APIKeyAuth
+// @in header
+// @name X-API-Key
+// @description API key of a machine client, created under /api-keys.
+
 func main() {
 	viperConfig := config.NewViper()
 	log := config.NewLogger(viperConfig)
+
+	// go run cmd/web/main.go migrate up|down|status
+	if len(os.Args) > 1 {
+		if err := config.RunCommand(viperConfig, log, os.Args[1:], os.Stdout); err != nil {
+			log.Fatalf("Failed to run %v: %v", os.Args[1:], err)
+		}
+		return
+	}
+
+	if viperConfig.GetBool("database.auto_migrate") {
+		if err := config.MigrateUp(viperConfig, log); err != nil {
+			log.Fatalf("Failed to migrate database: %v", err)
+		}
+	}
+
+	shutdownTracing := config.NewTracing(viperConfig, log)
 	db := config.NewDatabase(viperConfig, log)
+	redis := config.NewRedis(viperConfig, log)
 	validate := config.NewValidator(viperConfig)
-	app := config.NewFiber(viperConfig)
+	app := config.NewFiber(viperConfig, log)
+	server := config.NewServer(app, viperConfig, log)
 
-	config.Bootstrap(&config.BootstrapConfig{
+	application := config.Bootstrap(&config.BootstrapConfig{
 		DB:       db,
+		Redis:    redis,
 		App:      app,
 		Log:      log,
 		Validate: validate,
 		Config:   viperConfig,
+		Draining: server.Draining,
 	})
+	server.GRPC = application.GRPCServer
 
-	webPort := viperConfig.GetInt("web.port")
-	log.Infof("Starting server on port %d", webPort)
-	err := app.Listen(fmt.Sprintf(":%d", webPort))
+	log.WithFields(logrus.Fields{
+		"version":    buildinfo.Version,
+		"commit":     buildinfo.Commit,
+		"build_time": buildinfo.BuildTime,
+		"go_version": buildinfo.GoVersion,
+	}).Infof("Starting server on port %d", viperConfig.GetInt("web.port"))
+	err := server.Run()
+	shutdownTracing()
 	if err != nil {
 		log.Fatalf("Failed to start server: %v", err)
 	}
@@ -0,0 +1,24 @@
+// Package migrations embeds the SQL migrations of the schema, so the binary can
+// migrate its database without the migration files next to it. Each database driver
+// has its own directory; MySQL and SQLite start from a baseline of the schema the
+// Postgres migrations had built when they were added.
+package migrations
+
+import (
+	"embed"
+	"fmt"
+	"io/fs"
+)
+
+// files holds the <version>_<name>.up.sql and .down.sql files of golang-migrate.
+//
+//go:embed postgres/*.sql mysql/*.sql sqlite/*.sql
+var files embed.FS
+
+// For returns the migrations of a database driver: postgres, mysql or sqlite.
+func For(driver string) (fs.FS, error) {
+	if _, err := fs.Stat(files, driver); err != nil {
+		return nil, fmt.Errorf("no migrations for database driver %q", driver)
+	}
+	return fs.Sub(files, driver)
+}
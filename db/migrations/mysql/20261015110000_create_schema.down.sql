@@ -0,0 +1,21 @@
+drop table if exists login_events;
+drop table if exists webauthn_challenges;
+drop table if exists passkeys;
+drop table if exists magic_links;
+drop table if exists audit_logs;
+drop table if exists sessions;
+drop table if exists api_keys;
+drop table if exists password_resets;
+drop table if exists contact_changes;
+drop table if exists user_activity;
+drop table if exists reminders;
+drop table if exists webhook_deliveries;
+drop table if exists webhooks;
+drop table if exists email_templates;
+drop table if exists announcements;
+drop table if exists debug_captures;
+drop table if exists experiment_assignments;
+drop table if exists todos;
+drop table if exists addresses;
+drop table if exists contacts;
+drop table if exists users;
@@ -0,0 +1 @@
+drop index contacts_keyset_idx on contacts;
@@ -0,0 +1 @@
+create index contacts_keyset_idx on contacts (user_id, created_at, id);
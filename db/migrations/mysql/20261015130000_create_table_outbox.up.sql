@@ -0,0 +1,15 @@
+create table outbox
+(
+    id           bigint       not null auto_increment,
+    event_id     varchar(100) not null,
+    type         varchar(100) not null,
+    user_id      varchar(100) not null,
+    payload      text         not null,
+    attempts     int          not null default 0,
+    last_error   text         not null,
+    created_at   bigint       not null,
+    published_at bigint       null,
+    primary key (id)
+);
+
+create index outbox_published_at_idx on outbox (published_at, id);
@@ -0,0 +1,8 @@
+create table daily_stats
+(
+    day              date   not null,
+    registrations    bigint not null,
+    active_users     bigint not null,
+    contacts_created bigint not null,
+    primary key (day)
+);
@@ -0,0 +1,2 @@
+alter table addresses drop column version;
+alter table contacts drop column version;
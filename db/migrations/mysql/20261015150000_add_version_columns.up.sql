@@ -0,0 +1,2 @@
+alter table contacts add column version bigint not null default 1;
+alter table addresses add column version bigint not null default 1;
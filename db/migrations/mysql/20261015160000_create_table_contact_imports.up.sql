@@ -0,0 +1,22 @@
+create table contact_imports
+(
+    id             varchar(100) not null,
+    user_id        varchar(100) not null,
+    status         varchar(20)  not null,
+    data           longtext     not null,
+    mapping        text         not null,
+    total_rows     int          not null default 0,
+    processed_rows int          not null default 0,
+    imported       int          not null default 0,
+    failed         int          not null default 0,
+    errors         mediumtext   not null,
+    error          text         not null,
+    created_at     bigint       not null,
+    updated_at     bigint       not null,
+    completed_at   bigint       null,
+    primary key (id),
+    foreign key (user_id) references users (id)
+);
+
+create index contact_imports_user_id_idx on contact_imports (user_id);
+create index contact_imports_status_idx on contact_imports (status, created_at);
@@ -0,0 +1,27 @@
+create table tags
+(
+    id         varchar(100) not null,
+    user_id    varchar(100) not null,
+    name       varchar(50)  not null,
+    color      varchar(7)   not null default '',
+    created_at bigint       not null,
+    updated_at bigint       not null,
+    created_by varchar(100) not null default '',
+    updated_by varchar(100) not null default '',
+    primary key (id),
+    foreign key (user_id) references users (id) on delete cascade
+);
+
+create unique index tags_user_id_name_idx on tags (user_id, name);
+
+create table contact_tags
+(
+    contact_id varchar(100) not null,
+    tag_id     varchar(100) not null,
+    created_at bigint       not null,
+    primary key (contact_id, tag_id),
+    foreign key (contact_id) references contacts (id) on delete cascade,
+    foreign key (tag_id) references tags (id) on delete cascade
+);
+
+create index contact_tags_tag_id_idx on contact_tags (tag_id);
@@ -0,0 +1,14 @@
+create table notes
+(
+    id         varchar(100) not null,
+    contact_id varchar(100) not null,
+    body       text         not null,
+    created_at bigint       not null,
+    updated_at bigint       not null,
+    created_by varchar(100) not null default '',
+    updated_by varchar(100) not null default '',
+    primary key (id),
+    foreign key (contact_id) references contacts (id) on delete cascade
+);
+
+create index notes_contact_id_created_at_idx on notes (contact_id, created_at);
@@ -0,0 +1 @@
+drop table experiment_assignments;
\ No newline at end of file
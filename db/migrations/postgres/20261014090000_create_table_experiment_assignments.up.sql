@@ -0,0 +1,11 @@
+create table experiment_assignments
+(
+    user_id        varchar(100) not null,
+    experiment_key varchar(100) not null,
+    variant        varchar(100) not null,
+    created_at     bigint       not null,
+    primary key (user_id, experiment_key),
+    foreign key (user_id) references users (id)
+);
+
+create index experiment_assignments_experiment_key_variant_idx on experiment_assignments (experiment_key, variant);
@@ -0,0 +1 @@
+drop table debug_captures;
\ No newline at end of file
@@ -0,0 +1,19 @@
+create table debug_captures
+(
+    id               varchar(100) not null,
+    user_id          varchar(100),
+    method           varchar(10)  not null,
+    path             text         not null,
+    status           int          not null,
+    duration_ms      bigint       not null,
+    request_headers  text,
+    request_body     text,
+    response_headers text,
+    response_body    text,
+    created_at       bigint       not null,
+    expires_at       bigint       not null,
+    primary key (id)
+);
+
+create index debug_captures_user_id_idx on debug_captures (user_id);
+create index debug_captures_expires_at_idx on debug_captures (expires_at);
@@ -0,0 +1 @@
+drop table announcements;
\ No newline at end of file
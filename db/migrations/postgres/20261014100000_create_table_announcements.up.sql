@@ -0,0 +1,14 @@
+create table announcements
+(
+    id         varchar(100) not null,
+    title      varchar(255) not null,
+    body       text,
+    level      varchar(20)  not null,
+    starts_at  bigint       not null,
+    ends_at    bigint       not null default 0,
+    created_at bigint       not null,
+    updated_at bigint       not null,
+    primary key (id)
+);
+
+create index announcements_starts_at_ends_at_idx on announcements (starts_at, ends_at);
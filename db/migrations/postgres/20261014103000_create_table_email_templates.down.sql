@@ -0,0 +1 @@
+drop table email_templates;
\ No newline at end of file
@@ -0,0 +1,12 @@
+create table email_templates
+(
+    tenant     varchar(100) not null default '',
+    name       varchar(100) not null,
+    version    int          not null,
+    subject    text         not null,
+    html       text         not null,
+    text       text         not null,
+    created_at bigint       not null,
+    updated_at bigint       not null,
+    primary key (tenant, name)
+);
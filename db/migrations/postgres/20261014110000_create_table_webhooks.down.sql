@@ -0,0 +1,2 @@
+drop table webhook_deliveries;
+drop table webhooks;
\ No newline at end of file
@@ -0,0 +1,35 @@
+create table webhooks
+(
+    id          varchar(100)  not null,
+    user_id     varchar(100)  not null,
+    url         varchar(2000) not null,
+    event_types text          not null default '',
+    active      boolean       not null default true,
+    created_at  bigint        not null,
+    updated_at  bigint        not null,
+    primary key (id),
+    foreign key (user_id) references users (id) on delete cascade
+);
+
+create index webhooks_user_id_idx on webhooks (user_id);
+
+create table webhook_deliveries
+(
+    id               varchar(100) not null,
+    webhook_id       varchar(100) not null,
+    event_id         varchar(100) not null,
+    event_type       varchar(200) not null,
+    payload          text         not null,
+    attempt          int          not null,
+    status           varchar(20)  not null,
+    response_status  int          not null default 0,
+    latency_ms       bigint       not null default 0,
+    response_snippet text,
+    error            text,
+    replay_of        varchar(100),
+    created_at       bigint       not null,
+    primary key (id),
+    foreign key (webhook_id) references webhooks (id) on delete cascade
+);
+
+create index webhook_deliveries_webhook_id_created_at_idx on webhook_deliveries (webhook_id, created_at);
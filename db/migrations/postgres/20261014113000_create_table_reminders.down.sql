@@ -0,0 +1 @@
+drop table reminders;
\ No newline at end of file
@@ -0,0 +1,22 @@
+create table reminders
+(
+    id         varchar(100) not null,
+    user_id    varchar(100) not null,
+    contact_id varchar(100) not null,
+    note       text,
+    local_time varchar(20)  not null,
+    timezone   varchar(100) not null,
+    remind_at  bigint       not null,
+    channels   varchar(100) not null,
+    status     varchar(20)  not null,
+    error      text,
+    sent_at    bigint       not null default 0,
+    created_at bigint       not null,
+    updated_at bigint       not null,
+    primary key (id),
+    foreign key (user_id) references users (id) on delete cascade,
+    foreign key (contact_id) references contacts (id) on delete cascade
+);
+
+create index reminders_user_id_idx on reminders (user_id);
+create index reminders_status_remind_at_idx on reminders (status, remind_at);
@@ -0,0 +1,2 @@
+ALTER TABLE addresses DROP COLUMN deleted_at;
+ALTER TABLE contacts DROP COLUMN deleted_at;
\ No newline at end of file
@@ -0,0 +1,5 @@
+ALTER TABLE contacts ADD COLUMN deleted_at TIMESTAMPTZ NULL;
+ALTER TABLE addresses ADD COLUMN deleted_at TIMESTAMPTZ NULL;
+
+CREATE INDEX contacts_deleted_at_idx ON contacts (deleted_at);
+CREATE INDEX addresses_deleted_at_idx ON addresses (deleted_at);
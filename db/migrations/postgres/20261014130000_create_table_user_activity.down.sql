@@ -0,0 +1,3 @@
+drop index contacts_created_at_idx;
+drop index users_created_at_idx;
+drop table user_activity;
\ No newline at end of file
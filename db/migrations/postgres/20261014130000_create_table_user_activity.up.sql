@@ -0,0 +1,11 @@
+create table user_activity
+(
+    user_id varchar(100) not null,
+    day     date         not null,
+    primary key (user_id, day),
+    foreign key (user_id) references users (id) on delete cascade
+);
+
+create index user_activity_day_idx on user_activity (day);
+create index users_created_at_idx on users (created_at);
+create index contacts_created_at_idx on contacts (created_at);
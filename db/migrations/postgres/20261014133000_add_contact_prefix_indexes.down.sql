@@ -0,0 +1,3 @@
+drop index contacts_email_prefix_idx;
+drop index contacts_last_name_prefix_idx;
+drop index contacts_first_name_prefix_idx;
\ No newline at end of file
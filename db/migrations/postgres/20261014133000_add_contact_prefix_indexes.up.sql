@@ -0,0 +1,3 @@
+create index contacts_first_name_prefix_idx on contacts (user_id, lower(first_name) text_pattern_ops) where deleted_at is null;
+create index contacts_last_name_prefix_idx on contacts (user_id, lower(last_name) text_pattern_ops) where deleted_at is null;
+create index contacts_email_prefix_idx on contacts (user_id, lower(email) text_pattern_ops) where deleted_at is null;
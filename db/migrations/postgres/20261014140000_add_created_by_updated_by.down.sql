@@ -0,0 +1,6 @@
+ALTER TABLE email_templates DROP COLUMN updated_by, DROP COLUMN created_by;
+ALTER TABLE announcements DROP COLUMN updated_by, DROP COLUMN created_by;
+ALTER TABLE webhooks DROP COLUMN updated_by, DROP COLUMN created_by;
+ALTER TABLE reminders DROP COLUMN updated_by, DROP COLUMN created_by;
+ALTER TABLE addresses DROP COLUMN updated_by, DROP COLUMN created_by;
+ALTER TABLE contacts DROP COLUMN updated_by, DROP COLUMN created_by;
\ No newline at end of file
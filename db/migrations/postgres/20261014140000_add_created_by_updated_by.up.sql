@@ -0,0 +1,6 @@
+ALTER TABLE contacts ADD COLUMN created_by VARCHAR(100) NOT NULL DEFAULT '', ADD COLUMN updated_by VARCHAR(100) NOT NULL DEFAULT '';
+ALTER TABLE addresses ADD COLUMN created_by VARCHAR(100) NOT NULL DEFAULT '', ADD COLUMN updated_by VARCHAR(100) NOT NULL DEFAULT '';
+ALTER TABLE reminders ADD COLUMN created_by VARCHAR(100) NOT NULL DEFAULT '', ADD COLUMN updated_by VARCHAR(100) NOT NULL DEFAULT '';
+ALTER TABLE webhooks ADD COLUMN created_by VARCHAR(100) NOT NULL DEFAULT '', ADD COLUMN updated_by VARCHAR(100) NOT NULL DEFAULT '';
+ALTER TABLE announcements ADD COLUMN created_by VARCHAR(100) NOT NULL DEFAULT '', ADD COLUMN updated_by VARCHAR(100) NOT NULL DEFAULT '';
+ALTER TABLE email_templates ADD COLUMN created_by VARCHAR(100) NOT NULL DEFAULT '', ADD COLUMN updated_by VARCHAR(100) NOT NULL DEFAULT '';
@@ -0,0 +1,6 @@
+DROP SEQUENCE announcements_id_seq;
+DROP SEQUENCE webhook_deliveries_id_seq;
+DROP SEQUENCE webhooks_id_seq;
+DROP SEQUENCE reminders_id_seq;
+DROP SEQUENCE addresses_id_seq;
+DROP SEQUENCE contacts_id_seq;
\ No newline at end of file
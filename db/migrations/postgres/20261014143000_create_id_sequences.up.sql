@@ -0,0 +1,6 @@
+CREATE SEQUENCE contacts_id_seq;
+CREATE SEQUENCE addresses_id_seq;
+CREATE SEQUENCE reminders_id_seq;
+CREATE SEQUENCE webhooks_id_seq;
+CREATE SEQUENCE webhook_deliveries_id_seq;
+CREATE SEQUENCE announcements_id_seq;
@@ -0,0 +1 @@
+ALTER TABLE users DROP COLUMN region;
\ No newline at end of file
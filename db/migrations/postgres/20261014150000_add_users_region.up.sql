@@ -0,0 +1 @@
+ALTER TABLE users ADD COLUMN region VARCHAR(20) NOT NULL DEFAULT '';
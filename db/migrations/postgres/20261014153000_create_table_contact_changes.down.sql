@@ -0,0 +1 @@
+drop table contact_changes;
\ No newline at end of file
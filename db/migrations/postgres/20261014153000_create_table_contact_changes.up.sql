@@ -0,0 +1,11 @@
+create table contact_changes
+(
+    id         bigserial    not null,
+    user_id    varchar(100) not null,
+    contact_id varchar(100) not null,
+    created_at bigint       not null,
+    primary key (id)
+);
+
+create index contact_changes_user_id_idx on contact_changes (user_id, id);
+create index contact_changes_created_at_idx on contact_changes (created_at);
@@ -0,0 +1,4 @@
+drop table password_resets;
+
+DROP INDEX users_email_idx;
+ALTER TABLE users DROP COLUMN email;
\ No newline at end of file
@@ -0,0 +1,14 @@
+ALTER TABLE users ADD COLUMN email VARCHAR(100) NOT NULL DEFAULT '';
+CREATE UNIQUE INDEX users_email_idx ON users (lower(email)) WHERE email <> '';
+
+create table password_resets
+(
+    id         varchar(64)  not null,
+    user_id    varchar(100) not null,
+    expires_at bigint       not null,
+    created_at bigint       not null,
+    primary key (id),
+    foreign key (user_id) references users (id) on delete cascade
+);
+
+create index password_resets_user_id_idx on password_resets (user_id);
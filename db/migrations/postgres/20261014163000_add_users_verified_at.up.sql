@@ -0,0 +1,4 @@
+ALTER TABLE users ADD COLUMN verified_at BIGINT NULL;
+
+-- accounts created before verification existed must not be locked out when it is required
+UPDATE users SET verified_at = created_at;
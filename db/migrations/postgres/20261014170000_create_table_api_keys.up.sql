@@ -0,0 +1,16 @@
+create table api_keys
+(
+    id           varchar(100) not null,
+    user_id      varchar(100) not null,
+    name         varchar(100) not null,
+    prefix       varchar(20)  not null,
+    key_hash     varchar(64)  not null,
+    last_used_at bigint,
+    revoked_at   bigint,
+    created_at   bigint       not null,
+    primary key (id),
+    foreign key (user_id) references users (id) on delete cascade
+);
+
+create unique index api_keys_key_hash_idx on api_keys (key_hash);
+create index api_keys_user_id_idx on api_keys (user_id);
@@ -0,0 +1 @@
+drop table sessions;
\ No newline at end of file
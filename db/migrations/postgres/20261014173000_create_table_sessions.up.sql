@@ -0,0 +1,24 @@
+create table sessions
+(
+    id            varchar(100) not null,
+    user_id       varchar(100) not null,
+    token         varchar(100) not null,
+    refresh_token varchar(100) not null,
+    device        varchar(100) not null default '',
+    ip            varchar(45)  not null default '',
+    user_agent    varchar(500) not null default '',
+    last_seen_at  bigint       not null,
+    created_at    bigint       not null,
+    primary key (id),
+    foreign key (user_id) references users (id) on delete cascade
+);
+
+create unique index sessions_token_idx on sessions (token);
+create unique index sessions_refresh_token_idx on sessions (refresh_token);
+create index sessions_user_id_idx on sessions (user_id);
+
+-- users logged in before sessions existed keep their login as a session
+insert into sessions (id, user_id, token, refresh_token, last_seen_at, created_at)
+select md5(id || token)::uuid::text, id, token, coalesce(refresh_token, md5(random()::text)), updated_at, updated_at
+from users
+where token is not null and token <> '';
@@ -0,0 +1,16 @@
+create table audit_logs
+(
+    id          varchar(100) not null,
+    user_id     varchar(100) not null default '',
+    action      varchar(20)  not null,
+    entity_type varchar(50)  not null,
+    entity_id   varchar(100) not null,
+    changes     text         not null,
+    ip          varchar(45)  not null default '',
+    created_at  bigint       not null,
+    primary key (id)
+);
+
+create index audit_logs_created_at_idx on audit_logs (created_at);
+create index audit_logs_user_id_idx on audit_logs (user_id, created_at);
+create index audit_logs_entity_type_idx on audit_logs (entity_type, created_at);
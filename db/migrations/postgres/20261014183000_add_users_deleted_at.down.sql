@@ -0,0 +1,2 @@
+DROP INDEX users_deleted_at_idx;
+ALTER TABLE users DROP COLUMN deleted_at;
\ No newline at end of file
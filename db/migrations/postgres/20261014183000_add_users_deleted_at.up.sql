@@ -0,0 +1,3 @@
+ALTER TABLE users ADD COLUMN deleted_at BIGINT NULL;
+
+CREATE INDEX users_deleted_at_idx ON users (deleted_at) WHERE deleted_at IS NOT NULL;
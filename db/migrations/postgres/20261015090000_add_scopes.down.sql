@@ -0,0 +1,3 @@
+ALTER TABLE api_keys DROP COLUMN scopes;
+
+ALTER TABLE sessions DROP COLUMN scopes;
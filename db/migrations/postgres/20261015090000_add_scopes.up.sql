@@ -0,0 +1,3 @@
+ALTER TABLE sessions ADD COLUMN scopes VARCHAR(500) NOT NULL DEFAULT '';
+
+ALTER TABLE api_keys ADD COLUMN scopes VARCHAR(500) NOT NULL DEFAULT '';
@@ -0,0 +1,11 @@
+create table magic_links
+(
+    id         varchar(64)  not null,
+    user_id    varchar(100) not null,
+    expires_at bigint       not null,
+    created_at bigint       not null,
+    primary key (id),
+    foreign key (user_id) references users (id) on delete cascade
+);
+
+create index magic_links_user_id_idx on magic_links (user_id);
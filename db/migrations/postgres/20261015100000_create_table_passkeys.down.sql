@@ -0,0 +1,3 @@
+drop table webauthn_challenges;
+
+drop table passkeys;
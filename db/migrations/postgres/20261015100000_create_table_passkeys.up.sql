@@ -0,0 +1,27 @@
+create table passkeys
+(
+    id            varchar(100)  not null,
+    user_id       varchar(100)  not null,
+    name          varchar(100)  not null,
+    credential_id varchar(1400) not null,
+    public_key    bytea         not null,
+    sign_count    bigint        not null default 0,
+    last_used_at  bigint        null,
+    created_at    bigint        not null,
+    primary key (id),
+    foreign key (user_id) references users (id) on delete cascade
+);
+
+create unique index passkeys_credential_id_idx on passkeys (credential_id);
+create index passkeys_user_id_idx on passkeys (user_id);
+
+create table webauthn_challenges
+(
+    id         varchar(64)  not null,
+    user_id    varchar(100) not null default '',
+    expires_at bigint       not null,
+    created_at bigint       not null,
+    primary key (id)
+);
+
+create index webauthn_challenges_expires_at_idx on webauthn_challenges (expires_at);
@@ -0,0 +1,4 @@
+ALTER TABLE audit_logs DROP COLUMN impersonator_id;
+
+ALTER TABLE sessions DROP COLUMN expires_at;
+ALTER TABLE sessions DROP COLUMN impersonator_id;
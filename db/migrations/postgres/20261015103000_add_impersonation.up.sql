@@ -0,0 +1,4 @@
+ALTER TABLE sessions ADD COLUMN impersonator_id VARCHAR(100) NOT NULL DEFAULT '';
+ALTER TABLE sessions ADD COLUMN expires_at BIGINT;
+
+ALTER TABLE audit_logs ADD COLUMN impersonator_id VARCHAR(100) NOT NULL DEFAULT '';
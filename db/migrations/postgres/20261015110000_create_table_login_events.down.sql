@@ -0,0 +1 @@
+drop table login_events;
@@ -0,0 +1,16 @@
+create table login_events
+(
+    id         varchar(100) not null,
+    user_id    varchar(100) not null,
+    method     varchar(20)  not null,
+    success    boolean      not null,
+    reason     varchar(100) not null default '',
+    ip         varchar(45)  not null default '',
+    device     varchar(100) not null default '',
+    user_agent varchar(500) not null default '',
+    created_at bigint       not null,
+    primary key (id),
+    foreign key (user_id) references users (id) on delete cascade
+);
+
+create index login_events_user_id_idx on login_events (user_id, created_at);
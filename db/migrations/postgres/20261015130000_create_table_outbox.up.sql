@@ -0,0 +1,16 @@
+create table outbox
+(
+    id           bigserial    not null,
+    event_id     varchar(100) not null,
+    type         varchar(100) not null,
+    user_id      varchar(100) not null,
+    payload      text         not null,
+    attempts     int          not null default 0,
+    last_error   text         not null default '',
+    created_at   bigint       not null,
+    published_at bigint       null,
+    primary key (id)
+);
+
+create index outbox_unpublished_idx on outbox (id) where published_at is null;
+create index outbox_published_at_idx on outbox (published_at);
@@ -0,0 +1 @@
+drop table daily_stats;
@@ -0,0 +1,3 @@
+ALTER TABLE addresses DROP COLUMN version;
+
+ALTER TABLE contacts DROP COLUMN version;
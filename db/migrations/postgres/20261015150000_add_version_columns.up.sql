@@ -0,0 +1,3 @@
+ALTER TABLE contacts ADD COLUMN version BIGINT NOT NULL DEFAULT 1;
+
+ALTER TABLE addresses ADD COLUMN version BIGINT NOT NULL DEFAULT 1;
@@ -0,0 +1 @@
+drop table contact_imports;
@@ -0,0 +1,4 @@
+drop table contact_tags;
+drop table tags;
+
+drop sequence tags_id_seq;
@@ -0,0 +1,3 @@
+drop table notes;
+
+drop sequence notes_id_seq;
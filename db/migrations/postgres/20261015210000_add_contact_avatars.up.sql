@@ -0,0 +1 @@
+alter table contacts add column avatar_id varchar(100) not null default '';
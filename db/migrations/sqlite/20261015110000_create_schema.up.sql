@@ -0,0 +1,352 @@
+-- Baseline of the schema built by the Postgres migrations up to this version.
+create table users
+(
+    id            varchar(100) not null,
+    name          varchar(100) not null,
+    password      varchar(100) not null,
+    token         varchar(100) null,
+    refresh_token varchar(255) null,
+    role          varchar(20)  not null default 'user',
+    region        varchar(20)  not null default '',
+    email         varchar(100) not null default '',
+    verified_at   bigint       null,
+    deleted_at    bigint       null,
+    created_at    bigint       not null,
+    updated_at    bigint       not null,
+    primary key (id)
+);
+
+create index users_created_at_idx on users (created_at);
+create unique index users_email_idx on users (lower(email)) where email <> '';
+create index users_deleted_at_idx on users (deleted_at) where deleted_at is not null;
+
+create table contacts
+(
+    id         varchar(100) not null,
+    first_name varchar(100) not null,
+    last_name  varchar(100) null,
+    email      varchar(100) null,
+    phone      varchar(100) null,
+    user_id    varchar(100) not null,
+    created_at bigint       not null,
+    updated_at bigint       not null,
+    deleted_at datetime     null,
+    created_by varchar(100) not null default '',
+    updated_by varchar(100) not null default '',
+    primary key (id),
+    foreign key (user_id) references users (id)
+);
+
+create index contacts_deleted_at_idx on contacts (deleted_at);
+create index contacts_created_at_idx on contacts (created_at);
+create index contacts_first_name_prefix_idx on contacts (user_id, lower(first_name)) where deleted_at is null;
+create index contacts_last_name_prefix_idx on contacts (user_id, lower(last_name)) where deleted_at is null;
+create index contacts_email_prefix_idx on contacts (user_id, lower(email)) where deleted_at is null;
+
+create table addresses
+(
+    id          varchar(100) not null,
+    contact_id  varchar(100) not null,
+    street      varchar(255),
+    city        varchar(255),
+    province    varchar(255),
+    postal_code varchar(10),
+    country     varchar(100),
+    created_at  bigint       not null,
+    updated_at  bigint       not null,
+    deleted_at  datetime     null,
+    created_by  varchar(100) not null default '',
+    updated_by  varchar(100) not null default '',
+    primary key (id),
+    foreign key (contact_id) references contacts (id)
+);
+
+create index addresses_deleted_at_idx on addresses (deleted_at);
+
+create table todos
+(
+    id          varchar(100) not null,
+    title       varchar(100) not null,
+    description text         null,
+    user_id     varchar(100) not null,
+    created_at  bigint       not null,
+    updated_at  bigint       not null,
+    deleted_at  bigint       null,
+    primary key (id),
+    foreign key (user_id) references users (id)
+);
+
+create table experiment_assignments
+(
+    user_id        varchar(100) not null,
+    experiment_key varchar(100) not null,
+    variant        varchar(100) not null,
+    created_at     bigint       not null,
+    primary key (user_id, experiment_key),
+    foreign key (user_id) references users (id)
+);
+
+create index experiment_assignments_experiment_key_variant_idx on experiment_assignments (experiment_key, variant);
+
+create table debug_captures
+(
+    id               varchar(100) not null,
+    user_id          varchar(100),
+    method           varchar(10)  not null,
+    path             text         not null,
+    status           int          not null,
+    duration_ms      bigint       not null,
+    request_headers  text,
+    request_body     text,
+    response_headers text,
+    response_body    text,
+    created_at       bigint       not null,
+    expires_at       bigint       not null,
+    primary key (id)
+);
+
+create index debug_captures_user_id_idx on debug_captures (user_id);
+create index debug_captures_expires_at_idx on debug_captures (expires_at);
+
+create table announcements
+(
+    id         varchar(100) not null,
+    title      varchar(255) not null,
+    body       text,
+    level      varchar(20)  not null,
+    starts_at  bigint       not null,
+    ends_at    bigint       not null default 0,
+    created_at bigint       not null,
+    updated_at bigint       not null,
+    created_by varchar(100) not null default '',
+    updated_by varchar(100) not null default '',
+    primary key (id)
+);
+
+create index announcements_starts_at_ends_at_idx on announcements (starts_at, ends_at);
+
+create table email_templates
+(
+    tenant     varchar(100) not null default '',
+    name       varchar(100) not null,
+    version    int          not null,
+    subject    text         not null,
+    html       text         not null,
+    text       text         not null,
+    created_at bigint       not null,
+    updated_at bigint       not null,
+    created_by varchar(100) not null default '',
+    updated_by varchar(100) not null default '',
+    primary key (tenant, name)
+);
+
+create table webhooks
+(
+    id          varchar(100)  not null,
+    user_id     varchar(100)  not null,
+    url         varchar(2000) not null,
+    event_types text          not null default '',
+    active      boolean       not null default true,
+    created_at  bigint        not null,
+    updated_at  bigint        not null,
+    created_by  varchar(100)  not null default '',
+    updated_by  varchar(100)  not null default '',
+    primary key (id),
+    foreign key (user_id) references users (id) on delete cascade
+);
+
+create index webhooks_user_id_idx on webhooks (user_id);
+
+create table webhook_deliveries
+(
+    id               varchar(100) not null,
+    webhook_id       varchar(100) not null,
+    event_id         varchar(100) not null,
+    event_type       varchar(200) not null,
+    payload          text         not null,
+    attempt          int          not null,
+    status           varchar(20)  not null,
+    response_status  int          not null default 0,
+    latency_ms       bigint       not null default 0,
+    response_snippet text,
+    error            text,
+    replay_of        varchar(100),
+    created_at       bigint       not null,
+    primary key (id),
+    foreign key (webhook_id) references webhooks (id) on delete cascade
+);
+
+create index webhook_deliveries_webhook_id_created_at_idx on webhook_deliveries (webhook_id, created_at);
+
+create table reminders
+(
+    id         varchar(100) not null,
+    user_id    varchar(100) not null,
+    contact_id varchar(100) not null,
+    note       text,
+    local_time varchar(20)  not null,
+    timezone   varchar(100) not null,
+    remind_at  bigint       not null,
+    channels   varchar(100) not null,
+    status     varchar(20)  not null,
+    error      text,
+    sent_at    bigint       not null default 0,
+    created_at bigint       not null,
+    updated_at bigint       not null,
+    created_by varchar(100) not null default '',
+    updated_by varchar(100) not null default '',
+    primary key (id),
+    foreign key (user_id) references users (id) on delete cascade,
+    foreign key (contact_id) references contacts (id) on delete cascade
+);
+
+create index reminders_user_id_idx on reminders (user_id);
+create index reminders_status_remind_at_idx on reminders (status, remind_at);
+
+create table user_activity
+(
+    user_id varchar(100) not null,
+    day     date         not null,
+    primary key (user_id, day),
+    foreign key (user_id) references users (id) on delete cascade
+);
+
+create index user_activity_day_idx on user_activity (day);
+
+create table contact_changes
+(
+    id         integer      not null primary key autoincrement,
+    user_id    varchar(100) not null,
+    contact_id varchar(100) not null,
+    created_at bigint       not null
+);
+
+create index contact_changes_user_id_idx on contact_changes (user_id, id);
+create index contact_changes_created_at_idx on contact_changes (created_at);
+
+create table password_resets
+(
+    id         varchar(64)  not null,
+    user_id    varchar(100) not null,
+    expires_at bigint       not null,
+    created_at bigint       not null,
+    primary key (id),
+    foreign key (user_id) references users (id) on delete cascade
+);
+
+create index password_resets_user_id_idx on password_resets (user_id);
+
+create table api_keys
+(
+    id           varchar(100) not null,
+    user_id      varchar(100) not null,
+    name         varchar(100) not null,
+    prefix       varchar(20)  not null,
+    key_hash     varchar(64)  not null,
+    scopes       varchar(500) not null default '',
+    last_used_at bigint,
+    revoked_at   bigint,
+    created_at   bigint       not null,
+    primary key (id),
+    foreign key (user_id) references users (id) on delete cascade
+);
+
+create unique index api_keys_key_hash_idx on api_keys (key_hash);
+create index api_keys_user_id_idx on api_keys (user_id);
+
+create table sessions
+(
+    id              varchar(100) not null,
+    user_id         varchar(100) not null,
+    token           varchar(100) not null,
+    refresh_token   varchar(100) not null,
+    device          varchar(100) not null default '',
+    ip              varchar(45)  not null default '',
+    user_agent      varchar(500) not null default '',
+    scopes          varchar(500) not null default '',
+    impersonator_id varchar(100) not null default '',
+    expires_at      bigint,
+    last_seen_at    bigint       not null,
+    created_at      bigint       not null,
+    primary key (id),
+    foreign key (user_id) references users (id) on delete cascade
+);
+
+create unique index sessions_token_idx on sessions (token);
+create unique index sessions_refresh_token_idx on sessions (refresh_token);
+create index sessions_user_id_idx on sessions (user_id);
+
+create table audit_logs
+(
+    id              varchar(100) not null,
+    user_id         varchar(100) not null default '',
+    impersonator_id varchar(100) not null default '',
+    action          varchar(20)  not null,
+    entity_type     varchar(50)  not null,
+    entity_id       varchar(100) not null,
+    changes         text         not null,
+    ip              varchar(45)  not null default '',
+    created_at      bigint       not null,
+    primary key (id)
+);
+
+create index audit_logs_created_at_idx on audit_logs (created_at);
+create index audit_logs_user_id_idx on audit_logs (user_id, created_at);
+create index audit_logs_entity_type_idx on audit_logs (entity_type, created_at);
+
+create table magic_links
+(
+    id         varchar(64)  not null,
+    user_id    varchar(100) not null,
+    expires_at bigint       not null,
+    created_at bigint       not null,
+    primary key (id),
+    foreign key (user_id) references users (id) on delete cascade
+);
+
+create index magic_links_user_id_idx on magic_links (user_id);
+
+create table passkeys
+(
+    id            varchar(100)  not null,
+    user_id       varchar(100)  not null,
+    name          varchar(100)  not null,
+    credential_id varchar(1400) not null,
+    public_key    blob          not null,
+    sign_count    bigint        not null default 0,
+    last_used_at  bigint        null,
+    created_at    bigint        not null,
+    primary key (id),
+    foreign key (user_id) references users (id) on delete cascade
+);
+
+create unique index passkeys_credential_id_idx on passkeys (credential_id);
+create index passkeys_user_id_idx on passkeys (user_id);
+
+create table webauthn_challenges
+(
+    id         varchar(64)  not null,
+    user_id    varchar(100) not null default '',
+    expires_at bigint       not null,
+    created_at bigint       not null,
+    primary key (id)
+);
+
+create index webauthn_challenges_expires_at_idx on webauthn_challenges (expires_at);
+
+create table login_events
+(
+    id         varchar(100) not null,
+    user_id    varchar(100) not null,
+    method     varchar(20)  not null,
+    success    boolean      not null,
+    reason     varchar(100) not null default '',
+    ip         varchar(45)  not null default '',
+    device     varchar(100) not null default '',
+    user_agent varchar(500) not null default '',
+    created_at bigint       not null,
+    primary key (id),
+    foreign key (user_id) references users (id) on delete cascade
+);
+
+create index login_events_user_id_idx on login_events (user_id, created_at);
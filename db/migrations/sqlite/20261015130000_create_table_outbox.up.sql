@@ -0,0 +1,15 @@
+create table outbox
+(
+    id           integer      not null primary key autoincrement,
+    event_id     varchar(100) not null,
+    type         varchar(100) not null,
+    user_id      varchar(100) not null,
+    payload      text         not null,
+    attempts     int          not null default 0,
+    last_error   text         not null default '',
+    created_at   bigint       not null,
+    published_at bigint       null
+);
+
+create index outbox_unpublished_idx on outbox (id) where published_at is null;
+create index outbox_published_at_idx on outbox (published_at);
@@ -0,0 +1,4 @@
+drop index webhook_deliveries_next_attempt_at_idx;
+
+alter table webhook_deliveries drop column next_attempt_at;
+alter table webhooks drop column secret;
@@ -0,0 +1,4 @@
+alter table webhooks add column secret varchar(100) not null default '';
+alter table webhook_deliveries add column next_attempt_at bigint null;
+
+create index webhook_deliveries_next_attempt_at_idx on webhook_deliveries (next_attempt_at);
@@ -0,0 +1,11 @@
+alter table contacts add column email varchar(100) null;
+alter table contacts add column phone varchar(100) null;
+
+update contacts
+set email = (select substr(email, 1, 100) from contact_emails where contact_emails.contact_id = contacts.id and is_primary),
+    phone = (select substr(phone, 1, 100) from contact_phones where contact_phones.contact_id = contacts.id and is_primary);
+
+create index contacts_email_prefix_idx on contacts (user_id, lower(email)) where deleted_at is null;
+
+drop table contact_phones;
+drop table contact_emails;
@@ -0,0 +1,41 @@
+create table contact_emails
+(
+    contact_id varchar(100) not null,
+    position   int          not null,
+    type       varchar(20)  not null,
+    email      varchar(200) not null,
+    is_primary boolean      not null default false,
+    primary key (contact_id, position),
+    foreign key (contact_id) references contacts (id) on delete cascade
+);
+
+create table contact_phones
+(
+    contact_id varchar(100) not null,
+    position   int          not null,
+    type       varchar(20)  not null,
+    phone      varchar(100) not null,
+    is_primary boolean      not null default false,
+    primary key (contact_id, position),
+    foreign key (contact_id) references contacts (id) on delete cascade
+);
+
+create index contact_emails_email_prefix_idx on contact_emails (lower(email));
+
+-- the single email and phone of every contact become its primary ones
+insert into contact_emails (contact_id, position, type, email, is_primary)
+select id, 0, 'other', email, true
+from contacts
+where email is not null
+  and email <> '';
+
+insert into contact_phones (contact_id, position, type, phone, is_primary)
+select id, 0, 'other', phone, true
+from contacts
+where phone is not null
+  and phone <> '';
+
+drop index contacts_email_prefix_idx;
+
+alter table contacts drop column email;
+alter table contacts drop column phone;
@@ -0,0 +1 @@
+alter table contacts drop column avatar_id;
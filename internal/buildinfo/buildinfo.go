@@ -0,0 +1,43 @@
+// Package buildinfo holds the version of the running binary, stamped at build time:
+//
+//	go build -ldflags "-X go-rest-scaffold/internal/buildinfo.Version=v1.2.0 \
+//	  -X go-rest-scaffold/internal/buildinfo.Commit=$(git rev-parse HEAD) \
+//	  -X go-rest-scaffold/internal/buildinfo.BuildTime=$(date -u +%Y-%m-%dT%H:%M:%SZ)"
+//
+// A binary built without them falls back to the VCS information the Go toolchain
+// records, so `go build` in a checkout still reports its commit.
+package buildinfo
+
+import (
+	"runtime"
+	"runtime/debug"
+)
+
+var (
+	Version   = "dev"
+	Commit    = "unknown"
+	BuildTime = "unknown"
+)
+
+// GoVersion is the version of the Go toolchain the binary was built with.
+var GoVersion = runtime.Version()
+
+func init() {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return
+	}
+
+	for _, setting := range info.Settings {
+		switch setting.Key {
+		case "vcs.revision":
+			if Commit == "unknown" {
+				Commit = setting.Value
+			}
+		case "vcs.time":
+			if BuildTime == "unknown" {
+				BuildTime = setting.Value
+			}
+		}
+	}
+}
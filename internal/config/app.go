@@ -1,52 +1,337 @@
 package config
 
 import (
+	"context"
 	"go-rest-scaffold/internal/delivery/http"
 	"go-rest-scaffold/internal/delivery/http/middleware"
 	"go-rest-scaffold/internal/delivery/http/route"
+	"go-rest-scaffold/internal/event"
+	"go-rest-scaffold/internal/gateway/cdn"
+	"go-rest-scaffold/internal/gateway/idempotency"
+	"go-rest-scaffold/internal/gateway/lock"
+	"go-rest-scaffold/internal/gateway/ratelimit"
+	"go-rest-scaffold/internal/gateway/search"
+	"go-rest-scaffold/internal/model"
 	"go-rest-scaffold/internal/repository"
+	"go-rest-scaffold/internal/scheduler"
 	"go-rest-scaffold/internal/usecase"
+	"time"
 
 	"github.com/go-playground/validator/v10"
 	"github.com/gofiber/fiber/v2"
+	"github.com/redis/go-redis/v9"
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/viper"
+	"google.golang.org/grpc"
 	"gorm.io/gorm"
 )
 
 type BootstrapConfig struct {
 	DB       *gorm.DB
+	Redis    *redis.Client
 	App      *fiber.App
 	Log      *logrus.Logger
 	Validate *validator.Validate
 	Config   *viper.Viper
+	// Draining reports whether the server is shutting down, see Server.Draining.
+	Draining func() bool
+	// SkipJobs leaves the background jobs stopped, for commands such as seed.
+	SkipJobs bool
 }
 
-func Bootstrap(config *BootstrapConfig) {
+// Application exposes what the command line runs outside of HTTP.
+type Application struct {
+	SeedUseCase *usecase.SeedUseCase
+	// ContactSearchUseCase is nil unless search is enabled.
+	ContactSearchUseCase *usecase.ContactSearchUseCase
+	// GRPCServer serves the use cases over gRPC, nil unless grpc.port is set.
+	GRPCServer *grpc.Server
+}
+
+func Bootstrap(config *BootstrapConfig) *Application {
+	// setup event bus
+	eventBus := event.NewBus(config.Config.GetString("app.name"), config.Log)
+	sandboxEnabled := config.Config.GetBool("sandbox.enabled")
+	purger := NewPurger(config.Config, config.Log)
+	if sandboxEnabled {
+		// a public demo must never reach real integrations
+		purger = cdn.NoopPurger{}
+	}
+	purgeSubscriber := cdn.NewPurgeSubscriber(purger, config.Log)
+	eventBus.Subscribe(purgeSubscriber.Handle, purgeSubscriber.EventTypes()...)
+	readCache := NewReadCache(config.Config, config.Redis, config.Log)
+	if readCache != nil {
+		eventBus.Subscribe(readCache.Handle, readCache.EventTypes()...)
+	}
+	realtimeHub := NewRealtimeHub(config.Config, config.Redis, config.Log)
+	eventBus.Subscribe(realtimeHub.Handle)
+	var contactIndex search.Index
+	if !sandboxEnabled {
+		// sandbox resets bypass the events the index is kept up to date with
+		contactIndex = NewContactIndex(config.Config, config.Log)
+	}
+
 	// setup repositories
 	userRepository := repository.NewUserRepository(config.Log)
 	contactRepository := repository.NewContactRepository(config.Log)
 	addressRepository := repository.NewAddressRepository(config.Log)
+	tagRepository := repository.NewTagRepository(config.Log)
+	noteRepository := repository.NewNoteRepository(config.Log)
+	experimentAssignmentRepository := repository.NewExperimentAssignmentRepository(config.Log)
+	debugCaptureRepository := repository.NewDebugCaptureRepository(config.Log)
+	announcementRepository := repository.NewAnnouncementRepository(config.Log)
+	emailTemplateRepository := repository.NewEmailTemplateRepository(config.Log)
+	webhookRepository := repository.NewWebhookRepository(config.Log)
+	webhookDeliveryRepository := repository.NewWebhookDeliveryRepository(config.Log)
+	reminderRepository := repository.NewReminderRepository(config.Log)
+	trashRepository := repository.NewTrashRepository(config.Log)
+	statsRepository := repository.NewStatsRepository(config.Log)
+	userActivityRepository := repository.NewUserActivityRepository(config.Log)
+	contactChangeRepository := repository.NewContactChangeRepository(config.Log)
+	contactImportRepository := repository.NewContactImportRepository(config.Log)
+	passwordResetRepository := repository.NewPasswordResetRepository(config.Log)
+	magicLinkRepository := repository.NewMagicLinkRepository(config.Log)
+	passkeyRepository := repository.NewPasskeyRepository(config.Log)
+	webAuthnChallengeRepository := repository.NewWebAuthnChallengeRepository(config.Log)
+	sessionRepository := repository.NewSessionRepository(config.Log)
+	apiKeyRepository := repository.NewAPIKeyRepository(config.Log)
+	auditLogRepository := repository.NewAuditLogRepository(config.Log)
+	loginEventRepository := repository.NewLoginEventRepository(config.Log)
+	outboxRepository := repository.NewOutboxRepository(config.Log)
+	outboxEnabled := config.Config.GetBool("outbox.enabled")
+	if outboxEnabled {
+		// events are saved with the change that raised them and relayed to the broker
+		eventBus.Outbox = outboxRepository
+	}
 
 	// setup use cases
-	userUseCase := usecase.NewUserUseCase(config.DB, config.Log, config.Validate, userRepository)
-	contactUseCase := usecase.NewContactUseCase(config.DB, config.Log, config.Validate, contactRepository)
-	addressUseCase := usecase.NewAddressUseCase(config.DB, config.Log, config.Validate, contactRepository, addressRepository)
+	txManager := usecase.NewGormTxManager(config.DB)
+	idGenerators := NewIDGenerators(config.Config, config.DB, config.Log)
+	accessTokens := NewAccessTokenIssuer(config.Config, config.Log)
+	revocations := NewRevocationStore(config.Config, config.Redis, config.Log)
+	auditLogUseCase := usecase.NewAuditLogUseCase(txManager, config.Log, config.Validate, auditLogRepository)
+	contactAvatarUseCase := usecase.NewContactAvatarUseCase(txManager, config.Log, config.Validate, contactRepository, eventBus, auditLogUseCase,
+		NewStore(config.Config, config.Log), NewContactAvatarOptions(config.Config))
+	loginEventUseCase := usecase.NewLoginEventUseCase(txManager, config.Log, config.Validate, loginEventRepository)
+	emailTemplateUseCase := usecase.NewEmailTemplateUseCase(txManager, config.Log, config.Validate, NewMailCatalog(config.Config, config.Log), emailTemplateRepository)
+	userUseCase := usecase.NewUserUseCase(txManager, config.Log, config.Validate, userRepository, sessionRepository, passwordResetRepository, magicLinkRepository,
+		contactRepository, apiKeyRepository, emailTemplateUseCase,
+		NewMailSender(config.Config, config.Log), eventBus, auditLogUseCase, loginEventUseCase, NewRegions(config.Config), NewUserOptions(config.Config, accessTokens, revocations, readCache, contactAvatarUseCase, config.Log))
+	passkeyUseCase := usecase.NewPasskeyUseCase(txManager, config.Log, config.Validate, passkeyRepository, webAuthnChallengeRepository, userRepository,
+		userUseCase, auditLogUseCase, NewPasskeyOptions(config.Config))
+	requestSigner := NewRequestSigner(config.Config, config.Log)
+	apiKeyUseCase := usecase.NewAPIKeyUseCase(txManager, config.Log, config.Validate, apiKeyRepository, userRepository, auditLogUseCase, requestSigner, NewRegions(config.Config))
+	contactUseCase := usecase.NewContactUseCase(txManager, config.Log, config.Validate, contactRepository, eventBus, auditLogUseCase, idGenerators, NewContactOptions(config.Config, readCache, contactIndex, contactAvatarUseCase))
+	contactSyncUseCase := usecase.NewContactSyncUseCase(txManager, config.Log, config.Validate, contactRepository, contactChangeRepository,
+		NewContactSyncOptions(config.Config))
+	locker := lock.NewLocker(config.Redis, config.Log)
+	contactImportUseCase := usecase.NewContactImportUseCase(txManager, config.Log, config.Validate, contactImportRepository, contactUseCase,
+		locker, NewContactImportOptions(config.Config))
+	addressUseCase := usecase.NewAddressUseCase(txManager, config.Log, config.Validate, contactRepository, addressRepository, eventBus, auditLogUseCase, idGenerators)
+	tagUseCase := usecase.NewTagUseCase(txManager, config.Log, config.Validate, tagRepository, contactRepository, eventBus, auditLogUseCase, idGenerators)
+	noteUseCase := usecase.NewNoteUseCase(txManager, config.Log, config.Validate, contactRepository, noteRepository, eventBus, auditLogUseCase, idGenerators)
+	loggingUseCase := usecase.NewLoggingUseCase(config.Log, config.Validate, NewLogLevels(config.Config, config.Log), config.Config.ConfigFileUsed())
+	readOnlySwitch := NewReadOnlySwitch(config.Config, config.Redis, config.Log)
+	readOnlyUseCase := usecase.NewReadOnlyUseCase(config.Log, config.Validate, readOnlySwitch)
+	debugCaptureUseCase := usecase.NewDebugCaptureUseCase(txManager, config.Log, config.Validate, debugCaptureRepository, NewDebugCaptureOptions(config.Config))
+	sandboxUseCase := usecase.NewSandboxUseCase(txManager, config.Log, NewSandboxOptions(config.Config), userRepository,
+		contactRepository, addressRepository, experimentAssignmentRepository, debugCaptureRepository, idGenerators)
+	announcementUseCase := usecase.NewAnnouncementUseCase(txManager, config.Log, config.Validate, announcementRepository, auditLogUseCase, idGenerators)
+	webhookUseCase := usecase.NewWebhookUseCase(txManager, config.Log, config.Validate, webhookRepository, webhookDeliveryRepository,
+		NewWebhookSender(config.Config, config.Log), auditLogUseCase, idGenerators, NewWebhookOptions(config.Config))
+	reminderUseCase := usecase.NewReminderUseCase(txManager, config.Log, config.Validate, reminderRepository, contactRepository,
+		NewReminderNotifiers(eventBus, config.Log), auditLogUseCase, idGenerators, NewReminderOptions(config.Config))
+	accountUseCase := usecase.NewAccountUseCase(txManager, config.Log, config.Validate, userRepository, contactRepository, addressRepository,
+		reminderRepository, webhookRepository, eventBus, idGenerators)
+	trashUseCase := usecase.NewTrashUseCase(txManager, config.Log, config.Validate, trashRepository, contactRepository, addressRepository,
+		eventBus, NewTrashOptions(config.Config, contactAvatarUseCase))
+	statsUseCase := usecase.NewStatsUseCase(txManager, config.Log, config.Validate, statsRepository, userActivityRepository)
+	usageUseCase := usecase.NewUsageUseCase(txManager, config.Log, config.Validate, NewUsageMeter(config.Config, config.Redis, config.Log),
+		apiKeyRepository, NewUsageOptions(config.Config))
+	healthUseCase := usecase.NewHealthUseCase(config.Log, NewHealthOptions(config.Config, config.DB, config.Redis, config.Draining, config.Log))
+	experimentUseCase := usecase.NewExperimentUseCase(txManager, config.Log, NewExperiments(config.Config, config.Log), experimentAssignmentRepository)
+	seedUseCase := usecase.NewSeedUseCase(config.Log, userUseCase, contactUseCase, addressUseCase)
+	eventBus.Subscribe(contactSyncUseCase.Handle, contactSyncUseCase.EventTypes()...)
+	var contactSearchUseCase *usecase.ContactSearchUseCase
+	if contactIndex != nil {
+		contactSearchUseCase = usecase.NewContactSearchUseCase(txManager, config.Log, contactRepository, contactIndex,
+			NewContactSearchOptions(config.Config))
+		eventBus.Subscribe(contactSearchUseCase.Handle, contactSearchUseCase.EventTypes()...)
+	}
+	if !sandboxEnabled {
+		eventBus.Subscribe(webhookUseCase.Handle)
+	}
 
 	// setup controller
 	userController := http.NewUserController(userUseCase, config.Log)
 	contactController := http.NewContactController(contactUseCase, config.Log)
+	contactSyncController := http.NewContactSyncController(contactSyncUseCase, config.Log)
+	contactImportController := http.NewContactImportController(contactImportUseCase, config.Log)
+	contactAvatarController := http.NewContactAvatarController(contactAvatarUseCase, config.Log)
 	addressController := http.NewAddressController(addressUseCase, config.Log)
+	tagController := http.NewTagController(tagUseCase, config.Log)
+	noteController := http.NewNoteController(noteUseCase, config.Log)
+	loggingController := http.NewLoggingController(loggingUseCase, config.Log)
+	readOnlyController := http.NewReadOnlyController(readOnlyUseCase, config.Log)
+	debugCaptureController := http.NewDebugCaptureController(debugCaptureUseCase, config.Log)
+	announcementController := http.NewAnnouncementController(announcementUseCase, config.Log)
+	emailTemplateController := http.NewEmailTemplateController(emailTemplateUseCase, config.Log)
+	apiKeyController := http.NewAPIKeyController(apiKeyUseCase, config.Log)
+	passkeyController := http.NewPasskeyController(passkeyUseCase, config.Log)
+	webhookController := http.NewWebhookController(webhookUseCase, config.Log)
+	reminderController := http.NewReminderController(reminderUseCase, config.Log)
+	trashController := http.NewTrashController(trashUseCase, config.Log)
+	accountController := http.NewAccountController(accountUseCase, config.Log)
+	statsController := http.NewStatsController(statsUseCase, config.Log)
+	usageController := http.NewUsageController(usageUseCase, config.Log)
+	auditLogController := http.NewAuditLogController(auditLogUseCase, config.Log)
+	loginEventController := http.NewLoginEventController(loginEventUseCase, config.Log)
+	healthController := http.NewHealthController(healthUseCase, config.Log)
+	docsController := http.NewDocsController(config.Log)
+	pingInterval := time.Second * time.Duration(config.Config.GetInt("realtime.ping_interval"))
+	realtimeController := http.NewRealtimeController(realtimeHub, pingInterval, config.Log)
+	eventStreamController := http.NewEventStreamController(realtimeHub, pingInterval, config.Log)
+	discoveryController := http.NewDiscoveryController(config.App, config.Config, accessTokens, config.Log)
 
 	// setup middleware
-	authMiddleware := middleware.NewAuth(userUseCase)
+	authMiddleware := middleware.NewAPIKeyAuth(userUseCase, apiKeyUseCase)
+	adminMiddleware := middleware.NewAdmin()
+	odataMiddleware := middleware.NewOData(config.Config.GetBool("odata.enabled"))
+	cacheControl := middleware.NewCacheControl(config.Config)
+	deprecated := middleware.NewDeprecation(config.Config)
+	timeout := middleware.NewTimeout(config.Config)
+	rateLimit := middleware.NewRateLimit(ratelimit.NewLimiter(config.Redis, config.Log), config.Config, config.Log)
+	activityMiddleware := middleware.NewActivity(statsUseCase)
+	quotaMiddleware := middleware.NewQuota(usageUseCase)
+	experimentMiddleware := middleware.NewExperiments(experimentUseCase)
+	corsMiddleware := NewCORS(config.Config, config.Log)
+	requestIDMiddleware := middleware.NewRequestID()
+	apiVersionMiddleware := middleware.NewAPIVersion(config.Config, route.APIVersions)
+	queryTokenMiddleware := middleware.NewQueryToken()
+	accessLogMiddleware := middleware.NewAccessLog(NewAccessLogger(config.Config, config.Log), config.Config)
+	tracingMiddleware := middleware.NewTracing(config.Config.GetBool("tracing.enabled"))
+	faultInjectionMiddleware := middleware.NewFaultInjection(config.Config, config.Log)
+	readOnlyMiddleware := middleware.NewReadOnly(readOnlySwitch, config.Config.GetInt("read_only.retry_after"))
+	dryRunMiddleware := middleware.NewDryRun()
+	requireSignature := middleware.NewRequireSignature(requestSigner, NewReplayStore(requestSigner, config.Redis, config.Log))
+	openAPIDocument := NewOpenAPIDocument(config.Log)
+	openAPIValidationMiddleware := middleware.NewOpenAPIValidation(openAPIDocument, config.Config.GetBool("openapi.validate_requests"))
+	fieldPolicyMiddleware := middleware.NewFieldPolicy(openAPIDocument, NewFieldPolicy(config.Config, openAPIDocument, config.Log), config.Log)
+	fieldSelectionMiddleware := middleware.NewFieldSelection(openAPIDocument, config.Log)
+	sandboxMiddleware := middleware.NewSandbox(sandboxUseCase, sandboxEnabled)
+	debugCaptureMiddleware := middleware.NewDebugCapture(debugCaptureUseCase, config.Config)
+	idempotencyMiddleware := middleware.NewIdempotency(idempotency.NewStore(config.Redis, config.Log), config.Config)
+	requireIfMatch := middleware.NewRequireIfMatch(config.Config.GetBool("etag.require_if_match"))
 
 	routeConfig := route.RouteConfig{
-		App:               config.App,
-		UserController:    userController,
-		ContactController: contactController,
-		AddressController: addressController,
-		AuthMiddleware:    authMiddleware,
+		App:                         config.App,
+		UserController:              userController,
+		ContactController:           contactController,
+		ContactSyncController:       contactSyncController,
+		ContactImportController:     contactImportController,
+		ContactAvatarController:     contactAvatarController,
+		AddressController:           addressController,
+		TagController:               tagController,
+		NoteController:              noteController,
+		LoggingController:           loggingController,
+		ReadOnlyController:          readOnlyController,
+		DebugCaptureController:      debugCaptureController,
+		AnnouncementController:      announcementController,
+		EmailTemplateController:     emailTemplateController,
+		APIKeyController:            apiKeyController,
+		PasskeyController:           passkeyController,
+		WebhookController:           webhookController,
+		ReminderController:          reminderController,
+		TrashController:             trashController,
+		AccountController:           accountController,
+		StatsController:             statsController,
+		AuditLogController:          auditLogController,
+		LoginEventController:        loginEventController,
+		UsageController:             usageController,
+		HealthController:            healthController,
+		DocsController:              docsController,
+		DiscoveryController:         discoveryController,
+		RealtimeController:          realtimeController,
+		EventStreamController:       eventStreamController,
+		CORSMiddleware:              corsMiddleware,
+		RequestIDMiddleware:         requestIDMiddleware,
+		APIVersionMiddleware:        apiVersionMiddleware,
+		QueryTokenMiddleware:        queryTokenMiddleware,
+		AccessLogMiddleware:         accessLogMiddleware,
+		TracingMiddleware:           tracingMiddleware,
+		FaultInjectionMiddleware:    faultInjectionMiddleware,
+		ReadOnlyMiddleware:          readOnlyMiddleware,
+		DryRunMiddleware:            dryRunMiddleware,
+		OpenAPIValidationMiddleware: openAPIValidationMiddleware,
+		SandboxMiddleware:           sandboxMiddleware,
+		AuthMiddleware:              authMiddleware,
+		AdminMiddleware:             adminMiddleware,
+		ODataMiddleware:             odataMiddleware,
+		CacheControl:                cacheControl,
+		RateLimit:                   rateLimit,
+		Timeout:                     timeout,
+		RequireScope:                middleware.RequireScope,
+		MetricsToken:                middleware.NewMetricsToken(config.Config.GetString("metrics.token")),
+		RequireSignature:            requireSignature,
+		Deprecated:                  deprecated,
+		ActivityMiddleware:          activityMiddleware,
+		QuotaMiddleware:             quotaMiddleware,
+		ExperimentMiddleware:        experimentMiddleware,
+		DebugCaptureMiddleware:      debugCaptureMiddleware,
+		FieldPolicyMiddleware:       fieldPolicyMiddleware,
+		FieldSelectionMiddleware:    fieldSelectionMiddleware,
+		IdempotencyMiddleware:       idempotencyMiddleware,
+		RequireIfMatch:              requireIfMatch,
 	}
 	routeConfig.Setup()
+
+	application := &Application{
+		SeedUseCase:          seedUseCase,
+		ContactSearchUseCase: contactSearchUseCase,
+		GRPCServer:           NewGRPCServer(config.Config, config.Log, userUseCase, apiKeyUseCase, contactUseCase, addressUseCase, healthUseCase),
+	}
+	if config.SkipJobs {
+		return application
+	}
+
+	// every instance listens, since each holds its own WebSocket clients
+	go realtimeHub.Run(context.Background())
+
+	// setup singleton jobs
+	leaderElector := lock.NewLeaderElector(locker, config.DB, config.Log, 30*time.Second)
+	// user data is spread over the regions, so its jobs run once per region, "" being home
+	jobRegions := []string{""}
+	if regions := NewRegions(config.Config); len(regions) > 0 {
+		jobRegions = append(jobRegions, regions[1:]...)
+	}
+	jobScheduler := NewScheduler(config.Config, config.Log, map[string]scheduler.Task{
+		"trash-purge":       trashUseCase.PurgeExpired,
+		"token-cleanup":     userUseCase.PurgeExpiredTokens,
+		"stats-aggregation": statsUseCase.AggregateDaily,
+		"contact-import":    contactImportUseCase.RunImports,
+		"webhook-retry":     webhookUseCase.RetryDue,
+	})
+	var outboxUseCase *usecase.OutboxUseCase
+	if outboxEnabled {
+		outboxUseCase = usecase.NewOutboxUseCase(txManager, config.Log, outboxRepository, NewBroker(config.Config, config.Log),
+			NewOutboxOptions(config.Config))
+	}
+	for _, region := range jobRegions {
+		ctx := model.WithRegion(context.Background(), region)
+		suffix := ""
+		if region != "" {
+			suffix = "-" + region
+		}
+		go leaderElector.Run(ctx, "reminder-scheduler"+suffix, reminderUseCase.RunScheduler)
+		go leaderElector.Run(ctx, "scheduler"+suffix, jobScheduler.Run)
+		go leaderElector.Run(ctx, "contact-change-prune"+suffix, contactSyncUseCase.RunPruner)
+		go leaderElector.Run(ctx, "account-purge"+suffix, userUseCase.RunDeletionPurger)
+		if outboxUseCase != nil {
+			go leaderElector.Run(ctx, "outbox-relay"+suffix, outboxUseCase.RunRelay)
+		}
+	}
+	if sandboxEnabled {
+		go leaderElector.Run(context.Background(), "sandbox-reset", sandboxUseCase.RunResets)
+	}
+	return application
 }
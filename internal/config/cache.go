@@ -0,0 +1,31 @@
+package config
+
+import (
+	"go-rest-scaffold/internal/gateway/cache"
+	"go-rest-scaffold/internal/usecase"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
+)
+
+// NewReadCache caches hot reads in Redis for read_cache.user.ttl and
+// read_cache.contact.ttl seconds. Without Redis it returns nil and nothing is cached, since a cache per
+// instance would keep serving what another instance changed.
+func NewReadCache(viper *viper.Viper, client *redis.Client, log *logrus.Logger) *usecase.ReadCache {
+	options := usecase.CacheOptions{
+		UserTTL:    time.Duration(viper.GetInt("read_cache.user.ttl")) * time.Second,
+		ContactTTL: time.Duration(viper.GetInt("read_cache.contact.ttl")) * time.Second,
+	}
+	if options.UserTTL <= 0 && options.ContactTTL <= 0 {
+		return nil
+	}
+
+	if client == nil {
+		log.Info("Redis is not configured, reads are not cached")
+		return nil
+	}
+
+	return usecase.NewReadCache(cache.NewStore(client, log), log, options)
+}
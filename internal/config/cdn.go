@@ -0,0 +1,38 @@
+package config
+
+import (
+	"go-rest-scaffold/internal/gateway/cdn"
+	"net/http"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
+)
+
+// NewPurger selects the CDN purge API from cdn.provider ("fastly", "cloudflare" or empty for none).
+func NewPurger(viper *viper.Viper, log *logrus.Logger) cdn.Purger {
+	client := &http.Client{Timeout: 10 * time.Second}
+
+	switch provider := viper.GetString("cdn.provider"); provider {
+	case "":
+		return cdn.NoopPurger{}
+	case "fastly":
+		return &cdn.FastlyPurger{
+			Client:    client,
+			Endpoint:  viper.GetString("cdn.fastly.endpoint"),
+			ServiceID: viper.GetString("cdn.fastly.service_id"),
+			Token:     viper.GetString("cdn.fastly.token"),
+			Soft:      viper.GetBool("cdn.fastly.soft_purge"),
+		}
+	case "cloudflare":
+		return &cdn.CloudflarePurger{
+			Client:   client,
+			Endpoint: viper.GetString("cdn.cloudflare.endpoint"),
+			ZoneID:   viper.GetString("cdn.cloudflare.zone_id"),
+			Token:    viper.GetString("cdn.cloudflare.token"),
+		}
+	default:
+		log.Fatalf("unknown cdn provider %q", provider)
+		return nil
+	}
+}
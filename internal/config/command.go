@@ -0,0 +1,38 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
+)
+
+// RunCommand runs the command line of the binary when it is not serving:
+// migrate up, migrate down, migrate status, seed [file] or search reindex.
+func RunCommand(viper *viper.Viper, log *logrus.Logger, args []string, out io.Writer) error {
+	if len(args) > 0 && args[0] == "seed" && len(args) <= 2 {
+		file := viper.GetString("seed.file")
+		if len(args) == 2 {
+			file = args[1]
+		}
+		return Seed(viper, log, file, out)
+	}
+	if len(args) == 2 && args[0] == "search" && args[1] == "reindex" {
+		return Reindex(viper, log, out)
+	}
+	if len(args) != 2 || args[0] != "migrate" {
+		return errors.New("usage: migrate up|down|status, seed [file] or search reindex")
+	}
+
+	switch args[1] {
+	case "up":
+		return MigrateUp(viper, log)
+	case "down":
+		return MigrateDown(viper, log)
+	case "status":
+		return MigrationStatus(viper, log, out)
+	}
+	return fmt.Errorf("unknown migrate command %q, expected up, down or status", args[1])
+}
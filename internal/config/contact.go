@@ -0,0 +1,20 @@
+package config
+
+import (
+	"go-rest-scaffold/internal/gateway/search"
+	"go-rest-scaffold/internal/usecase"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+func NewContactOptions(viper *viper.Viper, readCache *usecase.ReadCache, searchIndex search.Index, avatars *usecase.ContactAvatarUseCase) usecase.ContactOptions {
+	return usecase.ContactOptions{
+		SuggestTimeout:    time.Duration(viper.GetInt("contact.suggest_timeout")) * time.Millisecond,
+		ReadCache:         readCache,
+		SearchIndex:       searchIndex,
+		BulkMaxOperations: viper.GetInt("contact.bulk_max_operations"),
+		ExportBatchSize:   viper.GetInt("contact.export_batch_size"),
+		Avatars:           avatars,
+	}
+}
@@ -0,0 +1,19 @@
+package config
+
+import (
+	"go-rest-scaffold/internal/usecase"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/spf13/viper"
+)
+
+func NewContactImportOptions(viper *viper.Viper) usecase.ContactImportOptions {
+	return usecase.ContactImportOptions{
+		AsyncRows: viper.GetInt("contact_import.async_rows"),
+		Retention: time.Duration(viper.GetInt("contact_import.retention_days")) * 24 * time.Hour,
+		FormatErrors: func(err validator.ValidationErrors) (string, map[string]string) {
+			return FormatValidationErrors(err), ValidationErrorFields(err)
+		},
+	}
+}
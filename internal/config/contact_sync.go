@@ -0,0 +1,18 @@
+package config
+
+import (
+	"go-rest-scaffold/internal/usecase"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+func NewContactSyncOptions(viper *viper.Viper) usecase.ContactSyncOptions {
+	return usecase.ContactSyncOptions{
+		BatchSize:         viper.GetInt("contact_sync.batch_size"),
+		PollInterval:      time.Duration(viper.GetInt("contact_sync.poll_interval")) * time.Millisecond,
+		HeartbeatInterval: time.Duration(viper.GetInt("contact_sync.heartbeat_interval")) * time.Second,
+		Retention:         time.Duration(viper.GetInt("contact_sync.retention_days")) * 24 * time.Hour,
+		PruneInterval:     time.Duration(viper.GetInt("contact_sync.prune_interval")) * time.Second,
+	}
+}
@@ -0,0 +1,45 @@
+package config
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/cors"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
+)
+
+// corsDevEnvironments are the app.environment values where any origin may call the
+// API when cors.allow_origins names none, so a local web client works as it is.
+var corsDevEnvironments = []string{"development", "test"}
+
+// NewCORS answers browsers calling the API from other origins as the cors config
+// allows. Elsewhere than in development and test, an empty cors.allow_origins allows
+// no other origin, so a deployment has to name the origins of its web clients.
+func NewCORS(viper *viper.Viper, log *logrus.Logger) fiber.Handler {
+	origins := viper.GetStringSlice("cors.allow_origins")
+	if len(origins) == 0 {
+		if !slices.Contains(corsDevEnvironments, viper.GetString("app.environment")) {
+			// without the headers browsers keep the responses from other origins
+			return func(ctx *fiber.Ctx) error {
+				return ctx.Next()
+			}
+		}
+		origins = []string{"*"}
+	}
+
+	credentials := viper.GetBool("cors.allow_credentials")
+	if credentials && slices.Contains(origins, "*") {
+		log.Fatalf("cors.allow_credentials needs the origins named in cors.allow_origins, not *")
+	}
+
+	return cors.New(cors.Config{
+		AllowOrigins:     strings.Join(origins, ","),
+		AllowMethods:     strings.Join(viper.GetStringSlice("cors.allow_methods"), ","),
+		AllowHeaders:     strings.Join(viper.GetStringSlice("cors.allow_headers"), ","),
+		ExposeHeaders:    strings.Join(viper.GetStringSlice("cors.expose_headers"), ","),
+		AllowCredentials: credentials,
+		MaxAge:           viper.GetInt("cors.max_age"),
+	})
+}
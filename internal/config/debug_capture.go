@@ -0,0 +1,18 @@
+package config
+
+import (
+	"go-rest-scaffold/internal/usecase"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+func NewDebugCaptureOptions(viper *viper.Viper) usecase.DebugCaptureOptions {
+	return usecase.DebugCaptureOptions{
+		TTL:             time.Duration(viper.GetInt("debug_capture.ttl")) * time.Second,
+		CleanupInterval: time.Duration(viper.GetInt("debug_capture.cleanup_interval")) * time.Second,
+		MaxBodySize:     viper.GetInt("debug_capture.max_body_size"),
+		RedactFields:    viper.GetStringSlice("debug_capture.redact_fields"),
+		RedactHeaders:   viper.GetStringSlice("debug_capture.redact_headers"),
+	}
+}
@@ -0,0 +1,24 @@
+package config
+
+import (
+	"go-rest-scaffold/internal/model"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
+)
+
+// NewExperiments reads the experiment definitions from the experiments config block.
+func NewExperiments(viper *viper.Viper, log *logrus.Logger) []model.Experiment {
+	var experiments []model.Experiment
+	if err := viper.UnmarshalKey("experiments", &experiments); err != nil {
+		log.Fatalf("invalid experiments config: %v", err)
+	}
+
+	for _, experiment := range experiments {
+		if experiment.Key == "" {
+			log.Fatalf("experiment without a key in experiments config")
+		}
+	}
+
+	return experiments
+}
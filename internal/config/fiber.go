@@ -1,15 +1,37 @@
 package config
 
 import (
+	"go-rest-scaffold/internal/delivery/http/middleware"
+	"time"
+
+	"github.com/go-playground/validator/v10"
 	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/utils"
+	"github.com/sirupsen/logrus"
 	"github.com/spf13/viper"
 )
 
-func NewFiber(config *viper.Viper) *fiber.App {
+// MIMEApplicationProblemJSON is the media type of problem details (RFC 9457).
+const MIMEApplicationProblemJSON = "application/problem+json"
+
+func NewFiber(config *viper.Viper, log *logrus.Logger) *fiber.App {
+	encoder := config.GetString("web.json_encoder")
+	jsonEncoder, jsonDecoder, err := NewJSONEncoder(encoder)
+	if err != nil {
+		log.Fatalf("unknown json encoder %q, expected standard, go-json or sonic", encoder)
+	}
+
 	var app = fiber.New(fiber.Config{
 		AppName:      config.GetString("app.name"),
 		ErrorHandler: NewErrorHandler(),
 		Prefork:      config.GetBool("web.prefork"),
+		Concurrency:  config.GetInt("web.concurrency"),
+		BodyLimit:    config.GetInt("web.body_limit"),
+		ReadTimeout:  time.Second * time.Duration(config.GetInt("web.read_timeout")),
+		WriteTimeout: time.Second * time.Duration(config.GetInt("web.write_timeout")),
+		IdleTimeout:  time.Second * time.Duration(config.GetInt("web.idle_timeout")),
+		JSONEncoder:  jsonEncoder,
+		JSONDecoder:  jsonDecoder,
 	})
 
 	return app
@@ -17,13 +39,31 @@ func NewFiber(config *viper.Viper) *fiber.App {
 
 func NewErrorHandler() fiber.ErrorHandler {
 	return func(ctx *fiber.Ctx, err error) error {
+		if e, ok := err.(validator.ValidationErrors); ok {
+			return ctx.Status(fiber.StatusBadRequest).JSON(middleware.WithRequestID(ctx, fiber.Map{
+				"errors": FormatValidationErrors(e),
+				"fields": ValidationErrorFields(e),
+			}))
+		}
+
 		code := fiber.StatusInternalServerError
 		if e, ok := err.(*fiber.Error); ok {
 			code = e.Code
 		}
 
-		return ctx.Status(code).JSON(fiber.Map{
+		// requests refused for their size or duration are answered as RFC 9457 problems
+		if code == fiber.StatusRequestTimeout || code == fiber.StatusRequestEntityTooLarge {
+			return ctx.Status(code).JSON(middleware.WithRequestID(ctx, fiber.Map{
+				"type":   "about:blank",
+				"title":  utils.StatusMessage(code),
+				"status": code,
+				"detail": err.Error(),
+				"errors": err.Error(),
+			}), MIMEApplicationProblemJSON)
+		}
+
+		return ctx.Status(code).JSON(middleware.WithRequestID(ctx, fiber.Map{
 			"errors": err.Error(),
-		})
+		}))
 	}
 }
@@ -0,0 +1,33 @@
+package config
+
+import (
+	"go-rest-scaffold/internal/delivery/http/middleware"
+	"go-rest-scaffold/internal/delivery/http/openapi"
+	"go-rest-scaffold/internal/model"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
+)
+
+// NewFieldPolicy reads the field_policy rules. Definitions missing from the document
+// are rejected, so a typo cannot silently leave a field visible.
+func NewFieldPolicy(viper *viper.Viper, document *openapi.Document, log *logrus.Logger) middleware.FieldPolicy {
+	var rules []model.FieldRule
+	if err := viper.UnmarshalKey("field_policy", &rules); err != nil {
+		log.Fatalf("invalid field_policy config: %v", err)
+	}
+
+	policy := make(middleware.FieldPolicy)
+	for _, rule := range rules {
+		if _, ok := document.Definitions[rule.Definition]; !ok {
+			log.Fatalf("unknown definition %q in field_policy", rule.Definition)
+		}
+
+		if policy[rule.Role] == nil {
+			policy[rule.Role] = make(map[string][]string)
+		}
+		policy[rule.Role][rule.Definition] = append(policy[rule.Role][rule.Definition], rule.Fields...)
+	}
+
+	return policy
+}
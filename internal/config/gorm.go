@@ -1,58 +1,283 @@
 package config
 
 import (
+	"context"
+	"database/sql"
 	"fmt"
+	"go-rest-scaffold/internal/logging"
+	"go-rest-scaffold/internal/metrics"
+	"go-rest-scaffold/internal/model"
+	"go-rest-scaffold/internal/region"
+	"go-rest-scaffold/internal/tracing"
+	"maps"
 	"os"
+	"slices"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/viper"
+	"gorm.io/driver/mysql"
 	"gorm.io/driver/postgres"
+	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
 	"gorm.io/gorm/logger"
+	"gorm.io/plugin/dbresolver"
 )
 
 func NewDatabase(viper *viper.Viper, log *logrus.Logger) *gorm.DB {
-	username := os.Getenv("DB_USER")
-	password := os.Getenv("DB_PASSWORD")
-	host := os.Getenv("DB_HOST")
-	port, err := strconv.Atoi(os.Getenv("DB_PORT"))
-	if err != nil {
-		log.Warnf("Failed to parse DB_PORT, defaulting to 5432: %v", err)
-		port = 5432
-	}
-	database := os.Getenv("DB_NAME")
 	idleConnection := viper.GetInt("database.pool.idle")
 	maxConnection := viper.GetInt("database.pool.max")
 	maxLifeTimeConnection := viper.GetInt("database.pool.lifetime")
 
-	dsn := fmt.Sprintf("host=%s user=%s password=%s dbname=%s port=%d sslmode=disable TimeZone=Asia/Jakarta", host, username, password, database, port)
+	gormConfig := &gorm.Config{
+		PrepareStmt:     viper.GetBool("database.prepare_stmt"),
+		CreateBatchSize: viper.GetInt("database.batch_size"),
+		// the repositories return the same errors whatever the database
+		TranslateError: true,
+		Logger: &slowQueryLogger{
+			Interface: logger.New(&logrusWriter{Logger: log}, logger.Config{
+				Colorful:                  false,
+				IgnoreRecordNotFoundError: true,
+				ParameterizedQueries:      true,
+				LogLevel:                  logger.Info,
+			}),
+			Log:       log,
+			Threshold: time.Duration(viper.GetInt("database.slow_threshold")) * time.Millisecond,
+		},
+	}
+
+	driver := databaseDriver(viper, log)
+	dialector := newDialector(driver, databaseDSN(driver, "DB", log), nil)
+	connections := make(map[string]*sql.DB)
+	var replicas []gorm.Dialector
+	var replicaConnections []*sql.DB
 
-	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{
-		Logger: logger.New(&logrusWriter{Logger: log}, logger.Config{
-			SlowThreshold:             time.Second * 5,
-			Colorful:                  false,
-			IgnoreRecordNotFoundError: true,
-			ParameterizedQueries:      true,
-			LogLevel:                  logger.Info,
-		}),
-	})
+	home := viper.GetString("region.home")
+	regions := viper.GetStringMapString("region.databases")
+	if home != "" && len(regions) > 0 {
+		connections[home] = openPool("region "+home, dialector, log)
+		for name, prefix := range regions {
+			connections[name] = openPool("region "+name, newDialector(driver, databaseDSN(driver, prefix, log), nil), log)
+		}
 
+		// prepared statements are cached by SQL alone and would be reused on another region's database
+		gormConfig.PrepareStmt = false
+		dialector = newDialector(driver, "", region.NewRouter(home, connections))
+
+		// a region without a replica reads from its primary
+		regionReplicas := viper.GetStringMapString("region.replicas")
+		if len(regionReplicas) > 0 {
+			pools := make(map[string]*sql.DB, len(connections))
+			for name, connection := range connections {
+				pools[name] = connection
+				if prefix, ok := regionReplicas[name]; ok {
+					pools[name] = openPool("replica of region "+name, newDialector(driver, databaseDSN(driver, prefix, log), nil), log)
+					replicaConnections = append(replicaConnections, pools[name])
+				}
+			}
+			replicas = append(replicas, newDialector(driver, "", region.NewRouter(home, pools)))
+		}
+	} else {
+		for _, prefix := range viper.GetStringSlice("database.replicas") {
+			connection := openPool("replica "+prefix, newDialector(driver, databaseDSN(driver, prefix, log), nil), log)
+			replicaConnections = append(replicaConnections, connection)
+			replicas = append(replicas, newDialector(driver, "", connection))
+		}
+	}
+
+	db, err := gorm.Open(dialector, gormConfig)
 	if err != nil {
 		log.Fatalf("failed to connect database: %v", err)
 	}
 
+	if err := registerActorCallbacks(db); err != nil {
+		log.Fatalf("failed to register gorm callbacks: %v", err)
+	}
+	if len(replicas) > 0 {
+		// reads outside of transactions and read-only transactions go to a replica,
+		// everything else to the primary
+		resolver := dbresolver.Register(dbresolver.Config{
+			Replicas: replicas,
+			Policy:   dbresolver.RandomPolicy{},
+		})
+		if err := db.Use(resolver); err != nil {
+			log.Fatalf("failed to register read replicas: %v", err)
+		}
+	}
+	if viper.GetBool("tracing.enabled") {
+		if err := db.Use(tracing.NewGormPlugin()); err != nil {
+			log.Fatalf("failed to register gorm tracing: %v", err)
+		}
+	}
+
+	if len(connections) == 0 {
+		connection, err := db.DB()
+		if err != nil {
+			log.Fatalf("failed to connect database: %v", err)
+		}
+		connections[home] = connection
+	}
+
+	for _, connection := range slices.AppendSeq(replicaConnections, maps.Values(connections)) {
+		connection.SetMaxIdleConns(idleConnection)
+		connection.SetMaxOpenConns(maxConnection)
+		connection.SetConnMaxLifetime(time.Second * time.Duration(maxLifeTimeConnection))
+	}
+
+	return db
+}
+
+// Drivers of database.driver, named after their gorm dialect.
+const (
+	driverPostgres = "postgres"
+	driverMySQL    = "mysql"
+	driverSQLite   = "sqlite"
+)
+
+// databaseDriver returns database.driver, postgres by default.
+func databaseDriver(viper *viper.Viper, log *logrus.Logger) string {
+	driver := viper.GetString("database.driver")
+	switch driver {
+	case driverPostgres, driverMySQL, driverSQLite:
+		return driver
+	}
+	log.Fatalf("unknown database driver %q, expected postgres, mysql or sqlite", driver)
+	return ""
+}
+
+// newDialector opens the database of driver at dsn or, when conn is set, over conn.
+func newDialector(driver string, dsn string, conn gorm.ConnPool) gorm.Dialector {
+	switch driver {
+	case driverMySQL:
+		return mysql.New(mysql.Config{DSN: dsn, Conn: conn})
+	case driverSQLite:
+		return &sqlite.Dialector{DSN: dsn, Conn: conn}
+	}
+	return postgres.New(postgres.Config{DSN: dsn, Conn: conn})
+}
+
+// databaseDSN builds the DSN of driver from the <prefix>_USER, _PASSWORD, _HOST, _PORT
+// and _NAME variables. SQLite only reads _NAME, the path of the database file.
+func databaseDSN(driver string, prefix string, log *logrus.Logger) string {
+	database := os.Getenv(prefix + "_NAME")
+	if driver == driverSQLite {
+		// SQLite leaves foreign keys unchecked unless asked, and fails writers at once
+		// when another one holds the lock
+		return fmt.Sprintf("file:%s?_foreign_keys=on&_busy_timeout=5000&_journal_mode=WAL", database)
+	}
+
+	defaultPort := 5432
+	if driver == driverMySQL {
+		defaultPort = 3306
+	}
+
+	username := os.Getenv(prefix + "_USER")
+	password := os.Getenv(prefix + "_PASSWORD")
+	host := os.Getenv(prefix + "_HOST")
+	port, err := strconv.Atoi(os.Getenv(prefix + "_PORT"))
+	if err != nil {
+		log.Warnf("Failed to parse %s_PORT, defaulting to %d: %v", prefix, defaultPort, err)
+		port = defaultPort
+	}
+
+	if driver == driverMySQL {
+		return fmt.Sprintf("%s:%s@tcp(%s:%d)/%s?charset=utf8mb4&parseTime=true&loc=Local", username, password, host, port, database)
+	}
+	return fmt.Sprintf("host=%s user=%s password=%s dbname=%s port=%d sslmode=disable TimeZone=Asia/Jakarta", host, username, password, database, port)
+}
+
+// openPool opens the connection pool of a regional database or read replica, named
+// in the errors by what it is.
+func openPool(name string, dialector gorm.Dialector, log *logrus.Logger) *sql.DB {
+	db, err := gorm.Open(dialector, &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		log.Fatalf("failed to connect database of %s: %v", name, err)
+	}
+
 	connection, err := db.DB()
 	if err != nil {
-		log.Fatalf("failed to connect database: %v", err)
+		log.Fatalf("failed to connect database of %s: %v", name, err)
 	}
+	return connection
+}
 
-	connection.SetMaxIdleConns(idleConnection)
-	connection.SetMaxOpenConns(maxConnection)
-	connection.SetConnMaxLifetime(time.Second * time.Duration(maxLifeTimeConnection))
+// registerActorCallbacks stamps the acting user of the statement context on every
+// entity with created_by and updated_by columns, so no use case has to set them.
+func registerActorCallbacks(db *gorm.DB) error {
+	if err := db.Callback().Create().Before("gorm:create").Register("actor:create", stampActor(true)); err != nil {
+		return err
+	}
+	return db.Callback().Update().Before("gorm:update").Register("actor:update", stampActor(false))
+}
 
-	return db
+func stampActor(create bool) func(db *gorm.DB) {
+	return func(db *gorm.DB) {
+		if db.Statement.Schema == nil {
+			return
+		}
+
+		actor := model.ActorFrom(db.Statement.Context)
+		if actor == "" {
+			return
+		}
+
+		if create && db.Statement.Schema.LookUpField("created_by") != nil {
+			db.Statement.SetColumn("created_by", actor, true)
+		}
+		if db.Statement.Schema.LookUpField("updated_by") != nil {
+			db.Statement.SetColumn("updated_by", actor, true)
+		}
+	}
+}
+
+// slowQueryLogger warns about every statement slower than Threshold, with the SQL
+// and its placeholders, and counts it in db_slow_queries_total, so N+1 queries and
+// missing indexes show up. A Threshold of 0 disables it. Values bound to statements
+// are never logged, by it or the logger it wraps, as they may be passwords or
+// personal data.
+type slowQueryLogger struct {
+	logger.Interface
+	Log       *logrus.Logger
+	Threshold time.Duration
+}
+
+func (l *slowQueryLogger) LogMode(level logger.LogLevel) logger.Interface {
+	return &slowQueryLogger{Interface: l.Interface.LogMode(level), Log: l.Log, Threshold: l.Threshold}
+}
+
+// ParamsFilter drops the values bound to the statement before it is logged.
+func (l *slowQueryLogger) ParamsFilter(ctx context.Context, sql string, params ...interface{}) (string, []interface{}) {
+	return sql, nil
+}
+
+func (l *slowQueryLogger) Trace(ctx context.Context, begin time.Time, fc func() (sql string, rowsAffected int64), err error) {
+	l.Interface.Trace(ctx, begin, fc, err)
+
+	elapsed := time.Since(begin)
+	if l.Threshold <= 0 || elapsed < l.Threshold {
+		return
+	}
+
+	sql, rows := fc()
+	metrics.SlowQueries.WithLabelValues(sqlOperation(sql)).Inc()
+	l.Log.WithContext(ctx).WithFields(logrus.Fields{
+		logging.ComponentField: "gorm",
+		"sql":                  sql,
+		"rows":                 rows,
+		"elapsed_ms":           elapsed.Milliseconds(),
+	}).Warn("Slow query")
+}
+
+// sqlOperation labels a statement by its first keyword, keeping the label set small.
+func sqlOperation(sql string) string {
+	keyword, _, _ := strings.Cut(strings.TrimSpace(sql), " ")
+	switch keyword = strings.ToLower(keyword); keyword {
+	case "select", "insert", "update", "delete":
+		return keyword
+	}
+	return "other"
 }
 
 type logrusWriter struct {
@@ -60,5 +285,5 @@ type logrusWriter struct {
 }
 
 func (l *logrusWriter) Printf(message string, args ...interface{}) {
-	l.Logger.Tracef(message, args...)
+	l.Logger.WithField(logging.ComponentField, "gorm").Tracef(message, args...)
 }
@@ -0,0 +1,40 @@
+package config
+
+import (
+	"context"
+	grpcdelivery "go-rest-scaffold/internal/delivery/grpc"
+	"go-rest-scaffold/internal/delivery/grpc/pb"
+	"go-rest-scaffold/internal/usecase"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
+	"google.golang.org/grpc"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/reflection"
+)
+
+// NewGRPCServer registers the user, contact and address services, the health service
+// updated every grpc.health_interval seconds from the readiness checks and server
+// reflection, or returns nil when grpc.port is not set.
+func NewGRPCServer(config *viper.Viper, log *logrus.Logger, userUseCase *usecase.UserUseCase, apiKeyUseCase *usecase.APIKeyUseCase,
+	contactUseCase *usecase.ContactUseCase, addressUseCase *usecase.AddressUseCase, healthUseCase *usecase.HealthUseCase) *grpc.Server {
+	if config.GetInt("grpc.port") <= 0 {
+		return nil
+	}
+
+	server := grpc.NewServer(grpc.ChainUnaryInterceptor(
+		grpcdelivery.NewErrorInterceptor(log),
+		grpcdelivery.NewAuthInterceptor(userUseCase, apiKeyUseCase),
+	))
+	pb.RegisterUserServiceServer(server, grpcdelivery.NewUserServer(userUseCase, log))
+	pb.RegisterContactServiceServer(server, grpcdelivery.NewContactServer(contactUseCase, log))
+	pb.RegisterAddressServiceServer(server, grpcdelivery.NewAddressServer(addressUseCase, log))
+
+	healthServer := grpcdelivery.NewHealthServer(healthUseCase, log)
+	healthpb.RegisterHealthServer(server, healthServer)
+	go healthServer.Run(context.Background(), time.Duration(config.GetInt("grpc.health_interval"))*time.Second)
+
+	reflection.Register(server)
+	return server
+}
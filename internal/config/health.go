@@ -0,0 +1,43 @@
+package config
+
+import (
+	"context"
+	"go-rest-scaffold/internal/region"
+	"go-rest-scaffold/internal/usecase"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
+	"gorm.io/gorm"
+)
+
+// NewHealthOptions checks the database, every regional database when the data is
+// split by region, and Redis when it is configured.
+func NewHealthOptions(viper *viper.Viper, db *gorm.DB, client *redis.Client, draining func() bool, log *logrus.Logger) usecase.HealthOptions {
+	checks := make(map[string]usecase.HealthCheck)
+
+	if router, ok := db.ConnPool.(*region.Router); ok {
+		for name, pool := range router.Pools() {
+			checks["database."+name] = pool.PingContext
+		}
+	} else {
+		connection, err := db.DB()
+		if err != nil {
+			log.Fatalf("failed to connect database: %v", err)
+		}
+		checks["database"] = connection.PingContext
+	}
+
+	if client != nil {
+		checks["redis"] = func(ctx context.Context) error {
+			return client.Ping(ctx).Err()
+		}
+	}
+
+	return usecase.HealthOptions{
+		Checks:   checks,
+		Timeout:  time.Duration(viper.GetInt("health.timeout")) * time.Millisecond,
+		Draining: draining,
+	}
+}
@@ -0,0 +1,36 @@
+package config
+
+import (
+	"go-rest-scaffold/internal/idgen"
+	"slices"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
+	"gorm.io/gorm"
+)
+
+// NewIDGenerators builds the generator of every entity from the id config block:
+// id.strategy is the default and id.entities.<entity> overrides it.
+func NewIDGenerators(viper *viper.Viper, db *gorm.DB, log *logrus.Logger) *idgen.Generators {
+	for entity := range viper.GetStringMapString("id.entities") {
+		if !slices.Contains(idgen.Entities, entity) {
+			log.Fatalf("unknown entity %q in id config", entity)
+		}
+	}
+
+	generators := make(map[string]idgen.IDGenerator, len(idgen.Entities))
+	for _, entity := range idgen.Entities {
+		strategy := viper.GetString("id.entities." + entity)
+		if strategy == "" {
+			strategy = viper.GetString("id.strategy")
+		}
+
+		generator, err := idgen.New(strategy, entity, db)
+		if err != nil {
+			log.Fatalf("invalid id config: %v", err)
+		}
+		generators[entity] = generator
+	}
+
+	return idgen.NewGenerators(generators)
+}
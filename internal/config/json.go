@@ -0,0 +1,31 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/bytedance/sonic"
+	gojson "github.com/goccy/go-json"
+	"github.com/gofiber/fiber/v2/utils"
+)
+
+const (
+	JSONEncoderStandard = "standard"
+	JSONEncoderGoJSON   = "go-json"
+	JSONEncoderSonic    = "sonic"
+)
+
+// NewJSONEncoder returns the marshal/unmarshal pair for the configured web.json_encoder.
+// Every option is configured to produce the same bytes as encoding/json.
+func NewJSONEncoder(name string) (utils.JSONMarshal, utils.JSONUnmarshal, error) {
+	switch name {
+	case "", JSONEncoderStandard:
+		return json.Marshal, json.Unmarshal, nil
+	case JSONEncoderGoJSON:
+		return gojson.Marshal, gojson.Unmarshal, nil
+	case JSONEncoderSonic:
+		return sonic.ConfigStd.Marshal, sonic.ConfigStd.Unmarshal, nil
+	default:
+		return nil, nil, fmt.Errorf("unknown json encoder %q", name)
+	}
+}
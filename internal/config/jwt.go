@@ -0,0 +1,119 @@
+package config
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"go-rest-scaffold/internal/jwt"
+	"os"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
+)
+
+// NewAccessTokenIssuer returns the issuer of JWT access tokens, or nil when jwt.enabled
+// is off and opaque tokens looked up in the database are issued instead.
+func NewAccessTokenIssuer(viper *viper.Viper, log *logrus.Logger) *jwt.Issuer {
+	if !viper.GetBool("jwt.enabled") {
+		return nil
+	}
+
+	issuer := viper.GetString("jwt.issuer")
+	ttl := time.Duration(viper.GetInt("jwt.ttl")) * time.Second
+
+	switch algorithm := viper.GetString("jwt.algorithm"); algorithm {
+	case jwt.HS256:
+		secret := viper.GetString("jwt.secret")
+		if len(secret) < 32 {
+			log.Fatalf("jwt.secret must be at least 32 bytes long for HS256")
+		}
+		return jwt.NewHS256(issuer, ttl, []byte(secret))
+	case jwt.RS256:
+		var trusted []*rsa.PublicKey
+		for _, path := range viper.GetStringSlice("jwt.trusted_public_key_files") {
+			publicKey, err := readPublicKey(path)
+			if err != nil {
+				log.Fatalf("Failed to read trusted public key %s: %v", path, err)
+			}
+			trusted = append(trusted, publicKey)
+		}
+
+		path := viper.GetString("jwt.private_key_file")
+		if path == "" {
+			// tokens then only verify on the instance that issued them and until it
+			// restarts, which is only good enough for development
+			log.Warn("JWT private key is not configured, using a random one")
+			key, err := rsa.GenerateKey(rand.Reader, 2048)
+			if err != nil {
+				log.Fatalf("Failed to generate JWT private key: %v", err)
+			}
+			return jwt.NewRS256(issuer, ttl, key, trusted...)
+		}
+
+		key, err := readPrivateKey(path)
+		if err != nil {
+			log.Fatalf("Failed to read JWT private key %s: %v", path, err)
+		}
+		return jwt.NewRS256(issuer, ttl, key, trusted...)
+	default:
+		log.Fatalf("Unknown jwt.algorithm %q, expected %q or %q", algorithm, jwt.RS256, jwt.HS256)
+		return nil
+	}
+}
+
+// readPrivateKey reads a PEM encoded RSA private key in PKCS #1 or PKCS #8 form.
+func readPrivateKey(path string) (*rsa.PrivateKey, error) {
+	block, err := readPEM(path)
+	if err != nil {
+		return nil, err
+	}
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	rsaKey, ok := key.(*rsa.PrivateKey)
+	if !ok {
+		return nil, errors.New("not an RSA private key")
+	}
+	return rsaKey, nil
+}
+
+// readPublicKey reads a PEM encoded RSA public key in PKIX or PKCS #1 form.
+func readPublicKey(path string) (*rsa.PublicKey, error) {
+	block, err := readPEM(path)
+	if err != nil {
+		return nil, err
+	}
+	if key, err := x509.ParsePKCS1PublicKey(block.Bytes); err == nil {
+		return key, nil
+	}
+
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	rsaKey, ok := key.(*rsa.PublicKey)
+	if !ok {
+		return nil, errors.New("not an RSA public key")
+	}
+	return rsaKey, nil
+}
+
+func readPEM(path string) (*pem.Block, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(raw)
+	if block == nil {
+		return nil, errors.New("no PEM data found")
+	}
+	return block, nil
+}
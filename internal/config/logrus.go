@@ -1,6 +1,10 @@
 package config
 
 import (
+	"go-rest-scaffold/internal/logging"
+	"os"
+
+	"github.com/fsnotify/fsnotify"
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/viper"
 )
@@ -10,6 +14,62 @@ func NewLogger(viper *viper.Viper) *logrus.Logger {
 
 	log.SetLevel(logrus.Level(viper.GetInt32("log.level")))
 	log.SetFormatter(&logrus.JSONFormatter{})
+	log.AddHook(logging.RequestIDHook{})
 
 	return log
 }
+
+// NewLogLevels puts the logger's level under runtime control, seeding it from
+// log.level and the per-component overrides in log.components. With log.hot_reload
+// the levels follow every change of the config file.
+func NewLogLevels(viper *viper.Viper, log *logrus.Logger) *logging.Levels {
+	level, components := configuredLogLevels(viper)
+	levels := logging.NewLevels(log, level, components)
+
+	if viper.GetBool("log.hot_reload") && viper.ConfigFileUsed() != "" {
+		viper.OnConfigChange(func(event fsnotify.Event) {
+			level, components := configuredLogLevels(viper)
+			levels.Set(level, components)
+			log.Warnf("Log level reloaded from %s as %s with components %v", event.Name, level, components)
+		})
+		viper.WatchConfig()
+	}
+
+	return levels
+}
+
+func configuredLogLevels(viper *viper.Viper) (logrus.Level, map[string]logrus.Level) {
+	components := make(map[string]logrus.Level)
+	for name := range viper.GetStringMap("log.components") {
+		components[name] = logrus.Level(viper.GetUint32("log.components." + name))
+	}
+	return logrus.Level(viper.GetInt32("log.level")), components
+}
+
+// NewAccessLogger returns the logger of the access log, which writes JSON lines to
+// access_log.output: stdout, stderr or the path of a file to append to. It is nil
+// unless access_log.enabled is set.
+func NewAccessLogger(viper *viper.Viper, log *logrus.Logger) *logrus.Logger {
+	if !viper.GetBool("access_log.enabled") {
+		return nil
+	}
+
+	accessLogger := logrus.New()
+	accessLogger.SetLevel(logrus.InfoLevel)
+	accessLogger.SetFormatter(&logrus.JSONFormatter{})
+
+	switch output := viper.GetString("access_log.output"); output {
+	case "", "stdout":
+		accessLogger.SetOutput(os.Stdout)
+	case "stderr":
+		accessLogger.SetOutput(os.Stderr)
+	default:
+		file, err := os.OpenFile(output, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+		if err != nil {
+			log.Fatalf("Failed to open access log %s: %v", output, err)
+		}
+		accessLogger.SetOutput(file)
+	}
+
+	return accessLogger
+}
@@ -0,0 +1,37 @@
+package config
+
+import (
+	"go-rest-scaffold/internal/gateway/mailer"
+	"go-rest-scaffold/internal/mail"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
+)
+
+func NewMailCatalog(viper *viper.Viper, log *logrus.Logger) *mail.Catalog {
+	catalog, err := mail.NewCatalog(viper.GetString("app.name"))
+	if err != nil {
+		log.Fatalf("Failed to load email templates: %v", err)
+	}
+	return catalog
+}
+
+// NewMailSender sends emails through mail.smtp.host, or only logs them when no SMTP
+// server is configured.
+func NewMailSender(viper *viper.Viper, log *logrus.Logger) mailer.Sender {
+	host := viper.GetString("mail.smtp.host")
+	if host == "" {
+		log.Warn("SMTP host is not configured, emails are logged instead of sent")
+		return mailer.LogSender{Log: log}
+	}
+
+	return &mailer.SMTPSender{
+		Host:     host,
+		Port:     viper.GetInt("mail.smtp.port"),
+		Username: viper.GetString("mail.smtp.username"),
+		Password: viper.GetString("mail.smtp.password"),
+		From:     viper.GetString("mail.from"),
+		Timeout:  time.Duration(viper.GetInt("mail.smtp.timeout")) * time.Second,
+	}
+}
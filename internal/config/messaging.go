@@ -0,0 +1,39 @@
+package config
+
+import (
+	"go-rest-scaffold/internal/gateway/messaging"
+	"go-rest-scaffold/internal/usecase"
+
+	"github.com/nats-io/nats.go"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
+)
+
+// NewBroker returns the broker the outbox relay publishes to, through the producer
+// selected by messaging.producer.
+func NewBroker(viper *viper.Viper, log *logrus.Logger) usecase.Broker {
+	return messaging.NewPublisher(NewProducer(viper, log), viper.GetStringMapString("messaging.topics"),
+		viper.GetString("messaging.default_topic"))
+}
+
+func NewProducer(viper *viper.Viper, log *logrus.Logger) messaging.Producer {
+	switch producer := viper.GetString("messaging.producer"); producer {
+	case "", "log":
+		return messaging.NewLogProducer(log)
+	case "kafka":
+		return messaging.NewKafkaProducer(viper.GetStringSlice("messaging.kafka.brokers"), viper.GetString("app.name"))
+	case "nats":
+		connection, err := nats.Connect(viper.GetString("messaging.nats.url"), nats.Name(viper.GetString("app.name")))
+		if err != nil {
+			log.Fatalf("failed to connect nats: %v", err)
+		}
+		natsProducer, err := messaging.NewNATSProducer(connection)
+		if err != nil {
+			log.Fatalf("failed to open nats jetstream: %v", err)
+		}
+		return natsProducer
+	default:
+		log.Fatalf("unknown messaging producer %q", producer)
+		return nil
+	}
+}
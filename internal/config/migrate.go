@@ -0,0 +1,200 @@
+package config
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"go-rest-scaffold/db/migrations"
+	"go-rest-scaffold/internal/logging"
+	"io"
+	"io/fs"
+	"sort"
+
+	"github.com/golang-migrate/migrate/v4"
+	"github.com/golang-migrate/migrate/v4/database"
+	migratemysql "github.com/golang-migrate/migrate/v4/database/mysql"
+	migratepgx "github.com/golang-migrate/migrate/v4/database/pgx/v5"
+	migratesqlite "github.com/golang-migrate/migrate/v4/database/sqlite3"
+	"github.com/golang-migrate/migrate/v4/source"
+	"github.com/golang-migrate/migrate/v4/source/iofs"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
+)
+
+// MigrateUp applies every pending migration to the database and, when the data is
+// split by region, to every regional database. Instances starting together wait
+// for each other on a database lock, so only one applies them.
+func MigrateUp(viper *viper.Viper, log *logrus.Logger) error {
+	return forEachMigrationDatabase(viper, log, func(name string, m *migrate.Migrate) error {
+		if err := m.Up(); err != nil && !errors.Is(err, migrate.ErrNoChange) {
+			return err
+		}
+		version, _, _ := m.Version()
+		log.WithField("database", name).Infof("Database schema at version %d", version)
+		return nil
+	})
+}
+
+// MigrateDown reverts the last migration applied to every database.
+func MigrateDown(viper *viper.Viper, log *logrus.Logger) error {
+	return forEachMigrationDatabase(viper, log, func(name string, m *migrate.Migrate) error {
+		if err := m.Steps(-1); err != nil {
+			return err
+		}
+		version, _, _ := m.Version()
+		log.WithField("database", name).Infof("Database schema reverted to version %d", version)
+		return nil
+	})
+}
+
+// MigrationStatus writes the schema version of every database, whether a migration
+// failed halfway (dirty) and the migrations still pending.
+func MigrationStatus(viper *viper.Viper, log *logrus.Logger, out io.Writer) error {
+	available, err := embeddedMigrations(databaseDriver(viper, log))
+	if err != nil {
+		return err
+	}
+
+	return forEachMigrationDatabase(viper, log, func(name string, m *migrate.Migrate) error {
+		version, dirty, err := m.Version()
+		if err != nil && !errors.Is(err, migrate.ErrNilVersion) {
+			return err
+		}
+
+		var pending []*source.Migration
+		for _, migration := range available {
+			if migration.Version > version {
+				pending = append(pending, migration)
+			}
+		}
+
+		status := ""
+		if dirty {
+			status = " (dirty: fix the schema, then force the version)"
+		}
+		fmt.Fprintf(out, "%s: version %d%s, %d pending\n", name, version, status, len(pending))
+		for _, migration := range pending {
+			fmt.Fprintf(out, "  %d_%s\n", migration.Version, migration.Identifier)
+		}
+		return nil
+	})
+}
+
+// embeddedMigrations lists the migrations of the binary for driver, oldest first.
+func embeddedMigrations(driver string) ([]*source.Migration, error) {
+	driverMigrations, err := migrations.For(driver)
+	if err != nil {
+		return nil, err
+	}
+	files, err := fs.Glob(driverMigrations, "*.up.sql")
+	if err != nil {
+		return nil, err
+	}
+
+	available := make([]*source.Migration, 0, len(files))
+	for _, file := range files {
+		migration, err := source.DefaultParse(file)
+		if err != nil {
+			return nil, fmt.Errorf("invalid migration %s: %w", file, err)
+		}
+		available = append(available, migration)
+	}
+	sort.Slice(available, func(i, j int) bool { return available[i].Version < available[j].Version })
+	return available, nil
+}
+
+// forEachMigrationDatabase runs apply on the home database, then on each regional
+// database in name order. Every database is migrated over a connection of its own,
+// closed afterwards, as golang-migrate closes the pool it is given.
+func forEachMigrationDatabase(viper *viper.Viper, log *logrus.Logger, apply func(name string, m *migrate.Migrate) error) error {
+	driver := databaseDriver(viper, log)
+	home := viper.GetString("region.home")
+	if home == "" {
+		home = "database"
+	}
+	databases := map[string]string{home: "DB"}
+	if viper.GetString("region.home") != "" {
+		for name, prefix := range viper.GetStringMapString("region.databases") {
+			databases[name] = prefix
+		}
+	}
+
+	names := make([]string, 0, len(databases))
+	for name := range databases {
+		if name != home {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	names = append([]string{home}, names...)
+
+	for _, name := range names {
+		m, err := newMigrate(driver, databaseDSN(driver, databases[name], log), log)
+		if err != nil {
+			return fmt.Errorf("failed to migrate database %s: %w", name, err)
+		}
+		err = apply(name, m)
+		sourceErr, databaseErr := m.Close()
+		if err = errors.Join(err, sourceErr, databaseErr); err != nil {
+			return fmt.Errorf("failed to migrate database %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+func newMigrate(driver string, dsn string, log *logrus.Logger) (*migrate.Migrate, error) {
+	driverMigrations, err := migrations.For(driver)
+	if err != nil {
+		return nil, err
+	}
+	migrationSource, err := iofs.New(driverMigrations, ".")
+	if err != nil {
+		return nil, err
+	}
+
+	// the database/sql drivers are registered by the gorm dialectors, which import them
+	sqlDriver, name := "pgx", "pgx5"
+	switch driver {
+	case driverMySQL:
+		// a migration file holds several statements, sent at once
+		sqlDriver, name, dsn = "mysql", "mysql", dsn+"&multiStatements=true"
+	case driverSQLite:
+		sqlDriver, name = "sqlite3", "sqlite3"
+	}
+
+	connection, err := sql.Open(sqlDriver, dsn)
+	if err != nil {
+		return nil, err
+	}
+
+	var instance database.Driver
+	switch driver {
+	case driverMySQL:
+		instance, err = migratemysql.WithInstance(connection, &migratemysql.Config{})
+	case driverSQLite:
+		instance, err = migratesqlite.WithInstance(connection, &migratesqlite.Config{})
+	default:
+		instance, err = migratepgx.WithInstance(connection, &migratepgx.Config{})
+	}
+	if err != nil {
+		connection.Close()
+		return nil, err
+	}
+
+	m, err := migrate.NewWithInstance("iofs", migrationSource, name, instance)
+	if err != nil {
+		instance.Close()
+		return nil, err
+	}
+	m.Log = migrateLogger{Entry: log.WithField(logging.ComponentField, "migrate")}
+	return m, nil
+}
+
+// migrateLogger writes the progress of golang-migrate to the application log.
+type migrateLogger struct {
+	*logrus.Entry
+}
+
+func (migrateLogger) Verbose() bool {
+	return false
+}
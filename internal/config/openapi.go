@@ -0,0 +1,17 @@
+package config
+
+import (
+	"go-rest-scaffold/docs"
+	"go-rest-scaffold/internal/delivery/http/openapi"
+
+	"github.com/sirupsen/logrus"
+)
+
+// NewOpenAPIDocument parses the generated Swagger document, the same one served under /swagger.
+func NewOpenAPIDocument(log *logrus.Logger) *openapi.Document {
+	document, err := openapi.Parse([]byte(docs.SwaggerInfo.ReadDoc()))
+	if err != nil {
+		log.Fatalf("Failed to load OpenAPI document: %v", err)
+	}
+	return document
+}
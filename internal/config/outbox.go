@@ -0,0 +1,16 @@
+package config
+
+import (
+	"go-rest-scaffold/internal/usecase"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+func NewOutboxOptions(viper *viper.Viper) usecase.OutboxOptions {
+	return usecase.OutboxOptions{
+		BatchSize:    viper.GetInt("outbox.batch_size"),
+		PollInterval: time.Duration(viper.GetInt("outbox.poll_interval")) * time.Millisecond,
+		Retention:    time.Duration(viper.GetInt("outbox.retention_days")) * 24 * time.Hour,
+	}
+}
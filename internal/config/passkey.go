@@ -0,0 +1,25 @@
+package config
+
+import (
+	"go-rest-scaffold/internal/usecase"
+	"go-rest-scaffold/internal/webauthn"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+func NewPasskeyOptions(viper *viper.Viper) usecase.PasskeyOptions {
+	name := viper.GetString("webauthn.rp_name")
+	if name == "" {
+		name = viper.GetString("app.name")
+	}
+
+	return usecase.PasskeyOptions{
+		RelyingParty: webauthn.RelyingParty{
+			ID:      viper.GetString("webauthn.rp_id"),
+			Name:    name,
+			Origins: viper.GetStringSlice("webauthn.origins"),
+		},
+		ChallengeTTL: time.Duration(viper.GetInt("webauthn.challenge_ttl")) * time.Second,
+	}
+}
@@ -0,0 +1,24 @@
+package config
+
+import (
+	"go-rest-scaffold/internal/gateway/readonly"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
+)
+
+// NewReadOnlySwitch starts the read-only switch. read_only.enabled keeps the instance
+// read-only from boot, which suits migrations run as part of a deploy; the admin
+// endpoint covers migrations started while the service is up.
+func NewReadOnlySwitch(viper *viper.Viper, client *redis.Client, log *logrus.Logger) *readonly.Switch {
+	configured := readonly.State{Enabled: viper.GetBool("read_only.enabled")}
+	if configured.Enabled {
+		configured.Reason = viper.GetString("read_only.reason")
+		configured.Since = time.Now()
+		log.Warn("Read-only mode is enabled by config, write requests will be refused")
+	}
+
+	return readonly.NewSwitch(client, configured, log)
+}
@@ -0,0 +1,24 @@
+package config
+
+import (
+	"go-rest-scaffold/internal/gateway/realtime"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
+)
+
+// NewRealtimeHub starts the hub of the WebSocket and Server-Sent Events clients, shared
+// between instances through Redis when it is configured.
+func NewRealtimeHub(viper *viper.Viper, client *redis.Client, log *logrus.Logger) *realtime.Hub {
+	if client == nil {
+		log.Info("Redis is not configured, real-time clients only receive the events of their instance")
+	}
+
+	return realtime.NewHub(client, realtime.Options{
+		BufferSize:  viper.GetInt("realtime.buffer_size"),
+		HistorySize: viper.GetInt("realtime.history_size"),
+		HistoryTTL:  time.Duration(viper.GetInt("realtime.history_ttl")) * time.Second,
+	}, log)
+}
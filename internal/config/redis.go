@@ -0,0 +1,36 @@
+package config
+
+import (
+	"context"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
+)
+
+// NewRedis connects to Redis when redis.address is configured and returns nil otherwise,
+// in which case Redis-backed components fall back to their single-instance implementations.
+func NewRedis(viper *viper.Viper, log *logrus.Logger) *redis.Client {
+	address := viper.GetString("redis.address")
+	if address == "" {
+		log.Info("Redis address is not configured, running without Redis")
+		return nil
+	}
+
+	client := redis.NewClient(&redis.Options{
+		Addr:     address,
+		Password: viper.GetString("redis.password"),
+		DB:       viper.GetInt("redis.db"),
+		PoolSize: viper.GetInt("redis.pool.max"),
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := client.Ping(ctx).Err(); err != nil {
+		log.Fatalf("failed to connect redis: %v", err)
+	}
+
+	return client
+}
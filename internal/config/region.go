@@ -0,0 +1,26 @@
+package config
+
+import (
+	"sort"
+
+	"github.com/spf13/viper"
+)
+
+// NewRegions lists the regions users can live in, the home region first, or nil
+// when the deployment has a single database.
+func NewRegions(viper *viper.Viper) []string {
+	home := viper.GetString("region.home")
+	databases := viper.GetStringMapString("region.databases")
+	if home == "" || len(databases) == 0 {
+		return nil
+	}
+
+	regions := make([]string, 0, len(databases))
+	for name := range databases {
+		if name != home {
+			regions = append(regions, name)
+		}
+	}
+	sort.Strings(regions)
+	return append([]string{home}, regions...)
+}
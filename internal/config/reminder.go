@@ -0,0 +1,29 @@
+package config
+
+import (
+	"go-rest-scaffold/internal/event"
+	"go-rest-scaffold/internal/gateway/notify"
+	"go-rest-scaffold/internal/model"
+	"go-rest-scaffold/internal/usecase"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
+)
+
+func NewReminderOptions(viper *viper.Viper) usecase.ReminderOptions {
+	return usecase.ReminderOptions{
+		PollInterval: time.Duration(viper.GetInt("reminder.poll_interval")) * time.Second,
+		BatchSize:    viper.GetInt("reminder.batch_size"),
+	}
+}
+
+// NewReminderNotifiers wires the reminder channels. In-app reminders are published
+// as events; email and push have no provider yet and are only logged.
+func NewReminderNotifiers(bus *event.Bus, log *logrus.Logger) map[string]notify.Notifier {
+	return map[string]notify.Notifier{
+		notify.ChannelInApp: notify.EventNotifier{Bus: bus, EventType: model.EventReminderDue},
+		notify.ChannelEmail: notify.LogNotifier{Log: log, Channel: notify.ChannelEmail},
+		notify.ChannelPush:  notify.LogNotifier{Log: log, Channel: notify.ChannelPush},
+	}
+}
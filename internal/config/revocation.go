@@ -0,0 +1,20 @@
+package config
+
+import (
+	"go-rest-scaffold/internal/gateway/revocation"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
+)
+
+// NewRevocationStore starts the list of sessions whose access tokens are refused. It is
+// only consulted for JWT access tokens, opaque tokens are checked against the database.
+func NewRevocationStore(viper *viper.Viper, client *redis.Client, log *logrus.Logger) *revocation.Store {
+	if client == nil && viper.GetBool("jwt.enabled") {
+		log.Warn("Redis is not configured, revoked access tokens are only refused by the instance that revoked them")
+	}
+
+	return revocation.NewStore(client, time.Duration(viper.GetInt("revocation.cache_ttl"))*time.Second, log)
+}
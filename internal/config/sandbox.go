@@ -0,0 +1,19 @@
+package config
+
+import (
+	"go-rest-scaffold/internal/usecase"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+func NewSandboxOptions(viper *viper.Viper) usecase.SandboxOptions {
+	return usecase.SandboxOptions{
+		UserId:        viper.GetString("sandbox.user.id"),
+		Password:      viper.GetString("sandbox.user.password"),
+		Name:          viper.GetString("sandbox.user.name"),
+		ResetInterval: time.Duration(viper.GetInt("sandbox.reset_interval")) * time.Second,
+		MaxContacts:   viper.GetInt("sandbox.max_contacts"),
+		MaxAddresses:  viper.GetInt("sandbox.max_addresses"),
+	}
+}
@@ -0,0 +1,38 @@
+package config
+
+import (
+	"go-rest-scaffold/internal/scheduler"
+	"slices"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
+)
+
+// NewScheduler schedules the tasks named in scheduler.jobs, each on its cron
+// expression. A job with an empty expression is turned off.
+func NewScheduler(viper *viper.Viper, log *logrus.Logger, tasks map[string]scheduler.Task) *scheduler.Scheduler {
+	jobScheduler := scheduler.New(log)
+
+	jobs := viper.GetStringMapString("scheduler.jobs")
+	names := make([]string, 0, len(jobs))
+	for name := range jobs {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	for _, name := range names {
+		if jobs[name] == "" {
+			continue
+		}
+
+		task, ok := tasks[name]
+		if !ok {
+			log.Fatalf("unknown scheduled job %q", name)
+		}
+		if err := jobScheduler.Add(name, jobs[name], task); err != nil {
+			log.Fatalf("invalid schedule %q of job %s: %v", jobs[name], name, err)
+		}
+	}
+
+	return jobScheduler
+}
@@ -0,0 +1,73 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go-rest-scaffold/internal/gateway/search"
+	"go-rest-scaffold/internal/model"
+	"go-rest-scaffold/internal/usecase"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
+)
+
+// NewContactIndex returns the Elasticsearch or OpenSearch index of contacts, created
+// when missing, or nil when search.enabled is off.
+func NewContactIndex(viper *viper.Viper, log *logrus.Logger) search.Index {
+	if !viper.GetBool("search.enabled") {
+		return nil
+	}
+
+	client := &http.Client{Timeout: time.Duration(viper.GetInt("search.timeout")) * time.Millisecond}
+	index := search.NewElasticsearch(client, viper.GetString("search.url"), viper.GetString("search.index"),
+		viper.GetString("search.username"), viper.GetString("search.password"))
+
+	// left to the index, the first document would create it without the mapping
+	if err := index.EnsureIndex(context.Background()); err != nil {
+		log.Fatalf("failed to create search index %s: %v", index.Name, err)
+	}
+	return index
+}
+
+func NewContactSearchOptions(viper *viper.Viper) usecase.ContactSearchOptions {
+	return usecase.ContactSearchOptions{
+		BatchSize: viper.GetInt("search.batch_size"),
+	}
+}
+
+// Reindex saves every contact of every region to the search index.
+func Reindex(viper *viper.Viper, log *logrus.Logger, out io.Writer) error {
+	application := Bootstrap(&BootstrapConfig{
+		DB:       NewDatabase(viper, log),
+		Redis:    NewRedis(viper, log),
+		App:      NewFiber(viper, log),
+		Log:      log,
+		Validate: NewValidator(viper),
+		Config:   viper,
+		SkipJobs: true,
+	})
+	if application.ContactSearchUseCase == nil {
+		return errors.New("search is not enabled, see search.enabled")
+	}
+
+	regions := []string{""}
+	if configured := NewRegions(viper); len(configured) > 0 {
+		regions = append(regions, configured[1:]...)
+	}
+	for _, region := range regions {
+		indexed, err := application.ContactSearchUseCase.Reindex(model.WithRegion(context.Background(), region))
+		if err != nil {
+			return err
+		}
+
+		if region == "" {
+			region = "home"
+		}
+		fmt.Fprintf(out, "indexed %d contacts of the %s region\n", indexed, region)
+	}
+	return nil
+}
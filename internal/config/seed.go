@@ -0,0 +1,85 @@
+package config
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"go-rest-scaffold/internal/model"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
+	"gopkg.in/yaml.v3"
+)
+
+// Seed loads the fixtures of file into the database through the use cases. It only
+// runs when app.environment is one of seed.environments, so fixtures with known
+// passwords cannot end up in production by a mistyped command.
+func Seed(viper *viper.Viper, log *logrus.Logger, file string, out io.Writer) error {
+	environment := viper.GetString("app.environment")
+	if !slices.Contains(viper.GetStringSlice("seed.environments"), environment) {
+		return fmt.Errorf("refusing to seed the %q environment, allowed in %v only (seed.environments)",
+			environment, viper.GetStringSlice("seed.environments"))
+	}
+
+	fixtures, err := readFixtures(file)
+	if err != nil {
+		return fmt.Errorf("failed to read fixtures %s: %w", file, err)
+	}
+
+	application := Bootstrap(&BootstrapConfig{
+		DB:       NewDatabase(viper, log),
+		Redis:    NewRedis(viper, log),
+		App:      NewFiber(viper, log),
+		Log:      log,
+		Validate: NewValidator(viper),
+		Config:   viper,
+		SkipJobs: true,
+	})
+
+	response, err := application.SeedUseCase.Seed(context.Background(), fixtures)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(out, "seeded %d users, %d contacts and %d addresses from %s, skipped %d existing users\n",
+		response.Users, response.Contacts, response.Addresses, file, response.SkippedUsers)
+	return nil
+}
+
+// readFixtures reads a .json, .yaml or .yml file. YAML is converted to JSON first,
+// so both formats use the json names of the request models. Unknown fields are
+// refused, as a misspelt field would silently seed an empty value.
+func readFixtures(file string) (*model.SeedFixtures, error) {
+	content, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	switch strings.ToLower(filepath.Ext(file)) {
+	case ".yaml", ".yml":
+		var document any
+		if err := yaml.Unmarshal(content, &document); err != nil {
+			return nil, err
+		}
+		if content, err = json.Marshal(document); err != nil {
+			return nil, err
+		}
+	case ".json":
+	default:
+		return nil, fmt.Errorf("unsupported fixtures format %s, expected .json, .yaml or .yml", filepath.Ext(file))
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(content))
+	decoder.DisallowUnknownFields()
+	fixtures := new(model.SeedFixtures)
+	if err := decoder.Decode(fixtures); err != nil {
+		return nil, err
+	}
+	return fixtures, nil
+}
@@ -0,0 +1,244 @@
+package config
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/cloudflare/tableflip"
+	"github.com/gofiber/fiber/v2"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
+	"google.golang.org/grpc"
+)
+
+// Server runs the Fiber app until SIGINT/SIGTERM and then drains in-flight requests.
+// When web.graceful_restart is enabled, SIGHUP starts a new copy of the binary that
+// inherits the listening socket; the old process stops accepting once the new one is
+// ready and exits after draining, so deploys don't drop requests. GRPC, when set, is
+// served on grpc.port alongside and drained with the app. With TLS set the app is
+// served over HTTPS, and Redirect, when set, on web.tls.redirect_port over HTTP.
+type Server struct {
+	App      *fiber.App
+	GRPC     *grpc.Server
+	Log      *logrus.Logger
+	Config   *viper.Viper
+	TLS      *tls.Config
+	Redirect http.Handler
+
+	draining atomic.Bool
+	redirect *http.Server
+}
+
+func NewServer(app *fiber.App, config *viper.Viper, log *logrus.Logger) *Server {
+	tlsConfig, redirect := NewTLS(config, log)
+	return &Server{
+		App:      app,
+		Log:      log,
+		Config:   config,
+		TLS:      tlsConfig,
+		Redirect: redirect,
+	}
+}
+
+// Draining reports whether the server stopped taking new work and is shutting down.
+func (s *Server) Draining() bool {
+	return s.draining.Load()
+}
+
+func (s *Server) Run() error {
+	address := fmt.Sprintf(":%d", s.Config.GetInt("web.port"))
+
+	if !s.Config.GetBool("web.graceful_restart") || s.Config.GetBool("web.prefork") {
+		return s.runPlain(address)
+	}
+
+	upgrader, err := tableflip.New(tableflip.Options{
+		PIDFile:        s.Config.GetString("web.pid_file"),
+		UpgradeTimeout: time.Second * time.Duration(s.Config.GetInt("web.shutdown_timeout")),
+	})
+	if errors.Is(err, tableflip.ErrNotSupported) {
+		s.Log.Warn("Graceful restart is not supported on this platform")
+		return s.runPlain(address)
+	}
+	if err != nil {
+		return err
+	}
+	defer upgrader.Stop()
+
+	// registered before listening, so no signal finds the default handler in place
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGHUP, syscall.SIGINT, syscall.SIGTERM)
+	go func() {
+		for sig := range signals {
+			if sig != syscall.SIGHUP {
+				upgrader.Stop()
+				return
+			}
+
+			s.Log.Info("Received SIGHUP, starting upgraded process")
+			if err := upgrader.Upgrade(); err != nil {
+				s.Log.WithError(err).Error("Failed to upgrade process")
+			}
+		}
+	}()
+
+	listener, err := upgrader.Listen("tcp", address)
+	if err != nil {
+		return err
+	}
+
+	serveErr := make(chan error, 3)
+	go func() {
+		serveErr <- s.App.Listener(s.withTLS(listener))
+	}()
+
+	if s.GRPC != nil {
+		grpcListener, err := upgrader.Listen("tcp", s.grpcAddress())
+		if err != nil {
+			return err
+		}
+		s.serveGRPC(grpcListener, serveErr)
+	}
+
+	if s.Redirect != nil {
+		redirectListener, err := upgrader.Listen("tcp", s.redirectAddress())
+		if err != nil {
+			return err
+		}
+		s.serveRedirect(redirectListener, serveErr)
+	}
+
+	if err := upgrader.Ready(); err != nil {
+		return err
+	}
+
+	select {
+	case err := <-serveErr:
+		return err
+	case <-upgrader.Exit():
+	}
+
+	return s.shutdown()
+}
+
+func (s *Server) runPlain(address string) error {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+
+	serveErr := make(chan error, 3)
+	go func() {
+		serveErr <- s.listen(address)
+	}()
+
+	// with prefork, the children share the HTTP port and the parent serves gRPC
+	if s.GRPC != nil && !fiber.IsChild() {
+		grpcListener, err := net.Listen("tcp", s.grpcAddress())
+		if err != nil {
+			return err
+		}
+		s.serveGRPC(grpcListener, serveErr)
+	}
+
+	if s.Redirect != nil && !fiber.IsChild() {
+		redirectListener, err := net.Listen("tcp", s.redirectAddress())
+		if err != nil {
+			return err
+		}
+		s.serveRedirect(redirectListener, serveErr)
+	}
+
+	select {
+	case err := <-serveErr:
+		return err
+	case <-signals:
+	}
+
+	return s.shutdown()
+}
+
+// listen serves the app on address, over TLS when it is configured.
+func (s *Server) listen(address string) error {
+	if s.TLS == nil {
+		return s.App.Listen(address)
+	}
+	if s.Config.GetBool("web.prefork") {
+		// Fiber only preforks listeners it opens itself, which take a certificate
+		return s.App.ListenTLSWithCertificate(address, s.TLS.Certificates[0])
+	}
+
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		return err
+	}
+	return s.App.Listener(s.withTLS(listener))
+}
+
+func (s *Server) withTLS(listener net.Listener) net.Listener {
+	if s.TLS == nil {
+		return listener
+	}
+	return tls.NewListener(listener, s.TLS)
+}
+
+func (s *Server) redirectAddress() string {
+	return fmt.Sprintf(":%d", s.Config.GetInt("web.tls.redirect_port"))
+}
+
+func (s *Server) serveRedirect(listener net.Listener, serveErr chan<- error) {
+	s.Log.Infof("Redirecting HTTP on port %d to HTTPS", s.Config.GetInt("web.tls.redirect_port"))
+	s.redirect = &http.Server{Handler: s.Redirect, ReadHeaderTimeout: 10 * time.Second}
+	go func() {
+		if err := s.redirect.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			serveErr <- err
+		}
+	}()
+}
+
+func (s *Server) grpcAddress() string {
+	return fmt.Sprintf(":%d", s.Config.GetInt("grpc.port"))
+}
+
+func (s *Server) serveGRPC(listener net.Listener, serveErr chan<- error) {
+	s.Log.Infof("Starting gRPC server on port %d", s.Config.GetInt("grpc.port"))
+	go func() {
+		if err := s.GRPC.Serve(listener); err != nil {
+			serveErr <- err
+		}
+	}()
+}
+
+func (s *Server) shutdown() error {
+	s.draining.Store(true)
+	if s.redirect != nil {
+		// redirects are answered at once, so there is nothing to drain
+		_ = s.redirect.Close()
+	}
+
+	timeout := time.Second * time.Duration(s.Config.GetInt("web.shutdown_timeout"))
+	s.Log.Infof("Shutting down, draining in-flight requests for up to %s", timeout)
+	if s.GRPC == nil {
+		return s.App.ShutdownWithTimeout(timeout)
+	}
+
+	deadline := time.After(timeout)
+	stopped := make(chan struct{})
+	go func() {
+		s.GRPC.GracefulStop()
+		close(stopped)
+	}()
+	err := s.App.ShutdownWithTimeout(timeout)
+	select {
+	case <-stopped:
+	case <-deadline:
+		s.GRPC.Stop()
+	}
+	return err
+}
@@ -0,0 +1,34 @@
+package config
+
+import (
+	"go-rest-scaffold/internal/gateway/replay"
+	"go-rest-scaffold/internal/signing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
+)
+
+// NewRequestSigner returns the verifier of request signatures, or nil when
+// request_signing.enabled is off and requests made with an API key need no signature.
+func NewRequestSigner(viper *viper.Viper, log *logrus.Logger) *signing.Signer {
+	if !viper.GetBool("request_signing.enabled") {
+		return nil
+	}
+
+	secret := viper.GetString("request_signing.secret")
+	if len(secret) < 32 {
+		log.Fatalf("request_signing.secret must be at least 32 bytes long")
+	}
+	return signing.NewSigner([]byte(secret), time.Duration(viper.GetInt("request_signing.tolerance"))*time.Second)
+}
+
+// NewReplayStore starts the record of signed requests already served.
+func NewReplayStore(signer *signing.Signer, client *redis.Client, log *logrus.Logger) *replay.Store {
+	if signer != nil && client == nil {
+		log.Warn("Redis is not configured, signed requests are only refused a second time by the instance that served them")
+	}
+
+	return replay.NewStore(client, log)
+}
@@ -0,0 +1,47 @@
+package config
+
+import (
+	"crypto/rand"
+	"go-rest-scaffold/internal/gateway/storage"
+	"go-rest-scaffold/internal/usecase"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
+)
+
+// NewStore returns the object storage of storage.driver: the local disk, S3 or MinIO.
+func NewStore(viper *viper.Viper, log *logrus.Logger) storage.Store {
+	switch driver := viper.GetString("storage.driver"); driver {
+	case "disk":
+		secret := []byte(viper.GetString("storage.disk.secret"))
+		// without a shared secret, URLs only open on the instance that signed them and
+		// until it restarts
+		if len(secret) == 0 {
+			log.Warn("Storage disk secret is not configured, using a random one")
+			secret = make([]byte, 32)
+			if _, err := rand.Read(secret); err != nil {
+				log.Fatalf("Failed to generate storage disk secret: %v", err)
+			}
+		}
+		return storage.NewDisk(viper.GetString("storage.disk.dir"), strings.TrimSuffix(viper.GetString("storage.disk.url"), "/"), secret)
+	case "s3", "minio":
+		client := &http.Client{Timeout: time.Duration(viper.GetInt("storage.timeout")) * time.Millisecond}
+		// MinIO only serves buckets in the path
+		pathStyle := driver == "minio" || viper.GetBool("storage.s3.path_style")
+		return storage.NewS3(client, viper.GetString("storage.s3.endpoint"), viper.GetString("storage.s3.region"),
+			viper.GetString("storage.s3.bucket"), viper.GetString("storage.s3.access_key"), viper.GetString("storage.s3.secret_key"), pathStyle)
+	default:
+		log.Fatalf("unknown storage driver %q, expected disk, s3 or minio", driver)
+		return nil
+	}
+}
+
+func NewContactAvatarOptions(viper *viper.Viper) usecase.ContactAvatarOptions {
+	return usecase.ContactAvatarOptions{
+		MaxBytes: viper.GetInt("contact.avatar_max_bytes"),
+		URLTTL:   time.Duration(viper.GetInt("storage.url_ttl")) * time.Second,
+	}
+}
@@ -0,0 +1,75 @@
+package config
+
+import (
+	"crypto/tls"
+	"net"
+	"net/http"
+	"strconv"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
+	"golang.org/x/crypto/acme/autocert"
+)
+
+// NewTLS returns the TLS config the server listens with, nil when web.tls sets no
+// certificate: the one in web.tls.cert_file and web.tls.key_file, or certificates
+// Let's Encrypt issues for web.tls.autocert.domains, renewed before they expire. The
+// handler serves web.tls.redirect_port, nil when it is 0: it answers the ACME
+// challenges of autocert and sends every other request to HTTPS.
+func NewTLS(viper *viper.Viper, log *logrus.Logger) (*tls.Config, http.Handler) {
+	certFile, keyFile := viper.GetString("web.tls.cert_file"), viper.GetString("web.tls.key_file")
+	domains := viper.GetStringSlice("web.tls.autocert.domains")
+
+	var config *tls.Config
+	var challenges func(fallback http.Handler) http.Handler
+	switch {
+	case certFile != "" && len(domains) > 0:
+		log.Fatalf("web.tls.cert_file and web.tls.autocert.domains cannot both be set")
+	case certFile != "":
+		certificate, err := tls.LoadX509KeyPair(certFile, keyFile)
+		if err != nil {
+			log.Fatalf("Failed to load TLS certificate %s: %v", certFile, err)
+		}
+		config = &tls.Config{Certificates: []tls.Certificate{certificate}}
+	case len(domains) > 0:
+		// Fiber only preforks listeners it opens itself, which cannot take a manager
+		if viper.GetBool("web.prefork") {
+			log.Fatalf("web.tls.autocert does not work with web.prefork")
+		}
+		manager := &autocert.Manager{
+			Prompt:     autocert.AcceptTOS,
+			HostPolicy: autocert.HostWhitelist(domains...),
+			Cache:      autocert.DirCache(viper.GetString("web.tls.autocert.cache_dir")),
+			Email:      viper.GetString("web.tls.autocert.email"),
+		}
+		config = manager.TLSConfig()
+		challenges = manager.HTTPHandler
+	default:
+		return nil, nil
+	}
+	config.MinVersion = tls.VersionTLS12
+
+	if viper.GetInt("web.tls.redirect_port") <= 0 {
+		return config, nil
+	}
+	redirect := redirectToHTTPS(viper.GetInt("web.port"))
+	if challenges != nil {
+		return config, challenges(redirect)
+	}
+	return config, redirect
+}
+
+// redirectToHTTPS sends requests to the same URL over HTTPS on port, keeping their
+// method and body with 308.
+func redirectToHTTPS(port int) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host := r.Host
+		if name, _, err := net.SplitHostPort(host); err == nil {
+			host = name
+		}
+		if port != 443 {
+			host = net.JoinHostPort(host, strconv.Itoa(port))
+		}
+		http.Redirect(w, r, "https://"+host+r.URL.RequestURI(), http.StatusPermanentRedirect)
+	})
+}
@@ -0,0 +1,88 @@
+package config
+
+import (
+	"context"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// NewTracing installs the global tracer provider, which sends spans in batches to the
+// OTLP collector at tracing.otlp.endpoint over tracing.otlp.protocol (grpc or http).
+// Without an endpoint the OTEL_EXPORTER_OTLP_* variables apply. The returned function
+// flushes the spans not sent yet and must be called before exiting. When
+// tracing.enabled is off nothing is exported and the function does nothing.
+func NewTracing(viper *viper.Viper, log *logrus.Logger) func() {
+	// trace context is passed on even when not tracing, so the traces of callers stay whole
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	if !viper.GetBool("tracing.enabled") {
+		return func() {}
+	}
+
+	exporter, err := newSpanExporter(viper)
+	if err != nil {
+		log.Fatalf("Failed to create trace exporter: %v", err)
+	}
+
+	serviceName := viper.GetString("tracing.service_name")
+	if serviceName == "" {
+		serviceName = viper.GetString("app.name")
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(resource.NewSchemaless(attribute.String("service.name", serviceName))),
+		// a caller that sampled a trace gets all of its spans
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(viper.GetFloat64("tracing.sample_ratio")))),
+	)
+	otel.SetTracerProvider(provider)
+	otel.SetErrorHandler(otel.ErrorHandlerFunc(func(err error) {
+		log.WithError(err).Warn("Failed to export spans")
+	}))
+
+	return func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		if err := provider.Shutdown(ctx); err != nil {
+			log.WithError(err).Warn("Failed to flush spans")
+		}
+	}
+}
+
+func newSpanExporter(viper *viper.Viper) (*otlptrace.Exporter, error) {
+	endpoint := viper.GetString("tracing.otlp.endpoint")
+	insecure := viper.GetBool("tracing.otlp.insecure")
+	headers := viper.GetStringMapString("tracing.otlp.headers")
+	timeout := time.Duration(viper.GetInt("tracing.otlp.timeout")) * time.Second
+
+	if viper.GetString("tracing.otlp.protocol") == "http" {
+		options := []otlptracehttp.Option{otlptracehttp.WithHeaders(headers), otlptracehttp.WithTimeout(timeout)}
+		if endpoint != "" {
+			options = append(options, otlptracehttp.WithEndpoint(endpoint))
+		}
+		if insecure {
+			options = append(options, otlptracehttp.WithInsecure())
+		}
+		return otlptracehttp.New(context.Background(), options...)
+	}
+
+	options := []otlptracegrpc.Option{otlptracegrpc.WithHeaders(headers), otlptracegrpc.WithTimeout(timeout)}
+	if endpoint != "" {
+		options = append(options, otlptracegrpc.WithEndpoint(endpoint))
+	}
+	if insecure {
+		options = append(options, otlptracegrpc.WithInsecure())
+	}
+	return otlptracegrpc.New(context.Background(), options...)
+}
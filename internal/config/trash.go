@@ -0,0 +1,15 @@
+package config
+
+import (
+	"go-rest-scaffold/internal/usecase"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+func NewTrashOptions(viper *viper.Viper, avatars *usecase.ContactAvatarUseCase) usecase.TrashOptions {
+	return usecase.TrashOptions{
+		Retention: time.Duration(viper.GetInt("trash.retention_days")) * 24 * time.Hour,
+		Avatars:   avatars,
+	}
+}
@@ -0,0 +1,28 @@
+package config
+
+import (
+	"go-rest-scaffold/internal/gateway/usage"
+	"go-rest-scaffold/internal/usecase"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
+)
+
+// NewUsageMeter starts the daily request counters. Without Redis every instance
+// counts on its own, so a client spread over instances gets a quota per instance.
+func NewUsageMeter(viper *viper.Viper, client *redis.Client, log *logrus.Logger) *usage.Meter {
+	if client == nil && viper.GetBool("quota.enabled") {
+		log.Warn("Redis is not configured, daily quotas are counted per instance")
+	}
+
+	return usage.NewMeter(client, log)
+}
+
+func NewUsageOptions(viper *viper.Viper) usecase.UsageOptions {
+	return usecase.UsageOptions{
+		Enabled:     viper.GetBool("quota.enabled"),
+		UserDaily:   viper.GetInt64("quota.user_daily"),
+		APIKeyDaily: viper.GetInt64("quota.api_key_daily"),
+	}
+}
@@ -0,0 +1,113 @@
+package config
+
+import (
+	"crypto/rand"
+	"go-rest-scaffold/internal/gateway/revocation"
+	"go-rest-scaffold/internal/jwt"
+	"go-rest-scaffold/internal/password"
+	"go-rest-scaffold/internal/usecase"
+	"io"
+	"os"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
+)
+
+func NewUserOptions(viper *viper.Viper, accessTokens *jwt.Issuer, revocations *revocation.Store, readCache *usecase.ReadCache,
+	avatars *usecase.ContactAvatarUseCase, log *logrus.Logger) usecase.UserOptions {
+	options := usecase.UserOptions{
+		PasswordResetURL:      viper.GetString("password_reset.url"),
+		PasswordResetTTL:      time.Duration(viper.GetInt("password_reset.ttl")) * time.Second,
+		MagicLinkURL:          viper.GetString("magic_link.url"),
+		MagicLinkTTL:          time.Duration(viper.GetInt("magic_link.ttl")) * time.Second,
+		VerificationURL:       viper.GetString("email_verification.url"),
+		VerificationTTL:       time.Duration(viper.GetInt("email_verification.ttl")) * time.Second,
+		VerificationRequired:  viper.GetBool("email_verification.required"),
+		VerificationSecret:    []byte(viper.GetString("email_verification.secret")),
+		PasswordPolicy:        NewPasswordPolicy(viper, log),
+		PasswordHasher:        NewPasswordHasher(viper, log),
+		AccessTokens:          accessTokens,
+		Revocations:           revocations,
+		DeletionGracePeriod:   time.Duration(viper.GetInt("account_deletion.grace_days")) * 24 * time.Hour,
+		DeletionPurgeInterval: time.Duration(viper.GetInt("account_deletion.purge_interval")) * time.Second,
+		ImpersonationTTL:      time.Duration(viper.GetInt("impersonation.ttl")) * time.Second,
+		ReadCache:             readCache,
+		Avatars:               avatars,
+	}
+
+	// without a shared secret, tokens only verify on the instance that sent them and
+	// until it restarts, which is fine while verification is optional
+	if len(options.VerificationSecret) == 0 {
+		if options.VerificationRequired {
+			log.Fatalf("email_verification.secret is required when email verification is required")
+		}
+		log.Warn("Email verification secret is not configured, using a random one")
+		options.VerificationSecret = make([]byte, 32)
+		if _, err := rand.Read(options.VerificationSecret); err != nil {
+			log.Fatalf("Failed to generate email verification secret: %v", err)
+		}
+	}
+
+	return options
+}
+
+func NewPasswordPolicy(viper *viper.Viper, log *logrus.Logger) password.Policy {
+	policy := password.Policy{
+		MinLength:     viper.GetInt("password.policy.min_length"),
+		RequireUpper:  viper.GetBool("password.policy.require_upper"),
+		RequireLower:  viper.GetBool("password.policy.require_lower"),
+		RequireDigit:  viper.GetBool("password.policy.require_digit"),
+		RequireSymbol: viper.GetBool("password.policy.require_symbol"),
+	}
+
+	if viper.GetBool("password.policy.reject_common") {
+		var extra io.Reader
+		if path := viper.GetString("password.policy.common_passwords_file"); path != "" {
+			file, err := os.Open(path)
+			if err != nil {
+				log.Fatalf("Failed to open common passwords file: %v", err)
+			}
+			defer file.Close()
+			extra = file
+		}
+
+		common, err := password.CommonPasswords(extra)
+		if err != nil {
+			log.Fatalf("Failed to read common passwords: %v", err)
+		}
+		policy.Common = common
+	}
+
+	return policy
+}
+
+func NewPasswordHasher(viper *viper.Viper, log *logrus.Logger) password.Hasher {
+	hasher := password.Hasher{
+		Algorithm:  viper.GetString("password.hash.algorithm"),
+		BcryptCost: viper.GetInt("password.hash.bcrypt_cost"),
+		Argon2: password.Argon2Params{
+			Memory:      viper.GetUint32("password.hash.argon2.memory"),
+			Iterations:  viper.GetUint32("password.hash.argon2.iterations"),
+			Parallelism: uint8(viper.GetUint("password.hash.argon2.parallelism")),
+			SaltLength:  viper.GetUint32("password.hash.argon2.salt_length"),
+			KeyLength:   viper.GetUint32("password.hash.argon2.key_length"),
+		},
+	}
+
+	switch hasher.Algorithm {
+	case password.Bcrypt:
+		if hasher.BcryptCost < 4 || hasher.BcryptCost > 31 {
+			log.Fatalf("password.hash.bcrypt_cost must be between 4 and 31, got %d", hasher.BcryptCost)
+		}
+	case password.Argon2id:
+		params := hasher.Argon2
+		if params.Memory == 0 || params.Iterations == 0 || params.Parallelism == 0 || params.SaltLength < 8 || params.KeyLength < 16 {
+			log.Fatalf("password.hash.argon2 needs a memory, iterations and parallelism, a salt_length of at least 8 and a key_length of at least 16")
+		}
+	default:
+		log.Fatalf("Unknown password.hash.algorithm %q, expected %q or %q", hasher.Algorithm, password.Bcrypt, password.Argon2id)
+	}
+
+	return hasher
+}
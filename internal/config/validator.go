@@ -2,6 +2,8 @@ package config
 
 import (
 	"fmt"
+	"go-rest-scaffold/internal/idgen"
+	"go-rest-scaffold/internal/model"
 	"strings"
 
 	"github.com/go-playground/validator/v10"
@@ -9,7 +11,31 @@ import (
 )
 
 func NewValidator(viper *viper.Viper) *validator.Validate {
-	return validator.New()
+	validate := validator.New()
+
+	// page_size and page_number guard search requests against absurd pagination,
+	// the limits come from the pagination config block
+	maxSize := viper.GetInt("pagination.max_size")
+	validate.RegisterValidation("page_size", func(fl validator.FieldLevel) bool {
+		return fl.Field().Int() <= int64(maxSize)
+	})
+
+	maxPage := viper.GetInt("pagination.max_page")
+	validate.RegisterValidation("page_number", func(fl validator.FieldLevel) bool {
+		return fl.Field().Int() <= int64(maxPage)
+	})
+
+	// entity_id accepts the IDs of every id strategy, which entities can switch between
+	validate.RegisterValidation("entity_id", func(fl validator.FieldLevel) bool {
+		return idgen.Valid(fl.Field().String())
+	})
+
+	// merge patch fields are checked as the value they set, see model.MergePatchValue
+	validate.RegisterCustomTypeFunc(model.MergePatchValue[string], model.MergePatchField[string]{})
+	validate.RegisterCustomTypeFunc(model.MergePatchValue[[]model.ContactEmail], model.MergePatchField[[]model.ContactEmail]{})
+	validate.RegisterCustomTypeFunc(model.MergePatchValue[[]model.ContactPhone], model.MergePatchField[[]model.ContactPhone]{})
+
+	return validate
 }
 
 // FormatValidationErrors formats validator errors into readable messages
@@ -29,6 +55,15 @@ func FormatValidationErrors(err error) string {
 	return err.Error()
 }
 
+// ValidationErrorFields maps each invalid field to its message
+func ValidationErrorFields(err validator.ValidationErrors) map[string]string {
+	fields := make(map[string]string, len(err))
+	for _, e := range err {
+		fields[toSnakeCase(e.Field())] = formatValidationError(e)
+	}
+	return fields
+}
+
 func formatValidationError(e validator.FieldError) string {
 	field := toSnakeCase(e.Field())
 
@@ -41,10 +76,20 @@ func formatValidationError(e validator.FieldError) string {
 		return fmt.Sprintf("%s must be at most %s characters", field, e.Param())
 	case "oneof":
 		return fmt.Sprintf("%s must be one of: %s", field, e.Param())
-	case "url":
+	case "url", "http_url":
 		return fmt.Sprintf("%s must be a valid URL", field)
+	case "timezone":
+		return fmt.Sprintf("%s must be an IANA time zone such as Asia/Jakarta", field)
+	case "datetime":
+		return fmt.Sprintf("%s must match the format %s", field, e.Param())
 	case "email":
 		return fmt.Sprintf("%s must be a valid email", field)
+	case "page_size":
+		return fmt.Sprintf("%s exceeds the maximum page size", field)
+	case "page_number":
+		return fmt.Sprintf("%s exceeds the maximum page number", field)
+	case "entity_id":
+		return fmt.Sprintf("%s must be a valid id", field)
 	default:
 		return fmt.Sprintf("%s is invalid", field)
 	}
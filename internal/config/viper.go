@@ -3,6 +3,7 @@ package config
 import (
 	"fmt"
 
+	"github.com/gofiber/fiber/v2"
 	"github.com/joho/godotenv"
 	"github.com/spf13/viper"
 )
@@ -35,9 +36,122 @@ func NewViper() *viper.Viper {
 	// Bind specific env vars to config keys
 	config.BindEnv("web.port", "APP_PORT")
 	config.BindEnv("app.name", "APP_NAME")
+	config.BindEnv("grpc.port", "GRPC_PORT")
+	config.BindEnv("app.environment", "APP_ENV")
+	config.BindEnv("database.driver", "DB_DRIVER")
+	config.BindEnv("redis.address", "REDIS_ADDRESS")
+	config.BindEnv("redis.password", "REDIS_PASSWORD")
+	config.BindEnv("cdn.fastly.token", "FASTLY_API_TOKEN")
+	config.BindEnv("cdn.cloudflare.token", "CLOUDFLARE_API_TOKEN")
+	config.BindEnv("read_only.enabled", "READ_ONLY")
+	config.BindEnv("mail.smtp.username", "SMTP_USERNAME")
+	config.BindEnv("mail.smtp.password", "SMTP_PASSWORD")
+	config.BindEnv("email_verification.secret", "EMAIL_VERIFICATION_SECRET")
+	config.BindEnv("jwt.secret", "JWT_SECRET")
+	config.BindEnv("jwt.private_key_file", "JWT_PRIVATE_KEY_FILE")
+	config.BindEnv("search.username", "SEARCH_USERNAME")
+	config.BindEnv("search.password", "SEARCH_PASSWORD")
+	config.BindEnv("storage.disk.secret", "STORAGE_DISK_SECRET")
+	config.BindEnv("storage.s3.access_key", "STORAGE_ACCESS_KEY")
+	config.BindEnv("storage.s3.secret_key", "STORAGE_SECRET_KEY")
+	config.BindEnv("metrics.token", "METRICS_TOKEN")
 
 	// Set defaults (fallback jika env tidak ada dan config.json tidak ada)
 	config.SetDefault("web.port", 3000)
+	config.SetDefault("web.concurrency", fiber.DefaultConcurrency)
+	config.SetDefault("web.body_limit", fiber.DefaultBodyLimit)
+	config.SetDefault("web.shutdown_timeout", 30)
+	config.SetDefault("web.tls.autocert.domains", []string{})
+	config.SetDefault("web.tls.autocert.cache_dir", "autocert")
+	config.SetDefault("web.tls.redirect_port", 0)
+	config.SetDefault("grpc.health_interval", 5)
+	config.SetDefault("realtime.buffer_size", 64)
+	config.SetDefault("realtime.ping_interval", 30)
+	config.SetDefault("realtime.history_size", 100)
+	config.SetDefault("realtime.history_ttl", 300)
+	config.SetDefault("app.version", "1.0.0")
+	config.SetDefault("app.environment", "production")
+	config.SetDefault("api.default_version", "v1")
+	config.SetDefault("idempotency.ttl", 86400)
+	config.SetDefault("idempotency.lock_timeout", 60)
+	config.SetDefault("etag.require_if_match", false)
+	config.SetDefault("timeout.default", 30)
+	config.SetDefault("timeout.bulk", 300)
+	config.SetDefault("cors.allow_origins", []string{})
+	config.SetDefault("cors.allow_methods", []string{"GET", "POST", "PUT", "PATCH", "DELETE", "HEAD"})
+	config.SetDefault("cors.allow_headers", []string{"Origin", "Accept", "Content-Type", "Authorization", "X-API-Key", "X-Signature", "API-Version", "Idempotency-Key", "If-Match", "If-None-Match", "X-Dry-Run", "X-Request-ID", "Last-Event-ID"})
+	config.SetDefault("cors.expose_headers", []string{"ETag", "Location", "Content-Disposition", "API-Version", "Deprecation", "Sunset", "Retry-After", "RateLimit-Limit", "RateLimit-Remaining", "RateLimit-Reset", "X-RateLimit-Limit", "X-RateLimit-Remaining", "X-RateLimit-Reset", "X-Request-ID", "Idempotent-Replayed", "X-Dry-Run", "X-Experiments"})
+	config.SetDefault("cors.allow_credentials", false)
+	config.SetDefault("cors.max_age", 600)
+	config.SetDefault("database.driver", "postgres")
+	config.SetDefault("database.batch_size", 100)
+	config.SetDefault("database.slow_threshold", 200)
+	config.SetDefault("database.auto_migrate", false)
+	config.SetDefault("database.replicas", []string{})
+	config.SetDefault("seed.file", "db/seeds/development.yaml")
+	config.SetDefault("seed.environments", []string{"development", "test"})
+	config.SetDefault("pagination.max_size", 100)
+	config.SetDefault("pagination.max_page", 10000)
+	config.SetDefault("security.expires_in_days", 365)
+	config.SetDefault("debug_capture.ttl", 86400)
+	config.SetDefault("debug_capture.cleanup_interval", 300)
+	config.SetDefault("sandbox.reset_interval", 3600)
+	config.SetDefault("reminder.poll_interval", 30)
+	config.SetDefault("reminder.batch_size", 100)
+	config.SetDefault("trash.retention_days", 30)
+	config.SetDefault("scheduler.jobs", map[string]string{
+		"trash-purge":       "@hourly",
+		"token-cleanup":     "*/15 * * * *",
+		"stats-aggregation": "10 0 * * *",
+		"contact-import":    "@every 10s",
+		"webhook-retry":     "@every 30s",
+	})
+	config.SetDefault("account_deletion.grace_days", 30)
+	config.SetDefault("account_deletion.purge_interval", 3600)
+	config.SetDefault("impersonation.ttl", 900)
+	config.SetDefault("contact.suggest_timeout", 200)
+	config.SetDefault("contact.bulk_max_operations", 100)
+	config.SetDefault("contact.export_batch_size", 500)
+	config.SetDefault("contact.avatar_max_bytes", 5<<20)
+	config.SetDefault("storage.driver", "disk")
+	config.SetDefault("storage.disk.dir", "storage")
+	config.SetDefault("storage.disk.url", "http://localhost:3000/api/storage")
+	config.SetDefault("storage.s3.region", "us-east-1")
+	config.SetDefault("storage.timeout", 10000)
+	config.SetDefault("storage.url_ttl", 3600)
+	config.SetDefault("contact_import.async_rows", 1000)
+	config.SetDefault("contact_import.retention_days", 7)
+	config.SetDefault("contact_sync.batch_size", 100)
+	config.SetDefault("contact_sync.poll_interval", 1000)
+	config.SetDefault("contact_sync.heartbeat_interval", 15)
+	config.SetDefault("contact_sync.retention_days", 7)
+	config.SetDefault("contact_sync.prune_interval", 3600)
+	config.SetDefault("read_only.retry_after", 60)
+	config.SetDefault("mail.smtp.port", 587)
+	config.SetDefault("mail.smtp.timeout", 10)
+	config.SetDefault("password.hash.algorithm", "bcrypt")
+	config.SetDefault("password.hash.bcrypt_cost", 10)
+	config.SetDefault("password.hash.argon2.memory", 65536)
+	config.SetDefault("password.hash.argon2.iterations", 3)
+	config.SetDefault("password.hash.argon2.parallelism", 2)
+	config.SetDefault("password.hash.argon2.salt_length", 16)
+	config.SetDefault("password.hash.argon2.key_length", 32)
+	config.SetDefault("password_reset.ttl", 3600)
+	config.SetDefault("magic_link.ttl", 900)
+	config.SetDefault("webauthn.rp_id", "localhost")
+	config.SetDefault("webauthn.challenge_ttl", 300)
+	config.SetDefault("email_verification.ttl", 86400)
+	config.SetDefault("jwt.algorithm", "RS256")
+	config.SetDefault("jwt.issuer", "go-rest-scaffold")
+	config.SetDefault("jwt.ttl", 900)
+	config.SetDefault("revocation.cache_ttl", 5)
+	config.SetDefault("request_signing.tolerance", 300)
+	config.SetDefault("access_log.output", "stdout")
+	config.SetDefault("access_log.sample_rate", 1.0)
+	config.SetDefault("health.timeout", 800)
+	config.SetDefault("tracing.sample_ratio", 1.0)
+	config.SetDefault("tracing.otlp.protocol", "grpc")
+	config.SetDefault("tracing.otlp.timeout", 10)
 
 	return config
 }
@@ -0,0 +1,45 @@
+package config
+
+import (
+	"go-rest-scaffold/internal/gateway/webhook"
+	"go-rest-scaffold/internal/usecase"
+	"net/netip"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
+)
+
+// NewWebhookSender returns the sender of webhook deliveries. webhook.allowed_networks
+// lists the addresses or CIDR ranges of private networks deliveries may reach anyway,
+// such as receivers inside the cluster.
+func NewWebhookSender(viper *viper.Viper, log *logrus.Logger) *webhook.Sender {
+	var allowed []netip.Prefix
+	for _, network := range viper.GetStringSlice("webhook.allowed_networks") {
+		prefix, err := netip.ParsePrefix(network)
+		if err != nil {
+			addr, addrErr := netip.ParseAddr(network)
+			if addrErr != nil {
+				log.Fatalf("invalid webhook.allowed_networks entry %q, expected an address or CIDR range", network)
+			}
+			prefix = netip.PrefixFrom(addr.Unmap(), addr.Unmap().BitLen())
+		}
+		allowed = append(allowed, prefix.Masked())
+	}
+
+	return webhook.NewSender(
+		time.Duration(viper.GetInt("webhook.timeout"))*time.Second,
+		viper.GetInt("webhook.response_snippet_size"),
+		viper.GetString("app.name")+"-webhook/"+viper.GetString("app.version"),
+		allowed,
+	)
+}
+
+func NewWebhookOptions(viper *viper.Viper) usecase.WebhookOptions {
+	return usecase.WebhookOptions{
+		ReplayLimit:     viper.GetInt("webhook.replay_limit"),
+		MaxAttempts:     viper.GetInt("webhook.max_attempts"),
+		RetryBackoff:    time.Duration(viper.GetInt("webhook.retry_backoff")) * time.Second,
+		MaxRetryBackoff: time.Duration(viper.GetInt("webhook.max_retry_backoff")) * time.Second,
+	}
+}
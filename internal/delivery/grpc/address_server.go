@@ -0,0 +1,105 @@
+package grpc
+
+import (
+	"context"
+	"go-rest-scaffold/internal/delivery/grpc/pb"
+	"go-rest-scaffold/internal/model"
+	"go-rest-scaffold/internal/usecase"
+
+	"github.com/sirupsen/logrus"
+)
+
+type AddressServer struct {
+	pb.UnimplementedAddressServiceServer
+	UseCase *usecase.AddressUseCase
+	Log     *logrus.Logger
+}
+
+func NewAddressServer(useCase *usecase.AddressUseCase, log *logrus.Logger) *AddressServer {
+	return &AddressServer{
+		Log:     log,
+		UseCase: useCase,
+	}
+}
+
+func (s *AddressServer) Create(ctx context.Context, in *pb.CreateAddressRequest) (*pb.Address, error) {
+	response, err := s.UseCase.Create(ctx, &model.CreateAddressRequest{
+		UserId:     GetUser(ctx).ID,
+		ContactId:  in.ContactId,
+		Street:     in.Street,
+		City:       in.City,
+		Province:   in.Province,
+		PostalCode: in.PostalCode,
+		Country:    in.Country,
+	})
+	if err != nil {
+		s.Log.WithContext(ctx).WithError(err).Error("failed to create address")
+		return nil, err
+	}
+
+	return addressToPb(response), nil
+}
+
+func (s *AddressServer) Get(ctx context.Context, in *pb.GetAddressRequest) (*pb.Address, error) {
+	response, err := s.UseCase.Get(ctx, &model.GetAddressRequest{
+		UserId:    GetUser(ctx).ID,
+		ContactId: in.ContactId,
+		ID:        in.Id,
+	})
+	if err != nil {
+		s.Log.WithContext(ctx).WithError(err).Error("failed to get address")
+		return nil, err
+	}
+
+	return addressToPb(response), nil
+}
+
+func (s *AddressServer) Update(ctx context.Context, in *pb.UpdateAddressRequest) (*pb.Address, error) {
+	response, err := s.UseCase.Update(ctx, &model.UpdateAddressRequest{
+		UserId:     GetUser(ctx).ID,
+		ContactId:  in.ContactId,
+		ID:         in.Id,
+		Street:     in.Street,
+		City:       in.City,
+		Province:   in.Province,
+		PostalCode: in.PostalCode,
+		Country:    in.Country,
+	})
+	if err != nil {
+		s.Log.WithContext(ctx).WithError(err).Error("failed to update address")
+		return nil, err
+	}
+
+	return addressToPb(response), nil
+}
+
+func (s *AddressServer) Delete(ctx context.Context, in *pb.DeleteAddressRequest) (*pb.DeleteAddressResponse, error) {
+	err := s.UseCase.Delete(ctx, &model.DeleteAddressRequest{
+		UserId:    GetUser(ctx).ID,
+		ContactId: in.ContactId,
+		ID:        in.Id,
+	})
+	if err != nil {
+		s.Log.WithContext(ctx).WithError(err).Error("failed to delete address")
+		return nil, err
+	}
+
+	return &pb.DeleteAddressResponse{}, nil
+}
+
+func (s *AddressServer) List(ctx context.Context, in *pb.ListAddressRequest) (*pb.ListAddressResponse, error) {
+	responses, err := s.UseCase.List(ctx, &model.ListAddressRequest{
+		UserId:    GetUser(ctx).ID,
+		ContactId: in.ContactId,
+	})
+	if err != nil {
+		s.Log.WithContext(ctx).WithError(err).Error("failed to list addresses")
+		return nil, err
+	}
+
+	addresses := make([]*pb.Address, len(responses))
+	for i := range responses {
+		addresses[i] = addressToPb(&responses[i])
+	}
+	return &pb.ListAddressResponse{Addresses: addresses}, nil
+}
@@ -0,0 +1,114 @@
+// Package grpc serves the user, contact and address use cases over gRPC, for internal
+// services that would rather not speak HTTP. Requests authenticate and are scoped as
+// on the HTTP API.
+package grpc
+
+import (
+	"context"
+	"go-rest-scaffold/internal/delivery/grpc/pb"
+	"go-rest-scaffold/internal/model"
+	"go-rest-scaffold/internal/usecase"
+	"net"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// MetadataAPIKey carries the API key of machine clients, in place of authorization.
+const MetadataAPIKey = "x-api-key"
+
+// scopes lists the scope each method requires, as the HTTP routes do.
+var scopes = map[string]string{
+	pb.UserService_Current_FullMethodName:   model.ScopeAccountRead,
+	pb.UserService_Update_FullMethodName:    model.ScopeAccountWrite,
+	pb.ContactService_Create_FullMethodName: model.ScopeContactsWrite,
+	pb.ContactService_Get_FullMethodName:    model.ScopeContactsRead,
+	pb.ContactService_Update_FullMethodName: model.ScopeContactsWrite,
+	pb.ContactService_Delete_FullMethodName: model.ScopeContactsWrite,
+	pb.ContactService_Search_FullMethodName: model.ScopeContactsRead,
+	pb.AddressService_Create_FullMethodName: model.ScopeAddressesWrite,
+	pb.AddressService_Get_FullMethodName:    model.ScopeAddressesRead,
+	pb.AddressService_Update_FullMethodName: model.ScopeAddressesWrite,
+	pb.AddressService_Delete_FullMethodName: model.ScopeAddressesWrite,
+	pb.AddressService_List_FullMethodName:   model.ScopeAddressesRead,
+}
+
+// public lists the methods answered without authentication, for the probes of the
+// orchestrator.
+var public = map[string]bool{
+	healthpb.Health_Check_FullMethodName: true,
+	healthpb.Health_List_FullMethodName:  true,
+}
+
+type authKey struct{}
+
+// NewAuthInterceptor authenticates every call like the HTTP auth middleware: by the
+// token in the authorization metadata, or by the API key in x-api-key when it is
+// sent. The call must then hold the scope of its method. Public methods are let through.
+func NewAuthInterceptor(userUseCase *usecase.UserUseCase, apiKeyUseCase *usecase.APIKeyUseCase) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if public[info.FullMethod] {
+			return handler(ctx, req)
+		}
+
+		md, _ := metadata.FromIncomingContext(ctx)
+
+		var auth *model.Auth
+		var err error
+		if key := first(md, MetadataAPIKey); key != "" {
+			auth, err = apiKeyUseCase.Verify(ctx, &model.VerifyAPIKeyRequest{Key: key})
+			if err != nil {
+				apiKeyUseCase.Log.Warnf("Failed find user by api key : %+v", err)
+				return nil, status.Error(codes.Unauthenticated, "Unauthorized")
+			}
+		} else {
+			auth, err = userUseCase.Verify(ctx, &model.VerifyUserRequest{Token: first(md, "authorization")})
+			if err != nil {
+				userUseCase.Log.Warnf("Failed find user by token : %+v", err)
+				return nil, status.Error(codes.Unauthenticated, "Unauthorized")
+			}
+		}
+
+		if scope, ok := scopes[info.FullMethod]; ok && !auth.HasScope(scope) {
+			return nil, status.Error(codes.PermissionDenied, "missing scope "+scope)
+		}
+		return handler(authenticated(ctx, auth), req)
+	}
+}
+
+func authenticated(ctx context.Context, auth *model.Auth) context.Context {
+	ctx = model.WithClientIP(model.WithActor(context.WithValue(ctx, authKey{}, auth), auth.ID), clientIP(ctx))
+	if auth.ImpersonatorId != "" {
+		ctx = model.WithImpersonator(ctx, auth.ImpersonatorId)
+	}
+	return model.WithRegion(ctx, auth.Region)
+}
+
+// GetUser returns the caller authenticated by the auth interceptor.
+func GetUser(ctx context.Context) *model.Auth {
+	return ctx.Value(authKey{}).(*model.Auth)
+}
+
+// first returns the first value of key in md, "" when there is none.
+func first(md metadata.MD, key string) string {
+	if values := md.Get(key); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+func clientIP(ctx context.Context) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok || p.Addr == nil {
+		return ""
+	}
+	host, _, err := net.SplitHostPort(p.Addr.String())
+	if err != nil {
+		return p.Addr.String()
+	}
+	return host
+}
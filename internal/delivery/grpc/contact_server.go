@@ -0,0 +1,122 @@
+package grpc
+
+import (
+	"context"
+	"go-rest-scaffold/internal/delivery/grpc/pb"
+	"go-rest-scaffold/internal/model"
+	"go-rest-scaffold/internal/usecase"
+	"math"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+type ContactServer struct {
+	pb.UnimplementedContactServiceServer
+	UseCase *usecase.ContactUseCase
+	Log     *logrus.Logger
+}
+
+func NewContactServer(useCase *usecase.ContactUseCase, log *logrus.Logger) *ContactServer {
+	return &ContactServer{
+		Log:     log,
+		UseCase: useCase,
+	}
+}
+
+func (s *ContactServer) Create(ctx context.Context, in *pb.CreateContactRequest) (*pb.Contact, error) {
+	response, err := s.UseCase.Create(ctx, &model.CreateContactRequest{
+		UserId:    GetUser(ctx).ID,
+		FirstName: in.FirstName,
+		LastName:  in.LastName,
+		Email:     in.Email,
+		Phone:     in.Phone,
+	})
+	if err != nil {
+		s.Log.WithContext(ctx).WithError(err).Error("error creating contact")
+		return nil, err
+	}
+
+	return contactToPb(response), nil
+}
+
+func (s *ContactServer) Get(ctx context.Context, in *pb.GetContactRequest) (*pb.Contact, error) {
+	response, err := s.UseCase.Get(ctx, &model.GetContactRequest{
+		UserId: GetUser(ctx).ID,
+		ID:     in.Id,
+	})
+	if err != nil {
+		s.Log.WithContext(ctx).WithError(err).Error("error getting contact")
+		return nil, err
+	}
+
+	return contactToPb(response), nil
+}
+
+func (s *ContactServer) Update(ctx context.Context, in *pb.UpdateContactRequest) (*pb.Contact, error) {
+	response, err := s.UseCase.Update(ctx, &model.UpdateContactRequest{
+		UserId:    GetUser(ctx).ID,
+		ID:        in.Id,
+		FirstName: in.FirstName,
+		LastName:  in.LastName,
+		Email:     in.Email,
+		Phone:     in.Phone,
+	})
+	if err != nil {
+		s.Log.WithContext(ctx).WithError(err).Error("error updating contact")
+		return nil, err
+	}
+
+	return contactToPb(response), nil
+}
+
+func (s *ContactServer) Delete(ctx context.Context, in *pb.DeleteContactRequest) (*pb.DeleteContactResponse, error) {
+	err := s.UseCase.Delete(ctx, &model.DeleteContactRequest{
+		UserId: GetUser(ctx).ID,
+		ID:     in.Id,
+	})
+	if err != nil {
+		s.Log.WithContext(ctx).WithError(err).Error("error deleting contact")
+		return nil, err
+	}
+
+	return &pb.DeleteContactResponse{}, nil
+}
+
+func (s *ContactServer) Search(ctx context.Context, in *pb.SearchContactRequest) (*pb.SearchContactResponse, error) {
+	request := &model.SearchContactRequest{
+		UserId: GetUser(ctx).ID,
+		Name:   in.Name,
+		Email:  in.Email,
+		Phone:  in.Phone,
+		Letter: strings.ToUpper(in.Letter),
+		Page:   int(in.Page),
+		Size:   int(in.Size),
+	}
+	if request.Page == 0 {
+		request.Page = 1
+	}
+	if request.Size == 0 {
+		request.Size = 10
+	}
+
+	responses, total, err := s.UseCase.Search(ctx, request)
+	if err != nil {
+		s.Log.WithContext(ctx).WithError(err).Error("error searching contact")
+		return nil, err
+	}
+
+	contacts := make([]*pb.Contact, len(responses))
+	for i := range responses {
+		contacts[i] = contactToPb(&responses[i])
+	}
+	return &pb.SearchContactResponse{
+		Contacts: contacts,
+		Paging: &pb.Paging{
+			Page:      int32(request.Page),
+			Size:      int32(request.Size),
+			TotalItem: total,
+			TotalPage: int64(math.Ceil(float64(total) / float64(request.Size))),
+		},
+	}, nil
+}
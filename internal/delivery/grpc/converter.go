@@ -0,0 +1,49 @@
+package grpc
+
+import (
+	"go-rest-scaffold/internal/delivery/grpc/pb"
+	"go-rest-scaffold/internal/model"
+)
+
+func userToPb(user *model.UserResponse) *pb.User {
+	return &pb.User{
+		Id:         user.ID,
+		Name:       user.Name,
+		Email:      user.Email,
+		Region:     user.Region,
+		VerifiedAt: user.VerifiedAt,
+		CreatedAt:  user.CreatedAt,
+		UpdatedAt:  user.UpdatedAt,
+	}
+}
+
+func contactToPb(contact *model.ContactResponse) *pb.Contact {
+	addresses := make([]*pb.Address, len(contact.Addresses))
+	for i := range contact.Addresses {
+		addresses[i] = addressToPb(&contact.Addresses[i])
+	}
+
+	return &pb.Contact{
+		Id:        contact.ID,
+		FirstName: contact.FirstName,
+		LastName:  contact.LastName,
+		Email:     contact.Email,
+		Phone:     contact.Phone,
+		CreatedAt: contact.CreatedAt,
+		UpdatedAt: contact.UpdatedAt,
+		Addresses: addresses,
+	}
+}
+
+func addressToPb(address *model.AddressResponse) *pb.Address {
+	return &pb.Address{
+		Id:         address.ID,
+		Street:     address.Street,
+		City:       address.City,
+		Province:   address.Province,
+		PostalCode: address.PostalCode,
+		Country:    address.Country,
+		CreatedAt:  address.CreatedAt,
+		UpdatedAt:  address.UpdatedAt,
+	}
+}
@@ -0,0 +1,62 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// codesByStatus maps the HTTP statuses the use cases fail with to gRPC codes.
+var codesByStatus = map[int]codes.Code{
+	fiber.StatusBadRequest:          codes.InvalidArgument,
+	fiber.StatusUnauthorized:        codes.Unauthenticated,
+	fiber.StatusForbidden:           codes.PermissionDenied,
+	fiber.StatusNotFound:            codes.NotFound,
+	fiber.StatusConflict:            codes.AlreadyExists,
+	fiber.StatusGone:                codes.NotFound,
+	fiber.StatusPreconditionFailed:  codes.FailedPrecondition,
+	fiber.StatusUnprocessableEntity: codes.InvalidArgument,
+	fiber.StatusTooManyRequests:     codes.ResourceExhausted,
+	fiber.StatusServiceUnavailable:  codes.Unavailable,
+	fiber.StatusGatewayTimeout:      codes.DeadlineExceeded,
+}
+
+// NewErrorInterceptor turns the errors of the use cases, which are fiber errors and
+// validation errors as the HTTP API answers them, into gRPC statuses. Other errors
+// are logged and answered as internal errors without their detail.
+func NewErrorInterceptor(log *logrus.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		response, err := handler(ctx, req)
+		if err == nil {
+			return response, nil
+		}
+		if _, ok := status.FromError(err); ok {
+			return nil, err
+		}
+
+		var validationErrors validator.ValidationErrors
+		if errors.As(err, &validationErrors) {
+			return nil, status.Error(codes.InvalidArgument, validationErrors.Error())
+		}
+		var fiberError *fiber.Error
+		if errors.As(err, &fiberError) {
+			code, ok := codesByStatus[fiberError.Code]
+			if !ok {
+				code = codes.Internal
+			}
+			return nil, status.Error(code, fiberError.Message)
+		}
+		if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+			return nil, status.FromContextError(err).Err()
+		}
+
+		log.WithContext(ctx).WithError(err).Errorf("Failed to serve %s", info.FullMethod)
+		return nil, status.Error(codes.Internal, "Internal Server Error")
+	}
+}
@@ -0,0 +1,67 @@
+package grpc
+
+import (
+	"context"
+	"go-rest-scaffold/internal/delivery/grpc/pb"
+	"go-rest-scaffold/internal/model"
+	"go-rest-scaffold/internal/usecase"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// services are the services whose status the health service reports, besides the
+// server as a whole.
+var services = []string{
+	pb.UserService_ServiceDesc.ServiceName,
+	pb.ContactService_ServiceDesc.ServiceName,
+	pb.AddressService_ServiceDesc.ServiceName,
+}
+
+// HealthServer is the standard gRPC health service, serving while the readiness
+// probe of the HTTP API would answer 200.
+type HealthServer struct {
+	*health.Server
+	UseCase *usecase.HealthUseCase
+	Log     *logrus.Logger
+}
+
+func NewHealthServer(useCase *usecase.HealthUseCase, log *logrus.Logger) *HealthServer {
+	return &HealthServer{
+		Server:  health.NewServer(),
+		UseCase: useCase,
+		Log:     log,
+	}
+}
+
+// Run updates the status every interval until ctx is done.
+func (s *HealthServer) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		s.Update(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Update runs the readiness checks and sets the status of the server and of every
+// service from them.
+func (s *HealthServer) Update(ctx context.Context) {
+	status := healthpb.HealthCheckResponse_SERVING
+	if response := s.UseCase.Ready(ctx); response.Status != model.HealthUp {
+		status = healthpb.HealthCheckResponse_NOT_SERVING
+	}
+
+	s.SetServingStatus("", status)
+	for _, service := range services {
+		s.SetServingStatus(service, status)
+	}
+}
@@ -0,0 +1,57 @@
+package grpc
+
+import (
+	"context"
+	"go-rest-scaffold/internal/delivery/grpc/pb"
+	"go-rest-scaffold/internal/model"
+	"go-rest-scaffold/internal/usecase"
+
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type UserServer struct {
+	pb.UnimplementedUserServiceServer
+	UseCase *usecase.UserUseCase
+	Log     *logrus.Logger
+}
+
+func NewUserServer(useCase *usecase.UserUseCase, log *logrus.Logger) *UserServer {
+	return &UserServer{
+		Log:     log,
+		UseCase: useCase,
+	}
+}
+
+func (s *UserServer) Current(ctx context.Context, _ *pb.CurrentUserRequest) (*pb.User, error) {
+	auth := GetUser(ctx)
+
+	response, err := s.UseCase.Current(ctx, &model.GetUserRequest{ID: auth.ID})
+	if err != nil {
+		s.Log.WithContext(ctx).WithError(err).Warnf("Failed to get current user")
+		return nil, err
+	}
+
+	return userToPb(response), nil
+}
+
+func (s *UserServer) Update(ctx context.Context, in *pb.UpdateUserRequest) (*pb.User, error) {
+	auth := GetUser(ctx)
+	if auth.ImpersonatorId != "" && in.Password != "" {
+		return nil, status.Error(codes.PermissionDenied, "not allowed while impersonating a user")
+	}
+
+	response, err := s.UseCase.Update(ctx, &model.UpdateUserRequest{
+		ID:       auth.ID,
+		Password: in.Password,
+		Name:     in.Name,
+		Email:    in.Email,
+	})
+	if err != nil {
+		s.Log.WithContext(ctx).WithError(err).Warnf("Failed to update user")
+		return nil, err
+	}
+
+	return userToPb(response), nil
+}
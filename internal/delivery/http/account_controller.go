@@ -0,0 +1,94 @@
+package http
+
+import (
+	"go-rest-scaffold/internal/delivery/http/middleware"
+	"go-rest-scaffold/internal/model"
+	"go-rest-scaffold/internal/usecase"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/sirupsen/logrus"
+)
+
+type AccountController struct {
+	Log     *logrus.Logger
+	UseCase *usecase.AccountUseCase
+}
+
+func NewAccountController(useCase *usecase.AccountUseCase, logger *logrus.Logger) *AccountController {
+	return &AccountController{
+		Log:     logger,
+		UseCase: useCase,
+	}
+}
+
+// Export godoc
+// @Summary      Export the account
+// @Description  Download everything the authenticated user owns (contacts with their addresses, reminders and webhooks) as a portable archive that another instance can import. Items in the trash are left out
+// @Tags         users
+// @Produce      json
+// @Security     BearerAuth
+// @Security     APIKeyAuth
+// @Success      200 {object} model.AccountArchive "Account archive"
+// @Failure      401 {object} object{errors=string} "Unauthorized"
+// @Failure      500 {object} object{errors=string} "Internal server error"
+// @Router       /users/_current/_export [get]
+func (c *AccountController) Export(ctx *fiber.Ctx) error {
+	auth := middleware.GetUser(ctx)
+
+	request := &model.ExportAccountRequest{
+		UserId: auth.ID,
+	}
+
+	archive, err := c.UseCase.Export(ctx.UserContext(), request)
+	if err != nil {
+		c.Log.WithContext(ctx.UserContext()).WithError(err).Error("error exporting account")
+		return err
+	}
+
+	ctx.Attachment("account-" + auth.ID + "-" + time.UnixMilli(archive.ExportedAt).UTC().Format("20060102") + ".json")
+	return ctx.JSON(archive)
+}
+
+// Import godoc
+// @Summary      Import an account archive
+// @Description  Add the content of an archive exported by this or another instance to the authenticated user's account, all or nothing. With ids=preserve the IDs of the archive are kept and must not exist here yet; with ids=remap every resource gets a new ID
+// @Tags         users
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Security     APIKeyAuth
+// @Param        ids query string false "Keep or regenerate the IDs of the archive" Enums(preserve, remap) default(preserve)
+// @Param        request body model.AccountArchive true "Account archive"
+// @Param        X-Dry-Run header bool false "Run all checks, then roll back instead of committing"
+// @Param        X-Signature header string false "t=<Unix seconds>,v1=<HMAC-SHA256>, required with an API key when request_signing.enabled"
+// @Success      200 {object} object{data=model.ImportAccountResponse} "Number of imported resources"
+// @Failure      400 {object} object{errors=string} "Invalid archive"
+// @Failure      401 {object} object{errors=string} "Unauthorized, or missing, invalid or reused signature"
+// @Failure      409 {object} object{errors=string} "IDs of the archive already exist"
+// @Failure      413 {object} object{errors=string} "Archive larger than web.body_limit"
+// @Failure      500 {object} object{errors=string} "Internal server error"
+// @Router       /users/_current/_import [post]
+func (c *AccountController) Import(ctx *fiber.Ctx) error {
+	auth := middleware.GetUser(ctx)
+
+	archive := new(model.AccountArchive)
+	if err := ctx.BodyParser(archive); err != nil {
+		c.Log.WithContext(ctx.UserContext()).WithError(err).Error("error parsing request body")
+		return fiber.ErrBadRequest
+	}
+
+	request := &model.ImportAccountRequest{
+		UserId:  auth.ID,
+		IDs:     ctx.Query("ids", model.ImportPreserveIds),
+		Archive: archive,
+	}
+
+	response, err := c.UseCase.Import(ctx.UserContext(), request)
+	if err != nil {
+		c.Log.WithContext(ctx.UserContext()).WithError(err).Error("error importing account")
+		return err
+	}
+
+	return ctx.JSON(model.WebResponse[*model.ImportAccountResponse]{Data: response})
+}
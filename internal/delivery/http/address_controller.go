@@ -28,8 +28,11 @@ func NewAddressController(useCase *usecase.AddressUseCase, log *logrus.Logger) *
 // @Accept       json
 // @Produce      json
 // @Security     BearerAuth
+// @Security     APIKeyAuth
 // @Param        contactId path string true "Contact ID"
 // @Param        request body model.CreateAddressRequest true "Address creation details"
+// @Param        X-Dry-Run header bool false "Run all checks, then roll back instead of committing"
+// @Param        Idempotency-Key header string false "Key of the request, unique per user; retries with the same key replay the first response"
 // @Success      200 {object} object{data=model.AddressResponse} "Successfully created address"
 // @Failure      400 {object} object{errors=string} "Invalid request body"
 // @Failure      401 {object} object{errors=string} "Unauthorized"
@@ -41,7 +44,7 @@ func (c *AddressController) Create(ctx *fiber.Ctx) error {
 
 	request := new(model.CreateAddressRequest)
 	if err := ctx.BodyParser(request); err != nil {
-		c.Log.WithError(err).Error("failed to parse request body")
+		c.Log.WithContext(ctx.UserContext()).WithError(err).Error("failed to parse request body")
 		return fiber.ErrBadRequest
 	}
 
@@ -50,10 +53,11 @@ func (c *AddressController) Create(ctx *fiber.Ctx) error {
 
 	response, err := c.UseCase.Create(ctx.UserContext(), request)
 	if err != nil {
-		c.Log.WithError(err).Error("failed to create address")
+		c.Log.WithContext(ctx.UserContext()).WithError(err).Error("failed to create address")
 		return err
 	}
 
+	ctx.Set(fiber.HeaderETag, middleware.ETag(response.Version))
 	return ctx.JSON(model.WebResponse[*model.AddressResponse]{Data: response})
 }
 
@@ -64,7 +68,9 @@ func (c *AddressController) Create(ctx *fiber.Ctx) error {
 // @Accept       json
 // @Produce      json
 // @Security     BearerAuth
+// @Security     APIKeyAuth
 // @Param        contactId path string true "Contact ID"
+// @Param        fields query string false "Comma separated fields to return; id is always returned"
 // @Success      200 {object} object{data=[]model.AddressResponse} "List of addresses"
 // @Failure      401 {object} object{errors=string} "Unauthorized"
 // @Failure      404 {object} object{errors=string} "Contact not found"
@@ -81,7 +87,7 @@ func (c *AddressController) List(ctx *fiber.Ctx) error {
 
 	responses, err := c.UseCase.List(ctx.UserContext(), request)
 	if err != nil {
-		c.Log.WithError(err).Error("failed to list addresses")
+		c.Log.WithContext(ctx.UserContext()).WithError(err).Error("failed to list addresses")
 		return err
 	}
 
@@ -95,9 +101,13 @@ func (c *AddressController) List(ctx *fiber.Ctx) error {
 // @Accept       json
 // @Produce      json
 // @Security     BearerAuth
+// @Security     APIKeyAuth
 // @Param        contactId path string true "Contact ID"
 // @Param        addressId path string true "Address ID"
+// @Param        If-None-Match header string false "ETag of the version held, answered with 304 while it is current"
+// @Param        fields query string false "Comma separated fields to return; id is always returned"
 // @Success      200 {object} object{data=model.AddressResponse} "Address details"
+// @Success      304 "Not modified"
 // @Failure      401 {object} object{errors=string} "Unauthorized"
 // @Failure      404 {object} object{errors=string} "Address not found"
 // @Failure      500 {object} object{errors=string} "Internal server error"
@@ -115,10 +125,13 @@ func (c *AddressController) Get(ctx *fiber.Ctx) error {
 
 	response, err := c.UseCase.Get(ctx.UserContext(), request)
 	if err != nil {
-		c.Log.WithError(err).Error("failed to get address")
+		c.Log.WithContext(ctx.UserContext()).WithError(err).Error("failed to get address")
 		return err
 	}
 
+	if middleware.NotModified(ctx, middleware.ETag(response.Version)) {
+		return ctx.SendStatus(fiber.StatusNotModified)
+	}
 	return ctx.JSON(model.WebResponse[*model.AddressResponse]{Data: response})
 }
 
@@ -129,13 +142,18 @@ func (c *AddressController) Get(ctx *fiber.Ctx) error {
 // @Accept       json
 // @Produce      json
 // @Security     BearerAuth
+// @Security     APIKeyAuth
 // @Param        contactId path string true "Contact ID"
 // @Param        addressId path string true "Address ID"
 // @Param        request body model.UpdateAddressRequest true "Address update details"
+// @Param        X-Dry-Run header bool false "Run all checks, then roll back instead of committing"
+// @Param        If-Match header string false "ETag of the version to change, required when etag.require_if_match"
 // @Success      200 {object} object{data=model.AddressResponse} "Successfully updated address"
 // @Failure      400 {object} object{errors=string} "Invalid request body"
 // @Failure      401 {object} object{errors=string} "Unauthorized"
 // @Failure      404 {object} object{errors=string} "Address not found"
+// @Failure      412 {object} object{errors=string} "The version changed since it was read"
+// @Failure      428 {object} object{errors=string} "If-Match is required"
 // @Failure      500 {object} object{errors=string} "Internal server error"
 // @Router       /contacts/{contactId}/addresses/{addressId} [put]
 func (c *AddressController) Update(ctx *fiber.Ctx) error {
@@ -143,7 +161,7 @@ func (c *AddressController) Update(ctx *fiber.Ctx) error {
 
 	request := new(model.UpdateAddressRequest)
 	if err := ctx.BodyParser(request); err != nil {
-		c.Log.WithError(err).Error("failed to parse request body")
+		c.Log.WithContext(ctx.UserContext()).WithError(err).Error("failed to parse request body")
 		return fiber.ErrBadRequest
 	}
 
@@ -151,27 +169,90 @@ func (c *AddressController) Update(ctx *fiber.Ctx) error {
 	request.ContactId = ctx.Params("contactId")
 	request.ID = ctx.Params("addressId")
 
+	version, err := middleware.IfMatch(ctx)
+	if err != nil {
+		return err
+	}
+	request.Version = version
+
 	response, err := c.UseCase.Update(ctx.UserContext(), request)
 	if err != nil {
-		c.Log.WithError(err).Error("failed to update address")
+		c.Log.WithContext(ctx.UserContext()).WithError(err).Error("failed to update address")
 		return err
 	}
 
+	ctx.Set(fiber.HeaderETag, middleware.ETag(response.Version))
+	return ctx.JSON(model.WebResponse[*model.AddressResponse]{Data: response})
+}
+
+// Patch godoc
+// @Summary      Partially update an address
+// @Description  Apply a JSON merge patch (RFC 7386) to a specific address: fields left out keep their value and null clears one, e.g. {"postal_code": "40115", "province": null}
+// @Tags         addresses
+// @Accept       json
+// @Accept       application/merge-patch+json
+// @Produce      json
+// @Security     BearerAuth
+// @Security     APIKeyAuth
+// @Param        contactId path string true "Contact ID"
+// @Param        addressId path string true "Address ID"
+// @Param        request body model.PatchAddressRequest true "Fields to change"
+// @Param        X-Dry-Run header bool false "Run all checks, then roll back instead of committing"
+// @Param        If-Match header string false "ETag of the version to change, required when etag.require_if_match"
+// @Success      200 {object} object{data=model.AddressResponse} "Successfully updated address"
+// @Failure      400 {object} object{errors=string} "Invalid request body"
+// @Failure      401 {object} object{errors=string} "Unauthorized"
+// @Failure      404 {object} object{errors=string} "Address not found"
+// @Failure      412 {object} object{errors=string} "The version changed since it was read"
+// @Failure      428 {object} object{errors=string} "If-Match is required"
+// @Failure      500 {object} object{errors=string} "Internal server error"
+// @Router       /contacts/{contactId}/addresses/{addressId} [patch]
+func (c *AddressController) Patch(ctx *fiber.Ctx) error {
+	auth := middleware.GetUser(ctx)
+
+	request := new(model.PatchAddressRequest)
+	if err := ctx.BodyParser(request); err != nil {
+		c.Log.WithContext(ctx.UserContext()).WithError(err).Error("failed to parse request body")
+		return fiber.ErrBadRequest
+	}
+
+	request.UserId = auth.ID
+	request.ContactId = ctx.Params("contactId")
+	request.ID = ctx.Params("addressId")
+
+	version, err := middleware.IfMatch(ctx)
+	if err != nil {
+		return err
+	}
+	request.Version = version
+
+	response, err := c.UseCase.Patch(ctx.UserContext(), request)
+	if err != nil {
+		c.Log.WithContext(ctx.UserContext()).WithError(err).Error("failed to patch address")
+		return err
+	}
+
+	ctx.Set(fiber.HeaderETag, middleware.ETag(response.Version))
 	return ctx.JSON(model.WebResponse[*model.AddressResponse]{Data: response})
 }
 
 // Delete godoc
 // @Summary      Delete an address
-// @Description  Delete a specific address by ID for a contact
+// @Description  Move a specific address of a contact to the trash, from where it can be restored until it is purged
 // @Tags         addresses
 // @Accept       json
 // @Produce      json
 // @Security     BearerAuth
+// @Security     APIKeyAuth
 // @Param        contactId path string true "Contact ID"
 // @Param        addressId path string true "Address ID"
+// @Param        X-Dry-Run header bool false "Run all checks, then roll back instead of committing"
+// @Param        If-Match header string false "ETag of the version to change, required when etag.require_if_match"
 // @Success      200 {object} object{data=bool} "Successfully deleted address"
 // @Failure      401 {object} object{errors=string} "Unauthorized"
 // @Failure      404 {object} object{errors=string} "Address not found"
+// @Failure      412 {object} object{errors=string} "The version changed since it was read"
+// @Failure      428 {object} object{errors=string} "If-Match is required"
 // @Failure      500 {object} object{errors=string} "Internal server error"
 // @Router       /contacts/{contactId}/addresses/{addressId} [delete]
 func (c *AddressController) Delete(ctx *fiber.Ctx) error {
@@ -185,8 +266,14 @@ func (c *AddressController) Delete(ctx *fiber.Ctx) error {
 		ID:        addressId,
 	}
 
+	version, err := middleware.IfMatch(ctx)
+	if err != nil {
+		return err
+	}
+	request.Version = version
+
 	if err := c.UseCase.Delete(ctx.UserContext(), request); err != nil {
-		c.Log.WithError(err).Error("failed to delete address")
+		c.Log.WithContext(ctx.UserContext()).WithError(err).Error("failed to delete address")
 		return err
 	}
 
@@ -0,0 +1,200 @@
+package http
+
+import (
+	"go-rest-scaffold/internal/model"
+	"go-rest-scaffold/internal/usecase"
+	"math"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/sirupsen/logrus"
+)
+
+type AnnouncementController struct {
+	Log     *logrus.Logger
+	UseCase *usecase.AnnouncementUseCase
+}
+
+func NewAnnouncementController(useCase *usecase.AnnouncementUseCase, logger *logrus.Logger) *AnnouncementController {
+	return &AnnouncementController{
+		Log:     logger,
+		UseCase: useCase,
+	}
+}
+
+// Active godoc
+// @Summary      List active announcements
+// @Description  List the announcements client apps should currently display, most severe first
+// @Tags         announcements
+// @Produce      json
+// @Success      200 {object} object{data=[]model.AnnouncementResponse} "Active announcements"
+// @Failure      500 {object} object{errors=string} "Internal server error"
+// @Router       /announcements/active [get]
+func (c *AnnouncementController) Active(ctx *fiber.Ctx) error {
+	responses, err := c.UseCase.Active(ctx.UserContext())
+	if err != nil {
+		c.Log.WithContext(ctx.UserContext()).WithError(err).Error("error listing active announcements")
+		return err
+	}
+
+	return ctx.JSON(model.WebResponse[[]model.AnnouncementResponse]{Data: responses})
+}
+
+// Create godoc
+// @Summary      Create an announcement
+// @Description  Schedule a banner such as a maintenance notice; starts_at defaults to now and ends_at 0 means open-ended
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Security     APIKeyAuth
+// @Param        request body model.CreateAnnouncementRequest true "Announcement details"
+// @Success      200 {object} object{data=model.AnnouncementResponse} "Successfully created announcement"
+// @Failure      400 {object} object{errors=string} "Invalid request body"
+// @Failure      401 {object} object{errors=string} "Unauthorized"
+// @Failure      403 {object} object{errors=string} "Not an admin"
+// @Failure      500 {object} object{errors=string} "Internal server error"
+// @Router       /admin/announcements [post]
+func (c *AnnouncementController) Create(ctx *fiber.Ctx) error {
+	request := new(model.CreateAnnouncementRequest)
+	if err := ctx.BodyParser(request); err != nil {
+		c.Log.WithContext(ctx.UserContext()).WithError(err).Error("error parsing request body")
+		return fiber.ErrBadRequest
+	}
+
+	response, err := c.UseCase.Create(ctx.UserContext(), request)
+	if err != nil {
+		c.Log.WithContext(ctx.UserContext()).WithError(err).Error("error creating announcement")
+		return err
+	}
+
+	return ctx.JSON(model.WebResponse[*model.AnnouncementResponse]{Data: response})
+}
+
+// List godoc
+// @Summary      List announcements
+// @Description  List every announcement, including scheduled and expired ones, newest first
+// @Tags         admin
+// @Produce      json
+// @Security     BearerAuth
+// @Security     APIKeyAuth
+// @Param        page query int false "Page number" default(1)
+// @Param        size query int false "Page size" default(10)
+// @Success      200 {object} object{data=[]model.AnnouncementResponse,paging=model.PageMetadata} "List of announcements with pagination"
+// @Failure      400 {object} object{errors=string} "Invalid query"
+// @Failure      401 {object} object{errors=string} "Unauthorized"
+// @Failure      403 {object} object{errors=string} "Not an admin"
+// @Failure      500 {object} object{errors=string} "Internal server error"
+// @Router       /admin/announcements [get]
+func (c *AnnouncementController) List(ctx *fiber.Ctx) error {
+	request := &model.SearchAnnouncementRequest{
+		Page: ctx.QueryInt("page", 1),
+		Size: ctx.QueryInt("size", 10),
+	}
+
+	responses, total, err := c.UseCase.Search(ctx.UserContext(), request)
+	if err != nil {
+		c.Log.WithContext(ctx.UserContext()).WithError(err).Error("error searching announcements")
+		return err
+	}
+
+	paging := &model.PageMetadata{
+		Page:      request.Page,
+		Size:      request.Size,
+		TotalItem: total,
+		TotalPage: int64(math.Ceil(float64(total) / float64(request.Size))),
+	}
+
+	return ctx.JSON(model.WebResponse[[]model.AnnouncementResponse]{
+		Data:   responses,
+		Paging: paging,
+	})
+}
+
+// Get godoc
+// @Summary      Get an announcement
+// @Description  Get a single announcement by ID
+// @Tags         admin
+// @Produce      json
+// @Security     BearerAuth
+// @Security     APIKeyAuth
+// @Param        announcementId path string true "Announcement ID"
+// @Success      200 {object} object{data=model.AnnouncementResponse} "Announcement details"
+// @Failure      401 {object} object{errors=string} "Unauthorized"
+// @Failure      403 {object} object{errors=string} "Not an admin"
+// @Failure      404 {object} object{errors=string} "Announcement not found"
+// @Failure      500 {object} object{errors=string} "Internal server error"
+// @Router       /admin/announcements/{announcementId} [get]
+func (c *AnnouncementController) Get(ctx *fiber.Ctx) error {
+	request := &model.GetAnnouncementRequest{
+		ID: ctx.Params("announcementId"),
+	}
+
+	response, err := c.UseCase.Get(ctx.UserContext(), request)
+	if err != nil {
+		c.Log.WithContext(ctx.UserContext()).WithError(err).Error("error getting announcement")
+		return err
+	}
+
+	return ctx.JSON(model.WebResponse[*model.AnnouncementResponse]{Data: response})
+}
+
+// Update godoc
+// @Summary      Update an announcement
+// @Description  Replace the content and schedule of an announcement
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Security     APIKeyAuth
+// @Param        announcementId path string true "Announcement ID"
+// @Param        request body model.UpdateAnnouncementRequest true "Announcement details"
+// @Success      200 {object} object{data=model.AnnouncementResponse} "Successfully updated announcement"
+// @Failure      400 {object} object{errors=string} "Invalid request body"
+// @Failure      401 {object} object{errors=string} "Unauthorized"
+// @Failure      403 {object} object{errors=string} "Not an admin"
+// @Failure      404 {object} object{errors=string} "Announcement not found"
+// @Failure      500 {object} object{errors=string} "Internal server error"
+// @Router       /admin/announcements/{announcementId} [put]
+func (c *AnnouncementController) Update(ctx *fiber.Ctx) error {
+	request := new(model.UpdateAnnouncementRequest)
+	if err := ctx.BodyParser(request); err != nil {
+		c.Log.WithContext(ctx.UserContext()).WithError(err).Error("error parsing request body")
+		return fiber.ErrBadRequest
+	}
+	request.ID = ctx.Params("announcementId")
+
+	response, err := c.UseCase.Update(ctx.UserContext(), request)
+	if err != nil {
+		c.Log.WithContext(ctx.UserContext()).WithError(err).Error("error updating announcement")
+		return err
+	}
+
+	return ctx.JSON(model.WebResponse[*model.AnnouncementResponse]{Data: response})
+}
+
+// Delete godoc
+// @Summary      Delete an announcement
+// @Description  Delete an announcement so clients stop displaying it
+// @Tags         admin
+// @Produce      json
+// @Security     BearerAuth
+// @Security     APIKeyAuth
+// @Param        announcementId path string true "Announcement ID"
+// @Success      200 {object} object{data=bool} "Successfully deleted announcement"
+// @Failure      401 {object} object{errors=string} "Unauthorized"
+// @Failure      403 {object} object{errors=string} "Not an admin"
+// @Failure      404 {object} object{errors=string} "Announcement not found"
+// @Failure      500 {object} object{errors=string} "Internal server error"
+// @Router       /admin/announcements/{announcementId} [delete]
+func (c *AnnouncementController) Delete(ctx *fiber.Ctx) error {
+	request := &model.DeleteAnnouncementRequest{
+		ID: ctx.Params("announcementId"),
+	}
+
+	if err := c.UseCase.Delete(ctx.UserContext(), request); err != nil {
+		c.Log.WithContext(ctx.UserContext()).WithError(err).Error("error deleting announcement")
+		return err
+	}
+
+	return ctx.JSON(model.WebResponse[bool]{Data: true})
+}
@@ -0,0 +1,127 @@
+package http
+
+import (
+	"go-rest-scaffold/internal/delivery/http/middleware"
+	"go-rest-scaffold/internal/model"
+	"go-rest-scaffold/internal/usecase"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/sirupsen/logrus"
+)
+
+// errAPIKeyManagement refuses key management to requests authenticated by a key, so a
+// leaked key cannot be used to mint more keys or revoke the ones it sits beside.
+var errAPIKeyManagement = fiber.NewError(fiber.StatusForbidden, "api keys cannot be managed with an api key")
+
+type APIKeyController struct {
+	Log     *logrus.Logger
+	UseCase *usecase.APIKeyUseCase
+}
+
+func NewAPIKeyController(useCase *usecase.APIKeyUseCase, logger *logrus.Logger) *APIKeyController {
+	return &APIKeyController{
+		Log:     logger,
+		UseCase: useCase,
+	}
+}
+
+// Create godoc
+// @Summary      Create an API key
+// @Description  Issue a key machine clients send in X-API-Key to act as the authenticated user. The key is only returned in this response
+// @Tags         api-keys
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        request body model.CreateAPIKeyRequest true "API key details"
+// @Param        Idempotency-Key header string false "Key of the request, unique per user; retries with the same key replay the first response"
+// @Success      200 {object} object{data=model.APIKeyResponse} "Successfully created API key"
+// @Failure      400 {object} object{errors=string} "Invalid request body"
+// @Failure      401 {object} object{errors=string} "Unauthorized"
+// @Failure      403 {object} object{errors=string} "Authenticated with an API key, impersonating the user, or scopes the session does not have"
+// @Failure      500 {object} object{errors=string} "Internal server error"
+// @Router       /api-keys [post]
+func (c *APIKeyController) Create(ctx *fiber.Ctx) error {
+	auth := middleware.GetUser(ctx)
+	if auth.APIKeyId != "" {
+		return errAPIKeyManagement
+	}
+	if auth.ImpersonatorId != "" {
+		return errImpersonating
+	}
+
+	request := new(model.CreateAPIKeyRequest)
+	if err := ctx.BodyParser(request); err != nil {
+		c.Log.WithContext(ctx.UserContext()).WithError(err).Error("error parsing request body")
+		return fiber.ErrBadRequest
+	}
+	request.UserId = auth.ID
+	request.CallerScopes = auth.Scopes
+
+	response, err := c.UseCase.Create(ctx.UserContext(), request)
+	if err != nil {
+		c.Log.WithContext(ctx.UserContext()).WithError(err).Error("error creating api key")
+		return err
+	}
+
+	return ctx.JSON(model.WebResponse[*model.APIKeyResponse]{Data: response})
+}
+
+// List godoc
+// @Summary      List API keys
+// @Description  List the API keys of the authenticated user, revoked ones included
+// @Tags         api-keys
+// @Produce      json
+// @Security     BearerAuth
+// @Security     APIKeyAuth
+// @Success      200 {object} object{data=[]model.APIKeyResponse} "List of API keys"
+// @Failure      401 {object} object{errors=string} "Unauthorized"
+// @Failure      500 {object} object{errors=string} "Internal server error"
+// @Router       /api-keys [get]
+func (c *APIKeyController) List(ctx *fiber.Ctx) error {
+	auth := middleware.GetUser(ctx)
+
+	request := &model.ListAPIKeyRequest{
+		UserId: auth.ID,
+	}
+
+	responses, err := c.UseCase.List(ctx.UserContext(), request)
+	if err != nil {
+		c.Log.WithContext(ctx.UserContext()).WithError(err).Error("error listing api keys")
+		return err
+	}
+
+	return ctx.JSON(model.WebResponse[[]model.APIKeyResponse]{Data: responses})
+}
+
+// Revoke godoc
+// @Summary      Revoke an API key
+// @Description  Stop an API key of the authenticated user from authenticating; it stays listed as revoked
+// @Tags         api-keys
+// @Produce      json
+// @Security     BearerAuth
+// @Param        apiKeyId path string true "API key ID"
+// @Success      200 {object} object{data=model.APIKeyResponse} "Successfully revoked API key"
+// @Failure      401 {object} object{errors=string} "Unauthorized"
+// @Failure      403 {object} object{errors=string} "Authenticated with an API key"
+// @Failure      404 {object} object{errors=string} "API key not found"
+// @Failure      500 {object} object{errors=string} "Internal server error"
+// @Router       /api-keys/{apiKeyId} [delete]
+func (c *APIKeyController) Revoke(ctx *fiber.Ctx) error {
+	auth := middleware.GetUser(ctx)
+	if auth.APIKeyId != "" {
+		return errAPIKeyManagement
+	}
+
+	request := &model.RevokeAPIKeyRequest{
+		UserId: auth.ID,
+		ID:     ctx.Params("apiKeyId"),
+	}
+
+	response, err := c.UseCase.Revoke(ctx.UserContext(), request)
+	if err != nil {
+		c.Log.WithContext(ctx.UserContext()).WithError(err).Error("error revoking api key")
+		return err
+	}
+
+	return ctx.JSON(model.WebResponse[*model.APIKeyResponse]{Data: response})
+}
@@ -0,0 +1,70 @@
+package http
+
+import (
+	"go-rest-scaffold/internal/model"
+	"go-rest-scaffold/internal/usecase"
+	"math"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/sirupsen/logrus"
+)
+
+type AuditLogController struct {
+	Log     *logrus.Logger
+	UseCase *usecase.AuditLogUseCase
+}
+
+func NewAuditLogController(useCase *usecase.AuditLogUseCase, logger *logrus.Logger) *AuditLogController {
+	return &AuditLogController{
+		Log:     logger,
+		UseCase: useCase,
+	}
+}
+
+// List godoc
+// @Summary      List audit logs
+// @Description  List the changes made through the API, newest first, with the fields changed by each
+// @Tags         admin
+// @Produce      json
+// @Security     BearerAuth
+// @Security     APIKeyAuth
+// @Param        user_id query string false "Only changes made by this user"
+// @Param        entity_type query string false "Only changes of this entity type" Enums(user, contact, address, reminder, webhook, api_key, announcement)
+// @Param        from query int false "Only changes made at or after this time, in Unix milliseconds"
+// @Param        to query int false "Only changes made before this time, in Unix milliseconds"
+// @Param        page query int false "Page number" default(1)
+// @Param        size query int false "Page size" default(10)
+// @Success      200 {object} object{data=[]model.AuditLogResponse,paging=model.PageMetadata} "List of audit logs with pagination"
+// @Failure      400 {object} object{errors=string} "Invalid query"
+// @Failure      401 {object} object{errors=string} "Unauthorized"
+// @Failure      403 {object} object{errors=string} "Not an admin"
+// @Failure      500 {object} object{errors=string} "Internal server error"
+// @Router       /admin/audit-logs [get]
+func (c *AuditLogController) List(ctx *fiber.Ctx) error {
+	request := &model.SearchAuditLogRequest{
+		UserId:     ctx.Query("user_id", ""),
+		EntityType: ctx.Query("entity_type", ""),
+		From:       int64(ctx.QueryInt("from", 0)),
+		To:         int64(ctx.QueryInt("to", 0)),
+		Page:       ctx.QueryInt("page", 1),
+		Size:       ctx.QueryInt("size", 10),
+	}
+
+	responses, total, err := c.UseCase.Search(ctx.UserContext(), request)
+	if err != nil {
+		c.Log.WithContext(ctx.UserContext()).WithError(err).Error("error searching audit logs")
+		return err
+	}
+
+	paging := &model.PageMetadata{
+		Page:      request.Page,
+		Size:      request.Size,
+		TotalItem: total,
+		TotalPage: int64(math.Ceil(float64(total) / float64(request.Size))),
+	}
+
+	return ctx.JSON(model.WebResponse[[]model.AuditLogResponse]{
+		Data:   responses,
+		Paging: paging,
+	})
+}
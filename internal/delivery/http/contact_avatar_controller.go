@@ -0,0 +1,183 @@
+package http
+
+import (
+	"errors"
+	"go-rest-scaffold/internal/delivery/http/middleware"
+	"go-rest-scaffold/internal/gateway/storage"
+	"go-rest-scaffold/internal/model"
+	"go-rest-scaffold/internal/usecase"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/sirupsen/logrus"
+)
+
+type ContactAvatarController struct {
+	UseCase *usecase.ContactAvatarUseCase
+	// Disk serves the pictures kept on the local disk, nil when they are kept elsewhere.
+	Disk *storage.Disk
+	Log  *logrus.Logger
+}
+
+// contactETag is the ETag of a contact, which changes with the signed URLs of its
+// avatar too.
+func contactETag(response *model.ContactResponse) string {
+	if response.Avatar != nil && response.Avatar.ExpiresAt > 0 {
+		return middleware.SignedETag(response.Version, response.Avatar.ExpiresAt)
+	}
+	return middleware.ETag(response.Version)
+}
+
+func NewContactAvatarController(useCase *usecase.ContactAvatarUseCase, log *logrus.Logger) *ContactAvatarController {
+	disk, _ := useCase.Store.(*storage.Disk)
+	return &ContactAvatarController{
+		UseCase: useCase,
+		Disk:    disk,
+		Log:     log,
+	}
+}
+
+// Upload godoc
+// @Summary      Upload the avatar of a contact
+// @Description  Replace the avatar of a contact with a JPEG, PNG or GIF picture of at most contact.avatar_max_bytes. The picture is cropped to a square and stored in 64, 256 and 512 pixel JPEGs, read through the signed URLs of the avatar of the contact, which expire at avatar.expires_at
+// @Tags         contacts
+// @Accept       multipart/form-data
+// @Produce      json
+// @Security     BearerAuth
+// @Security     APIKeyAuth
+// @Param        contactId path string true "Contact ID"
+// @Param        file formData file true "Picture"
+// @Param        X-Dry-Run header bool false "Run all checks, then roll back instead of committing"
+// @Param        If-Match header string false "ETag of the version to change, required when etag.require_if_match"
+// @Success      200 {object} object{data=model.ContactResponse} "Successfully uploaded avatar"
+// @Failure      400 {object} object{errors=string} "Missing file or not a picture"
+// @Failure      401 {object} object{errors=string} "Unauthorized"
+// @Failure      404 {object} object{errors=string} "Contact not found"
+// @Failure      412 {object} object{errors=string} "The version changed since it was read"
+// @Failure      413 {object} object{errors=string} "Picture larger than contact.avatar_max_bytes"
+// @Failure      428 {object} object{errors=string} "If-Match is required"
+// @Failure      500 {object} object{errors=string} "Internal server error"
+// @Router       /contacts/{contactId}/avatar [put]
+func (c *ContactAvatarController) Upload(ctx *fiber.Ctx) error {
+	auth := middleware.GetUser(ctx)
+
+	header, err := ctx.FormFile("file")
+	if err != nil {
+		c.Log.WithContext(ctx.UserContext()).WithError(err).Error("error reading uploaded file")
+		return fiber.NewError(fiber.StatusBadRequest, "send the picture as the file field of a multipart/form-data body")
+	}
+	if header.Size > int64(c.UseCase.Options.MaxBytes) {
+		return fiber.NewError(fiber.StatusRequestEntityTooLarge, "the picture is larger than "+strconv.Itoa(c.UseCase.Options.MaxBytes)+" bytes")
+	}
+
+	file, err := header.Open()
+	if err != nil {
+		c.Log.WithContext(ctx.UserContext()).WithError(err).Error("error opening uploaded file")
+		return fiber.ErrInternalServerError
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(file)
+	if err != nil {
+		c.Log.WithContext(ctx.UserContext()).WithError(err).Error("error reading uploaded file")
+		return fiber.ErrInternalServerError
+	}
+
+	request := &model.UploadContactAvatarRequest{
+		UserId:    auth.ID,
+		ContactId: ctx.Params("contactId"),
+		Data:      data,
+	}
+
+	version, err := middleware.IfMatch(ctx)
+	if err != nil {
+		return err
+	}
+	request.Version = version
+
+	response, err := c.UseCase.Upload(ctx.UserContext(), request)
+	if err != nil {
+		c.Log.WithContext(ctx.UserContext()).WithError(err).Warn("error uploading avatar")
+		return err
+	}
+
+	ctx.Set(fiber.HeaderETag, contactETag(response))
+	return ctx.JSON(model.WebResponse[*model.ContactResponse]{Data: response})
+}
+
+// Delete godoc
+// @Summary      Delete the avatar of a contact
+// @Description  Remove the avatar of a contact and delete its pictures; a contact without an avatar is returned unchanged
+// @Tags         contacts
+// @Produce      json
+// @Security     BearerAuth
+// @Security     APIKeyAuth
+// @Param        contactId path string true "Contact ID"
+// @Param        X-Dry-Run header bool false "Run all checks, then roll back instead of committing"
+// @Param        If-Match header string false "ETag of the version to change, required when etag.require_if_match"
+// @Success      200 {object} object{data=model.ContactResponse} "Successfully deleted avatar"
+// @Failure      401 {object} object{errors=string} "Unauthorized"
+// @Failure      404 {object} object{errors=string} "Contact not found"
+// @Failure      412 {object} object{errors=string} "The version changed since it was read"
+// @Failure      428 {object} object{errors=string} "If-Match is required"
+// @Failure      500 {object} object{errors=string} "Internal server error"
+// @Router       /contacts/{contactId}/avatar [delete]
+func (c *ContactAvatarController) Delete(ctx *fiber.Ctx) error {
+	auth := middleware.GetUser(ctx)
+
+	request := &model.DeleteContactAvatarRequest{
+		UserId:    auth.ID,
+		ContactId: ctx.Params("contactId"),
+	}
+
+	version, err := middleware.IfMatch(ctx)
+	if err != nil {
+		return err
+	}
+	request.Version = version
+
+	response, err := c.UseCase.Delete(ctx.UserContext(), request)
+	if err != nil {
+		c.Log.WithContext(ctx.UserContext()).WithError(err).Error("error deleting avatar")
+		return err
+	}
+
+	ctx.Set(fiber.HeaderETag, contactETag(response))
+	return ctx.JSON(model.WebResponse[*model.ContactResponse]{Data: response})
+}
+
+// Object godoc
+// @Summary      Read a stored picture
+// @Description  Read a picture kept on the local disk through a URL signed by the API, such as those of the avatars of contacts. Only served when storage.driver is disk
+// @Tags         storage
+// @Produce      jpeg
+// @Param        key path string true "Key of the picture"
+// @Param        expires query int true "Unix time the URL expires at"
+// @Param        signature query string true "Signature of the URL"
+// @Success      200 {file} binary "Picture"
+// @Failure      403 {object} object{errors=string} "Invalid or expired signature"
+// @Failure      404 {object} object{errors=string} "Picture not found"
+// @Failure      500 {object} object{errors=string} "Internal server error"
+// @Router       /storage/{key} [get]
+func (c *ContactAvatarController) Object(ctx *fiber.Ctx) error {
+	expires := ctx.Query("expires")
+	data, err := c.Disk.Open(ctx.Params("*"), expires, ctx.Query("signature"))
+	switch {
+	case errors.Is(err, storage.ErrInvalidSignature):
+		return fiber.NewError(fiber.StatusForbidden, "the URL is invalid or expired")
+	case errors.Is(err, storage.ErrNotFound):
+		return fiber.ErrNotFound
+	case err != nil:
+		c.Log.WithContext(ctx.UserContext()).WithError(err).Error("error reading stored object")
+		return fiber.ErrInternalServerError
+	}
+
+	// Open checked expires is a time to come
+	deadline, _ := strconv.ParseInt(expires, 10, 64)
+	maxAge := int(time.Until(time.Unix(deadline, 0)).Seconds())
+	ctx.Set(fiber.HeaderCacheControl, "private, max-age="+strconv.Itoa(max(maxAge, 0)))
+	ctx.Set(fiber.HeaderContentType, "image/jpeg")
+	return ctx.Send(data)
+}
@@ -1,15 +1,53 @@
 package http
 
 import (
+	"bufio"
+	"context"
+	"encoding/csv"
 	"go-rest-scaffold/internal/delivery/http/middleware"
+	"go-rest-scaffold/internal/delivery/http/odata"
+	"go-rest-scaffold/internal/delivery/http/queryparam"
 	"go-rest-scaffold/internal/model"
 	"go-rest-scaffold/internal/usecase"
 	"math"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/sirupsen/logrus"
 )
 
+// contactFilterFields are the fields the contact list can be filtered by, the
+// filterable columns of the contact repository.
+var contactFilterFields = queryparam.Fields{
+	"id":         queryparam.Text,
+	"first_name": queryparam.Text,
+	"last_name":  queryparam.Text,
+	"email":      queryparam.Text,
+	"phone":      queryparam.Text,
+	"created_at": queryparam.Time,
+	"updated_at": queryparam.Time,
+}
+
+const MIMETextCSV = "text/csv"
+
+// contactCSVHeader names the columns of a CSV export, which the import maps back to
+// the same fields; it ignores the others.
+var contactCSVHeader = []string{"id", "first_name", "last_name", "email", "phone", "created_at", "updated_at"}
+
+func contactCSVRow(contact *model.ContactResponse) []string {
+	return []string{
+		contact.ID,
+		model.EscapeCSVCell(contact.FirstName),
+		model.EscapeCSVCell(contact.LastName),
+		model.EscapeCSVCell(contact.Email),
+		model.EscapeCSVCell(contact.Phone),
+		strconv.FormatInt(contact.CreatedAt, 10),
+		strconv.FormatInt(contact.UpdatedAt, 10),
+	}
+}
+
 type ContactController struct {
 	UseCase *usecase.ContactUseCase
 	Log     *logrus.Logger
@@ -29,7 +67,10 @@ func NewContactController(useCase *usecase.ContactUseCase, log *logrus.Logger) *
 // @Accept       json
 // @Produce      json
 // @Security     BearerAuth
+// @Security     APIKeyAuth
 // @Param        request body model.CreateContactRequest true "Contact creation details"
+// @Param        X-Dry-Run header bool false "Run all checks, then roll back instead of committing"
+// @Param        Idempotency-Key header string false "Key of the request, unique per user; retries with the same key replay the first response"
 // @Success      200 {object} object{data=model.ContactResponse} "Successfully created contact"
 // @Failure      400 {object} object{errors=string} "Invalid request body"
 // @Failure      401 {object} object{errors=string} "Unauthorized"
@@ -40,33 +81,226 @@ func (c *ContactController) Create(ctx *fiber.Ctx) error {
 
 	request := new(model.CreateContactRequest)
 	if err := ctx.BodyParser(request); err != nil {
-		c.Log.WithError(err).Error("error parsing request body")
+		c.Log.WithContext(ctx.UserContext()).WithError(err).Error("error parsing request body")
 		return fiber.ErrBadRequest
 	}
 	request.UserId = auth.ID
 
 	response, err := c.UseCase.Create(ctx.UserContext(), request)
 	if err != nil {
-		c.Log.WithError(err).Error("error creating contact")
+		c.Log.WithContext(ctx.UserContext()).WithError(err).Error("error creating contact")
 		return err
 	}
 
+	ctx.Set(fiber.HeaderETag, contactETag(response))
 	return ctx.JSON(model.WebResponse[*model.ContactResponse]{Data: response})
 }
 
+// Export godoc
+// @Summary      Export contacts
+// @Description  Stream every contact of the authenticated user, ordered by id, as newline-delimited JSON with one contact and its addresses per line, or as CSV with one contact per row and without the addresses, cells starting with =, +, - or @ being prefixed with ' so spreadsheets do not run them as formulas, which the import removes. Contacts are read from the database in batches as they are sent, so an account of any size is exported without being held in memory
+// @Tags         contacts
+// @Produce      application/x-ndjson
+// @Produce      text/csv
+// @Security     BearerAuth
+// @Security     APIKeyAuth
+// @Param        format query string false "Export format" Enums(ndjson, csv) default(ndjson)
+// @Success      200 {object} model.ContactResponse "One contact per line, or per row after the header with format=csv"
+// @Failure      400 {object} object{errors=string} "Unsupported format"
+// @Failure      401 {object} object{errors=string} "Unauthorized"
+// @Router       /contacts/_export [get]
+func (c *ContactController) Export(ctx *fiber.Ctx) error {
+	auth := middleware.GetUser(ctx)
+	request := &model.ExportContactRequest{UserId: auth.ID}
+
+	format := ctx.Query("format", "ndjson")
+	switch format {
+	case "ndjson":
+		ctx.Set(fiber.HeaderContentType, MIMEApplicationNDJSON)
+	case "csv":
+		ctx.Set(fiber.HeaderContentType, MIMETextCSV)
+	default:
+		return fiber.NewError(fiber.StatusBadRequest, "unsupported export format "+format+", use ndjson or csv")
+	}
+
+	// the stream writer runs after the handler returned, when ctx is no longer usable
+	format = strings.Clone(format)
+	// it outlives the request timeout too, which ends with the handler; the write
+	// deadline renewed for every batch bounds it instead
+	userContext := context.WithoutCancel(ctx.UserContext())
+	encode := ctx.App().Config().JSONEncoder
+	conn := ctx.Context().Conn()
+	writeTimeout := ctx.App().Server().WriteTimeout
+
+	ctx.Set(fiber.HeaderContentDisposition, `attachment; filename="contacts.`+format+`"`)
+	ctx.Set(fiber.HeaderCacheControl, "no-store")
+	ctx.Set("X-Accel-Buffering", "no")
+
+	ctx.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		write := func(contacts []model.ContactResponse) error {
+			for i := range contacts {
+				line, err := encode(&contacts[i])
+				if err != nil {
+					return err
+				}
+				if _, err := w.Write(append(line, '\n')); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+		if format == "csv" {
+			rows := csv.NewWriter(w)
+			_ = rows.Write(contactCSVHeader)
+			write = func(contacts []model.ContactResponse) error {
+				for i := range contacts {
+					_ = rows.Write(contactCSVRow(&contacts[i]))
+				}
+				rows.Flush()
+				return rows.Error()
+			}
+		}
+
+		err := c.UseCase.Export(userContext, request, func(contacts []model.ContactResponse) error {
+			// renewed per batch, so a large export is not cut off by the server write timeout
+			if writeTimeout > 0 {
+				if err := conn.SetWriteDeadline(time.Now().Add(writeTimeout)); err != nil {
+					return err
+				}
+			}
+
+			if err := write(contacts); err != nil {
+				return err
+			}
+			return w.Flush()
+		})
+		if err != nil {
+			c.Log.WithContext(userContext).WithError(err).Warnf("Contact export of user %s ended early", request.UserId)
+		}
+	})
+
+	return nil
+}
+
+// Bulk godoc
+// @Summary      Apply contact operations in bulk
+// @Description  Create, update and delete contacts of the authenticated user in one request, e.g. to replay changes made offline. Operations run in order, each in a transaction of its own unless atomic is set, in which case either all are applied or none. The result at each index tells the outcome of the operation at that index, with the status its own endpoint would have answered; when an atomic request fails, the operations not at fault report 424
+// @Tags         contacts
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Security     APIKeyAuth
+// @Param        request body model.BulkContactRequest true "Operations, each with op create, update or delete"
+// @Param        X-Dry-Run header bool false "Run all checks, then roll back instead of committing"
+// @Param        Idempotency-Key header string false "Key of the request, unique per user; retries with the same key replay the first response"
+// @Success      200 {object} object{data=[]model.BulkContactResult} "Outcome of every operation"
+// @Failure      400 {object} object{errors=string} "No operations or too many"
+// @Failure      401 {object} object{errors=string} "Unauthorized"
+// @Failure      500 {object} object{errors=string} "Internal server error"
+// @Router       /contacts/_bulk [post]
+func (c *ContactController) Bulk(ctx *fiber.Ctx) error {
+	auth := middleware.GetUser(ctx)
+
+	request := new(model.BulkContactRequest)
+	if err := ctx.BodyParser(request); err != nil {
+		c.Log.WithContext(ctx.UserContext()).WithError(err).Error("error parsing request body")
+		return fiber.ErrBadRequest
+	}
+	request.UserId = auth.ID
+
+	results, err := c.UseCase.Bulk(ctx.UserContext(), request)
+	if err != nil {
+		c.Log.WithContext(ctx.UserContext()).WithError(err).Error("error applying bulk operations")
+		return err
+	}
+
+	return ctx.JSON(model.WebResponse[[]model.BulkContactResult]{Data: results})
+}
+
+// Suggest godoc
+// @Summary      Suggest contacts
+// @Description  Autocomplete contacts of the authenticated user whose first name, last name or any of their emails starts with q, ignoring case, with their primary email. First name matches come first, then the most recently updated contacts
+// @Tags         contacts
+// @Produce      json
+// @Security     BearerAuth
+// @Security     APIKeyAuth
+// @Param        q query string true "Typed prefix"
+// @Param        limit query int false "Number of suggestions" default(8)
+// @Success      200 {object} object{data=[]model.ContactSuggestionResponse} "Suggestions, empty when the query ran over its latency budget"
+// @Failure      400 {object} object{errors=string} "Invalid query"
+// @Failure      401 {object} object{errors=string} "Unauthorized"
+// @Failure      500 {object} object{errors=string} "Internal server error"
+// @Router       /contacts/_suggest [get]
+func (c *ContactController) Suggest(ctx *fiber.Ctx) error {
+	auth := middleware.GetUser(ctx)
+
+	request := &model.SuggestContactRequest{
+		UserId: auth.ID,
+		Query:  ctx.Query("q", ""),
+		Limit:  ctx.QueryInt("limit", 8),
+	}
+
+	responses, err := c.UseCase.Suggest(ctx.UserContext(), request)
+	if err != nil {
+		c.Log.WithContext(ctx.UserContext()).WithError(err).Error("error getting contact suggestions")
+		return err
+	}
+
+	return ctx.JSON(model.WebResponse[[]model.ContactSuggestionResponse]{Data: responses})
+}
+
+// Index godoc
+// @Summary      Get the alphabetical index
+// @Description  Count the contacts of the authenticated user per initial of their first name, from A to Z and then # for anything else, to jump to a letter with GET /contacts?letter=
+// @Tags         contacts
+// @Produce      json
+// @Security     BearerAuth
+// @Security     APIKeyAuth
+// @Success      200 {object} object{data=[]model.ContactIndexResponse} "Contact count per letter"
+// @Failure      401 {object} object{errors=string} "Unauthorized"
+// @Failure      500 {object} object{errors=string} "Internal server error"
+// @Router       /contacts/_index [get]
+func (c *ContactController) Index(ctx *fiber.Ctx) error {
+	auth := middleware.GetUser(ctx)
+
+	request := &model.ContactIndexRequest{
+		UserId: auth.ID,
+	}
+
+	responses, err := c.UseCase.Index(ctx.UserContext(), request)
+	if err != nil {
+		c.Log.WithContext(ctx.UserContext()).WithError(err).Error("error getting contact index")
+		return err
+	}
+
+	return ctx.JSON(model.WebResponse[[]model.ContactIndexResponse]{Data: responses})
+}
+
 // List godoc
 // @Summary      List contacts
-// @Description  Search and list contacts for the authenticated user with pagination
+// @Description  Search and list contacts for the authenticated user with pagination. Pass cursor, empty for the first page, to page by keyset with the next_cursor and prev_cursor of the response instead of page numbers. Filter with field[operator]=value parameters such as email[like]=gmail or created_at[gte]=2024-01-01, on id, first_name, last_name, email, phone, created_at and updated_at, with the operators eq, ne, gt, gte, lt, lte, like, prefix and suffix
 // @Tags         contacts
 // @Accept       json
 // @Produce      json
 // @Security     BearerAuth
+// @Security     APIKeyAuth
 // @Param        name query string false "Filter by name"
 // @Param        email query string false "Filter by email"
 // @Param        phone query string false "Filter by phone"
+// @Param        letter query string false "Only contacts whose first name starts with this letter, or # for any other character, sorted by name unless another order is given"
+// @Param        tag query []string false "Only contacts with the tag of this name, ignoring case; repeat for contacts with all of the tags" collectionFormat(multi)
 // @Param        page query int false "Page number" default(1)
 // @Param        size query int false "Page size" default(10)
+// @Param        cursor query string false "Cursor of the page to return, empty for the first page"
+// @Param        sort query string false "Comma separated fields to sort by, prefixed with - for descending, e.g. last_name,-created_at"
+// @Param        $filter query string false "OData filter expression, when OData support is enabled"
+// @Param        $orderby query string false "OData sort expression, when OData support is enabled"
+// @Param        $top query int false "OData page size, when OData support is enabled"
+// @Param        $skip query int false "OData number of rows to skip, when OData support is enabled"
+// @Param        $select query string false "OData comma separated fields to return, when OData support is enabled"
+// @Param        fields query string false "Comma separated fields to return; id is always returned"
 // @Success      200 {object} object{data=[]model.ContactResponse,paging=model.PageMetadata} "List of contacts with pagination"
+// @Failure      400 {object} object{errors=string} "Invalid query or cursor"
 // @Failure      401 {object} object{errors=string} "Unauthorized"
 // @Failure      500 {object} object{errors=string} "Internal server error"
 // @Router       /contacts [get]
@@ -78,21 +312,88 @@ func (c *ContactController) List(ctx *fiber.Ctx) error {
 		Name:   ctx.Query("name", ""),
 		Email:  ctx.Query("email", ""),
 		Phone:  ctx.Query("phone", ""),
+		Letter: strings.ToUpper(ctx.Query("letter", "")),
 		Page:   ctx.QueryInt("page", 1),
 		Size:   ctx.QueryInt("size", 10),
 	}
+	for _, tag := range ctx.Context().QueryArgs().PeekMulti("tag") {
+		request.Tags = append(request.Tags, string(tag))
+	}
+
+	query := middleware.GetODataQuery(ctx)
+	if query != nil {
+		request.Filter = query.Filter
+		request.Sort = query.OrderBy
+		request.Skip = query.Skip
+		if query.Top > 0 {
+			request.Page = 1
+			request.Size = query.Top
+		}
+	}
 
-	responses, total, err := c.UseCase.Search(ctx.UserContext(), request)
+	filter, err := queryparam.ParseFilter(ctx.Queries(), contactFilterFields)
 	if err != nil {
-		c.Log.WithError(err).Error("error searching contact")
-		return err
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
 	}
+	if filter != nil {
+		if request.Filter != nil {
+			filter = &model.FilterNode{Operator: model.FilterAnd, Children: []*model.FilterNode{request.Filter, filter}}
+		}
+		request.Filter = filter
+	}
+
+	if raw := ctx.Query("sort"); raw != "" {
+		if len(request.Sort) > 0 {
+			return fiber.NewError(fiber.StatusBadRequest, "sort cannot be combined with $orderby")
+		}
 
-	paging := &model.PageMetadata{
-		Page:      request.Page,
-		Size:      request.Size,
-		TotalItem: total,
-		TotalPage: int64(math.Ceil(float64(total) / float64(request.Size))),
+		sort, err := queryparam.ParseSort(raw)
+		if err != nil {
+			return fiber.NewError(fiber.StatusBadRequest, err.Error())
+		}
+		request.Sort = sort
+	}
+
+	var responses []model.ContactResponse
+	var paging *model.PageMetadata
+	if ctx.Context().QueryArgs().Has("cursor") {
+		cursor := ctx.Query("cursor")
+		request.Cursor = &cursor
+
+		var err error
+		responses, paging, err = c.UseCase.SearchByCursor(ctx.UserContext(), request)
+		if err != nil {
+			c.Log.WithContext(ctx.UserContext()).WithError(err).Error("error searching contact")
+			return err
+		}
+	} else {
+		var total int64
+		var err error
+		responses, total, err = c.UseCase.Search(ctx.UserContext(), request)
+		if err != nil {
+			c.Log.WithContext(ctx.UserContext()).WithError(err).Error("error searching contact")
+			return err
+		}
+
+		paging = &model.PageMetadata{
+			Page:      request.Page,
+			Size:      request.Size,
+			TotalItem: total,
+			TotalPage: int64(math.Ceil(float64(total) / float64(request.Size))),
+		}
+	}
+
+	if query != nil && len(query.Select) > 0 {
+		selected, err := odata.Select(responses, query.Select)
+		if err != nil {
+			c.Log.WithContext(ctx.UserContext()).WithError(err).Error("error selecting contact fields")
+			return fiber.ErrInternalServerError
+		}
+
+		return ctx.JSON(model.WebResponse[[]map[string]any]{
+			Data:   selected,
+			Paging: paging,
+		})
 	}
 
 	return ctx.JSON(model.WebResponse[[]model.ContactResponse]{
@@ -108,8 +409,12 @@ func (c *ContactController) List(ctx *fiber.Ctx) error {
 // @Accept       json
 // @Produce      json
 // @Security     BearerAuth
+// @Security     APIKeyAuth
 // @Param        contactId path string true "Contact ID"
+// @Param        If-None-Match header string false "ETag of the version held, answered with 304 while it is current"
+// @Param        fields query string false "Comma separated fields to return; id is always returned"
 // @Success      200 {object} object{data=model.ContactResponse} "Contact details"
+// @Success      304 "Not modified"
 // @Failure      401 {object} object{errors=string} "Unauthorized"
 // @Failure      404 {object} object{errors=string} "Contact not found"
 // @Failure      500 {object} object{errors=string} "Internal server error"
@@ -124,10 +429,13 @@ func (c *ContactController) Get(ctx *fiber.Ctx) error {
 
 	response, err := c.UseCase.Get(ctx.UserContext(), request)
 	if err != nil {
-		c.Log.WithError(err).Error("error getting contact")
+		c.Log.WithContext(ctx.UserContext()).WithError(err).Error("error getting contact")
 		return err
 	}
 
+	if middleware.NotModified(ctx, contactETag(response)) {
+		return ctx.SendStatus(fiber.StatusNotModified)
+	}
 	return ctx.JSON(model.WebResponse[*model.ContactResponse]{Data: response})
 }
 
@@ -138,12 +446,17 @@ func (c *ContactController) Get(ctx *fiber.Ctx) error {
 // @Accept       json
 // @Produce      json
 // @Security     BearerAuth
+// @Security     APIKeyAuth
 // @Param        contactId path string true "Contact ID"
 // @Param        request body model.UpdateContactRequest true "Contact update details"
+// @Param        X-Dry-Run header bool false "Run all checks, then roll back instead of committing"
+// @Param        If-Match header string false "ETag of the version to change, required when etag.require_if_match"
 // @Success      200 {object} object{data=model.ContactResponse} "Successfully updated contact"
 // @Failure      400 {object} object{errors=string} "Invalid request body"
 // @Failure      401 {object} object{errors=string} "Unauthorized"
 // @Failure      404 {object} object{errors=string} "Contact not found"
+// @Failure      412 {object} object{errors=string} "The version changed since it was read"
+// @Failure      428 {object} object{errors=string} "If-Match is required"
 // @Failure      500 {object} object{errors=string} "Internal server error"
 // @Router       /contacts/{contactId} [put]
 func (c *ContactController) Update(ctx *fiber.Ctx) error {
@@ -151,33 +464,94 @@ func (c *ContactController) Update(ctx *fiber.Ctx) error {
 
 	request := new(model.UpdateContactRequest)
 	if err := ctx.BodyParser(request); err != nil {
-		c.Log.WithError(err).Error("error parsing request body")
+		c.Log.WithContext(ctx.UserContext()).WithError(err).Error("error parsing request body")
 		return fiber.ErrBadRequest
 	}
 
 	request.UserId = auth.ID
 	request.ID = ctx.Params("contactId")
 
+	version, err := middleware.IfMatch(ctx)
+	if err != nil {
+		return err
+	}
+	request.Version = version
+
 	response, err := c.UseCase.Update(ctx.UserContext(), request)
 	if err != nil {
-		c.Log.WithError(err).Error("error updating contact")
+		c.Log.WithContext(ctx.UserContext()).WithError(err).Error("error updating contact")
+		return err
+	}
+
+	ctx.Set(fiber.HeaderETag, contactETag(response))
+	return ctx.JSON(model.WebResponse[*model.ContactResponse]{Data: response})
+}
+
+// Patch godoc
+// @Summary      Partially update a contact
+// @Description  Apply a JSON merge patch (RFC 7386) to a specific contact: fields left out keep their value and null clears one, e.g. {"phone": null}. email and phone replace the primary email and phone number, emails and phones all of them
+// @Tags         contacts
+// @Accept       json
+// @Accept       application/merge-patch+json
+// @Produce      json
+// @Security     BearerAuth
+// @Security     APIKeyAuth
+// @Param        contactId path string true "Contact ID"
+// @Param        request body model.PatchContactRequest true "Fields to change"
+// @Param        X-Dry-Run header bool false "Run all checks, then roll back instead of committing"
+// @Param        If-Match header string false "ETag of the version to change, required when etag.require_if_match"
+// @Success      200 {object} object{data=model.ContactResponse} "Successfully updated contact"
+// @Failure      400 {object} object{errors=string} "Invalid request body"
+// @Failure      401 {object} object{errors=string} "Unauthorized"
+// @Failure      404 {object} object{errors=string} "Contact not found"
+// @Failure      412 {object} object{errors=string} "The version changed since it was read"
+// @Failure      428 {object} object{errors=string} "If-Match is required"
+// @Failure      500 {object} object{errors=string} "Internal server error"
+// @Router       /contacts/{contactId} [patch]
+func (c *ContactController) Patch(ctx *fiber.Ctx) error {
+	auth := middleware.GetUser(ctx)
+
+	request := new(model.PatchContactRequest)
+	if err := ctx.BodyParser(request); err != nil {
+		c.Log.WithContext(ctx.UserContext()).WithError(err).Error("error parsing request body")
+		return fiber.ErrBadRequest
+	}
+
+	request.UserId = auth.ID
+	request.ID = ctx.Params("contactId")
+
+	version, err := middleware.IfMatch(ctx)
+	if err != nil {
 		return err
 	}
+	request.Version = version
 
+	response, err := c.UseCase.Patch(ctx.UserContext(), request)
+	if err != nil {
+		c.Log.WithContext(ctx.UserContext()).WithError(err).Error("error patching contact")
+		return err
+	}
+
+	ctx.Set(fiber.HeaderETag, contactETag(response))
 	return ctx.JSON(model.WebResponse[*model.ContactResponse]{Data: response})
 }
 
 // Delete godoc
 // @Summary      Delete a contact
-// @Description  Delete a specific contact by ID for the authenticated user
+// @Description  Move a specific contact of the authenticated user to the trash, from where it can be restored until it is purged
 // @Tags         contacts
 // @Accept       json
 // @Produce      json
 // @Security     BearerAuth
+// @Security     APIKeyAuth
 // @Param        contactId path string true "Contact ID"
+// @Param        X-Dry-Run header bool false "Run all checks, then roll back instead of committing"
+// @Param        If-Match header string false "ETag of the version to change, required when etag.require_if_match"
 // @Success      200 {object} object{data=bool} "Successfully deleted contact"
 // @Failure      401 {object} object{errors=string} "Unauthorized"
 // @Failure      404 {object} object{errors=string} "Contact not found"
+// @Failure      412 {object} object{errors=string} "The version changed since it was read"
+// @Failure      428 {object} object{errors=string} "If-Match is required"
 // @Failure      500 {object} object{errors=string} "Internal server error"
 // @Router       /contacts/{contactId} [delete]
 func (c *ContactController) Delete(ctx *fiber.Ctx) error {
@@ -189,8 +563,14 @@ func (c *ContactController) Delete(ctx *fiber.Ctx) error {
 		ID:     contactId,
 	}
 
+	version, err := middleware.IfMatch(ctx)
+	if err != nil {
+		return err
+	}
+	request.Version = version
+
 	if err := c.UseCase.Delete(ctx.UserContext(), request); err != nil {
-		c.Log.WithError(err).Error("error deleting contact")
+		c.Log.WithContext(ctx.UserContext()).WithError(err).Error("error deleting contact")
 		return err
 	}
 
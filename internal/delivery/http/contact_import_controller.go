@@ -0,0 +1,118 @@
+package http
+
+import (
+	"encoding/json"
+	"go-rest-scaffold/internal/delivery/http/middleware"
+	"go-rest-scaffold/internal/model"
+	"go-rest-scaffold/internal/usecase"
+	"io"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/sirupsen/logrus"
+)
+
+type ContactImportController struct {
+	UseCase *usecase.ContactImportUseCase
+	Log     *logrus.Logger
+}
+
+func NewContactImportController(useCase *usecase.ContactImportUseCase, log *logrus.Logger) *ContactImportController {
+	return &ContactImportController{
+		UseCase: useCase,
+		Log:     log,
+	}
+}
+
+// Import godoc
+// @Summary      Import contacts from CSV
+// @Description  Create a contact from every row of a UTF-8 CSV file whose header row names the columns. Columns named first_name, last_name, email or phone (case, spaces and dashes aside) are imported as that field; mapping maps other headers, and "" skips a column. Every row is imported on its own: rows that fail are reported with their line and error, and the others are imported still. Files of up to contact_import.async_rows rows are imported at once and answer 200 with the outcome; larger ones are queued and answer 202 with an import to poll at the Location header
+// @Tags         contacts
+// @Accept       multipart/form-data
+// @Produce      json
+// @Security     BearerAuth
+// @Security     APIKeyAuth
+// @Param        file formData file true "CSV file"
+// @Param        mapping formData string false "JSON object of column headers to contact fields, e.g. {\"Given Name\":\"first_name\",\"Notes\":\"\"}"
+// @Param        X-Dry-Run header bool false "Check every row, then roll back instead of importing; never queued"
+// @Param        Idempotency-Key header string false "Key of the request, unique per user; retries with the same key replay the first response"
+// @Success      200 {object} object{data=model.ContactImportResponse} "Outcome of the import"
+// @Success      202 {object} object{data=model.ContactImportResponse} "Import queued"
+// @Failure      400 {object} object{errors=string} "Missing or invalid file or mapping"
+// @Failure      401 {object} object{errors=string} "Unauthorized"
+// @Failure      413 {object} object{errors=string} "File larger than web.body_limit"
+// @Failure      500 {object} object{errors=string} "Internal server error"
+// @Router       /contacts/_import [post]
+func (c *ContactImportController) Import(ctx *fiber.Ctx) error {
+	auth := middleware.GetUser(ctx)
+
+	header, err := ctx.FormFile("file")
+	if err != nil {
+		c.Log.WithContext(ctx.UserContext()).WithError(err).Error("error reading uploaded file")
+		return fiber.NewError(fiber.StatusBadRequest, "send the CSV file as the file field of a multipart/form-data body")
+	}
+
+	file, err := header.Open()
+	if err != nil {
+		c.Log.WithContext(ctx.UserContext()).WithError(err).Error("error opening uploaded file")
+		return fiber.ErrInternalServerError
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(file)
+	if err != nil {
+		c.Log.WithContext(ctx.UserContext()).WithError(err).Error("error reading uploaded file")
+		return fiber.ErrInternalServerError
+	}
+
+	request := &model.ImportContactRequest{
+		UserId: auth.ID,
+		Data:   data,
+	}
+	if mapping := ctx.FormValue("mapping"); mapping != "" {
+		if err := json.Unmarshal([]byte(mapping), &request.Mapping); err != nil {
+			return fiber.NewError(fiber.StatusBadRequest, "mapping must be a JSON object of column headers to contact fields")
+		}
+	}
+
+	response, err := c.UseCase.Import(ctx.UserContext(), request)
+	if err != nil {
+		c.Log.WithContext(ctx.UserContext()).WithError(err).Warn("error importing contacts")
+		return err
+	}
+
+	if response.ID != "" {
+		ctx.Location(strings.TrimSuffix(ctx.Path(), "/") + "/" + response.ID)
+		return ctx.Status(fiber.StatusAccepted).JSON(model.WebResponse[*model.ContactImportResponse]{Data: response})
+	}
+	return ctx.JSON(model.WebResponse[*model.ContactImportResponse]{Data: response})
+}
+
+// Get godoc
+// @Summary      Get a contact import
+// @Description  Get the status and progress of an import queued by POST /contacts/_import, with the errors of the rows that failed so far. Finished imports are kept for contact_import.retention_days days
+// @Tags         contacts
+// @Produce      json
+// @Security     BearerAuth
+// @Security     APIKeyAuth
+// @Param        importId path string true "Import ID"
+// @Success      200 {object} object{data=model.ContactImportResponse} "Import status"
+// @Failure      401 {object} object{errors=string} "Unauthorized"
+// @Failure      404 {object} object{errors=string} "Import not found"
+// @Router       /contacts/_import/{importId} [get]
+func (c *ContactImportController) Get(ctx *fiber.Ctx) error {
+	auth := middleware.GetUser(ctx)
+
+	request := &model.GetContactImportRequest{
+		UserId: auth.ID,
+		ID:     ctx.Params("importId"),
+	}
+
+	response, err := c.UseCase.Get(ctx.UserContext(), request)
+	if err != nil {
+		c.Log.WithContext(ctx.UserContext()).WithError(err).Error("error getting contact import")
+		return err
+	}
+
+	return ctx.JSON(model.WebResponse[*model.ContactImportResponse]{Data: response})
+}
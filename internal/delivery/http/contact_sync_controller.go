@@ -0,0 +1,98 @@
+package http
+
+import (
+	"bufio"
+	"go-rest-scaffold/internal/delivery/http/middleware"
+	"go-rest-scaffold/internal/model"
+	"go-rest-scaffold/internal/usecase"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/sirupsen/logrus"
+)
+
+const MIMEApplicationNDJSON = "application/x-ndjson"
+
+type ContactSyncController struct {
+	UseCase *usecase.ContactSyncUseCase
+	Log     *logrus.Logger
+}
+
+func NewContactSyncController(useCase *usecase.ContactSyncUseCase, log *logrus.Logger) *ContactSyncController {
+	return &ContactSyncController{
+		UseCase: useCase,
+		Log:     log,
+	}
+}
+
+// Sync godoc
+// @Summary      Stream contacts for mirroring
+// @Description  Stream every contact of the authenticated user with its addresses as newline-delimited JSON, followed by a snapshot_end line and then by live upserts and deletes as they happen. The connection stays open, with heartbeat lines while nothing changes. Pass the offset of the last line received after snapshot_end to resume without a new snapshot; an offset older than the retained changes is refused with 410
+// @Tags         contacts
+// @Produce      application/x-ndjson
+// @Security     BearerAuth
+// @Security     APIKeyAuth
+// @Param        offset query int false "Resume after this offset instead of starting with a snapshot" minimum(0)
+// @Success      200 {object} model.ContactSyncEvent "One line per event"
+// @Failure      400 {object} object{errors=string} "Invalid offset"
+// @Failure      401 {object} object{errors=string} "Unauthorized"
+// @Failure      410 {object} object{errors=string} "Offset expired, start over without an offset"
+// @Failure      500 {object} object{errors=string} "Internal server error"
+// @Router       /contacts/_sync [get]
+func (c *ContactSyncController) Sync(ctx *fiber.Ctx) error {
+	auth := middleware.GetUser(ctx)
+
+	request := &model.SyncContactsRequest{UserId: auth.ID}
+	if raw := ctx.Query("offset"); raw != "" {
+		offset, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			c.Log.WithContext(ctx.UserContext()).WithError(err).Error("error parsing offset")
+			return fiber.ErrBadRequest
+		}
+		request.Resume = true
+		request.Offset = offset
+	}
+
+	cursor, err := c.UseCase.Open(ctx.UserContext(), request)
+	if err != nil {
+		c.Log.WithContext(ctx.UserContext()).WithError(err).Error("error opening contact sync")
+		return err
+	}
+
+	// the stream writer runs after the handler returned, when ctx is no longer usable
+	userContext := ctx.UserContext()
+	encode := ctx.App().Config().JSONEncoder
+	conn := ctx.Context().Conn()
+	writeTimeout := ctx.App().Server().WriteTimeout
+
+	ctx.Set(fiber.HeaderContentType, MIMEApplicationNDJSON)
+	ctx.Set(fiber.HeaderCacheControl, "no-store")
+	ctx.Set("X-Accel-Buffering", "no")
+
+	ctx.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		err := c.UseCase.Stream(userContext, cursor, func(events []model.ContactSyncEvent) error {
+			// the server write timeout would otherwise cut the stream off; renewing it per
+			// batch instead drops clients that stop reading
+			if writeTimeout > 0 {
+				if err := conn.SetWriteDeadline(time.Now().Add(writeTimeout)); err != nil {
+					return err
+				}
+			}
+
+			for _, event := range events {
+				line, err := encode(event)
+				if err != nil {
+					return err
+				}
+				if _, err := w.Write(append(line, '\n')); err != nil {
+					return err
+				}
+			}
+			return w.Flush()
+		})
+		c.Log.WithContext(ctx.UserContext()).WithError(err).Debugf("Contact sync stream of user %s ended", cursor.UserId)
+	})
+
+	return nil
+}
@@ -0,0 +1,92 @@
+package http
+
+import (
+	"go-rest-scaffold/internal/model"
+	"go-rest-scaffold/internal/usecase"
+	"math"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/sirupsen/logrus"
+)
+
+type DebugCaptureController struct {
+	Log     *logrus.Logger
+	UseCase *usecase.DebugCaptureUseCase
+}
+
+func NewDebugCaptureController(useCase *usecase.DebugCaptureUseCase, logger *logrus.Logger) *DebugCaptureController {
+	return &DebugCaptureController{
+		Log:     logger,
+		UseCase: useCase,
+	}
+}
+
+// List godoc
+// @Summary      List debug captures
+// @Description  List the unexpired request/response captures, newest first
+// @Tags         admin
+// @Produce      json
+// @Security     BearerAuth
+// @Security     APIKeyAuth
+// @Param        user_id query string false "Only captures of this user"
+// @Param        page query int false "Page number" default(1)
+// @Param        size query int false "Page size" default(10)
+// @Success      200 {object} object{data=[]model.DebugCaptureResponse,paging=model.PageMetadata} "List of captures with pagination"
+// @Failure      400 {object} object{errors=string} "Invalid query"
+// @Failure      401 {object} object{errors=string} "Unauthorized"
+// @Failure      403 {object} object{errors=string} "Not an admin"
+// @Failure      500 {object} object{errors=string} "Internal server error"
+// @Router       /admin/captures [get]
+func (c *DebugCaptureController) List(ctx *fiber.Ctx) error {
+	request := &model.SearchDebugCaptureRequest{
+		UserId: ctx.Query("user_id", ""),
+		Page:   ctx.QueryInt("page", 1),
+		Size:   ctx.QueryInt("size", 10),
+	}
+
+	responses, total, err := c.UseCase.Search(ctx.UserContext(), request)
+	if err != nil {
+		c.Log.WithContext(ctx.UserContext()).WithError(err).Error("error searching debug captures")
+		return err
+	}
+
+	paging := &model.PageMetadata{
+		Page:      request.Page,
+		Size:      request.Size,
+		TotalItem: total,
+		TotalPage: int64(math.Ceil(float64(total) / float64(request.Size))),
+	}
+
+	return ctx.JSON(model.WebResponse[[]model.DebugCaptureResponse]{
+		Data:   responses,
+		Paging: paging,
+	})
+}
+
+// Get godoc
+// @Summary      Get a debug capture
+// @Description  Get a single request/response capture by ID
+// @Tags         admin
+// @Produce      json
+// @Security     BearerAuth
+// @Security     APIKeyAuth
+// @Param        captureId path string true "Capture ID"
+// @Success      200 {object} object{data=model.DebugCaptureResponse} "Capture details"
+// @Failure      401 {object} object{errors=string} "Unauthorized"
+// @Failure      403 {object} object{errors=string} "Not an admin"
+// @Failure      404 {object} object{errors=string} "Capture not found or expired"
+// @Failure      500 {object} object{errors=string} "Internal server error"
+// @Router       /admin/captures/{captureId} [get]
+func (c *DebugCaptureController) Get(ctx *fiber.Ctx) error {
+	request := &model.GetDebugCaptureRequest{
+		ID: ctx.Params("captureId"),
+	}
+
+	response, err := c.UseCase.Get(ctx.UserContext(), request)
+	if err != nil {
+		c.Log.WithContext(ctx.UserContext()).WithError(err).Error("error getting debug capture")
+		return err
+	}
+
+	return ctx.JSON(model.WebResponse[*model.DebugCaptureResponse]{Data: response})
+}
@@ -0,0 +1,165 @@
+package http
+
+import (
+	"fmt"
+	"go-rest-scaffold/internal/buildinfo"
+	"go-rest-scaffold/internal/jwt"
+	"go-rest-scaffold/internal/model"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
+)
+
+var versionPrefix = regexp.MustCompile(`^/api/(v[0-9]+)/`)
+
+type DiscoveryController struct {
+	Log    *logrus.Logger
+	Config *viper.Viper
+	App    *fiber.App
+	// AccessTokens is the issuer of JWT access tokens, nil when they are disabled.
+	AccessTokens *jwt.Issuer
+}
+
+func NewDiscoveryController(app *fiber.App, config *viper.Viper, accessTokens *jwt.Issuer, log *logrus.Logger) *DiscoveryController {
+	return &DiscoveryController{
+		Log:          log,
+		Config:       config,
+		App:          app,
+		AccessTokens: accessTokens,
+	}
+}
+
+// SecurityTxt serves /.well-known/security.txt (RFC 9116) built from the security config block.
+func (c *DiscoveryController) SecurityTxt(ctx *fiber.Ctx) error {
+	contacts := c.Config.GetStringSlice("security.contacts")
+	if len(contacts) == 0 {
+		return fiber.ErrNotFound
+	}
+
+	var builder strings.Builder
+	for _, contact := range contacts {
+		fmt.Fprintf(&builder, "Contact: %s\n", contact)
+	}
+
+	expires := time.Now().UTC().AddDate(0, 0, c.Config.GetInt("security.expires_in_days"))
+	fmt.Fprintf(&builder, "Expires: %s\n", expires.Format(time.RFC3339))
+
+	if policy := c.Config.GetString("security.policy"); policy != "" {
+		fmt.Fprintf(&builder, "Policy: %s\n", policy)
+	}
+	if languages := c.Config.GetStringSlice("security.preferred_languages"); len(languages) > 0 {
+		fmt.Fprintf(&builder, "Preferred-Languages: %s\n", strings.Join(languages, ", "))
+	}
+
+	ctx.Set(fiber.HeaderContentType, fiber.MIMETextPlainCharsetUTF8)
+	return ctx.SendString(builder.String())
+}
+
+// JWKS serves /.well-known/jwks.json, the public keys JWT access tokens are signed
+// with, so other services can verify them without calling the API.
+func (c *DiscoveryController) JWKS(ctx *fiber.Ctx) error {
+	if c.AccessTokens == nil {
+		return fiber.ErrNotFound
+	}
+
+	// keys only change with a rotation, which trusts the previous key for a while
+	ctx.Set(fiber.HeaderCacheControl, "public, max-age=300")
+	return ctx.JSON(c.AccessTokens.JWKS())
+}
+
+// OpenIDConfiguration serves /.well-known/openid-configuration, the discovery document
+// pointing other services at the login and the JWKS of the access tokens. URLs are
+// absolute, on the address the document was requested at.
+func (c *DiscoveryController) OpenIDConfiguration(ctx *fiber.Ctx) error {
+	if c.AccessTokens == nil {
+		return fiber.ErrNotFound
+	}
+
+	ctx.Set(fiber.HeaderCacheControl, "public, max-age=300")
+	return ctx.JSON(&model.OpenIDConfigurationResponse{
+		Issuer:                           c.AccessTokens.Issuer,
+		JWKSURI:                          ctx.BaseURL() + "/.well-known/jwks.json",
+		TokenEndpoint:                    ctx.BaseURL() + "/api/users/_login",
+		GrantTypesSupported:              []string{"password", "refresh_token"},
+		ResponseTypesSupported:           []string{"token"},
+		SubjectTypesSupported:            []string{"public"},
+		IDTokenSigningAlgValuesSupported: []string{c.AccessTokens.Algorithm},
+	})
+}
+
+// Version serves /version, the build of the running binary, so a deployment can be
+// checked without reading its logs.
+func (c *DiscoveryController) Version(ctx *fiber.Ctx) error {
+	ctx.Set(fiber.HeaderCacheControl, "no-store")
+	return ctx.JSON(model.WebResponse[*model.BuildInfoResponse]{Data: &model.BuildInfoResponse{
+		Version:   buildinfo.Version,
+		Commit:    buildinfo.Commit,
+		BuildTime: buildinfo.BuildTime,
+		GoVersion: buildinfo.GoVersion,
+	}})
+}
+
+// Metadata godoc
+// @Summary      API metadata
+// @Description  List API versions, enabled capabilities and the registered endpoints of every version
+// @Tags         discovery
+// @Produce      json
+// @Success      200 {object} object{data=model.APIMetadataResponse} "API metadata"
+// @Router       /_meta [get]
+func (c *DiscoveryController) Metadata(ctx *fiber.Ctx) error {
+	response := &model.APIMetadataResponse{
+		Name:    c.Config.GetString("app.name"),
+		Version: c.Config.GetString("app.version"),
+		Capabilities: map[string]bool{
+			"odata":   c.Config.GetBool("odata.enabled"),
+			"cache":   c.Config.GetBool("cache.enabled"),
+			"sandbox": c.Config.GetBool("sandbox.enabled"),
+			"jwt":     c.AccessTokens != nil,
+		},
+	}
+
+	versions := make(map[string]bool)
+	seen := make(map[string]bool)
+	for _, route := range c.App.GetRoutes(true) {
+		if !strings.HasPrefix(route.Path, "/api/") || route.Method == fiber.MethodHead {
+			continue
+		}
+
+		key := route.Method + " " + route.Path
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+
+		response.Endpoints = append(response.Endpoints, model.EndpointResponse{Method: route.Method, Path: route.Path})
+		if match := versionPrefix.FindStringSubmatch(route.Path); match != nil {
+			versions[match[1]] = true
+		}
+	}
+
+	sort.Slice(response.Endpoints, func(i, j int) bool {
+		if response.Endpoints[i].Path == response.Endpoints[j].Path {
+			return response.Endpoints[i].Method < response.Endpoints[j].Method
+		}
+		return response.Endpoints[i].Path < response.Endpoints[j].Path
+	})
+
+	for version := range versions {
+		response.Versions = append(response.Versions, model.APIVersion{
+			Name:     version,
+			BasePath: "/api/" + version,
+			Default:  version == c.Config.GetString("api.default_version"),
+		})
+	}
+	sort.Slice(response.Versions, func(i, j int) bool { return response.Versions[i].Name < response.Versions[j].Name })
+	if len(response.Versions) == 0 {
+		response.Versions = []model.APIVersion{{Name: response.Version, BasePath: "/api", Default: true}}
+	}
+
+	return ctx.JSON(model.WebResponse[*model.APIMetadataResponse]{Data: response})
+}
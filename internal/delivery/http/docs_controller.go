@@ -0,0 +1,24 @@
+package http
+
+import (
+	"go-rest-scaffold/api"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/sirupsen/logrus"
+)
+
+type DocsController struct {
+	Log *logrus.Logger
+}
+
+func NewDocsController(log *logrus.Logger) *DocsController {
+	return &DocsController{
+		Log: log,
+	}
+}
+
+// AsyncAPI serves the AsyncAPI document describing webhook payloads, websocket events, and broker topics.
+func (c *DocsController) AsyncAPI(ctx *fiber.Ctx) error {
+	ctx.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+	return ctx.Send(api.AsyncAPI)
+}
@@ -0,0 +1,178 @@
+package http
+
+import (
+	"go-rest-scaffold/internal/model"
+	"go-rest-scaffold/internal/usecase"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/sirupsen/logrus"
+)
+
+type EmailTemplateController struct {
+	Log     *logrus.Logger
+	UseCase *usecase.EmailTemplateUseCase
+}
+
+func NewEmailTemplateController(useCase *usecase.EmailTemplateUseCase, logger *logrus.Logger) *EmailTemplateController {
+	return &EmailTemplateController{
+		Log:     logger,
+		UseCase: useCase,
+	}
+}
+
+// List godoc
+// @Summary      List email templates
+// @Description  List the template in effect for every email: the tenant's override, else the deployment-wide override, else the built-in template
+// @Tags         admin
+// @Produce      json
+// @Security     BearerAuth
+// @Security     APIKeyAuth
+// @Param        tenant query string false "Tenant whose overrides apply"
+// @Success      200 {object} object{data=[]model.EmailTemplateResponse} "Email templates in effect"
+// @Failure      400 {object} object{errors=string} "Invalid query"
+// @Failure      401 {object} object{errors=string} "Unauthorized"
+// @Failure      403 {object} object{errors=string} "Not an admin"
+// @Failure      500 {object} object{errors=string} "Internal server error"
+// @Router       /admin/email-templates [get]
+func (c *EmailTemplateController) List(ctx *fiber.Ctx) error {
+	request := &model.ListEmailTemplateRequest{
+		Tenant: ctx.Query("tenant", ""),
+	}
+
+	responses, err := c.UseCase.List(ctx.UserContext(), request)
+	if err != nil {
+		c.Log.WithContext(ctx.UserContext()).WithError(err).Error("error listing email templates")
+		return err
+	}
+
+	return ctx.JSON(model.WebResponse[[]model.EmailTemplateResponse]{Data: responses})
+}
+
+// Get godoc
+// @Summary      Get an email template
+// @Description  Get the template in effect for an email and tenant
+// @Tags         admin
+// @Produce      json
+// @Security     BearerAuth
+// @Security     APIKeyAuth
+// @Param        name path string true "Template name" Enums(verification, reset, invite, digest)
+// @Param        tenant query string false "Tenant whose overrides apply"
+// @Success      200 {object} object{data=model.EmailTemplateResponse} "Email template in effect"
+// @Failure      401 {object} object{errors=string} "Unauthorized"
+// @Failure      403 {object} object{errors=string} "Not an admin"
+// @Failure      404 {object} object{errors=string} "Unknown email template"
+// @Failure      500 {object} object{errors=string} "Internal server error"
+// @Router       /admin/email-templates/{name} [get]
+func (c *EmailTemplateController) Get(ctx *fiber.Ctx) error {
+	request := &model.GetEmailTemplateRequest{
+		Name:   ctx.Params("name"),
+		Tenant: ctx.Query("tenant", ""),
+	}
+
+	response, err := c.UseCase.Get(ctx.UserContext(), request)
+	if err != nil {
+		c.Log.WithContext(ctx.UserContext()).WithError(err).Error("error getting email template")
+		return err
+	}
+
+	return ctx.JSON(model.WebResponse[*model.EmailTemplateResponse]{Data: response})
+}
+
+// Update godoc
+// @Summary      Override an email template
+// @Description  Save an override of an email for a tenant, or for the whole deployment when tenant is empty. The override must render with the sample data of the email
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Security     APIKeyAuth
+// @Param        name path string true "Template name" Enums(verification, reset, invite, digest)
+// @Param        request body model.UpdateEmailTemplateRequest true "Template sources"
+// @Success      200 {object} object{data=model.EmailTemplateResponse} "Saved override"
+// @Failure      400 {object} object{errors=string} "Invalid request body or template"
+// @Failure      401 {object} object{errors=string} "Unauthorized"
+// @Failure      403 {object} object{errors=string} "Not an admin"
+// @Failure      404 {object} object{errors=string} "Unknown email template"
+// @Failure      500 {object} object{errors=string} "Internal server error"
+// @Router       /admin/email-templates/{name} [put]
+func (c *EmailTemplateController) Update(ctx *fiber.Ctx) error {
+	request := new(model.UpdateEmailTemplateRequest)
+	if err := ctx.BodyParser(request); err != nil {
+		c.Log.WithContext(ctx.UserContext()).WithError(err).Error("error parsing request body")
+		return fiber.ErrBadRequest
+	}
+	request.Name = ctx.Params("name")
+
+	response, err := c.UseCase.Update(ctx.UserContext(), request)
+	if err != nil {
+		c.Log.WithContext(ctx.UserContext()).WithError(err).Error("error updating email template")
+		return err
+	}
+
+	return ctx.JSON(model.WebResponse[*model.EmailTemplateResponse]{Data: response})
+}
+
+// Delete godoc
+// @Summary      Remove an email template override
+// @Description  Remove the override of an email for a tenant, falling back to the next template in line
+// @Tags         admin
+// @Produce      json
+// @Security     BearerAuth
+// @Security     APIKeyAuth
+// @Param        name path string true "Template name" Enums(verification, reset, invite, digest)
+// @Param        tenant query string false "Tenant owning the override"
+// @Success      200 {object} object{data=bool} "Successfully removed override"
+// @Failure      401 {object} object{errors=string} "Unauthorized"
+// @Failure      403 {object} object{errors=string} "Not an admin"
+// @Failure      404 {object} object{errors=string} "Override not found"
+// @Failure      500 {object} object{errors=string} "Internal server error"
+// @Router       /admin/email-templates/{name} [delete]
+func (c *EmailTemplateController) Delete(ctx *fiber.Ctx) error {
+	request := &model.DeleteEmailTemplateRequest{
+		Name:   ctx.Params("name"),
+		Tenant: ctx.Query("tenant", ""),
+	}
+
+	if err := c.UseCase.Delete(ctx.UserContext(), request); err != nil {
+		c.Log.WithContext(ctx.UserContext()).WithError(err).Error("error deleting email template")
+		return err
+	}
+
+	return ctx.JSON(model.WebResponse[bool]{Data: true})
+}
+
+// Preview godoc
+// @Summary      Preview an email
+// @Description  Render the template in effect, or a draft when subject, html and text are given, with the sample data of the email overlaid by data
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Security     APIKeyAuth
+// @Param        name path string true "Template name" Enums(verification, reset, invite, digest)
+// @Param        request body model.PreviewEmailTemplateRequest false "Tenant, draft and data"
+// @Success      200 {object} object{data=model.RenderedEmailResponse} "Rendered email"
+// @Failure      400 {object} object{errors=string} "Invalid request body or template"
+// @Failure      401 {object} object{errors=string} "Unauthorized"
+// @Failure      403 {object} object{errors=string} "Not an admin"
+// @Failure      404 {object} object{errors=string} "Unknown email template"
+// @Failure      500 {object} object{errors=string} "Internal server error"
+// @Router       /admin/email-templates/{name}/preview [post]
+func (c *EmailTemplateController) Preview(ctx *fiber.Ctx) error {
+	request := new(model.PreviewEmailTemplateRequest)
+	if len(ctx.Body()) > 0 {
+		if err := ctx.BodyParser(request); err != nil {
+			c.Log.WithContext(ctx.UserContext()).WithError(err).Error("error parsing request body")
+			return fiber.ErrBadRequest
+		}
+	}
+	request.Name = ctx.Params("name")
+
+	response, err := c.UseCase.Preview(ctx.UserContext(), request)
+	if err != nil {
+		c.Log.WithContext(ctx.UserContext()).WithError(err).Error("error previewing email template")
+		return err
+	}
+
+	return ctx.JSON(model.WebResponse[*model.RenderedEmailResponse]{Data: response})
+}
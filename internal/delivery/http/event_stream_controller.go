@@ -0,0 +1,146 @@
+package http
+
+import (
+	"bufio"
+	"fmt"
+	"go-rest-scaffold/internal/delivery/http/middleware"
+	"go-rest-scaffold/internal/gateway/realtime"
+	"go-rest-scaffold/internal/model"
+	"net"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	MIMETextEventStream = "text/event-stream"
+	HeaderLastEventID   = "Last-Event-ID"
+)
+
+// eventScopes names the scope a client needs to receive each event.
+var eventScopes = map[string]string{
+	model.EventUserRegistered:  model.ScopeAccountRead,
+	model.EventUserUpdated:     model.ScopeAccountRead,
+	model.EventUserDeleted:     model.ScopeAccountRead,
+	model.EventAccountImported: model.ScopeAccountRead,
+	model.EventContactCreated:  model.ScopeContactsRead,
+	model.EventContactUpdated:  model.ScopeContactsRead,
+	model.EventContactDeleted:  model.ScopeContactsRead,
+	model.EventContactRestored: model.ScopeContactsRead,
+	model.EventContactTagged:   model.ScopeContactsRead,
+	model.EventContactUntagged: model.ScopeContactsRead,
+	model.EventAddressCreated:  model.ScopeAddressesRead,
+	model.EventAddressUpdated:  model.ScopeAddressesRead,
+	model.EventAddressDeleted:  model.ScopeAddressesRead,
+	model.EventAddressRestored: model.ScopeAddressesRead,
+	model.EventTagCreated:      model.ScopeContactsRead,
+	model.EventTagUpdated:      model.ScopeContactsRead,
+	model.EventTagDeleted:      model.ScopeContactsRead,
+	model.EventNoteCreated:     model.ScopeContactsRead,
+	model.EventNoteUpdated:     model.ScopeContactsRead,
+	model.EventNoteDeleted:     model.ScopeContactsRead,
+	model.EventReminderDue:     model.ScopeRemindersRead,
+}
+
+type EventStreamController struct {
+	Hub *realtime.Hub
+	Log *logrus.Logger
+	// PingInterval is how often a comment is sent while nothing happens, which keeps
+	// proxies from closing the stream and notices clients that are gone.
+	PingInterval time.Duration
+}
+
+func NewEventStreamController(hub *realtime.Hub, pingInterval time.Duration, log *logrus.Logger) *EventStreamController {
+	return &EventStreamController{
+		Hub:          hub,
+		Log:          log,
+		PingInterval: pingInterval,
+	}
+}
+
+// Stream sends the domain events of the authenticated user as Server-Sent Events, each
+// with the CloudEvent ID as id, its type as event and the CloudEvent JSON as data, for
+// the scopes of the session or API key. A client sending Last-Event-ID, as EventSource
+// does when it reconnects, first receives the events it missed; when they are no
+// longer kept the stream starts with a resync event, after which it should reload.
+func (c *EventStreamController) Stream(ctx *fiber.Ctx) error {
+	auth := middleware.GetUser(ctx)
+
+	eventTypes := make([]string, 0, len(eventScopes))
+	for eventType, scope := range eventScopes {
+		if auth.HasScope(scope) {
+			eventTypes = append(eventTypes, eventType)
+		}
+	}
+	if len(eventTypes) == 0 {
+		return fiber.NewError(fiber.StatusForbidden, "missing a scope of the streamed events")
+	}
+
+	// the stream writer runs after the handler returned, when ctx is no longer usable
+	lastEventId := ctx.Get(HeaderLastEventID)
+	conn := ctx.Context().Conn()
+	shutdown := ctx.Context().Done()
+	writeTimeout := ctx.App().Server().WriteTimeout
+
+	ctx.Set(fiber.HeaderContentType, MIMETextEventStream)
+	ctx.Set(fiber.HeaderCacheControl, "no-store")
+	ctx.Set("X-Accel-Buffering", "no")
+
+	ctx.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		var client *realtime.Client
+		var missed []realtime.Message
+		found := true
+		if lastEventId == "" {
+			client = c.Hub.Connect(auth.ID, eventTypes...)
+		} else {
+			client, missed, found = c.Hub.Resume(auth.ID, lastEventId, eventTypes...)
+		}
+		defer client.Close()
+
+		err := c.send(w, conn, writeTimeout, func() {
+			if !found {
+				fmt.Fprint(w, "event: resync\ndata: {}\n\n")
+			}
+			for _, message := range missed {
+				writeEvent(w, message)
+			}
+			// an empty stream still starts, so the client sees it open
+			fmt.Fprint(w, ": connected\n\n")
+		})
+
+		ping := time.NewTicker(c.PingInterval)
+		defer ping.Stop()
+
+		for err == nil {
+			select {
+			case <-shutdown:
+				return
+			case message := <-client.Messages:
+				err = c.send(w, conn, writeTimeout, func() { writeEvent(w, message) })
+			case <-ping.C:
+				err = c.send(w, conn, writeTimeout, func() { fmt.Fprint(w, ": ping\n\n") })
+			}
+		}
+		c.Log.WithError(err).Debugf("Event stream of user %s ended", auth.ID)
+	})
+
+	return nil
+}
+
+// send writes to the stream and flushes it. The server write timeout would otherwise
+// cut the stream off; renewing it per write instead drops clients that stop reading.
+func (c *EventStreamController) send(w *bufio.Writer, conn net.Conn, writeTimeout time.Duration, write func()) error {
+	if writeTimeout > 0 {
+		if err := conn.SetWriteDeadline(time.Now().Add(writeTimeout)); err != nil {
+			return err
+		}
+	}
+
+	write()
+	return w.Flush()
+}
+
+func writeEvent(w *bufio.Writer, message realtime.Message) {
+	fmt.Fprintf(w, "id: %s\nevent: %s\ndata: %s\n\n", message.ID, message.Type, message.Payload)
+}
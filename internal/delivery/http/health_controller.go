@@ -0,0 +1,47 @@
+package http
+
+import (
+	"go-rest-scaffold/internal/model"
+	"go-rest-scaffold/internal/usecase"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/sirupsen/logrus"
+)
+
+// HealthController serves the probes of the orchestrator, outside of /api and
+// without authentication. Their answers are never cached.
+type HealthController struct {
+	Log     *logrus.Logger
+	UseCase *usecase.HealthUseCase
+}
+
+func NewHealthController(useCase *usecase.HealthUseCase, logger *logrus.Logger) *HealthController {
+	return &HealthController{
+		Log:     logger,
+		UseCase: useCase,
+	}
+}
+
+// Live answers 200 as long as the process serves requests.
+func (c *HealthController) Live(ctx *fiber.Ctx) error {
+	return c.respond(ctx, c.UseCase.Live(ctx.UserContext()))
+}
+
+// Health answers 200 when every dependency is up, 503 otherwise.
+func (c *HealthController) Health(ctx *fiber.Ctx) error {
+	return c.respond(ctx, c.UseCase.Health(ctx.UserContext()))
+}
+
+// Ready answers 200 when the instance can take traffic, 503 when a dependency is
+// down or the instance is shutting down.
+func (c *HealthController) Ready(ctx *fiber.Ctx) error {
+	return c.respond(ctx, c.UseCase.Ready(ctx.UserContext()))
+}
+
+func (c *HealthController) respond(ctx *fiber.Ctx, response *model.HealthResponse) error {
+	ctx.Set(fiber.HeaderCacheControl, "no-store")
+	if response.Status != model.HealthUp {
+		ctx.Status(fiber.StatusServiceUnavailable)
+	}
+	return ctx.JSON(response)
+}
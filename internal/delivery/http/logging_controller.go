@@ -0,0 +1,99 @@
+package http
+
+import (
+	"go-rest-scaffold/internal/model"
+	"go-rest-scaffold/internal/usecase"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/sirupsen/logrus"
+)
+
+type LoggingController struct {
+	Log     *logrus.Logger
+	UseCase *usecase.LoggingUseCase
+}
+
+func NewLoggingController(useCase *usecase.LoggingUseCase, logger *logrus.Logger) *LoggingController {
+	return &LoggingController{
+		Log:     logger,
+		UseCase: useCase,
+	}
+}
+
+// Get godoc
+// @Summary      Get log levels
+// @Description  Get the global log level and the per-component overrides
+// @Tags         admin
+// @Produce      json
+// @Security     BearerAuth
+// @Security     APIKeyAuth
+// @Success      200 {object} object{data=model.LoggingResponse} "Current log levels"
+// @Failure      401 {object} object{errors=string} "Unauthorized"
+// @Failure      403 {object} object{errors=string} "Not an admin"
+// @Router       /admin/logging [get]
+func (c *LoggingController) Get(ctx *fiber.Ctx) error {
+	response := c.UseCase.Get(ctx.UserContext())
+	return ctx.JSON(model.WebResponse[*model.LoggingResponse]{Data: response})
+}
+
+// Update godoc
+// @Summary      Change log levels
+// @Description  Change the global log level and the per-component overrides at runtime, optionally persisting them to the config file
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Security     APIKeyAuth
+// @Param        request body model.UpdateLoggingRequest true "New log levels"
+// @Success      200 {object} object{data=model.LoggingResponse} "Updated log levels"
+// @Failure      400 {object} object{errors=string} "Invalid request body"
+// @Failure      401 {object} object{errors=string} "Unauthorized"
+// @Failure      403 {object} object{errors=string} "Not an admin"
+// @Failure      500 {object} object{errors=string} "Internal server error"
+// @Router       /admin/logging [put]
+func (c *LoggingController) Update(ctx *fiber.Ctx) error {
+	request := new(model.UpdateLoggingRequest)
+	if err := ctx.BodyParser(request); err != nil {
+		c.Log.WithContext(ctx.UserContext()).Warnf("Failed to parse request body : %+v", err)
+		return fiber.ErrBadRequest
+	}
+
+	response, err := c.UseCase.Update(ctx.UserContext(), request)
+	if err != nil {
+		c.Log.WithContext(ctx.UserContext()).Warnf("Failed to update log levels : %+v", err)
+		return err
+	}
+
+	return ctx.JSON(model.WebResponse[*model.LoggingResponse]{Data: response})
+}
+
+// UpdateLevel godoc
+// @Summary      Change the log level
+// @Description  Switch the global log level at runtime, e.g. to debug during an incident, keeping the per-component overrides. It lasts until the next restart or config reload. Removed in v2, where PUT /admin/logging changes the level
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Security     APIKeyAuth
+// @Param        request body model.UpdateLogLevelRequest true "New log level"
+// @Success      200 {object} object{data=model.LoggingResponse} "Updated log levels"
+// @Failure      400 {object} object{errors=string} "Invalid request body"
+// @Failure      401 {object} object{errors=string} "Unauthorized"
+// @Failure      403 {object} object{errors=string} "Not an admin"
+// @Router       /admin/log-level [put]
+// @Deprecated
+func (c *LoggingController) UpdateLevel(ctx *fiber.Ctx) error {
+	request := new(model.UpdateLogLevelRequest)
+	if err := ctx.BodyParser(request); err != nil {
+		c.Log.WithContext(ctx.UserContext()).Warnf("Failed to parse request body : %+v", err)
+		return fiber.ErrBadRequest
+	}
+
+	response, err := c.UseCase.UpdateLevel(ctx.UserContext(), request)
+	if err != nil {
+		c.Log.WithContext(ctx.UserContext()).Warnf("Failed to update log level : %+v", err)
+		return err
+	}
+
+	return ctx.JSON(model.WebResponse[*model.LoggingResponse]{Data: response})
+}
@@ -0,0 +1,65 @@
+package http
+
+import (
+	"go-rest-scaffold/internal/delivery/http/middleware"
+	"go-rest-scaffold/internal/model"
+	"go-rest-scaffold/internal/usecase"
+	"math"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/sirupsen/logrus"
+)
+
+type LoginEventController struct {
+	Log     *logrus.Logger
+	UseCase *usecase.LoginEventUseCase
+}
+
+func NewLoginEventController(useCase *usecase.LoginEventUseCase, logger *logrus.Logger) *LoginEventController {
+	return &LoginEventController{
+		Log:     logger,
+		UseCase: useCase,
+	}
+}
+
+// List godoc
+// @Summary      List login history
+// @Description  List the attempts to log in as the authenticated user, successful or not, the latest first, so they can spot an access that was not theirs
+// @Tags         users
+// @Produce      json
+// @Security     BearerAuth
+// @Security     APIKeyAuth
+// @Param        page query int false "Page number" default(1)
+// @Param        size query int false "Page size" default(10)
+// @Success      200 {object} object{data=[]model.LoginEventResponse,paging=model.PageMetadata} "List of login events with pagination"
+// @Failure      400 {object} object{errors=string} "Invalid query"
+// @Failure      401 {object} object{errors=string} "Unauthorized"
+// @Failure      500 {object} object{errors=string} "Internal server error"
+// @Router       /users/_current/logins [get]
+func (c *LoginEventController) List(ctx *fiber.Ctx) error {
+	auth := middleware.GetUser(ctx)
+
+	request := &model.ListLoginEventRequest{
+		UserId: auth.ID,
+		Page:   ctx.QueryInt("page", 1),
+		Size:   ctx.QueryInt("size", 10),
+	}
+
+	responses, total, err := c.UseCase.List(ctx.UserContext(), request)
+	if err != nil {
+		c.Log.WithContext(ctx.UserContext()).WithError(err).Error("error listing login events")
+		return err
+	}
+
+	paging := &model.PageMetadata{
+		Page:      request.Page,
+		Size:      request.Size,
+		TotalItem: total,
+		TotalPage: int64(math.Ceil(float64(total) / float64(request.Size))),
+	}
+
+	return ctx.JSON(model.WebResponse[[]model.LoginEventResponse]{
+		Data:   responses,
+		Paging: paging,
+	})
+}
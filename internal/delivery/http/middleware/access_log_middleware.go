@@ -0,0 +1,92 @@
+package middleware
+
+import (
+	"go-rest-scaffold/internal/model"
+	"math/rand/v2"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
+)
+
+// NewAccessLog writes one entry per request to the access logger, apart from the
+// application logs and their levels. Requests answered with an error are always
+// logged, others at access_log.sample_rate (0 to 1). Paths in
+// access_log.exclude_paths are never logged; an entry ending in * excludes the
+// paths it prefixes. A nil logger turns access logging off.
+func NewAccessLog(accessLogger *logrus.Logger, config *viper.Viper) fiber.Handler {
+	sampleRate := config.GetFloat64("access_log.sample_rate")
+	excluded := make(map[string]struct{})
+	var excludedPrefixes []string
+	for _, path := range config.GetStringSlice("access_log.exclude_paths") {
+		if prefix, ok := strings.CutSuffix(path, "*"); ok {
+			excludedPrefixes = append(excludedPrefixes, prefix)
+		} else {
+			excluded[path] = struct{}{}
+		}
+	}
+
+	return func(ctx *fiber.Ctx) error {
+		if accessLogger == nil || isExcludedPath(ctx.Path(), excluded, excludedPrefixes) {
+			return ctx.Next()
+		}
+
+		// the path the client asked for, before the API version middleware routes it
+		path := strings.Clone(ctx.Path())
+		start := time.Now()
+		err := ctx.Next()
+		if err != nil {
+			// let the error handler write the response so the entry has its status
+			if handlerErr := ctx.App().ErrorHandler(ctx, err); handlerErr != nil {
+				_ = ctx.SendStatus(fiber.StatusInternalServerError)
+			}
+		}
+
+		status := ctx.Response().StatusCode()
+		if status < fiber.StatusBadRequest && (sampleRate <= 0 || rand.Float64() >= sampleRate) {
+			return nil
+		}
+
+		fields := logrus.Fields{
+			"method":        ctx.Method(),
+			"path":          path,
+			"route":         ctx.Route().Path,
+			"status":        status,
+			"latency_ms":    float64(time.Since(start).Microseconds()) / 1000,
+			"ip":            ctx.IP(),
+			"user_agent":    ctx.Get(fiber.HeaderUserAgent),
+			"request_id":    GetRequestID(ctx),
+			"request_size":  len(ctx.Request().Body()),
+			"response_size": responseSize(ctx),
+		}
+		if auth, ok := ctx.Locals("auth").(*model.Auth); ok {
+			fields["user_id"] = auth.ID
+		}
+		accessLogger.WithFields(fields).Info("access")
+
+		return nil
+	}
+}
+
+func isExcludedPath(path string, excluded map[string]struct{}, excludedPrefixes []string) bool {
+	if _, ok := excluded[path]; ok {
+		return true
+	}
+	for _, prefix := range excludedPrefixes {
+		if strings.HasPrefix(path, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// responseSize returns the size of the response body, or -1 for a stream of unknown
+// length, which cannot be measured without waiting for its end.
+func responseSize(ctx *fiber.Ctx) int {
+	if ctx.Response().IsBodyStream() {
+		return ctx.Response().Header.ContentLength()
+	}
+	return len(ctx.Response().Body())
+}
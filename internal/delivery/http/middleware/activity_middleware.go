@@ -0,0 +1,19 @@
+package middleware
+
+import (
+	"go-rest-scaffold/internal/usecase"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// NewActivity records the authenticated user as active today for the admin
+// statistics. It must run after the auth middleware.
+func NewActivity(statsUseCase *usecase.StatsUseCase) fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		if err := statsUseCase.RecordActivity(ctx.UserContext(), GetUser(ctx).ID); err != nil {
+			// statistics must never break the request they are counting
+			statsUseCase.Log.Warnf("Failed record user activity : %+v", err)
+		}
+		return ctx.Next()
+	}
+}
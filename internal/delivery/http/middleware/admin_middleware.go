@@ -0,0 +1,19 @@
+package middleware
+
+import (
+	"go-rest-scaffold/internal/model"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// NewAdmin only lets users with the admin role through, with a session or API key not
+// limited to scopes other than admin. It must run after the auth middleware.
+func NewAdmin() fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		auth := GetUser(ctx)
+		if auth.Role != model.RoleAdmin || !auth.HasScope(model.ScopeAdmin) {
+			return fiber.ErrForbidden
+		}
+		return ctx.Next()
+	}
+}
@@ -0,0 +1,108 @@
+package middleware
+
+import (
+	"net/http"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/spf13/viper"
+)
+
+// HeaderAPIVersion picks the version of a request to the unversioned /api paths, and
+// names the version that answered on every response of the API.
+const HeaderAPIVersion = "API-Version"
+
+var versionedPath = regexp.MustCompile(`^/api/(v[0-9]+)(/.*)?$`)
+
+// NewAPIVersion negotiates the version of every request to the API. Requests to
+// /api/{version} get that version; requests to the unversioned /api paths get the
+// one named by the API-Version header, or api.default_version without it, and are
+// routed to it. versions lists the versions the routes are registered for.
+func NewAPIVersion(config *viper.Viper, versions []string) fiber.Handler {
+	defaultVersion := config.GetString("api.default_version")
+
+	return func(ctx *fiber.Ctx) error {
+		path := ctx.Path()
+		if path != "/api" && !strings.HasPrefix(path, "/api/") {
+			return ctx.Next()
+		}
+
+		// the path is only valid during the request, unlike the local
+		if match := versionedPath.FindStringSubmatch(path); match != nil {
+			version := strings.Clone(match[1])
+			ctx.Locals("api_version", version)
+			ctx.Set(HeaderAPIVersion, version)
+			return ctx.Next()
+		}
+
+		// the response of an unversioned path depends on the header
+		ctx.Vary(HeaderAPIVersion)
+
+		version := defaultVersion
+		if requested := ctx.Get(HeaderAPIVersion); requested != "" {
+			version = strings.ToLower(strings.Clone(requested))
+			if !strings.HasPrefix(version, "v") {
+				version = "v" + version
+			}
+			if !slices.Contains(versions, version) {
+				return fiber.NewError(fiber.StatusBadRequest, "unsupported API version "+requested+
+					", supported are "+strings.Join(versions, ", "))
+			}
+		}
+
+		ctx.Locals("api_version", version)
+		ctx.Set(HeaderAPIVersion, version)
+		ctx.Path("/api/" + version + strings.TrimPrefix(path, "/api"))
+		return ctx.Next()
+	}
+}
+
+// GetAPIVersion returns the version negotiated for the request, empty outside the API.
+func GetAPIVersion(ctx *fiber.Ctx) string {
+	version, _ := ctx.Locals("api_version").(string)
+	return version
+}
+
+// APIPath returns the path of the request without its version, /api/contacts for
+// /api/v2/contacts, which is how the OpenAPI document and the configured path rules
+// name endpoints whatever version they are called in.
+func APIPath(ctx *fiber.Ctx) string {
+	match := versionedPath.FindStringSubmatch(ctx.Path())
+	if match == nil {
+		return ctx.Path()
+	}
+	return "/api" + match[2]
+}
+
+// NewDeprecation returns a factory of per-route middleware marking the responses of
+// an endpoint retired in a later version with the Deprecation (RFC 9745) and Sunset
+// (RFC 8594) headers and linking the endpoint replacing it, so clients migrate before
+// it is removed. The dates are api.deprecated_at and api.sunset_at; a header whose
+// date is not set is left out.
+func NewDeprecation(config *viper.Viper) func(successor string) fiber.Handler {
+	deprecation := ""
+	if deprecatedAt := config.GetTime("api.deprecated_at"); !deprecatedAt.IsZero() {
+		deprecation = "@" + strconv.FormatInt(deprecatedAt.Unix(), 10)
+	}
+
+	sunset := ""
+	if sunsetAt := config.GetTime("api.sunset_at"); !sunsetAt.IsZero() {
+		sunset = sunsetAt.UTC().Format(http.TimeFormat)
+	}
+
+	return func(successor string) fiber.Handler {
+		return func(ctx *fiber.Ctx) error {
+			if deprecation != "" {
+				ctx.Set("Deprecation", deprecation)
+			}
+			if sunset != "" {
+				ctx.Set("Sunset", sunset)
+			}
+			ctx.Append(fiber.HeaderLink, `<`+successor+`>; rel="successor-version"`)
+			return ctx.Next()
+		}
+	}
+}
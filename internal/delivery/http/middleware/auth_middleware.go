@@ -7,6 +7,9 @@ import (
 	"github.com/gofiber/fiber/v2"
 )
 
+// HeaderAPIKey carries the API key of machine clients, in place of Authorization.
+const HeaderAPIKey = "X-API-Key"
+
 func NewAuth(userUserCase *usecase.UserUseCase) fiber.Handler {
 	return func(ctx *fiber.Ctx) error {
 		request := &model.VerifyUserRequest{Token: ctx.Get("Authorization", "NOT_FOUND")}
@@ -19,9 +22,40 @@ func NewAuth(userUserCase *usecase.UserUseCase) fiber.Handler {
 		}
 
 		userUserCase.Log.Debugf("User : %+v", auth.ID)
-		ctx.Locals("auth", auth)
-		return ctx.Next()
+		return authenticated(ctx, auth)
+	}
+}
+
+// NewAPIKeyAuth is NewAuth that also accepts an API key in X-API-Key. A request with
+// the header is authenticated by the key alone, as the user owning it.
+func NewAPIKeyAuth(userUserCase *usecase.UserUseCase, apiKeyUseCase *usecase.APIKeyUseCase) fiber.Handler {
+	tokenAuth := NewAuth(userUserCase)
+
+	return func(ctx *fiber.Ctx) error {
+		key := ctx.Get(HeaderAPIKey)
+		if key == "" {
+			return tokenAuth(ctx)
+		}
+
+		auth, err := apiKeyUseCase.Verify(ctx.UserContext(), &model.VerifyAPIKeyRequest{Key: key})
+		if err != nil {
+			apiKeyUseCase.Log.Warnf("Failed find user by api key : %+v", err)
+			return fiber.ErrUnauthorized
+		}
+
+		apiKeyUseCase.Log.Debugf("User : %+v, api key : %s", auth.ID, auth.APIKeyId)
+		return authenticated(ctx, auth)
+	}
+}
+
+func authenticated(ctx *fiber.Ctx, auth *model.Auth) error {
+	ctx.Locals("auth", auth)
+	userContext := model.WithClientIP(model.WithActor(ctx.UserContext(), auth.ID), ctx.IP())
+	if auth.ImpersonatorId != "" {
+		userContext = model.WithImpersonator(userContext, auth.ImpersonatorId)
 	}
+	ctx.SetUserContext(model.WithRegion(userContext, auth.Region))
+	return ctx.Next()
 }
 
 func GetUser(ctx *fiber.Ctx) *model.Auth {
@@ -0,0 +1,62 @@
+package middleware
+
+import (
+	"fmt"
+	"go-rest-scaffold/internal/gateway/cdn"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/spf13/viper"
+)
+
+// SurrogateKeys derives the surrogate keys of a response from the request.
+type SurrogateKeys func(ctx *fiber.Ctx) []string
+
+// NewCacheControl returns a factory of per-route middleware that marks successful GET
+// responses as cacheable and tags them with surrogate keys, so the CDN can drop
+// exactly the affected responses when the purge subscriber sees a write.
+func NewCacheControl(config *viper.Viper) func(keys SurrogateKeys) fiber.Handler {
+	enabled := config.GetBool("cache.enabled")
+	maxAge := config.GetInt("cache.max_age")
+	sharedMaxAge := config.GetInt("cache.shared_max_age")
+
+	cacheControl := fmt.Sprintf("private, max-age=%d", maxAge)
+	if sharedMaxAge > 0 {
+		cacheControl = fmt.Sprintf("public, max-age=%d, s-maxage=%d", maxAge, sharedMaxAge)
+	}
+
+	return func(keys SurrogateKeys) fiber.Handler {
+		return func(ctx *fiber.Ctx) error {
+			if err := ctx.Next(); err != nil {
+				return err
+			}
+
+			if !enabled || ctx.Method() != fiber.MethodGet || ctx.Response().StatusCode() != fiber.StatusOK {
+				return nil
+			}
+
+			tags := keys(ctx)
+			ctx.Set(fiber.HeaderCacheControl, cacheControl)
+			// responses belong to the owner of the token or key, so a shared cache must key on them
+			ctx.Vary(fiber.HeaderAuthorization, HeaderAPIKey)
+			ctx.Set("Surrogate-Key", strings.Join(tags, " "))
+			ctx.Set("Cache-Tag", strings.Join(tags, ","))
+			if sharedMaxAge > 0 {
+				ctx.Set("Surrogate-Control", fmt.Sprintf("max-age=%d", sharedMaxAge))
+			}
+			return nil
+		}
+	}
+}
+
+func CurrentUserKeys(ctx *fiber.Ctx) []string {
+	return []string{cdn.UserKey(GetUser(ctx).ID)}
+}
+
+func ContactListKeys(ctx *fiber.Ctx) []string {
+	return []string{cdn.ContactsKey(GetUser(ctx).ID)}
+}
+
+func ContactKeys(ctx *fiber.Ctx) []string {
+	return []string{cdn.ContactKey(ctx.Params("contactId"))}
+}
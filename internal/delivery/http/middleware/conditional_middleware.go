@@ -0,0 +1,72 @@
+package middleware
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// ETag is the entity tag of a contact or address at version. The version moves with
+// every change, so the tag of a response tells which change it shows.
+func ETag(version int64) string {
+	return `"` + strconv.FormatInt(version, 10) + `"`
+}
+
+// SignedETag is the entity tag of a version whose response carries URLs signed until
+// expiresAt, such as a contact with an avatar. The tag changes when the URLs are
+// signed anew, so a client revalidating gets fresh URLs instead of 304. If-Match
+// only compares the version.
+func SignedETag(version int64, expiresAt int64) string {
+	return `"` + strconv.FormatInt(version, 10) + "." + strconv.FormatInt(expiresAt, 10) + `"`
+}
+
+// NotModified tags the response with tag, the ETag of what it shows, and reports
+// whether the client has it already, per If-None-Match, so the controller answers
+// 304 without a body.
+func NotModified(ctx *fiber.Ctx, tag string) bool {
+	ctx.Set(fiber.HeaderETag, tag)
+
+	for _, candidate := range strings.Split(ctx.Get(fiber.HeaderIfNoneMatch), ",") {
+		// If-None-Match compares weakly, so W/ tags of proxies match too
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == "*" || candidate == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// IfMatch returns the version the If-Match header requires the entity to be at, 0
+// when it accepts any. Tags this API did not hand out, weak ones included, can never
+// match and fail with 412.
+func IfMatch(ctx *fiber.Ctx) (int64, error) {
+	tag := strings.TrimSpace(ctx.Get(fiber.HeaderIfMatch))
+	if tag == "" || tag == "*" {
+		return 0, nil
+	}
+
+	unquoted, ok := strings.CutPrefix(tag, `"`)
+	if ok {
+		unquoted, ok = strings.CutSuffix(unquoted, `"`)
+	}
+	// the signing window of a SignedETag does not make another version
+	unquoted, _, _ = strings.Cut(unquoted, ".")
+	version, err := strconv.ParseInt(unquoted, 10, 64)
+	if !ok || err != nil || version <= 0 {
+		return 0, fiber.NewError(fiber.StatusPreconditionFailed, "If-Match must be the ETag of a version, e.g. \"3\"")
+	}
+	return version, nil
+}
+
+// NewRequireIfMatch refuses changes without an If-Match header with 428 when enabled,
+// so a client cannot overwrite a change it has not seen. Clients read the ETag from a
+// GET first.
+func NewRequireIfMatch(enabled bool) fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		if enabled && ctx.Get(fiber.HeaderIfMatch) == "" {
+			return fiber.NewError(fiber.StatusPreconditionRequired, "If-Match is required, send the ETag of the version to change")
+		}
+		return ctx.Next()
+	}
+}
@@ -0,0 +1,79 @@
+package middleware
+
+import (
+	"context"
+	"go-rest-scaffold/internal/model"
+	"go-rest-scaffold/internal/usecase"
+	"math/rand/v2"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/spf13/viper"
+)
+
+// NewDebugCapture records the full exchange of a sampled share of requests
+// (debug_capture.sample_rate, 0 to 1) and of every request made by the users in
+// debug_capture.user_ids. Captures are redacted and stored in the background, so
+// the request never waits for them. It must run after the auth middleware.
+func NewDebugCapture(debugCaptureUseCase *usecase.DebugCaptureUseCase, config *viper.Viper) fiber.Handler {
+	enabled := config.GetBool("debug_capture.enabled")
+	sampleRate := config.GetFloat64("debug_capture.sample_rate")
+	users := make(map[string]struct{})
+	for _, id := range config.GetStringSlice("debug_capture.user_ids") {
+		users[id] = struct{}{}
+	}
+
+	return func(ctx *fiber.Ctx) error {
+		// captures of the admin endpoints would only contain other captures
+		if !enabled || strings.HasPrefix(APIPath(ctx), "/api/admin/") {
+			return ctx.Next()
+		}
+
+		auth := GetUser(ctx)
+		if _, ok := users[auth.ID]; !ok && (sampleRate <= 0 || rand.Float64() >= sampleRate) {
+			return ctx.Next()
+		}
+
+		start := time.Now()
+		err := ctx.Next()
+		if err != nil {
+			// let the error handler write the response so the capture sees it
+			if handlerErr := ctx.App().ErrorHandler(ctx, err); handlerErr != nil {
+				_ = ctx.SendStatus(fiber.StatusInternalServerError)
+			}
+		}
+
+		request := &model.RecordDebugCaptureRequest{
+			UserId:          auth.ID,
+			Method:          ctx.Method(),
+			Path:            ctx.Path(),
+			Query:           string(ctx.Request().URI().QueryString()),
+			Status:          ctx.Response().StatusCode(),
+			DurationMs:      time.Since(start).Milliseconds(),
+			RequestHeaders:  make(map[string]string),
+			RequestBody:     append([]byte(nil), ctx.Body()...),
+			RequestType:     ctx.Get(fiber.HeaderContentType),
+			ResponseHeaders: make(map[string]string),
+			ResponseType:    string(ctx.Response().Header.ContentType()),
+		}
+		// reading a streamed body would wait for the whole stream, which may never end
+		if !ctx.Response().IsBodyStream() {
+			request.ResponseBody = append([]byte(nil), ctx.Response().Body()...)
+		}
+		ctx.Request().Header.VisitAll(func(key, value []byte) {
+			request.RequestHeaders[string(key)] = string(value)
+		})
+		ctx.Response().Header.VisitAll(func(key, value []byte) {
+			request.ResponseHeaders[string(key)] = string(value)
+		})
+
+		go func() {
+			if err := debugCaptureUseCase.Record(context.Background(), request); err != nil {
+				debugCaptureUseCase.Log.Warnf("Failed record debug capture : %+v", err)
+			}
+		}()
+
+		return nil
+	}
+}
@@ -0,0 +1,33 @@
+package middleware
+
+import (
+	"go-rest-scaffold/internal/model"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+const HeaderDryRun = "X-Dry-Run"
+
+// NewDryRun turns a write request carrying "X-Dry-Run: true" or "?dry_run=true" into a
+// dry run: use cases run every validation and business check, then roll back instead
+// of committing. The response echoes X-Dry-Run when the request was actually rolled back.
+func NewDryRun() fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		if ctx.Method() == fiber.MethodGet || ctx.Method() == fiber.MethodHead || ctx.Method() == fiber.MethodOptions {
+			return ctx.Next()
+		}
+
+		if ctx.Get(HeaderDryRun) != "true" && ctx.Query("dry_run") != "true" {
+			return ctx.Next()
+		}
+
+		userContext, dryRun := model.WithDryRun(ctx.UserContext())
+		ctx.SetUserContext(userContext)
+
+		err := ctx.Next()
+		if dryRun.RolledBack() {
+			ctx.Set(HeaderDryRun, "true")
+		}
+		return err
+	}
+}
@@ -0,0 +1,51 @@
+package middleware
+
+import (
+	"go-rest-scaffold/internal/model"
+	"go-rest-scaffold/internal/usecase"
+	"sort"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// NewExperiments assigns the authenticated user to every enabled experiment and
+// reports the assignments in the X-Experiments header as "key=variant" pairs.
+func NewExperiments(experimentUseCase *usecase.ExperimentUseCase) fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		if len(experimentUseCase.Experiments) == 0 {
+			return ctx.Next()
+		}
+
+		experiments, err := experimentUseCase.Assign(ctx.UserContext(), GetUser(ctx).ID)
+		if err != nil {
+			// an experiment must never break the request it is observing
+			experimentUseCase.Log.Warnf("Failed assign experiments : %+v", err)
+			experiments = model.Experiments{}
+		}
+
+		ctx.Locals("experiments", experiments)
+		if len(experiments) > 0 {
+			ctx.Set("X-Experiments", formatExperiments(experiments))
+		}
+		return ctx.Next()
+	}
+}
+
+// GetExperiments returns the experiment assignments of the current request.
+func GetExperiments(ctx *fiber.Ctx) model.Experiments {
+	experiments, ok := ctx.Locals("experiments").(model.Experiments)
+	if !ok {
+		return model.Experiments{}
+	}
+	return experiments
+}
+
+func formatExperiments(experiments model.Experiments) string {
+	pairs := make([]string, 0, len(experiments))
+	for key, variant := range experiments {
+		pairs = append(pairs, key+"="+variant)
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ", ")
+}
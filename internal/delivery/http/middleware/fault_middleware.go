@@ -0,0 +1,80 @@
+package middleware
+
+import (
+	"go-rest-scaffold/internal/model"
+	"math/rand/v2"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
+)
+
+// NewFaultInjection injects latency, error responses and dropped connections into
+// the routes listed in fault_injection.rules, so client retry and backoff behavior
+// can be exercised in staging. It does nothing unless fault_injection.enabled is set.
+func NewFaultInjection(config *viper.Viper, log *logrus.Logger) fiber.Handler {
+	var rules []model.FaultRule
+	if err := config.UnmarshalKey("fault_injection.rules", &rules); err != nil {
+		log.Fatalf("invalid fault injection rules: %v", err)
+	}
+
+	if !config.GetBool("fault_injection.enabled") || len(rules) == 0 {
+		return func(ctx *fiber.Ctx) error {
+			return ctx.Next()
+		}
+	}
+
+	log.Warnf("Fault injection is enabled with %d rules", len(rules))
+
+	return func(ctx *fiber.Ctx) error {
+		rule := matchFaultRule(rules, ctx.Method(), APIPath(ctx))
+		if rule == nil {
+			return ctx.Next()
+		}
+
+		if rule.DropProbability > 0 && rand.Float64() < rule.DropProbability {
+			log.Debugf("Fault injection dropped connection for %s %s", ctx.Method(), ctx.Path())
+			ctx.Context().HijackSetNoResponse(true)
+			ctx.Context().Hijack(func(conn net.Conn) {
+				_ = conn.Close()
+			})
+			return nil
+		}
+
+		if rule.LatencyMs > 0 && rand.Float64() < rule.LatencyProbability {
+			ctx.Append("X-Fault-Injected", "latency")
+			select {
+			case <-ctx.UserContext().Done():
+			case <-time.After(time.Duration(rule.LatencyMs) * time.Millisecond):
+			}
+		}
+
+		if rule.ErrorStatus > 0 && rand.Float64() < rule.ErrorProbability {
+			ctx.Append("X-Fault-Injected", "error")
+			return fiber.NewError(rule.ErrorStatus, "injected fault")
+		}
+
+		return ctx.Next()
+	}
+}
+
+func matchFaultRule(rules []model.FaultRule, method string, path string) *model.FaultRule {
+	for i := range rules {
+		rule := &rules[i]
+		if rule.Method != "" && !strings.EqualFold(rule.Method, method) {
+			continue
+		}
+
+		if prefix, ok := strings.CutSuffix(rule.Path, "*"); ok {
+			if strings.HasPrefix(path, prefix) {
+				return rule
+			}
+		} else if rule.Path == path {
+			return rule
+		}
+	}
+	return nil
+}
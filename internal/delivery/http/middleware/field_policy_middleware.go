@@ -0,0 +1,74 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"go-rest-scaffold/internal/delivery/http/openapi"
+	"go-rest-scaffold/internal/model"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/sirupsen/logrus"
+)
+
+// FieldPolicy maps a role to the fields it may not see per response definition,
+// e.g. {"user": {"model.ContactResponse": ["phone"]}}.
+type FieldPolicy map[string]map[string][]string
+
+// NewFieldPolicy strips the fields hidden from the caller's role out of successful
+// JSON responses once the controller has written them. Objects are matched by the
+// definition the OpenAPI document gives them, so a hidden contact field disappears
+// from single contacts, lists and contacts nested in other responses alike, and no
+// controller has to know about the policy. It must run after the auth middleware.
+func NewFieldPolicy(document *openapi.Document, policy FieldPolicy, log *logrus.Logger) fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		if err := ctx.Next(); err != nil {
+			return err
+		}
+
+		auth, _ := ctx.Locals("auth").(*model.Auth)
+		if auth == nil || len(policy[auth.Role]) == 0 {
+			return nil
+		}
+
+		status := ctx.Response().StatusCode()
+		contentType := ctx.Response().Header.ContentType()
+		if status < 200 || status >= 300 || !bytes.HasPrefix(contentType, []byte(fiber.MIMEApplicationJSON)) {
+			return nil
+		}
+
+		operation, _ := document.Find(ctx.Method(), APIPath(ctx))
+		if operation == nil {
+			return nil
+		}
+
+		decoder := json.NewDecoder(bytes.NewReader(ctx.Response().Body()))
+		decoder.UseNumber()
+
+		var body any
+		if err := decoder.Decode(&body); err != nil {
+			return nil
+		}
+
+		hidden := policy[auth.Role]
+		removed := false
+		document.WalkResponse(operation, status, body, func(definition string, object map[string]any) {
+			for _, field := range hidden[definition] {
+				if _, ok := object[field]; ok {
+					delete(object, field)
+					removed = true
+				}
+			}
+		})
+		if !removed {
+			return nil
+		}
+
+		filtered, err := json.Marshal(body)
+		if err != nil {
+			log.WithError(err).Error("failed to encode filtered response")
+			return fiber.ErrInternalServerError
+		}
+		ctx.Response().SetBody(filtered)
+		return nil
+	}
+}
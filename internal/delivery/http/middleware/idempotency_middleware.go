@@ -0,0 +1,83 @@
+package middleware
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"go-rest-scaffold/internal/gateway/idempotency"
+	"go-rest-scaffold/internal/model"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/spf13/viper"
+)
+
+const (
+	HeaderIdempotencyKey = "Idempotency-Key"
+	// HeaderIdempotentReplayed marks a response replayed from the first attempt.
+	HeaderIdempotentReplayed = "Idempotent-Replayed"
+)
+
+// maxIdempotencyKeyLength is as long as a key may be; UUIDs are the usual choice.
+const maxIdempotencyKeyLength = 255
+
+// NewIdempotency makes a create endpoint safe to retry: the response of a request
+// with an Idempotency-Key header is kept for idempotency.ttl seconds and replayed to
+// retries with the same key, which create nothing. Keys belong to the user, and a key
+// reused for a different method, path or body is refused with 422, as is a retry
+// while the first attempt is running with 409 for up to idempotency.lock_timeout
+// seconds. Server errors are not kept, so they can be retried. Requests without the
+// header and dry runs pass as before. It must run after the auth middleware.
+func NewIdempotency(store *idempotency.Store, config *viper.Viper) fiber.Handler {
+	ttl := time.Duration(config.GetInt("idempotency.ttl")) * time.Second
+	lockTimeout := time.Duration(config.GetInt("idempotency.lock_timeout")) * time.Second
+
+	return func(ctx *fiber.Ctx) error {
+		key := ctx.Get(HeaderIdempotencyKey)
+		if key == "" || ttl <= 0 || model.IsDryRun(ctx.UserContext()) {
+			return ctx.Next()
+		}
+		if len(key) > maxIdempotencyKeyLength {
+			return fiber.NewError(fiber.StatusBadRequest, "Idempotency-Key must not be longer than 255 characters")
+		}
+
+		hash := sha256.New()
+		hash.Write([]byte(ctx.Method() + " " + ctx.Path() + "\n"))
+		hash.Write(ctx.Body())
+		fingerprint := hex.EncodeToString(hash.Sum(nil))
+
+		key = GetUser(ctx).ID + ":" + key
+		if record := store.Claim(ctx.UserContext(), key, fingerprint, lockTimeout); record != nil {
+			if record.Fingerprint != fingerprint {
+				return fiber.NewError(fiber.StatusUnprocessableEntity, "Idempotency-Key was already used for a different request")
+			}
+			if record.Response == nil {
+				return fiber.NewError(fiber.StatusConflict, "a request with this Idempotency-Key is still being processed")
+			}
+
+			ctx.Set(HeaderIdempotentReplayed, "true")
+			ctx.Set(fiber.HeaderContentType, record.Response.ContentType)
+			return ctx.Status(record.Response.Status).Send(record.Response.Body)
+		}
+
+		err := ctx.Next()
+		if err != nil {
+			// let the error handler write the response so a refusal is replayed too
+			if handlerErr := ctx.App().ErrorHandler(ctx, err); handlerErr != nil {
+				_ = ctx.SendStatus(fiber.StatusInternalServerError)
+			}
+		}
+
+		status := ctx.Response().StatusCode()
+		if status >= fiber.StatusInternalServerError {
+			store.Release(ctx.UserContext(), key)
+			return nil
+		}
+
+		store.Complete(ctx.UserContext(), key, fingerprint, &idempotency.Response{
+			Status:      status,
+			ContentType: string(ctx.Response().Header.ContentType()),
+			Body:        append([]byte(nil), ctx.Response().Body()...),
+		}, ttl)
+		return nil
+	}
+}
@@ -0,0 +1,24 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// NewMetricsToken returns a middleware serving handler to the scrapers sending token
+// as their bearer token, and passing the other requests on to the auth and admin
+// middlewares, so admins can read the metrics as well. An empty token is never
+// accepted, leaving the metrics to admins only.
+func NewMetricsToken(token string) func(handler fiber.Handler) fiber.Handler {
+	return func(handler fiber.Handler) fiber.Handler {
+		return func(ctx *fiber.Ctx) error {
+			bearer, found := strings.CutPrefix(ctx.Get(fiber.HeaderAuthorization), "Bearer ")
+			if token != "" && found && subtle.ConstantTimeCompare([]byte(bearer), []byte(token)) == 1 {
+				return handler(ctx)
+			}
+			return ctx.Next()
+		}
+	}
+}
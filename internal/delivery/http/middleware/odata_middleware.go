@@ -0,0 +1,33 @@
+package middleware
+
+import (
+	"go-rest-scaffold/internal/delivery/http/odata"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// NewOData parses OData system query options into the request locals. When
+// disabled the options are ignored and list endpoints behave as usual.
+func NewOData(enabled bool) fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		if !enabled {
+			return ctx.Next()
+		}
+
+		query, err := odata.Parse(func(key string) string { return ctx.Query(key) })
+		if err != nil {
+			return fiber.NewError(fiber.StatusBadRequest, err.Error())
+		}
+
+		if query.HasOptions() {
+			ctx.Locals("odata", query)
+		}
+		return ctx.Next()
+	}
+}
+
+// GetODataQuery returns the parsed OData options, or nil when none were given.
+func GetODataQuery(ctx *fiber.Ctx) *odata.Query {
+	query, _ := ctx.Locals("odata").(*odata.Query)
+	return query
+}
@@ -0,0 +1,49 @@
+package middleware
+
+import (
+	"go-rest-scaffold/internal/delivery/http/openapi"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// NewOpenAPIValidation checks the path, query, header and body parameters of every
+// request the document describes before any controller runs. Violations are answered
+// with the same 400 shape as the validator errors of the use cases. Requests outside
+// the document fall through untouched, so routing still decides on 404 and 405.
+func NewOpenAPIValidation(document *openapi.Document, enabled bool) fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		if !enabled {
+			return ctx.Next()
+		}
+
+		operation, params := document.Find(ctx.Method(), APIPath(ctx))
+		if operation == nil {
+			return ctx.Next()
+		}
+
+		violations := document.Validate(operation, openapi.Request{
+			PathParams: params,
+			Query:      func(key string) string { return ctx.Query(key) },
+			Header:     func(key string) string { return ctx.Get(key) },
+			Body:       ctx.Body(),
+		})
+		if len(violations) == 0 {
+			return ctx.Next()
+		}
+
+		messages := make([]string, len(violations))
+		fields := make(map[string]string, len(violations))
+		for i, violation := range violations {
+			messages[i] = violation.Message
+			if _, ok := fields[violation.Field]; !ok {
+				fields[violation.Field] = violation.Message
+			}
+		}
+
+		return ctx.Status(fiber.StatusBadRequest).JSON(WithRequestID(ctx, fiber.Map{
+			"errors": strings.Join(messages, "; "),
+			"fields": fields,
+		}))
+	}
+}
@@ -0,0 +1,23 @@
+package middleware
+
+import (
+	"github.com/gofiber/fiber/v2"
+)
+
+// QueryAccessToken carries the token of browsers on the endpoints they cannot send
+// headers to, WebSockets and EventSource.
+const QueryAccessToken = "access_token"
+
+// NewQueryToken moves an access_token query parameter to Authorization, unless the
+// request has one, so the auth middleware reads it. The parameter is removed from the
+// query so the token is not recorded with it. It must run before the auth middleware.
+func NewQueryToken() fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		args := ctx.Request().URI().QueryArgs()
+		if token := args.Peek(QueryAccessToken); len(token) > 0 && ctx.Get(fiber.HeaderAuthorization) == "" {
+			ctx.Request().Header.SetBytesV(fiber.HeaderAuthorization, token)
+		}
+		args.Del(QueryAccessToken)
+		return ctx.Next()
+	}
+}
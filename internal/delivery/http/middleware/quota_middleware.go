@@ -0,0 +1,25 @@
+package middleware
+
+import (
+	"go-rest-scaffold/internal/usecase"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// NewQuota meters every authenticated request against the daily quotas of its user
+// and API key and refuses it with 429 once one is used up, with Retry-After set to
+// the next UTC midnight, when the quotas restart. It must run after the auth
+// middleware.
+func NewQuota(usageUseCase *usecase.UsageUseCase) fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		if err := usageUseCase.Record(ctx.UserContext(), GetUser(ctx)); err != nil {
+			now := time.Now().UTC()
+			midnight := now.Truncate(24 * time.Hour).Add(24 * time.Hour)
+			ctx.Set(fiber.HeaderRetryAfter, strconv.FormatInt(int64(midnight.Sub(now).Seconds())+1, 10))
+			return err
+		}
+		return ctx.Next()
+	}
+}
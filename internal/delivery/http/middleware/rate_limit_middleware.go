@@ -0,0 +1,97 @@
+package middleware
+
+import (
+	"go-rest-scaffold/internal/gateway/ratelimit"
+	"go-rest-scaffold/internal/metrics"
+	"go-rest-scaffold/internal/model"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
+)
+
+// RateLimitKey derives the client a request is counted against.
+type RateLimitKey func(ctx *fiber.Ctx) string
+
+// NewRateLimit returns a factory of middleware enforcing the rate_limit.<policy>.limit
+// budget per rate_limit.<policy>.window seconds, rate_limit.window unless the policy
+// sets its own. Budgets are token buckets, so a client may burst up to the limit and
+// then keeps to it per window. Every response carries the RateLimit-* and
+// X-RateLimit-* headers of the policy that ran last, and rejected requests get a 429
+// with Retry-After.
+func NewRateLimit(limiter *ratelimit.Limiter, config *viper.Viper, log *logrus.Logger) func(policy string, key RateLimitKey) fiber.Handler {
+	enabled := config.GetBool("rate_limit.enabled")
+
+	return func(policy string, key RateLimitKey) fiber.Handler {
+		limit := config.GetInt("rate_limit." + policy + ".limit")
+		window := time.Duration(config.GetInt("rate_limit.window")) * time.Second
+		if config.IsSet("rate_limit." + policy + ".window") {
+			window = time.Duration(config.GetInt("rate_limit."+policy+".window")) * time.Second
+		}
+
+		return func(ctx *fiber.Ctx) error {
+			if !enabled || limit <= 0 || window <= 0 {
+				return ctx.Next()
+			}
+
+			result, err := limiter.Take(ctx.UserContext(), policy+":"+key(ctx), limit, window)
+			if err != nil {
+				log.WithError(err).Warnf("Failed to count request against rate limit policy %s", policy)
+			}
+
+			reset := int64(math.Ceil(result.Reset.Seconds()))
+			ctx.Set("RateLimit-Limit", strconv.Itoa(result.Limit))
+			ctx.Set("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+			ctx.Set("RateLimit-Reset", strconv.FormatInt(reset, 10))
+			// the X- headers older clients read, with the reset as a Unix time
+			ctx.Set("X-RateLimit-Limit", strconv.Itoa(result.Limit))
+			ctx.Set("X-RateLimit-Remaining", strconv.Itoa(result.Remaining))
+			ctx.Set("X-RateLimit-Reset", strconv.FormatInt(time.Now().Unix()+reset, 10))
+			ctx.Locals("rate_limit", &model.RateLimitResponse{
+				Enabled:   true,
+				Policy:    policy,
+				Limit:     result.Limit,
+				Remaining: result.Remaining,
+				Reset:     reset,
+				Window:    int64(window.Seconds()),
+			})
+
+			if !result.Allowed {
+				metrics.RateLimitRejections.WithLabelValues(policy).Inc()
+				ctx.Set(fiber.HeaderRetryAfter, strconv.FormatInt(reset, 10))
+				return fiber.NewError(fiber.StatusTooManyRequests, "rate limit exceeded")
+			}
+
+			return ctx.Next()
+		}
+	}
+}
+
+func ClientIPKey(ctx *fiber.Ctx) string {
+	return ctx.IP()
+}
+
+func CurrentUserIDKey(ctx *fiber.Ctx) string {
+	return GetUser(ctx).ID
+}
+
+// UserOrIPKey counts a request against its user once authenticated and against its
+// client IP before, so route groups open to guests can share a policy.
+func UserOrIPKey(ctx *fiber.Ctx) string {
+	if auth, ok := ctx.Locals("auth").(*model.Auth); ok && auth != nil {
+		return "user:" + auth.ID
+	}
+	return "ip:" + ctx.IP()
+}
+
+// GetRateLimit returns the budget left after the current request, or a disabled
+// response when no rate limit policy applied to it.
+func GetRateLimit(ctx *fiber.Ctx) *model.RateLimitResponse {
+	if response, ok := ctx.Locals("rate_limit").(*model.RateLimitResponse); ok {
+		return response
+	}
+	return &model.RateLimitResponse{}
+}
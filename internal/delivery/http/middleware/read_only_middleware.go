@@ -0,0 +1,49 @@
+package middleware
+
+import (
+	"go-rest-scaffold/internal/gateway/readonly"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// CodeReadOnly is the machine-readable code of writes refused in read-only mode.
+const CodeReadOnly = "read_only"
+
+// readOnlyPath is the admin endpoint that turns read-only mode off again, so it is
+// the one write that is never refused.
+const readOnlyPath = "/api/admin/read-only"
+
+// NewReadOnly refuses every write request with 503 while read-only mode is on, so a
+// long-running migration can hold its locks without taking reads down too. Clients
+// tell it apart from other outages by the code read_only and retry after retryAfter
+// seconds.
+func NewReadOnly(readOnlySwitch *readonly.Switch, retryAfter int) fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		if ctx.Method() == fiber.MethodGet || ctx.Method() == fiber.MethodHead || ctx.Method() == fiber.MethodOptions {
+			return ctx.Next()
+		}
+
+		if APIPath(ctx) == readOnlyPath {
+			return ctx.Next()
+		}
+
+		state := readOnlySwitch.State(ctx.UserContext())
+		if !state.Enabled {
+			return ctx.Next()
+		}
+
+		message := "the service is read-only"
+		if state.Reason != "" {
+			message += ": " + state.Reason
+		}
+
+		if retryAfter > 0 {
+			ctx.Set(fiber.HeaderRetryAfter, strconv.Itoa(retryAfter))
+		}
+		return ctx.Status(fiber.StatusServiceUnavailable).JSON(WithRequestID(ctx, fiber.Map{
+			"errors": message,
+			"code":   CodeReadOnly,
+		}))
+	}
+}
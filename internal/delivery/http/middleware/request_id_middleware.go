@@ -0,0 +1,57 @@
+package middleware
+
+import (
+	"go-rest-scaffold/internal/model"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// maxRequestIDLength bounds the request IDs taken from callers.
+const maxRequestIDLength = 128
+
+// NewRequestID gives every request an ID: the X-Request-ID of the caller, such as a
+// load balancer, or a new UUID when there is none or it is unusable. The ID is sent
+// back in X-Request-ID, carried by the user context so log entries of the request
+// include it, and added to error responses so users can report errors by it.
+func NewRequestID() fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		requestId := ctx.Get(fiber.HeaderXRequestID)
+		if !validRequestID(requestId) {
+			requestId = uuid.NewString()
+		}
+
+		ctx.Locals("request_id", requestId)
+		ctx.Set(fiber.HeaderXRequestID, requestId)
+		ctx.SetUserContext(model.WithRequestID(ctx.UserContext(), requestId))
+		return ctx.Next()
+	}
+}
+
+// GetRequestID returns the ID of the request, or "" before NewRequestID ran.
+func GetRequestID(ctx *fiber.Ctx) string {
+	requestId, _ := ctx.Locals("request_id").(string)
+	return requestId
+}
+
+// WithRequestID adds the request ID to the body of an error response.
+func WithRequestID(ctx *fiber.Ctx, body fiber.Map) fiber.Map {
+	if requestId := GetRequestID(ctx); requestId != "" {
+		body["request_id"] = requestId
+	}
+	return body
+}
+
+// validRequestID accepts printable ASCII without spaces, so an ID cannot break the
+// header it is echoed in or the log lines it is written to.
+func validRequestID(requestId string) bool {
+	if requestId == "" || len(requestId) > maxRequestIDLength {
+		return false
+	}
+	for i := 0; i < len(requestId); i++ {
+		if requestId[i] < '!' || requestId[i] > '~' {
+			return false
+		}
+	}
+	return true
+}
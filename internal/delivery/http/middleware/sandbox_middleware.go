@@ -0,0 +1,53 @@
+package middleware
+
+import (
+	"go-rest-scaffold/internal/usecase"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// NewSandbox guards a public demo deployment: it caps how many contacts and addresses
+// a user can create, refuses account and contact imports and avatar uploads, and keeps
+// the shared demo account from being modified. It must run after the auth middleware.
+func NewSandbox(sandboxUseCase *usecase.SandboxUseCase, enabled bool) fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		if !enabled {
+			return ctx.Next()
+		}
+
+		ctx.Set("X-Sandbox", "true")
+
+		auth := GetUser(ctx)
+		path := APIPath(ctx)
+		if auth.ID == sandboxUseCase.Options.UserId && ctx.Method() == fiber.MethodPatch && path == "/api/users/_current" {
+			return fiber.NewError(fiber.StatusForbidden, "the sandbox demo account cannot be modified")
+		}
+		if ctx.Method() == fiber.MethodPut && strings.HasPrefix(path, "/api/contacts/") && strings.HasSuffix(path, "/avatar") {
+			// anyone could host pictures on the demo, and resets would leave them in the storage
+			return fiber.NewError(fiber.StatusForbidden, "avatar uploads are disabled in the sandbox")
+		}
+
+		if ctx.Method() != fiber.MethodPost {
+			return ctx.Next()
+		}
+
+		var err error
+		switch {
+		case path == "/api/contacts":
+			err = sandboxUseCase.CheckContactQuota(ctx.UserContext(), auth.ID)
+		case strings.HasPrefix(path, "/api/contacts/") && strings.HasSuffix(path, "/addresses"):
+			err = sandboxUseCase.CheckAddressQuota(ctx.UserContext(), auth.ID)
+		case path == "/api/users/_current/_import":
+			// an archive would go around both quotas at once
+			err = fiber.NewError(fiber.StatusForbidden, "account imports are disabled in the sandbox")
+		case path == "/api/contacts/_import":
+			err = fiber.NewError(fiber.StatusForbidden, "contact imports are disabled in the sandbox")
+		}
+		if err != nil {
+			return err
+		}
+
+		return ctx.Next()
+	}
+}
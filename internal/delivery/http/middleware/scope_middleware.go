@@ -0,0 +1,16 @@
+package middleware
+
+import (
+	"github.com/gofiber/fiber/v2"
+)
+
+// RequireScope only lets requests through whose session or API key may act within scope;
+// ones not limited to scopes always pass. It must run after the auth middleware.
+func RequireScope(scope string) fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		if !GetUser(ctx).HasScope(scope) {
+			return fiber.NewError(fiber.StatusForbidden, "missing scope "+scope)
+		}
+		return ctx.Next()
+	}
+}
@@ -0,0 +1,42 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/spf13/viper"
+)
+
+// NewTimeout returns a factory of middleware giving requests timeout.<group> seconds:
+// the context handed to the use cases is cancelled then, which aborts their queries,
+// and a request that failed for it is answered with 408. A group applied to a route
+// replaces the one applied to every route, so slow routes may take longer than the
+// default rather than less. Timeouts cannot stop code that ignores its context.
+func NewTimeout(config *viper.Viper) func(group string) fiber.Handler {
+	return func(group string) fiber.Handler {
+		timeout := time.Duration(config.GetInt("timeout."+group)) * time.Second
+
+		return func(ctx *fiber.Ctx) error {
+			// detached from the deadline of a timeout that ran before, keeping its values
+			parent := context.WithoutCancel(ctx.UserContext())
+			if timeout <= 0 {
+				ctx.SetUserContext(parent)
+				return ctx.Next()
+			}
+
+			userContext, cancel := context.WithTimeout(parent, timeout)
+			defer cancel()
+			ctx.SetUserContext(userContext)
+
+			err := ctx.Next()
+			// a response written in time stands, even when the deadline passed since
+			if errors.Is(ctx.UserContext().Err(), context.DeadlineExceeded) &&
+				(err != nil || ctx.Response().StatusCode() >= fiber.StatusInternalServerError) {
+				return fiber.NewError(fiber.StatusRequestTimeout, "request took longer than "+timeout.String())
+			}
+			return err
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package middleware
+
+import (
+	"go-rest-scaffold/internal/tracing"
+
+	"github.com/gofiber/fiber/v2"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// NewTracing starts a server span for every request, continuing the trace of the
+// traceparent header when the caller sent one, and hands the span down through the
+// user context so use cases and queries become its children. When disabled the
+// request is not traced.
+func NewTracing(enabled bool) fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		if !enabled {
+			return ctx.Next()
+		}
+
+		parent := otel.GetTextMapPropagator().Extract(ctx.UserContext(), headerCarrier{ctx: ctx})
+		spanCtx, span := tracing.Start(parent, ctx.Method(), trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				attribute.String("http.request.method", ctx.Method()),
+				attribute.String("url.path", ctx.Path()),
+				attribute.String("client.address", ctx.IP()),
+				attribute.String("user_agent.original", ctx.Get(fiber.HeaderUserAgent)),
+			))
+		defer span.End()
+		ctx.SetUserContext(spanCtx)
+
+		err := ctx.Next()
+		if err != nil {
+			span.RecordError(err)
+			// let the error handler write the response so the span sees its status
+			if handlerErr := ctx.App().ErrorHandler(ctx, err); handlerErr != nil {
+				_ = ctx.SendStatus(fiber.StatusInternalServerError)
+			}
+		}
+
+		// the route is only known once the router matched it
+		span.SetName(ctx.Method() + " " + ctx.Route().Path)
+		status := ctx.Response().StatusCode()
+		span.SetAttributes(attribute.String("http.route", ctx.Route().Path), attribute.Int("http.response.status_code", status))
+		if status >= fiber.StatusInternalServerError {
+			span.SetStatus(codes.Error, "")
+		}
+		return nil
+	}
+}
+
+// headerCarrier reads and writes trace context in the request headers.
+type headerCarrier struct {
+	ctx *fiber.Ctx
+}
+
+func (c headerCarrier) Get(key string) string {
+	return c.ctx.Get(key)
+}
+
+func (c headerCarrier) Set(key string, value string) {
+	c.ctx.Request().Header.Set(key, value)
+}
+
+func (c headerCarrier) Keys() []string {
+	keys := make([]string, 0)
+	for key := range c.ctx.GetReqHeaders() {
+		keys = append(keys, key)
+	}
+	return keys
+}
@@ -0,0 +1,211 @@
+package http
+
+import (
+	"go-rest-scaffold/internal/delivery/http/middleware"
+	"go-rest-scaffold/internal/model"
+	"go-rest-scaffold/internal/usecase"
+	"math"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/sirupsen/logrus"
+)
+
+type NoteController struct {
+	UseCase *usecase.NoteUseCase
+	Log     *logrus.Logger
+}
+
+func NewNoteController(useCase *usecase.NoteUseCase, log *logrus.Logger) *NoteController {
+	return &NoteController{
+		UseCase: useCase,
+		Log:     log,
+	}
+}
+
+// Create godoc
+// @Summary      Create a note
+// @Description  Add a free text note to a contact, such as the history of a call
+// @Tags         notes
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Security     APIKeyAuth
+// @Param        contactId path string true "Contact ID"
+// @Param        request body model.CreateNoteRequest true "Note details"
+// @Param        X-Dry-Run header bool false "Run all checks, then roll back instead of committing"
+// @Param        Idempotency-Key header string false "Key of the request, unique per user; retries with the same key replay the first response"
+// @Success      200 {object} object{data=model.NoteResponse} "Successfully created note"
+// @Failure      400 {object} object{errors=string} "Invalid request body"
+// @Failure      401 {object} object{errors=string} "Unauthorized"
+// @Failure      404 {object} object{errors=string} "Contact not found"
+// @Failure      500 {object} object{errors=string} "Internal server error"
+// @Router       /contacts/{contactId}/notes [post]
+func (c *NoteController) Create(ctx *fiber.Ctx) error {
+	auth := middleware.GetUser(ctx)
+
+	request := new(model.CreateNoteRequest)
+	if err := ctx.BodyParser(request); err != nil {
+		c.Log.WithContext(ctx.UserContext()).WithError(err).Error("failed to parse request body")
+		return fiber.ErrBadRequest
+	}
+	request.UserId = auth.ID
+	request.ContactId = ctx.Params("contactId")
+
+	response, err := c.UseCase.Create(ctx.UserContext(), request)
+	if err != nil {
+		c.Log.WithContext(ctx.UserContext()).WithError(err).Error("failed to create note")
+		return err
+	}
+
+	return ctx.JSON(model.WebResponse[*model.NoteResponse]{Data: response})
+}
+
+// List godoc
+// @Summary      List notes
+// @Description  List the notes of a contact, newest first
+// @Tags         notes
+// @Produce      json
+// @Security     BearerAuth
+// @Security     APIKeyAuth
+// @Param        contactId path string true "Contact ID"
+// @Param        page query int false "Page number" default(1)
+// @Param        size query int false "Page size" default(10)
+// @Param        fields query string false "Comma separated fields to return; id is always returned"
+// @Success      200 {object} object{data=[]model.NoteResponse,paging=model.PageMetadata} "List of notes with pagination"
+// @Failure      400 {object} object{errors=string} "Invalid query"
+// @Failure      401 {object} object{errors=string} "Unauthorized"
+// @Failure      404 {object} object{errors=string} "Contact not found"
+// @Failure      500 {object} object{errors=string} "Internal server error"
+// @Router       /contacts/{contactId}/notes [get]
+func (c *NoteController) List(ctx *fiber.Ctx) error {
+	auth := middleware.GetUser(ctx)
+
+	request := &model.SearchNoteRequest{
+		UserId:    auth.ID,
+		ContactId: ctx.Params("contactId"),
+		Page:      ctx.QueryInt("page", 1),
+		Size:      ctx.QueryInt("size", 10),
+	}
+
+	responses, total, err := c.UseCase.Search(ctx.UserContext(), request)
+	if err != nil {
+		c.Log.WithContext(ctx.UserContext()).WithError(err).Error("failed to search notes")
+		return err
+	}
+
+	paging := &model.PageMetadata{
+		Page:      request.Page,
+		Size:      request.Size,
+		TotalItem: total,
+		TotalPage: int64(math.Ceil(float64(total) / float64(request.Size))),
+	}
+
+	return ctx.JSON(model.WebResponse[[]model.NoteResponse]{
+		Data:   responses,
+		Paging: paging,
+	})
+}
+
+// Get godoc
+// @Summary      Get a note
+// @Description  Get a note of a contact by ID
+// @Tags         notes
+// @Produce      json
+// @Security     BearerAuth
+// @Security     APIKeyAuth
+// @Param        contactId path string true "Contact ID"
+// @Param        noteId path string true "Note ID"
+// @Param        fields query string false "Comma separated fields to return; id is always returned"
+// @Success      200 {object} object{data=model.NoteResponse} "Note details"
+// @Failure      401 {object} object{errors=string} "Unauthorized"
+// @Failure      404 {object} object{errors=string} "Note not found"
+// @Failure      500 {object} object{errors=string} "Internal server error"
+// @Router       /contacts/{contactId}/notes/{noteId} [get]
+func (c *NoteController) Get(ctx *fiber.Ctx) error {
+	auth := middleware.GetUser(ctx)
+
+	request := &model.GetNoteRequest{
+		UserId:    auth.ID,
+		ContactId: ctx.Params("contactId"),
+		ID:        ctx.Params("noteId"),
+	}
+
+	response, err := c.UseCase.Get(ctx.UserContext(), request)
+	if err != nil {
+		c.Log.WithContext(ctx.UserContext()).WithError(err).Error("failed to get note")
+		return err
+	}
+
+	return ctx.JSON(model.WebResponse[*model.NoteResponse]{Data: response})
+}
+
+// Update godoc
+// @Summary      Update a note
+// @Description  Replace the text of a note of a contact
+// @Tags         notes
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Security     APIKeyAuth
+// @Param        contactId path string true "Contact ID"
+// @Param        noteId path string true "Note ID"
+// @Param        request body model.UpdateNoteRequest true "Note details"
+// @Param        X-Dry-Run header bool false "Run all checks, then roll back instead of committing"
+// @Success      200 {object} object{data=model.NoteResponse} "Successfully updated note"
+// @Failure      400 {object} object{errors=string} "Invalid request body"
+// @Failure      401 {object} object{errors=string} "Unauthorized"
+// @Failure      404 {object} object{errors=string} "Note not found"
+// @Failure      500 {object} object{errors=string} "Internal server error"
+// @Router       /contacts/{contactId}/notes/{noteId} [put]
+func (c *NoteController) Update(ctx *fiber.Ctx) error {
+	auth := middleware.GetUser(ctx)
+
+	request := new(model.UpdateNoteRequest)
+	if err := ctx.BodyParser(request); err != nil {
+		c.Log.WithContext(ctx.UserContext()).WithError(err).Error("failed to parse request body")
+		return fiber.ErrBadRequest
+	}
+	request.UserId = auth.ID
+	request.ContactId = ctx.Params("contactId")
+	request.ID = ctx.Params("noteId")
+
+	response, err := c.UseCase.Update(ctx.UserContext(), request)
+	if err != nil {
+		c.Log.WithContext(ctx.UserContext()).WithError(err).Error("failed to update note")
+		return err
+	}
+
+	return ctx.JSON(model.WebResponse[*model.NoteResponse]{Data: response})
+}
+
+// Delete godoc
+// @Summary      Delete a note
+// @Description  Delete a note of a contact for good
+// @Tags         notes
+// @Produce      json
+// @Security     BearerAuth
+// @Security     APIKeyAuth
+// @Param        contactId path string true "Contact ID"
+// @Param        noteId path string true "Note ID"
+// @Param        X-Dry-Run header bool false "Run all checks, then roll back instead of committing"
+// @Success      200 {object} object{data=bool} "Successfully deleted note"
+// @Failure      401 {object} object{errors=string} "Unauthorized"
+// @Failure      404 {object} object{errors=string} "Note not found"
+// @Failure      500 {object} object{errors=string} "Internal server error"
+// @Router       /contacts/{contactId}/notes/{noteId} [delete]
+func (c *NoteController) Delete(ctx *fiber.Ctx) error {
+	auth := middleware.GetUser(ctx)
+
+	request := &model.DeleteNoteRequest{
+		UserId:    auth.ID,
+		ContactId: ctx.Params("contactId"),
+		ID:        ctx.Params("noteId"),
+	}
+
+	if err := c.UseCase.Delete(ctx.UserContext(), request); err != nil {
+		c.Log.WithContext(ctx.UserContext()).WithError(err).Error("failed to delete note")
+		return err
+	}
+
+	return ctx.JSON(model.WebResponse[bool]{Data: true})
+}
@@ -0,0 +1,291 @@
+package odata
+
+import (
+	"fmt"
+	"go-rest-scaffold/internal/model"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+var comparisonOperators = map[string]string{
+	"eq": model.FilterEq,
+	"ne": model.FilterNe,
+	"gt": model.FilterGt,
+	"ge": model.FilterGe,
+	"lt": model.FilterLt,
+	"le": model.FilterLe,
+}
+
+var functionOperators = map[string]string{
+	"contains":   model.FilterContains,
+	"startswith": model.FilterStartsWith,
+	"endswith":   model.FilterEndsWith,
+}
+
+type tokenKind int
+
+const (
+	tokenIdent tokenKind = iota
+	tokenString
+	tokenNumber
+	tokenOpenParen
+	tokenCloseParen
+	tokenComma
+	tokenEOF
+)
+
+type token struct {
+	kind  tokenKind
+	text  string
+	value any
+}
+
+// ParseFilter parses an OData $filter expression such as
+// "contains(first_name,'jo') and created_at ge 1700000000000".
+func ParseFilter(raw string) (*model.FilterNode, error) {
+	tokens, err := tokenize(raw)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &parser{tokens: tokens}
+	node, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+
+	if p.peek().kind != tokenEOF {
+		return nil, fmt.Errorf("unexpected %q", p.peek().text)
+	}
+
+	return node, nil
+}
+
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	t := p.tokens[p.pos]
+	if t.kind != tokenEOF {
+		p.pos++
+	}
+	return t
+}
+
+func (p *parser) isKeyword(keyword string) bool {
+	t := p.peek()
+	return t.kind == tokenIdent && strings.EqualFold(t.text, keyword)
+}
+
+func (p *parser) expect(kind tokenKind, description string) (token, error) {
+	t := p.next()
+	if t.kind != kind {
+		return t, fmt.Errorf("expected %s but found %q", description, t.text)
+	}
+	return t, nil
+}
+
+func (p *parser) parseOr() (*model.FilterNode, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.isKeyword("or") {
+		p.next()
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = &model.FilterNode{Operator: model.FilterOr, Children: []*model.FilterNode{left, right}}
+	}
+
+	return left, nil
+}
+
+func (p *parser) parseAnd() (*model.FilterNode, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.isKeyword("and") {
+		p.next()
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = &model.FilterNode{Operator: model.FilterAnd, Children: []*model.FilterNode{left, right}}
+	}
+
+	return left, nil
+}
+
+func (p *parser) parseUnary() (*model.FilterNode, error) {
+	if p.isKeyword("not") {
+		p.next()
+		child, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &model.FilterNode{Operator: model.FilterNot, Children: []*model.FilterNode{child}}, nil
+	}
+
+	return p.parsePrimary()
+}
+
+func (p *parser) parsePrimary() (*model.FilterNode, error) {
+	t := p.next()
+
+	switch t.kind {
+	case tokenOpenParen:
+		node, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if _, err := p.expect(tokenCloseParen, "')'"); err != nil {
+			return nil, err
+		}
+		return node, nil
+	case tokenIdent:
+		if operator, ok := functionOperators[strings.ToLower(t.text)]; ok && p.peek().kind == tokenOpenParen {
+			return p.parseFunction(operator)
+		}
+
+		operatorToken, err := p.expect(tokenIdent, "comparison operator")
+		if err != nil {
+			return nil, err
+		}
+		operator, ok := comparisonOperators[strings.ToLower(operatorToken.text)]
+		if !ok {
+			return nil, fmt.Errorf("unsupported operator %q", operatorToken.text)
+		}
+
+		value, err := p.parseLiteral()
+		if err != nil {
+			return nil, err
+		}
+		return &model.FilterNode{Operator: operator, Field: t.text, Value: value}, nil
+	default:
+		return nil, fmt.Errorf("unexpected %q", t.text)
+	}
+}
+
+func (p *parser) parseFunction(operator string) (*model.FilterNode, error) {
+	p.next() // (
+
+	field, err := p.expect(tokenIdent, "field name")
+	if err != nil {
+		return nil, err
+	}
+	if _, err := p.expect(tokenComma, "','"); err != nil {
+		return nil, err
+	}
+	value, err := p.expect(tokenString, "string literal")
+	if err != nil {
+		return nil, err
+	}
+	if _, err := p.expect(tokenCloseParen, "')'"); err != nil {
+		return nil, err
+	}
+
+	return &model.FilterNode{Operator: operator, Field: field.text, Value: value.value}, nil
+}
+
+func (p *parser) parseLiteral() (any, error) {
+	t := p.next()
+
+	switch t.kind {
+	case tokenString, tokenNumber:
+		return t.value, nil
+	case tokenIdent:
+		switch strings.ToLower(t.text) {
+		case "true":
+			return true, nil
+		case "false":
+			return false, nil
+		case "null":
+			return nil, nil
+		}
+	}
+
+	return nil, fmt.Errorf("expected literal but found %q", t.text)
+}
+
+func tokenize(raw string) ([]token, error) {
+	var tokens []token
+	runes := []rune(raw)
+
+	for i := 0; i < len(runes); {
+		r := runes[i]
+
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '(':
+			tokens = append(tokens, token{kind: tokenOpenParen, text: "("})
+			i++
+		case r == ')':
+			tokens = append(tokens, token{kind: tokenCloseParen, text: ")"})
+			i++
+		case r == ',':
+			tokens = append(tokens, token{kind: tokenComma, text: ","})
+			i++
+		case r == '\'':
+			var builder strings.Builder
+			i++
+			closed := false
+			for i < len(runes) {
+				if runes[i] == '\'' {
+					// a doubled quote is an escaped quote
+					if i+1 < len(runes) && runes[i+1] == '\'' {
+						builder.WriteRune('\'')
+						i += 2
+						continue
+					}
+					closed = true
+					i++
+					break
+				}
+				builder.WriteRune(runes[i])
+				i++
+			}
+			if !closed {
+				return nil, fmt.Errorf("unterminated string literal")
+			}
+			tokens = append(tokens, token{kind: tokenString, text: builder.String(), value: builder.String()})
+		case r == '-' || unicode.IsDigit(r):
+			start := i
+			i++
+			for i < len(runes) && (unicode.IsDigit(runes[i]) || runes[i] == '.') {
+				i++
+			}
+			text := string(runes[start:i])
+			if value, err := strconv.ParseInt(text, 10, 64); err == nil {
+				tokens = append(tokens, token{kind: tokenNumber, text: text, value: value})
+			} else if value, err := strconv.ParseFloat(text, 64); err == nil {
+				tokens = append(tokens, token{kind: tokenNumber, text: text, value: value})
+			} else {
+				return nil, fmt.Errorf("invalid number %q", text)
+			}
+		case unicode.IsLetter(r) || r == '_':
+			start := i
+			for i < len(runes) && (unicode.IsLetter(runes[i]) || unicode.IsDigit(runes[i]) || runes[i] == '_') {
+				i++
+			}
+			text := string(runes[start:i])
+			tokens = append(tokens, token{kind: tokenIdent, text: text})
+		default:
+			return nil, fmt.Errorf("unexpected character %q", r)
+		}
+	}
+
+	return append(tokens, token{kind: tokenEOF, text: "end of expression"}), nil
+}
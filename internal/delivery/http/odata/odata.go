@@ -0,0 +1,89 @@
+// Package odata parses the subset of OData system query options supported by list endpoints:
+// $filter, $orderby, $top, $skip and $select.
+package odata
+
+import (
+	"fmt"
+	"go-rest-scaffold/internal/model"
+	"strconv"
+	"strings"
+)
+
+type Query struct {
+	Filter  *model.FilterNode
+	OrderBy []model.SortField
+	Top     int
+	Skip    int
+	Select  []string
+}
+
+// HasOptions reports whether any OData option was present in the request.
+func (q *Query) HasOptions() bool {
+	return q.Filter != nil || len(q.OrderBy) > 0 || q.Top > 0 || q.Skip > 0 || len(q.Select) > 0
+}
+
+// Parse builds a Query from the raw query string values, looking up each option with get.
+func Parse(get func(key string) string) (*Query, error) {
+	query := new(Query)
+
+	if raw := strings.TrimSpace(get("$filter")); raw != "" {
+		filter, err := ParseFilter(raw)
+		if err != nil {
+			return nil, fmt.Errorf("invalid $filter: %w", err)
+		}
+		query.Filter = filter
+	}
+
+	if raw := strings.TrimSpace(get("$orderby")); raw != "" {
+		orderBy, err := ParseOrderBy(raw)
+		if err != nil {
+			return nil, fmt.Errorf("invalid $orderby: %w", err)
+		}
+		query.OrderBy = orderBy
+	}
+
+	if raw := strings.TrimSpace(get("$top")); raw != "" {
+		top, err := strconv.Atoi(raw)
+		if err != nil || top < 1 {
+			return nil, fmt.Errorf("invalid $top: must be a positive integer")
+		}
+		query.Top = top
+	}
+
+	if raw := strings.TrimSpace(get("$skip")); raw != "" {
+		skip, err := strconv.Atoi(raw)
+		if err != nil || skip < 0 {
+			return nil, fmt.Errorf("invalid $skip: must be a non-negative integer")
+		}
+		query.Skip = skip
+	}
+
+	if raw := strings.TrimSpace(get("$select")); raw != "" {
+		for _, field := range strings.Split(raw, ",") {
+			if field = strings.TrimSpace(field); field != "" {
+				query.Select = append(query.Select, field)
+			}
+		}
+	}
+
+	return query, nil
+}
+
+// ParseOrderBy parses a comma separated list of "field [asc|desc]" items.
+func ParseOrderBy(raw string) ([]model.SortField, error) {
+	var fields []model.SortField
+	for _, item := range strings.Split(raw, ",") {
+		parts := strings.Fields(item)
+		switch {
+		case len(parts) == 1:
+			fields = append(fields, model.SortField{Field: parts[0]})
+		case len(parts) == 2 && strings.EqualFold(parts[1], "asc"):
+			fields = append(fields, model.SortField{Field: parts[0]})
+		case len(parts) == 2 && strings.EqualFold(parts[1], "desc"):
+			fields = append(fields, model.SortField{Field: parts[0], Desc: true})
+		default:
+			return nil, fmt.Errorf("unexpected %q", strings.TrimSpace(item))
+		}
+	}
+	return fields, nil
+}
@@ -0,0 +1,27 @@
+package odata
+
+import "encoding/json"
+
+// Select projects every item onto the requested JSON fields, as asked for by $select.
+func Select[T any](items []T, fields []string) ([]map[string]any, error) {
+	projected := make([]map[string]any, len(items))
+	for i, item := range items {
+		bytes, err := json.Marshal(item)
+		if err != nil {
+			return nil, err
+		}
+
+		full := make(map[string]any)
+		if err := json.Unmarshal(bytes, &full); err != nil {
+			return nil, err
+		}
+
+		projected[i] = make(map[string]any, len(fields))
+		for _, field := range fields {
+			if value, ok := full[field]; ok {
+				projected[i][field] = value
+			}
+		}
+	}
+	return projected, nil
+}
@@ -0,0 +1,156 @@
+// Package openapi validates incoming requests against the Swagger 2.0 document
+// generated by swag and served under /swagger, so the published contract and
+// what the API accepts cannot drift apart. The same document types response
+// bodies for the field policy.
+package openapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Schema is the subset of a Swagger schema object the validator understands.
+type Schema struct {
+	Ref         string             `json:"$ref,omitempty"`
+	Type        string             `json:"type,omitempty"`
+	Required    []string           `json:"required,omitempty"`
+	Properties  map[string]*Schema `json:"properties,omitempty"`
+	Items       *Schema            `json:"items,omitempty"`
+	AllOf       []*Schema          `json:"allOf,omitempty"`
+	Enum        []any              `json:"enum,omitempty"`
+	MinLength   *int               `json:"minLength,omitempty"`
+	MaxLength   *int               `json:"maxLength,omitempty"`
+	Minimum     *float64           `json:"minimum,omitempty"`
+	Maximum     *float64           `json:"maximum,omitempty"`
+	MinItems    *int               `json:"minItems,omitempty"`
+	MaxItems    *int               `json:"maxItems,omitempty"`
+	UniqueItems bool               `json:"uniqueItems,omitempty"`
+}
+
+// Parameter is a path, query, header or body parameter of an operation. Non-body
+// parameters describe their type inline, body parameters through BodySchema.
+type Parameter struct {
+	Schema
+	Name             string  `json:"name"`
+	In               string  `json:"in"`
+	Required         bool    `json:"required,omitempty"`
+	CollectionFormat string  `json:"collectionFormat,omitempty"`
+	BodySchema       *Schema `json:"schema,omitempty"`
+}
+
+type Operation struct {
+	Parameters []*Parameter         `json:"parameters,omitempty"`
+	Responses  map[string]*Response `json:"responses,omitempty"`
+}
+
+type Response struct {
+	Schema *Schema `json:"schema,omitempty"`
+}
+
+type Document struct {
+	BasePath    string                           `json:"basePath"`
+	Paths       map[string]map[string]*Operation `json:"paths"`
+	Definitions map[string]*Schema               `json:"definitions"`
+
+	routes []route
+}
+
+type route struct {
+	segments   []string
+	operations map[string]*Operation
+}
+
+// Parse reads a Swagger 2.0 document.
+func Parse(raw []byte) (*Document, error) {
+	document := new(Document)
+	if err := json.Unmarshal(raw, document); err != nil {
+		return nil, fmt.Errorf("invalid document: %w", err)
+	}
+
+	basePath := strings.TrimSuffix(document.BasePath, "/")
+	for path, operations := range document.Paths {
+		byMethod := make(map[string]*Operation, len(operations))
+		for method, operation := range operations {
+			byMethod[strings.ToUpper(method)] = operation
+		}
+		document.routes = append(document.routes, route{
+			segments:   splitPath(basePath + path),
+			operations: byMethod,
+		})
+	}
+
+	return document, nil
+}
+
+// Find returns the operation serving method and path together with the values of
+// its path parameters, or nil when the document does not describe the request.
+// Literal segments win over templated ones, so /contacts/_index is not mistaken
+// for /contacts/{contactId}.
+func (d *Document) Find(method string, path string) (*Operation, map[string]string) {
+	segments := splitPath(path)
+
+	var found *Operation
+	var foundParams map[string]string
+	bestLiterals := -1
+
+	for _, route := range d.routes {
+		operation, ok := route.operations[method]
+		if !ok || len(route.segments) != len(segments) {
+			continue
+		}
+
+		params, literals, ok := route.match(segments)
+		if ok && literals > bestLiterals {
+			found, foundParams, bestLiterals = operation, params, literals
+		}
+	}
+
+	return found, foundParams
+}
+
+func (r route) match(segments []string) (map[string]string, int, bool) {
+	params := make(map[string]string)
+	literals := 0
+
+	for i, segment := range r.segments {
+		if strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}") {
+			params[segment[1:len(segment)-1]] = segments[i]
+			continue
+		}
+		if segment != segments[i] {
+			return nil, 0, false
+		}
+		literals++
+	}
+
+	return params, literals, true
+}
+
+// resolve follows $ref and single allOf wrappers until it reaches a concrete schema.
+func (d *Document) resolve(schema *Schema) *Schema {
+	schema, _ = d.resolveNamed(schema)
+	return schema
+}
+
+// resolveNamed is resolve that also returns the name of the last definition
+// followed, or "" for an inline schema.
+func (d *Document) resolveNamed(schema *Schema) (*Schema, string) {
+	name := ""
+	for i := 0; schema != nil && i < 32; i++ {
+		switch {
+		case schema.Ref != "":
+			name = strings.TrimPrefix(schema.Ref, "#/definitions/")
+			schema = d.Definitions[name]
+		case len(schema.AllOf) == 1 && schema.Type == "":
+			schema = schema.AllOf[0]
+		default:
+			return schema, name
+		}
+	}
+	return schema, name
+}
+
+func splitPath(path string) []string {
+	return strings.Split(strings.Trim(path, "/"), "/")
+}
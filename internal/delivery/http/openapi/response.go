@@ -0,0 +1,63 @@
+package openapi
+
+import "strconv"
+
+// WalkResponse calls visit for every object of a decoded response body that the
+// document types with a named definition, such as model.ContactResponse, whether
+// it is the data itself, an element of a list or nested in another object.
+// Statuses without a documented schema are not walked.
+func (d *Document) WalkResponse(operation *Operation, status int, value any, visit func(definition string, object map[string]any)) {
+	response, ok := operation.Responses[strconv.Itoa(status)]
+	if !ok {
+		response, ok = operation.Responses["default"]
+	}
+	if !ok || response.Schema == nil {
+		return
+	}
+
+	d.walk(response.Schema, value, visit, 0)
+}
+
+func (d *Document) walk(schema *Schema, value any, visit func(definition string, object map[string]any), depth int) {
+	schema, name := d.resolveNamed(schema)
+	if schema == nil || value == nil || depth > 32 {
+		return
+	}
+
+	switch typed := value.(type) {
+	case map[string]any:
+		for property, child := range schema.Properties {
+			if childValue, ok := typed[property]; ok {
+				d.walk(child, childValue, visit, depth+1)
+			}
+		}
+		if name != "" {
+			visit(name, typed)
+		}
+	case []any:
+		if schema.Items != nil {
+			for _, item := range typed {
+				d.walk(schema.Items, item, visit, depth+1)
+			}
+		}
+	}
+}
+
+// DataProperties returns the properties of the objects a response carries in its
+// data field, whether data is one object or a list of them, or nil when the
+// document does not type them as objects.
+func (d *Document) DataProperties(operation *Operation, status int) map[string]*Schema {
+	response, ok := operation.Responses[strconv.Itoa(status)]
+	if !ok || response.Schema == nil {
+		return nil
+	}
+
+	data := d.resolve(d.resolve(response.Schema).Properties["data"])
+	if data != nil && data.Type == "array" {
+		data = d.resolve(data.Items)
+	}
+	if data == nil {
+		return nil
+	}
+	return data.Properties
+}
@@ -0,0 +1,270 @@
+package openapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Request is the part of an incoming request checked against an operation.
+type Request struct {
+	PathParams map[string]string
+	Query      func(key string) string
+	Header     func(key string) string
+	Body       []byte
+}
+
+// Violation is one way a request breaks the contract, keyed by the offending
+// parameter or body field (nested fields as "items[0].type").
+type Violation struct {
+	Field   string
+	Message string
+}
+
+// Validate checks the parameters and JSON body of request against operation and
+// returns every violation found, or nil when the request is valid. Fields the
+// document does not describe are ignored rather than rejected.
+func (d *Document) Validate(operation *Operation, request Request) []Violation {
+	var violations []Violation
+
+	for _, parameter := range operation.Parameters {
+		var raw string
+		switch parameter.In {
+		case "path":
+			raw = request.PathParams[parameter.Name]
+		case "query":
+			raw = request.Query(parameter.Name)
+		case "header":
+			raw = request.Header(parameter.Name)
+		case "body":
+			violations = d.validateBody(parameter, request.Body, violations)
+			continue
+		default:
+			continue
+		}
+
+		if raw == "" {
+			if parameter.Required {
+				violations = append(violations, Violation{parameter.Name, parameter.Name + " is required"})
+			}
+			continue
+		}
+
+		violations = d.validateValue(&parameter.Schema, coerce(&parameter.Schema, parameter.CollectionFormat, raw), parameter.Name, violations)
+	}
+
+	return violations
+}
+
+func (d *Document) validateBody(parameter *Parameter, body []byte, violations []Violation) []Violation {
+	if len(bytes.TrimSpace(body)) == 0 {
+		if parameter.Required {
+			violations = append(violations, Violation{"body", "request body is required"})
+		}
+		return violations
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+
+	var value any
+	if err := decoder.Decode(&value); err != nil {
+		return append(violations, Violation{"body", "request body must be valid JSON"})
+	}
+
+	return d.validateValue(parameter.BodySchema, value, "", violations)
+}
+
+// coerce turns a raw parameter string into the JSON value its schema expects. A
+// string that does not parse is returned as is, so validateValue reports it.
+func coerce(schema *Schema, collectionFormat string, raw string) any {
+	switch schema.Type {
+	case "integer":
+		if _, err := strconv.ParseInt(raw, 10, 64); err == nil {
+			return json.Number(raw)
+		}
+	case "number":
+		if _, err := strconv.ParseFloat(raw, 64); err == nil {
+			return json.Number(raw)
+		}
+	case "boolean":
+		if value, err := strconv.ParseBool(raw); err == nil {
+			return value
+		}
+	case "array":
+		separator := ","
+		switch collectionFormat {
+		case "ssv":
+			separator = " "
+		case "tsv":
+			separator = "\t"
+		case "pipes":
+			separator = "|"
+		}
+
+		items := make([]any, 0)
+		for _, item := range strings.Split(raw, separator) {
+			if schema.Items != nil {
+				items = append(items, coerce(schema.Items, "", item))
+			} else {
+				items = append(items, item)
+			}
+		}
+		return items
+	}
+	return raw
+}
+
+func (d *Document) validateValue(schema *Schema, value any, field string, violations []Violation) []Violation {
+	schema = d.resolve(schema)
+	if schema == nil || value == nil {
+		return violations
+	}
+
+	name := field
+	if name == "" {
+		name = "body"
+	}
+	invalid := func(format string, args ...any) []Violation {
+		return append(violations, Violation{name, name + " " + fmt.Sprintf(format, args...)})
+	}
+
+	switch schema.Type {
+	case "object":
+		object, ok := value.(map[string]any)
+		if !ok {
+			return invalid("must be an object")
+		}
+		return d.validateObject(schema, object, field, violations)
+	case "array":
+		items, ok := value.([]any)
+		if !ok {
+			return invalid("must be an array")
+		}
+		return d.validateArray(schema, items, name, violations)
+	case "string":
+		text, ok := value.(string)
+		if !ok {
+			return invalid("must be a string")
+		}
+		length := utf8.RuneCountInString(text)
+		if schema.MinLength != nil && length < *schema.MinLength {
+			return invalid("must be at least %d characters", *schema.MinLength)
+		}
+		if schema.MaxLength != nil && length > *schema.MaxLength {
+			return invalid("must be at most %d characters", *schema.MaxLength)
+		}
+	case "integer", "number":
+		number, ok := value.(json.Number)
+		if !ok {
+			return invalid("must be %s", article(schema.Type))
+		}
+		if schema.Type == "integer" {
+			if _, err := number.Int64(); err != nil {
+				return invalid("must be an integer")
+			}
+		}
+		parsed, err := number.Float64()
+		if err != nil {
+			return invalid("must be a number")
+		}
+		if schema.Minimum != nil && parsed < *schema.Minimum {
+			return invalid("must be at least %v", *schema.Minimum)
+		}
+		if schema.Maximum != nil && parsed > *schema.Maximum {
+			return invalid("must be at most %v", *schema.Maximum)
+		}
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			return invalid("must be a boolean")
+		}
+	default:
+		if object, ok := value.(map[string]any); ok && len(schema.Properties) > 0 {
+			return d.validateObject(schema, object, field, violations)
+		}
+	}
+
+	if len(schema.Enum) > 0 && !inEnum(schema.Enum, value) {
+		allowed := make([]string, len(schema.Enum))
+		for i, option := range schema.Enum {
+			allowed[i] = fmt.Sprint(option)
+		}
+		return invalid("must be one of: %s", strings.Join(allowed, " "))
+	}
+
+	return violations
+}
+
+func (d *Document) validateObject(schema *Schema, object map[string]any, field string, violations []Violation) []Violation {
+	for _, required := range schema.Required {
+		if object[required] == nil {
+			name := joinField(field, required)
+			violations = append(violations, Violation{name, name + " is required"})
+		}
+	}
+
+	names := make([]string, 0, len(schema.Properties))
+	for property := range schema.Properties {
+		names = append(names, property)
+	}
+	sort.Strings(names)
+
+	for _, property := range names {
+		if value, ok := object[property]; ok {
+			violations = d.validateValue(schema.Properties[property], value, joinField(field, property), violations)
+		}
+	}
+	return violations
+}
+
+func (d *Document) validateArray(schema *Schema, items []any, name string, violations []Violation) []Violation {
+	if schema.MinItems != nil && len(items) < *schema.MinItems {
+		return append(violations, Violation{name, fmt.Sprintf("%s must contain at least %d items", name, *schema.MinItems)})
+	}
+	if schema.MaxItems != nil && len(items) > *schema.MaxItems {
+		return append(violations, Violation{name, fmt.Sprintf("%s must contain at most %d items", name, *schema.MaxItems)})
+	}
+
+	if schema.UniqueItems {
+		seen := make(map[string]bool, len(items))
+		for _, item := range items {
+			key, _ := json.Marshal(item)
+			if seen[string(key)] {
+				return append(violations, Violation{name, name + " must not contain duplicate items"})
+			}
+			seen[string(key)] = true
+		}
+	}
+
+	for i, item := range items {
+		violations = d.validateValue(schema.Items, item, fmt.Sprintf("%s[%d]", name, i), violations)
+	}
+	return violations
+}
+
+func inEnum(enum []any, value any) bool {
+	for _, option := range enum {
+		if fmt.Sprint(option) == fmt.Sprint(value) {
+			return true
+		}
+	}
+	return false
+}
+
+func joinField(parent string, child string) string {
+	if parent == "" {
+		return child
+	}
+	return parent + "." + child
+}
+
+func article(kind string) string {
+	if kind == "integer" {
+		return "an integer"
+	}
+	return "a " + kind
+}
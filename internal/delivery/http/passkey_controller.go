@@ -0,0 +1,204 @@
+package http
+
+import (
+	"go-rest-scaffold/internal/delivery/http/middleware"
+	"go-rest-scaffold/internal/model"
+	"go-rest-scaffold/internal/usecase"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/sirupsen/logrus"
+)
+
+// errPasskeyManagement refuses passkey registration and deletion to requests
+// authenticated by a key, so a leaked key cannot be turned into a way to log in.
+var errPasskeyManagement = fiber.NewError(fiber.StatusForbidden, "passkeys cannot be managed with an api key")
+
+type PasskeyController struct {
+	Log     *logrus.Logger
+	UseCase *usecase.PasskeyUseCase
+}
+
+func NewPasskeyController(useCase *usecase.PasskeyUseCase, logger *logrus.Logger) *PasskeyController {
+	return &PasskeyController{
+		Log:     logger,
+		UseCase: useCase,
+	}
+}
+
+// BeginRegistration godoc
+// @Summary      Begin registering a passkey
+// @Description  Get the options to pass to navigator.credentials.create(), with a challenge valid for webauthn.challenge_ttl seconds
+// @Tags         passkeys
+// @Produce      json
+// @Security     BearerAuth
+// @Success      200 {object} object{data=model.PasskeyCreationOptions} "Passkey creation options"
+// @Failure      401 {object} object{errors=string} "Unauthorized"
+// @Failure      403 {object} object{errors=string} "Authenticated with an API key, or impersonating the user"
+// @Failure      500 {object} object{errors=string} "Internal server error"
+// @Router       /users/_current/passkeys/_begin [post]
+func (c *PasskeyController) BeginRegistration(ctx *fiber.Ctx) error {
+	auth := middleware.GetUser(ctx)
+	if auth.APIKeyId != "" {
+		return errPasskeyManagement
+	}
+	if auth.ImpersonatorId != "" {
+		return errImpersonating
+	}
+
+	request := &model.BeginPasskeyRegistrationRequest{UserId: auth.ID}
+
+	response, err := c.UseCase.BeginRegistration(ctx.UserContext(), request)
+	if err != nil {
+		c.Log.WithContext(ctx.UserContext()).WithError(err).Error("error beginning passkey registration")
+		return err
+	}
+
+	return ctx.JSON(model.WebResponse[*model.PasskeyCreationOptions]{Data: response})
+}
+
+// Register godoc
+// @Summary      Register a passkey
+// @Description  Save the passkey navigator.credentials.create() returned, encoded by PublicKeyCredential.toJSON(). The challenge it answers is used up
+// @Tags         passkeys
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        request body model.RegisterPasskeyRequest true "Passkey name and credential"
+// @Success      200 {object} object{data=model.PasskeyResponse} "Successfully registered passkey"
+// @Failure      400 {object} object{errors=string} "Invalid request body, challenge or passkey"
+// @Failure      401 {object} object{errors=string} "Unauthorized"
+// @Failure      403 {object} object{errors=string} "Authenticated with an API key, or impersonating the user"
+// @Failure      409 {object} object{errors=string} "Passkey already registered"
+// @Failure      500 {object} object{errors=string} "Internal server error"
+// @Router       /users/_current/passkeys [post]
+func (c *PasskeyController) Register(ctx *fiber.Ctx) error {
+	auth := middleware.GetUser(ctx)
+	if auth.APIKeyId != "" {
+		return errPasskeyManagement
+	}
+	if auth.ImpersonatorId != "" {
+		return errImpersonating
+	}
+
+	request := new(model.RegisterPasskeyRequest)
+	if err := ctx.BodyParser(request); err != nil {
+		c.Log.WithContext(ctx.UserContext()).WithError(err).Error("error parsing request body")
+		return fiber.ErrBadRequest
+	}
+	request.UserId = auth.ID
+
+	response, err := c.UseCase.Register(ctx.UserContext(), request)
+	if err != nil {
+		c.Log.WithContext(ctx.UserContext()).WithError(err).Error("error registering passkey")
+		return err
+	}
+
+	return ctx.JSON(model.WebResponse[*model.PasskeyResponse]{Data: response})
+}
+
+// List godoc
+// @Summary      List passkeys
+// @Description  List the passkeys of the authenticated user
+// @Tags         passkeys
+// @Produce      json
+// @Security     BearerAuth
+// @Security     APIKeyAuth
+// @Success      200 {object} object{data=[]model.PasskeyResponse} "List of passkeys"
+// @Failure      401 {object} object{errors=string} "Unauthorized"
+// @Failure      500 {object} object{errors=string} "Internal server error"
+// @Router       /users/_current/passkeys [get]
+func (c *PasskeyController) List(ctx *fiber.Ctx) error {
+	auth := middleware.GetUser(ctx)
+
+	request := &model.ListPasskeyRequest{UserId: auth.ID}
+
+	responses, err := c.UseCase.List(ctx.UserContext(), request)
+	if err != nil {
+		c.Log.WithContext(ctx.UserContext()).WithError(err).Error("error listing passkeys")
+		return err
+	}
+
+	return ctx.JSON(model.WebResponse[[]model.PasskeyResponse]{Data: responses})
+}
+
+// Delete godoc
+// @Summary      Delete a passkey
+// @Description  Delete a passkey of the authenticated user, so it can no longer log in
+// @Tags         passkeys
+// @Produce      json
+// @Security     BearerAuth
+// @Param        passkeyId path string true "Passkey ID"
+// @Success      200 {object} object{data=bool} "Successfully deleted passkey"
+// @Failure      401 {object} object{errors=string} "Unauthorized"
+// @Failure      403 {object} object{errors=string} "Authenticated with an API key"
+// @Failure      404 {object} object{errors=string} "Passkey not found"
+// @Failure      500 {object} object{errors=string} "Internal server error"
+// @Router       /users/_current/passkeys/{passkeyId} [delete]
+func (c *PasskeyController) Delete(ctx *fiber.Ctx) error {
+	auth := middleware.GetUser(ctx)
+	if auth.APIKeyId != "" {
+		return errPasskeyManagement
+	}
+
+	request := &model.DeletePasskeyRequest{
+		UserId: auth.ID,
+		ID:     ctx.Params("passkeyId"),
+	}
+
+	if err := c.UseCase.Delete(ctx.UserContext(), request); err != nil {
+		c.Log.WithContext(ctx.UserContext()).WithError(err).Error("error deleting passkey")
+		return err
+	}
+
+	return ctx.JSON(model.WebResponse[bool]{Data: true})
+}
+
+// BeginLogin godoc
+// @Summary      Begin a passkey login
+// @Description  Get the options to pass to navigator.credentials.get(), with a challenge valid for webauthn.challenge_ttl seconds
+// @Tags         passkeys
+// @Produce      json
+// @Success      200 {object} object{data=model.PasskeyRequestOptions} "Passkey request options"
+// @Failure      500 {object} object{errors=string} "Internal server error"
+// @Router       /users/_passkey-login/_begin [post]
+func (c *PasskeyController) BeginLogin(ctx *fiber.Ctx) error {
+	response, err := c.UseCase.BeginLogin(ctx.UserContext())
+	if err != nil {
+		c.Log.WithContext(ctx.UserContext()).WithError(err).Error("error beginning passkey login")
+		return err
+	}
+
+	return ctx.JSON(model.WebResponse[*model.PasskeyRequestOptions]{Data: response})
+}
+
+// Login godoc
+// @Summary      Log in with a passkey
+// @Description  Exchange the assertion navigator.credentials.get() returned, encoded by PublicKeyCredential.toJSON(), for an access and refresh token. The challenge it answers is used up
+// @Tags         passkeys
+// @Accept       json
+// @Produce      json
+// @Param        request body model.PasskeyLoginRequest true "Passkey assertion"
+// @Success      200 {object} object{data=model.UserResponse} "Successfully logged in"
+// @Failure      400 {object} object{errors=string} "Invalid request body or challenge"
+// @Failure      401 {object} object{errors=string} "Invalid passkey"
+// @Failure      403 {object} object{errors=string} "Email address is not verified"
+// @Failure      500 {object} object{errors=string} "Internal server error"
+// @Router       /users/_passkey-login [post]
+func (c *PasskeyController) Login(ctx *fiber.Ctx) error {
+	request := new(model.PasskeyLoginRequest)
+	if err := ctx.BodyParser(request); err != nil {
+		c.Log.WithContext(ctx.UserContext()).WithError(err).Error("error parsing request body")
+		return fiber.ErrBadRequest
+	}
+
+	request.IP = ctx.IP()
+	request.UserAgent = ctx.Get(fiber.HeaderUserAgent)
+
+	response, err := c.UseCase.Login(ctx.UserContext(), request)
+	if err != nil {
+		c.Log.WithContext(ctx.UserContext()).WithError(err).Error("error logging in with passkey")
+		return err
+	}
+
+	return ctx.JSON(model.WebResponse[*model.UserResponse]{Data: response})
+}
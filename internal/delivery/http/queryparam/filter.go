@@ -0,0 +1,108 @@
+package queryparam
+
+import (
+	"fmt"
+	"go-rest-scaffold/internal/model"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Kind is how the values of a filterable field are written in a query.
+type Kind int
+
+const (
+	// Text fields compare their values as written.
+	Text Kind = iota
+	// Time fields hold Unix milliseconds. They accept those, RFC 3339 times and dates
+	// such as 2024-01-01, which are midnight UTC.
+	Time
+)
+
+// Fields lists the fields a list endpoint can be filtered by, with their kind.
+type Fields map[string]Kind
+
+// operators maps the operators of filter parameters to the filter operators; the
+// last three match anywhere, at the start or at the end of the value.
+var operators = map[string]string{
+	"eq":     model.FilterEq,
+	"ne":     model.FilterNe,
+	"gt":     model.FilterGt,
+	"gte":    model.FilterGe,
+	"lt":     model.FilterLt,
+	"lte":    model.FilterLe,
+	"like":   model.FilterContains,
+	"prefix": model.FilterStartsWith,
+	"suffix": model.FilterEndsWith,
+}
+
+var filterParameter = regexp.MustCompile(`^(\w+)\[(\w+)\]$`)
+
+// ParseFilter builds the filter of the field[operator]=value parameters of query,
+// such as email[like]=gmail or created_at[gte]=2024-01-01, all of which must hold.
+// Parameters of another shape are left to the endpoint. It returns nil when there
+// are none.
+func ParseFilter(query map[string]string, fields Fields) (*model.FilterNode, error) {
+	// a stable order keeps equal queries equal, e.g. for the read cache
+	keys := make([]string, 0, len(query))
+	for key := range query {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+
+	var conditions []*model.FilterNode
+	for _, key := range keys {
+		match := filterParameter.FindStringSubmatch(key)
+		if match == nil {
+			continue
+		}
+		field, name := strings.Clone(match[1]), match[2]
+
+		kind, ok := fields[field]
+		if !ok {
+			return nil, fmt.Errorf("invalid filter: field %q is not filterable", field)
+		}
+		operator, ok := operators[name]
+		if !ok {
+			return nil, fmt.Errorf("invalid filter: unknown operator %q, use one of eq, ne, gt, gte, lt, lte, like, prefix, suffix", name)
+		}
+
+		value, err := parseValue(kind, operator, strings.Clone(query[key]))
+		if err != nil {
+			return nil, fmt.Errorf("invalid filter %s: %w", key, err)
+		}
+		conditions = append(conditions, &model.FilterNode{Operator: operator, Field: field, Value: value})
+	}
+
+	switch len(conditions) {
+	case 0:
+		return nil, nil
+	case 1:
+		return conditions[0], nil
+	}
+	return &model.FilterNode{Operator: model.FilterAnd, Children: conditions}, nil
+}
+
+func parseValue(kind Kind, operator string, raw string) (any, error) {
+	if kind == Text {
+		return raw, nil
+	}
+
+	switch operator {
+	case model.FilterContains, model.FilterStartsWith, model.FilterEndsWith:
+		return nil, fmt.Errorf("times can only be compared")
+	}
+
+	if millis, err := strconv.ParseInt(raw, 10, 64); err == nil {
+		return millis, nil
+	}
+	if at, err := time.Parse(time.RFC3339, raw); err == nil {
+		return at.UnixMilli(), nil
+	}
+	if at, err := time.Parse(time.DateOnly, raw); err == nil {
+		return at.UnixMilli(), nil
+	}
+	return nil, fmt.Errorf("%q is not a time, use Unix milliseconds, a date such as 2024-01-01 or an RFC 3339 time", raw)
+}
@@ -0,0 +1,29 @@
+// Package queryparam parses the sort and filter query parameters of list endpoints
+// into the typed specifications the repositories apply. Field names are not checked
+// here; the use cases check them against the columns each repository allows.
+package queryparam
+
+import (
+	"fmt"
+	"go-rest-scaffold/internal/model"
+	"slices"
+	"strings"
+)
+
+// ParseSort parses a comma separated list of fields, each prefixed with - to sort
+// descending, such as last_name,-created_at.
+func ParseSort(raw string) ([]model.SortField, error) {
+	var fields []model.SortField
+	for _, item := range strings.Split(raw, ",") {
+		item = strings.TrimSpace(item)
+		field, desc := strings.CutPrefix(item, "-")
+		if field == "" {
+			return nil, fmt.Errorf("invalid sort: empty field")
+		}
+		if slices.ContainsFunc(fields, func(sort model.SortField) bool { return sort.Field == field }) {
+			return nil, fmt.Errorf("invalid sort: field %q appears more than once", field)
+		}
+		fields = append(fields, model.SortField{Field: field, Desc: desc})
+	}
+	return fields, nil
+}
@@ -0,0 +1,68 @@
+package http
+
+import (
+	"go-rest-scaffold/internal/model"
+	"go-rest-scaffold/internal/usecase"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/sirupsen/logrus"
+)
+
+type ReadOnlyController struct {
+	Log     *logrus.Logger
+	UseCase *usecase.ReadOnlyUseCase
+}
+
+func NewReadOnlyController(useCase *usecase.ReadOnlyUseCase, logger *logrus.Logger) *ReadOnlyController {
+	return &ReadOnlyController{
+		Log:     logger,
+		UseCase: useCase,
+	}
+}
+
+// Get godoc
+// @Summary      Get read-only mode
+// @Description  Get whether write requests are currently refused
+// @Tags         admin
+// @Produce      json
+// @Security     BearerAuth
+// @Security     APIKeyAuth
+// @Success      200 {object} object{data=model.ReadOnlyResponse} "Current read-only mode"
+// @Failure      401 {object} object{errors=string} "Unauthorized"
+// @Failure      403 {object} object{errors=string} "Not an admin"
+// @Router       /admin/read-only [get]
+func (c *ReadOnlyController) Get(ctx *fiber.Ctx) error {
+	response := c.UseCase.Get(ctx.UserContext())
+	return ctx.JSON(model.WebResponse[*model.ReadOnlyResponse]{Data: response})
+}
+
+// Update godoc
+// @Summary      Change read-only mode
+// @Description  Turn read-only mode on or off at runtime. While it is on, reads keep working and every write is answered with 503 and the code read_only
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Security     APIKeyAuth
+// @Param        request body model.UpdateReadOnlyRequest true "New read-only mode"
+// @Success      200 {object} object{data=model.ReadOnlyResponse} "Updated read-only mode"
+// @Failure      400 {object} object{errors=string} "Invalid request body"
+// @Failure      401 {object} object{errors=string} "Unauthorized"
+// @Failure      403 {object} object{errors=string} "Not an admin"
+// @Failure      500 {object} object{errors=string} "Internal server error"
+// @Router       /admin/read-only [put]
+func (c *ReadOnlyController) Update(ctx *fiber.Ctx) error {
+	request := new(model.UpdateReadOnlyRequest)
+	if err := ctx.BodyParser(request); err != nil {
+		c.Log.WithContext(ctx.UserContext()).Warnf("Failed to parse request body : %+v", err)
+		return fiber.ErrBadRequest
+	}
+
+	response, err := c.UseCase.Update(ctx.UserContext(), request)
+	if err != nil {
+		c.Log.WithContext(ctx.UserContext()).Warnf("Failed to update read-only mode : %+v", err)
+		return err
+	}
+
+	return ctx.JSON(model.WebResponse[*model.ReadOnlyResponse]{Data: response})
+}
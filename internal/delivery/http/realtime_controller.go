@@ -0,0 +1,98 @@
+package http
+
+import (
+	"go-rest-scaffold/internal/gateway/realtime"
+	"go-rest-scaffold/internal/model"
+	"time"
+
+	"github.com/gofiber/contrib/websocket"
+	"github.com/gofiber/fiber/v2"
+	"github.com/sirupsen/logrus"
+)
+
+// contactEventTypes are the events pushed to WebSocket clients.
+var contactEventTypes = []string{
+	model.EventContactCreated,
+	model.EventContactUpdated,
+	model.EventContactDeleted,
+	model.EventContactRestored,
+	model.EventContactTagged,
+	model.EventContactUntagged,
+}
+
+type RealtimeController struct {
+	Hub *realtime.Hub
+	Log *logrus.Logger
+	// PingInterval is how often idle connections are pinged; a client that has not
+	// answered for two intervals is disconnected.
+	PingInterval time.Duration
+}
+
+func NewRealtimeController(hub *realtime.Hub, pingInterval time.Duration, log *logrus.Logger) *RealtimeController {
+	return &RealtimeController{
+		Hub:          hub,
+		Log:          log,
+		PingInterval: pingInterval,
+	}
+}
+
+// Handshake only lets WebSocket handshakes through.
+func (c *RealtimeController) Handshake(ctx *fiber.Ctx) error {
+	if !websocket.IsWebSocketUpgrade(ctx) {
+		return fiber.ErrUpgradeRequired
+	}
+	return ctx.Next()
+}
+
+// Events pushes the events of the contacts of the authenticated user as CloudEvents
+// JSON text frames, until either side closes the connection. Messages from the
+// client are ignored.
+func (c *RealtimeController) Events() fiber.Handler {
+	return websocket.New(func(conn *websocket.Conn) {
+		auth := conn.Locals("auth").(*model.Auth)
+		client := c.Hub.Connect(auth.ID, contactEventTypes...)
+		defer client.Close()
+
+		deadline := 2 * c.PingInterval
+		_ = conn.SetReadDeadline(time.Now().Add(deadline))
+		conn.SetPongHandler(func(string) error {
+			return conn.SetReadDeadline(time.Now().Add(deadline))
+		})
+
+		closed := make(chan struct{})
+		go func() {
+			defer close(closed)
+			for {
+				if _, _, err := conn.ReadMessage(); err != nil {
+					return
+				}
+			}
+		}()
+
+		ping := time.NewTicker(c.PingInterval)
+		defer ping.Stop()
+
+		for {
+			select {
+			case <-closed:
+				return
+			case message := <-client.Messages:
+				if err := c.write(conn, websocket.TextMessage, message.Payload); err != nil {
+					c.Log.WithError(err).Debugf("Failed to send an event to user %s", auth.ID)
+					return
+				}
+			case <-ping.C:
+				if err := c.write(conn, websocket.PingMessage, nil); err != nil {
+					return
+				}
+			}
+		}
+	})
+}
+
+func (c *RealtimeController) write(conn *websocket.Conn, messageType int, payload []byte) error {
+	if err := conn.SetWriteDeadline(time.Now().Add(c.PingInterval)); err != nil {
+		return err
+	}
+	return conn.WriteMessage(messageType, payload)
+}
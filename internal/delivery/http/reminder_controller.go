@@ -0,0 +1,204 @@
+package http
+
+import (
+	"go-rest-scaffold/internal/delivery/http/middleware"
+	"go-rest-scaffold/internal/model"
+	"go-rest-scaffold/internal/usecase"
+	"math"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/sirupsen/logrus"
+)
+
+type ReminderController struct {
+	Log     *logrus.Logger
+	UseCase *usecase.ReminderUseCase
+}
+
+func NewReminderController(useCase *usecase.ReminderUseCase, logger *logrus.Logger) *ReminderController {
+	return &ReminderController{
+		Log:     logger,
+		UseCase: useCase,
+	}
+}
+
+// Create godoc
+// @Summary      Create a reminder
+// @Description  Schedule a reminder about a contact at a local date and time (YYYY-MM-DDTHH:MM) in an IANA timezone, UTC by default
+// @Tags         reminders
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Security     APIKeyAuth
+// @Param        request body model.CreateReminderRequest true "Reminder details"
+// @Param        X-Dry-Run header bool false "Run all checks, then roll back instead of committing"
+// @Param        Idempotency-Key header string false "Key of the request, unique per user; retries with the same key replay the first response"
+// @Success      200 {object} object{data=model.ReminderResponse} "Successfully created reminder"
+// @Failure      400 {object} object{errors=string} "Invalid request body"
+// @Failure      401 {object} object{errors=string} "Unauthorized"
+// @Failure      404 {object} object{errors=string} "Contact not found"
+// @Failure      500 {object} object{errors=string} "Internal server error"
+// @Router       /reminders [post]
+func (c *ReminderController) Create(ctx *fiber.Ctx) error {
+	auth := middleware.GetUser(ctx)
+
+	request := new(model.CreateReminderRequest)
+	if err := ctx.BodyParser(request); err != nil {
+		c.Log.WithContext(ctx.UserContext()).WithError(err).Error("error parsing request body")
+		return fiber.ErrBadRequest
+	}
+	request.UserId = auth.ID
+
+	response, err := c.UseCase.Create(ctx.UserContext(), request)
+	if err != nil {
+		c.Log.WithContext(ctx.UserContext()).WithError(err).Error("error creating reminder")
+		return err
+	}
+
+	return ctx.JSON(model.WebResponse[*model.ReminderResponse]{Data: response})
+}
+
+// List godoc
+// @Summary      List reminders
+// @Description  List the reminders of the authenticated user, soonest first
+// @Tags         reminders
+// @Produce      json
+// @Security     BearerAuth
+// @Security     APIKeyAuth
+// @Param        contact_id query string false "Only reminders about this contact"
+// @Param        status query string false "Only reminders with this status" Enums(pending, sent, failed)
+// @Param        page query int false "Page number" default(1)
+// @Param        size query int false "Page size" default(10)
+// @Param        fields query string false "Comma separated fields to return; id is always returned"
+// @Success      200 {object} object{data=[]model.ReminderResponse,paging=model.PageMetadata} "List of reminders with pagination"
+// @Failure      400 {object} object{errors=string} "Invalid query"
+// @Failure      401 {object} object{errors=string} "Unauthorized"
+// @Failure      500 {object} object{errors=string} "Internal server error"
+// @Router       /reminders [get]
+func (c *ReminderController) List(ctx *fiber.Ctx) error {
+	auth := middleware.GetUser(ctx)
+
+	request := &model.SearchReminderRequest{
+		UserId:    auth.ID,
+		ContactId: ctx.Query("contact_id", ""),
+		Status:    ctx.Query("status", ""),
+		Page:      ctx.QueryInt("page", 1),
+		Size:      ctx.QueryInt("size", 10),
+	}
+
+	responses, total, err := c.UseCase.Search(ctx.UserContext(), request)
+	if err != nil {
+		c.Log.WithContext(ctx.UserContext()).WithError(err).Error("error searching reminders")
+		return err
+	}
+
+	paging := &model.PageMetadata{
+		Page:      request.Page,
+		Size:      request.Size,
+		TotalItem: total,
+		TotalPage: int64(math.Ceil(float64(total) / float64(request.Size))),
+	}
+
+	return ctx.JSON(model.WebResponse[[]model.ReminderResponse]{
+		Data:   responses,
+		Paging: paging,
+	})
+}
+
+// Get godoc
+// @Summary      Get a reminder
+// @Description  Get a reminder of the authenticated user by ID
+// @Tags         reminders
+// @Produce      json
+// @Security     BearerAuth
+// @Security     APIKeyAuth
+// @Param        reminderId path string true "Reminder ID"
+// @Param        fields query string false "Comma separated fields to return; id is always returned"
+// @Success      200 {object} object{data=model.ReminderResponse} "Reminder details"
+// @Failure      401 {object} object{errors=string} "Unauthorized"
+// @Failure      404 {object} object{errors=string} "Reminder not found"
+// @Failure      500 {object} object{errors=string} "Internal server error"
+// @Router       /reminders/{reminderId} [get]
+func (c *ReminderController) Get(ctx *fiber.Ctx) error {
+	auth := middleware.GetUser(ctx)
+
+	request := &model.GetReminderRequest{
+		UserId: auth.ID,
+		ID:     ctx.Params("reminderId"),
+	}
+
+	response, err := c.UseCase.Get(ctx.UserContext(), request)
+	if err != nil {
+		c.Log.WithContext(ctx.UserContext()).WithError(err).Error("error getting reminder")
+		return err
+	}
+
+	return ctx.JSON(model.WebResponse[*model.ReminderResponse]{Data: response})
+}
+
+// Update godoc
+// @Summary      Update a reminder
+// @Description  Change a reminder of the authenticated user and schedule it again
+// @Tags         reminders
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Security     APIKeyAuth
+// @Param        reminderId path string true "Reminder ID"
+// @Param        request body model.UpdateReminderRequest true "Reminder details"
+// @Param        X-Dry-Run header bool false "Run all checks, then roll back instead of committing"
+// @Success      200 {object} object{data=model.ReminderResponse} "Successfully updated reminder"
+// @Failure      400 {object} object{errors=string} "Invalid request body"
+// @Failure      401 {object} object{errors=string} "Unauthorized"
+// @Failure      404 {object} object{errors=string} "Reminder not found"
+// @Failure      500 {object} object{errors=string} "Internal server error"
+// @Router       /reminders/{reminderId} [put]
+func (c *ReminderController) Update(ctx *fiber.Ctx) error {
+	auth := middleware.GetUser(ctx)
+
+	request := new(model.UpdateReminderRequest)
+	if err := ctx.BodyParser(request); err != nil {
+		c.Log.WithContext(ctx.UserContext()).WithError(err).Error("error parsing request body")
+		return fiber.ErrBadRequest
+	}
+	request.UserId = auth.ID
+	request.ID = ctx.Params("reminderId")
+
+	response, err := c.UseCase.Update(ctx.UserContext(), request)
+	if err != nil {
+		c.Log.WithContext(ctx.UserContext()).WithError(err).Error("error updating reminder")
+		return err
+	}
+
+	return ctx.JSON(model.WebResponse[*model.ReminderResponse]{Data: response})
+}
+
+// Delete godoc
+// @Summary      Delete a reminder
+// @Description  Delete a reminder of the authenticated user
+// @Tags         reminders
+// @Produce      json
+// @Security     BearerAuth
+// @Security     APIKeyAuth
+// @Param        reminderId path string true "Reminder ID"
+// @Param        X-Dry-Run header bool false "Run all checks, then roll back instead of committing"
+// @Success      200 {object} object{data=bool} "Successfully deleted reminder"
+// @Failure      401 {object} object{errors=string} "Unauthorized"
+// @Failure      404 {object} object{errors=string} "Reminder not found"
+// @Failure      500 {object} object{errors=string} "Internal server error"
+// @Router       /reminders/{reminderId} [delete]
+func (c *ReminderController) Delete(ctx *fiber.Ctx) error {
+	auth := middleware.GetUser(ctx)
+
+	request := &model.DeleteReminderRequest{
+		UserId: auth.ID,
+		ID:     ctx.Params("reminderId"),
+	}
+
+	if err := c.UseCase.Delete(ctx.UserContext(), request); err != nil {
+		c.Log.WithContext(ctx.UserContext()).WithError(err).Error("error deleting reminder")
+		return err
+	}
+
+	return ctx.JSON(model.WebResponse[bool]{Data: true})
+}
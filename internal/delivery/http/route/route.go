@@ -2,47 +2,285 @@ package route
 
 import (
 	"go-rest-scaffold/internal/delivery/http"
+	"go-rest-scaffold/internal/delivery/http/middleware"
+	"go-rest-scaffold/internal/model"
 
 	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/adaptor"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	fiberSwagger "github.com/swaggo/fiber-swagger"
 )
 
 type RouteConfig struct {
-	App               *fiber.App
-	UserController    *http.UserController
-	ContactController *http.ContactController
-	AddressController *http.AddressController
-	AuthMiddleware    fiber.Handler
+	App                         *fiber.App
+	UserController              *http.UserController
+	ContactController           *http.ContactController
+	ContactSyncController       *http.ContactSyncController
+	ContactImportController     *http.ContactImportController
+	ContactAvatarController     *http.ContactAvatarController
+	AddressController           *http.AddressController
+	TagController               *http.TagController
+	NoteController              *http.NoteController
+	LoggingController           *http.LoggingController
+	ReadOnlyController          *http.ReadOnlyController
+	DebugCaptureController      *http.DebugCaptureController
+	AnnouncementController      *http.AnnouncementController
+	EmailTemplateController     *http.EmailTemplateController
+	APIKeyController            *http.APIKeyController
+	PasskeyController           *http.PasskeyController
+	WebhookController           *http.WebhookController
+	ReminderController          *http.ReminderController
+	TrashController             *http.TrashController
+	AccountController           *http.AccountController
+	StatsController             *http.StatsController
+	AuditLogController          *http.AuditLogController
+	LoginEventController        *http.LoginEventController
+	UsageController             *http.UsageController
+	HealthController            *http.HealthController
+	DocsController              *http.DocsController
+	DiscoveryController         *http.DiscoveryController
+	RealtimeController          *http.RealtimeController
+	EventStreamController       *http.EventStreamController
+	CORSMiddleware              fiber.Handler
+	RequestIDMiddleware         fiber.Handler
+	APIVersionMiddleware        fiber.Handler
+	QueryTokenMiddleware        fiber.Handler
+	AccessLogMiddleware         fiber.Handler
+	TracingMiddleware           fiber.Handler
+	FaultInjectionMiddleware    fiber.Handler
+	ReadOnlyMiddleware          fiber.Handler
+	DryRunMiddleware            fiber.Handler
+	OpenAPIValidationMiddleware fiber.Handler
+	SandboxMiddleware           fiber.Handler
+	AuthMiddleware              fiber.Handler
+	AdminMiddleware             fiber.Handler
+	ODataMiddleware             fiber.Handler
+	ActivityMiddleware          fiber.Handler
+	QuotaMiddleware             fiber.Handler
+	ExperimentMiddleware        fiber.Handler
+	DebugCaptureMiddleware      fiber.Handler
+	FieldPolicyMiddleware       fiber.Handler
+	FieldSelectionMiddleware    fiber.Handler
+	IdempotencyMiddleware       fiber.Handler
+	RequireIfMatch              fiber.Handler
+	CacheControl                func(keys middleware.SurrogateKeys) fiber.Handler
+	RateLimit                   func(policy string, key middleware.RateLimitKey) fiber.Handler
+	Timeout                     func(group string) fiber.Handler
+	RequireScope                func(scope string) fiber.Handler
+	RequireSignature            fiber.Handler
+	MetricsToken                func(handler fiber.Handler) fiber.Handler
+	Deprecated                  func(successor string) fiber.Handler
 }
 
+// APIVersions are the versions of the API, oldest first, each served under
+// /api/{version}. A version only differs from the one before where its routes check
+// the version they are registered for.
+var APIVersions = []string{"v1", "v2"}
+
 func (c *RouteConfig) Setup() {
+	c.SetupProbeRoute()
+	// ahead of the rest, so preflight requests are answered before auth and rate limits
+	c.App.Use(c.CORSMiddleware)
+	c.App.Use(c.RequestIDMiddleware)
+	c.App.Use(c.AccessLogMiddleware)
+	c.App.Use(c.TracingMiddleware)
+	c.App.Use(c.APIVersionMiddleware)
+	c.App.Use(c.Timeout("default"))
+	c.App.Use(c.RateLimit("ip", middleware.ClientIPKey))
+	c.App.Use(c.FaultInjectionMiddleware)
+	c.App.Use(c.ReadOnlyMiddleware)
+	c.App.Use(c.DryRunMiddleware)
+	c.App.Use(c.OpenAPIValidationMiddleware)
 	c.SetupGuestRoute()
 	c.SetupAuthRoute()
 }
 
+// SetupProbeRoute registers the probes ahead of every middleware, so they are not
+// traced, rate limited or refused by read-only mode and fault injection.
+func (c *RouteConfig) SetupProbeRoute() {
+	c.App.Get("/livez", c.HealthController.Live)
+	c.App.Get("/readyz", c.HealthController.Ready)
+	c.App.Get("/healthz", c.HealthController.Health)
+}
+
 func (c *RouteConfig) SetupGuestRoute() {
-	c.App.Post("/api/users", c.UserController.Register)
-	c.App.Post("/api/users/_login", c.UserController.Login)
-	c.App.Post("/api/users/refresh-token", c.UserController.RefreshToken)
+	// ahead of the auth middleware, which reads the token moved from the query
+	c.App.Use("/ws", c.QueryTokenMiddleware)
+	c.App.Use("/events", c.QueryTokenMiddleware)
 
 	c.App.Get("/swagger/*", fiberSwagger.WrapHandler)
+	// scrapers send the bearer token of metrics.token, anyone else must be an admin
+	metrics := adaptor.HTTPHandler(promhttp.Handler())
+	c.App.Get("/metrics", c.MetricsToken(metrics), c.AuthMiddleware, c.AdminMiddleware, metrics)
+	c.App.Get("/version", c.DiscoveryController.Version)
+	c.App.Get("/asyncapi.json", c.DocsController.AsyncAPI)
+	c.App.Get("/.well-known/security.txt", c.DiscoveryController.SecurityTxt)
+	c.App.Get("/.well-known/jwks.json", c.DiscoveryController.JWKS)
+	c.App.Get("/.well-known/openid-configuration", c.DiscoveryController.OpenIDConfiguration)
+
+	for _, version := range APIVersions {
+		c.setupGuestAPIRoute(c.App.Group("/api/"+version), version)
+	}
+}
+
+func (c *RouteConfig) setupGuestAPIRoute(api fiber.Router, version string) {
+	// the routes guessing or mailing credentials share a tighter budget per client
+	authLimit := c.RateLimit("auth", middleware.UserOrIPKey)
+
+	api.Post("/users", authLimit, c.UserController.Register)
+	api.Post("/users/_login", authLimit, c.UserController.Login)
+	if version == "v1" {
+		api.Post("/users/refresh-token", c.Deprecated("/api/v2/users/_refresh"), c.UserController.RefreshToken)
+	} else {
+		api.Post("/users/_refresh", c.UserController.RefreshToken)
+	}
+	api.Get("/users/_verify", c.UserController.VerifyEmail)
+	api.Post("/users/_resend-verification", authLimit, c.UserController.ResendVerification)
+	api.Post("/users/_forgot-password", authLimit, c.UserController.ForgotPassword)
+	api.Post("/users/_reset-password", authLimit, c.UserController.ResetPassword)
+	api.Post("/users/_magic-link", authLimit, c.UserController.SendMagicLink)
+	api.Post("/users/_magic-link/_exchange", authLimit, c.UserController.ExchangeMagicLink)
+	api.Post("/users/_passkey-login/_begin", authLimit, c.PasskeyController.BeginLogin)
+	api.Post("/users/_passkey-login", authLimit, c.PasskeyController.Login)
+	api.Get("/_meta", c.DiscoveryController.Metadata)
+	api.Get("/announcements/active", c.AnnouncementController.Active)
+	if c.ContactAvatarController.Disk != nil {
+		// the signature of the URL stands in for the token, so pictures load in img tags
+		api.Get("/storage/*", c.ContactAvatarController.Object)
+	}
 }
 
 func (c *RouteConfig) SetupAuthRoute() {
 	c.App.Use(c.AuthMiddleware)
-	c.App.Delete("/api/users", c.UserController.Logout)
-	c.App.Patch("/api/users/_current", c.UserController.Update)
-	c.App.Get("/api/users/_current", c.UserController.Current)
-
-	c.App.Get("/api/contacts", c.ContactController.List)
-	c.App.Post("/api/contacts", c.ContactController.Create)
-	c.App.Put("/api/contacts/:contactId", c.ContactController.Update)
-	c.App.Get("/api/contacts/:contactId", c.ContactController.Get)
-	c.App.Delete("/api/contacts/:contactId", c.ContactController.Delete)
-
-	c.App.Get("/api/contacts/:contactId/addresses", c.AddressController.List)
-	c.App.Post("/api/contacts/:contactId/addresses", c.AddressController.Create)
-	c.App.Put("/api/contacts/:contactId/addresses/:addressId", c.AddressController.Update)
-	c.App.Get("/api/contacts/:contactId/addresses/:addressId", c.AddressController.Get)
-	c.App.Delete("/api/contacts/:contactId/addresses/:addressId", c.AddressController.Delete)
+	c.App.Use(c.RateLimit("user", middleware.CurrentUserIDKey))
+	c.App.Use(c.QuotaMiddleware)
+	c.App.Use(c.ActivityMiddleware)
+	c.App.Use(c.SandboxMiddleware)
+	c.App.Use(c.ExperimentMiddleware)
+	c.App.Use(c.DebugCaptureMiddleware)
+	c.App.Use(c.FieldPolicyMiddleware)
+	c.App.Use(c.FieldSelectionMiddleware)
+
+	c.App.Get("/ws", c.RealtimeController.Handshake, c.RequireScope(model.ScopeContactsRead), c.RealtimeController.Events())
+	c.App.Get("/events", c.EventStreamController.Stream)
+
+	for _, version := range APIVersions {
+		c.setupAuthAPIRoute(c.App.Group("/api/"+version), version)
+	}
+}
+
+func (c *RouteConfig) setupAuthAPIRoute(api fiber.Router, version string) {
+	accountRead, accountWrite := c.RequireScope(model.ScopeAccountRead), c.RequireScope(model.ScopeAccountWrite)
+	contactsRead, contactsWrite := c.RequireScope(model.ScopeContactsRead), c.RequireScope(model.ScopeContactsWrite)
+	addressesRead, addressesWrite := c.RequireScope(model.ScopeAddressesRead), c.RequireScope(model.ScopeAddressesWrite)
+	remindersRead, remindersWrite := c.RequireScope(model.ScopeRemindersRead), c.RequireScope(model.ScopeRemindersWrite)
+	webhooksRead, webhooksWrite := c.RequireScope(model.ScopeWebhooksRead), c.RequireScope(model.ScopeWebhooksWrite)
+	// exports, imports and bulk changes are heavy, so they share a budget and timeout of their own
+	bulkLimit, bulkTimeout := c.RateLimit("bulk", middleware.UserOrIPKey), c.Timeout("bulk")
+
+	api.Delete("/users", c.UserController.Logout)
+	api.Delete("/users/_current", accountWrite, c.RequireSignature, c.UserController.Delete)
+	api.Patch("/users/_current", accountWrite, c.UserController.Update)
+	api.Get("/users/_current", accountRead, c.CacheControl(middleware.CurrentUserKeys), c.UserController.Current)
+	api.Get("/users/_current/rate-limit", c.UserController.RateLimit)
+	api.Get("/users/_current/usage", c.UsageController.Get)
+	api.Get("/users/_sessions", accountRead, c.UserController.Sessions)
+	api.Delete("/users/_sessions/:sessionId", accountWrite, c.UserController.RevokeSession)
+	api.Get("/users/_current/_export", accountRead, bulkLimit, bulkTimeout, c.AccountController.Export)
+	api.Post("/users/_current/_import", accountWrite, c.RequireSignature, bulkLimit, bulkTimeout, c.AccountController.Import)
+	api.Post("/users/_current/passkeys/_begin", accountWrite, c.PasskeyController.BeginRegistration)
+	api.Post("/users/_current/passkeys", accountWrite, c.PasskeyController.Register)
+	api.Get("/users/_current/passkeys", accountRead, c.PasskeyController.List)
+	api.Delete("/users/_current/passkeys/:passkeyId", accountWrite, c.PasskeyController.Delete)
+	api.Get("/users/_current/logins", accountRead, c.LoginEventController.List)
+
+	api.Get("/contacts", contactsRead, c.ODataMiddleware, c.CacheControl(middleware.ContactListKeys), c.ContactController.List)
+	api.Post("/contacts", contactsWrite, c.IdempotencyMiddleware, c.ContactController.Create)
+	api.Post("/contacts/_bulk", contactsWrite, bulkLimit, bulkTimeout, c.IdempotencyMiddleware, c.ContactController.Bulk)
+	api.Get("/contacts/_suggest", contactsRead, c.ContactController.Suggest)
+	api.Get("/contacts/_sync", contactsRead, c.ContactSyncController.Sync)
+	api.Get("/contacts/_index", contactsRead, c.CacheControl(middleware.ContactListKeys), c.ContactController.Index)
+	api.Get("/contacts/_export", contactsRead, bulkLimit, bulkTimeout, c.ContactController.Export)
+	api.Post("/contacts/_import", contactsWrite, bulkLimit, bulkTimeout, c.IdempotencyMiddleware, c.ContactImportController.Import)
+	api.Get("/contacts/_import/:importId", contactsRead, c.ContactImportController.Get)
+	api.Get("/contacts/_trash", contactsRead, c.TrashController.ListContacts)
+	api.Put("/contacts/:contactId", contactsWrite, c.RequireIfMatch, c.ContactController.Update)
+	api.Patch("/contacts/:contactId", contactsWrite, c.RequireIfMatch, c.ContactController.Patch)
+	api.Get("/contacts/:contactId", contactsRead, c.CacheControl(middleware.ContactKeys), c.ContactController.Get)
+	api.Delete("/contacts/:contactId", contactsWrite, c.RequireIfMatch, c.ContactController.Delete)
+	api.Post("/contacts/:contactId/_restore", contactsWrite, c.TrashController.RestoreContact)
+	api.Put("/contacts/:contactId/avatar", contactsWrite, c.RequireIfMatch, c.ContactAvatarController.Upload)
+	api.Delete("/contacts/:contactId/avatar", contactsWrite, c.RequireIfMatch, c.ContactAvatarController.Delete)
+
+	api.Get("/contacts/:contactId/addresses", addressesRead, c.CacheControl(middleware.ContactKeys), c.AddressController.List)
+	api.Post("/contacts/:contactId/addresses", addressesWrite, c.IdempotencyMiddleware, c.AddressController.Create)
+	api.Put("/contacts/:contactId/addresses/:addressId", addressesWrite, c.RequireIfMatch, c.AddressController.Update)
+	api.Patch("/contacts/:contactId/addresses/:addressId", addressesWrite, c.RequireIfMatch, c.AddressController.Patch)
+	api.Get("/contacts/:contactId/addresses/:addressId", addressesRead, c.CacheControl(middleware.ContactKeys), c.AddressController.Get)
+	api.Delete("/contacts/:contactId/addresses/:addressId", addressesWrite, c.RequireIfMatch, c.AddressController.Delete)
+
+	api.Get("/contacts/:contactId/notes", contactsRead, c.NoteController.List)
+	api.Post("/contacts/:contactId/notes", contactsWrite, c.IdempotencyMiddleware, c.NoteController.Create)
+	api.Get("/contacts/:contactId/notes/:noteId", contactsRead, c.NoteController.Get)
+	api.Put("/contacts/:contactId/notes/:noteId", contactsWrite, c.NoteController.Update)
+	api.Delete("/contacts/:contactId/notes/:noteId", contactsWrite, c.NoteController.Delete)
+
+	api.Get("/contacts/:contactId/tags", contactsRead, c.TagController.ListByContact)
+	api.Put("/contacts/:contactId/tags/:tagId", contactsWrite, c.TagController.Assign)
+	api.Delete("/contacts/:contactId/tags/:tagId", contactsWrite, c.TagController.Unassign)
+	api.Post("/tags", contactsWrite, c.IdempotencyMiddleware, c.TagController.Create)
+	api.Get("/tags", contactsRead, c.TagController.List)
+	api.Get("/tags/:tagId", contactsRead, c.TagController.Get)
+	api.Put("/tags/:tagId", contactsWrite, c.TagController.Update)
+	api.Delete("/tags/:tagId", contactsWrite, c.TagController.Delete)
+
+	api.Get("/trash", contactsRead, c.TrashController.List)
+	api.Post("/trash/_restore", contactsWrite, c.TrashController.Restore)
+	api.Delete("/trash", contactsWrite, c.RequireSignature, c.TrashController.Empty)
+
+	api.Post("/reminders", remindersWrite, c.IdempotencyMiddleware, c.ReminderController.Create)
+	api.Get("/reminders", remindersRead, c.ReminderController.List)
+	api.Get("/reminders/:reminderId", remindersRead, c.ReminderController.Get)
+	api.Put("/reminders/:reminderId", remindersWrite, c.ReminderController.Update)
+	api.Delete("/reminders/:reminderId", remindersWrite, c.ReminderController.Delete)
+
+	api.Post("/api-keys", accountWrite, c.IdempotencyMiddleware, c.APIKeyController.Create)
+	api.Get("/api-keys", accountRead, c.APIKeyController.List)
+	api.Delete("/api-keys/:apiKeyId", accountWrite, c.APIKeyController.Revoke)
+
+	api.Post("/webhooks", webhooksWrite, c.IdempotencyMiddleware, c.WebhookController.Create)
+	api.Get("/webhooks", webhooksRead, c.WebhookController.List)
+	api.Get("/webhooks/:webhookId", webhooksRead, c.WebhookController.Get)
+	api.Put("/webhooks/:webhookId", webhooksWrite, c.WebhookController.Update)
+	api.Delete("/webhooks/:webhookId", webhooksWrite, c.WebhookController.Delete)
+	api.Post("/webhooks/:webhookId/_rotate-secret", webhooksWrite, c.WebhookController.RotateSecret)
+	api.Get("/webhooks/:webhookId/deliveries", webhooksRead, c.WebhookController.ListDeliveries)
+	api.Post("/webhooks/:webhookId/deliveries/_replay", webhooksWrite, c.WebhookController.ReplayFailed)
+	api.Post("/webhooks/:webhookId/deliveries/:deliveryId/replay", webhooksWrite, c.WebhookController.Replay)
+
+	api.Get("/admin/logging", c.AdminMiddleware, c.LoggingController.Get)
+	api.Put("/admin/logging", c.AdminMiddleware, c.LoggingController.Update)
+	if version == "v1" {
+		api.Put("/admin/log-level", c.AdminMiddleware, c.Deprecated("/api/v2/admin/logging"), c.LoggingController.UpdateLevel)
+	}
+	api.Delete("/admin/users/:userId/sessions", c.AdminMiddleware, c.UserController.RevokeUserSessions)
+	api.Post("/admin/users/:userId/_impersonate", c.AdminMiddleware, c.UserController.Impersonate)
+	api.Get("/admin/read-only", c.AdminMiddleware, c.ReadOnlyController.Get)
+	api.Put("/admin/read-only", c.AdminMiddleware, c.ReadOnlyController.Update)
+	api.Get("/admin/stats", c.AdminMiddleware, c.StatsController.Get)
+	api.Get("/admin/stats/daily", c.AdminMiddleware, c.StatsController.Daily)
+	api.Get("/admin/stats/top-accounts", c.AdminMiddleware, c.StatsController.TopAccounts)
+	api.Get("/admin/audit-logs", c.AdminMiddleware, c.AuditLogController.List)
+	api.Get("/admin/captures", c.AdminMiddleware, c.DebugCaptureController.List)
+	api.Get("/admin/captures/:captureId", c.AdminMiddleware, c.DebugCaptureController.Get)
+	api.Post("/admin/announcements", c.AdminMiddleware, c.AnnouncementController.Create)
+	api.Get("/admin/announcements", c.AdminMiddleware, c.AnnouncementController.List)
+	api.Get("/admin/announcements/:announcementId", c.AdminMiddleware, c.AnnouncementController.Get)
+	api.Put("/admin/announcements/:announcementId", c.AdminMiddleware, c.AnnouncementController.Update)
+	api.Delete("/admin/announcements/:announcementId", c.AdminMiddleware, c.AnnouncementController.Delete)
+	api.Get("/admin/email-templates", c.AdminMiddleware, c.EmailTemplateController.List)
+	api.Get("/admin/email-templates/:name", c.AdminMiddleware, c.EmailTemplateController.Get)
+	api.Put("/admin/email-templates/:name", c.AdminMiddleware, c.EmailTemplateController.Update)
+	api.Delete("/admin/email-templates/:name", c.AdminMiddleware, c.EmailTemplateController.Delete)
+	api.Post("/admin/email-templates/:name/preview", c.AdminMiddleware, c.EmailTemplateController.Preview)
 }
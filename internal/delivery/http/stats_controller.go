@@ -0,0 +1,99 @@
+package http
+
+import (
+	"go-rest-scaffold/internal/model"
+	"go-rest-scaffold/internal/usecase"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/sirupsen/logrus"
+)
+
+type StatsController struct {
+	Log     *logrus.Logger
+	UseCase *usecase.StatsUseCase
+}
+
+func NewStatsController(useCase *usecase.StatsUseCase, logger *logrus.Logger) *StatsController {
+	return &StatsController{
+		Log:     logger,
+		UseCase: useCase,
+	}
+}
+
+// Get godoc
+// @Summary      Get statistics
+// @Description  Get the total users and resources, the users active today and in the last 7 and 30 days (UTC), and the storage used by every table
+// @Tags         admin
+// @Produce      json
+// @Security     BearerAuth
+// @Security     APIKeyAuth
+// @Success      200 {object} object{data=model.StatsResponse} "Statistics"
+// @Failure      401 {object} object{errors=string} "Unauthorized"
+// @Failure      403 {object} object{errors=string} "Not an admin"
+// @Failure      500 {object} object{errors=string} "Internal server error"
+// @Router       /admin/stats [get]
+func (c *StatsController) Get(ctx *fiber.Ctx) error {
+	response, err := c.UseCase.Get(ctx.UserContext())
+	if err != nil {
+		c.Log.WithContext(ctx.UserContext()).WithError(err).Error("error getting statistics")
+		return err
+	}
+
+	return ctx.JSON(model.WebResponse[*model.StatsResponse]{Data: response})
+}
+
+// Daily godoc
+// @Summary      Get daily statistics
+// @Description  Get the registrations, active users and created contacts of each of the last days (UTC), today included, oldest first
+// @Tags         admin
+// @Produce      json
+// @Security     BearerAuth
+// @Security     APIKeyAuth
+// @Param        days query int false "Number of days" default(30)
+// @Success      200 {object} object{data=[]model.DailyStatsResponse} "Statistics per day"
+// @Failure      400 {object} object{errors=string} "Invalid query"
+// @Failure      401 {object} object{errors=string} "Unauthorized"
+// @Failure      403 {object} object{errors=string} "Not an admin"
+// @Failure      500 {object} object{errors=string} "Internal server error"
+// @Router       /admin/stats/daily [get]
+func (c *StatsController) Daily(ctx *fiber.Ctx) error {
+	request := &model.DailyStatsRequest{
+		Days: ctx.QueryInt("days", 30),
+	}
+
+	responses, err := c.UseCase.Daily(ctx.UserContext(), request)
+	if err != nil {
+		c.Log.WithContext(ctx.UserContext()).WithError(err).Error("error getting daily statistics")
+		return err
+	}
+
+	return ctx.JSON(model.WebResponse[[]model.DailyStatsResponse]{Data: responses})
+}
+
+// TopAccounts godoc
+// @Summary      Get the top accounts
+// @Description  Get the users owning the most contacts, with their contact and address counts, trash left out
+// @Tags         admin
+// @Produce      json
+// @Security     BearerAuth
+// @Security     APIKeyAuth
+// @Param        limit query int false "Number of accounts" default(10)
+// @Success      200 {object} object{data=[]model.TopAccountResponse} "Top accounts"
+// @Failure      400 {object} object{errors=string} "Invalid query"
+// @Failure      401 {object} object{errors=string} "Unauthorized"
+// @Failure      403 {object} object{errors=string} "Not an admin"
+// @Failure      500 {object} object{errors=string} "Internal server error"
+// @Router       /admin/stats/top-accounts [get]
+func (c *StatsController) TopAccounts(ctx *fiber.Ctx) error {
+	request := &model.TopAccountsRequest{
+		Limit: ctx.QueryInt("limit", 10),
+	}
+
+	responses, err := c.UseCase.TopAccounts(ctx.UserContext(), request)
+	if err != nil {
+		c.Log.WithContext(ctx.UserContext()).WithError(err).Error("error getting top accounts")
+		return err
+	}
+
+	return ctx.JSON(model.WebResponse[[]model.TopAccountResponse]{Data: responses})
+}
@@ -0,0 +1,281 @@
+package http
+
+import (
+	"go-rest-scaffold/internal/delivery/http/middleware"
+	"go-rest-scaffold/internal/model"
+	"go-rest-scaffold/internal/usecase"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/sirupsen/logrus"
+)
+
+type TagController struct {
+	UseCase *usecase.TagUseCase
+	Log     *logrus.Logger
+}
+
+func NewTagController(useCase *usecase.TagUseCase, log *logrus.Logger) *TagController {
+	return &TagController{
+		UseCase: useCase,
+		Log:     log,
+	}
+}
+
+// Create godoc
+// @Summary      Create a tag
+// @Description  Create a tag to label contacts with. Names are unique per user, ignoring case; color is an optional hex color such as #1e90ff
+// @Tags         tags
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Security     APIKeyAuth
+// @Param        request body model.CreateTagRequest true "Tag details"
+// @Param        X-Dry-Run header bool false "Run all checks, then roll back instead of committing"
+// @Param        Idempotency-Key header string false "Key of the request, unique per user; retries with the same key replay the first response"
+// @Success      200 {object} object{data=model.TagResponse} "Successfully created tag"
+// @Failure      400 {object} object{errors=string} "Invalid request body"
+// @Failure      401 {object} object{errors=string} "Unauthorized"
+// @Failure      409 {object} object{errors=string} "A tag with this name already exists"
+// @Failure      500 {object} object{errors=string} "Internal server error"
+// @Router       /tags [post]
+func (c *TagController) Create(ctx *fiber.Ctx) error {
+	auth := middleware.GetUser(ctx)
+
+	request := new(model.CreateTagRequest)
+	if err := ctx.BodyParser(request); err != nil {
+		c.Log.WithContext(ctx.UserContext()).WithError(err).Error("error parsing request body")
+		return fiber.ErrBadRequest
+	}
+	request.UserId = auth.ID
+
+	response, err := c.UseCase.Create(ctx.UserContext(), request)
+	if err != nil {
+		c.Log.WithContext(ctx.UserContext()).WithError(err).Error("error creating tag")
+		return err
+	}
+
+	return ctx.JSON(model.WebResponse[*model.TagResponse]{Data: response})
+}
+
+// List godoc
+// @Summary      List tags
+// @Description  List the tags of the authenticated user by name
+// @Tags         tags
+// @Produce      json
+// @Security     BearerAuth
+// @Security     APIKeyAuth
+// @Param        fields query string false "Comma separated fields to return; id is always returned"
+// @Success      200 {object} object{data=[]model.TagResponse} "List of tags"
+// @Failure      401 {object} object{errors=string} "Unauthorized"
+// @Failure      500 {object} object{errors=string} "Internal server error"
+// @Router       /tags [get]
+func (c *TagController) List(ctx *fiber.Ctx) error {
+	auth := middleware.GetUser(ctx)
+
+	request := &model.ListTagRequest{
+		UserId: auth.ID,
+	}
+
+	responses, err := c.UseCase.List(ctx.UserContext(), request)
+	if err != nil {
+		c.Log.WithContext(ctx.UserContext()).WithError(err).Error("error listing tags")
+		return err
+	}
+
+	return ctx.JSON(model.WebResponse[[]model.TagResponse]{Data: responses})
+}
+
+// Get godoc
+// @Summary      Get a tag
+// @Description  Get a tag of the authenticated user by ID
+// @Tags         tags
+// @Produce      json
+// @Security     BearerAuth
+// @Security     APIKeyAuth
+// @Param        tagId path string true "Tag ID"
+// @Param        fields query string false "Comma separated fields to return; id is always returned"
+// @Success      200 {object} object{data=model.TagResponse} "Tag details"
+// @Failure      401 {object} object{errors=string} "Unauthorized"
+// @Failure      404 {object} object{errors=string} "Tag not found"
+// @Failure      500 {object} object{errors=string} "Internal server error"
+// @Router       /tags/{tagId} [get]
+func (c *TagController) Get(ctx *fiber.Ctx) error {
+	auth := middleware.GetUser(ctx)
+
+	request := &model.GetTagRequest{
+		UserId: auth.ID,
+		ID:     ctx.Params("tagId"),
+	}
+
+	response, err := c.UseCase.Get(ctx.UserContext(), request)
+	if err != nil {
+		c.Log.WithContext(ctx.UserContext()).WithError(err).Error("error getting tag")
+		return err
+	}
+
+	return ctx.JSON(model.WebResponse[*model.TagResponse]{Data: response})
+}
+
+// Update godoc
+// @Summary      Update a tag
+// @Description  Rename or recolor a tag of the authenticated user; it stays assigned to its contacts
+// @Tags         tags
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Security     APIKeyAuth
+// @Param        tagId path string true "Tag ID"
+// @Param        request body model.UpdateTagRequest true "Tag details"
+// @Param        X-Dry-Run header bool false "Run all checks, then roll back instead of committing"
+// @Success      200 {object} object{data=model.TagResponse} "Successfully updated tag"
+// @Failure      400 {object} object{errors=string} "Invalid request body"
+// @Failure      401 {object} object{errors=string} "Unauthorized"
+// @Failure      404 {object} object{errors=string} "Tag not found"
+// @Failure      409 {object} object{errors=string} "A tag with this name already exists"
+// @Failure      500 {object} object{errors=string} "Internal server error"
+// @Router       /tags/{tagId} [put]
+func (c *TagController) Update(ctx *fiber.Ctx) error {
+	auth := middleware.GetUser(ctx)
+
+	request := new(model.UpdateTagRequest)
+	if err := ctx.BodyParser(request); err != nil {
+		c.Log.WithContext(ctx.UserContext()).WithError(err).Error("error parsing request body")
+		return fiber.ErrBadRequest
+	}
+	request.UserId = auth.ID
+	request.ID = ctx.Params("tagId")
+
+	response, err := c.UseCase.Update(ctx.UserContext(), request)
+	if err != nil {
+		c.Log.WithContext(ctx.UserContext()).WithError(err).Error("error updating tag")
+		return err
+	}
+
+	return ctx.JSON(model.WebResponse[*model.TagResponse]{Data: response})
+}
+
+// Delete godoc
+// @Summary      Delete a tag
+// @Description  Delete a tag of the authenticated user and take it off every contact
+// @Tags         tags
+// @Produce      json
+// @Security     BearerAuth
+// @Security     APIKeyAuth
+// @Param        tagId path string true "Tag ID"
+// @Param        X-Dry-Run header bool false "Run all checks, then roll back instead of committing"
+// @Success      200 {object} object{data=bool} "Successfully deleted tag"
+// @Failure      401 {object} object{errors=string} "Unauthorized"
+// @Failure      404 {object} object{errors=string} "Tag not found"
+// @Failure      500 {object} object{errors=string} "Internal server error"
+// @Router       /tags/{tagId} [delete]
+func (c *TagController) Delete(ctx *fiber.Ctx) error {
+	auth := middleware.GetUser(ctx)
+
+	request := &model.DeleteTagRequest{
+		UserId: auth.ID,
+		ID:     ctx.Params("tagId"),
+	}
+
+	if err := c.UseCase.Delete(ctx.UserContext(), request); err != nil {
+		c.Log.WithContext(ctx.UserContext()).WithError(err).Error("error deleting tag")
+		return err
+	}
+
+	return ctx.JSON(model.WebResponse[bool]{Data: true})
+}
+
+// ListByContact godoc
+// @Summary      List the tags of a contact
+// @Description  List the tags assigned to a contact by name
+// @Tags         tags
+// @Produce      json
+// @Security     BearerAuth
+// @Security     APIKeyAuth
+// @Param        contactId path string true "Contact ID"
+// @Success      200 {object} object{data=[]model.TagResponse} "Tags of the contact"
+// @Failure      401 {object} object{errors=string} "Unauthorized"
+// @Failure      404 {object} object{errors=string} "Contact not found"
+// @Failure      500 {object} object{errors=string} "Internal server error"
+// @Router       /contacts/{contactId}/tags [get]
+func (c *TagController) ListByContact(ctx *fiber.Ctx) error {
+	auth := middleware.GetUser(ctx)
+
+	request := &model.ListContactTagRequest{
+		UserId:    auth.ID,
+		ContactId: ctx.Params("contactId"),
+	}
+
+	responses, err := c.UseCase.ListByContact(ctx.UserContext(), request)
+	if err != nil {
+		c.Log.WithContext(ctx.UserContext()).WithError(err).Error("error listing contact tags")
+		return err
+	}
+
+	return ctx.JSON(model.WebResponse[[]model.TagResponse]{Data: responses})
+}
+
+// Assign godoc
+// @Summary      Assign a tag to a contact
+// @Description  Assign a tag to a contact and return the tags of the contact; assigning a tag the contact has already changes nothing
+// @Tags         tags
+// @Produce      json
+// @Security     BearerAuth
+// @Security     APIKeyAuth
+// @Param        contactId path string true "Contact ID"
+// @Param        tagId path string true "Tag ID"
+// @Param        X-Dry-Run header bool false "Run all checks, then roll back instead of committing"
+// @Success      200 {object} object{data=[]model.TagResponse} "Tags of the contact"
+// @Failure      401 {object} object{errors=string} "Unauthorized"
+// @Failure      404 {object} object{errors=string} "Contact or tag not found"
+// @Failure      500 {object} object{errors=string} "Internal server error"
+// @Router       /contacts/{contactId}/tags/{tagId} [put]
+func (c *TagController) Assign(ctx *fiber.Ctx) error {
+	auth := middleware.GetUser(ctx)
+
+	request := &model.AssignTagRequest{
+		UserId:    auth.ID,
+		ContactId: ctx.Params("contactId"),
+		TagId:     ctx.Params("tagId"),
+	}
+
+	responses, err := c.UseCase.Assign(ctx.UserContext(), request)
+	if err != nil {
+		c.Log.WithContext(ctx.UserContext()).WithError(err).Error("error assigning tag")
+		return err
+	}
+
+	return ctx.JSON(model.WebResponse[[]model.TagResponse]{Data: responses})
+}
+
+// Unassign godoc
+// @Summary      Take a tag off a contact
+// @Description  Take a tag off a contact and return the tags the contact has left; taking off a tag the contact does not have changes nothing
+// @Tags         tags
+// @Produce      json
+// @Security     BearerAuth
+// @Security     APIKeyAuth
+// @Param        contactId path string true "Contact ID"
+// @Param        tagId path string true "Tag ID"
+// @Param        X-Dry-Run header bool false "Run all checks, then roll back instead of committing"
+// @Success      200 {object} object{data=[]model.TagResponse} "Tags of the contact"
+// @Failure      401 {object} object{errors=string} "Unauthorized"
+// @Failure      404 {object} object{errors=string} "Contact or tag not found"
+// @Failure      500 {object} object{errors=string} "Internal server error"
+// @Router       /contacts/{contactId}/tags/{tagId} [delete]
+func (c *TagController) Unassign(ctx *fiber.Ctx) error {
+	auth := middleware.GetUser(ctx)
+
+	request := &model.AssignTagRequest{
+		UserId:    auth.ID,
+		ContactId: ctx.Params("contactId"),
+		TagId:     ctx.Params("tagId"),
+	}
+
+	responses, err := c.UseCase.Unassign(ctx.UserContext(), request)
+	if err != nil {
+		c.Log.WithContext(ctx.UserContext()).WithError(err).Error("error unassigning tag")
+		return err
+	}
+
+	return ctx.JSON(model.WebResponse[[]model.TagResponse]{Data: responses})
+}
@@ -0,0 +1,187 @@
+package http
+
+import (
+	"go-rest-scaffold/internal/delivery/http/middleware"
+	"go-rest-scaffold/internal/model"
+	"go-rest-scaffold/internal/usecase"
+	"math"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/sirupsen/logrus"
+)
+
+type TrashController struct {
+	Log     *logrus.Logger
+	UseCase *usecase.TrashUseCase
+}
+
+func NewTrashController(useCase *usecase.TrashUseCase, logger *logrus.Logger) *TrashController {
+	return &TrashController{
+		Log:     logger,
+		UseCase: useCase,
+	}
+}
+
+// List godoc
+// @Summary      List the trash
+// @Description  List the deleted contacts and addresses of the authenticated user, most recently deleted first, with the time left before each is deleted for good
+// @Tags         trash
+// @Produce      json
+// @Security     BearerAuth
+// @Security     APIKeyAuth
+// @Param        type query string false "Only items of this type" Enums(contact, address)
+// @Param        page query int false "Page number" default(1)
+// @Param        size query int false "Page size" default(10)
+// @Success      200 {object} object{data=[]model.TrashItemResponse,paging=model.PageMetadata} "List of deleted items with pagination"
+// @Failure      400 {object} object{errors=string} "Invalid query"
+// @Failure      401 {object} object{errors=string} "Unauthorized"
+// @Failure      500 {object} object{errors=string} "Internal server error"
+// @Router       /trash [get]
+func (c *TrashController) List(ctx *fiber.Ctx) error {
+	return c.search(ctx, ctx.Query("type", ""))
+}
+
+// search lists the trash of the authenticated user, only items of itemType unless it is empty.
+func (c *TrashController) search(ctx *fiber.Ctx, itemType string) error {
+	auth := middleware.GetUser(ctx)
+
+	request := &model.SearchTrashRequest{
+		UserId: auth.ID,
+		Type:   itemType,
+		Page:   ctx.QueryInt("page", 1),
+		Size:   ctx.QueryInt("size", 10),
+	}
+
+	responses, total, err := c.UseCase.Search(ctx.UserContext(), request)
+	if err != nil {
+		c.Log.WithContext(ctx.UserContext()).WithError(err).Error("error searching trash")
+		return err
+	}
+
+	paging := &model.PageMetadata{
+		Page:      request.Page,
+		Size:      request.Size,
+		TotalItem: total,
+		TotalPage: int64(math.Ceil(float64(total) / float64(request.Size))),
+	}
+
+	return ctx.JSON(model.WebResponse[[]model.TrashItemResponse]{
+		Data:   responses,
+		Paging: paging,
+	})
+}
+
+// ListContacts godoc
+// @Summary      List deleted contacts
+// @Description  List the deleted contacts of the authenticated user, most recently deleted first, with the time left before each is deleted for good
+// @Tags         trash
+// @Produce      json
+// @Security     BearerAuth
+// @Security     APIKeyAuth
+// @Param        page query int false "Page number" default(1)
+// @Param        size query int false "Page size" default(10)
+// @Success      200 {object} object{data=[]model.TrashItemResponse,paging=model.PageMetadata} "List of deleted contacts with pagination"
+// @Failure      400 {object} object{errors=string} "Invalid query"
+// @Failure      401 {object} object{errors=string} "Unauthorized"
+// @Failure      500 {object} object{errors=string} "Internal server error"
+// @Router       /contacts/_trash [get]
+func (c *TrashController) ListContacts(ctx *fiber.Ctx) error {
+	return c.search(ctx, model.TrashContact)
+}
+
+// RestoreContact godoc
+// @Summary      Restore a deleted contact
+// @Description  Take a contact out of the trash together with its addresses, except those deleted on their own
+// @Tags         trash
+// @Produce      json
+// @Security     BearerAuth
+// @Security     APIKeyAuth
+// @Param        contactId path string true "Contact ID"
+// @Param        X-Dry-Run header bool false "Run all checks, then roll back instead of committing"
+// @Success      200 {object} object{data=model.ContactResponse} "Restored contact"
+// @Failure      400 {object} object{errors=string} "Invalid contact ID"
+// @Failure      401 {object} object{errors=string} "Unauthorized"
+// @Failure      404 {object} object{errors=string} "Contact not in the trash"
+// @Failure      500 {object} object{errors=string} "Internal server error"
+// @Router       /contacts/{contactId}/_restore [post]
+func (c *TrashController) RestoreContact(ctx *fiber.Ctx) error {
+	auth := middleware.GetUser(ctx)
+
+	request := &model.RestoreContactRequest{
+		UserId: auth.ID,
+		ID:     ctx.Params("contactId"),
+	}
+
+	response, err := c.UseCase.RestoreContact(ctx.UserContext(), request)
+	if err != nil {
+		c.Log.WithContext(ctx.UserContext()).WithError(err).Error("error restoring contact")
+		return err
+	}
+
+	return ctx.JSON(model.WebResponse[*model.ContactResponse]{Data: response})
+}
+
+// Restore godoc
+// @Summary      Restore from the trash
+// @Description  Restore a batch of deleted contacts and addresses, all or nothing. An address can only be restored once its contact is, earlier in the same batch or before
+// @Tags         trash
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Security     APIKeyAuth
+// @Param        request body model.RestoreTrashRequest true "Items to restore"
+// @Param        X-Dry-Run header bool false "Run all checks, then roll back instead of committing"
+// @Success      200 {object} object{data=model.RestoreTrashResponse} "Number of restored items"
+// @Failure      400 {object} object{errors=string} "Invalid request body"
+// @Failure      401 {object} object{errors=string} "Unauthorized"
+// @Failure      404 {object} object{errors=string} "Item not in the trash"
+// @Failure      409 {object} object{errors=string} "Contact of an address is still in the trash"
+// @Failure      500 {object} object{errors=string} "Internal server error"
+// @Router       /trash/_restore [post]
+func (c *TrashController) Restore(ctx *fiber.Ctx) error {
+	auth := middleware.GetUser(ctx)
+
+	request := new(model.RestoreTrashRequest)
+	if err := ctx.BodyParser(request); err != nil {
+		c.Log.WithContext(ctx.UserContext()).WithError(err).Error("error parsing request body")
+		return fiber.ErrBadRequest
+	}
+	request.UserId = auth.ID
+
+	response, err := c.UseCase.Restore(ctx.UserContext(), request)
+	if err != nil {
+		c.Log.WithContext(ctx.UserContext()).WithError(err).Error("error restoring trash")
+		return err
+	}
+
+	return ctx.JSON(model.WebResponse[*model.RestoreTrashResponse]{Data: response})
+}
+
+// Empty godoc
+// @Summary      Empty the trash
+// @Description  Delete everything in the trash of the authenticated user for good
+// @Tags         trash
+// @Produce      json
+// @Security     BearerAuth
+// @Security     APIKeyAuth
+// @Param        X-Dry-Run header bool false "Run all checks, then roll back instead of committing"
+// @Param        X-Signature header string false "t=<Unix seconds>,v1=<HMAC-SHA256>, required with an API key when request_signing.enabled"
+// @Success      200 {object} object{data=model.EmptyTrashResponse} "Number of deleted rows"
+// @Failure      401 {object} object{errors=string} "Unauthorized, or missing, invalid or reused signature"
+// @Failure      500 {object} object{errors=string} "Internal server error"
+// @Router       /trash [delete]
+func (c *TrashController) Empty(ctx *fiber.Ctx) error {
+	auth := middleware.GetUser(ctx)
+
+	request := &model.EmptyTrashRequest{
+		UserId: auth.ID,
+	}
+
+	response, err := c.UseCase.Empty(ctx.UserContext(), request)
+	if err != nil {
+		c.Log.WithContext(ctx.UserContext()).WithError(err).Error("error emptying trash")
+		return err
+	}
+
+	return ctx.JSON(model.WebResponse[*model.EmptyTrashResponse]{Data: response})
+}
@@ -0,0 +1,49 @@
+package http
+
+import (
+	"go-rest-scaffold/internal/delivery/http/middleware"
+	"go-rest-scaffold/internal/model"
+	"go-rest-scaffold/internal/usecase"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/sirupsen/logrus"
+)
+
+type UsageController struct {
+	UseCase *usecase.UsageUseCase
+	Log     *logrus.Logger
+}
+
+func NewUsageController(useCase *usecase.UsageUseCase, log *logrus.Logger) *UsageController {
+	return &UsageController{
+		UseCase: useCase,
+		Log:     log,
+	}
+}
+
+// Get godoc
+// @Summary      Get current usage
+// @Description  Get how many requests the authenticated user and each of their API keys made today, per UTC day and including this request, against the daily quotas. Requests over a quota are refused with 429 until reset
+// @Tags         users
+// @Produce      json
+// @Security     BearerAuth
+// @Security     APIKeyAuth
+// @Success      200 {object} object{data=model.UsageResponse} "Requests made today"
+// @Failure      401 {object} object{errors=string} "Unauthorized"
+// @Failure      429 {object} object{errors=string} "Daily quota used up"
+// @Router       /users/_current/usage [get]
+func (c *UsageController) Get(ctx *fiber.Ctx) error {
+	auth := middleware.GetUser(ctx)
+
+	request := &model.GetUsageRequest{
+		UserId: auth.ID,
+	}
+
+	response, err := c.UseCase.Get(ctx.UserContext(), request)
+	if err != nil {
+		c.Log.WithContext(ctx.UserContext()).WithError(err).Error("error getting usage")
+		return err
+	}
+
+	return ctx.JSON(model.WebResponse[*model.UsageResponse]{Data: response})
+}
@@ -9,6 +9,10 @@ import (
 	"github.com/sirupsen/logrus"
 )
 
+// errImpersonating refuses admins impersonating a user the credentials that would let
+// them act as the user after their impersonation token expires.
+var errImpersonating = fiber.NewError(fiber.StatusForbidden, "not allowed while impersonating a user")
+
 type UserController struct {
 	Log     *logrus.Logger
 	UseCase *usecase.UserUseCase
@@ -28,21 +32,23 @@ func NewUserController(useCase *usecase.UserUseCase, logger *logrus.Logger) *Use
 // @Accept       json
 // @Produce      json
 // @Param        request body model.RegisterUserRequest true "User registration details"
+// @Param        X-Dry-Run header bool false "Run all checks, then roll back instead of committing"
 // @Success      200 {object} object{data=model.UserResponse} "Successfully registered user"
 // @Failure      400 {object} object{errors=string} "Invalid request body"
+// @Failure      409 {object} object{errors=string} "User ID or email already in use"
 // @Failure      500 {object} object{errors=string} "Internal server error"
 // @Router       /users [post]
 func (c *UserController) Register(ctx *fiber.Ctx) error {
 	request := new(model.RegisterUserRequest)
 	err := ctx.BodyParser(request)
 	if err != nil {
-		c.Log.Warnf("Failed to parse request body : %+v", err)
+		c.Log.WithContext(ctx.UserContext()).Warnf("Failed to parse request body : %+v", err)
 		return fiber.ErrBadRequest
 	}
 
 	response, err := c.UseCase.Create(ctx.UserContext(), request)
 	if err != nil {
-		c.Log.Warnf("Failed to register user : %+v", err)
+		c.Log.WithContext(ctx.UserContext()).Warnf("Failed to register user : %+v", err)
 		return err
 	}
 
@@ -59,19 +65,23 @@ func (c *UserController) Register(ctx *fiber.Ctx) error {
 // @Success      200 {object} object{data=model.UserResponse} "Successfully logged in with token"
 // @Failure      400 {object} object{errors=string} "Invalid request body"
 // @Failure      401 {object} object{errors=string} "Invalid credentials"
+// @Failure      403 {object} object{errors=string} "Email address not verified, when verification is required"
 // @Failure      500 {object} object{errors=string} "Internal server error"
 // @Router       /users/_login [post]
 func (c *UserController) Login(ctx *fiber.Ctx) error {
 	request := new(model.LoginUserRequest)
 	err := ctx.BodyParser(request)
 	if err != nil {
-		c.Log.Warnf("Failed to parse request body : %+v", err)
+		c.Log.WithContext(ctx.UserContext()).Warnf("Failed to parse request body : %+v", err)
 		return fiber.ErrBadRequest
 	}
 
+	request.IP = ctx.IP()
+	request.UserAgent = ctx.Get(fiber.HeaderUserAgent)
+
 	response, err := c.UseCase.Login(ctx.UserContext(), request)
 	if err != nil {
-		c.Log.Warnf("Failed to login user : %+v", err)
+		c.Log.WithContext(ctx.UserContext()).Warnf("Failed to login user : %+v", err)
 		return err
 	}
 
@@ -85,6 +95,8 @@ func (c *UserController) Login(ctx *fiber.Ctx) error {
 // @Accept       json
 // @Produce      json
 // @Security     BearerAuth
+// @Security     APIKeyAuth
+// @Param        fields query string false "Comma separated fields to return; id is always returned"
 // @Success      200 {object} object{data=model.UserResponse} "Current user information"
 // @Failure      401 {object} object{errors=string} "Unauthorized"
 // @Failure      500 {object} object{errors=string} "Internal server error"
@@ -98,20 +110,36 @@ func (c *UserController) Current(ctx *fiber.Ctx) error {
 
 	response, err := c.UseCase.Current(ctx.UserContext(), request)
 	if err != nil {
-		c.Log.WithError(err).Warnf("Failed to get current user")
+		c.Log.WithContext(ctx.UserContext()).WithError(err).Warnf("Failed to get current user")
 		return err
 	}
 
 	return ctx.JSON(model.WebResponse[*model.UserResponse]{Data: response})
 }
 
+// RateLimit godoc
+// @Summary      Get current rate limit budget
+// @Description  Get the rate limit budget left to the authenticated user, including this request; the same values are sent on every response as RateLimit-* and X-RateLimit-* headers
+// @Tags         users
+// @Produce      json
+// @Security     BearerAuth
+// @Security     APIKeyAuth
+// @Success      200 {object} object{data=model.RateLimitResponse} "Current rate limit budget"
+// @Failure      401 {object} object{errors=string} "Unauthorized"
+// @Failure      429 {object} object{errors=string} "Rate limit exceeded"
+// @Router       /users/_current/rate-limit [get]
+func (c *UserController) RateLimit(ctx *fiber.Ctx) error {
+	return ctx.JSON(model.WebResponse[*model.RateLimitResponse]{Data: middleware.GetRateLimit(ctx)})
+}
+
 // Logout godoc
 // @Summary      User logout
-// @Description  Logout the currently authenticated user and invalidate token
+// @Description  Logout the currently authenticated user from every session and invalidate their tokens
 // @Tags         users
 // @Accept       json
 // @Produce      json
 // @Security     BearerAuth
+// @Security     APIKeyAuth
 // @Success      200 {object} object{data=bool} "Successfully logged out"
 // @Failure      401 {object} object{errors=string} "Unauthorized"
 // @Failure      500 {object} object{errors=string} "Internal server error"
@@ -125,13 +153,164 @@ func (c *UserController) Logout(ctx *fiber.Ctx) error {
 
 	response, err := c.UseCase.Logout(ctx.UserContext(), request)
 	if err != nil {
-		c.Log.WithError(err).Warnf("Failed to logout user")
+		c.Log.WithContext(ctx.UserContext()).WithError(err).Warnf("Failed to logout user")
 		return err
 	}
 
 	return ctx.JSON(model.WebResponse[bool]{Data: response})
 }
 
+// Delete godoc
+// @Summary      Delete account
+// @Description  Delete the account of the authenticated user: their contacts go to the trash, every session and API key is revoked, and the account with all its data is purged for good after the grace period
+// @Tags         users
+// @Produce      json
+// @Security     BearerAuth
+// @Security     APIKeyAuth
+// @Param        X-Signature header string false "t=<Unix seconds>,v1=<HMAC-SHA256>, required with an API key when request_signing.enabled"
+// @Success      200 {object} object{data=model.DeleteUserResponse} "Account deleted"
+// @Failure      401 {object} object{errors=string} "Unauthorized, or missing, invalid or reused signature"
+// @Failure      500 {object} object{errors=string} "Internal server error"
+// @Router       /users/_current [delete]
+func (c *UserController) Delete(ctx *fiber.Ctx) error {
+	auth := middleware.GetUser(ctx)
+
+	request := &model.DeleteUserRequest{
+		ID: auth.ID,
+	}
+
+	response, err := c.UseCase.Delete(ctx.UserContext(), request)
+	if err != nil {
+		c.Log.WithContext(ctx.UserContext()).WithError(err).Warnf("Failed to delete user")
+		return err
+	}
+
+	return ctx.JSON(model.WebResponse[*model.DeleteUserResponse]{Data: response})
+}
+
+// Sessions godoc
+// @Summary      List sessions
+// @Description  List the logins of the authenticated user with their device, IP, user agent and last activity; current marks the session of this request
+// @Tags         users
+// @Produce      json
+// @Security     BearerAuth
+// @Security     APIKeyAuth
+// @Success      200 {object} object{data=[]model.SessionResponse} "List of sessions"
+// @Failure      401 {object} object{errors=string} "Unauthorized"
+// @Failure      500 {object} object{errors=string} "Internal server error"
+// @Router       /users/_sessions [get]
+func (c *UserController) Sessions(ctx *fiber.Ctx) error {
+	auth := middleware.GetUser(ctx)
+
+	request := &model.ListSessionRequest{
+		UserId:    auth.ID,
+		SessionId: auth.SessionId,
+	}
+
+	responses, err := c.UseCase.Sessions(ctx.UserContext(), request)
+	if err != nil {
+		c.Log.WithContext(ctx.UserContext()).WithError(err).Warnf("Failed to list sessions")
+		return err
+	}
+
+	return ctx.JSON(model.WebResponse[[]model.SessionResponse]{Data: responses})
+}
+
+// RevokeSession godoc
+// @Summary      Revoke a session
+// @Description  Sign the authenticated user out of one session; its access and refresh token stop working while other sessions stay signed in
+// @Tags         users
+// @Produce      json
+// @Security     BearerAuth
+// @Security     APIKeyAuth
+// @Param        sessionId path string true "Session ID"
+// @Success      200 {object} object{data=bool} "Successfully revoked session"
+// @Failure      401 {object} object{errors=string} "Unauthorized"
+// @Failure      404 {object} object{errors=string} "Session not found"
+// @Failure      500 {object} object{errors=string} "Internal server error"
+// @Router       /users/_sessions/{sessionId} [delete]
+func (c *UserController) RevokeSession(ctx *fiber.Ctx) error {
+	auth := middleware.GetUser(ctx)
+
+	request := &model.RevokeSessionRequest{
+		UserId: auth.ID,
+		ID:     ctx.Params("sessionId"),
+	}
+
+	response, err := c.UseCase.RevokeSession(ctx.UserContext(), request)
+	if err != nil {
+		c.Log.WithContext(ctx.UserContext()).WithError(err).Warnf("Failed to revoke session")
+		return err
+	}
+
+	return ctx.JSON(model.WebResponse[bool]{Data: response})
+}
+
+// RevokeUserSessions godoc
+// @Summary      Revoke the sessions of a user
+// @Description  Sign a user out of every session; their access and refresh tokens stop working at once
+// @Tags         admin
+// @Produce      json
+// @Security     BearerAuth
+// @Security     APIKeyAuth
+// @Param        userId path string true "User ID"
+// @Param        X-Dry-Run header bool false "Run all checks, then roll back instead of committing"
+// @Success      200 {object} object{data=bool} "Successfully revoked sessions"
+// @Failure      401 {object} object{errors=string} "Unauthorized"
+// @Failure      403 {object} object{errors=string} "Not an admin"
+// @Failure      404 {object} object{errors=string} "User not found"
+// @Failure      500 {object} object{errors=string} "Internal server error"
+// @Router       /admin/users/{userId}/sessions [delete]
+func (c *UserController) RevokeUserSessions(ctx *fiber.Ctx) error {
+	request := &model.RevokeUserSessionsRequest{
+		UserId: ctx.Params("userId"),
+	}
+
+	response, err := c.UseCase.RevokeUserSessions(ctx.UserContext(), request)
+	if err != nil {
+		c.Log.WithContext(ctx.UserContext()).WithError(err).Warnf("Failed to revoke sessions of user")
+		return err
+	}
+
+	return ctx.JSON(model.WebResponse[bool]{Data: response})
+}
+
+// Impersonate godoc
+// @Summary      Impersonate a user
+// @Description  Get a short-lived access token acting as a user, valid for impersonation.ttl seconds and without a refresh token. The admin is kept in the token and recorded in the audit log, with every change made with it. Admins cannot be impersonated
+// @Tags         admin
+// @Produce      json
+// @Security     BearerAuth
+// @Param        userId path string true "User ID"
+// @Param        X-Dry-Run header bool false "Run all checks, then roll back instead of committing"
+// @Success      200 {object} object{data=model.ImpersonationResponse} "Impersonation token"
+// @Failure      400 {object} object{errors=string} "Impersonating oneself"
+// @Failure      401 {object} object{errors=string} "Unauthorized"
+// @Failure      403 {object} object{errors=string} "Not an admin, authenticated with an API key, or the user is an admin"
+// @Failure      404 {object} object{errors=string} "User not found"
+// @Failure      500 {object} object{errors=string} "Internal server error"
+// @Router       /admin/users/{userId}/_impersonate [post]
+func (c *UserController) Impersonate(ctx *fiber.Ctx) error {
+	// the admin must be named by a session of theirs, not a key shared by machines
+	auth := middleware.GetUser(ctx)
+	if auth.APIKeyId != "" {
+		return fiber.NewError(fiber.StatusForbidden, "users cannot be impersonated with an api key")
+	}
+
+	request := &model.ImpersonateUserRequest{
+		AdminId: auth.ID,
+		UserId:  ctx.Params("userId"),
+	}
+
+	response, err := c.UseCase.Impersonate(ctx.UserContext(), request)
+	if err != nil {
+		c.Log.WithContext(ctx.UserContext()).WithError(err).Warnf("Failed to impersonate user")
+		return err
+	}
+
+	return ctx.JSON(model.WebResponse[*model.ImpersonationResponse]{Data: response})
+}
+
 // Update godoc
 // @Summary      Update current user
 // @Description  Update the currently authenticated user's information (name and/or password)
@@ -139,10 +318,13 @@ func (c *UserController) Logout(ctx *fiber.Ctx) error {
 // @Accept       json
 // @Produce      json
 // @Security     BearerAuth
+// @Security     APIKeyAuth
 // @Param        request body model.UpdateUserRequest true "User update details"
+// @Param        X-Dry-Run header bool false "Run all checks, then roll back instead of committing"
 // @Success      200 {object} object{data=model.UserResponse} "Successfully updated user"
 // @Failure      400 {object} object{errors=string} "Invalid request body"
 // @Failure      401 {object} object{errors=string} "Unauthorized"
+// @Failure      403 {object} object{errors=string} "Password changed while impersonating the user"
 // @Failure      500 {object} object{errors=string} "Internal server error"
 // @Router       /users/_current [patch]
 func (c *UserController) Update(ctx *fiber.Ctx) error {
@@ -150,14 +332,17 @@ func (c *UserController) Update(ctx *fiber.Ctx) error {
 
 	request := new(model.UpdateUserRequest)
 	if err := ctx.BodyParser(request); err != nil {
-		c.Log.Warnf("Failed to parse request body : %+v", err)
+		c.Log.WithContext(ctx.UserContext()).Warnf("Failed to parse request body : %+v", err)
 		return fiber.ErrBadRequest
 	}
+	if auth.ImpersonatorId != "" && request.Password != "" {
+		return errImpersonating
+	}
 
 	request.ID = auth.ID
 	response, err := c.UseCase.Update(ctx.UserContext(), request)
 	if err != nil {
-		c.Log.WithError(err).Warnf("Failed to update user")
+		c.Log.WithContext(ctx.UserContext()).WithError(err).Warnf("Failed to update user")
 		return err
 	}
 
@@ -166,7 +351,7 @@ func (c *UserController) Update(ctx *fiber.Ctx) error {
 
 // RefreshToken godoc
 // @Summary      Refresh access token
-// @Description  Get a new access token using a valid refresh token
+// @Description  Get a new access token using a valid refresh token. Renamed to POST /users/_refresh in v2
 // @Tags         users
 // @Accept       json
 // @Produce      json
@@ -176,18 +361,180 @@ func (c *UserController) Update(ctx *fiber.Ctx) error {
 // @Failure      401 {object} object{errors=string} "Invalid refresh token"
 // @Failure      500 {object} object{errors=string} "Internal server error"
 // @Router       /users/refresh-token [post]
+// @Deprecated
 func (c *UserController) RefreshToken(ctx *fiber.Ctx) error {
 	request := new(model.RefreshTokenRequest)
 	if err := ctx.BodyParser(request); err != nil {
-		c.Log.Warnf("Failed to parse request body : %+v", err)
+		c.Log.WithContext(ctx.UserContext()).Warnf("Failed to parse request body : %+v", err)
 		return fiber.ErrBadRequest
 	}
 
 	response, err := c.UseCase.RefreshToken(ctx.UserContext(), request)
 	if err != nil {
-		c.Log.Warnf("Failed to refresh token : %+v", err)
+		c.Log.WithContext(ctx.UserContext()).Warnf("Failed to refresh token : %+v", err)
+		return err
+	}
+
+	return ctx.JSON(model.WebResponse[*model.UserResponse]{Data: response})
+}
+
+// ForgotPassword godoc
+// @Summary      Request a password reset
+// @Description  Email a single-use password reset link to the user with this email address. The response is the same whether or not such a user exists
+// @Tags         users
+// @Accept       json
+// @Produce      json
+// @Param        request body model.ForgotPasswordRequest true "Email address of the account"
+// @Success      200 {object} object{data=bool} "Reset link sent if the address belongs to a user"
+// @Failure      400 {object} object{errors=string} "Invalid request body"
+// @Failure      500 {object} object{errors=string} "Internal server error"
+// @Router       /users/_forgot-password [post]
+func (c *UserController) ForgotPassword(ctx *fiber.Ctx) error {
+	request := new(model.ForgotPasswordRequest)
+	if err := ctx.BodyParser(request); err != nil {
+		c.Log.WithContext(ctx.UserContext()).Warnf("Failed to parse request body : %+v", err)
+		return fiber.ErrBadRequest
+	}
+
+	response, err := c.UseCase.ForgotPassword(ctx.UserContext(), request)
+	if err != nil {
+		c.Log.WithContext(ctx.UserContext()).Warnf("Failed to request password reset : %+v", err)
+		return err
+	}
+
+	return ctx.JSON(model.WebResponse[bool]{Data: response})
+}
+
+// SendMagicLink godoc
+// @Summary      Request a magic link
+// @Description  Email a single-use login link to the user with this email address. The response is the same whether or not such a user exists
+// @Tags         users
+// @Accept       json
+// @Produce      json
+// @Param        request body model.MagicLinkRequest true "Email address of the account"
+// @Success      200 {object} object{data=bool} "Login link sent if the address belongs to a user"
+// @Failure      400 {object} object{errors=string} "Invalid request body"
+// @Failure      500 {object} object{errors=string} "Internal server error"
+// @Router       /users/_magic-link [post]
+func (c *UserController) SendMagicLink(ctx *fiber.Ctx) error {
+	request := new(model.MagicLinkRequest)
+	if err := ctx.BodyParser(request); err != nil {
+		c.Log.WithContext(ctx.UserContext()).Warnf("Failed to parse request body : %+v", err)
+		return fiber.ErrBadRequest
+	}
+
+	response, err := c.UseCase.SendMagicLink(ctx.UserContext(), request)
+	if err != nil {
+		c.Log.WithContext(ctx.UserContext()).Warnf("Failed to request magic link : %+v", err)
+		return err
+	}
+
+	return ctx.JSON(model.WebResponse[bool]{Data: response})
+}
+
+// ExchangeMagicLink godoc
+// @Summary      Log in with a magic link
+// @Description  Exchange the token of a magic link email for an access and refresh token. The token can be used once
+// @Tags         users
+// @Accept       json
+// @Produce      json
+// @Param        request body model.ExchangeMagicLinkRequest true "Magic link token"
+// @Success      200 {object} object{data=model.UserResponse} "Successfully logged in"
+// @Failure      400 {object} object{errors=string} "Invalid request body"
+// @Failure      401 {object} object{errors=string} "Invalid, used or expired token"
+// @Failure      500 {object} object{errors=string} "Internal server error"
+// @Router       /users/_magic-link/_exchange [post]
+func (c *UserController) ExchangeMagicLink(ctx *fiber.Ctx) error {
+	request := new(model.ExchangeMagicLinkRequest)
+	if err := ctx.BodyParser(request); err != nil {
+		c.Log.WithContext(ctx.UserContext()).Warnf("Failed to parse request body : %+v", err)
+		return fiber.ErrBadRequest
+	}
+
+	request.IP = ctx.IP()
+	request.UserAgent = ctx.Get(fiber.HeaderUserAgent)
+
+	response, err := c.UseCase.ExchangeMagicLink(ctx.UserContext(), request)
+	if err != nil {
+		c.Log.WithContext(ctx.UserContext()).Warnf("Failed to log in with magic link : %+v", err)
 		return err
 	}
 
 	return ctx.JSON(model.WebResponse[*model.UserResponse]{Data: response})
 }
+
+// ResetPassword godoc
+// @Summary      Reset the password
+// @Description  Set a new password with the token of a password reset email. The token can be used once and the user is signed out everywhere
+// @Tags         users
+// @Accept       json
+// @Produce      json
+// @Param        request body model.ResetPasswordRequest true "Reset token and new password"
+// @Success      200 {object} object{data=bool} "Password changed"
+// @Failure      400 {object} object{errors=string} "Invalid request body or invalid or expired token"
+// @Failure      500 {object} object{errors=string} "Internal server error"
+// @Router       /users/_reset-password [post]
+func (c *UserController) ResetPassword(ctx *fiber.Ctx) error {
+	request := new(model.ResetPasswordRequest)
+	if err := ctx.BodyParser(request); err != nil {
+		c.Log.WithContext(ctx.UserContext()).Warnf("Failed to parse request body : %+v", err)
+		return fiber.ErrBadRequest
+	}
+
+	response, err := c.UseCase.ResetPassword(ctx.UserContext(), request)
+	if err != nil {
+		c.Log.WithContext(ctx.UserContext()).Warnf("Failed to reset password : %+v", err)
+		return err
+	}
+
+	return ctx.JSON(model.WebResponse[bool]{Data: response})
+}
+
+// VerifyEmail godoc
+// @Summary      Verify the email address
+// @Description  Confirm the email address of a user with the token of the verification email sent on registration or when the address changes
+// @Tags         users
+// @Produce      json
+// @Param        token query string true "Verification token"
+// @Success      200 {object} object{data=bool} "Email address verified"
+// @Failure      400 {object} object{errors=string} "Invalid or expired token"
+// @Failure      500 {object} object{errors=string} "Internal server error"
+// @Router       /users/_verify [get]
+func (c *UserController) VerifyEmail(ctx *fiber.Ctx) error {
+	request := &model.VerifyEmailRequest{Token: ctx.Query("token")}
+
+	response, err := c.UseCase.VerifyEmail(ctx.UserContext(), request)
+	if err != nil {
+		c.Log.WithContext(ctx.UserContext()).Warnf("Failed to verify email : %+v", err)
+		return err
+	}
+
+	return ctx.JSON(model.WebResponse[bool]{Data: response})
+}
+
+// ResendVerification godoc
+// @Summary      Resend the verification email
+// @Description  Send a new verification email to the user with this email address, unless it is verified already. The response is the same whether or not such a user exists
+// @Tags         users
+// @Accept       json
+// @Produce      json
+// @Param        request body model.ResendVerificationRequest true "Email address of the account"
+// @Success      200 {object} object{data=bool} "Verification email sent if the address belongs to an unverified user"
+// @Failure      400 {object} object{errors=string} "Invalid request body"
+// @Failure      500 {object} object{errors=string} "Internal server error"
+// @Router       /users/_resend-verification [post]
+func (c *UserController) ResendVerification(ctx *fiber.Ctx) error {
+	request := new(model.ResendVerificationRequest)
+	if err := ctx.BodyParser(request); err != nil {
+		c.Log.WithContext(ctx.UserContext()).Warnf("Failed to parse request body : %+v", err)
+		return fiber.ErrBadRequest
+	}
+
+	response, err := c.UseCase.ResendVerification(ctx.UserContext(), request)
+	if err != nil {
+		c.Log.WithContext(ctx.UserContext()).Warnf("Failed to resend verification email : %+v", err)
+		return err
+	}
+
+	return ctx.JSON(model.WebResponse[bool]{Data: response})
+}
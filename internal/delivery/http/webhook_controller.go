@@ -0,0 +1,326 @@
+package http
+
+import (
+	"go-rest-scaffold/internal/delivery/http/middleware"
+	"go-rest-scaffold/internal/model"
+	"go-rest-scaffold/internal/usecase"
+	"math"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/sirupsen/logrus"
+)
+
+type WebhookController struct {
+	Log     *logrus.Logger
+	UseCase *usecase.WebhookUseCase
+}
+
+func NewWebhookController(useCase *usecase.WebhookUseCase, logger *logrus.Logger) *WebhookController {
+	return &WebhookController{
+		Log:     logger,
+		UseCase: useCase,
+	}
+}
+
+// Create godoc
+// @Summary      Create a webhook
+// @Description  Register an endpoint receiving the events of the authenticated user as CloudEvents; no event_types means every event. The response holds the secret signing the deliveries, which is not shown again
+// @Tags         webhooks
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Security     APIKeyAuth
+// @Param        request body model.CreateWebhookRequest true "Webhook details"
+// @Param        Idempotency-Key header string false "Key of the request, unique per user; retries with the same key replay the first response"
+// @Success      200 {object} object{data=model.WebhookResponse} "Successfully created webhook"
+// @Failure      400 {object} object{errors=string} "Invalid request body"
+// @Failure      401 {object} object{errors=string} "Unauthorized"
+// @Failure      500 {object} object{errors=string} "Internal server error"
+// @Router       /webhooks [post]
+func (c *WebhookController) Create(ctx *fiber.Ctx) error {
+	auth := middleware.GetUser(ctx)
+
+	request := new(model.CreateWebhookRequest)
+	if err := ctx.BodyParser(request); err != nil {
+		c.Log.WithContext(ctx.UserContext()).WithError(err).Error("error parsing request body")
+		return fiber.ErrBadRequest
+	}
+	request.UserId = auth.ID
+
+	response, err := c.UseCase.Create(ctx.UserContext(), request)
+	if err != nil {
+		c.Log.WithContext(ctx.UserContext()).WithError(err).Error("error creating webhook")
+		return err
+	}
+
+	return ctx.JSON(model.WebResponse[*model.WebhookResponse]{Data: response})
+}
+
+// List godoc
+// @Summary      List webhooks
+// @Description  List the webhooks of the authenticated user
+// @Tags         webhooks
+// @Produce      json
+// @Security     BearerAuth
+// @Security     APIKeyAuth
+// @Param        fields query string false "Comma separated fields to return; id is always returned"
+// @Success      200 {object} object{data=[]model.WebhookResponse} "List of webhooks"
+// @Failure      401 {object} object{errors=string} "Unauthorized"
+// @Failure      500 {object} object{errors=string} "Internal server error"
+// @Router       /webhooks [get]
+func (c *WebhookController) List(ctx *fiber.Ctx) error {
+	auth := middleware.GetUser(ctx)
+
+	request := &model.ListWebhookRequest{
+		UserId: auth.ID,
+	}
+
+	responses, err := c.UseCase.List(ctx.UserContext(), request)
+	if err != nil {
+		c.Log.WithContext(ctx.UserContext()).WithError(err).Error("error listing webhooks")
+		return err
+	}
+
+	return ctx.JSON(model.WebResponse[[]model.WebhookResponse]{Data: responses})
+}
+
+// Get godoc
+// @Summary      Get a webhook
+// @Description  Get a webhook of the authenticated user by ID
+// @Tags         webhooks
+// @Produce      json
+// @Security     BearerAuth
+// @Security     APIKeyAuth
+// @Param        webhookId path string true "Webhook ID"
+// @Param        fields query string false "Comma separated fields to return; id is always returned"
+// @Success      200 {object} object{data=model.WebhookResponse} "Webhook details"
+// @Failure      401 {object} object{errors=string} "Unauthorized"
+// @Failure      404 {object} object{errors=string} "Webhook not found"
+// @Failure      500 {object} object{errors=string} "Internal server error"
+// @Router       /webhooks/{webhookId} [get]
+func (c *WebhookController) Get(ctx *fiber.Ctx) error {
+	auth := middleware.GetUser(ctx)
+
+	request := &model.GetWebhookRequest{
+		UserId: auth.ID,
+		ID:     ctx.Params("webhookId"),
+	}
+
+	response, err := c.UseCase.Get(ctx.UserContext(), request)
+	if err != nil {
+		c.Log.WithContext(ctx.UserContext()).WithError(err).Error("error getting webhook")
+		return err
+	}
+
+	return ctx.JSON(model.WebResponse[*model.WebhookResponse]{Data: response})
+}
+
+// Update godoc
+// @Summary      Update a webhook
+// @Description  Change the URL, event types or active flag of a webhook of the authenticated user
+// @Tags         webhooks
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Security     APIKeyAuth
+// @Param        webhookId path string true "Webhook ID"
+// @Param        request body model.UpdateWebhookRequest true "Webhook details"
+// @Success      200 {object} object{data=model.WebhookResponse} "Successfully updated webhook"
+// @Failure      400 {object} object{errors=string} "Invalid request body"
+// @Failure      401 {object} object{errors=string} "Unauthorized"
+// @Failure      404 {object} object{errors=string} "Webhook not found"
+// @Failure      500 {object} object{errors=string} "Internal server error"
+// @Router       /webhooks/{webhookId} [put]
+func (c *WebhookController) Update(ctx *fiber.Ctx) error {
+	auth := middleware.GetUser(ctx)
+
+	request := new(model.UpdateWebhookRequest)
+	if err := ctx.BodyParser(request); err != nil {
+		c.Log.WithContext(ctx.UserContext()).WithError(err).Error("error parsing request body")
+		return fiber.ErrBadRequest
+	}
+	request.UserId = auth.ID
+	request.ID = ctx.Params("webhookId")
+
+	response, err := c.UseCase.Update(ctx.UserContext(), request)
+	if err != nil {
+		c.Log.WithContext(ctx.UserContext()).WithError(err).Error("error updating webhook")
+		return err
+	}
+
+	return ctx.JSON(model.WebResponse[*model.WebhookResponse]{Data: response})
+}
+
+// RotateSecret godoc
+// @Summary      Rotate the secret of a webhook
+// @Description  Give a webhook a new secret signing its deliveries from now on and return it; the old secret stops working at once. Webhooks registered before deliveries were signed get their first secret this way
+// @Tags         webhooks
+// @Produce      json
+// @Security     BearerAuth
+// @Security     APIKeyAuth
+// @Param        webhookId path string true "Webhook ID"
+// @Success      200 {object} object{data=model.WebhookResponse} "Webhook with its new secret"
+// @Failure      401 {object} object{errors=string} "Unauthorized"
+// @Failure      404 {object} object{errors=string} "Webhook not found"
+// @Failure      500 {object} object{errors=string} "Internal server error"
+// @Router       /webhooks/{webhookId}/_rotate-secret [post]
+func (c *WebhookController) RotateSecret(ctx *fiber.Ctx) error {
+	auth := middleware.GetUser(ctx)
+
+	request := &model.RotateWebhookSecretRequest{
+		UserId: auth.ID,
+		ID:     ctx.Params("webhookId"),
+	}
+
+	response, err := c.UseCase.RotateSecret(ctx.UserContext(), request)
+	if err != nil {
+		c.Log.WithContext(ctx.UserContext()).WithError(err).Error("error rotating webhook secret")
+		return err
+	}
+
+	return ctx.JSON(model.WebResponse[*model.WebhookResponse]{Data: response})
+}
+
+// Delete godoc
+// @Summary      Delete a webhook
+// @Description  Delete a webhook of the authenticated user together with its delivery log
+// @Tags         webhooks
+// @Produce      json
+// @Security     BearerAuth
+// @Security     APIKeyAuth
+// @Param        webhookId path string true "Webhook ID"
+// @Success      200 {object} object{data=bool} "Successfully deleted webhook"
+// @Failure      401 {object} object{errors=string} "Unauthorized"
+// @Failure      404 {object} object{errors=string} "Webhook not found"
+// @Failure      500 {object} object{errors=string} "Internal server error"
+// @Router       /webhooks/{webhookId} [delete]
+func (c *WebhookController) Delete(ctx *fiber.Ctx) error {
+	auth := middleware.GetUser(ctx)
+
+	request := &model.DeleteWebhookRequest{
+		UserId: auth.ID,
+		ID:     ctx.Params("webhookId"),
+	}
+
+	if err := c.UseCase.Delete(ctx.UserContext(), request); err != nil {
+		c.Log.WithContext(ctx.UserContext()).WithError(err).Error("error deleting webhook")
+		return err
+	}
+
+	return ctx.JSON(model.WebResponse[bool]{Data: true})
+}
+
+// ListDeliveries godoc
+// @Summary      List webhook deliveries
+// @Description  List the delivery attempts of a webhook, newest first, with their response status, latency and response snippet
+// @Tags         webhooks
+// @Produce      json
+// @Security     BearerAuth
+// @Security     APIKeyAuth
+// @Param        webhookId path string true "Webhook ID"
+// @Param        status query string false "Only attempts with this outcome" Enums(succeeded, failed)
+// @Param        page query int false "Page number" default(1)
+// @Param        size query int false "Page size" default(10)
+// @Success      200 {object} object{data=[]model.WebhookDeliveryResponse,paging=model.PageMetadata} "List of deliveries with pagination"
+// @Failure      400 {object} object{errors=string} "Invalid query"
+// @Failure      401 {object} object{errors=string} "Unauthorized"
+// @Failure      404 {object} object{errors=string} "Webhook not found"
+// @Failure      500 {object} object{errors=string} "Internal server error"
+// @Router       /webhooks/{webhookId}/deliveries [get]
+func (c *WebhookController) ListDeliveries(ctx *fiber.Ctx) error {
+	auth := middleware.GetUser(ctx)
+
+	request := &model.SearchWebhookDeliveryRequest{
+		UserId:    auth.ID,
+		WebhookId: ctx.Params("webhookId"),
+		Status:    ctx.Query("status", ""),
+		Page:      ctx.QueryInt("page", 1),
+		Size:      ctx.QueryInt("size", 10),
+	}
+
+	responses, total, err := c.UseCase.SearchDeliveries(ctx.UserContext(), request)
+	if err != nil {
+		c.Log.WithContext(ctx.UserContext()).WithError(err).Error("error searching webhook deliveries")
+		return err
+	}
+
+	paging := &model.PageMetadata{
+		Page:      request.Page,
+		Size:      request.Size,
+		TotalItem: total,
+		TotalPage: int64(math.Ceil(float64(total) / float64(request.Size))),
+	}
+
+	return ctx.JSON(model.WebResponse[[]model.WebhookDeliveryResponse]{
+		Data:   responses,
+		Paging: paging,
+	})
+}
+
+// Replay godoc
+// @Summary      Replay a webhook delivery
+// @Description  Send the event of a delivery to the webhook again and return the new attempt
+// @Tags         webhooks
+// @Produce      json
+// @Security     BearerAuth
+// @Security     APIKeyAuth
+// @Param        webhookId path string true "Webhook ID"
+// @Param        deliveryId path string true "Delivery ID"
+// @Success      200 {object} object{data=model.WebhookDeliveryResponse} "New delivery attempt"
+// @Failure      401 {object} object{errors=string} "Unauthorized"
+// @Failure      404 {object} object{errors=string} "Webhook or delivery not found"
+// @Failure      500 {object} object{errors=string} "Internal server error"
+// @Router       /webhooks/{webhookId}/deliveries/{deliveryId}/replay [post]
+func (c *WebhookController) Replay(ctx *fiber.Ctx) error {
+	auth := middleware.GetUser(ctx)
+
+	request := &model.ReplayWebhookDeliveryRequest{
+		UserId:    auth.ID,
+		WebhookId: ctx.Params("webhookId"),
+		ID:        ctx.Params("deliveryId"),
+	}
+
+	response, err := c.UseCase.Replay(ctx.UserContext(), request)
+	if err != nil {
+		c.Log.WithContext(ctx.UserContext()).WithError(err).Error("error replaying webhook delivery")
+		return err
+	}
+
+	return ctx.JSON(model.WebResponse[*model.WebhookDeliveryResponse]{Data: response})
+}
+
+// ReplayFailed godoc
+// @Summary      Replay failed webhook deliveries
+// @Description  Send again, in the background, every event that failed to reach the webhook between from and to (unix milliseconds) and was not delivered since
+// @Tags         webhooks
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Security     APIKeyAuth
+// @Param        webhookId path string true "Webhook ID"
+// @Param        request body model.ReplayFailedWebhookDeliveriesRequest true "Time window"
+// @Success      202 {object} object{data=model.ReplayFailedWebhookDeliveriesResponse} "Number of events being replayed"
+// @Failure      400 {object} object{errors=string} "Invalid request body"
+// @Failure      401 {object} object{errors=string} "Unauthorized"
+// @Failure      404 {object} object{errors=string} "Webhook not found"
+// @Failure      500 {object} object{errors=string} "Internal server error"
+// @Router       /webhooks/{webhookId}/deliveries/_replay [post]
+func (c *WebhookController) ReplayFailed(ctx *fiber.Ctx) error {
+	auth := middleware.GetUser(ctx)
+
+	request := new(model.ReplayFailedWebhookDeliveriesRequest)
+	if err := ctx.BodyParser(request); err != nil {
+		c.Log.WithContext(ctx.UserContext()).WithError(err).Error("error parsing request body")
+		return fiber.ErrBadRequest
+	}
+	request.UserId = auth.ID
+	request.WebhookId = ctx.Params("webhookId")
+
+	response, err := c.UseCase.ReplayFailed(ctx.UserContext(), request)
+	if err != nil {
+		c.Log.WithContext(ctx.UserContext()).WithError(err).Error("error replaying failed webhook deliveries")
+		return err
+	}
+
+	return ctx.Status(fiber.StatusAccepted).JSON(model.WebResponse[*model.ReplayFailedWebhookDeliveriesResponse]{Data: response})
+}
@@ -0,0 +1,20 @@
+package entity
+
+// Announcement is a system-wide banner, such as a maintenance notice, shown by client apps
+// between StartsAt and EndsAt. An EndsAt of zero keeps it active until it is removed.
+type Announcement struct {
+	ID        string `gorm:"column:id;primaryKey"`
+	Title     string `gorm:"column:title"`
+	Body      string `gorm:"column:body"`
+	Level     string `gorm:"column:level"`
+	StartsAt  int64  `gorm:"column:starts_at"`
+	EndsAt    int64  `gorm:"column:ends_at"`
+	CreatedAt int64  `gorm:"column:created_at;autoCreateTime:milli"`
+	UpdatedAt int64  `gorm:"column:updated_at;autoCreateTime:milli;autoUpdateTime:milli"`
+	CreatedBy string `gorm:"column:created_by"`
+	UpdatedBy string `gorm:"column:updated_by"`
+}
+
+func (a *Announcement) TableName() string {
+	return "announcements"
+}
@@ -0,0 +1,21 @@
+package entity
+
+// APIKey lets a machine client act as the user owning it without a session. Only the
+// SHA-256 hash of the key is stored; Prefix is the start of the key, kept so users can
+// tell their keys apart. Scopes, space separated, limit the key to part of the API;
+// empty for no limit.
+type APIKey struct {
+	ID         string `gorm:"column:id;primaryKey"`
+	UserId     string `gorm:"column:user_id"`
+	Name       string `gorm:"column:name"`
+	Prefix     string `gorm:"column:prefix"`
+	KeyHash    string `gorm:"column:key_hash"`
+	Scopes     string `gorm:"column:scopes"`
+	LastUsedAt *int64 `gorm:"column:last_used_at"`
+	RevokedAt  *int64 `gorm:"column:revoked_at"`
+	CreatedAt  int64  `gorm:"column:created_at;autoCreateTime:milli"`
+}
+
+func (a *APIKey) TableName() string {
+	return "api_keys"
+}
@@ -0,0 +1,20 @@
+package entity
+
+// AuditLog records one change made through the API: who made it, from where, and the
+// fields of the entity before and after it. Changes is a JSON object keyed by field.
+// ImpersonatorId is the admin who made the change while impersonating UserId.
+type AuditLog struct {
+	ID             string `gorm:"column:id;primaryKey"`
+	UserId         string `gorm:"column:user_id"`
+	Action         string `gorm:"column:action"`
+	EntityType     string `gorm:"column:entity_type"`
+	EntityId       string `gorm:"column:entity_id"`
+	Changes        string `gorm:"column:changes"`
+	IP             string `gorm:"column:ip"`
+	ImpersonatorId string `gorm:"column:impersonator_id"`
+	CreatedAt      int64  `gorm:"column:created_at;autoCreateTime:milli"`
+}
+
+func (a *AuditLog) TableName() string {
+	return "audit_logs"
+}
@@ -0,0 +1,14 @@
+package entity
+
+// ContactChange records that a contact, or one of its addresses, changed. The ID is
+// the offset of the change in the sync stream, increasing across all users.
+type ContactChange struct {
+	ID        int64  `gorm:"column:id;primaryKey;autoIncrement"`
+	UserId    string `gorm:"column:user_id"`
+	ContactId string `gorm:"column:contact_id"`
+	CreatedAt int64  `gorm:"column:created_at;autoCreateTime:milli"`
+}
+
+func (c *ContactChange) TableName() string {
+	return "contact_changes"
+}
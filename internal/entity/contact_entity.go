@@ -1,18 +1,73 @@
 package entity
 
+import "gorm.io/gorm"
+
 type Contact struct {
-	ID        string    `gorm:"column:id;primaryKey"`
-	FirstName string    `gorm:"column:first_name"`
-	LastName  string    `gorm:"column:last_name"`
-	Email     string    `gorm:"column:email"`
-	Phone     string    `gorm:"column:phone"`
-	UserId    string    `gorm:"column:user_id"`
-	CreatedAt int64     `gorm:"column:created_at;autoCreateTime:milli"`
-	UpdatedAt int64     `gorm:"column:updated_at;autoCreateTime:milli;autoUpdateTime:milli"`
-	User      User      `gorm:"foreignKey:user_id;references:id"`
-	Addresses []Address `gorm:"foreignKey:contact_id;references:id"`
+	ID        string         `gorm:"column:id;primaryKey"`
+	FirstName string         `gorm:"column:first_name"`
+	LastName  string         `gorm:"column:last_name"`
+	UserId    string         `gorm:"column:user_id"`
+	AvatarId  string         `gorm:"column:avatar_id"` // names the stored pictures of the avatar, "" without one
+	CreatedAt int64          `gorm:"column:created_at;autoCreateTime:milli"`
+	UpdatedAt int64          `gorm:"column:updated_at;autoCreateTime:milli;autoUpdateTime:milli"`
+	CreatedBy string         `gorm:"column:created_by"`
+	UpdatedBy string         `gorm:"column:updated_by"`
+	Version   int64          `gorm:"column:version;default:1"`
+	DeletedAt gorm.DeletedAt `gorm:"column:deleted_at;index"`
+	User      User           `gorm:"foreignKey:user_id;references:id"`
+	Addresses []Address      `gorm:"foreignKey:contact_id;references:id"`
+	Emails    []ContactEmail `gorm:"foreignKey:contact_id;references:id"`
+	Phones    []ContactPhone `gorm:"foreignKey:contact_id;references:id"`
 }
 
 func (c *Contact) TableName() string {
 	return "contacts"
 }
+
+// PrimaryEmail returns the primary email of the contact, "" when it has none.
+func (c *Contact) PrimaryEmail() string {
+	for _, email := range c.Emails {
+		if email.Primary {
+			return email.Email
+		}
+	}
+	return ""
+}
+
+// PrimaryPhone returns the primary phone number of the contact, "" when it has none.
+func (c *Contact) PrimaryPhone() string {
+	for _, phone := range c.Phones {
+		if phone.Primary {
+			return phone.Phone
+		}
+	}
+	return ""
+}
+
+// ContactEmail is one of the email addresses of a contact, kept in the order of
+// Position. At most one of them is primary.
+type ContactEmail struct {
+	ContactId string `gorm:"column:contact_id;primaryKey"`
+	Position  int    `gorm:"column:position;primaryKey;autoIncrement:false"`
+	Type      string `gorm:"column:type"`
+	Email     string `gorm:"column:email"`
+	Primary   bool   `gorm:"column:is_primary"`
+}
+
+func (c *ContactEmail) TableName() string {
+	return "contact_emails"
+}
+
+// ContactPhone is one of the phone numbers of a contact, kept in the order of
+// Position. At most one of them is primary.
+type ContactPhone struct {
+	ContactId string `gorm:"column:contact_id;primaryKey"`
+	Position  int    `gorm:"column:position;primaryKey;autoIncrement:false"`
+	Type      string `gorm:"column:type"`
+	Phone     string `gorm:"column:phone"`
+	Primary   bool   `gorm:"column:is_primary"`
+}
+
+func (c *ContactPhone) TableName() string {
+	return "contact_phones"
+}
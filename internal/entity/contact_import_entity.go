@@ -0,0 +1,27 @@
+package entity
+
+// ContactImport is a CSV file of contacts queued to be imported in the background.
+// Data holds the file until the import is finished, Mapping the column mapping as
+// JSON and Errors the rows that could not be imported as JSON. The progress is saved
+// as the rows are imported, so an import interrupted by a restart resumes after the
+// last saved row.
+type ContactImport struct {
+	ID            string `gorm:"column:id;primaryKey"`
+	UserId        string `gorm:"column:user_id"`
+	Status        string `gorm:"column:status"`
+	Data          string `gorm:"column:data"`
+	Mapping       string `gorm:"column:mapping"`
+	TotalRows     int    `gorm:"column:total_rows"`
+	ProcessedRows int    `gorm:"column:processed_rows"`
+	Imported      int    `gorm:"column:imported"`
+	Failed        int    `gorm:"column:failed"`
+	Errors        string `gorm:"column:errors"`
+	Error         string `gorm:"column:error"`
+	CreatedAt     int64  `gorm:"column:created_at;autoCreateTime:milli"`
+	UpdatedAt     int64  `gorm:"column:updated_at;autoCreateTime:milli;autoUpdateTime:milli"`
+	CompletedAt   *int64 `gorm:"column:completed_at"`
+}
+
+func (c *ContactImport) TableName() string {
+	return "contact_imports"
+}
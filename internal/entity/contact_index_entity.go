@@ -0,0 +1,8 @@
+package entity
+
+// ContactInitial is a row of the alphabetical index of contacts, computed by a
+// grouped query over the contacts table. It has no table of its own.
+type ContactInitial struct {
+	Letter string `gorm:"column:letter"`
+	Total  int64  `gorm:"column:total"`
+}
@@ -0,0 +1,21 @@
+package entity
+
+// DebugCapture is a redacted request/response pair recorded by the debug capture middleware.
+type DebugCapture struct {
+	ID              string `gorm:"column:id;primaryKey"`
+	UserId          string `gorm:"column:user_id"`
+	Method          string `gorm:"column:method"`
+	Path            string `gorm:"column:path"`
+	Status          int    `gorm:"column:status"`
+	DurationMs      int64  `gorm:"column:duration_ms"`
+	RequestHeaders  string `gorm:"column:request_headers"`
+	RequestBody     string `gorm:"column:request_body"`
+	ResponseHeaders string `gorm:"column:response_headers"`
+	ResponseBody    string `gorm:"column:response_body"`
+	CreatedAt       int64  `gorm:"column:created_at;autoCreateTime:milli"`
+	ExpiresAt       int64  `gorm:"column:expires_at"`
+}
+
+func (d *DebugCapture) TableName() string {
+	return "debug_captures"
+}
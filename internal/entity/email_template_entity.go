@@ -0,0 +1,20 @@
+package entity
+
+// EmailTemplate overrides a built-in email template for a tenant. The empty tenant
+// holds the deployment-wide override used when a tenant has none of its own.
+type EmailTemplate struct {
+	Tenant    string `gorm:"column:tenant;primaryKey"`
+	Name      string `gorm:"column:name;primaryKey"`
+	Version   int    `gorm:"column:version"`
+	Subject   string `gorm:"column:subject"`
+	HTML      string `gorm:"column:html"`
+	Text      string `gorm:"column:text"`
+	CreatedAt int64  `gorm:"column:created_at;autoCreateTime:milli"`
+	UpdatedAt int64  `gorm:"column:updated_at;autoCreateTime:milli;autoUpdateTime:milli"`
+	CreatedBy string `gorm:"column:created_by"`
+	UpdatedBy string `gorm:"column:updated_by"`
+}
+
+func (e *EmailTemplate) TableName() string {
+	return "email_templates"
+}
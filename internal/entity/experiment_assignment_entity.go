@@ -0,0 +1,12 @@
+package entity
+
+type ExperimentAssignment struct {
+	UserId        string `gorm:"column:user_id;primaryKey"`
+	ExperimentKey string `gorm:"column:experiment_key;primaryKey"`
+	Variant       string `gorm:"column:variant"`
+	CreatedAt     int64  `gorm:"column:created_at;autoCreateTime:milli"`
+}
+
+func (e *ExperimentAssignment) TableName() string {
+	return "experiment_assignments"
+}
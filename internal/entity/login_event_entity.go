@@ -0,0 +1,19 @@
+package entity
+
+// LoginEvent is one attempt to log in as a user, successful or not. Method is how the
+// user tried to log in; Reason why a failed attempt was refused.
+type LoginEvent struct {
+	ID        string `gorm:"column:id;primaryKey"`
+	UserId    string `gorm:"column:user_id"`
+	Method    string `gorm:"column:method"`
+	Success   bool   `gorm:"column:success"`
+	Reason    string `gorm:"column:reason"`
+	IP        string `gorm:"column:ip"`
+	Device    string `gorm:"column:device"`
+	UserAgent string `gorm:"column:user_agent"`
+	CreatedAt int64  `gorm:"column:created_at;autoCreateTime:milli"`
+}
+
+func (l *LoginEvent) TableName() string {
+	return "login_events"
+}
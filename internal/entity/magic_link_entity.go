@@ -0,0 +1,14 @@
+package entity
+
+// MagicLink is a pending passwordless login. ID is the SHA-256 hash of the token sent
+// by email, so the tokens themselves are never stored.
+type MagicLink struct {
+	ID        string `gorm:"column:id;primaryKey"`
+	UserId    string `gorm:"column:user_id"`
+	ExpiresAt int64  `gorm:"column:expires_at"`
+	CreatedAt int64  `gorm:"column:created_at;autoCreateTime:milli"`
+}
+
+func (m *MagicLink) TableName() string {
+	return "magic_links"
+}
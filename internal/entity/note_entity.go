@@ -0,0 +1,16 @@
+package entity
+
+// Note is free text a user keeps about a contact, such as the history of a call.
+type Note struct {
+	ID        string `gorm:"column:id;primaryKey"`
+	ContactId string `gorm:"column:contact_id"`
+	Body      string `gorm:"column:body"`
+	CreatedAt int64  `gorm:"column:created_at;autoCreateTime:milli"`
+	UpdatedAt int64  `gorm:"column:updated_at;autoCreateTime:milli;autoUpdateTime:milli"`
+	CreatedBy string `gorm:"column:created_by"`
+	UpdatedBy string `gorm:"column:updated_by"`
+}
+
+func (n *Note) TableName() string {
+	return "notes"
+}
@@ -0,0 +1,19 @@
+package entity
+
+// OutboxEvent is a domain event saved in the transaction of the change that raised it,
+// waiting for the relay to publish it. The ID orders the events as they were recorded.
+type OutboxEvent struct {
+	ID          int64  `gorm:"column:id;primaryKey;autoIncrement"`
+	EventId     string `gorm:"column:event_id"`
+	Type        string `gorm:"column:type"`
+	UserId      string `gorm:"column:user_id"`
+	Payload     string `gorm:"column:payload"`
+	Attempts    int    `gorm:"column:attempts"`
+	LastError   string `gorm:"column:last_error"`
+	CreatedAt   int64  `gorm:"column:created_at;autoCreateTime:milli"`
+	PublishedAt *int64 `gorm:"column:published_at"`
+}
+
+func (o *OutboxEvent) TableName() string {
+	return "outbox"
+}
@@ -0,0 +1,33 @@
+package entity
+
+// Passkey is a FIDO2 credential a user logs in with instead of a password. CredentialId
+// is the base64url ID the authenticator gave it, PublicKey its COSE encoded key and
+// SignCount the last signature counter it sent, to detect cloned authenticators.
+type Passkey struct {
+	ID           string `gorm:"column:id;primaryKey"`
+	UserId       string `gorm:"column:user_id"`
+	Name         string `gorm:"column:name"`
+	CredentialId string `gorm:"column:credential_id"`
+	PublicKey    []byte `gorm:"column:public_key"`
+	SignCount    int64  `gorm:"column:sign_count"`
+	LastUsedAt   *int64 `gorm:"column:last_used_at"`
+	CreatedAt    int64  `gorm:"column:created_at;autoCreateTime:milli"`
+}
+
+func (p *Passkey) TableName() string {
+	return "passkeys"
+}
+
+// WebAuthnChallenge is a challenge handed to an authenticator, which can be answered
+// once. ID is the base64url challenge; UserId is the user registering a passkey, ""
+// for a login.
+type WebAuthnChallenge struct {
+	ID        string `gorm:"column:id;primaryKey"`
+	UserId    string `gorm:"column:user_id"`
+	ExpiresAt int64  `gorm:"column:expires_at"`
+	CreatedAt int64  `gorm:"column:created_at;autoCreateTime:milli"`
+}
+
+func (w *WebAuthnChallenge) TableName() string {
+	return "webauthn_challenges"
+}
@@ -0,0 +1,27 @@
+package entity
+
+// Reminder asks for a notification about a contact at a local date and time.
+// RemindAt is that moment resolved in Timezone, in unix milliseconds, and
+// Channels is a comma separated list of the channels to notify through.
+type Reminder struct {
+	ID        string  `gorm:"column:id;primaryKey"`
+	UserId    string  `gorm:"column:user_id"`
+	ContactId string  `gorm:"column:contact_id"`
+	Note      string  `gorm:"column:note"`
+	LocalTime string  `gorm:"column:local_time"`
+	Timezone  string  `gorm:"column:timezone"`
+	RemindAt  int64   `gorm:"column:remind_at"`
+	Channels  string  `gorm:"column:channels"`
+	Status    string  `gorm:"column:status"`
+	Error     string  `gorm:"column:error"`
+	SentAt    int64   `gorm:"column:sent_at"`
+	CreatedAt int64   `gorm:"column:created_at;autoCreateTime:milli"`
+	UpdatedAt int64   `gorm:"column:updated_at;autoCreateTime:milli;autoUpdateTime:milli"`
+	CreatedBy string  `gorm:"column:created_by"`
+	UpdatedBy string  `gorm:"column:updated_by"`
+	Contact   Contact `gorm:"foreignKey:contact_id;references:id"`
+}
+
+func (r *Reminder) TableName() string {
+	return "reminders"
+}
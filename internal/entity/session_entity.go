@@ -0,0 +1,24 @@
+package entity
+
+// Session is one login of a user, on one device, with its own access and refresh token.
+// Scopes, space separated, limit the session to part of the API; empty for no limit.
+// An admin impersonating the user gets a session naming them in ImpersonatorId, which
+// expires at ExpiresAt and cannot be refreshed; other sessions have no ExpiresAt.
+type Session struct {
+	ID             string `gorm:"column:id;primaryKey"`
+	UserId         string `gorm:"column:user_id"`
+	Token          string `gorm:"column:token"`
+	RefreshToken   string `gorm:"column:refresh_token"`
+	Device         string `gorm:"column:device"`
+	IP             string `gorm:"column:ip"`
+	UserAgent      string `gorm:"column:user_agent"`
+	Scopes         string `gorm:"column:scopes"`
+	ImpersonatorId string `gorm:"column:impersonator_id"`
+	ExpiresAt      *int64 `gorm:"column:expires_at"`
+	LastSeenAt     int64  `gorm:"column:last_seen_at"`
+	CreatedAt      int64  `gorm:"column:created_at;autoCreateTime:milli"`
+}
+
+func (s *Session) TableName() string {
+	return "sessions"
+}
@@ -0,0 +1,39 @@
+package entity
+
+import "time"
+
+// The statistics below are read-only projections computed by aggregate queries over
+// the other tables. Only DailyStats has a table of its own, where the statistics of
+// the days that are over are kept by the daily aggregation.
+
+type StatsTotals struct {
+	Users     int64 `gorm:"column:users"`
+	Contacts  int64 `gorm:"column:contacts"`
+	Addresses int64 `gorm:"column:addresses"`
+	Reminders int64 `gorm:"column:reminders"`
+	Webhooks  int64 `gorm:"column:webhooks"`
+}
+
+type TableStorage struct {
+	Table string `gorm:"column:table"`
+	Rows  int64  `gorm:"column:rows"`
+	Bytes int64  `gorm:"column:bytes"`
+}
+
+type DailyStats struct {
+	Day             time.Time `gorm:"column:day;primaryKey"`
+	Registrations   int64     `gorm:"column:registrations"`
+	ActiveUsers     int64     `gorm:"column:active_users"`
+	ContactsCreated int64     `gorm:"column:contacts_created"`
+}
+
+func (d *DailyStats) TableName() string {
+	return "daily_stats"
+}
+
+type TopAccount struct {
+	UserId    string `gorm:"column:user_id"`
+	Name      string `gorm:"column:name"`
+	Contacts  int64  `gorm:"column:contacts"`
+	Addresses int64  `gorm:"column:addresses"`
+}
@@ -0,0 +1,29 @@
+package entity
+
+// Tag labels contacts of its user, who can then list the contacts with the tag.
+// Color is a hex color such as #1e90ff for clients to show the tag in, or empty.
+type Tag struct {
+	ID        string `gorm:"column:id;primaryKey"`
+	UserId    string `gorm:"column:user_id"`
+	Name      string `gorm:"column:name"`
+	Color     string `gorm:"column:color"`
+	CreatedAt int64  `gorm:"column:created_at;autoCreateTime:milli"`
+	UpdatedAt int64  `gorm:"column:updated_at;autoCreateTime:milli;autoUpdateTime:milli"`
+	CreatedBy string `gorm:"column:created_by"`
+	UpdatedBy string `gorm:"column:updated_by"`
+}
+
+func (t *Tag) TableName() string {
+	return "tags"
+}
+
+// ContactTag assigns a tag to a contact.
+type ContactTag struct {
+	ContactId string `gorm:"column:contact_id;primaryKey"`
+	TagId     string `gorm:"column:tag_id;primaryKey"`
+	CreatedAt int64  `gorm:"column:created_at;autoCreateTime:milli"`
+}
+
+func (c *ContactTag) TableName() string {
+	return "contact_tags"
+}
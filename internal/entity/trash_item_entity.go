@@ -0,0 +1,13 @@
+package entity
+
+import "time"
+
+// TrashItem is a row of the trash listing. It is read from the soft-deleted
+// contacts and addresses and has no table of its own.
+type TrashItem struct {
+	Type      string    `gorm:"column:type"`
+	ID        string    `gorm:"column:id"`
+	ContactId string    `gorm:"column:contact_id"`
+	Title     string    `gorm:"column:title"`
+	DeletedAt time.Time `gorm:"column:deleted_at"`
+}
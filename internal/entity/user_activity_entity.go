@@ -0,0 +1,13 @@
+package entity
+
+import "time"
+
+// UserActivity records that a user made at least one authenticated request on a day (UTC).
+type UserActivity struct {
+	UserId string    `gorm:"column:user_id;primaryKey"`
+	Day    time.Time `gorm:"column:day;primaryKey;type:date"`
+}
+
+func (u *UserActivity) TableName() string {
+	return "user_activity"
+}
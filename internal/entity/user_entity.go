@@ -2,14 +2,21 @@ package entity
 
 // User is a struct that represents a user entity
 type User struct {
-	ID           string    `gorm:"column:id;primaryKey"`
-	Password     string    `gorm:"column:password"`
-	Name         string    `gorm:"column:name"`
-	Token        string    `gorm:"column:token"`
-	RefreshToken string    `gorm:"column:refresh_token"`
-	CreatedAt    int64     `gorm:"column:created_at;autoCreateTime:milli"`
-	UpdatedAt    int64     `gorm:"column:updated_at;autoCreateTime:milli;autoUpdateTime:milli"`
-	Contacts     []Contact `gorm:"foreignKey:user_id;references:id"`
+	ID           string `gorm:"column:id;primaryKey"`
+	Password     string `gorm:"column:password"`
+	Name         string `gorm:"column:name"`
+	Email        string `gorm:"column:email"`
+	Token        string `gorm:"column:token"`
+	RefreshToken string `gorm:"column:refresh_token"`
+	Role         string `gorm:"column:role;default:user"`
+	Region       string `gorm:"column:region"`
+	VerifiedAt   *int64 `gorm:"column:verified_at"`
+	// DeletedAt is when the user deleted their account, which is purged for good once
+	// the grace period is over.
+	DeletedAt *int64    `gorm:"column:deleted_at"`
+	CreatedAt int64     `gorm:"column:created_at;autoCreateTime:milli"`
+	UpdatedAt int64     `gorm:"column:updated_at;autoCreateTime:milli;autoUpdateTime:milli"`
+	Contacts  []Contact `gorm:"foreignKey:user_id;references:id"`
 }
 
 func (u *User) TableName() string {
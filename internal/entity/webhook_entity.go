@@ -0,0 +1,46 @@
+package entity
+
+// Webhook is an endpoint a user registered to receive the events of their account.
+// EventTypes is a comma separated list of event types, empty for every event. Secret
+// signs the deliveries, empty for webhooks registered before deliveries were signed
+// until their secret is rotated.
+type Webhook struct {
+	ID         string `gorm:"column:id;primaryKey"`
+	UserId     string `gorm:"column:user_id"`
+	URL        string `gorm:"column:url"`
+	Secret     string `gorm:"column:secret"`
+	EventTypes string `gorm:"column:event_types"`
+	Active     bool   `gorm:"column:active"`
+	CreatedAt  int64  `gorm:"column:created_at;autoCreateTime:milli"`
+	UpdatedAt  int64  `gorm:"column:updated_at;autoCreateTime:milli;autoUpdateTime:milli"`
+	CreatedBy  string `gorm:"column:created_by"`
+	UpdatedBy  string `gorm:"column:updated_by"`
+}
+
+func (w *Webhook) TableName() string {
+	return "webhooks"
+}
+
+// WebhookDelivery is one attempt to deliver an event to a webhook. Replays are new
+// attempts of the same event and point at the delivery they replayed. NextAttemptAt
+// is when a failed attempt is retried, nil once it was or when it will not be.
+type WebhookDelivery struct {
+	ID              string `gorm:"column:id;primaryKey"`
+	WebhookId       string `gorm:"column:webhook_id"`
+	EventId         string `gorm:"column:event_id"`
+	EventType       string `gorm:"column:event_type"`
+	Payload         string `gorm:"column:payload"`
+	Attempt         int    `gorm:"column:attempt"`
+	Status          string `gorm:"column:status"`
+	ResponseStatus  int    `gorm:"column:response_status"`
+	LatencyMs       int64  `gorm:"column:latency_ms"`
+	ResponseSnippet string `gorm:"column:response_snippet"`
+	Error           string `gorm:"column:error"`
+	ReplayOf        string `gorm:"column:replay_of"`
+	NextAttemptAt   *int64 `gorm:"column:next_attempt_at"`
+	CreatedAt       int64  `gorm:"column:created_at;autoCreateTime:milli"`
+}
+
+func (w *WebhookDelivery) TableName() string {
+	return "webhook_deliveries"
+}
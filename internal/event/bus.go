@@ -0,0 +1,117 @@
+// Package event is the in-process event bus. Use cases record a CloudEvent in their
+// transaction, which also saves it to the outbox when there is one, and deliver it
+// once the transaction commits: every subscriber (CDN purging, webhooks, real-time
+// fan-out, ...) receives it without the use case knowing about them.
+package event
+
+import (
+	"context"
+	"go-rest-scaffold/internal/model"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// Outbox keeps events in the database of the change that raised them, for the relay
+// to publish to the broker once they are committed.
+type Outbox interface {
+	Save(db *gorm.DB, event *model.CloudEvent) error
+}
+
+// Handler receives a published event. Handlers run synchronously on the publishing
+// goroutine, so anything slow (network calls) should hand the work off itself.
+type Handler func(ctx context.Context, event *model.CloudEvent)
+
+type Bus struct {
+	Log    *logrus.Logger
+	Source string
+	// Outbox receives the events of Record, nil to only deliver them in-process.
+	Outbox Outbox
+
+	mutex    sync.RWMutex
+	handlers map[string][]Handler
+	wildcard []Handler
+}
+
+func NewBus(source string, log *logrus.Logger) *Bus {
+	return &Bus{
+		Log:      log,
+		Source:   source,
+		handlers: make(map[string][]Handler),
+	}
+}
+
+// Subscribe registers handler for the given event types, or for every event when none are given.
+func (b *Bus) Subscribe(handler Handler, eventTypes ...string) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if len(eventTypes) == 0 {
+		b.wildcard = append(b.wildcard, handler)
+		return
+	}
+
+	for _, eventType := range eventTypes {
+		b.handlers[eventType] = append(b.handlers[eventType], handler)
+	}
+}
+
+// Record wraps data in a CloudEvent and, with an outbox, saves it in tx so the event
+// leaves the service if and only if tx commits. Once it has, the caller hands the
+// event to Deliver. A nil bus records nothing and returns a nil event.
+func (b *Bus) Record(ctx context.Context, tx *gorm.DB, eventType string, subject string, userId string, data any) (*model.CloudEvent, error) {
+	if b == nil {
+		return nil, nil
+	}
+
+	event := model.NewCloudEvent(b.Source, eventType, subject, userId, data)
+	if b.Outbox != nil {
+		if err := b.Outbox.Save(tx, event); err != nil {
+			return nil, err
+		}
+	}
+
+	return event, nil
+}
+
+// Publish wraps data in a CloudEvent and delivers it to the subscribers of eventType,
+// without going through the outbox.
+func (b *Bus) Publish(ctx context.Context, eventType string, subject string, userId string, data any) {
+	if b == nil {
+		return
+	}
+
+	b.Deliver(ctx, model.NewCloudEvent(b.Source, eventType, subject, userId, data))
+}
+
+// Deliver hands event to its subscribers. A nil bus or event is valid and dropped,
+// which keeps use cases usable in isolation. Events of dry-run requests are dropped
+// too, since nothing was committed.
+func (b *Bus) Deliver(ctx context.Context, event *model.CloudEvent) {
+	if b == nil || event == nil || model.IsDryRun(ctx) {
+		return
+	}
+
+	eventType := event.Type
+
+	b.mutex.RLock()
+	handlers := make([]Handler, 0, len(b.handlers[eventType])+len(b.wildcard))
+	handlers = append(handlers, b.handlers[eventType]...)
+	handlers = append(handlers, b.wildcard...)
+	b.mutex.RUnlock()
+
+	for _, handler := range handlers {
+		b.dispatch(ctx, handler, event)
+	}
+}
+
+func (b *Bus) dispatch(ctx context.Context, handler Handler, event *model.CloudEvent) {
+	defer func() {
+		if r := recover(); r != nil {
+			b.Log.Errorf("Event handler for %s panicked : %+v", event.Type, r)
+		}
+	}()
+
+	handler(ctx, event)
+}
@@ -0,0 +1,48 @@
+// Package cache keeps serialized responses of hot reads in Redis, shared by every
+// instance so a change invalidated by one is seen by all.
+package cache
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/sirupsen/logrus"
+)
+
+const keyPrefix = "cache:"
+
+// Store is a byte cache in Redis. It never fails a read: when Redis cannot be reached
+// a lookup is a miss and a write is dropped, so an outage only costs database load.
+type Store struct {
+	Log   *logrus.Logger
+	Redis *redis.Client
+}
+
+func NewStore(client *redis.Client, log *logrus.Logger) *Store {
+	return &Store{
+		Log:   log,
+		Redis: client,
+	}
+}
+
+// Get returns the value of key and whether it was found.
+func (s *Store) Get(ctx context.Context, key string) ([]byte, bool) {
+	value, err := s.Redis.Get(ctx, keyPrefix+key).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, false
+	}
+	if err != nil {
+		s.Log.WithContext(ctx).WithError(err).Warnf("Failed to read cache key %s", key)
+		return nil, false
+	}
+	return value, true
+}
+
+// Set stores value under key for ttl, or until it is deleted when ttl is zero.
+func (s *Store) Set(ctx context.Context, key string, value []byte, ttl time.Duration) {
+	if err := s.Redis.Set(ctx, keyPrefix+key, value, ttl).Err(); err != nil {
+		s.Log.WithContext(ctx).WithError(err).Warnf("Failed to write cache key %s", key)
+	}
+}
@@ -0,0 +1,88 @@
+// Package cdn tags cacheable responses with surrogate keys and purges them from
+// the CDN when the underlying data changes.
+package cdn
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// Purger invalidates every cached response tagged with one of the keys.
+type Purger interface {
+	Purge(ctx context.Context, keys []string) error
+}
+
+// NoopPurger is used when no CDN sits in front of the service.
+type NoopPurger struct{}
+
+func (NoopPurger) Purge(ctx context.Context, keys []string) error {
+	return nil
+}
+
+// FastlyPurger purges by surrogate key through the Fastly API.
+type FastlyPurger struct {
+	Client    *http.Client
+	Endpoint  string
+	ServiceID string
+	Token     string
+	Soft      bool
+}
+
+func (p *FastlyPurger) Purge(ctx context.Context, keys []string) error {
+	url := fmt.Sprintf("%s/service/%s/purge", strings.TrimSuffix(p.Endpoint, "/"), p.ServiceID)
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, url, nil)
+	if err != nil {
+		return err
+	}
+
+	request.Header.Set("Fastly-Key", p.Token)
+	request.Header.Set("Surrogate-Key", strings.Join(keys, " "))
+	if p.Soft {
+		request.Header.Set("Fastly-Soft-Purge", "1")
+	}
+
+	return send(p.Client, request)
+}
+
+// CloudflarePurger purges by cache tag through the Cloudflare API.
+type CloudflarePurger struct {
+	Client   *http.Client
+	Endpoint string
+	ZoneID   string
+	Token    string
+}
+
+func (p *CloudflarePurger) Purge(ctx context.Context, keys []string) error {
+	body, err := json.Marshal(map[string][]string{"tags": keys})
+	if err != nil {
+		return err
+	}
+
+	url := fmt.Sprintf("%s/zones/%s/purge_cache", strings.TrimSuffix(p.Endpoint, "/"), p.ZoneID)
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+
+	request.Header.Set("Authorization", "Bearer "+p.Token)
+	request.Header.Set("Content-Type", "application/json")
+
+	return send(p.Client, request)
+}
+
+func send(client *http.Client, request *http.Request) error {
+	response, err := client.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode >= 300 {
+		return fmt.Errorf("purge request failed with status %d", response.StatusCode)
+	}
+	return nil
+}
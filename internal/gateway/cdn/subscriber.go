@@ -0,0 +1,101 @@
+package cdn
+
+import (
+	"context"
+	"go-rest-scaffold/internal/model"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// UserKey tags the responses describing the user themselves.
+func UserKey(userId string) string {
+	return "user-" + userId
+}
+
+// ContactsKey tags the contact listing of a user.
+func ContactsKey(userId string) string {
+	return "contacts-" + userId
+}
+
+// ContactKey tags a contact and everything nested under it, addresses included.
+func ContactKey(contactId string) string {
+	return "contact-" + contactId
+}
+
+// PurgeSubscriber turns change events into CDN purges.
+type PurgeSubscriber struct {
+	Log     *logrus.Logger
+	Purger  Purger
+	Timeout time.Duration
+}
+
+func NewPurgeSubscriber(purger Purger, log *logrus.Logger) *PurgeSubscriber {
+	return &PurgeSubscriber{
+		Log:     log,
+		Purger:  purger,
+		Timeout: 10 * time.Second,
+	}
+}
+
+// EventTypes lists the events that invalidate cached responses.
+func (s *PurgeSubscriber) EventTypes() []string {
+	return []string{
+		model.EventUserUpdated,
+		model.EventAccountImported,
+		model.EventContactCreated,
+		model.EventContactUpdated,
+		model.EventContactDeleted,
+		model.EventContactRestored,
+		model.EventContactTagged,
+		model.EventContactUntagged,
+		model.EventAddressCreated,
+		model.EventAddressUpdated,
+		model.EventAddressDeleted,
+		model.EventAddressRestored,
+		model.EventTagUpdated,
+		model.EventTagDeleted,
+	}
+}
+
+// Handle purges in the background so a slow CDN API never delays the write that triggered it.
+func (s *PurgeSubscriber) Handle(ctx context.Context, event *model.CloudEvent) {
+	keys := Keys(event)
+	if len(keys) == 0 {
+		return
+	}
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), s.Timeout)
+		defer cancel()
+
+		if err := s.Purger.Purge(ctx, keys); err != nil {
+			s.Log.WithError(err).Warnf("Failed to purge surrogate keys %v", keys)
+		}
+	}()
+}
+
+// Keys returns the surrogate keys invalidated by an event. Subjects are resource
+// paths such as "contacts/{id}", "contacts/{id}/addresses/{addressId}" and "tags/{id}".
+func Keys(event *model.CloudEvent) []string {
+	parts := strings.Split(event.Subject, "/")
+
+	switch {
+	case event.Type == model.EventAccountImported:
+		return []string{UserKey(event.UserId), ContactsKey(event.UserId)}
+	case len(parts) == 2 && parts[0] == "users":
+		return []string{UserKey(parts[1])}
+	case len(parts) == 2 && parts[0] == "contacts":
+		return []string{ContactKey(parts[1]), ContactsKey(event.UserId)}
+	case len(parts) == 4 && parts[0] == "contacts" && parts[2] == "addresses":
+		return []string{ContactKey(parts[1])}
+	case len(parts) == 4 && parts[0] == "contacts" && parts[2] == "tags":
+		// the contact itself is unchanged, only the listings filtered by the tag are not
+		return []string{ContactsKey(event.UserId)}
+	case len(parts) == 2 && parts[0] == "tags":
+		return []string{ContactsKey(event.UserId)}
+	default:
+		return nil
+	}
+}
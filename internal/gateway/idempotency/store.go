@@ -0,0 +1,150 @@
+// Package idempotency keeps the responses of requests sent with an Idempotency-Key,
+// so a client retrying after a network failure gets the response of its first
+// attempt instead of creating the resource twice.
+package idempotency
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/sirupsen/logrus"
+)
+
+const keyPrefix = "idempotency:"
+
+// maxEntries bounds the local records; expired entries are swept once it is reached.
+const maxEntries = 10000
+
+// Response is what the request that claimed a key answered, replayed to its retries.
+type Response struct {
+	Status      int    `json:"status"`
+	ContentType string `json:"content_type"`
+	Body        []byte `json:"body"`
+}
+
+// Record is the state of a key: the hash of the request that claimed it and, once
+// that request completed, its response.
+type Record struct {
+	Fingerprint string    `json:"fingerprint"`
+	Response    *Response `json:"response,omitempty"`
+}
+
+type entry struct {
+	record    Record
+	expiresAt time.Time
+}
+
+// Store records keys until they expire. With Redis configured the records are shared
+// between instances; without it, or while Redis cannot be reached, a retry is only
+// recognized by the instance that served the first attempt.
+type Store struct {
+	Log   *logrus.Logger
+	Redis *redis.Client
+
+	mutex   sync.Mutex
+	records map[string]entry
+}
+
+func NewStore(client *redis.Client, log *logrus.Logger) *Store {
+	return &Store{
+		Log:     log,
+		Redis:   client,
+		records: make(map[string]entry),
+	}
+}
+
+// Claim takes key for the request with fingerprint for ttl. It returns nil when the
+// key was free, else the record of the request that took it.
+func (s *Store) Claim(ctx context.Context, key string, fingerprint string, ttl time.Duration) *Record {
+	if s.Redis != nil {
+		record, err := s.claimRedis(ctx, key, fingerprint, ttl)
+		if err == nil {
+			return record
+		}
+		s.Log.WithContext(ctx).WithError(err).Warn("Failed to claim idempotency key, checking this instance only")
+	}
+
+	now := time.Now()
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if claimed, found := s.records[key]; found && now.Before(claimed.expiresAt) {
+		return &claimed.record
+	}
+	if len(s.records) >= maxEntries {
+		for claimed, entry := range s.records {
+			if !now.Before(entry.expiresAt) {
+				delete(s.records, claimed)
+			}
+		}
+	}
+	s.records[key] = entry{record: Record{Fingerprint: fingerprint}, expiresAt: now.Add(ttl)}
+	return nil
+}
+
+func (s *Store) claimRedis(ctx context.Context, key string, fingerprint string, ttl time.Duration) (*Record, error) {
+	value, err := json.Marshal(Record{Fingerprint: fingerprint})
+	if err != nil {
+		return nil, err
+	}
+
+	// the record may expire between the two calls, so the claim is tried again then
+	for {
+		claimed, err := s.Redis.SetNX(ctx, keyPrefix+key, value, ttl).Result()
+		if err != nil || claimed {
+			return nil, err
+		}
+
+		stored, err := s.Redis.Get(ctx, keyPrefix+key).Bytes()
+		if errors.Is(err, redis.Nil) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		record := new(Record)
+		if err := json.Unmarshal(stored, record); err != nil {
+			return nil, err
+		}
+		return record, nil
+	}
+}
+
+// Complete stores the response of the request that claimed key, replayed to its
+// retries for ttl.
+func (s *Store) Complete(ctx context.Context, key string, fingerprint string, response *Response, ttl time.Duration) {
+	record := Record{Fingerprint: fingerprint, Response: response}
+
+	if s.Redis != nil {
+		value, err := json.Marshal(record)
+		if err == nil {
+			err = s.Redis.Set(ctx, keyPrefix+key, value, ttl).Err()
+		}
+		if err == nil {
+			return
+		}
+		s.Log.WithContext(ctx).WithError(err).Warn("Failed to store idempotent response, keeping it on this instance only")
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.records[key] = entry{record: record, expiresAt: time.Now().Add(ttl)}
+}
+
+// Release frees key, so the request that claimed it can be retried from scratch.
+func (s *Store) Release(ctx context.Context, key string) {
+	if s.Redis != nil {
+		if err := s.Redis.Del(ctx, keyPrefix+key).Err(); err != nil {
+			s.Log.WithContext(ctx).WithError(err).Warn("Failed to release idempotency key")
+		}
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	delete(s.records, key)
+}
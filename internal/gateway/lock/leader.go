@@ -0,0 +1,185 @@
+package lock
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"hash/fnv"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// LeaderElector makes sure a singleton task (cron jobs, the outbox relay) runs on
+// exactly one instance. Leadership is a lock that the leader keeps renewing; when
+// renewal fails the task context is cancelled and another instance can take over.
+//
+// With Redis configured the lock is a redsync mutex, otherwise it is a session-level
+// lock of the database (a Postgres advisory lock or a MySQL named lock) held on a
+// dedicated connection. A SQLite database belongs to a single process, which always
+// leads.
+type LeaderElector struct {
+	Log    *logrus.Logger
+	Locker *Locker
+	DB     *gorm.DB
+	TTL    time.Duration
+}
+
+func NewLeaderElector(locker *Locker, db *gorm.DB, log *logrus.Logger, ttl time.Duration) *LeaderElector {
+	return &LeaderElector{
+		Log:    log,
+		Locker: locker,
+		DB:     db,
+		TTL:    ttl,
+	}
+}
+
+type leadership interface {
+	renew(ctx context.Context) error
+	release(ctx context.Context)
+}
+
+// Run campaigns for leadership of name until ctx is done. Whenever this instance
+// becomes leader, task is called with a context that is cancelled as soon as
+// leadership is lost. If task returns on its own, leadership is released.
+func (e *LeaderElector) Run(ctx context.Context, name string, task func(ctx context.Context)) {
+	for ctx.Err() == nil {
+		held, err := e.campaign(ctx, name)
+		if err != nil {
+			if !errors.Is(err, ErrNotObtained) {
+				e.Log.WithError(err).Warnf("Failed to campaign for leadership of %s", name)
+			}
+
+			select {
+			case <-ctx.Done():
+			case <-time.After(e.TTL / 2):
+			}
+			continue
+		}
+
+		e.Log.Infof("Acquired leadership of %s", name)
+		e.lead(ctx, name, held, task)
+		e.Log.Infof("Released leadership of %s", name)
+	}
+}
+
+func (e *LeaderElector) lead(ctx context.Context, name string, held leadership, task func(ctx context.Context)) {
+	taskCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	defer held.release(context.WithoutCancel(ctx))
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		task(taskCtx)
+	}()
+
+	ticker := time.NewTicker(e.TTL / 3)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+			if err := held.renew(ctx); err != nil {
+				e.Log.WithError(err).Warnf("Lost leadership of %s", name)
+				cancel()
+				<-done
+				return
+			}
+		}
+	}
+}
+
+func (e *LeaderElector) campaign(ctx context.Context, name string) (leadership, error) {
+	if e.Locker != nil && e.Locker.Redsync != nil {
+		lock, err := e.Locker.TryLock(ctx, "leader:"+name, e.TTL)
+		if err != nil {
+			return nil, err
+		}
+		return &redisLeadership{lock: lock}, nil
+	}
+
+	switch e.DB.Dialector.Name() {
+	case "sqlite":
+		return soleLeadership{}, nil
+	case "mysql":
+		// GET_LOCK returns NULL on error, which scans as not acquired
+		return e.campaignSession(ctx, "SELECT COALESCE(GET_LOCK(?, 0), 0) = 1", "SELECT RELEASE_LOCK(?)", "leader:"+name)
+	default:
+		return e.campaignSession(ctx, "SELECT pg_try_advisory_lock($1)", "SELECT pg_advisory_unlock($1)", advisoryKey(name))
+	}
+}
+
+// campaignSession takes a session-level lock of the database with tryLock, on a
+// connection kept for as long as the lock is held.
+func (e *LeaderElector) campaignSession(ctx context.Context, tryLock string, unlock string, key any) (leadership, error) {
+	sqlDB, err := e.DB.DB()
+	if err != nil {
+		return nil, err
+	}
+
+	conn, err := sqlDB.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var acquired bool
+	if err := conn.QueryRowContext(ctx, tryLock, key).Scan(&acquired); err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+
+	if !acquired {
+		_ = conn.Close()
+		return nil, ErrNotObtained
+	}
+
+	return &sessionLeadership{conn: conn, unlock: unlock, key: key}, nil
+}
+
+func advisoryKey(name string) int64 {
+	hash := fnv.New64a()
+	_, _ = hash.Write([]byte("leader:" + name))
+	return int64(hash.Sum64())
+}
+
+type redisLeadership struct {
+	lock *Lock
+}
+
+func (l *redisLeadership) renew(ctx context.Context) error {
+	return l.lock.Extend(ctx)
+}
+
+func (l *redisLeadership) release(ctx context.Context) {
+	_ = l.lock.Unlock(ctx)
+}
+
+// sessionLeadership holds the database lock for as long as the session lives,
+// so renewing only has to prove the connection is still alive.
+type sessionLeadership struct {
+	conn   *sql.Conn
+	unlock string
+	key    any
+}
+
+func (l *sessionLeadership) renew(ctx context.Context) error {
+	return l.conn.PingContext(ctx)
+}
+
+func (l *sessionLeadership) release(ctx context.Context) {
+	_, _ = l.conn.ExecContext(ctx, l.unlock, l.key)
+	_ = l.conn.Close()
+}
+
+// soleLeadership is held by the only process using a SQLite database.
+type soleLeadership struct{}
+
+func (soleLeadership) renew(ctx context.Context) error {
+	return nil
+}
+
+func (soleLeadership) release(ctx context.Context) {}
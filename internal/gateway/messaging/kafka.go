@@ -0,0 +1,38 @@
+package messaging
+
+import (
+	"context"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// KafkaProducer writes messages to Kafka, partitioned by key and acknowledged by every
+// in-sync replica.
+type KafkaProducer struct {
+	Writer *kafka.Writer
+}
+
+func NewKafkaProducer(brokers []string, clientId string) *KafkaProducer {
+	return &KafkaProducer{
+		Writer: &kafka.Writer{
+			Addr:         kafka.TCP(brokers...),
+			Balancer:     &kafka.Hash{},
+			RequiredAcks: kafka.RequireAll,
+			Transport:    &kafka.Transport{ClientID: clientId},
+		},
+	}
+}
+
+func (p *KafkaProducer) Produce(ctx context.Context, message *Message) error {
+	headers := make([]kafka.Header, 0, len(message.Headers))
+	for key, value := range message.Headers {
+		headers = append(headers, kafka.Header{Key: key, Value: []byte(value)})
+	}
+
+	return p.Writer.WriteMessages(ctx, kafka.Message{
+		Topic:   message.Topic,
+		Key:     []byte(message.Key),
+		Value:   message.Value,
+		Headers: headers,
+	})
+}
@@ -0,0 +1,35 @@
+package messaging
+
+import (
+	"context"
+
+	"github.com/nats-io/nats.go"
+)
+
+// NATSProducer publishes messages to NATS JetStream, which acknowledges them once
+// stored. Topics are subjects, and a stream must capture them. The key is not needed
+// for ordering, a subject being a single ordered log.
+type NATSProducer struct {
+	JetStream nats.JetStreamContext
+}
+
+func NewNATSProducer(connection *nats.Conn) (*NATSProducer, error) {
+	jetStream, err := connection.JetStream()
+	if err != nil {
+		return nil, err
+	}
+	return &NATSProducer{JetStream: jetStream}, nil
+}
+
+func (p *NATSProducer) Produce(ctx context.Context, message *Message) error {
+	msg := nats.NewMsg(message.Topic)
+	msg.Data = message.Value
+	// JetStream drops the duplicates of a relay retrying a message
+	msg.Header.Set(nats.MsgIdHdr, message.ID)
+	for key, value := range message.Headers {
+		msg.Header.Set(key, value)
+	}
+
+	_, err := p.JetStream.PublishMsg(msg, nats.Context(ctx))
+	return err
+}
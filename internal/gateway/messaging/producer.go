@@ -0,0 +1,45 @@
+// Package messaging publishes the domain events relayed from the outbox to the
+// message broker (Kafka or NATS) other services consume them from.
+package messaging
+
+import (
+	"context"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Message is a record written to a topic. Messages with the same key keep their order.
+type Message struct {
+	// ID identifies the message to the brokers that drop duplicates.
+	ID      string
+	Topic   string
+	Key     string
+	Value   []byte
+	Headers map[string]string
+}
+
+// Producer writes messages to a broker. Produce returns once the broker acknowledged
+// the message, so a nil error means it will not be lost.
+type Producer interface {
+	Produce(ctx context.Context, message *Message) error
+}
+
+// LogProducer writes every message to the log instead of a broker, for development
+// and for deployments with no consumer yet.
+type LogProducer struct {
+	Log *logrus.Logger
+}
+
+func NewLogProducer(log *logrus.Logger) *LogProducer {
+	return &LogProducer{
+		Log: log,
+	}
+}
+
+func (p *LogProducer) Produce(ctx context.Context, message *Message) error {
+	p.Log.WithContext(ctx).WithFields(logrus.Fields{
+		"topic": message.Topic,
+		"key":   message.Key,
+	}).Infof("Produced message %s", message.Value)
+	return nil
+}
@@ -0,0 +1,55 @@
+package messaging
+
+import (
+	"context"
+	"encoding/json"
+	"go-rest-scaffold/internal/model"
+	"strings"
+)
+
+// Publisher publishes CloudEvents in structured mode, the whole envelope being the
+// message, to the topic of their resource. Events are keyed by user, so the events of
+// a user are consumed in the order they happened.
+type Publisher struct {
+	Producer Producer
+	// Topics maps a resource ("user", "contact", ...) to its topic.
+	Topics map[string]string
+	// DefaultTopic receives the events of the resources missing from Topics.
+	DefaultTopic string
+}
+
+func NewPublisher(producer Producer, topics map[string]string, defaultTopic string) *Publisher {
+	return &Publisher{
+		Producer:     producer,
+		Topics:       topics,
+		DefaultTopic: defaultTopic,
+	}
+}
+
+func (p *Publisher) Publish(ctx context.Context, event *model.CloudEvent) error {
+	value, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	return p.Producer.Produce(ctx, &Message{
+		ID:    event.ID,
+		Topic: p.Topic(event.Type),
+		Key:   event.UserId,
+		Value: value,
+		Headers: map[string]string{
+			"content-type": "application/cloudevents+json",
+			"ce_id":        event.ID,
+			"ce_type":      event.Type,
+		},
+	})
+}
+
+// Topic returns the topic of an event type such as "com.go-rest-scaffold.contact.created.v1".
+func (p *Publisher) Topic(eventType string) string {
+	resource, _, _ := strings.Cut(strings.TrimPrefix(eventType, model.EventTypePrefix), ".")
+	if topic, ok := p.Topics[resource]; ok {
+		return topic
+	}
+	return p.DefaultTopic
+}
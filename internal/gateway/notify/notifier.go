@@ -0,0 +1,51 @@
+// Package notify delivers user notifications through the channels of the service.
+package notify
+
+import (
+	"context"
+	"go-rest-scaffold/internal/event"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	ChannelInApp = "in_app"
+	ChannelEmail = "email"
+	ChannelPush  = "push"
+)
+
+// Notification is a message for a user, about the resource at Subject.
+type Notification struct {
+	UserId  string
+	Subject string
+	Title   string
+	Body    string
+	Data    any
+}
+
+type Notifier interface {
+	Notify(ctx context.Context, notification Notification) error
+}
+
+// EventNotifier delivers in-app notifications by publishing them on the event bus,
+// from where they reach the user's webhooks and connected clients.
+type EventNotifier struct {
+	Bus       *event.Bus
+	EventType string
+}
+
+func (n EventNotifier) Notify(ctx context.Context, notification Notification) error {
+	n.Bus.Publish(ctx, n.EventType, notification.Subject, notification.UserId, notification.Data)
+	return nil
+}
+
+// LogNotifier stands in for a channel without a provider: it only logs the notification.
+type LogNotifier struct {
+	Log     *logrus.Logger
+	Channel string
+}
+
+func (n LogNotifier) Notify(ctx context.Context, notification Notification) error {
+	n.Log.WithField("channel", n.Channel).Infof("Notification for %s : %s", notification.UserId, notification.Title)
+	return nil
+}
@@ -0,0 +1,161 @@
+// Package ratelimit counts requests per client in token buckets shared by every instance of the service.
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/sirupsen/logrus"
+)
+
+const keyPrefix = "ratelimit:"
+
+// takeScript refills the bucket at KEYS[1] for the time since it was last taken from
+// and takes a token if one is left, in one step so concurrent requests cannot both
+// take the last token. ARGV holds the capacity, the refill rate in tokens per
+// millisecond, the current time in milliseconds and how long an idle bucket is kept.
+// The tokens are returned as a string, since Redis truncates Lua numbers to integers.
+var takeScript = redis.NewScript(`
+local bucket = redis.call('HMGET', KEYS[1], 'tokens', 'updated')
+local capacity = tonumber(ARGV[1])
+local rate = tonumber(ARGV[2])
+local now = tonumber(ARGV[3])
+local tokens = tonumber(bucket[1]) or capacity
+local updated = tonumber(bucket[2]) or now
+tokens = math.min(capacity, tokens + math.max(now - updated, 0) * rate)
+local allowed = 0
+if tokens >= 1 then
+	tokens = tokens - 1
+	allowed = 1
+end
+redis.call('HSET', KEYS[1], 'tokens', tostring(tokens), 'updated', now)
+redis.call('PEXPIRE', KEYS[1], ARGV[4])
+return {allowed, tostring(tokens)}
+`)
+
+// Result is the budget of a key after a request has been counted.
+type Result struct {
+	Limit     int
+	Remaining int
+	// Reset is the time left until the next token is added to the bucket, which is
+	// when a rejected request may be retried; zero when the bucket is full.
+	Reset   time.Duration
+	Allowed bool
+}
+
+// Limiter is a token bucket per key: a bucket holds up to limit tokens, every request
+// takes one and the bucket refills at limit tokens per window, so a client may burst
+// up to limit requests and then keeps to limit per window. With Redis configured the
+// buckets are shared between instances; without Redis they are kept in memory, which
+// is enough for single-instance deployments and local development.
+type Limiter struct {
+	Log   *logrus.Logger
+	Redis *redis.Client
+
+	mutex sync.Mutex
+	local map[string]localBucket
+}
+
+type localBucket struct {
+	tokens  float64
+	updated time.Time
+	// full is when the bucket is refilled if no request takes from it meanwhile.
+	full time.Time
+}
+
+func NewLimiter(client *redis.Client, log *logrus.Logger) *Limiter {
+	return &Limiter{
+		Log:   log,
+		Redis: client,
+		local: make(map[string]localBucket),
+	}
+}
+
+// Take takes one token from the bucket of key. When the bucket cannot be reached the
+// request is allowed, so an unavailable Redis degrades to no limiting rather than to
+// an outage.
+func (l *Limiter) Take(ctx context.Context, key string, limit int, window time.Duration) (Result, error) {
+	now := time.Now()
+	// tokens per millisecond
+	rate := float64(limit) / float64(window.Milliseconds())
+
+	var allowed bool
+	var tokens float64
+	var err error
+	if l.Redis == nil {
+		allowed, tokens = l.takeLocal(key, limit, rate, now)
+	} else {
+		allowed, tokens, err = l.takeRedis(ctx, key, limit, rate, now, window)
+		if err != nil {
+			return Result{Limit: limit, Remaining: limit, Allowed: true}, err
+		}
+	}
+
+	var reset time.Duration
+	if tokens < float64(limit) {
+		next := math.Floor(tokens) + 1 - tokens
+		reset = time.Duration(math.Ceil(next/rate)) * time.Millisecond
+	}
+
+	return Result{
+		Limit:     limit,
+		Remaining: int(tokens),
+		Reset:     reset,
+		Allowed:   allowed,
+	}, nil
+}
+
+func (l *Limiter) takeRedis(ctx context.Context, key string, limit int, rate float64, now time.Time, window time.Duration) (bool, float64, error) {
+	// an idle bucket is full again after a window, which is what a missing bucket means
+	values, err := takeScript.Run(ctx, l.Redis, []string{keyPrefix + key},
+		limit, strconv.FormatFloat(rate, 'g', -1, 64), now.UnixMilli(), window.Milliseconds()).Slice()
+	if err != nil {
+		return false, 0, err
+	}
+
+	allowed, _ := values[0].(int64)
+	encoded, _ := values[1].(string)
+	tokens, err := strconv.ParseFloat(encoded, 64)
+	if err != nil {
+		return false, 0, err
+	}
+	return allowed == 1, tokens, nil
+}
+
+func (l *Limiter) takeLocal(key string, limit int, rate float64, now time.Time) (bool, float64) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	current, ok := l.local[key]
+	if !ok {
+		if len(l.local) >= 10000 {
+			l.sweep(now)
+		}
+		current = localBucket{tokens: float64(limit), updated: now}
+	}
+
+	elapsed := float64(max(now.Sub(current.updated).Milliseconds(), 0))
+	current.tokens = min(float64(limit), current.tokens+elapsed*rate)
+	current.updated = now
+
+	allowed := current.tokens >= 1
+	if allowed {
+		current.tokens--
+	}
+	current.full = now.Add(time.Duration((float64(limit)-current.tokens)/rate) * time.Millisecond)
+	l.local[key] = current
+	return allowed, current.tokens
+}
+
+// sweep forgets the buckets that are full again, which is what a missing bucket means.
+func (l *Limiter) sweep(now time.Time) {
+	for key, current := range l.local {
+		if !current.full.After(now) {
+			delete(l.local, key)
+		}
+	}
+}
@@ -0,0 +1,109 @@
+// Package readonly holds the read-only switch that keeps reads served while writes are
+// refused, e.g. while a long-running schema migration holds locks on the tables.
+package readonly
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/sirupsen/logrus"
+)
+
+const key = "read_only"
+
+// refreshInterval bounds how often the switch is read back from Redis, so checking it
+// on every write request does not add a Redis round trip to each of them.
+const refreshInterval = time.Second
+
+// State is whether writes are refused and why.
+type State struct {
+	Enabled bool      `json:"enabled"`
+	Reason  string    `json:"reason,omitempty"`
+	Since   time.Time `json:"since"`
+}
+
+// Switch is the read-only flag. With Redis configured it is shared between instances,
+// so an operator flips it once for the whole deployment; without Redis it only applies
+// to the instance that received the change. Configured is the state from the config
+// file, which keeps an instance read-only until the config changes whatever the
+// runtime flag says.
+type Switch struct {
+	Log        *logrus.Logger
+	Redis      *redis.Client
+	Configured State
+
+	mutex     sync.Mutex
+	local     State
+	fetchedAt time.Time
+}
+
+func NewSwitch(client *redis.Client, configured State, log *logrus.Logger) *Switch {
+	return &Switch{
+		Log:        log,
+		Redis:      client,
+		Configured: configured,
+	}
+}
+
+// State returns the effective state: the configured one when it is enabled, the runtime
+// one otherwise. When Redis cannot be reached the last state read from it is kept.
+func (s *Switch) State(ctx context.Context) State {
+	if s.Configured.Enabled {
+		return s.Configured
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.Redis != nil && time.Since(s.fetchedAt) >= refreshInterval {
+		state, err := s.fetch(ctx)
+		if err != nil {
+			s.Log.WithError(err).Warn("Failed to read the read-only switch, keeping the last known state")
+		} else {
+			s.local = state
+		}
+		s.fetchedAt = time.Now()
+	}
+
+	return s.local
+}
+
+// Set changes the runtime state. Disabling it has no effect on an instance whose config
+// enables read-only mode.
+func (s *Switch) Set(ctx context.Context, state State) error {
+	if s.Redis != nil {
+		value, err := json.Marshal(state)
+		if err != nil {
+			return err
+		}
+		if err := s.Redis.Set(ctx, key, value, 0).Err(); err != nil {
+			return err
+		}
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.local = state
+	s.fetchedAt = time.Now()
+	return nil
+}
+
+func (s *Switch) fetch(ctx context.Context) (State, error) {
+	var state State
+
+	value, err := s.Redis.Get(ctx, key).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return state, nil
+	}
+	if err != nil {
+		return state, err
+	}
+
+	err = json.Unmarshal(value, &state)
+	return state, err
+}
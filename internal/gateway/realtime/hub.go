@@ -0,0 +1,256 @@
+// Package realtime pushes the events of a user to their connected WebSocket and
+// Server-Sent Events clients.
+package realtime
+
+import (
+	"context"
+	"encoding/json"
+	"go-rest-scaffold/internal/metrics"
+	"go-rest-scaffold/internal/model"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/sirupsen/logrus"
+)
+
+const channel = "realtime:events"
+
+// Message is an event as clients receive it, Payload being its CloudEvents JSON.
+type Message struct {
+	ID      string
+	Type    string
+	Payload []byte
+	at      time.Time
+}
+
+// Client receives the events of one user on Messages until it is closed.
+type Client struct {
+	UserId   string
+	Messages chan Message
+
+	// types are the event types the client receives, nil for every type
+	types map[string]struct{}
+	hub   *Hub
+}
+
+// Close stops the events of the client.
+func (c *Client) Close() {
+	c.hub.mutex.Lock()
+	defer c.hub.mutex.Unlock()
+
+	clients := c.hub.clients[c.UserId]
+	if _, found := clients[c]; !found {
+		return
+	}
+	delete(clients, c)
+	if len(clients) == 0 {
+		delete(c.hub.clients, c.UserId)
+	}
+	metrics.RealtimeClients.Dec()
+}
+
+func (c *Client) wants(eventType string) bool {
+	if c.types == nil {
+		return true
+	}
+	_, found := c.types[eventType]
+	return found
+}
+
+// Options sizes the buffers of the hub.
+type Options struct {
+	// BufferSize is how many events a client may fall behind before it misses some.
+	BufferSize int
+	// HistorySize is how many of the latest events of each user are kept for clients
+	// resuming after a disconnect, and HistoryTTL how long they are kept.
+	HistorySize int
+	HistoryTTL  time.Duration
+}
+
+// Hub fans the events of the bus out to the clients of their user. With Redis
+// configured every instance publishes its events on a Redis channel and sends what it
+// receives there to its own clients, so a client gets the events of its user whichever
+// instance raised them; without Redis it only gets those of its own instance. A
+// client that does not keep up misses the events that no longer fit its buffer.
+type Hub struct {
+	Log     *logrus.Logger
+	Redis   *redis.Client
+	Options Options
+
+	mutex      sync.RWMutex
+	clients    map[string]map[*Client]struct{}
+	history    map[string][]Message
+	lastPruned time.Time
+}
+
+func NewHub(client *redis.Client, options Options, log *logrus.Logger) *Hub {
+	return &Hub{
+		Log:     log,
+		Redis:   client,
+		Options: options,
+		clients: make(map[string]map[*Client]struct{}),
+		history: make(map[string][]Message),
+	}
+}
+
+// Connect starts a client receiving the events of the user of the given types, or of
+// every type when none are given.
+func (h *Hub) Connect(userId string, eventTypes ...string) *Client {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	return h.connect(userId, eventTypes)
+}
+
+// Resume is Connect for a client that already received the event lastEventId: it also
+// returns the events of the user after that one. found is false when the event is no
+// longer kept, in which case the client may have missed events.
+func (h *Hub) Resume(userId string, lastEventId string, eventTypes ...string) (client *Client, missed []Message, found bool) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	client = h.connect(userId, eventTypes)
+	history := h.history[userId]
+	index := slices.IndexFunc(history, func(message Message) bool {
+		return message.ID == lastEventId
+	})
+	if index < 0 {
+		return client, nil, false
+	}
+
+	for _, message := range history[index+1:] {
+		if client.wants(message.Type) && time.Since(message.at) < h.Options.HistoryTTL {
+			missed = append(missed, message)
+		}
+	}
+	return client, missed, true
+}
+
+func (h *Hub) connect(userId string, eventTypes []string) *Client {
+	client := &Client{
+		UserId:   userId,
+		Messages: make(chan Message, h.Options.BufferSize),
+		hub:      h,
+	}
+	if len(eventTypes) > 0 {
+		client.types = make(map[string]struct{}, len(eventTypes))
+		for _, eventType := range eventTypes {
+			client.types[eventType] = struct{}{}
+		}
+	}
+
+	if h.clients[userId] == nil {
+		h.clients[userId] = make(map[*Client]struct{})
+	}
+	h.clients[userId][client] = struct{}{}
+	metrics.RealtimeClients.Inc()
+	return client
+}
+
+// Handle pushes an event to the clients of its user, through Redis when it is
+// configured. Publishing to Redis runs in the background so it never delays the
+// write that raised the event.
+func (h *Hub) Handle(ctx context.Context, event *model.CloudEvent) {
+	if event.UserId == "" {
+		return
+	}
+
+	payload, err := json.Marshal(event)
+	if err != nil {
+		h.Log.WithContext(ctx).WithError(err).Warnf("Failed to encode event %s", event.ID)
+		return
+	}
+
+	if h.Redis == nil {
+		h.receive(payload)
+		return
+	}
+
+	go func() {
+		ctx := context.WithoutCancel(ctx)
+		if err := h.Redis.Publish(ctx, channel, payload).Err(); err != nil {
+			h.Log.WithContext(ctx).WithError(err).Warnf("Failed to publish event %s to the other instances", event.ID)
+		}
+	}()
+}
+
+// Run sends the events published by every instance to the clients of this one until
+// ctx is done. The subscription reconnects by itself after Redis errors. Without
+// Redis it returns at once.
+func (h *Hub) Run(ctx context.Context) {
+	if h.Redis == nil {
+		return
+	}
+
+	subscription := h.Redis.Subscribe(ctx, channel)
+	defer subscription.Close()
+
+	messages := subscription.Channel()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case message := <-messages:
+			h.receive([]byte(message.Payload))
+		}
+	}
+}
+
+// receive keeps an event in the history of its user and sends it to their clients.
+func (h *Hub) receive(payload []byte) {
+	var event struct {
+		ID     string `json:"id"`
+		Type   string `json:"type"`
+		UserId string `json:"userid"`
+	}
+	if err := json.Unmarshal(payload, &event); err != nil {
+		h.Log.WithError(err).Warn("Failed to decode a published event")
+		return
+	}
+	message := Message{ID: event.ID, Type: event.Type, Payload: payload, at: time.Now()}
+
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	if h.Options.HistorySize > 0 {
+		history := append(h.history[event.UserId], message)
+		if len(history) > h.Options.HistorySize {
+			history = slices.Clone(history[len(history)-h.Options.HistorySize:])
+		}
+		h.history[event.UserId] = history
+		h.prune()
+	}
+
+	for client := range h.clients[event.UserId] {
+		if !client.wants(message.Type) {
+			continue
+		}
+		select {
+		case client.Messages <- message:
+		default:
+			metrics.RealtimeDroppedEvents.Inc()
+		}
+	}
+}
+
+// prune forgets, at most once per HistoryTTL, the events kept longer than it, so the
+// history of users who stopped changing anything does not stay around.
+func (h *Hub) prune() {
+	if time.Since(h.lastPruned) < h.Options.HistoryTTL {
+		return
+	}
+	h.lastPruned = time.Now()
+
+	for userId, history := range h.history {
+		index := slices.IndexFunc(history, func(message Message) bool {
+			return time.Since(message.at) < h.Options.HistoryTTL
+		})
+		if index < 0 {
+			delete(h.history, userId)
+		} else if index > 0 {
+			h.history[userId] = slices.Clone(history[index:])
+		}
+	}
+}
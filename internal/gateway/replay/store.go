@@ -0,0 +1,64 @@
+// Package replay remembers the signed requests already served, so the same request
+// sent again within the tolerance of its signature is refused.
+package replay
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/sirupsen/logrus"
+)
+
+const keyPrefix = "signed_request:"
+
+// maxEntries bounds the local record; expired entries are swept once it is reached.
+const maxEntries = 10000
+
+// Store records signatures until they expire. With Redis configured the record is
+// shared between instances; without it, or while Redis cannot be reached, a request
+// is only refused a second time by the instance that served it.
+type Store struct {
+	Log   *logrus.Logger
+	Redis *redis.Client
+
+	mutex sync.Mutex
+	seen  map[string]time.Time
+}
+
+func NewStore(client *redis.Client, log *logrus.Logger) *Store {
+	return &Store{
+		Log:   log,
+		Redis: client,
+		seen:  make(map[string]time.Time),
+	}
+}
+
+// Claim records the signature for ttl and reports whether it was new.
+func (s *Store) Claim(ctx context.Context, signature string, ttl time.Duration) bool {
+	if s.Redis != nil {
+		claimed, err := s.Redis.SetNX(ctx, keyPrefix+signature, 1, ttl).Result()
+		if err == nil {
+			return claimed
+		}
+		s.Log.WithError(err).Warn("Failed to record signed request, checking this instance only")
+	}
+
+	now := time.Now()
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if expiresAt, found := s.seen[signature]; found && now.Before(expiresAt) {
+		return false
+	}
+	if len(s.seen) >= maxEntries {
+		for seen, expiresAt := range s.seen {
+			if !now.Before(expiresAt) {
+				delete(s.seen, seen)
+			}
+		}
+	}
+	s.seen[signature] = now.Add(ttl)
+	return true
+}
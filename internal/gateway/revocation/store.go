@@ -0,0 +1,120 @@
+// Package revocation holds the sessions whose JWT access tokens must no longer be
+// trusted, since those tokens are verified by signature and are not looked up.
+package revocation
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/sirupsen/logrus"
+)
+
+const keyPrefix = "revoked_session:"
+
+// maxEntries bounds the local cache; expired entries are swept once it is reached.
+const maxEntries = 10000
+
+type entry struct {
+	revoked   bool
+	expiresAt time.Time
+}
+
+// Store records revoked sessions until their access tokens have expired anyway. With
+// Redis configured revocations are shared between instances; without it they only
+// apply to the instance that recorded them. Lookups are cached for CacheTTL, so other
+// instances may accept a revoked token for that long.
+type Store struct {
+	Log      *logrus.Logger
+	Redis    *redis.Client
+	CacheTTL time.Duration
+
+	mutex   sync.Mutex
+	entries map[string]entry
+}
+
+func NewStore(client *redis.Client, cacheTTL time.Duration, log *logrus.Logger) *Store {
+	return &Store{
+		Log:      log,
+		Redis:    client,
+		CacheTTL: cacheTTL,
+		entries:  make(map[string]entry),
+	}
+}
+
+// Revoke marks sessions as revoked for ttl, which should be the lifetime of an access
+// token. Instances then refuse the tokens of those sessions.
+func (s *Store) Revoke(ctx context.Context, ttl time.Duration, sessionIds ...string) error {
+	if len(sessionIds) == 0 {
+		return nil
+	}
+
+	if s.Redis != nil {
+		pipe := s.Redis.Pipeline()
+		for _, id := range sessionIds {
+			pipe.Set(ctx, keyPrefix+id, 1, ttl)
+		}
+		if _, err := pipe.Exec(ctx); err != nil {
+			return err
+		}
+	}
+
+	expiresAt := time.Now().Add(ttl)
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	for _, id := range sessionIds {
+		s.put(id, entry{revoked: true, expiresAt: expiresAt})
+	}
+	return nil
+}
+
+// IsRevoked reports whether the session was revoked. When Redis cannot be reached the
+// session is taken as not revoked, so an outage of Redis does not sign everyone out.
+func (s *Store) IsRevoked(ctx context.Context, sessionId string) bool {
+	now := time.Now()
+
+	s.mutex.Lock()
+	cached, found := s.entries[sessionId]
+	s.mutex.Unlock()
+	if found && now.Before(cached.expiresAt) {
+		return cached.revoked
+	}
+	if s.Redis == nil {
+		return false
+	}
+
+	ttl, err := s.Redis.PTTL(ctx, keyPrefix+sessionId).Result()
+	if err != nil && !errors.Is(err, redis.Nil) {
+		s.Log.WithError(err).Warn("Failed to read revoked sessions, trusting the token")
+		return false
+	}
+
+	// PTTL is negative for a missing key
+	current := entry{revoked: ttl > 0, expiresAt: now.Add(s.CacheTTL)}
+	if current.revoked {
+		current.expiresAt = now.Add(ttl)
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.put(sessionId, current)
+	return current.revoked
+}
+
+func (s *Store) put(sessionId string, value entry) {
+	if len(s.entries) >= maxEntries {
+		now := time.Now()
+		for id, cached := range s.entries {
+			if !now.Before(cached.expiresAt) {
+				delete(s.entries, id)
+			}
+		}
+	}
+	// a revocation recorded here is known for sure, keep it over a cached lookup
+	if cached, found := s.entries[sessionId]; found && cached.revoked && !value.revoked && time.Now().Before(cached.expiresAt) {
+		return
+	}
+	s.entries[sessionId] = value
+}
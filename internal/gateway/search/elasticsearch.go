@@ -0,0 +1,266 @@
+package search
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// mapping matches names as they are typed and emails and phone numbers by any part,
+// as the SQL search does.
+const mapping = `{
+  "settings": {
+    "analysis": {
+      "normalizer": {
+        "lowercase_keyword": { "type": "custom", "filter": ["lowercase"] }
+      }
+    }
+  },
+  "mappings": {
+    "properties": {
+      "id": { "type": "keyword" },
+      "user_id": { "type": "keyword" },
+      "first_name": { "type": "search_as_you_type" },
+      "last_name": { "type": "search_as_you_type" },
+      "email": { "type": "keyword", "normalizer": "lowercase_keyword" },
+      "phone": { "type": "keyword" },
+      "created_at": { "type": "date", "format": "epoch_millis" }
+    }
+  }
+}`
+
+// Elasticsearch is the Index of an Elasticsearch or OpenSearch index. Documents are
+// routed by user, so the documents of a user live on one shard and a search only
+// asks that shard.
+type Elasticsearch struct {
+	Client   *http.Client
+	URL      string
+	Name     string
+	Username string
+	Password string
+}
+
+func NewElasticsearch(client *http.Client, url string, name string, username string, password string) *Elasticsearch {
+	return &Elasticsearch{
+		Client:   client,
+		URL:      strings.TrimSuffix(url, "/"),
+		Name:     name,
+		Username: username,
+		Password: password,
+	}
+}
+
+// EnsureIndex creates the index with its mapping unless it exists.
+func (e *Elasticsearch) EnsureIndex(ctx context.Context) error {
+	status, _, err := e.do(ctx, http.MethodHead, "/"+e.Name, nil, nil)
+	if err != nil {
+		return err
+	}
+	if status == http.StatusOK {
+		return nil
+	}
+
+	status, body, err := e.do(ctx, http.MethodPut, "/"+e.Name, nil, []byte(mapping))
+	if status == http.StatusBadRequest && bytes.Contains(body, []byte("resource_already_exists_exception")) {
+		// another instance created it in between
+		return nil
+	}
+	return e.expect(status, body, err)
+}
+
+func (e *Elasticsearch) Save(ctx context.Context, documents []Document) error {
+	if len(documents) == 0 {
+		return nil
+	}
+
+	body := new(bytes.Buffer)
+	encoder := json.NewEncoder(body)
+	for _, document := range documents {
+		action := map[string]any{"index": map[string]string{"_id": document.ID, "routing": document.UserId}}
+		if err := encoder.Encode(action); err != nil {
+			return err
+		}
+		if err := encoder.Encode(document); err != nil {
+			return err
+		}
+	}
+	return e.bulk(ctx, body.Bytes())
+}
+
+func (e *Elasticsearch) Delete(ctx context.Context, userId string, ids []string) error {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	body := new(bytes.Buffer)
+	encoder := json.NewEncoder(body)
+	for _, id := range ids {
+		action := map[string]any{"delete": map[string]string{"_id": id, "routing": userId}}
+		if err := encoder.Encode(action); err != nil {
+			return err
+		}
+	}
+	return e.bulk(ctx, body.Bytes())
+}
+
+func (e *Elasticsearch) DeleteByUserId(ctx context.Context, userId string) error {
+	body, err := json.Marshal(map[string]any{
+		"query": map[string]any{"term": map[string]string{"user_id": userId}},
+	})
+	if err != nil {
+		return err
+	}
+
+	query := url.Values{"routing": {userId}, "conflicts": {"proceed"}}
+	return e.expect(e.do(ctx, http.MethodPost, "/"+e.Name+"/_delete_by_query", query, body))
+}
+
+func (e *Elasticsearch) Search(ctx context.Context, query *Query) ([]string, int64, error) {
+	must := make([]any, 0, 3)
+	if query.Name != "" {
+		must = append(must, map[string]any{"multi_match": map[string]any{
+			"query": query.Name,
+			"type":  "bool_prefix",
+			"fields": []string{
+				"first_name", "first_name._2gram", "first_name._3gram",
+				"last_name", "last_name._2gram", "last_name._3gram",
+			},
+		}})
+	}
+	if query.Email != "" {
+		must = append(must, contains("email", strings.ToLower(query.Email)))
+	}
+	if query.Phone != "" {
+		must = append(must, contains("phone", query.Phone))
+	}
+
+	body, err := json.Marshal(map[string]any{
+		"from":             query.From,
+		"size":             query.Size,
+		"track_total_hits": true,
+		"_source":          false,
+		"query": map[string]any{"bool": map[string]any{
+			"filter": []any{map[string]any{"term": map[string]string{"user_id": query.UserId}}},
+			"must":   must,
+		}},
+		"sort": []any{"_score", map[string]string{"created_at": "asc"}, map[string]string{"id": "asc"}},
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	status, response, err := e.do(ctx, http.MethodPost, "/"+e.Name+"/_search", url.Values{"routing": {query.UserId}}, body)
+	if err := e.expect(status, response, err); err != nil {
+		return nil, 0, err
+	}
+
+	var result struct {
+		Hits struct {
+			Total struct {
+				Value int64 `json:"value"`
+			} `json:"total"`
+			Hits []struct {
+				ID string `json:"_id"`
+			} `json:"hits"`
+		} `json:"hits"`
+	}
+	if err := json.Unmarshal(response, &result); err != nil {
+		return nil, 0, err
+	}
+
+	ids := make([]string, len(result.Hits.Hits))
+	for i, hit := range result.Hits.Hits {
+		ids[i] = hit.ID
+	}
+	return ids, result.Hits.Total.Value, nil
+}
+
+// wildcardEscaper escapes the characters a wildcard query treats as patterns.
+var wildcardEscaper = strings.NewReplacer(`\`, `\\`, `*`, `\*`, `?`, `\?`)
+
+// contains matches the keyword field holding value anywhere.
+func contains(field string, value string) map[string]any {
+	return map[string]any{"wildcard": map[string]any{
+		field: map[string]string{"value": "*" + wildcardEscaper.Replace(value) + "*"},
+	}}
+}
+
+// bulk runs a _bulk request, which answers 200 even when some of its actions failed.
+func (e *Elasticsearch) bulk(ctx context.Context, body []byte) error {
+	status, response, err := e.do(ctx, http.MethodPost, "/"+e.Name+"/_bulk", nil, body)
+	if err := e.expect(status, response, err); err != nil {
+		return err
+	}
+
+	var result struct {
+		Errors bool `json:"errors"`
+		Items  []map[string]struct {
+			Error any `json:"error"`
+		} `json:"items"`
+	}
+	if err := json.Unmarshal(response, &result); err != nil {
+		return err
+	}
+	if !result.Errors {
+		return nil
+	}
+
+	for _, item := range result.Items {
+		for action, outcome := range item {
+			if outcome.Error != nil {
+				return fmt.Errorf("search: bulk %s failed: %v", action, outcome.Error)
+			}
+		}
+	}
+	return errors.New("search: bulk request failed")
+}
+
+func (e *Elasticsearch) do(ctx context.Context, method string, path string, query url.Values, body []byte) (int, []byte, error) {
+	target := e.URL + path
+	if len(query) > 0 {
+		target += "?" + query.Encode()
+	}
+
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+	request, err := http.NewRequestWithContext(ctx, method, target, reader)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	if body != nil {
+		// the bulk API takes newline delimited JSON, which it accepts as application/json too
+		request.Header.Set("Content-Type", "application/json")
+	}
+	if e.Username != "" {
+		request.SetBasicAuth(e.Username, e.Password)
+	}
+
+	response, err := e.Client.Do(request)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer response.Body.Close()
+
+	content, err := io.ReadAll(response.Body)
+	return response.StatusCode, content, err
+}
+
+// expect turns the outcome of do into an error unless the request succeeded.
+func (e *Elasticsearch) expect(status int, body []byte, err error) error {
+	if err != nil {
+		return err
+	}
+	if status >= 300 {
+		return fmt.Errorf("search: request failed with status %d: %s", status, bytes.TrimSpace(body))
+	}
+	return nil
+}
@@ -0,0 +1,48 @@
+// Package search mirrors contacts into Elasticsearch or OpenSearch, whose REST APIs
+// agree on everything used here, so users with millions of contacts are searched
+// without scanning the contacts table.
+package search
+
+import (
+	"context"
+)
+
+// Document is the indexed form of a contact. Its emails and phone numbers are indexed
+// as arrays under the fields that held a single one, so Query matches any of them.
+type Document struct {
+	ID        string   `json:"id"`
+	UserId    string   `json:"user_id"`
+	FirstName string   `json:"first_name"`
+	LastName  string   `json:"last_name"`
+	Emails    []string `json:"email"`
+	Phones    []string `json:"phone"`
+	CreatedAt int64    `json:"created_at"`
+}
+
+// Query selects a page of the contacts of a user. Fields left empty match every contact.
+type Query struct {
+	UserId string
+	Name   string
+	Email  string
+	Phone  string
+	From   int
+	Size   int
+}
+
+// Index keeps the documents of contacts and searches them. Documents are grouped by
+// user, which every call names.
+type Index interface {
+	// Save adds or replaces documents.
+	Save(ctx context.Context, documents []Document) error
+	// Delete removes the documents of the user with the ids, ignoring unknown ones.
+	Delete(ctx context.Context, userId string, ids []string) error
+	// DeleteByUserId removes every document of the user.
+	DeleteByUserId(ctx context.Context, userId string) error
+	// Search returns the ids of the page of documents matching query, best match
+	// first, and how many match in all.
+	Search(ctx context.Context, query *Query) ([]string, int64, error)
+}
+
+// MaxResultWindow is how deep Search pages, the default index.max_result_window of
+// Elasticsearch and OpenSearch.
+const MaxResultWindow = 10000
@@ -0,0 +1,237 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	amzDateLayout   = "20060102T150405Z"
+	amzDayLayout    = "20060102"
+	unsignedPayload = "UNSIGNED-PAYLOAD"
+)
+
+// S3 is the Store of an S3 bucket, or of a bucket of a store speaking the S3 API such
+// as MinIO. Requests are signed with AWS Signature Version 4, and so are the URLs it
+// hands out, which read the objects without the credentials for up to seven days.
+type S3 struct {
+	Client *http.Client
+	// Endpoint is the base URL of the store, such as https://s3.eu-west-1.amazonaws.com.
+	Endpoint  string
+	Region    string
+	Bucket    string
+	AccessKey string
+	SecretKey string
+	// PathStyle addresses the bucket in the path of the URLs, as MinIO expects,
+	// instead of in their host.
+	PathStyle bool
+}
+
+func NewS3(client *http.Client, endpoint string, region string, bucket string, accessKey string, secretKey string, pathStyle bool) *S3 {
+	return &S3{
+		Client:    client,
+		Endpoint:  strings.TrimSuffix(endpoint, "/"),
+		Region:    region,
+		Bucket:    bucket,
+		AccessKey: accessKey,
+		SecretKey: secretKey,
+		PathStyle: pathStyle,
+	}
+}
+
+func (s *S3) Put(ctx context.Context, key string, contentType string, data []byte) error {
+	request, err := s.request(ctx, http.MethodPut, key, data)
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Content-Type", contentType)
+	return s.do(request)
+}
+
+func (s *S3) Delete(ctx context.Context, keys ...string) error {
+	for _, key := range keys {
+		request, err := s.request(ctx, http.MethodDelete, key, nil)
+		if err != nil {
+			return err
+		}
+		// deleting a missing object succeeds as well
+		if err := s.do(request); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *S3) URL(key string, signedAt time.Time, ttl time.Duration) (string, error) {
+	target, err := s.objectURL(key)
+	if err != nil {
+		return "", err
+	}
+
+	signedAt = signedAt.UTC()
+	query := url.Values{
+		"X-Amz-Algorithm":     {"AWS4-HMAC-SHA256"},
+		"X-Amz-Credential":    {s.AccessKey + "/" + s.scope(signedAt)},
+		"X-Amz-Date":          {signedAt.Format(amzDateLayout)},
+		"X-Amz-Expires":       {strconv.FormatInt(int64(ttl/time.Second), 10)},
+		"X-Amz-SignedHeaders": {"host"},
+	}
+	target.RawQuery = canonicalQuery(query)
+
+	headers := http.Header{"Host": {target.Host}}
+	signature := s.signature(http.MethodGet, target, headers, unsignedPayload, signedAt)
+	target.RawQuery += "&X-Amz-Signature=" + signature
+	return target.String(), nil
+}
+
+// request returns a request of key signed in its Authorization header.
+func (s *S3) request(ctx context.Context, method string, key string, body []byte) (*http.Request, error) {
+	target, err := s.objectURL(key)
+	if err != nil {
+		return nil, err
+	}
+
+	request, err := http.NewRequestWithContext(ctx, method, target.String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	sum := sha256.Sum256(body)
+	payloadHash := hex.EncodeToString(sum[:])
+	request.Header.Set("X-Amz-Date", now.Format(amzDateLayout))
+	request.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	headers := http.Header{
+		"Host":                 {target.Host},
+		"X-Amz-Content-Sha256": {payloadHash},
+		"X-Amz-Date":           {now.Format(amzDateLayout)},
+	}
+	signature := s.signature(method, target, headers, payloadHash, now)
+	request.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s.AccessKey, s.scope(now), signedHeaders(headers), signature))
+	return request, nil
+}
+
+func (s *S3) do(request *http.Request) error {
+	response, err := s.Client.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	body, _ := io.ReadAll(io.LimitReader(response.Body, 1024))
+	if response.StatusCode >= 300 {
+		return fmt.Errorf("storage: %s %s answered %d: %s", request.Method, request.URL.Path, response.StatusCode, body)
+	}
+	return nil
+}
+
+func (s *S3) objectURL(key string) (*url.URL, error) {
+	target, err := url.Parse(s.Endpoint)
+	if err != nil {
+		return nil, err
+	}
+	if s.PathStyle {
+		target.Path += "/" + s.Bucket + "/" + key
+	} else {
+		target.Host = s.Bucket + "." + target.Host
+		target.Path += "/" + key
+	}
+	return target, nil
+}
+
+func (s *S3) scope(at time.Time) string {
+	return at.Format(amzDayLayout) + "/" + s.Region + "/s3/aws4_request"
+}
+
+// signature signs a request of target with headers as Signature Version 4 does.
+func (s *S3) signature(method string, target *url.URL, headers http.Header, payloadHash string, at time.Time) string {
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, strings.ToLower(name))
+	}
+	sort.Strings(names)
+
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + strings.TrimSpace(headers.Get(name)) + "\n")
+	}
+
+	canonicalRequest := strings.Join([]string{
+		method,
+		uriEncode(target.Path, false),
+		target.RawQuery,
+		canonicalHeaders.String(),
+		signedHeaders(headers),
+		payloadHash,
+	}, "\n")
+	sum := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := "AWS4-HMAC-SHA256\n" + at.Format(amzDateLayout) + "\n" + s.scope(at) + "\n" + hex.EncodeToString(sum[:])
+
+	key := hmacSHA256([]byte("AWS4"+s.SecretKey), at.Format(amzDayLayout))
+	key = hmacSHA256(key, s.Region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	return hex.EncodeToString(hmacSHA256(key, stringToSign))
+}
+
+func signedHeaders(headers http.Header) string {
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, strings.ToLower(name))
+	}
+	sort.Strings(names)
+	return strings.Join(names, ";")
+}
+
+// canonicalQuery encodes query sorted by name, with the encoding Signature Version 4 expects.
+func canonicalQuery(query url.Values) string {
+	names := make([]string, 0, len(query))
+	for name := range query {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	parts := make([]string, 0, len(names))
+	for _, name := range names {
+		for _, value := range query[name] {
+			parts = append(parts, uriEncode(name, true)+"="+uriEncode(value, true))
+		}
+	}
+	return strings.Join(parts, "&")
+}
+
+// uriEncode percent-encodes every byte of value but the unreserved characters of
+// RFC 3986, and slashes unless encodeSlash.
+func uriEncode(value string, encodeSlash bool) string {
+	var encoded strings.Builder
+	for _, b := range []byte(value) {
+		switch {
+		case 'A' <= b && b <= 'Z', 'a' <= b && b <= 'z', '0' <= b && b <= '9', b == '-', b == '_', b == '.', b == '~':
+			encoded.WriteByte(b)
+		case b == '/' && !encodeSlash:
+			encoded.WriteByte(b)
+		default:
+			fmt.Fprintf(&encoded, "%%%02X", b)
+		}
+	}
+	return encoded.String()
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
@@ -0,0 +1,28 @@
+// Package storage keeps files such as contact avatars in an object store, on the local
+// disk or in an S3 bucket, S3 compatible stores such as MinIO included, and hands out
+// signed URLs that read them for a while without the credentials of the store.
+package storage
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// ErrNotFound is returned by Disk.Open for a key without a file.
+var ErrNotFound = errors.New("object not found")
+
+// ErrInvalidSignature is returned by Disk.Open for a URL that was not signed by the
+// store, or whose signature expired.
+var ErrInvalidSignature = errors.New("invalid or expired signature")
+
+// Store keeps objects by key. Keys are paths of letters, digits, dashes, dots and
+// slashes, which every backend stores as they are.
+type Store interface {
+	// Put stores data at key, replacing the object there.
+	Put(ctx context.Context, key string, contentType string, data []byte) error
+	// Delete removes the objects at keys; keys without an object are skipped.
+	Delete(ctx context.Context, keys ...string) error
+	// URL returns a URL reading the object at key, valid from signedAt for ttl.
+	URL(key string, signedAt time.Time, ttl time.Duration) (string, error)
+}
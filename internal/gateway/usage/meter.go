@@ -0,0 +1,120 @@
+// Package usage counts the requests of users and API keys per UTC day.
+package usage
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/sirupsen/logrus"
+)
+
+const keyPrefix = "usage:"
+
+// dayLayout names the day of a counter in its key.
+const dayLayout = "2006-01-02"
+
+// retention is how long a counter outlives its day, so the day before can still be
+// read around midnight.
+const retention = 48 * time.Hour
+
+// Meter counts requests per subject and day. With Redis configured the counters are
+// shared between instances; without Redis every instance counts the requests it
+// served, which is enough for single-instance deployments and local development.
+type Meter struct {
+	Log   *logrus.Logger
+	Redis *redis.Client
+
+	mutex sync.Mutex
+	day   string
+	local map[string]int64
+}
+
+func NewMeter(client *redis.Client, log *logrus.Logger) *Meter {
+	return &Meter{
+		Log:   log,
+		Redis: client,
+		local: make(map[string]int64),
+	}
+}
+
+// Add counts one request of every subject on day and returns their counts with it,
+// in the order of subjects.
+func (m *Meter) Add(ctx context.Context, day time.Time, subjects ...string) ([]int64, error) {
+	name := day.Format(dayLayout)
+	if m.Redis == nil {
+		return m.addLocal(name, subjects), nil
+	}
+
+	pipeline := m.Redis.TxPipeline()
+	incrs := make([]*redis.IntCmd, len(subjects))
+	for i, subject := range subjects {
+		incrs[i] = pipeline.Incr(ctx, keyPrefix+subject+":"+name)
+		pipeline.Expire(ctx, keyPrefix+subject+":"+name, retention)
+	}
+	if _, err := pipeline.Exec(ctx); err != nil {
+		return nil, err
+	}
+
+	counts := make([]int64, len(subjects))
+	for i, incr := range incrs {
+		counts[i] = incr.Val()
+	}
+	return counts, nil
+}
+
+// Get returns the requests of every subject on day, in the order of subjects.
+func (m *Meter) Get(ctx context.Context, day time.Time, subjects ...string) ([]int64, error) {
+	name := day.Format(dayLayout)
+	counts := make([]int64, len(subjects))
+	if len(subjects) == 0 {
+		return counts, nil
+	}
+
+	if m.Redis == nil {
+		m.mutex.Lock()
+		defer m.mutex.Unlock()
+		if m.day == name {
+			for i, subject := range subjects {
+				counts[i] = m.local[subject]
+			}
+		}
+		return counts, nil
+	}
+
+	keys := make([]string, len(subjects))
+	for i, subject := range subjects {
+		keys[i] = keyPrefix + subject + ":" + name
+	}
+	values, err := m.Redis.MGet(ctx, keys...).Result()
+	if err != nil {
+		return nil, err
+	}
+	for i, value := range values {
+		// missing counters are nil, the others come back as strings
+		if value, ok := value.(string); ok {
+			counts[i], _ = strconv.ParseInt(value, 10, 64)
+		}
+	}
+	return counts, nil
+}
+
+func (m *Meter) addLocal(day string, subjects []string) []int64 {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	// only the current day is kept in memory
+	if m.day != day {
+		m.day = day
+		m.local = make(map[string]int64)
+	}
+
+	counts := make([]int64, len(subjects))
+	for i, subject := range subjects {
+		m.local[subject]++
+		counts[i] = m.local[subject]
+	}
+	return counts
+}
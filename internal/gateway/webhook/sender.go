@@ -0,0 +1,160 @@
+// Package webhook posts events to the endpoints registered by users.
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/netip"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// secretPrefix starts every signing secret, as in the Standard Webhooks specification.
+const secretPrefix = "whsec_"
+
+// ErrForbiddenAddress is returned for deliveries to an address webhooks may not reach.
+var ErrForbiddenAddress = errors.New("webhook address is not allowed")
+
+// NewSecret returns a random secret to sign the deliveries of a webhook with.
+func NewSecret() (string, error) {
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return "", err
+	}
+	return secretPrefix + base64.StdEncoding.EncodeToString(key), nil
+}
+
+// Sign returns the Webhook-Signature of a delivery per the Standard Webhooks
+// specification: v1, then the base64 HMAC-SHA256 of "<id>.<timestamp>.<payload>"
+// keyed by the base64 decoded secret.
+func Sign(secret string, id string, timestamp time.Time, payload []byte) (string, error) {
+	encoded, found := strings.CutPrefix(secret, secretPrefix)
+	if !found {
+		return "", errors.New("webhook secret must start with " + secretPrefix)
+	}
+	key, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", err
+	}
+
+	hash := hmac.New(sha256.New, key)
+	hash.Write([]byte(id + "." + strconv.FormatInt(timestamp.Unix(), 10) + "."))
+	hash.Write(payload)
+	return "v1," + base64.StdEncoding.EncodeToString(hash.Sum(nil)), nil
+}
+
+// Result describes one delivery attempt.
+type Result struct {
+	Status  int
+	Latency time.Duration
+	// Snippet is the beginning of the response body, for debugging failed deliveries.
+	Snippet string
+	Err     error
+}
+
+// Succeeded reports whether the endpoint accepted the event with a 2xx response.
+func (r Result) Succeeded() bool {
+	return r.Err == nil && r.Status >= 200 && r.Status < 300
+}
+
+type Sender struct {
+	Client      *http.Client
+	UserAgent   string
+	SnippetSize int
+}
+
+// NewSender returns a sender that only reaches public addresses and those in allowed.
+// Addresses are checked as they are dialed, after DNS resolution, so a hostname
+// resolving to a private address is refused as well. Redirects are not followed,
+// a redirect being a failed delivery like any other response but a 2xx.
+func NewSender(timeout time.Duration, snippetSize int, userAgent string, allowed []netip.Prefix) *Sender {
+	dialer := &net.Dialer{Timeout: timeout, Control: dialControl(allowed)}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	// a proxy would dial the endpoint itself, past the check
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+
+	return &Sender{
+		Client: &http.Client{
+			Timeout:   timeout,
+			Transport: transport,
+			CheckRedirect: func(request *http.Request, via []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+		UserAgent:   userAgent,
+		SnippetSize: snippetSize,
+	}
+}
+
+// dialControl refuses connections to loopback, private, link-local and unspecified
+// addresses outside of allowed, which would let users reach the network of the
+// service and read the responses from the delivery log.
+func dialControl(allowed []netip.Prefix) func(network string, address string, conn syscall.RawConn) error {
+	return func(network string, address string, conn syscall.RawConn) error {
+		addrPort, err := netip.ParseAddrPort(address)
+		if err != nil {
+			return err
+		}
+
+		addr := addrPort.Addr().Unmap()
+		for _, prefix := range allowed {
+			if prefix.Contains(addr) {
+				return nil
+			}
+		}
+		if addr.IsLoopback() || addr.IsPrivate() || addr.IsLinkLocalUnicast() || addr.IsLinkLocalMulticast() || addr.IsUnspecified() {
+			return ErrForbiddenAddress
+		}
+		return nil
+	}
+}
+
+// Send posts a CloudEvent in structured content mode, signed with secret unless it
+// is empty. Anything but a 2xx response counts as a failed attempt.
+func (s *Sender) Send(ctx context.Context, url string, secret string, eventId string, eventType string, payload []byte) Result {
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(payload))
+	if err != nil {
+		return Result{Err: err}
+	}
+	request.Header.Set("Content-Type", "application/cloudevents+json")
+	request.Header.Set("User-Agent", s.UserAgent)
+	request.Header.Set("Webhook-Id", eventId)
+	request.Header.Set("Webhook-Event", eventType)
+
+	if secret != "" {
+		// every attempt is signed anew, so retries pass the receiver's timestamp check
+		now := time.Now()
+		signature, err := Sign(secret, eventId, now, payload)
+		if err != nil {
+			return Result{Err: err}
+		}
+		request.Header.Set("Webhook-Timestamp", strconv.FormatInt(now.Unix(), 10))
+		request.Header.Set("Webhook-Signature", signature)
+	}
+
+	start := time.Now()
+	response, err := s.Client.Do(request)
+	if err != nil {
+		return Result{Latency: time.Since(start), Err: err}
+	}
+	defer response.Body.Close()
+
+	// the snippet is best effort, a body cut short does not fail an accepted delivery
+	snippet, _ := io.ReadAll(io.LimitReader(response.Body, int64(s.SnippetSize)))
+	return Result{
+		Status:  response.StatusCode,
+		Latency: time.Since(start),
+		Snippet: string(snippet),
+	}
+}
@@ -0,0 +1,188 @@
+// Package idgen generates the primary keys of entities. Each entity picks its own
+// strategy, so a deployment can use time-sortable IDs where index locality matters
+// and opaque ones where IDs must not reveal when a row was created.
+package idgen
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	// UUIDv4 is random and reveals nothing, the default.
+	UUIDv4 = "uuidv4"
+	// UUIDv7 starts with the creation time in milliseconds, so IDs sort by age.
+	UUIDv7 = "uuidv7"
+	// ULID is time-sortable like UUIDv7 in 26 URL-safe characters.
+	ULID = "ulid"
+	// Sequence takes the next value of the entity's database sequence.
+	Sequence = "sequence"
+)
+
+// Entities whose IDs are generated through a Generators.
+const (
+	Contact         = "contact"
+	Address         = "address"
+	Tag             = "tag"
+	Note            = "note"
+	Reminder        = "reminder"
+	Webhook         = "webhook"
+	WebhookDelivery = "webhook_delivery"
+	Announcement    = "announcement"
+)
+
+var Entities = []string{Contact, Address, Tag, Note, Reminder, Webhook, WebhookDelivery, Announcement}
+
+// sequences names the database sequence backing each entity for the Sequence strategy.
+var sequences = map[string]string{
+	Contact:         "contacts_id_seq",
+	Address:         "addresses_id_seq",
+	Tag:             "tags_id_seq",
+	Note:            "notes_id_seq",
+	Reminder:        "reminders_id_seq",
+	Webhook:         "webhooks_id_seq",
+	WebhookDelivery: "webhook_deliveries_id_seq",
+	Announcement:    "announcements_id_seq",
+}
+
+// IDGenerator returns a new unique ID.
+type IDGenerator interface {
+	NewID(ctx context.Context) (string, error)
+}
+
+// New returns the generator of strategy for entity. db is only used by Sequence.
+func New(strategy string, entity string, db *gorm.DB) (IDGenerator, error) {
+	switch strategy {
+	case "", UUIDv4:
+		return uuidV4{}, nil
+	case UUIDv7:
+		return uuidV7{}, nil
+	case ULID:
+		return ulid{}, nil
+	case Sequence:
+		if db.Dialector.Name() != "postgres" {
+			return nil, fmt.Errorf("id strategy %q needs postgres, not %s", strategy, db.Dialector.Name())
+		}
+		sequence, ok := sequences[entity]
+		if !ok {
+			return nil, fmt.Errorf("entity %q has no id sequence", entity)
+		}
+		return &sequenceGenerator{DB: db, Sequence: sequence}, nil
+	default:
+		return nil, fmt.Errorf("unknown id strategy %q", strategy)
+	}
+}
+
+// Generators holds the generator of every entity. A nil Generators is valid and
+// hands out UUIDv4, which keeps use cases usable without configuration.
+type Generators struct {
+	generators map[string]IDGenerator
+}
+
+func NewGenerators(generators map[string]IDGenerator) *Generators {
+	return &Generators{generators: generators}
+}
+
+// NewID returns a new ID for entity.
+func (g *Generators) NewID(ctx context.Context, entity string) (string, error) {
+	if g != nil {
+		if generator, ok := g.generators[entity]; ok {
+			return generator.NewID(ctx)
+		}
+	}
+	return uuid.NewString(), nil
+}
+
+type uuidV4 struct{}
+
+func (uuidV4) NewID(ctx context.Context) (string, error) {
+	return uuid.NewString(), nil
+}
+
+type uuidV7 struct{}
+
+func (uuidV7) NewID(ctx context.Context) (string, error) {
+	id, err := uuid.NewV7()
+	if err != nil {
+		return "", err
+	}
+	return id.String(), nil
+}
+
+const crockford = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+// ulid encodes a 48-bit millisecond timestamp followed by 80 random bits in
+// Crockford base32. IDs created in the same millisecond are not ordered.
+type ulid struct{}
+
+func (ulid) NewID(ctx context.Context) (string, error) {
+	var id [16]byte
+	if _, err := rand.Read(id[6:]); err != nil {
+		return "", err
+	}
+	ms := uint64(time.Now().UnixMilli())
+	for i := 5; i >= 0; i-- {
+		id[i] = byte(ms)
+		ms >>= 8
+	}
+
+	hi := binary.BigEndian.Uint64(id[:8])
+	lo := binary.BigEndian.Uint64(id[8:])
+
+	var encoded [26]byte
+	for i := len(encoded) - 1; i >= 0; i-- {
+		encoded[i] = crockford[lo&31]
+		lo = lo>>5 | hi<<59
+		hi >>= 5
+	}
+	return string(encoded[:]), nil
+}
+
+// Valid reports whether id has the format of one of the strategies, so requests can
+// check the IDs they name whatever strategy created them: a UUID, a ULID or the
+// decimal value of a sequence.
+func Valid(id string) bool {
+	switch {
+	case len(id) == 36:
+		_, err := uuid.Parse(id)
+		return err == nil
+	case len(id) == 26:
+		// the timestamp fills 48 of the first 50 bits, so the first character is at most 7
+		if id[0] > '7' {
+			return false
+		}
+		for i := 0; i < len(id); i++ {
+			if strings.IndexByte(crockford, id[i]) < 0 {
+				return false
+			}
+		}
+		return true
+	case len(id) > 0 && len(id) <= 19:
+		_, err := strconv.ParseInt(id, 10, 64)
+		return err == nil && id[0] != '-' && id[0] != '+'
+	}
+	return false
+}
+
+type sequenceGenerator struct {
+	DB       *gorm.DB
+	Sequence string
+}
+
+// NewID draws from the sequence outside of any transaction: sequence values are
+// never handed out twice, even when the transaction of the insert rolls back.
+func (g *sequenceGenerator) NewID(ctx context.Context) (string, error) {
+	var value int64
+	if err := g.DB.WithContext(ctx).Raw("SELECT nextval(?::regclass)", g.Sequence).Scan(&value).Error; err != nil {
+		return "", err
+	}
+	return fmt.Sprint(value), nil
+}
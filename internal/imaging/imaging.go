@@ -0,0 +1,94 @@
+// Package imaging decodes uploaded pictures and scales them to square thumbnails
+// with the standard library alone.
+package imaging
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	_ "image/gif"
+	"image/jpeg"
+	_ "image/png"
+)
+
+// MaxPixels bounds the pictures Decode accepts, as a small file can hold a picture
+// that takes gigabytes to decode.
+const MaxPixels = 40_000_000
+
+// ErrUnsupported is returned by Decode for data that is not a JPEG, PNG or GIF picture.
+var ErrUnsupported = errors.New("not a JPEG, PNG or GIF image")
+
+// ErrTooLarge is returned by Decode for a picture of more than MaxPixels pixels.
+var ErrTooLarge = errors.New("image has too many pixels")
+
+// Decode reads a JPEG, PNG or GIF picture, checking its size before decoding it.
+func Decode(data []byte) (image.Image, error) {
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrUnsupported
+	}
+	if config.Width <= 0 || config.Height <= 0 || config.Width*config.Height > MaxPixels {
+		return nil, ErrTooLarge
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrUnsupported
+	}
+	return img, nil
+}
+
+// Thumbnail crops the middle square of img and scales it to size×size pixels. Every
+// pixel is the average of the pixels it covers, so scaled down photos do not alias,
+// and transparent pixels are laid on white, as JPEG has no transparency.
+func Thumbnail(img image.Image, size int) *image.RGBA {
+	bounds := img.Bounds()
+	side := min(bounds.Dx(), bounds.Dy())
+	left := bounds.Min.X + (bounds.Dx()-side)/2
+	top := bounds.Min.Y + (bounds.Dy()-side)/2
+
+	thumbnail := image.NewRGBA(image.Rect(0, 0, size, size))
+	for y := range size {
+		y0, y1 := span(top, side, size, y)
+		for x := range size {
+			x0, x1 := span(left, side, size, x)
+
+			var r, g, b, a, n uint64
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					pr, pg, pb, pa := img.At(sx, sy).RGBA()
+					r, g, b, a = r+uint64(pr), g+uint64(pg), b+uint64(pb), a+uint64(pa)
+					n++
+				}
+			}
+
+			// the colors are premultiplied, so white shows through by what alpha leaves
+			white := 0xffff*n - a
+			thumbnail.SetRGBA(x, y, color.RGBA{
+				R: uint8((r + white) / n >> 8),
+				G: uint8((g + white) / n >> 8),
+				B: uint8((b + white) / n >> 8),
+				A: 0xff,
+			})
+		}
+	}
+	return thumbnail
+}
+
+// span returns the source pixels under pixel i of size, of a side long source
+// starting at start, at least one of them when scaling up.
+func span(start int, side int, size int, i int) (int, int) {
+	from := start + i*side/size
+	to := start + (i+1)*side/size
+	return from, max(to, from+1)
+}
+
+// EncodeJPEG encodes img as a JPEG of good quality.
+func EncodeJPEG(img image.Image) ([]byte, error) {
+	var buffer bytes.Buffer
+	if err := jpeg.Encode(&buffer, img, &jpeg.Options{Quality: 85}); err != nil {
+		return nil, err
+	}
+	return buffer.Bytes(), nil
+}
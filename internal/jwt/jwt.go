@@ -0,0 +1,244 @@
+// Package jwt issues and verifies the signed access tokens that let instances
+// authenticate requests without looking the token up in the database. Only the
+// algorithms the API is configured with are accepted, never the one a token names.
+package jwt
+
+import (
+	"crypto"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"strings"
+	"time"
+)
+
+const (
+	// RS256 signs with an RSA key whose public half is published as a JWKS, so other
+	// services can verify tokens too.
+	RS256 = "RS256"
+	// HS256 signs with a shared secret, which only the instances holding it can verify.
+	HS256 = "HS256"
+)
+
+var (
+	ErrInvalid = errors.New("invalid token")
+	ErrExpired = errors.New("token has expired")
+)
+
+// Claims are what an access token asserts about its bearer.
+type Claims struct {
+	Issuer    string `json:"iss,omitempty"`
+	Subject   string `json:"sub"`
+	Role      string `json:"role,omitempty"`
+	Region    string `json:"region,omitempty"`
+	SessionId string `json:"sid,omitempty"`
+	// Scope limits the token to part of the API, space separated. Empty for no limit.
+	Scope string `json:"scope,omitempty"`
+	// Actor is the admin acting as Subject when impersonating them, nil otherwise.
+	Actor     *Actor `json:"act,omitempty"`
+	IssuedAt  int64  `json:"iat"`
+	ExpiresAt int64  `json:"exp"`
+}
+
+// Actor names who is acting on behalf of the subject of a token (RFC 8693).
+type Actor struct {
+	Subject string `json:"sub"`
+}
+
+type header struct {
+	Algorithm string `json:"alg"`
+	Type      string `json:"typ"`
+	KeyID     string `json:"kid,omitempty"`
+}
+
+// Issuer signs access tokens with Algorithm and verifies them.
+type Issuer struct {
+	Algorithm string
+	Issuer    string
+	TTL       time.Duration
+	// PrivateKey signs RS256 tokens.
+	PrivateKey *rsa.PrivateKey
+	// PublicKeys verify RS256 tokens by key ID: the one of PrivateKey and the ones still
+	// trusted after a key rotation, until the tokens they signed have expired.
+	PublicKeys map[string]*rsa.PublicKey
+	// Secret signs and verifies HS256 tokens.
+	Secret []byte
+}
+
+// NewRS256 returns an issuer signing with key and also accepting tokens signed by trusted.
+func NewRS256(issuer string, ttl time.Duration, key *rsa.PrivateKey, trusted ...*rsa.PublicKey) *Issuer {
+	publicKeys := map[string]*rsa.PublicKey{KeyID(&key.PublicKey): &key.PublicKey}
+	for _, publicKey := range trusted {
+		publicKeys[KeyID(publicKey)] = publicKey
+	}
+
+	return &Issuer{
+		Algorithm:  RS256,
+		Issuer:     issuer,
+		TTL:        ttl,
+		PrivateKey: key,
+		PublicKeys: publicKeys,
+	}
+}
+
+// NewHS256 returns an issuer signing with the shared secret.
+func NewHS256(issuer string, ttl time.Duration, secret []byte) *Issuer {
+	return &Issuer{
+		Algorithm: HS256,
+		Issuer:    issuer,
+		TTL:       ttl,
+		Secret:    secret,
+	}
+}
+
+// IsJWT tells a JWT apart from an opaque token, which holds at most one dot.
+func IsJWT(token string) bool {
+	return strings.Count(token, ".") == 2
+}
+
+// Issue signs claims, setting their issuer, issue time and expiry from now.
+func (i *Issuer) Issue(claims Claims, now time.Time) (string, error) {
+	return i.IssueFor(claims, now, i.TTL)
+}
+
+// IssueFor is Issue for a token expiring after ttl instead of the TTL of the issuer.
+func (i *Issuer) IssueFor(claims Claims, now time.Time, ttl time.Duration) (string, error) {
+	claims.Issuer = i.Issuer
+	claims.IssuedAt = now.Unix()
+	claims.ExpiresAt = now.Add(ttl).Unix()
+
+	tokenHeader := header{Algorithm: i.Algorithm, Type: "JWT"}
+	if i.PrivateKey != nil {
+		tokenHeader.KeyID = KeyID(&i.PrivateKey.PublicKey)
+	}
+
+	encodedHeader, err := encode(tokenHeader)
+	if err != nil {
+		return "", err
+	}
+	encodedClaims, err := encode(claims)
+	if err != nil {
+		return "", err
+	}
+
+	signingInput := encodedHeader + "." + encodedClaims
+	signature, err := i.sign(signingInput)
+	if err != nil {
+		return "", err
+	}
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
+
+// Verify checks the signature, issuer and expiry of token and returns its claims.
+func (i *Issuer) Verify(token string, now time.Time) (*Claims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, ErrInvalid
+	}
+
+	tokenHeader := new(header)
+	if err := decode(parts[0], tokenHeader); err != nil || tokenHeader.Algorithm != i.Algorithm {
+		return nil, ErrInvalid
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, ErrInvalid
+	}
+	if !i.verify(parts[0]+"."+parts[1], signature, tokenHeader.KeyID) {
+		return nil, ErrInvalid
+	}
+
+	claims := new(Claims)
+	if err := decode(parts[1], claims); err != nil || claims.Issuer != i.Issuer || claims.Subject == "" {
+		return nil, ErrInvalid
+	}
+	if now.Unix() >= claims.ExpiresAt {
+		return nil, ErrExpired
+	}
+	return claims, nil
+}
+
+func (i *Issuer) sign(signingInput string) ([]byte, error) {
+	if i.Algorithm == HS256 {
+		mac := hmac.New(sha256.New, i.Secret)
+		mac.Write([]byte(signingInput))
+		return mac.Sum(nil), nil
+	}
+
+	digest := sha256.Sum256([]byte(signingInput))
+	return rsa.SignPKCS1v15(rand.Reader, i.PrivateKey, crypto.SHA256, digest[:])
+}
+
+func (i *Issuer) verify(signingInput string, signature []byte, keyID string) bool {
+	if i.Algorithm == HS256 {
+		expected, _ := i.sign(signingInput)
+		return hmac.Equal(signature, expected)
+	}
+
+	publicKey, ok := i.PublicKeys[keyID]
+	if !ok {
+		return false
+	}
+	digest := sha256.Sum256([]byte(signingInput))
+	return rsa.VerifyPKCS1v15(publicKey, crypto.SHA256, digest[:], signature) == nil
+}
+
+// JWK is an RSA public key as published in a JSON Web Key Set (RFC 7517).
+type JWK struct {
+	KeyType   string `json:"kty"`
+	Use       string `json:"use"`
+	Algorithm string `json:"alg"`
+	KeyID     string `json:"kid"`
+	N         string `json:"n"`
+	E         string `json:"e"`
+}
+
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// JWKS returns the public keys tokens are verified with. It is empty for HS256, whose
+// secret must not be published.
+func (i *Issuer) JWKS() JWKS {
+	keys := JWKS{Keys: []JWK{}}
+	for keyID, publicKey := range i.PublicKeys {
+		n, e := rsaComponents(publicKey)
+		keys.Keys = append(keys.Keys, JWK{KeyType: "RSA", Use: "sig", Algorithm: RS256, KeyID: keyID, N: n, E: e})
+	}
+	return keys
+}
+
+// KeyID is the RFC 7638 thumbprint of publicKey, so the same key always gets the same
+// ID on every instance without configuring one.
+func KeyID(publicKey *rsa.PublicKey) string {
+	n, e := rsaComponents(publicKey)
+	// the members in lexicographic order, as the thumbprint requires
+	digest := sha256.Sum256([]byte(`{"e":"` + e + `","kty":"RSA","n":"` + n + `"}`))
+	return base64.RawURLEncoding.EncodeToString(digest[:])
+}
+
+func rsaComponents(publicKey *rsa.PublicKey) (string, string) {
+	return base64.RawURLEncoding.EncodeToString(publicKey.N.Bytes()),
+		base64.RawURLEncoding.EncodeToString(big.NewInt(int64(publicKey.E)).Bytes())
+}
+
+func encode(value any) (string, error) {
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(raw), nil
+}
+
+func decode(encoded string, value any) error {
+	raw, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(raw, value)
+}
@@ -0,0 +1,86 @@
+// Package logging lets the log level be changed at runtime, globally and per component.
+package logging
+
+import (
+	"sync"
+
+	"github.com/sirupsen/logrus"
+)
+
+// ComponentField is the entry field that names the component an entry comes from,
+// e.g. log.WithField(logging.ComponentField, "gorm").
+const ComponentField = "component"
+
+// Levels holds the global level and per-component overrides of a logger. The logger
+// itself runs at the most verbose of these levels and a filtering formatter drops the
+// entries that are above the effective level of their component.
+type Levels struct {
+	Logger *logrus.Logger
+
+	mutex      sync.RWMutex
+	level      logrus.Level
+	components map[string]logrus.Level
+}
+
+// NewLevels takes over level handling of logger.
+func NewLevels(logger *logrus.Logger, level logrus.Level, components map[string]logrus.Level) *Levels {
+	levels := &Levels{Logger: logger}
+	logger.SetFormatter(&filterFormatter{Formatter: logger.Formatter, levels: levels})
+	levels.Set(level, components)
+	return levels
+}
+
+// Set replaces the global level and all component overrides.
+func (l *Levels) Set(level logrus.Level, components map[string]logrus.Level) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	l.level = level
+	l.components = make(map[string]logrus.Level, len(components))
+
+	verbose := level
+	for name, componentLevel := range components {
+		l.components[name] = componentLevel
+		if componentLevel > verbose {
+			verbose = componentLevel
+		}
+	}
+	l.Logger.SetLevel(verbose)
+}
+
+// Get returns the global level and a copy of the component overrides.
+func (l *Levels) Get() (logrus.Level, map[string]logrus.Level) {
+	l.mutex.RLock()
+	defer l.mutex.RUnlock()
+
+	components := make(map[string]logrus.Level, len(l.components))
+	for name, level := range l.components {
+		components[name] = level
+	}
+	return l.level, components
+}
+
+func (l *Levels) enabled(entry *logrus.Entry) bool {
+	l.mutex.RLock()
+	defer l.mutex.RUnlock()
+
+	if component, ok := entry.Data[ComponentField].(string); ok {
+		if level, ok := l.components[component]; ok {
+			return entry.Level <= level
+		}
+	}
+	return entry.Level <= l.level
+}
+
+type filterFormatter struct {
+	logrus.Formatter
+	levels *Levels
+}
+
+// Format returns nothing for filtered entries, so logrus writes nothing for them.
+func (f *filterFormatter) Format(entry *logrus.Entry) ([]byte, error) {
+	if !f.levels.enabled(entry) {
+		return nil, nil
+	}
+	return f.Formatter.Format(entry)
+}
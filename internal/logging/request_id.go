@@ -0,0 +1,29 @@
+package logging
+
+import (
+	"go-rest-scaffold/internal/model"
+
+	"github.com/sirupsen/logrus"
+)
+
+// RequestIDField is the entry field holding the ID of the request an entry was
+// logged for, so every entry of a request can be found from the ID a user reports.
+const RequestIDField = "request_id"
+
+// RequestIDHook adds the request ID to the entries logged with the context of a
+// request, e.g. log.WithContext(ctx).Error(...).
+type RequestIDHook struct{}
+
+func (RequestIDHook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+func (RequestIDHook) Fire(entry *logrus.Entry) error {
+	if entry.Context == nil {
+		return nil
+	}
+	if requestId := model.RequestIDFrom(entry.Context); requestId != "" {
+		entry.Data[RequestIDField] = requestId
+	}
+	return nil
+}
@@ -0,0 +1,191 @@
+// Package mail renders the transactional emails sent by the service.
+package mail
+
+import (
+	"bytes"
+	"embed"
+	"encoding/json"
+	"fmt"
+	htmltemplate "html/template"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	texttemplate "text/template"
+)
+
+// The built-in templates live in templates/<name>/v<version>/ as subject.txt, body.html
+// and body.txt, next to a sample.json holding the data used for previews. Changing a
+// built-in template means adding a new version directory; the highest version wins.
+//
+//go:embed templates
+var templatesFS embed.FS
+
+// Template is the source of one email. Subject and Text are text/template sources,
+// HTML is an html/template source so data is escaped.
+type Template struct {
+	Name    string
+	Version int
+	Subject string
+	HTML    string
+	Text    string
+}
+
+// Message is a rendered email.
+type Message struct {
+	Subject string
+	HTML    string
+	Text    string
+}
+
+// Catalog holds the latest version of every built-in template. Every template can
+// use {{.AppName}} on top of the data of its own email.
+type Catalog struct {
+	AppName   string
+	templates map[string]Template
+	samples   map[string]map[string]any
+}
+
+func NewCatalog(appName string) (*Catalog, error) {
+	catalog := &Catalog{
+		AppName:   appName,
+		templates: make(map[string]Template),
+		samples:   make(map[string]map[string]any),
+	}
+
+	entries, err := fs.ReadDir(templatesFS, "templates")
+	if err != nil {
+		return nil, err
+	}
+
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+
+		template, sample, err := load(entry.Name())
+		if err != nil {
+			return nil, fmt.Errorf("email template %s: %w", entry.Name(), err)
+		}
+		catalog.templates[template.Name] = template
+		catalog.samples[template.Name] = sample
+
+		if _, err := template.Render(catalog.Sample(template.Name)); err != nil {
+			return nil, fmt.Errorf("email template %s: %w", entry.Name(), err)
+		}
+	}
+
+	return catalog, nil
+}
+
+func load(name string) (Template, map[string]any, error) {
+	dir := path.Join("templates", name)
+	entries, err := fs.ReadDir(templatesFS, dir)
+	if err != nil {
+		return Template{}, nil, err
+	}
+
+	template := Template{Name: name}
+	for _, entry := range entries {
+		version, err := strconv.Atoi(strings.TrimPrefix(entry.Name(), "v"))
+		if entry.IsDir() && strings.HasPrefix(entry.Name(), "v") && err == nil && version > template.Version {
+			template.Version = version
+		}
+	}
+	if template.Version == 0 {
+		return Template{}, nil, fmt.Errorf("no version directory")
+	}
+
+	versionDir := path.Join(dir, "v"+strconv.Itoa(template.Version))
+	for file, source := range map[string]*string{"subject.txt": &template.Subject, "body.html": &template.HTML, "body.txt": &template.Text} {
+		content, err := fs.ReadFile(templatesFS, path.Join(versionDir, file))
+		if err != nil {
+			return Template{}, nil, err
+		}
+		*source = string(content)
+	}
+
+	sample := make(map[string]any)
+	content, err := fs.ReadFile(templatesFS, path.Join(dir, "sample.json"))
+	if err != nil {
+		return Template{}, nil, err
+	}
+	if err := json.Unmarshal(content, &sample); err != nil {
+		return Template{}, nil, err
+	}
+
+	return template, sample, nil
+}
+
+// Names returns the names of the built-in templates in alphabetical order.
+func (c *Catalog) Names() []string {
+	names := make([]string, 0, len(c.templates))
+	for name := range c.templates {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (c *Catalog) Default(name string) (Template, bool) {
+	template, ok := c.templates[name]
+	return template, ok
+}
+
+// Sample returns a copy of the preview data of a template.
+func (c *Catalog) Sample(name string) map[string]any {
+	return c.Data(c.samples[name])
+}
+
+// Data returns a copy of data completed with the values shared by every template.
+func (c *Catalog) Data(data map[string]any) map[string]any {
+	result := make(map[string]any, len(data)+1)
+	for key, value := range data {
+		result[key] = value
+	}
+	result["AppName"] = c.AppName
+	return result
+}
+
+// Render executes the template. Referencing a value missing from data is an error,
+// so a mistyped field in an override is caught when it is previewed or saved.
+func (t Template) Render(data map[string]any) (*Message, error) {
+	subject, err := executeText(t.Subject, data)
+	if err != nil {
+		return nil, fmt.Errorf("subject: %w", err)
+	}
+
+	text, err := executeText(t.Text, data)
+	if err != nil {
+		return nil, fmt.Errorf("text: %w", err)
+	}
+
+	html, err := htmltemplate.New("html").Option("missingkey=error").Parse(t.HTML)
+	if err != nil {
+		return nil, fmt.Errorf("html: %w", err)
+	}
+	buffer := new(bytes.Buffer)
+	if err := html.Execute(buffer, data); err != nil {
+		return nil, fmt.Errorf("html: %w", err)
+	}
+
+	return &Message{
+		Subject: strings.TrimSpace(subject),
+		HTML:    buffer.String(),
+		Text:    text,
+	}, nil
+}
+
+func executeText(source string, data map[string]any) (string, error) {
+	template, err := texttemplate.New("text").Option("missingkey=error").Parse(source)
+	if err != nil {
+		return "", err
+	}
+
+	buffer := new(bytes.Buffer)
+	if err := template.Execute(buffer, data); err != nil {
+		return "", err
+	}
+	return buffer.String(), nil
+}
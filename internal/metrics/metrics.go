@@ -0,0 +1,155 @@
+// Package metrics holds the Prometheus collectors exported on /metrics.
+package metrics
+
+import (
+	"errors"
+	"go-rest-scaffold/internal/buildinfo"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	// BuildInfo is always 1, its labels tell which build is running.
+	BuildInfo = promauto.NewGaugeFunc(prometheus.GaugeOpts{
+		Name: "build_info",
+		Help: "Build of the running binary, labeled by version, commit, build time and Go version.",
+		ConstLabels: prometheus.Labels{
+			"version":    buildinfo.Version,
+			"commit":     buildinfo.Commit,
+			"build_time": buildinfo.BuildTime,
+			"goversion":  buildinfo.GoVersion,
+		},
+	}, func() float64 { return 1 })
+
+	LockAcquisitions = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "lock_acquisitions_total",
+		Help: "Distributed lock acquisition attempts by lock kind and result (acquired, contended, error).",
+	}, []string{"kind", "result"})
+
+	LockWaitSeconds = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "lock_wait_seconds",
+		Help:    "Time spent waiting before a distributed lock was acquired, by lock kind.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"kind"})
+
+	RateLimitRejections = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "rate_limit_rejections_total",
+		Help: "Requests rejected with 429 by rate limit policy.",
+	}, []string{"policy"})
+
+	CacheLookups = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "cache_lookups_total",
+		Help: "Read cache lookups by cached read and result (hit, miss).",
+	}, []string{"name", "result"})
+
+	SlowQueries = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "db_slow_queries_total",
+		Help: "SQL statements slower than database.slow_threshold, by operation (select, insert, update, delete, other).",
+	}, []string{"operation"})
+
+	ScheduledJobRuns = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "scheduled_job_runs_total",
+		Help: "Scheduled job runs by job and result (success, failure, skipped while still running).",
+	}, []string{"job", "result"})
+
+	ScheduledJobSeconds = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "scheduled_job_duration_seconds",
+		Help:    "Duration of the scheduled job runs, by job.",
+		Buckets: []float64{0.01, 0.05, 0.1, 0.5, 1, 5, 10, 30, 60, 300},
+	}, []string{"job"})
+
+	ScheduledJobItems = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "scheduled_job_items_total",
+		Help: "Items (rows purged, days aggregated, ...) processed by the scheduled jobs, by job.",
+	}, []string{"job"})
+
+	ScheduledJobLastSuccess = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "scheduled_job_last_success_timestamp_seconds",
+		Help: "Unix time of the last successful run of the scheduled jobs, by job.",
+	}, []string{"job"})
+
+	RealtimeClients = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "realtime_clients",
+		Help: "WebSocket and Server-Sent Events clients connected to this instance.",
+	})
+
+	RealtimeDroppedEvents = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "realtime_dropped_events_total",
+		Help: "Events not sent to a WebSocket or Server-Sent Events client that fell too far behind.",
+	})
+)
+
+// Business events, all labeled by outcome (see Outcome).
+var (
+	Registrations = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "user_registrations_total",
+		Help: "User registrations by outcome.",
+	}, []string{"outcome"})
+
+	Logins = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "user_logins_total",
+		Help: "User logins by outcome.",
+	}, []string{"outcome"})
+
+	Logouts = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "user_logouts_total",
+		Help: "User logouts by outcome.",
+	}, []string{"outcome"})
+
+	ContactCreations = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "contact_creations_total",
+		Help: "Contact creations by outcome.",
+	}, []string{"outcome"})
+
+	Imports = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "imports_total",
+		Help: "Data imports by kind and outcome.",
+	}, []string{"kind", "outcome"})
+
+	WebhookDeliveries = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "webhook_deliveries_total",
+		Help: "Webhook delivery attempts by event type and outcome.",
+	}, []string{"event_type", "outcome"})
+)
+
+const (
+	OutcomeSuccess      = "success"
+	OutcomeInvalid      = "invalid"
+	OutcomeUnauthorized = "unauthorized"
+	OutcomeNotFound     = "not_found"
+	OutcomeConflict     = "conflict"
+	OutcomeError        = "error"
+)
+
+// Outcome classifies the error returned by a use case into a low-cardinality label.
+func Outcome(err error) string {
+	if err == nil {
+		return OutcomeSuccess
+	}
+
+	var validationErrors validator.ValidationErrors
+	if errors.As(err, &validationErrors) {
+		return OutcomeInvalid
+	}
+
+	var fiberError *fiber.Error
+	if !errors.As(err, &fiberError) {
+		return OutcomeError
+	}
+
+	switch fiberError.Code {
+	case fiber.StatusBadRequest, fiber.StatusUnprocessableEntity:
+		return OutcomeInvalid
+	case fiber.StatusUnauthorized, fiber.StatusForbidden:
+		return OutcomeUnauthorized
+	case fiber.StatusNotFound:
+		return OutcomeNotFound
+	case fiber.StatusConflict:
+		return OutcomeConflict
+	default:
+		return OutcomeError
+	}
+}
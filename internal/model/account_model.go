@@ -0,0 +1,97 @@
+package model
+
+const (
+	// AccountArchiveFormat identifies an account archive, AccountArchiveVersion is
+	// bumped only when the archive changes incompatibly.
+	AccountArchiveFormat  = "go-rest-scaffold/account"
+	AccountArchiveVersion = 1
+
+	// ImportPreserveIds keeps the IDs of the archive, ImportRemapIds gives every
+	// imported resource a new ID and rewrites the references between them.
+	ImportPreserveIds = "preserve"
+	ImportRemapIds    = "remap"
+)
+
+// AccountArchive is a portable copy of everything an account owns, exported by one
+// instance of the service and imported by another. Items in the trash are left out.
+type AccountArchive struct {
+	Format     string                   `json:"format" validate:"required,eq=go-rest-scaffold/account"`
+	Version    int                      `json:"version" validate:"min=1,max=1"`
+	ExportedAt int64                    `json:"exported_at"`
+	User       AccountArchiveUser       `json:"user"`
+	Contacts   []AccountArchiveContact  `json:"contacts" validate:"max=10000,dive"`
+	Reminders  []AccountArchiveReminder `json:"reminders" validate:"max=10000,dive"`
+	Webhooks   []AccountArchiveWebhook  `json:"webhooks" validate:"max=100,dive"`
+}
+
+type AccountArchiveUser struct {
+	ID        string `json:"id" validate:"max=100"`
+	Name      string `json:"name" validate:"max=100"`
+	CreatedAt int64  `json:"created_at"`
+}
+
+type AccountArchiveContact struct {
+	ID        string                  `json:"id" validate:"required,max=100,entity_id"`
+	FirstName string                  `json:"first_name" validate:"required,max=100"`
+	LastName  string                  `json:"last_name" validate:"max=100"`
+	Email     string                  `json:"email" validate:"omitempty,max=200,email"`
+	Phone     string                  `json:"phone" validate:"max=20"`
+	Emails    []ContactEmail          `json:"emails,omitempty" validate:"max=10,unique=Email,dive"`
+	Phones    []ContactPhone          `json:"phones,omitempty" validate:"max=10,unique=Phone,dive"`
+	CreatedAt int64                   `json:"created_at"`
+	UpdatedAt int64                   `json:"updated_at"`
+	Addresses []AccountArchiveAddress `json:"addresses" validate:"max=100,dive"`
+}
+
+type AccountArchiveAddress struct {
+	ID         string `json:"id" validate:"required,max=100,entity_id"`
+	Street     string `json:"street" validate:"max=255"`
+	City       string `json:"city" validate:"max=255"`
+	Province   string `json:"province" validate:"max=255"`
+	PostalCode string `json:"postal_code" validate:"max=10"`
+	Country    string `json:"country" validate:"max=100"`
+	CreatedAt  int64  `json:"created_at"`
+	UpdatedAt  int64  `json:"updated_at"`
+}
+
+type AccountArchiveReminder struct {
+	ID        string   `json:"id" validate:"required,max=100,entity_id"`
+	ContactId string   `json:"contact_id" validate:"required,max=100,entity_id"`
+	Note      string   `json:"note" validate:"max=1000"`
+	LocalTime string   `json:"local_time" validate:"required,datetime=2006-01-02T15:04"`
+	Timezone  string   `json:"timezone" validate:"required,max=100,timezone"`
+	RemindAt  int64    `json:"remind_at"`
+	Channels  []string `json:"channels" validate:"required,min=1,unique,dive,oneof=in_app email push"`
+	Status    string   `json:"status" validate:"required,oneof=pending sent failed"`
+	Error     string   `json:"error,omitempty"`
+	SentAt    int64    `json:"sent_at,omitempty"`
+	CreatedAt int64    `json:"created_at"`
+	UpdatedAt int64    `json:"updated_at"`
+}
+
+type AccountArchiveWebhook struct {
+	ID         string   `json:"id" validate:"required,max=100,entity_id"`
+	URL        string   `json:"url" validate:"required,max=2000,http_url"`
+	EventTypes []string `json:"event_types" validate:"max=50,dive,required,max=200"`
+	Active     bool     `json:"active"`
+	CreatedAt  int64    `json:"created_at"`
+	UpdatedAt  int64    `json:"updated_at"`
+}
+
+type ExportAccountRequest struct {
+	UserId string `json:"-" validate:"required"`
+}
+
+type ImportAccountRequest struct {
+	UserId  string          `json:"-" validate:"required"`
+	IDs     string          `json:"-" validate:"required,oneof=preserve remap"`
+	Archive *AccountArchive `json:"-" validate:"required"`
+}
+
+type ImportAccountResponse struct {
+	IDs       string `json:"ids"`
+	Contacts  int    `json:"contacts"`
+	Addresses int    `json:"addresses"`
+	Reminders int    `json:"reminders"`
+	Webhooks  int    `json:"webhooks"`
+}
@@ -0,0 +1,45 @@
+package model
+
+import "context"
+
+type actorKey struct{}
+
+// WithActor records the user acting in ctx. Rows created or updated with ctx are
+// stamped with it in their created_by and updated_by columns.
+func WithActor(ctx context.Context, userId string) context.Context {
+	return context.WithValue(ctx, actorKey{}, userId)
+}
+
+// ActorFrom returns the acting user of ctx, or "" for work nobody asked for,
+// such as background jobs.
+func ActorFrom(ctx context.Context) string {
+	userId, _ := ctx.Value(actorKey{}).(string)
+	return userId
+}
+
+type impersonatorKey struct{}
+
+// WithImpersonator records the admin acting in ctx as the user WithActor names.
+func WithImpersonator(ctx context.Context, adminId string) context.Context {
+	return context.WithValue(ctx, impersonatorKey{}, adminId)
+}
+
+// ImpersonatorFrom returns the admin impersonating the acting user of ctx, or "" when
+// the user acts themselves.
+func ImpersonatorFrom(ctx context.Context) string {
+	adminId, _ := ctx.Value(impersonatorKey{}).(string)
+	return adminId
+}
+
+type clientIPKey struct{}
+
+// WithClientIP records the address the acting user's request came from.
+func WithClientIP(ctx context.Context, ip string) context.Context {
+	return context.WithValue(ctx, clientIPKey{}, ip)
+}
+
+// ClientIPFrom returns the client address of ctx, or "" outside of a request.
+func ClientIPFrom(ctx context.Context) string {
+	ip, _ := ctx.Value(clientIPKey{}).(string)
+	return ip
+}
@@ -9,16 +9,17 @@ type AddressResponse struct {
 	Country    string `json:"country"`
 	CreatedAt  int64  `json:"created_at"`
 	UpdatedAt  int64  `json:"updated_at"`
+	Version    int64  `json:"version"`
 }
 
 type ListAddressRequest struct {
 	UserId    string `json:"-" validate:"required"`
-	ContactId string `json:"-" validate:"required,max=100,uuid"`
+	ContactId string `json:"-" validate:"required,max=100,entity_id"`
 }
 
 type CreateAddressRequest struct {
 	UserId     string `json:"-" validate:"required"`
-	ContactId  string `json:"-" validate:"required,max=100,uuid"`
+	ContactId  string `json:"-" validate:"required,max=100,entity_id"`
 	Street     string `json:"street" validate:"max=255"`
 	City       string `json:"city" validate:"max=255"`
 	Province   string `json:"province" validate:"max=255"`
@@ -28,23 +29,40 @@ type CreateAddressRequest struct {
 
 type UpdateAddressRequest struct {
 	UserId     string `json:"-" validate:"required"`
-	ContactId  string `json:"-" validate:"required,max=100,uuid"`
-	ID         string `json:"-" validate:"required,max=100,uuid"`
+	ContactId  string `json:"-" validate:"required,max=100,entity_id"`
+	ID         string `json:"-" validate:"required,max=100,entity_id"`
 	Street     string `json:"street" validate:"max=255"`
 	City       string `json:"city" validate:"max=255"`
 	Province   string `json:"province" validate:"max=255"`
 	PostalCode string `json:"postal_code" validate:"max=10"`
 	Country    string `json:"country" validate:"max=100"`
+	// Version is the version the address must be at, from If-Match; 0 accepts any.
+	Version int64 `json:"-"`
+}
+
+// PatchAddressRequest is a JSON merge patch of an address: fields left out keep their
+// value, and null or an empty string clears one.
+type PatchAddressRequest struct {
+	UserId     string                  `json:"-" validate:"required"`
+	ContactId  string                  `json:"-" validate:"required,max=100,entity_id"`
+	ID         string                  `json:"-" validate:"required,max=100,entity_id"`
+	Street     MergePatchField[string] `json:"street" validate:"omitnil,max=255" swaggertype:"string"`
+	City       MergePatchField[string] `json:"city" validate:"omitnil,max=255" swaggertype:"string"`
+	Province   MergePatchField[string] `json:"province" validate:"omitnil,max=255" swaggertype:"string"`
+	PostalCode MergePatchField[string] `json:"postal_code" validate:"omitnil,max=10" swaggertype:"string"`
+	Country    MergePatchField[string] `json:"country" validate:"omitnil,max=100" swaggertype:"string"`
+	Version    int64                   `json:"-"`
 }
 
 type GetAddressRequest struct {
 	UserId    string `json:"-" validate:"required"`
-	ContactId string `json:"-" validate:"required,max=100,uuid"`
-	ID        string `json:"-" validate:"required,max=100,uuid"`
+	ContactId string `json:"-" validate:"required,max=100,entity_id"`
+	ID        string `json:"-" validate:"required,max=100,entity_id"`
 }
 
 type DeleteAddressRequest struct {
 	UserId    string `json:"-" validate:"required"`
-	ContactId string `json:"-" validate:"required,max=100,uuid"`
-	ID        string `json:"-" validate:"required,max=100,uuid"`
+	ContactId string `json:"-" validate:"required,max=100,entity_id"`
+	ID        string `json:"-" validate:"required,max=100,entity_id"`
+	Version   int64  `json:"-"`
 }
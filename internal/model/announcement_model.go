@@ -0,0 +1,44 @@
+package model
+
+type AnnouncementResponse struct {
+	ID        string `json:"id"`
+	Title     string `json:"title"`
+	Body      string `json:"body"`
+	Level     string `json:"level"`
+	StartsAt  int64  `json:"starts_at"`
+	EndsAt    int64  `json:"ends_at"`
+	CreatedAt int64  `json:"created_at"`
+	UpdatedAt int64  `json:"updated_at"`
+	CreatedBy string `json:"created_by,omitempty"`
+	UpdatedBy string `json:"updated_by,omitempty"`
+}
+
+type CreateAnnouncementRequest struct {
+	Title    string `json:"title" validate:"required,max=255"`
+	Body     string `json:"body" validate:"max=10000"`
+	Level    string `json:"level" validate:"required,oneof=info warning critical"`
+	StartsAt int64  `json:"starts_at" validate:"min=0"`
+	EndsAt   int64  `json:"ends_at" validate:"omitempty,gtfield=StartsAt"`
+}
+
+type UpdateAnnouncementRequest struct {
+	ID       string `json:"-" validate:"required,max=100,entity_id"`
+	Title    string `json:"title" validate:"required,max=255"`
+	Body     string `json:"body" validate:"max=10000"`
+	Level    string `json:"level" validate:"required,oneof=info warning critical"`
+	StartsAt int64  `json:"starts_at" validate:"min=0"`
+	EndsAt   int64  `json:"ends_at" validate:"omitempty,gtfield=StartsAt"`
+}
+
+type GetAnnouncementRequest struct {
+	ID string `json:"-" validate:"required,max=100,entity_id"`
+}
+
+type DeleteAnnouncementRequest struct {
+	ID string `json:"-" validate:"required,max=100,entity_id"`
+}
+
+type SearchAnnouncementRequest struct {
+	Page int `json:"page" validate:"min=1,page_number"`
+	Size int `json:"size" validate:"min=1,page_size"`
+}
@@ -0,0 +1,39 @@
+package model
+
+// APIKeyResponse describes an API key. Key and SigningSecret are only set in the
+// response creating it, they cannot be read back afterwards.
+type APIKeyResponse struct {
+	ID            string   `json:"id"`
+	Name          string   `json:"name"`
+	Prefix        string   `json:"prefix"`
+	Key           string   `json:"key,omitempty"`
+	SigningSecret string   `json:"signing_secret,omitempty"`
+	Scopes        []string `json:"scopes,omitempty"`
+	LastUsedAt    int64    `json:"last_used_at,omitempty"`
+	RevokedAt     int64    `json:"revoked_at,omitempty"`
+	CreatedAt     int64    `json:"created_at"`
+}
+
+type CreateAPIKeyRequest struct {
+	UserId string `json:"-" validate:"required"`
+	Name   string `json:"name" validate:"required,max=100"`
+	// Scopes limit the key to part of the API, e.g. ["contacts:read"]. Without them
+	// the key may do anything the user may.
+	Scopes []string `json:"scopes,omitempty" validate:"max=20,unique,dive,oneof=account:read account:write contacts:read contacts:write addresses:read addresses:write reminders:read reminders:write webhooks:read webhooks:write admin"`
+	// CallerScopes are the scopes of the session creating the key, which the key
+	// cannot exceed. nil when the session is not limited.
+	CallerScopes []string `json:"-"`
+}
+
+type ListAPIKeyRequest struct {
+	UserId string `json:"-" validate:"required"`
+}
+
+type RevokeAPIKeyRequest struct {
+	UserId string `json:"-" validate:"required"`
+	ID     string `json:"-" validate:"required,max=100,uuid"`
+}
+
+type VerifyAPIKeyRequest struct {
+	Key string `validate:"required,max=200"`
+}
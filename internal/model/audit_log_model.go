@@ -0,0 +1,54 @@
+package model
+
+const (
+	AuditCreate      = "create"
+	AuditUpdate      = "update"
+	AuditDelete      = "delete"
+	AuditImpersonate = "impersonate"
+)
+
+// Entity types of the audit log.
+const (
+	AuditUser         = "user"
+	AuditContact      = "contact"
+	AuditAddress      = "address"
+	AuditTag          = "tag"
+	AuditNote         = "note"
+	AuditReminder     = "reminder"
+	AuditWebhook      = "webhook"
+	AuditAPIKey       = "api_key"
+	AuditAnnouncement = "announcement"
+	AuditPasskey      = "passkey"
+)
+
+// AuditChange is the value of a field before and after a change; Before is null for
+// a created entity and After for a deleted one.
+type AuditChange struct {
+	Before any `json:"before"`
+	After  any `json:"after"`
+}
+
+// AuditLogResponse is one entry of the audit log. ImpersonatorId is set when an admin
+// made the change while impersonating UserId.
+type AuditLogResponse struct {
+	ID             string                 `json:"id"`
+	UserId         string                 `json:"user_id,omitempty"`
+	Action         string                 `json:"action"`
+	EntityType     string                 `json:"entity_type"`
+	EntityId       string                 `json:"entity_id"`
+	Changes        map[string]AuditChange `json:"changes"`
+	IP             string                 `json:"ip,omitempty"`
+	ImpersonatorId string                 `json:"impersonator_id,omitempty"`
+	CreatedAt      int64                  `json:"created_at"`
+}
+
+// SearchAuditLogRequest filters the audit log. From and To are Unix milliseconds, From
+// inclusive and To exclusive, 0 leaving that end open.
+type SearchAuditLogRequest struct {
+	UserId     string `json:"user_id" validate:"max=100"`
+	EntityType string `json:"entity_type" validate:"max=50"`
+	From       int64  `json:"from" validate:"min=0"`
+	To         int64  `json:"to" validate:"min=0"`
+	Page       int    `json:"page" validate:"min=1,page_number"`
+	Size       int    `json:"size" validate:"min=1,page_size"`
+}
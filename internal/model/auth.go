@@ -1,6 +1,46 @@
 package model
 
+import "slices"
+
+const (
+	RoleUser  = "user"
+	RoleAdmin = "admin"
+)
+
+// Scopes a session or API key can be limited to. One without scopes may do anything
+// its user may.
+const (
+	ScopeAccountRead    = "account:read"
+	ScopeAccountWrite   = "account:write"
+	ScopeContactsRead   = "contacts:read"
+	ScopeContactsWrite  = "contacts:write"
+	ScopeAddressesRead  = "addresses:read"
+	ScopeAddressesWrite = "addresses:write"
+	ScopeRemindersRead  = "reminders:read"
+	ScopeRemindersWrite = "reminders:write"
+	ScopeWebhooksRead   = "webhooks:read"
+	ScopeWebhooksWrite  = "webhooks:write"
+	ScopeAdmin          = "admin"
+)
+
 type Auth struct {
 	// Login user id
 	ID string
+	// Role of the login user, RoleUser or RoleAdmin
+	Role string
+	// Region holding the login user's data, "" for the home region
+	Region string
+	// SessionId is the session the request authenticated with, "" for an API key
+	SessionId string
+	// APIKeyId is the API key the request authenticated with, "" for a session token
+	APIKeyId string
+	// Scopes limit what the request may do, nil when it is not limited
+	Scopes []string
+	// ImpersonatorId is the admin acting as the user, "" unless impersonating
+	ImpersonatorId string
+}
+
+// HasScope reports whether the request may act within scope.
+func (a *Auth) HasScope(scope string) bool {
+	return a.Scopes == nil || slices.Contains(a.Scopes, scope)
 }
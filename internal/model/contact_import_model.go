@@ -0,0 +1,72 @@
+package model
+
+import "strings"
+
+// Statuses of a contact import. Small files are imported while the request waits and
+// answer completed; larger ones are queued as pending and run in the background.
+const (
+	ContactImportPending   = "pending"
+	ContactImportRunning   = "running"
+	ContactImportCompleted = "completed"
+	ContactImportFailed    = "failed"
+)
+
+// ContactImportFields are the contact fields a CSV column can be mapped to.
+var ContactImportFields = []string{"first_name", "last_name", "email", "phone"}
+
+// csvFormulaPrefixes start the cells spreadsheets evaluate as formulas.
+const csvFormulaPrefixes = "=+-@"
+
+// EscapeCSVCell prefixes a cell spreadsheets would evaluate as a formula with a quote,
+// so an exported contact cannot run one in the spreadsheet it is opened in.
+func EscapeCSVCell(cell string) string {
+	if cell != "" && strings.ContainsRune(csvFormulaPrefixes, rune(cell[0])) {
+		return "'" + cell
+	}
+	return cell
+}
+
+// UnescapeCSVCell removes the quote EscapeCSVCell prefixed cell with, so an export
+// imports back as it was.
+func UnescapeCSVCell(cell string) string {
+	if len(cell) > 1 && cell[0] == '\'' && strings.ContainsRune(csvFormulaPrefixes, rune(cell[1])) {
+		return cell[1:]
+	}
+	return cell
+}
+
+type ImportContactRequest struct {
+	UserId string `json:"-" validate:"required"`
+	// Data is the CSV file, with a header row naming the columns.
+	Data []byte `json:"-" validate:"required"`
+	// Mapping maps column headers to contact fields, "" to skip a column. Headers it
+	// leaves out are matched to the field of the same name.
+	Mapping map[string]string `json:"-"`
+}
+
+type GetContactImportRequest struct {
+	UserId string `json:"-" validate:"required"`
+	ID     string `json:"-" validate:"required,max=100"`
+}
+
+// ContactImportRowError tells why a row of the file was not imported. Row is the line
+// of the row in the file, the header being line 1.
+type ContactImportRowError struct {
+	Row    int               `json:"row"`
+	Errors string            `json:"errors"`
+	Fields map[string]string `json:"fields,omitempty"`
+}
+
+type ContactImportResponse struct {
+	// ID is set for imports queued to run in the background, to poll their status.
+	ID            string                  `json:"id,omitempty"`
+	Status        string                  `json:"status" enums:"pending,running,completed,failed"`
+	TotalRows     int                     `json:"total_rows"`
+	ProcessedRows int                     `json:"processed_rows"`
+	Imported      int                     `json:"imported"`
+	Failed        int                     `json:"failed"`
+	Errors        []ContactImportRowError `json:"errors"`
+	Error         string                  `json:"error,omitempty"`
+	CreatedAt     int64                   `json:"created_at,omitempty"`
+	CompletedAt   *int64                  `json:"completed_at,omitempty"`
+}
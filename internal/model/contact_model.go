@@ -1,31 +1,173 @@
 package model
 
+// Types of the emails and phone numbers of a contact. ContactTypeOther is the type
+// of those given without one.
+const (
+	ContactTypeWork   = "work"
+	ContactTypeHome   = "home"
+	ContactTypeMobile = "mobile"
+	ContactTypeOther  = "other"
+)
+
+// ContactEmail is one of the emails of a contact. At most one of them is primary;
+// when none is marked, the first one is.
+type ContactEmail struct {
+	Type    string `json:"type" validate:"omitempty,oneof=work home mobile other"`
+	Email   string `json:"email" validate:"required,max=200,email"`
+	Primary bool   `json:"primary"`
+}
+
+// ContactPhone is one of the phone numbers of a contact, primary as ContactEmail is.
+type ContactPhone struct {
+	Type    string `json:"type" validate:"omitempty,oneof=work home mobile other"`
+	Phone   string `json:"phone" validate:"required,max=20"`
+	Primary bool   `json:"primary"`
+}
+
 type ContactResponse struct {
-	ID        string            `json:"id"`
-	FirstName string            `json:"first_name"`
-	LastName  string            `json:"last_name"`
+	ID        string `json:"id"`
+	FirstName string `json:"first_name"`
+	LastName  string `json:"last_name"`
+	// Email and Phone are the primary email and phone number, "" when there is none.
 	Email     string            `json:"email"`
 	Phone     string            `json:"phone"`
+	Emails    []ContactEmail    `json:"emails"`
+	Phones    []ContactPhone    `json:"phones"`
 	CreatedAt int64             `json:"created_at"`
 	UpdatedAt int64             `json:"updated_at"`
+	Version   int64             `json:"version"`
+	Avatar    *ContactAvatar    `json:"avatar,omitempty"`
 	Addresses []AddressResponse `json:"addresses,omitempty"`
 }
 
-type CreateContactRequest struct {
+// Sizes of the pictures of a contact avatar, in pixels a side.
+const (
+	AvatarSmall  = 64
+	AvatarMedium = 256
+	AvatarLarge  = 512
+)
+
+// ContactAvatar is the avatar of a contact, its pictures scaled to square sizes.
+// The URLs are signed and read the pictures without credentials until ExpiresAt;
+// they are left out of events and the audit log, which only name the avatar by ID.
+type ContactAvatar struct {
+	ID        string `json:"id"`
+	Small     string `json:"small,omitempty"`
+	Medium    string `json:"medium,omitempty"`
+	Large     string `json:"large,omitempty"`
+	ExpiresAt int64  `json:"expires_at,omitempty"`
+}
+
+// UploadContactAvatarRequest replaces the avatar of a contact with the picture in
+// Data, a JPEG, PNG or GIF image.
+type UploadContactAvatarRequest struct {
 	UserId    string `json:"-" validate:"required"`
-	FirstName string `json:"first_name" validate:"required,max=100"`
-	LastName  string `json:"last_name" validate:"max=100"`
-	Email     string `json:"email" validate:"max=200,email"`
-	Phone     string `json:"phone" validate:"max=20"`
+	ContactId string `json:"-" validate:"required,max=100,entity_id"`
+	Data      []byte `json:"-" validate:"required"`
+	Version   int64  `json:"-"`
 }
 
-type UpdateContactRequest struct {
+type DeleteContactAvatarRequest struct {
 	UserId    string `json:"-" validate:"required"`
-	ID        string `json:"-" validate:"required,max=100,uuid"`
-	FirstName string `json:"first_name" validate:"required,max=100"`
-	LastName  string `json:"last_name" validate:"max=100"`
-	Email     string `json:"email" validate:"max=200,email"`
-	Phone     string `json:"phone" validate:"max=20"`
+	ContactId string `json:"-" validate:"required,max=100,entity_id"`
+	Version   int64  `json:"-"`
+}
+
+// ContactSuggestionResponse is the lightweight shape of a contact returned for autocomplete.
+type ContactSuggestionResponse struct {
+	ID    string `json:"id"`
+	Name  string `json:"name"`
+	Email string `json:"email,omitempty"`
+}
+
+// ContactIndexOther buckets the contacts whose first name does not start with a letter from A to Z.
+const ContactIndexOther = "#"
+
+// ContactIndexResponse counts the contacts whose first name starts with Letter.
+type ContactIndexResponse struct {
+	Letter string `json:"letter"`
+	Count  int64  `json:"count"`
+}
+
+// CreateContactRequest creates a contact with at least one email. Email and Phone
+// are shorthands for a single primary email or phone number of type other, used
+// when Emails or Phones are left out.
+type CreateContactRequest struct {
+	UserId    string         `json:"-" validate:"required"`
+	FirstName string         `json:"first_name" validate:"required,max=100"`
+	LastName  string         `json:"last_name" validate:"max=100"`
+	Email     string         `json:"email" validate:"omitempty,max=200,email"`
+	Phone     string         `json:"phone" validate:"max=20"`
+	Emails    []ContactEmail `json:"emails" validate:"max=10,unique=Email,dive"`
+	Phones    []ContactPhone `json:"phones" validate:"max=10,unique=Phone,dive"`
+}
+
+// UpdateContactRequest replaces a contact, its emails and phone numbers included,
+// which are given as in CreateContactRequest.
+type UpdateContactRequest struct {
+	UserId    string         `json:"-" validate:"required"`
+	ID        string         `json:"-" validate:"required,max=100,entity_id"`
+	FirstName string         `json:"first_name" validate:"required,max=100"`
+	LastName  string         `json:"last_name" validate:"max=100"`
+	Email     string         `json:"email" validate:"omitempty,max=200,email"`
+	Phone     string         `json:"phone" validate:"max=20"`
+	Emails    []ContactEmail `json:"emails" validate:"max=10,unique=Email,dive"`
+	Phones    []ContactPhone `json:"phones" validate:"max=10,unique=Phone,dive"`
+	// Version is the version the contact must be at, from If-Match; 0 accepts any.
+	Version int64 `json:"-"`
+}
+
+// PatchContactRequest is a JSON merge patch of a contact: fields left out keep their
+// value and null clears one, except the first name and emails, which can only be
+// replaced, as a contact is created with them. Email and Phone replace the primary
+// email and phone number only; Emails and Phones replace all of them.
+type PatchContactRequest struct {
+	UserId    string                          `json:"-" validate:"required"`
+	ID        string                          `json:"-" validate:"required,max=100,entity_id"`
+	FirstName MergePatchField[string]         `json:"first_name" validate:"omitnil,min=1,max=100" swaggertype:"string"`
+	LastName  MergePatchField[string]         `json:"last_name" validate:"omitnil,max=100" swaggertype:"string"`
+	Email     MergePatchField[string]         `json:"email" validate:"omitnil,max=200,email" swaggertype:"string"`
+	Phone     MergePatchField[string]         `json:"phone" validate:"omitnil,max=20" swaggertype:"string"`
+	Emails    MergePatchField[[]ContactEmail] `json:"emails" validate:"omitnil,min=1,max=10,unique=Email,dive" swaggertype:"array,object"`
+	Phones    MergePatchField[[]ContactPhone] `json:"phones" validate:"omitnil,max=10,unique=Phone,dive" swaggertype:"array,object"`
+	Version   int64                           `json:"-"`
+}
+
+// Operations of a contact bulk request.
+const (
+	BulkCreate = "create"
+	BulkUpdate = "update"
+	BulkDelete = "delete"
+)
+
+// BulkContactOperation is one operation of a bulk request. Create and update carry
+// the fields of the contact as their endpoints do; update and delete name the contact
+// by ID and, like If-Match, may require it to be at Version.
+type BulkContactOperation struct {
+	Op        string         `json:"op"`
+	ID        string         `json:"id,omitempty"`
+	Version   int64          `json:"version,omitempty"`
+	FirstName string         `json:"first_name,omitempty"`
+	LastName  string         `json:"last_name,omitempty"`
+	Email     string         `json:"email,omitempty"`
+	Phone     string         `json:"phone,omitempty"`
+	Emails    []ContactEmail `json:"emails,omitempty"`
+	Phones    []ContactPhone `json:"phones,omitempty"`
+}
+
+type BulkContactRequest struct {
+	UserId string `json:"-" validate:"required"`
+	// Atomic applies all operations or, when one fails, none of them.
+	Atomic     bool                   `json:"atomic"`
+	Operations []BulkContactOperation `json:"operations" validate:"required,min=1"`
+}
+
+// BulkContactResult is the outcome of the operation at the same index of a bulk
+// request, with the status its own endpoint would have answered.
+type BulkContactResult struct {
+	Status  int              `json:"status"`
+	Contact *ContactResponse `json:"contact,omitempty"`
+	Error   string           `json:"error,omitempty"`
 }
 
 type SearchContactRequest struct {
@@ -33,16 +175,44 @@ type SearchContactRequest struct {
 	Name   string `json:"name" validate:"max=100"`
 	Email  string `json:"email" validate:"max=200"`
 	Phone  string `json:"phone" validate:"max=20"`
-	Page   int    `json:"page" validate:"min=1"`
-	Size   int    `json:"size" validate:"min=1,max=100"`
+	Letter string `json:"letter" validate:"omitempty,oneof=A B C D E F G H I J K L M N O P Q R S T U V W X Y Z #"`
+	// Tags are the names of tags the contacts must all have, ignoring case.
+	Tags   []string    `json:"tag" validate:"max=10,dive,required,max=50"`
+	Page   int         `json:"page" validate:"min=1,page_number"`
+	Size   int         `json:"size" validate:"min=1,page_size"`
+	Skip   int         `json:"-" validate:"min=0"`
+	Filter *FilterNode `json:"-"`
+	Sort   []SortField `json:"-"`
+	// Cursor pages by keyset instead of offset when set, from the first page when empty.
+	Cursor *string `json:"cursor" validate:"omitempty,max=2000"`
+}
+
+// Offset returns the number of rows to skip before the requested page.
+func (r *SearchContactRequest) Offset() int {
+	return (r.Page-1)*r.Size + r.Skip
 }
 
 type GetContactRequest struct {
 	UserId string `json:"-" validate:"required"`
-	ID     string `json:"-" validate:"required,max=100,uuid"`
+	ID     string `json:"-" validate:"required,max=100,entity_id"`
 }
 
 type DeleteContactRequest struct {
+	UserId  string `json:"-" validate:"required"`
+	ID      string `json:"-" validate:"required,max=100,entity_id"`
+	Version int64  `json:"-"`
+}
+
+type ExportContactRequest struct {
+	UserId string `json:"-" validate:"required"`
+}
+
+type SuggestContactRequest struct {
+	UserId string `json:"-" validate:"required"`
+	Query  string `json:"q" validate:"required,max=100"`
+	Limit  int    `json:"limit" validate:"min=1,max=20"`
+}
+
+type ContactIndexRequest struct {
 	UserId string `json:"-" validate:"required"`
-	ID     string `json:"-" validate:"required,max=100,uuid"`
 }
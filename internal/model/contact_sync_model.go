@@ -0,0 +1,30 @@
+package model
+
+// Types of the lines of the contact sync stream.
+const (
+	// ContactSyncUpsert carries the current state of a contact, addresses included.
+	ContactSyncUpsert = "upsert"
+	// ContactSyncDelete tells that a contact was deleted.
+	ContactSyncDelete = "delete"
+	// ContactSyncSnapshotEnd follows the last contact of the initial snapshot.
+	ContactSyncSnapshotEnd = "snapshot_end"
+	// ContactSyncHeartbeat is sent while nothing changes, so idle streams stay open.
+	ContactSyncHeartbeat = "heartbeat"
+)
+
+// ContactSyncEvent is one line of the contact sync stream. Offset is where a stream
+// resumes from after this line; the lines of the snapshot all carry the offset its
+// changes continue from, which only becomes safe to resume from at snapshot_end.
+type ContactSyncEvent struct {
+	Type      string           `json:"type"`
+	Offset    int64            `json:"offset"`
+	ContactId string           `json:"contact_id,omitempty"`
+	Contact   *ContactResponse `json:"contact,omitempty"`
+}
+
+type SyncContactsRequest struct {
+	UserId string `json:"-" validate:"required"`
+	// Resume continues after Offset instead of starting with a snapshot of every contact.
+	Resume bool  `json:"-"`
+	Offset int64 `json:"-" validate:"min=0"`
+}
@@ -0,0 +1,83 @@
+package converter
+
+import (
+	"go-rest-scaffold/internal/entity"
+	"go-rest-scaffold/internal/model"
+	"strings"
+)
+
+func AccountToArchive(user *entity.User, contacts []entity.Contact, reminders []entity.Reminder, webhooks []entity.Webhook,
+	exportedAt int64) *model.AccountArchive {
+	archive := &model.AccountArchive{
+		Format:     model.AccountArchiveFormat,
+		Version:    model.AccountArchiveVersion,
+		ExportedAt: exportedAt,
+		User: model.AccountArchiveUser{
+			ID:        user.ID,
+			Name:      user.Name,
+			CreatedAt: user.CreatedAt,
+		},
+		Contacts:  make([]model.AccountArchiveContact, len(contacts)),
+		Reminders: make([]model.AccountArchiveReminder, len(reminders)),
+		Webhooks:  make([]model.AccountArchiveWebhook, len(webhooks)),
+	}
+
+	for i, contact := range contacts {
+		response := ContactToResponse(&contact)
+		archive.Contacts[i] = model.AccountArchiveContact{
+			ID:        contact.ID,
+			FirstName: contact.FirstName,
+			LastName:  contact.LastName,
+			Email:     response.Email,
+			Phone:     response.Phone,
+			Emails:    response.Emails,
+			Phones:    response.Phones,
+			CreatedAt: contact.CreatedAt,
+			UpdatedAt: contact.UpdatedAt,
+			Addresses: make([]model.AccountArchiveAddress, len(contact.Addresses)),
+		}
+		for j, address := range contact.Addresses {
+			archive.Contacts[i].Addresses[j] = model.AccountArchiveAddress{
+				ID:         address.ID,
+				Street:     address.Street,
+				City:       address.City,
+				Province:   address.Province,
+				PostalCode: address.PostalCode,
+				Country:    address.Country,
+				CreatedAt:  address.CreatedAt,
+				UpdatedAt:  address.UpdatedAt,
+			}
+		}
+	}
+
+	for i, reminder := range reminders {
+		archive.Reminders[i] = model.AccountArchiveReminder{
+			ID:        reminder.ID,
+			ContactId: reminder.ContactId,
+			Note:      reminder.Note,
+			LocalTime: reminder.LocalTime,
+			Timezone:  reminder.Timezone,
+			RemindAt:  reminder.RemindAt,
+			Channels:  strings.Split(reminder.Channels, ","),
+			Status:    reminder.Status,
+			Error:     reminder.Error,
+			SentAt:    reminder.SentAt,
+			CreatedAt: reminder.CreatedAt,
+			UpdatedAt: reminder.UpdatedAt,
+		}
+	}
+
+	for i, webhook := range webhooks {
+		response := WebhookToResponse(&webhook)
+		archive.Webhooks[i] = model.AccountArchiveWebhook{
+			ID:         response.ID,
+			URL:        response.URL,
+			EventTypes: response.EventTypes,
+			Active:     response.Active,
+			CreatedAt:  response.CreatedAt,
+			UpdatedAt:  response.UpdatedAt,
+		}
+	}
+
+	return archive
+}
@@ -15,5 +15,6 @@ func AddressToResponse(address *entity.Address) *model.AddressResponse {
 		Country:    address.Country,
 		CreatedAt:  address.CreatedAt,
 		UpdatedAt:  address.UpdatedAt,
+		Version:    address.Version,
 	}
 }
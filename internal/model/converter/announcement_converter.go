@@ -0,0 +1,21 @@
+package converter
+
+import (
+	"go-rest-scaffold/internal/entity"
+	"go-rest-scaffold/internal/model"
+)
+
+func AnnouncementToResponse(announcement *entity.Announcement) *model.AnnouncementResponse {
+	return &model.AnnouncementResponse{
+		ID:        announcement.ID,
+		Title:     announcement.Title,
+		Body:      announcement.Body,
+		Level:     announcement.Level,
+		StartsAt:  announcement.StartsAt,
+		EndsAt:    announcement.EndsAt,
+		CreatedAt: announcement.CreatedAt,
+		UpdatedAt: announcement.UpdatedAt,
+		CreatedBy: announcement.CreatedBy,
+		UpdatedBy: announcement.UpdatedBy,
+	}
+}
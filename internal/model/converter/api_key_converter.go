@@ -0,0 +1,24 @@
+package converter
+
+import (
+	"go-rest-scaffold/internal/entity"
+	"go-rest-scaffold/internal/model"
+	"strings"
+)
+
+func APIKeyToResponse(apiKey *entity.APIKey) *model.APIKeyResponse {
+	response := &model.APIKeyResponse{
+		ID:        apiKey.ID,
+		Name:      apiKey.Name,
+		Prefix:    apiKey.Prefix,
+		Scopes:    strings.Fields(apiKey.Scopes),
+		CreatedAt: apiKey.CreatedAt,
+	}
+	if apiKey.LastUsedAt != nil {
+		response.LastUsedAt = *apiKey.LastUsedAt
+	}
+	if apiKey.RevokedAt != nil {
+		response.RevokedAt = *apiKey.RevokedAt
+	}
+	return response
+}
@@ -0,0 +1,23 @@
+package converter
+
+import (
+	"encoding/json"
+	"go-rest-scaffold/internal/entity"
+	"go-rest-scaffold/internal/model"
+)
+
+func AuditLogToResponse(auditLog *entity.AuditLog) *model.AuditLogResponse {
+	response := &model.AuditLogResponse{
+		ID:             auditLog.ID,
+		UserId:         auditLog.UserId,
+		Action:         auditLog.Action,
+		EntityType:     auditLog.EntityType,
+		EntityId:       auditLog.EntityId,
+		IP:             auditLog.IP,
+		ImpersonatorId: auditLog.ImpersonatorId,
+		CreatedAt:      auditLog.CreatedAt,
+	}
+	// written by the audit log use case, so it is always valid
+	_ = json.Unmarshal([]byte(auditLog.Changes), &response.Changes)
+	return response
+}
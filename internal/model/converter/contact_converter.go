@@ -3,16 +3,48 @@ package converter
 import (
 	"go-rest-scaffold/internal/entity"
 	"go-rest-scaffold/internal/model"
+	"strings"
 )
 
 func ContactToResponse(contact *entity.Contact) *model.ContactResponse {
-	return &model.ContactResponse{
+	response := &model.ContactResponse{
 		ID:        contact.ID,
 		FirstName: contact.FirstName,
 		LastName:  contact.LastName,
-		Email:     contact.Email,
-		Phone:     contact.Phone,
+		Email:     contact.PrimaryEmail(),
+		Phone:     contact.PrimaryPhone(),
+		Emails:    make([]model.ContactEmail, len(contact.Emails)),
+		Phones:    make([]model.ContactPhone, len(contact.Phones)),
 		CreatedAt: contact.CreatedAt,
 		UpdatedAt: contact.UpdatedAt,
+		Version:   contact.Version,
 	}
+	if contact.AvatarId != "" {
+		response.Avatar = &model.ContactAvatar{ID: contact.AvatarId}
+	}
+	for i, email := range contact.Emails {
+		response.Emails[i] = model.ContactEmail{Type: email.Type, Email: email.Email, Primary: email.Primary}
+	}
+	for i, phone := range contact.Phones {
+		response.Phones[i] = model.ContactPhone{Type: phone.Type, Phone: phone.Phone, Primary: phone.Primary}
+	}
+	return response
+}
+
+func ContactToSuggestion(contact *entity.Contact) *model.ContactSuggestionResponse {
+	return &model.ContactSuggestionResponse{
+		ID:    contact.ID,
+		Name:  strings.TrimSpace(contact.FirstName + " " + contact.LastName),
+		Email: contact.PrimaryEmail(),
+	}
+}
+
+// ContactWithAddressesToResponse is ContactToResponse including the preloaded addresses.
+func ContactWithAddressesToResponse(contact *entity.Contact) *model.ContactResponse {
+	response := ContactToResponse(contact)
+	response.Addresses = make([]model.AddressResponse, len(contact.Addresses))
+	for i := range contact.Addresses {
+		response.Addresses[i] = *AddressToResponse(&contact.Addresses[i])
+	}
+	return response
 }
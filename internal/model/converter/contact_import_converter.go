@@ -0,0 +1,24 @@
+package converter
+
+import (
+	"encoding/json"
+	"go-rest-scaffold/internal/entity"
+	"go-rest-scaffold/internal/model"
+)
+
+func ContactImportToResponse(contactImport *entity.ContactImport) *model.ContactImportResponse {
+	response := &model.ContactImportResponse{
+		ID:            contactImport.ID,
+		Status:        contactImport.Status,
+		TotalRows:     contactImport.TotalRows,
+		ProcessedRows: contactImport.ProcessedRows,
+		Imported:      contactImport.Imported,
+		Failed:        contactImport.Failed,
+		Errors:        []model.ContactImportRowError{},
+		Error:         contactImport.Error,
+		CreatedAt:     contactImport.CreatedAt,
+		CompletedAt:   contactImport.CompletedAt,
+	}
+	_ = json.Unmarshal([]byte(contactImport.Errors), &response.Errors)
+	return response
+}
@@ -0,0 +1,25 @@
+package converter
+
+import (
+	"encoding/json"
+	"go-rest-scaffold/internal/entity"
+	"go-rest-scaffold/internal/model"
+)
+
+func DebugCaptureToResponse(capture *entity.DebugCapture) *model.DebugCaptureResponse {
+	response := &model.DebugCaptureResponse{
+		ID:           capture.ID,
+		UserId:       capture.UserId,
+		Method:       capture.Method,
+		Path:         capture.Path,
+		Status:       capture.Status,
+		DurationMs:   capture.DurationMs,
+		RequestBody:  capture.RequestBody,
+		ResponseBody: capture.ResponseBody,
+		CreatedAt:    capture.CreatedAt,
+		ExpiresAt:    capture.ExpiresAt,
+	}
+	_ = json.Unmarshal([]byte(capture.RequestHeaders), &response.RequestHeaders)
+	_ = json.Unmarshal([]byte(capture.ResponseHeaders), &response.ResponseHeaders)
+	return response
+}
@@ -0,0 +1,40 @@
+package converter
+
+import (
+	"go-rest-scaffold/internal/entity"
+	"go-rest-scaffold/internal/mail"
+	"go-rest-scaffold/internal/model"
+)
+
+func EmailTemplateToResponse(template *entity.EmailTemplate) *model.EmailTemplateResponse {
+	return &model.EmailTemplateResponse{
+		Name:      template.Name,
+		Tenant:    template.Tenant,
+		Source:    model.EmailTemplateSourceOverride,
+		Version:   template.Version,
+		Subject:   template.Subject,
+		HTML:      template.HTML,
+		Text:      template.Text,
+		UpdatedAt: template.UpdatedAt,
+		UpdatedBy: template.UpdatedBy,
+	}
+}
+
+func MailTemplateToResponse(template mail.Template) *model.EmailTemplateResponse {
+	return &model.EmailTemplateResponse{
+		Name:    template.Name,
+		Source:  model.EmailTemplateSourceEmbedded,
+		Version: template.Version,
+		Subject: template.Subject,
+		HTML:    template.HTML,
+		Text:    template.Text,
+	}
+}
+
+func MailMessageToResponse(message *mail.Message) *model.RenderedEmailResponse {
+	return &model.RenderedEmailResponse{
+		Subject: message.Subject,
+		HTML:    message.HTML,
+		Text:    message.Text,
+	}
+}
@@ -0,0 +1,19 @@
+package converter
+
+import (
+	"go-rest-scaffold/internal/entity"
+	"go-rest-scaffold/internal/model"
+)
+
+func LoginEventToResponse(event *entity.LoginEvent) *model.LoginEventResponse {
+	return &model.LoginEventResponse{
+		ID:        event.ID,
+		Method:    event.Method,
+		Success:   event.Success,
+		Reason:    event.Reason,
+		IP:        event.IP,
+		Device:    event.Device,
+		UserAgent: event.UserAgent,
+		CreatedAt: event.CreatedAt,
+	}
+}
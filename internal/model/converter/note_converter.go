@@ -0,0 +1,15 @@
+package converter
+
+import (
+	"go-rest-scaffold/internal/entity"
+	"go-rest-scaffold/internal/model"
+)
+
+func NoteToResponse(note *entity.Note) *model.NoteResponse {
+	return &model.NoteResponse{
+		ID:        note.ID,
+		Body:      note.Body,
+		CreatedAt: note.CreatedAt,
+		UpdatedAt: note.UpdatedAt,
+	}
+}
@@ -0,0 +1,18 @@
+package converter
+
+import (
+	"encoding/json"
+	"go-rest-scaffold/internal/entity"
+	"go-rest-scaffold/internal/model"
+)
+
+// OutboxEventToCloudEvent decodes the event saved in the outbox. Its data stays the
+// JSON it was recorded as, so publishing it again yields the same payload.
+func OutboxEventToCloudEvent(outboxEvent *entity.OutboxEvent) (*model.CloudEvent, error) {
+	data := new(json.RawMessage)
+	event := &model.CloudEvent{Data: data}
+	if err := json.Unmarshal([]byte(outboxEvent.Payload), event); err != nil {
+		return nil, err
+	}
+	return event, nil
+}
@@ -0,0 +1,18 @@
+package converter
+
+import (
+	"go-rest-scaffold/internal/entity"
+	"go-rest-scaffold/internal/model"
+)
+
+func PasskeyToResponse(passkey *entity.Passkey) *model.PasskeyResponse {
+	response := &model.PasskeyResponse{
+		ID:        passkey.ID,
+		Name:      passkey.Name,
+		CreatedAt: passkey.CreatedAt,
+	}
+	if passkey.LastUsedAt != nil {
+		response.LastUsedAt = *passkey.LastUsedAt
+	}
+	return response
+}
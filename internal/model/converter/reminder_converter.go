@@ -0,0 +1,24 @@
+package converter
+
+import (
+	"go-rest-scaffold/internal/entity"
+	"go-rest-scaffold/internal/model"
+	"strings"
+)
+
+func ReminderToResponse(reminder *entity.Reminder) *model.ReminderResponse {
+	return &model.ReminderResponse{
+		ID:        reminder.ID,
+		ContactId: reminder.ContactId,
+		Note:      reminder.Note,
+		LocalTime: reminder.LocalTime,
+		Timezone:  reminder.Timezone,
+		RemindAt:  reminder.RemindAt,
+		Channels:  strings.Split(reminder.Channels, ","),
+		Status:    reminder.Status,
+		Error:     reminder.Error,
+		SentAt:    reminder.SentAt,
+		CreatedAt: reminder.CreatedAt,
+		UpdatedAt: reminder.UpdatedAt,
+	}
+}
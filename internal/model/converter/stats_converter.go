@@ -0,0 +1,32 @@
+package converter
+
+import (
+	"go-rest-scaffold/internal/entity"
+	"go-rest-scaffold/internal/model"
+)
+
+func TableStorageToResponse(table *entity.TableStorage) *model.TableStorageResponse {
+	return &model.TableStorageResponse{
+		Table: table.Table,
+		Rows:  table.Rows,
+		Bytes: table.Bytes,
+	}
+}
+
+func DailyStatsToResponse(day *entity.DailyStats) *model.DailyStatsResponse {
+	return &model.DailyStatsResponse{
+		Day:             day.Day.Format(model.StatsDayLayout),
+		Registrations:   day.Registrations,
+		ActiveUsers:     day.ActiveUsers,
+		ContactsCreated: day.ContactsCreated,
+	}
+}
+
+func TopAccountToResponse(account *entity.TopAccount) *model.TopAccountResponse {
+	return &model.TopAccountResponse{
+		UserId:    account.UserId,
+		Name:      account.Name,
+		Contacts:  account.Contacts,
+		Addresses: account.Addresses,
+	}
+}
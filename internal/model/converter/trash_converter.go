@@ -0,0 +1,27 @@
+package converter
+
+import (
+	"go-rest-scaffold/internal/entity"
+	"go-rest-scaffold/internal/model"
+	"math"
+	"time"
+)
+
+// TrashItemToResponse describes an item that is deleted for good retention after its deletion.
+func TrashItemToResponse(item *entity.TrashItem, retention time.Duration, now time.Time) *model.TrashItemResponse {
+	purgeAt := item.DeletedAt.Add(retention)
+	days := int(math.Ceil(purgeAt.Sub(now).Hours() / 24))
+	if days < 0 {
+		days = 0
+	}
+
+	return &model.TrashItemResponse{
+		Type:           item.Type,
+		ID:             item.ID,
+		ContactId:      item.ContactId,
+		Title:          item.Title,
+		DeletedAt:      item.DeletedAt.UnixMilli(),
+		PurgeAt:        purgeAt.UnixMilli(),
+		DaysUntilPurge: days,
+	}
+}
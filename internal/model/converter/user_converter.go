@@ -3,15 +3,22 @@ package converter
 import (
 	"go-rest-scaffold/internal/entity"
 	"go-rest-scaffold/internal/model"
+	"strings"
 )
 
 func UserToResponse(user *entity.User) *model.UserResponse {
-	return &model.UserResponse{
+	response := &model.UserResponse{
 		ID:        user.ID,
 		Name:      user.Name,
+		Email:     user.Email,
+		Region:    user.Region,
 		CreatedAt: user.CreatedAt,
 		UpdatedAt: user.UpdatedAt,
 	}
+	if user.VerifiedAt != nil {
+		response.VerifiedAt = *user.VerifiedAt
+	}
+	return response
 }
 
 func UserToTokenResponse(user *entity.User) *model.UserResponse {
@@ -20,3 +27,16 @@ func UserToTokenResponse(user *entity.User) *model.UserResponse {
 		RefreshToken: user.RefreshToken,
 	}
 }
+
+func SessionToResponse(session *entity.Session) *model.SessionResponse {
+	return &model.SessionResponse{
+		ID:             session.ID,
+		Device:         session.Device,
+		IP:             session.IP,
+		UserAgent:      session.UserAgent,
+		Scopes:         strings.Fields(session.Scopes),
+		ImpersonatorId: session.ImpersonatorId,
+		LastSeenAt:     session.LastSeenAt,
+		CreatedAt:      session.CreatedAt,
+	}
+}
@@ -0,0 +1,41 @@
+package converter
+
+import (
+	"go-rest-scaffold/internal/entity"
+	"go-rest-scaffold/internal/model"
+	"strings"
+)
+
+func WebhookToResponse(webhook *entity.Webhook) *model.WebhookResponse {
+	eventTypes := []string{}
+	if webhook.EventTypes != "" {
+		eventTypes = strings.Split(webhook.EventTypes, ",")
+	}
+
+	return &model.WebhookResponse{
+		ID:         webhook.ID,
+		URL:        webhook.URL,
+		EventTypes: eventTypes,
+		Active:     webhook.Active,
+		CreatedAt:  webhook.CreatedAt,
+		UpdatedAt:  webhook.UpdatedAt,
+	}
+}
+
+func WebhookDeliveryToResponse(delivery *entity.WebhookDelivery) *model.WebhookDeliveryResponse {
+	return &model.WebhookDeliveryResponse{
+		ID:              delivery.ID,
+		WebhookId:       delivery.WebhookId,
+		EventId:         delivery.EventId,
+		EventType:       delivery.EventType,
+		Attempt:         delivery.Attempt,
+		Status:          delivery.Status,
+		ResponseStatus:  delivery.ResponseStatus,
+		LatencyMs:       delivery.LatencyMs,
+		ResponseSnippet: delivery.ResponseSnippet,
+		Error:           delivery.Error,
+		ReplayOf:        delivery.ReplayOf,
+		NextAttemptAt:   delivery.NextAttemptAt,
+		CreatedAt:       delivery.CreatedAt,
+	}
+}
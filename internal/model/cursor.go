@@ -0,0 +1,73 @@
+package model
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+)
+
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// Cursor is a position in a keyset paginated listing: the sort key of the row a
+// page starts after, or ends before when Before is set. It travels to the client
+// as an opaque string.
+type Cursor struct {
+	// Sort is the order the cursor was taken in, see SortSignature.
+	Sort   string `json:"s"`
+	Values []any  `json:"v"`
+	Before bool   `json:"b,omitempty"`
+}
+
+// Encode returns the opaque form of the cursor.
+func (c *Cursor) Encode() string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// DecodeCursor parses a cursor returned by Encode. Its values come back as strings
+// or int64, the types of the columns the listings are sorted by.
+func DecodeCursor(value string) (*Cursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	cursor := new(Cursor)
+	if err := decoder.Decode(cursor); err != nil || len(cursor.Values) == 0 {
+		return nil, ErrInvalidCursor
+	}
+
+	for i, v := range cursor.Values {
+		switch v := v.(type) {
+		case string:
+		case json.Number:
+			n, err := v.Int64()
+			if err != nil {
+				return nil, ErrInvalidCursor
+			}
+			cursor.Values[i] = n
+		default:
+			return nil, ErrInvalidCursor
+		}
+	}
+
+	return cursor, nil
+}
+
+// SortSignature identifies an order, so a cursor taken in one order is not used in another.
+func SortSignature(fields []SortField) string {
+	parts := make([]string, len(fields))
+	for i, field := range fields {
+		if field.Desc {
+			parts[i] = "-" + field.Field
+		} else {
+			parts[i] = field.Field
+		}
+	}
+	return strings.Join(parts, ",")
+}
@@ -0,0 +1,42 @@
+package model
+
+type DebugCaptureResponse struct {
+	ID              string            `json:"id"`
+	UserId          string            `json:"user_id,omitempty"`
+	Method          string            `json:"method"`
+	Path            string            `json:"path"`
+	Status          int               `json:"status"`
+	DurationMs      int64             `json:"duration_ms"`
+	RequestHeaders  map[string]string `json:"request_headers,omitempty"`
+	RequestBody     string            `json:"request_body,omitempty"`
+	ResponseHeaders map[string]string `json:"response_headers,omitempty"`
+	ResponseBody    string            `json:"response_body,omitempty"`
+	CreatedAt       int64             `json:"created_at"`
+	ExpiresAt       int64             `json:"expires_at"`
+}
+
+// RecordDebugCaptureRequest is the raw, unredacted exchange handed over by the middleware.
+type RecordDebugCaptureRequest struct {
+	UserId          string
+	Method          string
+	Path            string
+	Query           string
+	Status          int
+	DurationMs      int64
+	RequestHeaders  map[string]string
+	RequestBody     []byte
+	RequestType     string
+	ResponseHeaders map[string]string
+	ResponseBody    []byte
+	ResponseType    string
+}
+
+type SearchDebugCaptureRequest struct {
+	UserId string `json:"user_id"`
+	Page   int    `json:"page" validate:"min=1,page_number"`
+	Size   int    `json:"size" validate:"min=1,page_size"`
+}
+
+type GetDebugCaptureRequest struct {
+	ID string `json:"-" validate:"required,max=100,uuid"`
+}
@@ -0,0 +1,34 @@
+package model
+
+type APIMetadataResponse struct {
+	Name         string             `json:"name"`
+	Version      string             `json:"version"`
+	Versions     []APIVersion       `json:"versions"`
+	Capabilities map[string]bool    `json:"capabilities"`
+	Endpoints    []EndpointResponse `json:"endpoints"`
+}
+
+type APIVersion struct {
+	Name     string `json:"name"`
+	BasePath string `json:"base_path"`
+	// Default is whether the unversioned /api paths serve the version when requests
+	// do not pick one.
+	Default bool `json:"default"`
+}
+
+type EndpointResponse struct {
+	Method string `json:"method"`
+	Path   string `json:"path"`
+}
+
+// OpenIDConfigurationResponse is the OpenID Connect discovery document of the JWT
+// access tokens, telling other services where to get tokens and the keys verifying them.
+type OpenIDConfigurationResponse struct {
+	Issuer                           string   `json:"issuer"`
+	JWKSURI                          string   `json:"jwks_uri"`
+	TokenEndpoint                    string   `json:"token_endpoint"`
+	GrantTypesSupported              []string `json:"grant_types_supported"`
+	ResponseTypesSupported           []string `json:"response_types_supported"`
+	SubjectTypesSupported            []string `json:"subject_types_supported"`
+	IDTokenSigningAlgValuesSupported []string `json:"id_token_signing_alg_values_supported"`
+}
@@ -0,0 +1,38 @@
+package model
+
+import (
+	"context"
+	"sync/atomic"
+)
+
+type dryRunKey struct{}
+
+// DryRun marks a request whose writes must be rolled back instead of committed.
+type DryRun struct {
+	rolledBack atomic.Bool
+}
+
+// MarkRolledBack records that a transaction was rolled back because of the dry run.
+func (d *DryRun) MarkRolledBack() {
+	d.rolledBack.Store(true)
+}
+
+// RolledBack reports whether any use case honored the dry run.
+func (d *DryRun) RolledBack() bool {
+	return d.rolledBack.Load()
+}
+
+func WithDryRun(ctx context.Context) (context.Context, *DryRun) {
+	dryRun := new(DryRun)
+	return context.WithValue(ctx, dryRunKey{}, dryRun), dryRun
+}
+
+// DryRunFrom returns the dry run of ctx, or nil when writes should be committed.
+func DryRunFrom(ctx context.Context) *DryRun {
+	dryRun, _ := ctx.Value(dryRunKey{}).(*DryRun)
+	return dryRun
+}
+
+func IsDryRun(ctx context.Context) bool {
+	return DryRunFrom(ctx) != nil
+}
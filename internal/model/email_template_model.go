@@ -0,0 +1,58 @@
+package model
+
+const (
+	EmailTemplateSourceEmbedded = "embedded"
+	EmailTemplateSourceOverride = "override"
+)
+
+type EmailTemplateResponse struct {
+	Name string `json:"name"`
+	// Tenant is the tenant owning the override in effect, empty for deployment-wide ones.
+	Tenant    string `json:"tenant"`
+	Source    string `json:"source"`
+	Version   int    `json:"version"`
+	Subject   string `json:"subject"`
+	HTML      string `json:"html"`
+	Text      string `json:"text"`
+	UpdatedAt int64  `json:"updated_at,omitempty"`
+	UpdatedBy string `json:"updated_by,omitempty"`
+}
+
+type RenderedEmailResponse struct {
+	Subject string `json:"subject"`
+	HTML    string `json:"html"`
+	Text    string `json:"text"`
+}
+
+type ListEmailTemplateRequest struct {
+	Tenant string `json:"-" validate:"max=100"`
+}
+
+type GetEmailTemplateRequest struct {
+	Name   string `json:"-" validate:"required,max=100"`
+	Tenant string `json:"-" validate:"max=100"`
+}
+
+type UpdateEmailTemplateRequest struct {
+	Name    string `json:"-" validate:"required,max=100"`
+	Tenant  string `json:"tenant" validate:"max=100"`
+	Subject string `json:"subject" validate:"required,max=1000"`
+	HTML    string `json:"html" validate:"required,max=100000"`
+	Text    string `json:"text" validate:"required,max=100000"`
+}
+
+type DeleteEmailTemplateRequest struct {
+	Name   string `json:"-" validate:"required,max=100"`
+	Tenant string `json:"-" validate:"max=100"`
+}
+
+// PreviewEmailTemplateRequest renders the template in effect for the tenant, or the
+// draft when Subject, HTML and Text are given, with Data laid over the sample data.
+type PreviewEmailTemplateRequest struct {
+	Name    string         `json:"-" validate:"required,max=100"`
+	Tenant  string         `json:"tenant" validate:"max=100"`
+	Subject string         `json:"subject" validate:"required_with=HTML Text,max=1000"`
+	HTML    string         `json:"html" validate:"required_with=Subject Text,max=100000"`
+	Text    string         `json:"text" validate:"required_with=Subject HTML,max=100000"`
+	Data    map[string]any `json:"data"`
+}
@@ -0,0 +1,79 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	CloudEventSpecVersion = "1.0"
+
+	// EventTypePrefix namespaces every event type emitted by the service.
+	// Full types look like "com.go-rest-scaffold.contact.created.v1", the
+	// trailing version is bumped only when the data payload changes incompatibly.
+	EventTypePrefix = "com.go-rest-scaffold."
+)
+
+// CloudEvent is the CloudEvents 1.0 envelope used for every published event,
+// regardless of whether it leaves through the outbox, a webhook, or a broker.
+type CloudEvent struct {
+	SpecVersion     string `json:"specversion"`
+	ID              string `json:"id"`
+	Source          string `json:"source"`
+	Type            string `json:"type"`
+	Subject         string `json:"subject,omitempty"`
+	Time            string `json:"time"`
+	DataContentType string `json:"datacontenttype"`
+	// UserId is a CloudEvents extension attribute naming the account the event belongs to.
+	UserId string `json:"userid,omitempty"`
+	Data   any    `json:"data"`
+}
+
+func (e *CloudEvent) GetId() string {
+	return e.ID
+}
+
+var (
+	EventUserRegistered  = EventType("user", "registered")
+	EventUserUpdated     = EventType("user", "updated")
+	EventUserDeleted     = EventType("user", "deleted")
+	EventAccountImported = EventType("account", "imported")
+	EventContactCreated  = EventType("contact", "created")
+	EventContactUpdated  = EventType("contact", "updated")
+	EventContactDeleted  = EventType("contact", "deleted")
+	EventContactRestored = EventType("contact", "restored")
+	EventContactTagged   = EventType("contact", "tagged")
+	EventContactUntagged = EventType("contact", "untagged")
+	EventAddressCreated  = EventType("address", "created")
+	EventAddressUpdated  = EventType("address", "updated")
+	EventAddressDeleted  = EventType("address", "deleted")
+	EventAddressRestored = EventType("address", "restored")
+	EventTagCreated      = EventType("tag", "created")
+	EventTagUpdated      = EventType("tag", "updated")
+	EventTagDeleted      = EventType("tag", "deleted")
+	EventNoteCreated     = EventType("note", "created")
+	EventNoteUpdated     = EventType("note", "updated")
+	EventNoteDeleted     = EventType("note", "deleted")
+	EventReminderDue     = EventType("reminder", "due")
+)
+
+// EventType builds a stable event type from the resource and action, e.g. EventType("contact", "created").
+func EventType(resource string, action string) string {
+	return EventTypePrefix + resource + "." + action + ".v1"
+}
+
+// NewCloudEvent wraps data in a CloudEvents envelope with a fresh ID and the current time.
+func NewCloudEvent(source string, eventType string, subject string, userId string, data any) *CloudEvent {
+	return &CloudEvent{
+		SpecVersion:     CloudEventSpecVersion,
+		ID:              uuid.NewString(),
+		Source:          source,
+		Type:            eventType,
+		Subject:         subject,
+		Time:            time.Now().UTC().Format(time.RFC3339Nano),
+		DataContentType: "application/json",
+		UserId:          userId,
+		Data:            data,
+	}
+}
@@ -0,0 +1,22 @@
+package model
+
+// Experiment is an experiment definition from the experiments config block.
+type Experiment struct {
+	Key      string              `mapstructure:"key"`
+	Enabled  bool                `mapstructure:"enabled"`
+	Variants []ExperimentVariant `mapstructure:"variants"`
+}
+
+// ExperimentVariant is one arm of an experiment. Weights are relative; a zero weight
+// counts as one so an experiment without weights is split evenly.
+type ExperimentVariant struct {
+	Name   string `mapstructure:"name"`
+	Weight int    `mapstructure:"weight"`
+}
+
+// Experiments maps experiment keys to the variant assigned to the current user.
+type Experiments map[string]string
+
+func (e Experiments) Variant(key string) string {
+	return e[key]
+}
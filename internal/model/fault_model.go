@@ -0,0 +1,13 @@
+package model
+
+// FaultRule describes the faults injected into requests matching Method and Path.
+// Path matches exactly, or by prefix when it ends with "*". An empty Method matches any.
+type FaultRule struct {
+	Method             string  `mapstructure:"method"`
+	Path               string  `mapstructure:"path"`
+	LatencyMs          int     `mapstructure:"latency_ms"`
+	LatencyProbability float64 `mapstructure:"latency_probability"`
+	ErrorStatus        int     `mapstructure:"error_status"`
+	ErrorProbability   float64 `mapstructure:"error_probability"`
+	DropProbability    float64 `mapstructure:"drop_probability"`
+}
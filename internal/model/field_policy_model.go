@@ -0,0 +1,9 @@
+package model
+
+// FieldRule hides fields of a response definition from a role, e.g. the phone of
+// model.ContactResponse from RoleUser. Definitions are named as in the Swagger document.
+type FieldRule struct {
+	Role       string   `mapstructure:"role"`
+	Definition string   `mapstructure:"definition"`
+	Fields     []string `mapstructure:"fields"`
+}
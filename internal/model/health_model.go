@@ -0,0 +1,22 @@
+package model
+
+const (
+	HealthUp   = "up"
+	HealthDown = "down"
+)
+
+// HealthResponse is the answer of the probe endpoints. Status is down when any of
+// the components is, or while the instance shuts down.
+type HealthResponse struct {
+	Status     string                     `json:"status"`
+	Draining   bool                       `json:"draining,omitempty"`
+	Components map[string]ComponentHealth `json:"components,omitempty"`
+}
+
+type ComponentHealth struct {
+	Status    string `json:"status"`
+	LatencyMs int64  `json:"latency_ms"`
+	// Error is "timeout" or "unavailable", the cause is only logged as it may name
+	// internal hosts.
+	Error string `json:"error,omitempty"`
+}
@@ -0,0 +1,17 @@
+package model
+
+type LoggingResponse struct {
+	Level      string            `json:"level"`
+	Components map[string]string `json:"components"`
+}
+
+type UpdateLogLevelRequest struct {
+	Level string `json:"level" validate:"required,oneof=panic fatal error warn warning info debug trace"`
+}
+
+type UpdateLoggingRequest struct {
+	Level      string            `json:"level" validate:"required,oneof=panic fatal error warn warning info debug trace"`
+	Components map[string]string `json:"components" validate:"dive,keys,required,max=100,endkeys,oneof=panic fatal error warn warning info debug trace"`
+	// Persist writes the new levels back to the config file so they survive a restart.
+	Persist bool `json:"persist"`
+}
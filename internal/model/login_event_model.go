@@ -0,0 +1,27 @@
+package model
+
+// Methods a user can log in with.
+const (
+	LoginMethodPassword  = "password"
+	LoginMethodMagicLink = "magic_link"
+	LoginMethodPasskey   = "passkey"
+)
+
+// LoginEventResponse is one attempt to log in as the user. Reason tells why a failed
+// attempt was refused.
+type LoginEventResponse struct {
+	ID        string `json:"id"`
+	Method    string `json:"method"`
+	Success   bool   `json:"success"`
+	Reason    string `json:"reason,omitempty"`
+	IP        string `json:"ip"`
+	Device    string `json:"device"`
+	UserAgent string `json:"user_agent"`
+	CreatedAt int64  `json:"created_at"`
+}
+
+type ListLoginEventRequest struct {
+	UserId string `json:"-" validate:"required"`
+	Page   int    `json:"page" validate:"min=1,page_number"`
+	Size   int    `json:"size" validate:"min=1,page_size"`
+}
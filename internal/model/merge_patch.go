@@ -0,0 +1,56 @@
+package model
+
+import (
+	"encoding/json"
+	"reflect"
+)
+
+// MIMEMergePatchJSON is the media type of JSON merge patches (RFC 7386).
+const MIMEMergePatchJSON = "application/merge-patch+json"
+
+// MergePatchField is a field of a JSON merge patch (RFC 7386). A field the patch
+// leaves out keeps its value, one it sets to null is cleared and any other value
+// replaces it.
+type MergePatchField[T any] struct {
+	// Present reports whether the patch names the field, null included.
+	Present bool
+	// Value is the new value, nil when the patch clears the field.
+	Value *T
+}
+
+func (f *MergePatchField[T]) UnmarshalJSON(data []byte) error {
+	f.Present = true
+	f.Value = nil
+	if string(data) == "null" {
+		return nil
+	}
+	return json.Unmarshal(data, &f.Value)
+}
+
+// Apply merges the field into target, which is left alone when the patch does not
+// name the field and set to its zero value when the patch clears it.
+func (f MergePatchField[T]) Apply(target *T) {
+	if !f.Present {
+		return
+	}
+	if f.Value == nil {
+		var zero T
+		*target = zero
+		return
+	}
+	*target = *f.Value
+}
+
+// MergePatchValue lets the validator check a merge patch field as the value it sets:
+// nil when the patch leaves the field out, so omitnil skips it, and the zero value
+// when the patch clears it, so a field that must not be empty cannot be cleared.
+func MergePatchValue[T any](field reflect.Value) any {
+	patch := field.Interface().(MergePatchField[T])
+	if !patch.Present {
+		return (*T)(nil)
+	}
+	if patch.Value == nil {
+		return new(T)
+	}
+	return patch.Value
+}
@@ -16,4 +16,8 @@ type PageMetadata struct {
 	Size      int   `json:"size"`
 	TotalItem int64 `json:"total_item"`
 	TotalPage int64 `json:"total_page"`
+	// NextCursor and PrevCursor are only set by listings paged with a cursor, which
+	// leave Page, TotalItem and TotalPage at zero.
+	NextCursor string `json:"next_cursor,omitempty"`
+	PrevCursor string `json:"prev_cursor,omitempty"`
 }
@@ -0,0 +1,40 @@
+package model
+
+type NoteResponse struct {
+	ID        string `json:"id"`
+	Body      string `json:"body"`
+	CreatedAt int64  `json:"created_at"`
+	UpdatedAt int64  `json:"updated_at"`
+}
+
+type CreateNoteRequest struct {
+	UserId    string `json:"-" validate:"required"`
+	ContactId string `json:"-" validate:"required,max=100,entity_id"`
+	Body      string `json:"body" validate:"required,max=10000"`
+}
+
+type UpdateNoteRequest struct {
+	UserId    string `json:"-" validate:"required"`
+	ContactId string `json:"-" validate:"required,max=100,entity_id"`
+	ID        string `json:"-" validate:"required,max=100,entity_id"`
+	Body      string `json:"body" validate:"required,max=10000"`
+}
+
+type GetNoteRequest struct {
+	UserId    string `json:"-" validate:"required"`
+	ContactId string `json:"-" validate:"required,max=100,entity_id"`
+	ID        string `json:"-" validate:"required,max=100,entity_id"`
+}
+
+type DeleteNoteRequest struct {
+	UserId    string `json:"-" validate:"required"`
+	ContactId string `json:"-" validate:"required,max=100,entity_id"`
+	ID        string `json:"-" validate:"required,max=100,entity_id"`
+}
+
+type SearchNoteRequest struct {
+	UserId    string `json:"-" validate:"required"`
+	ContactId string `json:"-" validate:"required,max=100,entity_id"`
+	Page      int    `json:"page" validate:"min=1,page_number"`
+	Size      int    `json:"size" validate:"min=1,page_size"`
+}
@@ -0,0 +1,113 @@
+package model
+
+type PasskeyResponse struct {
+	ID         string `json:"id"`
+	Name       string `json:"name"`
+	LastUsedAt int64  `json:"last_used_at,omitempty"`
+	CreatedAt  int64  `json:"created_at"`
+}
+
+// PasskeyCreationOptions are the options of navigator.credentials.create() in their
+// WebAuthn JSON form, binary values base64url encoded, ready for
+// PublicKeyCredential.parseCreationOptionsFromJSON().
+type PasskeyCreationOptions struct {
+	Challenge              string                        `json:"challenge"`
+	RP                     PasskeyRelyingParty           `json:"rp"`
+	User                   PasskeyUser                   `json:"user"`
+	PubKeyCredParams       []PasskeyCredentialParameter  `json:"pubKeyCredParams"`
+	Timeout                int64                         `json:"timeout"`
+	ExcludeCredentials     []PasskeyCredentialDescriptor `json:"excludeCredentials"`
+	AuthenticatorSelection PasskeyAuthenticatorSelection `json:"authenticatorSelection"`
+	Attestation            string                        `json:"attestation"`
+}
+
+// PasskeyRequestOptions are the options of navigator.credentials.get() in their
+// WebAuthn JSON form, ready for PublicKeyCredential.parseRequestOptionsFromJSON().
+type PasskeyRequestOptions struct {
+	Challenge        string                        `json:"challenge"`
+	RPID             string                        `json:"rpId"`
+	Timeout          int64                         `json:"timeout"`
+	AllowCredentials []PasskeyCredentialDescriptor `json:"allowCredentials"`
+	UserVerification string                        `json:"userVerification"`
+}
+
+type PasskeyRelyingParty struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+type PasskeyUser struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	DisplayName string `json:"displayName"`
+}
+
+type PasskeyCredentialParameter struct {
+	Type      string `json:"type"`
+	Algorithm int    `json:"alg"`
+}
+
+type PasskeyCredentialDescriptor struct {
+	Type string `json:"type"`
+	ID   string `json:"id"`
+}
+
+type PasskeyAuthenticatorSelection struct {
+	ResidentKey      string `json:"residentKey"`
+	UserVerification string `json:"userVerification"`
+}
+
+// PasskeyAttestationResponse is the response of navigator.credentials.create(), as
+// PublicKeyCredential.toJSON() encodes it.
+type PasskeyAttestationResponse struct {
+	ClientDataJSON    string `json:"clientDataJSON" validate:"required,max=10000"`
+	AttestationObject string `json:"attestationObject" validate:"required,max=20000"`
+}
+
+type PasskeyRegistrationCredential struct {
+	ID       string                     `json:"id" validate:"required,max=1400"`
+	Response PasskeyAttestationResponse `json:"response"`
+}
+
+// PasskeyAssertionResponse is the response of navigator.credentials.get(), as
+// PublicKeyCredential.toJSON() encodes it. UserHandle is the base64url user ID.
+type PasskeyAssertionResponse struct {
+	ClientDataJSON    string `json:"clientDataJSON" validate:"required,max=10000"`
+	AuthenticatorData string `json:"authenticatorData" validate:"required,max=10000"`
+	Signature         string `json:"signature" validate:"required,max=2000"`
+	UserHandle        string `json:"userHandle" validate:"required,max=200"`
+}
+
+type PasskeyLoginCredential struct {
+	ID       string                   `json:"id" validate:"required,max=1400"`
+	Response PasskeyAssertionResponse `json:"response"`
+}
+
+type BeginPasskeyRegistrationRequest struct {
+	UserId string `json:"-" validate:"required"`
+}
+
+type RegisterPasskeyRequest struct {
+	UserId     string                        `json:"-" validate:"required"`
+	Name       string                        `json:"name" validate:"required,max=100"`
+	Credential PasskeyRegistrationCredential `json:"credential"`
+}
+
+type ListPasskeyRequest struct {
+	UserId string `json:"-" validate:"required"`
+}
+
+type DeletePasskeyRequest struct {
+	UserId string `json:"-" validate:"required"`
+	ID     string `json:"-" validate:"required,max=100,uuid"`
+}
+
+// PasskeyLoginRequest logs in with a passkey. Device and Scopes work as in
+// LoginUserRequest.
+type PasskeyLoginRequest struct {
+	Credential PasskeyLoginCredential `json:"credential"`
+	Device     string                 `json:"device,omitempty" validate:"max=100"`
+	Scopes     []string               `json:"scopes,omitempty" validate:"max=20,unique,dive,oneof=account:read account:write contacts:read contacts:write addresses:read addresses:write reminders:read reminders:write webhooks:read webhooks:write admin"`
+	IP         string                 `json:"-"`
+	UserAgent  string                 `json:"-"`
+}
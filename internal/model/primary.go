@@ -0,0 +1,17 @@
+package model
+
+import "context"
+
+type primaryKey struct{}
+
+// WithPrimary sends the reads done with ctx to the primary database rather than a
+// read replica, for work that must see a write the replicas may not have applied yet.
+func WithPrimary(ctx context.Context) context.Context {
+	return context.WithValue(ctx, primaryKey{}, true)
+}
+
+// IsPrimary reports whether the reads done with ctx must go to the primary database.
+func IsPrimary(ctx context.Context) bool {
+	primary, _ := ctx.Value(primaryKey{}).(bool)
+	return primary
+}
@@ -0,0 +1,47 @@
+package model
+
+// Filter operators understood by the repository specification layer.
+const (
+	FilterAnd        = "and"
+	FilterOr         = "or"
+	FilterNot        = "not"
+	FilterEq         = "eq"
+	FilterNe         = "ne"
+	FilterGt         = "gt"
+	FilterGe         = "ge"
+	FilterLt         = "lt"
+	FilterLe         = "le"
+	FilterContains   = "contains"
+	FilterStartsWith = "startswith"
+	FilterEndsWith   = "endswith"
+)
+
+// FilterNode is a typed filter expression tree. Logical nodes (and, or, not)
+// carry Children, comparison nodes carry a Field and a Value.
+type FilterNode struct {
+	Operator string        `json:"operator"`
+	Field    string        `json:"field,omitempty"`
+	Value    any           `json:"value,omitempty"`
+	Children []*FilterNode `json:"children,omitempty"`
+}
+
+// Fields returns every field referenced by the expression.
+func (n *FilterNode) Fields() []string {
+	if n == nil {
+		return nil
+	}
+
+	var fields []string
+	if n.Field != "" {
+		fields = append(fields, n.Field)
+	}
+	for _, child := range n.Children {
+		fields = append(fields, child.Fields()...)
+	}
+	return fields
+}
+
+type SortField struct {
+	Field string `json:"field"`
+	Desc  bool   `json:"desc"`
+}
@@ -0,0 +1,10 @@
+package model
+
+type RateLimitResponse struct {
+	Enabled   bool   `json:"enabled"`
+	Policy    string `json:"policy,omitempty"`
+	Limit     int    `json:"limit"`
+	Remaining int    `json:"remaining"`
+	Reset     int64  `json:"reset"`
+	Window    int64  `json:"window"`
+}
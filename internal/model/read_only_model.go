@@ -0,0 +1,16 @@
+package model
+
+type ReadOnlyResponse struct {
+	Enabled bool   `json:"enabled"`
+	Reason  string `json:"reason,omitempty"`
+	// Since is when read-only mode was enabled, in Unix milliseconds, or 0 when it is off.
+	Since int64 `json:"since"`
+	// Configured is set when the config file enables read-only mode, which the admin
+	// endpoint cannot turn off.
+	Configured bool `json:"configured"`
+}
+
+type UpdateReadOnlyRequest struct {
+	Enabled bool   `json:"enabled"`
+	Reason  string `json:"reason" validate:"max=255"`
+}
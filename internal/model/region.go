@@ -0,0 +1,22 @@
+package model
+
+import "context"
+
+type regionKey struct{}
+
+// WithRegion pins the database work done with ctx to region. The empty region is
+// the home region, which also holds the data shared by every user.
+func WithRegion(ctx context.Context, region string) context.Context {
+	return context.WithValue(ctx, regionKey{}, region)
+}
+
+// WithHomeRegion pins ctx to the home region, for data that is not owned by a user.
+func WithHomeRegion(ctx context.Context) context.Context {
+	return WithRegion(ctx, "")
+}
+
+// RegionFrom returns the region ctx is pinned to, "" for the home region.
+func RegionFrom(ctx context.Context) string {
+	region, _ := ctx.Value(regionKey{}).(string)
+	return region
+}
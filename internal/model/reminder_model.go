@@ -0,0 +1,61 @@
+package model
+
+const (
+	ReminderPending = "pending"
+	ReminderSent    = "sent"
+	ReminderFailed  = "failed"
+
+	// ReminderTimeLayout is the layout of the local date and time of a reminder.
+	ReminderTimeLayout = "2006-01-02T15:04"
+)
+
+type ReminderResponse struct {
+	ID        string   `json:"id"`
+	ContactId string   `json:"contact_id"`
+	Note      string   `json:"note"`
+	LocalTime string   `json:"local_time"`
+	Timezone  string   `json:"timezone"`
+	RemindAt  int64    `json:"remind_at"`
+	Channels  []string `json:"channels"`
+	Status    string   `json:"status"`
+	Error     string   `json:"error,omitempty"`
+	SentAt    int64    `json:"sent_at,omitempty"`
+	CreatedAt int64    `json:"created_at"`
+	UpdatedAt int64    `json:"updated_at"`
+}
+
+type CreateReminderRequest struct {
+	UserId    string   `json:"-" validate:"required"`
+	ContactId string   `json:"contact_id" validate:"required,max=100,entity_id"`
+	Note      string   `json:"note" validate:"max=1000"`
+	LocalTime string   `json:"local_time" validate:"required,datetime=2006-01-02T15:04"`
+	Timezone  string   `json:"timezone" validate:"omitempty,max=100,timezone"`
+	Channels  []string `json:"channels" validate:"required,min=1,unique,dive,oneof=in_app email push"`
+}
+
+type UpdateReminderRequest struct {
+	UserId    string   `json:"-" validate:"required"`
+	ID        string   `json:"-" validate:"required,max=100,entity_id"`
+	Note      string   `json:"note" validate:"max=1000"`
+	LocalTime string   `json:"local_time" validate:"required,datetime=2006-01-02T15:04"`
+	Timezone  string   `json:"timezone" validate:"omitempty,max=100,timezone"`
+	Channels  []string `json:"channels" validate:"required,min=1,unique,dive,oneof=in_app email push"`
+}
+
+type GetReminderRequest struct {
+	UserId string `json:"-" validate:"required"`
+	ID     string `json:"-" validate:"required,max=100,entity_id"`
+}
+
+type DeleteReminderRequest struct {
+	UserId string `json:"-" validate:"required"`
+	ID     string `json:"-" validate:"required,max=100,entity_id"`
+}
+
+type SearchReminderRequest struct {
+	UserId    string `json:"-" validate:"required"`
+	ContactId string `json:"contact_id" validate:"omitempty,max=100,entity_id"`
+	Status    string `json:"status" validate:"omitempty,oneof=pending sent failed"`
+	Page      int    `json:"page" validate:"min=1,page_number"`
+	Size      int    `json:"size" validate:"min=1,page_size"`
+}
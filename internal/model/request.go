@@ -0,0 +1,17 @@
+package model
+
+import "context"
+
+type requestIDKey struct{}
+
+// WithRequestID records the ID of the request ctx serves, which its log entries and
+// error responses carry.
+func WithRequestID(ctx context.Context, requestId string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, requestId)
+}
+
+// RequestIDFrom returns the request ID of ctx, or "" outside of a request.
+func RequestIDFrom(ctx context.Context) string {
+	requestId, _ := ctx.Value(requestIDKey{}).(string)
+	return requestId
+}
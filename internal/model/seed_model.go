@@ -0,0 +1,26 @@
+package model
+
+// SeedFixtures are the development data loaded by the seed command, from a JSON or
+// YAML file. Each record is created through its use case, so it is validated and
+// stored exactly like one created through the API.
+type SeedFixtures struct {
+	Users []SeedUser `json:"users"`
+}
+
+type SeedUser struct {
+	RegisterUserRequest
+	Contacts []SeedContact `json:"contacts"`
+}
+
+type SeedContact struct {
+	CreateContactRequest
+	Addresses []CreateAddressRequest `json:"addresses"`
+}
+
+type SeedResponse struct {
+	Users     int `json:"users"`
+	Contacts  int `json:"contacts"`
+	Addresses int `json:"addresses"`
+	// SkippedUsers already existed: they are left as they are, with their contacts.
+	SkippedUsers int `json:"skipped_users"`
+}
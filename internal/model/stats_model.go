@@ -0,0 +1,46 @@
+package model
+
+// StatsDayLayout is the layout of the UTC days of the statistics.
+const StatsDayLayout = "2006-01-02"
+
+type StatsResponse struct {
+	Users            int64                  `json:"users"`
+	ActiveToday      int64                  `json:"active_today"`
+	ActiveLast7Days  int64                  `json:"active_last_7_days"`
+	ActiveLast30Days int64                  `json:"active_last_30_days"`
+	Contacts         int64                  `json:"contacts"`
+	Addresses        int64                  `json:"addresses"`
+	Reminders        int64                  `json:"reminders"`
+	Webhooks         int64                  `json:"webhooks"`
+	StorageBytes     int64                  `json:"storage_bytes"`
+	Tables           []TableStorageResponse `json:"tables"`
+	GeneratedAt      int64                  `json:"generated_at"`
+}
+
+type TableStorageResponse struct {
+	Table string `json:"table"`
+	Rows  int64  `json:"rows"`
+	Bytes int64  `json:"bytes"`
+}
+
+type DailyStatsResponse struct {
+	Day             string `json:"day"`
+	Registrations   int64  `json:"registrations"`
+	ActiveUsers     int64  `json:"active_users"`
+	ContactsCreated int64  `json:"contacts_created"`
+}
+
+type TopAccountResponse struct {
+	UserId    string `json:"user_id"`
+	Name      string `json:"name"`
+	Contacts  int64  `json:"contacts"`
+	Addresses int64  `json:"addresses"`
+}
+
+type DailyStatsRequest struct {
+	Days int `json:"days" validate:"min=1,max=366"`
+}
+
+type TopAccountsRequest struct {
+	Limit int `json:"limit" validate:"min=1,max=100"`
+}
@@ -0,0 +1,48 @@
+package model
+
+type TagResponse struct {
+	ID        string `json:"id"`
+	Name      string `json:"name"`
+	Color     string `json:"color,omitempty"`
+	CreatedAt int64  `json:"created_at"`
+	UpdatedAt int64  `json:"updated_at"`
+}
+
+type CreateTagRequest struct {
+	UserId string `json:"-" validate:"required"`
+	Name   string `json:"name" validate:"required,max=50"`
+	Color  string `json:"color" validate:"omitempty,hexcolor,max=7"`
+}
+
+type UpdateTagRequest struct {
+	UserId string `json:"-" validate:"required"`
+	ID     string `json:"-" validate:"required,max=100,entity_id"`
+	Name   string `json:"name" validate:"required,max=50"`
+	Color  string `json:"color" validate:"omitempty,hexcolor,max=7"`
+}
+
+type GetTagRequest struct {
+	UserId string `json:"-" validate:"required"`
+	ID     string `json:"-" validate:"required,max=100,entity_id"`
+}
+
+type DeleteTagRequest struct {
+	UserId string `json:"-" validate:"required"`
+	ID     string `json:"-" validate:"required,max=100,entity_id"`
+}
+
+type ListTagRequest struct {
+	UserId string `json:"-" validate:"required"`
+}
+
+type ListContactTagRequest struct {
+	UserId    string `json:"-" validate:"required"`
+	ContactId string `json:"-" validate:"required,max=100,entity_id"`
+}
+
+// AssignTagRequest assigns a tag to a contact or takes it off again.
+type AssignTagRequest struct {
+	UserId    string `json:"-" validate:"required"`
+	ContactId string `json:"-" validate:"required,max=100,entity_id"`
+	TagId     string `json:"-" validate:"required,max=100,entity_id"`
+}
@@ -0,0 +1,52 @@
+package model
+
+const (
+	TrashContact = "contact"
+	TrashAddress = "address"
+)
+
+type TrashItemResponse struct {
+	Type      string `json:"type"`
+	ID        string `json:"id"`
+	ContactId string `json:"contact_id,omitempty"`
+	Title     string `json:"title"`
+	DeletedAt int64  `json:"deleted_at"`
+	PurgeAt   int64  `json:"purge_at"`
+	// DaysUntilPurge counts the started days left before the item is deleted for good.
+	DaysUntilPurge int `json:"days_until_purge"`
+}
+
+type SearchTrashRequest struct {
+	UserId string `json:"-" validate:"required"`
+	Type   string `json:"type" validate:"omitempty,oneof=contact address"`
+	Page   int    `json:"page" validate:"min=1,page_number"`
+	Size   int    `json:"size" validate:"min=1,page_size"`
+}
+
+type TrashItemRequest struct {
+	Type string `json:"type" validate:"required,oneof=contact address"`
+	ID   string `json:"id" validate:"required,max=100,entity_id"`
+}
+
+// RestoreTrashRequest restores every listed item or, when one of them cannot be, none of them.
+type RestoreTrashRequest struct {
+	UserId string             `json:"-" validate:"required"`
+	Items  []TrashItemRequest `json:"items" validate:"required,min=1,max=100,unique,dive"`
+}
+
+type RestoreContactRequest struct {
+	UserId string `json:"-" validate:"required"`
+	ID     string `json:"-" validate:"required,max=100,entity_id"`
+}
+
+type RestoreTrashResponse struct {
+	Restored int `json:"restored"`
+}
+
+type EmptyTrashRequest struct {
+	UserId string `json:"-" validate:"required"`
+}
+
+type EmptyTrashResponse struct {
+	Purged int64 `json:"purged"`
+}
@@ -0,0 +1,32 @@
+package model
+
+// UsageResponse is how many requests the user made today, counted per UTC day, and
+// how many each of their API keys made.
+type UsageResponse struct {
+	Enabled bool `json:"enabled"`
+	// Date is the UTC day counted, e.g. 2026-10-15.
+	Date string `json:"date"`
+	// Reset is the Unix time the counters restart at, the next UTC midnight.
+	Reset    int64                 `json:"reset"`
+	Requests QuotaUsage            `json:"requests"`
+	APIKeys  []APIKeyUsageResponse `json:"api_keys"`
+}
+
+// QuotaUsage is the requests counted against a daily quota. Limit and Remaining are
+// left out when there is no quota.
+type QuotaUsage struct {
+	Used      int64  `json:"used"`
+	Limit     *int64 `json:"limit,omitempty"`
+	Remaining *int64 `json:"remaining,omitempty"`
+}
+
+type APIKeyUsageResponse struct {
+	ID       string     `json:"id"`
+	Name     string     `json:"name"`
+	Prefix   string     `json:"prefix"`
+	Requests QuotaUsage `json:"requests"`
+}
+
+type GetUsageRequest struct {
+	UserId string `json:"-" validate:"required"`
+}
@@ -3,37 +3,63 @@ package model
 type UserResponse struct {
 	ID           string `json:"id,omitempty"`
 	Name         string `json:"name,omitempty"`
+	Email        string `json:"email,omitempty"`
 	Token        string `json:"token,omitempty"`
 	RefreshToken string `json:"refresh_token,omitempty"`
+	Region       string `json:"region,omitempty"`
+	VerifiedAt   int64  `json:"verified_at,omitempty"`
 	CreatedAt    int64  `json:"created_at,omitempty"`
 	UpdatedAt    int64  `json:"updated_at,omitempty"`
 }
 
 type VerifyUserRequest struct {
-	Token string `validate:"required,max=100"`
+	// Token is an opaque token or, with JWT access tokens, a JWT
+	Token string `validate:"required,max=2000"`
 }
 
 type RegisterUserRequest struct {
 	ID       string `json:"id" validate:"required,max=100"`
 	Password string `json:"password" validate:"required,max=100"`
 	Name     string `json:"name" validate:"required,max=100"`
+	// Email is where verification and password reset links are sent
+	Email string `json:"email,omitempty" validate:"omitempty,email,max=100"`
+	// Region to keep the user's data in, the home region when empty
+	Region string `json:"region,omitempty" validate:"max=20"`
 }
 
 type UpdateUserRequest struct {
 	ID       string `json:"-" validate:"required,max=100"`
 	Password string `json:"password,omitempty" validate:"max=100"`
 	Name     string `json:"name,omitempty" validate:"max=100"`
+	Email    string `json:"email,omitempty" validate:"omitempty,email,max=100"`
 }
 
 type LoginUserRequest struct {
 	ID       string `json:"id" validate:"required,max=100"`
 	Password string `json:"password" validate:"required,max=100"`
+	// Device names the session in the session list, e.g. "Eko's laptop". Without it
+	// the session is named after the browser and system of the user agent.
+	Device string `json:"device,omitempty" validate:"max=100"`
+	// Scopes limit the session to part of the API, e.g. ["contacts:read"]. Without
+	// them the session may do anything the user may.
+	Scopes    []string `json:"scopes,omitempty" validate:"max=20,unique,dive,oneof=account:read account:write contacts:read contacts:write addresses:read addresses:write reminders:read reminders:write webhooks:read webhooks:write admin"`
+	IP        string   `json:"-"`
+	UserAgent string   `json:"-"`
 }
 
 type LogoutUserRequest struct {
 	ID string `json:"id" validate:"required,max=100"`
 }
 
+type DeleteUserRequest struct {
+	ID string `json:"-" validate:"required,max=100"`
+}
+
+// DeleteUserResponse tells when a deleted account is purged for good, in Unix milliseconds.
+type DeleteUserResponse struct {
+	PurgeAt int64 `json:"purge_at"`
+}
+
 type GetUserRequest struct {
 	ID string `json:"id" validate:"required,max=100"`
 }
@@ -41,3 +67,76 @@ type GetUserRequest struct {
 type RefreshTokenRequest struct {
 	RefreshToken string `json:"refresh_token" validate:"required,max=100"`
 }
+
+type ForgotPasswordRequest struct {
+	Email string `json:"email" validate:"required,email,max=100"`
+}
+
+type MagicLinkRequest struct {
+	Email string `json:"email" validate:"required,email,max=100"`
+}
+
+// ExchangeMagicLinkRequest logs in with the token of a magic link email. Device and
+// Scopes work as in LoginUserRequest.
+type ExchangeMagicLinkRequest struct {
+	Token     string   `json:"token" validate:"required,max=100"`
+	Device    string   `json:"device,omitempty" validate:"max=100"`
+	Scopes    []string `json:"scopes,omitempty" validate:"max=20,unique,dive,oneof=account:read account:write contacts:read contacts:write addresses:read addresses:write reminders:read reminders:write webhooks:read webhooks:write admin"`
+	IP        string   `json:"-"`
+	UserAgent string   `json:"-"`
+}
+
+type VerifyEmailRequest struct {
+	Token string `json:"token" validate:"required,max=500"`
+}
+
+type ResendVerificationRequest struct {
+	Email string `json:"email" validate:"required,email,max=100"`
+}
+
+type ResetPasswordRequest struct {
+	Token    string `json:"token" validate:"required,max=100"`
+	Password string `json:"password" validate:"required,max=100"`
+}
+
+// SessionResponse is one login of the user. Current marks the session the request
+// was made with; ImpersonatorId the admin a session was issued to, so the user can
+// tell when support acted as them.
+type SessionResponse struct {
+	ID             string   `json:"id"`
+	Device         string   `json:"device"`
+	IP             string   `json:"ip"`
+	UserAgent      string   `json:"user_agent"`
+	Scopes         []string `json:"scopes,omitempty"`
+	ImpersonatorId string   `json:"impersonator_id,omitempty"`
+	Current        bool     `json:"current"`
+	LastSeenAt     int64    `json:"last_seen_at"`
+	CreatedAt      int64    `json:"created_at"`
+}
+
+type ListSessionRequest struct {
+	UserId    string `json:"-" validate:"required"`
+	SessionId string `json:"-"`
+}
+
+type RevokeSessionRequest struct {
+	UserId string `json:"-" validate:"required"`
+	ID     string `json:"-" validate:"required,max=100,uuid"`
+}
+
+type RevokeUserSessionsRequest struct {
+	UserId string `json:"-" validate:"required,max=100"`
+}
+
+type ImpersonateUserRequest struct {
+	AdminId string `json:"-" validate:"required,max=100"`
+	UserId  string `json:"-" validate:"required,max=100"`
+}
+
+// ImpersonationResponse is an access token acting as UserId on behalf of the admin,
+// valid until ExpiresAt in Unix milliseconds. It has no refresh token.
+type ImpersonationResponse struct {
+	UserId    string `json:"user_id"`
+	Token     string `json:"token"`
+	ExpiresAt int64  `json:"expires_at"`
+}
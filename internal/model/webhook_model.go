@@ -0,0 +1,94 @@
+package model
+
+const (
+	WebhookDeliverySucceeded = "succeeded"
+	WebhookDeliveryFailed    = "failed"
+)
+
+type WebhookResponse struct {
+	ID         string   `json:"id"`
+	URL        string   `json:"url"`
+	EventTypes []string `json:"event_types"`
+	Active     bool     `json:"active"`
+	CreatedAt  int64    `json:"created_at"`
+	UpdatedAt  int64    `json:"updated_at"`
+	// Secret verifies the Webhook-Signature of the deliveries. It is only returned
+	// when the webhook is registered and when its secret is rotated.
+	Secret string `json:"secret,omitempty"`
+}
+
+type WebhookDeliveryResponse struct {
+	ID              string `json:"id"`
+	WebhookId       string `json:"webhook_id"`
+	EventId         string `json:"event_id"`
+	EventType       string `json:"event_type"`
+	Attempt         int    `json:"attempt"`
+	Status          string `json:"status"`
+	ResponseStatus  int    `json:"response_status"`
+	LatencyMs       int64  `json:"latency_ms"`
+	ResponseSnippet string `json:"response_snippet,omitempty"`
+	Error           string `json:"error,omitempty"`
+	ReplayOf        string `json:"replay_of,omitempty"`
+	NextAttemptAt   *int64 `json:"next_attempt_at,omitempty"`
+	CreatedAt       int64  `json:"created_at"`
+}
+
+type CreateWebhookRequest struct {
+	UserId     string   `json:"-" validate:"required"`
+	URL        string   `json:"url" validate:"required,max=2000,http_url"`
+	EventTypes []string `json:"event_types" validate:"max=50,dive,required,max=200"`
+}
+
+type UpdateWebhookRequest struct {
+	UserId     string   `json:"-" validate:"required"`
+	ID         string   `json:"-" validate:"required,max=100,entity_id"`
+	URL        string   `json:"url" validate:"required,max=2000,http_url"`
+	EventTypes []string `json:"event_types" validate:"max=50,dive,required,max=200"`
+	Active     bool     `json:"active"`
+}
+
+type GetWebhookRequest struct {
+	UserId string `json:"-" validate:"required"`
+	ID     string `json:"-" validate:"required,max=100,entity_id"`
+}
+
+type DeleteWebhookRequest struct {
+	UserId string `json:"-" validate:"required"`
+	ID     string `json:"-" validate:"required,max=100,entity_id"`
+}
+
+type RotateWebhookSecretRequest struct {
+	UserId string `json:"-" validate:"required"`
+	ID     string `json:"-" validate:"required,max=100,entity_id"`
+}
+
+type ListWebhookRequest struct {
+	UserId string `json:"-" validate:"required"`
+}
+
+type SearchWebhookDeliveryRequest struct {
+	UserId    string `json:"-" validate:"required"`
+	WebhookId string `json:"-" validate:"required,max=100,entity_id"`
+	Status    string `json:"-" validate:"omitempty,oneof=succeeded failed"`
+	Page      int    `json:"page" validate:"min=1,page_number"`
+	Size      int    `json:"size" validate:"min=1,page_size"`
+}
+
+type ReplayWebhookDeliveryRequest struct {
+	UserId    string `json:"-" validate:"required"`
+	WebhookId string `json:"-" validate:"required,max=100,entity_id"`
+	ID        string `json:"-" validate:"required,max=100,entity_id"`
+}
+
+// ReplayFailedWebhookDeliveriesRequest replays every event whose deliveries created
+// between From and To (unix milliseconds) failed and were never delivered since.
+type ReplayFailedWebhookDeliveriesRequest struct {
+	UserId    string `json:"-" validate:"required"`
+	WebhookId string `json:"-" validate:"required,max=100,entity_id"`
+	From      int64  `json:"from" validate:"min=0"`
+	To        int64  `json:"to" validate:"required,gtfield=From"`
+}
+
+type ReplayFailedWebhookDeliveriesResponse struct {
+	Replayed int `json:"replayed"`
+}
@@ -0,0 +1,137 @@
+// Package password checks new passwords against the password policy and hashes them
+// with the configured algorithm. Hashes record their algorithm and parameters, so
+// hashes made under an older configuration keep verifying and can be upgraded on login.
+package password
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	// Bcrypt is the default algorithm.
+	Bcrypt = "bcrypt"
+	// Argon2id is memory-hard, which makes guessing with GPUs expensive.
+	Argon2id = "argon2id"
+)
+
+// ErrMismatch is returned by Compare when the password does not match the hash.
+var ErrMismatch = errors.New("password does not match")
+
+// Argon2Params are the cost parameters of Argon2id. Memory is in KiB.
+type Argon2Params struct {
+	Memory      uint32
+	Iterations  uint32
+	Parallelism uint8
+	SaltLength  uint32
+	KeyLength   uint32
+}
+
+// Hasher hashes passwords with Algorithm. The zero Hasher uses bcrypt at its default cost.
+type Hasher struct {
+	Algorithm  string
+	BcryptCost int
+	Argon2     Argon2Params
+}
+
+// Hash returns the hash of password in the encoding of its algorithm.
+func (h Hasher) Hash(password string) (string, error) {
+	switch h.Algorithm {
+	case "", Bcrypt:
+		hash, err := bcrypt.GenerateFromPassword([]byte(password), h.bcryptCost())
+		return string(hash), err
+	case Argon2id:
+		salt := make([]byte, h.Argon2.SaltLength)
+		if _, err := rand.Read(salt); err != nil {
+			return "", err
+		}
+		key := argon2.IDKey([]byte(password), salt, h.Argon2.Iterations, h.Argon2.Memory, h.Argon2.Parallelism, h.Argon2.KeyLength)
+		return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s", argon2.Version, h.Argon2.Memory, h.Argon2.Iterations, h.Argon2.Parallelism,
+			base64.RawStdEncoding.EncodeToString(salt), base64.RawStdEncoding.EncodeToString(key)), nil
+	default:
+		return "", fmt.Errorf("unknown password hashing algorithm %q", h.Algorithm)
+	}
+}
+
+// Compare checks password against a hash of either algorithm, whatever the configured
+// one is. It returns ErrMismatch when they do not match.
+func (h Hasher) Compare(hash string, password string) error {
+	if !strings.HasPrefix(hash, "$argon2id$") {
+		err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
+		if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+			return ErrMismatch
+		}
+		return err
+	}
+
+	params, salt, key, err := decodeArgon2(hash)
+	if err != nil {
+		return err
+	}
+	actual := argon2.IDKey([]byte(password), salt, params.Iterations, params.Memory, params.Parallelism, uint32(len(key)))
+	if subtle.ConstantTimeCompare(actual, key) != 1 {
+		return ErrMismatch
+	}
+	return nil
+}
+
+// NeedsRehash reports whether hash was made with another algorithm or other parameters
+// than the configured ones.
+func (h Hasher) NeedsRehash(hash string) bool {
+	switch h.Algorithm {
+	case "", Bcrypt:
+		cost, err := bcrypt.Cost([]byte(hash))
+		return err != nil || cost != h.bcryptCost()
+	case Argon2id:
+		params, salt, key, err := decodeArgon2(hash)
+		return err != nil || params.Memory != h.Argon2.Memory || params.Iterations != h.Argon2.Iterations ||
+			params.Parallelism != h.Argon2.Parallelism || uint32(len(salt)) != h.Argon2.SaltLength || uint32(len(key)) != h.Argon2.KeyLength
+	default:
+		return false
+	}
+}
+
+func (h Hasher) bcryptCost() int {
+	if h.BcryptCost == 0 {
+		return bcrypt.DefaultCost
+	}
+	return h.BcryptCost
+}
+
+// decodeArgon2 reads a hash written by Hash, "$argon2id$v=19$m=...,t=...,p=...$salt$key".
+func decodeArgon2(hash string) (Argon2Params, []byte, []byte, error) {
+	var params Argon2Params
+
+	parts := strings.Split(hash, "$")
+	if len(parts) != 6 || parts[1] != Argon2id {
+		return params, nil, nil, errors.New("invalid argon2id hash")
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return params, nil, nil, errors.New("unsupported argon2id version")
+	}
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &params.Memory, &params.Iterations, &params.Parallelism); err != nil {
+		return params, nil, nil, fmt.Errorf("invalid argon2id parameters: %w", err)
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return params, nil, nil, fmt.Errorf("invalid argon2id salt: %w", err)
+	}
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil {
+		return params, nil, nil, fmt.Errorf("invalid argon2id key: %w", err)
+	}
+
+	params.SaltLength = uint32(len(salt))
+	params.KeyLength = uint32(len(key))
+	return params, salt, key, nil
+}
@@ -0,0 +1,91 @@
+package password
+
+import (
+	"bufio"
+	_ "embed"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// common is a list of the most used passwords, one per line.
+//
+//go:embed common.txt
+var common string
+
+// Policy is what a new password must satisfy. The zero Policy accepts any password.
+type Policy struct {
+	MinLength     int
+	RequireUpper  bool
+	RequireLower  bool
+	RequireDigit  bool
+	RequireSymbol bool
+	// Common holds the lowercased passwords refused as too easy to guess, nil to allow them.
+	Common map[string]struct{}
+}
+
+// CommonPasswords returns the built-in list of common passwords, lowercased, together
+// with the ones read from extra, if any.
+func CommonPasswords(extra io.Reader) (map[string]struct{}, error) {
+	passwords := make(map[string]struct{})
+
+	readers := []io.Reader{strings.NewReader(common)}
+	if extra != nil {
+		readers = append(readers, extra)
+	}
+	for _, reader := range readers {
+		scanner := bufio.NewScanner(reader)
+		for scanner.Scan() {
+			if line := strings.TrimSpace(scanner.Text()); line != "" {
+				passwords[strings.ToLower(line)] = struct{}{}
+			}
+		}
+		if err := scanner.Err(); err != nil {
+			return nil, err
+		}
+	}
+
+	return passwords, nil
+}
+
+// Check returns why password breaks the policy, or nil when it does not. The message
+// is meant for the user choosing the password.
+func (p Policy) Check(password string) error {
+	if utf8.RuneCountInString(password) < p.MinLength {
+		return fmt.Errorf("password must be at least %d characters long", p.MinLength)
+	}
+
+	var upper, lower, digit, symbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsDigit(r):
+			digit = true
+		case unicode.IsPunct(r) || unicode.IsSymbol(r) || unicode.IsSpace(r):
+			symbol = true
+		}
+	}
+
+	switch {
+	case p.RequireUpper && !upper:
+		return errors.New("password must contain an uppercase letter")
+	case p.RequireLower && !lower:
+		return errors.New("password must contain a lowercase letter")
+	case p.RequireDigit && !digit:
+		return errors.New("password must contain a digit")
+	case p.RequireSymbol && !symbol:
+		return errors.New("password must contain a symbol")
+	}
+
+	if _, found := p.Common[strings.ToLower(password)]; found {
+		return errors.New("password is too common")
+	}
+
+	return nil
+}
@@ -0,0 +1,104 @@
+// Package region routes database work to the database of the region a user's
+// data lives in, so deployments can keep EU and US data in separate databases.
+package region
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"go-rest-scaffold/internal/model"
+	"sort"
+)
+
+// ErrUnknownRegion is returned for work pinned to a region this instance has no
+// database for. Nothing falls back to another region.
+var ErrUnknownRegion = errors.New("region: no database for the requested region")
+
+// Router is a GORM connection pool that picks the regional database from the
+// region the statement context is pinned to, see model.WithRegion. Transactions
+// stay on the database they were started on.
+type Router struct {
+	Home   string
+	pools  map[string]*sql.DB
+	denied *sql.DB
+}
+
+// NewRouter routes to pools by region name; pools must contain home.
+func NewRouter(home string, pools map[string]*sql.DB) *Router {
+	return &Router{
+		Home:   home,
+		pools:  pools,
+		denied: sql.OpenDB(deniedConnector{}),
+	}
+}
+
+// Regions lists the configured regions, the home region first.
+func (r *Router) Regions() []string {
+	regions := make([]string, 0, len(r.pools))
+	for name := range r.pools {
+		if name != r.Home {
+			regions = append(regions, name)
+		}
+	}
+	sort.Strings(regions)
+	return append([]string{r.Home}, regions...)
+}
+
+// Pools returns the database of every region.
+func (r *Router) Pools() map[string]*sql.DB {
+	return r.pools
+}
+
+func (r *Router) pool(ctx context.Context) *sql.DB {
+	name := model.RegionFrom(ctx)
+	if name == "" {
+		name = r.Home
+	}
+	if pool, ok := r.pools[name]; ok {
+		return pool
+	}
+	return r.denied
+}
+
+func (r *Router) PrepareContext(ctx context.Context, query string) (*sql.Stmt, error) {
+	return r.pool(ctx).PrepareContext(ctx, query)
+}
+
+func (r *Router) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	return r.pool(ctx).ExecContext(ctx, query, args...)
+}
+
+func (r *Router) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	return r.pool(ctx).QueryContext(ctx, query, args...)
+}
+
+func (r *Router) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	return r.pool(ctx).QueryRowContext(ctx, query, args...)
+}
+
+func (r *Router) BeginTx(ctx context.Context, opts *sql.TxOptions) (*sql.Tx, error) {
+	return r.pool(ctx).BeginTx(ctx, opts)
+}
+
+// GetDBConn returns the home database, for the callers of gorm.DB.DB().
+func (r *Router) GetDBConn() (*sql.DB, error) {
+	return r.pools[r.Home], nil
+}
+
+// deniedConnector backs the pool of unknown regions: every connection attempt fails.
+type deniedConnector struct{}
+
+func (deniedConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	return nil, ErrUnknownRegion
+}
+
+func (deniedConnector) Driver() driver.Driver {
+	return deniedDriver{}
+}
+
+type deniedDriver struct{}
+
+func (deniedDriver) Open(name string) (driver.Conn, error) {
+	return nil, ErrUnknownRegion
+}
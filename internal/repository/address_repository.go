@@ -29,3 +29,20 @@ func (r *AddressRepository) FindAllByContactId(tx *gorm.DB, contactId string) ([
 	}
 	return addresses, nil
 }
+
+// FindDeletedByIdAndUserId finds an address in the trash that belongs to a contact of the user.
+func (r *AddressRepository) FindDeletedByIdAndUserId(db *gorm.DB, address *entity.Address, id string, userId string) error {
+	return db.Unscoped().
+		Where("id = ? AND deleted_at IS NOT NULL", id).
+		Where("contact_id IN (?)", db.Unscoped().Model(&entity.Contact{}).Select("id").Where("user_id = ?", userId)).
+		Take(address).Error
+}
+
+func (r *AddressRepository) CountByUserId(db *gorm.DB, userId string) (int64, error) {
+	var total int64
+	err := db.Model(&entity.Address{}).
+		Joins("JOIN contacts ON contacts.id = addresses.contact_id").
+		Where("contacts.user_id = ? AND contacts.deleted_at IS NULL", userId).
+		Count(&total).Error
+	return total, err
+}
@@ -0,0 +1,44 @@
+package repository
+
+import (
+	"go-rest-scaffold/internal/entity"
+	"go-rest-scaffold/internal/model"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+type AnnouncementRepository struct {
+	Repository[entity.Announcement]
+	Log *logrus.Logger
+}
+
+func NewAnnouncementRepository(log *logrus.Logger) *AnnouncementRepository {
+	return &AnnouncementRepository{
+		Log: log,
+	}
+}
+
+// FindActive returns the announcements whose display window contains now, most severe first.
+func (r *AnnouncementRepository) FindActive(db *gorm.DB, now int64) ([]entity.Announcement, error) {
+	var announcements []entity.Announcement
+	err := db.Where("starts_at <= ? AND (ends_at = 0 OR ends_at > ?)", now, now).
+		Order("CASE level WHEN 'critical' THEN 0 WHEN 'warning' THEN 1 ELSE 2 END").
+		Order("starts_at DESC").
+		Find(&announcements).Error
+	return announcements, err
+}
+
+func (r *AnnouncementRepository) Search(db *gorm.DB, request *model.SearchAnnouncementRequest) ([]entity.Announcement, int64, error) {
+	var announcements []entity.Announcement
+	if err := db.Order("starts_at DESC").Offset((request.Page - 1) * request.Size).Limit(request.Size).Find(&announcements).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var total int64 = 0
+	if err := db.Model(&entity.Announcement{}).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	return announcements, total, nil
+}
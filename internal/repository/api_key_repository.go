@@ -0,0 +1,49 @@
+package repository
+
+import (
+	"go-rest-scaffold/internal/entity"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+type APIKeyRepository struct {
+	Repository[entity.APIKey]
+	Log *logrus.Logger
+}
+
+func NewAPIKeyRepository(log *logrus.Logger) *APIKeyRepository {
+	return &APIKeyRepository{
+		Log: log,
+	}
+}
+
+func (r *APIKeyRepository) FindByIdAndUserId(db *gorm.DB, apiKey *entity.APIKey, id string, userId string) error {
+	return db.Where("id = ? AND user_id = ?", id, userId).Take(apiKey).Error
+}
+
+func (r *APIKeyRepository) FindAllByUserId(db *gorm.DB, userId string) ([]entity.APIKey, error) {
+	var apiKeys []entity.APIKey
+	if err := db.Where("user_id = ?", userId).Order("created_at").Find(&apiKeys).Error; err != nil {
+		return nil, err
+	}
+	return apiKeys, nil
+}
+
+// FindUnrevokedByKeyHash returns the key with the given hash unless it has been revoked.
+func (r *APIKeyRepository) FindUnrevokedByKeyHash(db *gorm.DB, apiKey *entity.APIKey, keyHash string) error {
+	return db.Where("key_hash = ? AND revoked_at IS NULL", keyHash).Take(apiKey).Error
+}
+
+// RevokeAllByUserId revokes every key of the user that is not revoked yet.
+func (r *APIKeyRepository) RevokeAllByUserId(db *gorm.DB, userId string, revokedAt int64) error {
+	return db.Model(&entity.APIKey{}).Where("user_id = ? AND revoked_at IS NULL", userId).Update("revoked_at", revokedAt).Error
+}
+
+// TouchLastUsed sets the last use of a key to usedAt unless it was already recorded
+// after staleBefore, so a busy key is not written on every request.
+func (r *APIKeyRepository) TouchLastUsed(db *gorm.DB, id string, usedAt int64, staleBefore int64) error {
+	return db.Model(&entity.APIKey{}).
+		Where("id = ? AND (last_used_at IS NULL OR last_used_at < ?)", id, staleBefore).
+		Update("last_used_at", usedAt).Error
+}
@@ -0,0 +1,53 @@
+package repository
+
+import (
+	"go-rest-scaffold/internal/entity"
+	"go-rest-scaffold/internal/model"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+type AuditLogRepository struct {
+	Repository[entity.AuditLog]
+	Log *logrus.Logger
+}
+
+func NewAuditLogRepository(log *logrus.Logger) *AuditLogRepository {
+	return &AuditLogRepository{
+		Log: log,
+	}
+}
+
+func (r *AuditLogRepository) Search(db *gorm.DB, request *model.SearchAuditLogRequest) ([]entity.AuditLog, int64, error) {
+	var auditLogs []entity.AuditLog
+	if err := db.Scopes(r.FilterAuditLog(request)).Order("created_at DESC").
+		Offset((request.Page - 1) * request.Size).Limit(request.Size).Find(&auditLogs).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var total int64 = 0
+	if err := db.Model(&entity.AuditLog{}).Scopes(r.FilterAuditLog(request)).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	return auditLogs, total, nil
+}
+
+func (r *AuditLogRepository) FilterAuditLog(request *model.SearchAuditLogRequest) func(tx *gorm.DB) *gorm.DB {
+	return func(tx *gorm.DB) *gorm.DB {
+		if request.UserId != "" {
+			tx = tx.Where("user_id = ?", request.UserId)
+		}
+		if request.EntityType != "" {
+			tx = tx.Where("entity_type = ?", request.EntityType)
+		}
+		if request.From > 0 {
+			tx = tx.Where("created_at >= ?", request.From)
+		}
+		if request.To > 0 {
+			tx = tx.Where("created_at < ?", request.To)
+		}
+		return tx
+	}
+}
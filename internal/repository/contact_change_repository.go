@@ -0,0 +1,75 @@
+package repository
+
+import (
+	"go-rest-scaffold/internal/entity"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+type ContactChangeRepository struct {
+	Repository[entity.ContactChange]
+	Log *logrus.Logger
+}
+
+func NewContactChangeRepository(log *logrus.Logger) *ContactChangeRepository {
+	return &ContactChangeRepository{
+		Log: log,
+	}
+}
+
+// CreateForUser records a change of every contact of the user, for writes that replace
+// many contacts at once such as an account import.
+func (r *ContactChangeRepository) CreateForUser(db *gorm.DB, userId string) error {
+	return db.Exec(`INSERT INTO contact_changes (user_id, contact_id, created_at)
+SELECT user_id, id, ? FROM contacts WHERE user_id = ? AND deleted_at IS NULL`, time.Now().UnixMilli(), userId).Error
+}
+
+// FindByUserIdAfter returns up to limit changes of the user with an offset above offset, oldest first.
+func (r *ContactChangeRepository) FindByUserIdAfter(db *gorm.DB, userId string, offset int64, limit int) ([]entity.ContactChange, error) {
+	var changes []entity.ContactChange
+	err := db.Where("user_id = ? AND id > ?", userId, offset).Order("id").Limit(limit).Find(&changes).Error
+	return changes, err
+}
+
+// Head returns the offset of the latest change recorded, or 0 before the first one.
+func (r *ContactChangeRepository) Head(db *gorm.DB) (int64, error) {
+	var head int64
+	var err error
+	switch db.Dialector.Name() {
+	case "mysql":
+		// the AUTO_INCREMENT of information_schema is cached, so the head is the newest
+		// change, which DeleteCreatedBefore keeps
+		err = db.Model(&entity.ContactChange{}).Select("coalesce(max(id), 0)").Scan(&head).Error
+	case "sqlite":
+		err = db.Raw("SELECT coalesce((SELECT seq FROM sqlite_sequence WHERE name = 'contact_changes'), 0)").Scan(&head).Error
+	default:
+		err = db.Raw("SELECT CASE WHEN is_called THEN last_value ELSE 0 END FROM contact_changes_id_seq").Scan(&head).Error
+	}
+	return head, err
+}
+
+// Horizon returns the offset up to which changes were pruned: a stream resumed from
+// an offset below it would have missed changes.
+func (r *ContactChangeRepository) Horizon(db *gorm.DB) (int64, error) {
+	var oldest *int64
+	if err := db.Model(&entity.ContactChange{}).Select("MIN(id)").Scan(&oldest).Error; err != nil {
+		return 0, err
+	}
+	if oldest != nil {
+		return *oldest - 1, nil
+	}
+	return r.Head(db)
+}
+
+// DeleteCreatedBefore prunes the changes recorded before createdBefore, except the
+// newest one, and returns how many were deleted.
+func (r *ContactChangeRepository) DeleteCreatedBefore(db *gorm.DB, createdBefore time.Time) (int64, error) {
+	var newest int64
+	if err := db.Model(&entity.ContactChange{}).Select("coalesce(max(id), 0)").Scan(&newest).Error; err != nil {
+		return 0, err
+	}
+	result := db.Where("created_at < ? AND id < ?", createdBefore.UnixMilli(), newest).Delete(&entity.ContactChange{})
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,55 @@
+package repository
+
+import (
+	"go-rest-scaffold/internal/entity"
+	"go-rest-scaffold/internal/model"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+type ContactImportRepository struct {
+	Repository[entity.ContactImport]
+	Log *logrus.Logger
+}
+
+func NewContactImportRepository(log *logrus.Logger) *ContactImportRepository {
+	return &ContactImportRepository{
+		Log: log,
+	}
+}
+
+func (r *ContactImportRepository) FindByIdAndUserId(db *gorm.DB, contactImport *entity.ContactImport, id string, userId string) error {
+	return db.Where("id = ? AND user_id = ?", id, userId).Take(contactImport).Error
+}
+
+// FindRunnable returns up to limit imports to run, oldest first: the pending ones and
+// the running ones whose progress was last saved before staleBefore, whose run died.
+func (r *ContactImportRepository) FindRunnable(db *gorm.DB, staleBefore int64, limit int) ([]entity.ContactImport, error) {
+	var imports []entity.ContactImport
+	err := db.Where("status = ? OR (status = ? AND updated_at < ?)", model.ContactImportPending, model.ContactImportRunning, staleBefore).
+		Order("created_at").Limit(limit).Find(&imports).Error
+	return imports, err
+}
+
+// Claim marks the import running unless its row changed since it was read at
+// updatedAt, and reports whether it did, so two runs never take the same import.
+func (r *ContactImportRepository) Claim(db *gorm.DB, contactImport *entity.ContactImport, updatedAt int64) (bool, error) {
+	result := db.Model(contactImport).Where("updated_at = ?", updatedAt).
+		Updates(map[string]any{"status": model.ContactImportRunning, "updated_at": contactImport.UpdatedAt})
+	return result.RowsAffected == 1, result.Error
+}
+
+// SaveProgress saves the counters and row errors of a running import, and its status,
+// data and completion time once it finished.
+func (r *ContactImportRepository) SaveProgress(db *gorm.DB, contactImport *entity.ContactImport) error {
+	return db.Model(contactImport).Select("status", "data", "processed_rows", "imported", "failed", "errors", "error",
+		"updated_at", "completed_at").Updates(contactImport).Error
+}
+
+// DeleteFinishedBefore deletes the imports that finished before completedBefore and
+// returns how many.
+func (r *ContactImportRepository) DeleteFinishedBefore(db *gorm.DB, completedBefore int64) (int64, error) {
+	result := db.Where("completed_at < ?", completedBefore).Delete(&entity.ContactImport{})
+	return result.RowsAffected, result.Error
+}
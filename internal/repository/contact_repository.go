@@ -1,13 +1,28 @@
 package repository
 
 import (
+	"database/sql"
 	"go-rest-scaffold/internal/entity"
 	"go-rest-scaffold/internal/model"
+	"strings"
 
 	"github.com/sirupsen/logrus"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
+// ContactColumns lists the contact fields that can be used in filter and sort
+// specifications. Email and phone are the primary ones, "" when there is none.
+var ContactColumns = Columns{
+	"id":         "id",
+	"first_name": "first_name",
+	"last_name":  "last_name",
+	"email":      "coalesce((SELECT email FROM contact_emails WHERE contact_emails.contact_id = contacts.id AND is_primary), '')",
+	"phone":      "coalesce((SELECT phone FROM contact_phones WHERE contact_phones.contact_id = contacts.id AND is_primary), '')",
+	"created_at": "created_at",
+	"updated_at": "updated_at",
+}
+
 type ContactRepository struct {
 	Repository[entity.Contact]
 	Log *logrus.Logger
@@ -19,13 +34,111 @@ func NewContactRepository(log *logrus.Logger) *ContactRepository {
 	}
 }
 
+// withChannels preloads the emails and phone numbers of the contacts, in their order.
+func withChannels(db *gorm.DB) *gorm.DB {
+	return db.Preload("Emails", func(db *gorm.DB) *gorm.DB {
+		return db.Order("position")
+	}).Preload("Phones", func(db *gorm.DB) *gorm.DB {
+		return db.Order("position")
+	})
+}
+
 func (r *ContactRepository) FindByIdAndUserId(db *gorm.DB, contact *entity.Contact, id string, userId string) error {
-	return db.Where("id = ? AND user_id = ?", id, userId).Take(contact).Error
+	return db.Scopes(withChannels).Where("id = ? AND user_id = ?", id, userId).Take(contact).Error
+}
+
+// FindByIdAndUserIdShared is FindByIdAndUserId with concurrent lookups of the same contact collapsed into one query.
+func (r *ContactRepository) FindByIdAndUserIdShared(db *gorm.DB, contact *entity.Contact, id string, userId string) error {
+	return r.Shared(db, userId+":"+id, contact, func(db *gorm.DB, result *entity.Contact) error {
+		return r.FindByIdAndUserId(db, result, id, userId)
+	})
+}
+
+// FindAllByUserId returns every contact of a user with its addresses, oldest first.
+func (r *ContactRepository) FindAllByUserId(db *gorm.DB, userId string) ([]entity.Contact, error) {
+	var contacts []entity.Contact
+	err := db.Scopes(withChannels).Preload("Addresses", func(db *gorm.DB) *gorm.DB {
+		return db.Order("created_at, id")
+	}).Where("user_id = ?", userId).Order("created_at, id").Find(&contacts).Error
+	return contacts, err
+}
+
+// FindPageByUserIdAfterId returns up to limit contacts of a user with their addresses,
+// ordered by id and starting after afterId, for walking all contacts in batches.
+func (r *ContactRepository) FindPageByUserIdAfterId(db *gorm.DB, userId string, afterId string, limit int) ([]entity.Contact, error) {
+	var contacts []entity.Contact
+	err := db.Scopes(withChannels).Preload("Addresses", func(db *gorm.DB) *gorm.DB {
+		return db.Order("created_at, id")
+	}).Where("user_id = ? AND id > ?", userId, afterId).Order("id").Limit(limit).Find(&contacts).Error
+	return contacts, err
+}
+
+// FindPageAfterId returns up to limit contacts of every user, ordered by id and
+// starting after afterId, for walking the whole table in batches.
+func (r *ContactRepository) FindPageAfterId(db *gorm.DB, afterId string, limit int) ([]entity.Contact, error) {
+	var contacts []entity.Contact
+	err := db.Scopes(withChannels).Where("id > ?", afterId).Order("id").Limit(limit).Find(&contacts).Error
+	return contacts, err
+}
+
+// FindByIdsAndUserId returns the contacts of a user among ids with their addresses.
+// Ids of deleted or unknown contacts are left out.
+func (r *ContactRepository) FindByIdsAndUserId(db *gorm.DB, ids []string, userId string) ([]entity.Contact, error) {
+	var contacts []entity.Contact
+	err := db.Scopes(withChannels).Preload("Addresses", func(db *gorm.DB) *gorm.DB {
+		return db.Order("created_at, id")
+	}).Where("id IN ? AND user_id = ?", ids, userId).Find(&contacts).Error
+	return contacts, err
+}
+
+// FindDeletedByIdAndUserId finds a contact of the user that is in the trash.
+func (r *ContactRepository) FindDeletedByIdAndUserId(db *gorm.DB, contact *entity.Contact, id string, userId string) error {
+	return db.Unscoped().Scopes(withChannels).Where("id = ? AND user_id = ? AND deleted_at IS NOT NULL", id, userId).Take(contact).Error
+}
+
+// ReplaceChannels replaces the stored emails and phone numbers of contact with the
+// ones it holds, which are numbered in their order.
+func (r *ContactRepository) ReplaceChannels(db *gorm.DB, contact *entity.Contact) error {
+	if err := db.Where("contact_id = ?", contact.ID).Delete(&entity.ContactEmail{}).Error; err != nil {
+		return err
+	}
+	if err := db.Where("contact_id = ?", contact.ID).Delete(&entity.ContactPhone{}).Error; err != nil {
+		return err
+	}
+
+	for i := range contact.Emails {
+		contact.Emails[i].ContactId = contact.ID
+		contact.Emails[i].Position = i
+	}
+	for i := range contact.Phones {
+		contact.Phones[i].ContactId = contact.ID
+		contact.Phones[i].Position = i
+	}
+	if len(contact.Emails) > 0 {
+		if err := db.Create(&contact.Emails).Error; err != nil {
+			return err
+		}
+	}
+	if len(contact.Phones) > 0 {
+		if err := db.Create(&contact.Phones).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// DeleteAllByUserId moves every contact of the user to the trash.
+func (r *ContactRepository) DeleteAllByUserId(db *gorm.DB, userId string) error {
+	return db.Where("user_id = ?", userId).Delete(&entity.Contact{}).Error
+}
+
+func (r *ContactRepository) CountByUserId(db *gorm.DB, userId string) (int64, error) {
+	return r.Count(db, "user_id = ?", userId)
 }
 
 func (r *ContactRepository) Search(db *gorm.DB, request *model.SearchContactRequest) ([]entity.Contact, int64, error) {
 	var contacts []entity.Contact
-	if err := db.Scopes(r.FilterContact(request)).Offset((request.Page - 1) * request.Size).Limit(request.Size).Find(&contacts).Error; err != nil {
+	if err := db.Scopes(withChannels, r.FilterContact(request), SortSpecification(request.Sort, ContactColumns)).Offset(request.Offset()).Limit(request.Size).Find(&contacts).Error; err != nil {
 		return nil, 0, err
 	}
 
@@ -37,6 +150,95 @@ func (r *ContactRepository) Search(db *gorm.DB, request *model.SearchContactRequ
 	return contacts, total, nil
 }
 
+// SearchByCursor returns, in the order of sort, the first request.Size+1 contacts
+// matching the request after the cursor, or the last ones before it when it points
+// backwards, nearest first. The extra contact tells whether there is another page.
+func (r *ContactRepository) SearchByCursor(db *gorm.DB, request *model.SearchContactRequest, sort []model.SortField, cursor *model.Cursor) ([]entity.Contact, error) {
+	var values []any
+	before := false
+	if cursor != nil {
+		values = cursor.Values
+		before = cursor.Before
+	}
+
+	var contacts []entity.Contact
+	err := db.Scopes(withChannels, r.FilterContact(request), KeysetSpecification(sort, values, before, ContactColumns)).Limit(request.Size + 1).Find(&contacts).Error
+	return contacts, err
+}
+
+// ContactSortKey returns the values of the fields of contact, a row's position in a keyset order.
+func ContactSortKey(contact *entity.Contact, fields []model.SortField) []any {
+	values := make([]any, len(fields))
+	for i, field := range fields {
+		switch field.Field {
+		case "id":
+			values[i] = contact.ID
+		case "first_name":
+			values[i] = contact.FirstName
+		case "last_name":
+			values[i] = contact.LastName
+		case "email":
+			values[i] = contact.PrimaryEmail()
+		case "phone":
+			values[i] = contact.PrimaryPhone()
+		case "created_at":
+			values[i] = contact.CreatedAt
+		case "updated_at":
+			values[i] = contact.UpdatedAt
+		}
+	}
+	return values
+}
+
+// Suggest returns the contacts of a user whose first name, last name or any email
+// starts with prefix, ignoring case, with their primary email. First name matches
+// rank first, then last name, then email, and the most recently updated contacts
+// first within each rank. The lower() expressions match the prefix indexes of the
+// contacts and contact_emails tables.
+func (r *ContactRepository) Suggest(db *gorm.DB, userId string, prefix string, limit int) ([]entity.Contact, error) {
+	pattern := likeEscaper.Replace(strings.ToLower(prefix)) + "%"
+	like := " LIKE @pattern" + likeEscape(db)
+
+	var contacts []entity.Contact
+	err := db.Select("id", "first_name", "last_name").
+		Preload("Emails", "is_primary = ?", true).
+		Where("user_id = ?", userId).
+		Where("lower(first_name)"+like+" OR lower(last_name)"+like+
+			" OR id IN (SELECT contact_id FROM contact_emails WHERE lower(email)"+like+")", sql.Named("pattern", pattern)).
+		Order(clause.Expr{
+			SQL:  "CASE WHEN lower(first_name) LIKE ?" + likeEscape(db) + " THEN 0 WHEN lower(last_name) LIKE ?" + likeEscape(db) + " THEN 1 ELSE 2 END, updated_at DESC, id",
+			Vars: []any{pattern, pattern},
+		}).
+		Limit(limit).Find(&contacts).Error
+	return contacts, err
+}
+
+// startsWithLetter is true for the contacts whose first name starts with a letter of
+// model.ContactIndex, in the SQL of the database of db.
+func startsWithLetter(db *gorm.DB) string {
+	switch db.Dialector.Name() {
+	case "mysql":
+		return "lower(first_name) REGEXP '^[a-z]'"
+	case "sqlite":
+		return "lower(substr(first_name, 1, 1)) BETWEEN 'a' AND 'z'"
+	default:
+		return "lower(first_name) ~ '^[a-z]'"
+	}
+}
+
+// initialExpression buckets a contact by the upper-cased first letter of its first name, or model.ContactIndexOther.
+func initialExpression(db *gorm.DB) string {
+	return "CASE WHEN " + startsWithLetter(db) + " THEN upper(substr(first_name, 1, 1)) ELSE '" + model.ContactIndexOther + "' END"
+}
+
+// CountByInitial counts the contacts of a user per initial of their first name.
+func (r *ContactRepository) CountByInitial(db *gorm.DB, userId string) ([]entity.ContactInitial, error) {
+	var initials []entity.ContactInitial
+	err := db.Model(&entity.Contact{}).Select(initialExpression(db)+" AS letter, count(*) AS total").
+		Where("user_id = ?", userId).Group("letter").Order("letter").Find(&initials).Error
+	return initials, err
+}
+
 func (r *ContactRepository) FilterContact(request *model.SearchContactRequest) func(tx *gorm.DB) *gorm.DB {
 	return func(tx *gorm.DB) *gorm.DB {
 		tx = tx.Where("user_id = ?", request.UserId)
@@ -46,14 +248,31 @@ func (r *ContactRepository) FilterContact(request *model.SearchContactRequest) f
 			tx = tx.Where("first_name LIKE ? OR last_name LIKE ?", name, name)
 		}
 
+		// phone and email match any of the phone numbers and emails of a contact
 		if phone := request.Phone; phone != "" {
 			phone = "%" + phone + "%"
-			tx = tx.Where("phone LIKE ?", phone)
+			tx = tx.Where("id IN (SELECT contact_id FROM contact_phones WHERE phone LIKE ?)", phone)
 		}
 
 		if email := request.Email; email != "" {
 			email = "%" + email + "%"
-			tx = tx.Where("email LIKE ?", email)
+			tx = tx.Where("id IN (SELECT contact_id FROM contact_emails WHERE email LIKE ?)", email)
+		}
+
+		// a letter is a prefix match served by the index on lower(first_name)
+		if letter := request.Letter; letter == model.ContactIndexOther {
+			tx = tx.Where("NOT (" + startsWithLetter(tx) + ")")
+		} else if letter != "" {
+			tx = tx.Where("lower(first_name) LIKE ?", strings.ToLower(letter)+"%")
+		}
+
+		for _, tag := range request.Tags {
+			tx = tx.Where("id IN (SELECT contact_tags.contact_id FROM contact_tags JOIN tags ON tags.id = contact_tags.tag_id"+
+				" WHERE tags.user_id = ? AND lower(tags.name) = lower(?))", request.UserId, tag)
+		}
+
+		if request.Filter != nil {
+			tx = tx.Scopes(FilterSpecification(request.Filter, ContactColumns))
 		}
 
 		return tx
@@ -0,0 +1,54 @@
+package repository
+
+import (
+	"go-rest-scaffold/internal/entity"
+	"go-rest-scaffold/internal/model"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+type DebugCaptureRepository struct {
+	Repository[entity.DebugCapture]
+	Log *logrus.Logger
+}
+
+func NewDebugCaptureRepository(log *logrus.Logger) *DebugCaptureRepository {
+	return &DebugCaptureRepository{
+		Log: log,
+	}
+}
+
+func (r *DebugCaptureRepository) FindUnexpiredById(db *gorm.DB, capture *entity.DebugCapture, id string, now int64) error {
+	return db.Where("id = ? AND expires_at > ?", id, now).Take(capture).Error
+}
+
+func (r *DebugCaptureRepository) Search(db *gorm.DB, request *model.SearchDebugCaptureRequest, now int64) ([]entity.DebugCapture, int64, error) {
+	var captures []entity.DebugCapture
+	if err := db.Scopes(r.FilterDebugCapture(request, now)).Order("created_at DESC").
+		Offset((request.Page - 1) * request.Size).Limit(request.Size).Find(&captures).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var total int64 = 0
+	if err := db.Model(&entity.DebugCapture{}).Scopes(r.FilterDebugCapture(request, now)).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	return captures, total, nil
+}
+
+func (r *DebugCaptureRepository) FilterDebugCapture(request *model.SearchDebugCaptureRequest, now int64) func(tx *gorm.DB) *gorm.DB {
+	return func(tx *gorm.DB) *gorm.DB {
+		tx = tx.Where("expires_at > ?", now)
+		if request.UserId != "" {
+			tx = tx.Where("user_id = ?", request.UserId)
+		}
+		return tx
+	}
+}
+
+func (r *DebugCaptureRepository) DeleteExpired(db *gorm.DB, now int64) (int64, error) {
+	result := db.Where("expires_at <= ?", now).Delete(&entity.DebugCapture{})
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,32 @@
+package repository
+
+import (
+	"go-rest-scaffold/internal/entity"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+type EmailTemplateRepository struct {
+	Repository[entity.EmailTemplate]
+	Log *logrus.Logger
+}
+
+func NewEmailTemplateRepository(log *logrus.Logger) *EmailTemplateRepository {
+	return &EmailTemplateRepository{
+		Log: log,
+	}
+}
+
+func (r *EmailTemplateRepository) FindByTenantAndName(db *gorm.DB, template *entity.EmailTemplate, tenant string, name string) error {
+	return db.Where("tenant = ? AND name = ?", tenant, name).Take(template).Error
+}
+
+// FindByTenants returns the overrides of the given tenants, for every template name.
+func (r *EmailTemplateRepository) FindByTenants(db *gorm.DB, tenants []string) ([]entity.EmailTemplate, error) {
+	var templates []entity.EmailTemplate
+	if err := db.Where("tenant IN ?", tenants).Find(&templates).Error; err != nil {
+		return nil, err
+	}
+	return templates, nil
+}
@@ -0,0 +1,28 @@
+package repository
+
+import (
+	"go-rest-scaffold/internal/entity"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type ExperimentAssignmentRepository struct {
+	Repository[entity.ExperimentAssignment]
+	Log *logrus.Logger
+}
+
+func NewExperimentAssignmentRepository(log *logrus.Logger) *ExperimentAssignmentRepository {
+	return &ExperimentAssignmentRepository{
+		Log: log,
+	}
+}
+
+// Record stores assignments, keeping the first one seen for each user and experiment.
+func (r *ExperimentAssignmentRepository) Record(db *gorm.DB, assignments []entity.ExperimentAssignment) error {
+	if len(assignments) == 0 {
+		return nil
+	}
+	return db.Clauses(clause.OnConflict{DoNothing: true}).Create(&assignments).Error
+}
@@ -0,0 +1,36 @@
+package repository
+
+import (
+	"go-rest-scaffold/internal/entity"
+	"go-rest-scaffold/internal/model"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+type LoginEventRepository struct {
+	Repository[entity.LoginEvent]
+	Log *logrus.Logger
+}
+
+func NewLoginEventRepository(log *logrus.Logger) *LoginEventRepository {
+	return &LoginEventRepository{
+		Log: log,
+	}
+}
+
+// FindAllByUserId returns a page of the login attempts of a user, the latest first.
+func (r *LoginEventRepository) FindAllByUserId(db *gorm.DB, request *model.ListLoginEventRequest) ([]entity.LoginEvent, int64, error) {
+	var events []entity.LoginEvent
+	if err := db.Where("user_id = ?", request.UserId).Order("created_at DESC").
+		Offset((request.Page - 1) * request.Size).Limit(request.Size).Find(&events).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var total int64 = 0
+	if err := db.Model(&entity.LoginEvent{}).Where("user_id = ?", request.UserId).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	return events, total, nil
+}
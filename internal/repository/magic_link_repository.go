@@ -0,0 +1,37 @@
+package repository
+
+import (
+	"go-rest-scaffold/internal/entity"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type MagicLinkRepository struct {
+	Repository[entity.MagicLink]
+	Log *logrus.Logger
+}
+
+func NewMagicLinkRepository(log *logrus.Logger) *MagicLinkRepository {
+	return &MagicLinkRepository{
+		Log: log,
+	}
+}
+
+// FindUnexpiredByIdForUpdate finds a link that has not expired at now (Unix milliseconds)
+// and locks it, so a link clicked twice at once is only used by one of the requests.
+func (r *MagicLinkRepository) FindUnexpiredByIdForUpdate(db *gorm.DB, link *entity.MagicLink, id string, now int64) error {
+	return db.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ? AND expires_at > ?", id, now).Take(link).Error
+}
+
+// DeleteByUserId deletes every pending link of the user.
+func (r *MagicLinkRepository) DeleteByUserId(db *gorm.DB, userId string) error {
+	return db.Where("user_id = ?", userId).Delete(new(entity.MagicLink)).Error
+}
+
+// DeleteExpired deletes the links expired at now (Unix milliseconds) and returns how many.
+func (r *MagicLinkRepository) DeleteExpired(db *gorm.DB, now int64) (int64, error) {
+	result := db.Where("expires_at <= ?", now).Delete(new(entity.MagicLink))
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,40 @@
+package repository
+
+import (
+	"go-rest-scaffold/internal/entity"
+	"go-rest-scaffold/internal/model"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+type NoteRepository struct {
+	Repository[entity.Note]
+	Log *logrus.Logger
+}
+
+func NewNoteRepository(log *logrus.Logger) *NoteRepository {
+	return &NoteRepository{
+		Log: log,
+	}
+}
+
+func (r *NoteRepository) FindByIdAndContactId(tx *gorm.DB, note *entity.Note, id string, contactId string) error {
+	return tx.Where("id = ? AND contact_id = ?", id, contactId).First(note).Error
+}
+
+// Search returns a page of the notes of a contact, newest first.
+func (r *NoteRepository) Search(db *gorm.DB, request *model.SearchNoteRequest) ([]entity.Note, int64, error) {
+	var notes []entity.Note
+	if err := db.Where("contact_id = ?", request.ContactId).Order("created_at DESC, id DESC").
+		Offset((request.Page - 1) * request.Size).Limit(request.Size).Find(&notes).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var total int64 = 0
+	if err := db.Model(&entity.Note{}).Where("contact_id = ?", request.ContactId).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	return notes, total, nil
+}
@@ -0,0 +1,63 @@
+package repository
+
+import (
+	"encoding/json"
+	"go-rest-scaffold/internal/entity"
+	"go-rest-scaffold/internal/model"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+type OutboxRepository struct {
+	Repository[entity.OutboxEvent]
+	Log *logrus.Logger
+}
+
+func NewOutboxRepository(log *logrus.Logger) *OutboxRepository {
+	return &OutboxRepository{
+		Log: log,
+	}
+}
+
+// Save records event in db, the transaction of the change that raised it.
+func (r *OutboxRepository) Save(db *gorm.DB, event *model.CloudEvent) error {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	return r.Create(db, &entity.OutboxEvent{
+		EventId: event.ID,
+		Type:    event.Type,
+		UserId:  event.UserId,
+		Payload: string(payload),
+	})
+}
+
+// FindUnpublished returns up to limit events not published yet, in the order they were recorded.
+func (r *OutboxRepository) FindUnpublished(db *gorm.DB, limit int) ([]entity.OutboxEvent, error) {
+	var events []entity.OutboxEvent
+	err := db.Where("published_at IS NULL").Order("id").Limit(limit).Find(&events).Error
+	return events, err
+}
+
+func (r *OutboxRepository) MarkPublished(db *gorm.DB, id int64, publishedAt int64) error {
+	return db.Model(&entity.OutboxEvent{}).Where("id = ?", id).Update("published_at", publishedAt).Error
+}
+
+// RecordFailure counts a failed attempt to publish the event and keeps its error.
+func (r *OutboxRepository) RecordFailure(db *gorm.DB, id int64, lastError string) error {
+	return db.Model(&entity.OutboxEvent{}).Where("id = ?", id).Updates(map[string]any{
+		"attempts":   gorm.Expr("attempts + 1"),
+		"last_error": lastError,
+	}).Error
+}
+
+// DeletePublishedBefore prunes the events published before publishedBefore and returns
+// how many were deleted.
+func (r *OutboxRepository) DeletePublishedBefore(db *gorm.DB, publishedBefore time.Time) (int64, error) {
+	result := db.Where("published_at < ?", publishedBefore.UnixMilli()).Delete(&entity.OutboxEvent{})
+	return result.RowsAffected, result.Error
+}
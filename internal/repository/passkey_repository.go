@@ -0,0 +1,67 @@
+package repository
+
+import (
+	"go-rest-scaffold/internal/entity"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type PasskeyRepository struct {
+	Repository[entity.Passkey]
+	Log *logrus.Logger
+}
+
+func NewPasskeyRepository(log *logrus.Logger) *PasskeyRepository {
+	return &PasskeyRepository{
+		Log: log,
+	}
+}
+
+func (r *PasskeyRepository) FindByIdAndUserId(db *gorm.DB, passkey *entity.Passkey, id string, userId string) error {
+	return db.Where("id = ? AND user_id = ?", id, userId).Take(passkey).Error
+}
+
+func (r *PasskeyRepository) FindAllByUserId(db *gorm.DB, userId string) ([]entity.Passkey, error) {
+	var passkeys []entity.Passkey
+	if err := db.Where("user_id = ?", userId).Order("created_at").Find(&passkeys).Error; err != nil {
+		return nil, err
+	}
+	return passkeys, nil
+}
+
+// FindByCredentialIdForUpdate finds the passkey of the user with the credential ID and
+// locks it, so two logins at once cannot both accept the same signature counter.
+func (r *PasskeyRepository) FindByCredentialIdForUpdate(db *gorm.DB, passkey *entity.Passkey, credentialId string, userId string) error {
+	return db.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("credential_id = ? AND user_id = ?", credentialId, userId).Take(passkey).Error
+}
+
+func (r *PasskeyRepository) CountByCredentialId(db *gorm.DB, credentialId string) (int64, error) {
+	return r.Count(db, "credential_id = ?", credentialId)
+}
+
+type WebAuthnChallengeRepository struct {
+	Repository[entity.WebAuthnChallenge]
+	Log *logrus.Logger
+}
+
+func NewWebAuthnChallengeRepository(log *logrus.Logger) *WebAuthnChallengeRepository {
+	return &WebAuthnChallengeRepository{
+		Log: log,
+	}
+}
+
+// FindUnexpiredByIdForUpdate finds the challenge of the user ("" for a login) that has
+// not expired at now (Unix milliseconds) and locks it until it is used up.
+func (r *WebAuthnChallengeRepository) FindUnexpiredByIdForUpdate(db *gorm.DB, challenge *entity.WebAuthnChallenge, id string, userId string, now int64) error {
+	return db.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id = ? AND user_id = ? AND expires_at > ?", id, userId, now).Take(challenge).Error
+}
+
+// DeleteExpired deletes the challenges that expired at now (Unix milliseconds) without
+// being answered.
+func (r *WebAuthnChallengeRepository) DeleteExpired(db *gorm.DB, now int64) error {
+	return db.Where("expires_at <= ?", now).Delete(new(entity.WebAuthnChallenge)).Error
+}
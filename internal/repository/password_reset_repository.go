@@ -0,0 +1,37 @@
+package repository
+
+import (
+	"go-rest-scaffold/internal/entity"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type PasswordResetRepository struct {
+	Repository[entity.PasswordReset]
+	Log *logrus.Logger
+}
+
+func NewPasswordResetRepository(log *logrus.Logger) *PasswordResetRepository {
+	return &PasswordResetRepository{
+		Log: log,
+	}
+}
+
+// FindUnexpiredByIdForUpdate finds a reset that has not expired at now (Unix milliseconds)
+// and locks it, so a token submitted twice at once only sets one of the passwords.
+func (r *PasswordResetRepository) FindUnexpiredByIdForUpdate(db *gorm.DB, reset *entity.PasswordReset, id string, now int64) error {
+	return db.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ? AND expires_at > ?", id, now).Take(reset).Error
+}
+
+// DeleteByUserId deletes every pending reset of the user.
+func (r *PasswordResetRepository) DeleteByUserId(db *gorm.DB, userId string) error {
+	return db.Where("user_id = ?", userId).Delete(new(entity.PasswordReset)).Error
+}
+
+// DeleteExpired deletes the resets expired at now (Unix milliseconds) and returns how many.
+func (r *PasswordResetRepository) DeleteExpired(db *gorm.DB, now int64) (int64, error) {
+	result := db.Where("expires_at <= ?", now).Delete(new(entity.PasswordReset))
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,68 @@
+package repository
+
+import (
+	"go-rest-scaffold/internal/entity"
+	"go-rest-scaffold/internal/model"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type ReminderRepository struct {
+	Repository[entity.Reminder]
+	Log *logrus.Logger
+}
+
+func NewReminderRepository(log *logrus.Logger) *ReminderRepository {
+	return &ReminderRepository{
+		Log: log,
+	}
+}
+
+func (r *ReminderRepository) FindByIdAndUserId(db *gorm.DB, reminder *entity.Reminder, id string, userId string) error {
+	return db.Where("id = ? AND user_id = ?", id, userId).Take(reminder).Error
+}
+
+func (r *ReminderRepository) FindAllByUserId(db *gorm.DB, userId string) ([]entity.Reminder, error) {
+	var reminders []entity.Reminder
+	err := db.Where("user_id = ?", userId).Order("created_at, id").Find(&reminders).Error
+	return reminders, err
+}
+
+// FindDue locks the pending reminders due at now, skipping those another instance is delivering.
+func (r *ReminderRepository) FindDue(db *gorm.DB, now int64, limit int) ([]entity.Reminder, error) {
+	var reminders []entity.Reminder
+	err := db.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+		Where("status = ? AND remind_at <= ?", model.ReminderPending, now).
+		Order("remind_at").Limit(limit).Find(&reminders).Error
+	return reminders, err
+}
+
+func (r *ReminderRepository) Search(db *gorm.DB, request *model.SearchReminderRequest) ([]entity.Reminder, int64, error) {
+	var reminders []entity.Reminder
+	if err := db.Scopes(r.FilterReminder(request)).Order("remind_at").
+		Offset((request.Page - 1) * request.Size).Limit(request.Size).Find(&reminders).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var total int64 = 0
+	if err := db.Model(&entity.Reminder{}).Scopes(r.FilterReminder(request)).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	return reminders, total, nil
+}
+
+func (r *ReminderRepository) FilterReminder(request *model.SearchReminderRequest) func(tx *gorm.DB) *gorm.DB {
+	return func(tx *gorm.DB) *gorm.DB {
+		tx = tx.Where("user_id = ?", request.UserId)
+		if contactId := request.ContactId; contactId != "" {
+			tx = tx.Where("contact_id = ?", contactId)
+		}
+		if status := request.Status; status != "" {
+			tx = tx.Where("status = ?", status)
+		}
+		return tx
+	}
+}
@@ -1,29 +1,136 @@
 package repository
 
-import "gorm.io/gorm"
+import (
+	"context"
 
+	"golang.org/x/sync/singleflight"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	defaultBatchSize = 100
+	// idChunkSize keeps IN lists well below the bind parameter limit of the database.
+	idChunkSize = 1000
+)
+
+// Errors of the repositories, whatever the database: config.NewDatabase has gorm
+// translate the errors of the drivers.
+var (
+	// ErrNotFound is returned when no row matches.
+	ErrNotFound = gorm.ErrRecordNotFound
+	// ErrConflict is returned when a write would duplicate a unique key.
+	ErrConflict = gorm.ErrDuplicatedKey
+)
+
+// Repository holds the operations every entity shares. Each repository embeds the one
+// of its entity and only adds the queries specific to it.
 type Repository[T any] struct {
-	DB *gorm.DB
+	DB    *gorm.DB
+	group singleflight.Group
 }
 
+// Create inserts entity, failing with ErrConflict when its ID or a unique key is taken.
 func (r *Repository[T]) Create(db *gorm.DB, entity *T) error {
 	return db.Create(entity).Error
 }
 
+// CreateInBatches inserts entities with multi-row INSERTs of database.batch_size rows each.
+func (r *Repository[T]) CreateInBatches(db *gorm.DB, entities []T) error {
+	if len(entities) == 0 {
+		return nil
+	}
+
+	batchSize := db.CreateBatchSize
+	if batchSize <= 0 {
+		batchSize = defaultBatchSize
+	}
+	return db.CreateInBatches(&entities, batchSize).Error
+}
+
 func (r *Repository[T]) Update(db *gorm.DB, entity *T) error {
 	return db.Save(entity).Error
 }
 
+// UpdateVersion saves an entity of a table with a version column unless its row moved
+// past version since it was read, and reports whether it saved it. The caller moves
+// the entity to its next version first, so concurrent changes of the same version
+// all but one fail instead of overwriting each other.
+func (r *Repository[T]) UpdateVersion(db *gorm.DB, entity *T, version int64) (bool, error) {
+	result := db.Model(entity).Where("version = ?", version).Omit(clause.Associations).Select("*").Updates(entity)
+	return result.RowsAffected > 0, result.Error
+}
+
 func (r *Repository[T]) Delete(db *gorm.DB, entity *T) error {
 	return db.Delete(entity).Error
 }
 
-func (r *Repository[T]) CountById(db *gorm.DB, id any) (int64, error) {
+// DeleteAll removes every row of the table, soft-deleted rows included.
+func (r *Repository[T]) DeleteAll(db *gorm.DB) error {
+	return db.Session(&gorm.Session{AllowGlobalUpdate: true}).Unscoped().Delete(new(T)).Error
+}
+
+// Restore takes a soft-deleted entity out of the trash.
+func (r *Repository[T]) Restore(db *gorm.DB, entity *T) error {
+	return db.Unscoped().Model(entity).Update("deleted_at", nil).Error
+}
+
+// Count counts the rows matching query and args, given as to Where.
+func (r *Repository[T]) Count(db *gorm.DB, query any, args ...any) (int64, error) {
 	var total int64
-	err := db.Model(new(T)).Where("id = ?", id).Count(&total).Error
+	err := db.Model(new(T)).Where(query, args...).Count(&total).Error
 	return total, err
 }
 
+func (r *Repository[T]) CountById(db *gorm.DB, id any) (int64, error) {
+	return r.Count(db, "id = ?", id)
+}
+
+// CountByIds counts the rows, soft-deleted ones included, whose ID is one of ids.
+func (r *Repository[T]) CountByIds(db *gorm.DB, ids []string) (int64, error) {
+	var total int64
+	for start := 0; start < len(ids); start += idChunkSize {
+		var count int64
+		chunk := ids[start:min(start+idChunkSize, len(ids))]
+		if err := db.Unscoped().Model(new(T)).Where("id IN ?", chunk).Count(&count).Error; err != nil {
+			return 0, err
+		}
+		total += count
+	}
+	return total, nil
+}
+
+// FindById finds the entity with id, failing with ErrNotFound when there is none.
 func (r *Repository[T]) FindById(db *gorm.DB, entity *T, id any) error {
 	return db.Where("id = ?", id).Take(entity).Error
 }
+
+// Shared collapses concurrent lookups with the same key into a single call of find and
+// copies the result into entity for every caller. The query runs detached from the
+// first caller's cancellation so one aborted request does not fail the others. Lookups
+// in a transaction are not collapsed: they must see the writes and snapshot of their
+// own transaction, so find runs on it alone.
+func (r *Repository[T]) Shared(db *gorm.DB, key string, entity *T, find func(db *gorm.DB, result *T) error) error {
+	if _, inTx := db.Statement.ConnPool.(gorm.TxCommitter); inTx {
+		return find(db, entity)
+	}
+
+	result, err, _ := r.group.Do(key, func() (any, error) {
+		ctx := context.Background()
+		if db.Statement != nil && db.Statement.Context != nil {
+			ctx = context.WithoutCancel(db.Statement.Context)
+		}
+
+		found := new(T)
+		if err := find(db.WithContext(ctx), found); err != nil {
+			return nil, err
+		}
+		return found, nil
+	})
+	if err != nil {
+		return err
+	}
+
+	*entity = *result.(*T)
+	return nil
+}
@@ -0,0 +1,59 @@
+package repository
+
+import (
+	"go-rest-scaffold/internal/entity"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+type SessionRepository struct {
+	Repository[entity.Session]
+	Log *logrus.Logger
+}
+
+func NewSessionRepository(log *logrus.Logger) *SessionRepository {
+	return &SessionRepository{
+		Log: log,
+	}
+}
+
+func (r *SessionRepository) FindByToken(db *gorm.DB, session *entity.Session, token string) error {
+	return db.Where("token = ?", token).Take(session).Error
+}
+
+func (r *SessionRepository) FindByRefreshToken(db *gorm.DB, session *entity.Session, refreshToken string) error {
+	return db.Where("refresh_token = ?", refreshToken).Take(session).Error
+}
+
+func (r *SessionRepository) FindByIdAndUserId(db *gorm.DB, session *entity.Session, id string, userId string) error {
+	return db.Where("id = ? AND user_id = ?", id, userId).Take(session).Error
+}
+
+// FindAllByUserId returns the sessions of a user, the most recently seen first.
+func (r *SessionRepository) FindAllByUserId(db *gorm.DB, userId string) ([]entity.Session, error) {
+	var sessions []entity.Session
+	if err := db.Where("user_id = ?", userId).Order("last_seen_at DESC").Find(&sessions).Error; err != nil {
+		return nil, err
+	}
+	return sessions, nil
+}
+
+func (r *SessionRepository) DeleteByUserId(db *gorm.DB, userId string) error {
+	return db.Where("user_id = ?", userId).Delete(&entity.Session{}).Error
+}
+
+// DeleteExpired deletes the sessions expired at now (Unix milliseconds), which only
+// impersonation sessions do, and returns how many.
+func (r *SessionRepository) DeleteExpired(db *gorm.DB, now int64) (int64, error) {
+	result := db.Where("expires_at <= ?", now).Delete(&entity.Session{})
+	return result.RowsAffected, result.Error
+}
+
+// TouchLastSeen sets the last activity of a session to seenAt unless it was already
+// recorded after staleBefore, so a busy session is not written on every request.
+func (r *SessionRepository) TouchLastSeen(db *gorm.DB, id string, seenAt int64, staleBefore int64) error {
+	return db.Model(&entity.Session{}).
+		Where("id = ? AND last_seen_at < ?", id, staleBefore).
+		Update("last_seen_at", seenAt).Error
+}
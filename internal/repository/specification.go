@@ -0,0 +1,197 @@
+package repository
+
+import (
+	"fmt"
+	"go-rest-scaffold/internal/model"
+	"slices"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// Columns maps the public field names accepted in filter and sort specifications
+// to the database columns they are allowed to touch. Anything that is not in the
+// map is rejected, so user input never reaches the generated SQL as an identifier.
+type Columns map[string]string
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// likeEscape is the ESCAPE clause of LIKE patterns escaped by likeEscaper, in the SQL
+// of the database of db. SQLite has no escape character unless one is named, and
+// the backslash escapes the quote in the string literals of MySQL.
+func likeEscape(db *gorm.DB) string {
+	if db.Dialector.Name() == "mysql" {
+		return ` ESCAPE '\\'`
+	}
+	return ` ESCAPE '\'`
+}
+
+// CheckFilter returns an error when the filter references a field outside the allowed columns.
+func (c Columns) CheckFilter(node *model.FilterNode) error {
+	for _, field := range node.Fields() {
+		if _, ok := c[field]; !ok {
+			return fmt.Errorf("field %q is not filterable", field)
+		}
+	}
+	return nil
+}
+
+// CheckSort returns an error when the sort references a field outside the allowed columns.
+func (c Columns) CheckSort(fields []model.SortField) error {
+	for _, field := range fields {
+		if _, ok := c[field.Field]; !ok {
+			return fmt.Errorf("field %q is not sortable", field.Field)
+		}
+	}
+	return nil
+}
+
+// FilterSpecification translates a filter expression into a GORM scope.
+func FilterSpecification(node *model.FilterNode, columns Columns) func(tx *gorm.DB) *gorm.DB {
+	return func(tx *gorm.DB) *gorm.DB {
+		if node == nil {
+			return tx
+		}
+
+		query, args, err := buildFilter(node, columns, likeEscape(tx))
+		if err != nil {
+			_ = tx.AddError(err)
+			return tx
+		}
+
+		return tx.Where(query, args...)
+	}
+}
+
+// SortSpecification translates sort fields into ORDER BY clauses.
+func SortSpecification(fields []model.SortField, columns Columns) func(tx *gorm.DB) *gorm.DB {
+	return func(tx *gorm.DB) *gorm.DB {
+		for _, field := range fields {
+			column, ok := columns[field.Field]
+			if !ok {
+				_ = tx.AddError(fmt.Errorf("field %q is not sortable", field.Field))
+				return tx
+			}
+
+			if field.Desc {
+				tx = tx.Order(column + " DESC")
+			} else {
+				tx = tx.Order(column + " ASC")
+			}
+		}
+		return tx
+	}
+}
+
+// KeysetSpecification orders by fields and keeps the rows that come after values,
+// the sort key of a row, in that order. With before it keeps the rows that come
+// before values instead, in reverse order, so a limit picks those nearest to it.
+// Without values it only orders. fields must end with a unique field for the
+// order to be stable.
+func KeysetSpecification(fields []model.SortField, values []any, before bool, columns Columns) func(tx *gorm.DB) *gorm.DB {
+	return func(tx *gorm.DB) *gorm.DB {
+		if values != nil && len(values) != len(fields) {
+			_ = tx.AddError(fmt.Errorf("cursor has %d values for %d sort fields", len(values), len(fields)))
+			return tx
+		}
+
+		// (f1 > v1) OR (f1 = v1 AND f2 > v2) OR ..., with < for descending fields
+		var parts []string
+		var args []any
+		var equal []string
+		var equalArgs []any
+		for i, field := range fields {
+			column, ok := columns[field.Field]
+			if !ok {
+				_ = tx.AddError(fmt.Errorf("field %q is not sortable", field.Field))
+				return tx
+			}
+
+			desc := field.Desc != before
+			if desc {
+				tx = tx.Order(column + " DESC")
+			} else {
+				tx = tx.Order(column + " ASC")
+			}
+
+			if values == nil {
+				continue
+			}
+
+			operator := " > ?"
+			if desc {
+				operator = " < ?"
+			}
+			parts = append(parts, "("+strings.Join(append(slices.Clone(equal), column+operator), " AND ")+")")
+			args = append(append(args, equalArgs...), values[i])
+
+			equal = append(equal, column+" = ?")
+			equalArgs = append(equalArgs, values[i])
+		}
+
+		if len(parts) > 0 {
+			tx = tx.Where(strings.Join(parts, " OR "), args...)
+		}
+		return tx
+	}
+}
+
+func buildFilter(node *model.FilterNode, columns Columns, escape string) (string, []any, error) {
+	switch node.Operator {
+	case model.FilterAnd, model.FilterOr:
+		var parts []string
+		var args []any
+		for _, child := range node.Children {
+			query, childArgs, err := buildFilter(child, columns, escape)
+			if err != nil {
+				return "", nil, err
+			}
+			parts = append(parts, "("+query+")")
+			args = append(args, childArgs...)
+		}
+		return strings.Join(parts, " "+strings.ToUpper(node.Operator)+" "), args, nil
+	case model.FilterNot:
+		if len(node.Children) != 1 {
+			return "", nil, fmt.Errorf("not expects exactly one operand")
+		}
+		query, args, err := buildFilter(node.Children[0], columns, escape)
+		if err != nil {
+			return "", nil, err
+		}
+		return "NOT (" + query + ")", args, nil
+	}
+
+	column, ok := columns[node.Field]
+	if !ok {
+		return "", nil, fmt.Errorf("field %q is not filterable", node.Field)
+	}
+
+	switch node.Operator {
+	case model.FilterEq:
+		if node.Value == nil {
+			return column + " IS NULL", nil, nil
+		}
+		return column + " = ?", []any{node.Value}, nil
+	case model.FilterNe:
+		if node.Value == nil {
+			return column + " IS NOT NULL", nil, nil
+		}
+		return column + " <> ?", []any{node.Value}, nil
+	case model.FilterGt:
+		return column + " > ?", []any{node.Value}, nil
+	case model.FilterGe:
+		return column + " >= ?", []any{node.Value}, nil
+	case model.FilterLt:
+		return column + " < ?", []any{node.Value}, nil
+	case model.FilterLe:
+		return column + " <= ?", []any{node.Value}, nil
+	case model.FilterContains:
+		return column + " LIKE ?" + escape, []any{"%" + likeEscaper.Replace(fmt.Sprint(node.Value)) + "%"}, nil
+	case model.FilterStartsWith:
+		return column + " LIKE ?" + escape, []any{likeEscaper.Replace(fmt.Sprint(node.Value)) + "%"}, nil
+	case model.FilterEndsWith:
+		return column + " LIKE ?" + escape, []any{"%" + likeEscaper.Replace(fmt.Sprint(node.Value))}, nil
+	default:
+		return "", nil, fmt.Errorf("unsupported operator %q", node.Operator)
+	}
+}
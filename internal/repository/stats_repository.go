@@ -0,0 +1,207 @@
+package repository
+
+import (
+	"go-rest-scaffold/internal/entity"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type UserActivityRepository struct {
+	Repository[entity.UserActivity]
+	Log *logrus.Logger
+}
+
+func NewUserActivityRepository(log *logrus.Logger) *UserActivityRepository {
+	return &UserActivityRepository{
+		Log: log,
+	}
+}
+
+// Record marks the user as active on day, keeping the existing row if there is one.
+func (r *UserActivityRepository) Record(db *gorm.DB, activity *entity.UserActivity) error {
+	return db.Clauses(clause.OnConflict{DoNothing: true}).Create(activity).Error
+}
+
+// StatsRepository runs the aggregate queries of the admin statistics. Every query is
+// a single round trip served by indexes, so the statistics stay cheap as data grows.
+type StatsRepository struct {
+	Log *logrus.Logger
+}
+
+func NewStatsRepository(log *logrus.Logger) *StatsRepository {
+	return &StatsRepository{
+		Log: log,
+	}
+}
+
+// Totals counts the resources of every user, leaving out those in the trash.
+func (r *StatsRepository) Totals(db *gorm.DB) (*entity.StatsTotals, error) {
+	totals := new(entity.StatsTotals)
+	err := db.Raw(`
+SELECT (SELECT count(*) FROM users) AS users,
+       (SELECT count(*) FROM contacts WHERE deleted_at IS NULL) AS contacts,
+       (SELECT count(*) FROM addresses WHERE deleted_at IS NULL) AS addresses,
+       (SELECT count(*) FROM reminders) AS reminders,
+       (SELECT count(*) FROM webhooks) AS webhooks`).Scan(totals).Error
+	return totals, err
+}
+
+// CountActiveSince counts the distinct users active on since or a later day.
+func (r *StatsRepository) CountActiveSince(db *gorm.DB, since time.Time) (int64, error) {
+	var total int64
+	err := db.Model(&entity.UserActivity{}).Where("day >= ?", since).Distinct("user_id").Count(&total).Error
+	return total, err
+}
+
+// Storage returns the estimated rows and the on-disk size, indexes included, of every
+// table. SQLite keeps no such statistics, so only its tables are listed.
+func (r *StatsRepository) Storage(db *gorm.DB) ([]entity.TableStorage, error) {
+	query := `
+SELECT relname AS "table", n_live_tup AS rows, pg_total_relation_size(relid) AS bytes
+FROM pg_stat_user_tables
+ORDER BY bytes DESC, relname`
+	switch db.Dialector.Name() {
+	case "mysql":
+		query = `
+SELECT table_name AS ` + "`table`, table_rows AS `rows`" + `, data_length + index_length AS bytes
+FROM information_schema.tables
+WHERE table_schema = DATABASE() AND table_type = 'BASE TABLE'
+ORDER BY bytes DESC, table_name`
+	case "sqlite":
+		query = `
+SELECT name AS "table", 0 AS rows, 0 AS bytes
+FROM sqlite_master
+WHERE type = 'table' AND name NOT LIKE 'sqlite_%'
+ORDER BY name`
+	}
+
+	var tables []entity.TableStorage
+	err := db.Raw(query).Scan(&tables).Error
+	return tables, err
+}
+
+// Daily returns one row per UTC day from since to until, days without activity included.
+func (r *StatsRepository) Daily(db *gorm.DB, since time.Time, until time.Time) ([]entity.DailyStats, error) {
+	if db.Dialector.Name() != "postgres" {
+		return r.dailyByDay(db, since, until)
+	}
+
+	var days []entity.DailyStats
+	err := db.Raw(`
+WITH days AS (SELECT generate_series(@since::date, @until::date, interval '1 day')::date AS day),
+     registrations AS (SELECT (to_timestamp(created_at / 1000.0) AT TIME ZONE 'UTC')::date AS day, count(*) AS total
+                       FROM users WHERE created_at >= @millis GROUP BY 1),
+     contacts AS (SELECT (to_timestamp(created_at / 1000.0) AT TIME ZONE 'UTC')::date AS day, count(*) AS total
+                  FROM contacts WHERE created_at >= @millis GROUP BY 1),
+     active AS (SELECT day, count(*) AS total FROM user_activity WHERE day >= @since::date GROUP BY day)
+SELECT days.day,
+       coalesce(registrations.total, 0) AS registrations,
+       coalesce(active.total, 0) AS active_users,
+       coalesce(contacts.total, 0) AS contacts_created
+FROM days
+LEFT JOIN registrations USING (day)
+LEFT JOIN active USING (day)
+LEFT JOIN contacts USING (day)
+ORDER BY days.day`, map[string]any{
+		"since":  since.Format("2006-01-02"),
+		"until":  until.Format("2006-01-02"),
+		"millis": since.UnixMilli(),
+	}).Scan(&days).Error
+	return days, err
+}
+
+type dayTotal struct {
+	Day   string `gorm:"column:day"`
+	Total int64  `gorm:"column:total"`
+}
+
+// dailyByDay is Daily for the databases without generate_series: every statistic is
+// grouped by day on its own and the days are filled in here.
+func (r *StatsRepository) dailyByDay(db *gorm.DB, since time.Time, until time.Time) ([]entity.DailyStats, error) {
+	createdDay, activeDay := "date(created_at / 1000, 'unixepoch')", "date(day)"
+	if db.Dialector.Name() == "mysql" {
+		createdDay = "DATE_FORMAT(TIMESTAMPADD(SECOND, created_at DIV 1000, '1970-01-01'), '%Y-%m-%d')"
+		activeDay = "DATE_FORMAT(day, '%Y-%m-%d')"
+	}
+
+	var registrations, contacts, active []dayTotal
+	if err := db.Table("users").Select(createdDay+" AS day, count(*) AS total").
+		Where("created_at >= ?", since.UnixMilli()).Group("day").Scan(&registrations).Error; err != nil {
+		return nil, err
+	}
+	if err := db.Table("contacts").Select(createdDay+" AS day, count(*) AS total").
+		Where("created_at >= ?", since.UnixMilli()).Group("day").Scan(&contacts).Error; err != nil {
+		return nil, err
+	}
+	if err := db.Model(&entity.UserActivity{}).Select(activeDay+" AS day, count(*) AS total").
+		Where("day >= ?", since).Group("day").Scan(&active).Error; err != nil {
+		return nil, err
+	}
+
+	byDay := make(map[string]*entity.DailyStats)
+	var days []entity.DailyStats
+	for day := since.UTC().Truncate(24 * time.Hour); !day.After(until); day = day.AddDate(0, 0, 1) {
+		days = append(days, entity.DailyStats{Day: day})
+	}
+	for i := range days {
+		byDay[days[i].Day.Format("2006-01-02")] = &days[i]
+	}
+
+	for _, total := range registrations {
+		if stats, ok := byDay[total.Day]; ok {
+			stats.Registrations = total.Total
+		}
+	}
+	for _, total := range active {
+		if stats, ok := byDay[total.Day]; ok {
+			stats.ActiveUsers = total.Total
+		}
+	}
+	for _, total := range contacts {
+		if stats, ok := byDay[total.Day]; ok {
+			stats.ContactsCreated = total.Total
+		}
+	}
+	return days, nil
+}
+
+// SaveDaily stores the statistics of days, replacing those already stored.
+func (r *StatsRepository) SaveDaily(db *gorm.DB, days []entity.DailyStats) error {
+	if len(days) == 0 {
+		return nil
+	}
+	return db.Clauses(clause.OnConflict{UpdateAll: true}).CreateInBatches(&days, defaultBatchSize).Error
+}
+
+// FindDaily returns the stored statistics of the days from since to until, oldest first.
+func (r *StatsRepository) FindDaily(db *gorm.DB, since time.Time, until time.Time) ([]entity.DailyStats, error) {
+	var days []entity.DailyStats
+	err := db.Where("day >= ? AND day <= ?", since, until).Order("day").Find(&days).Error
+	return days, err
+}
+
+// FindLatestDaily returns the statistics of the latest day stored, or nil before the first.
+func (r *StatsRepository) FindLatestDaily(db *gorm.DB) (*entity.DailyStats, error) {
+	var days []entity.DailyStats
+	if err := db.Order("day DESC").Limit(1).Find(&days).Error; err != nil || len(days) == 0 {
+		return nil, err
+	}
+	return &days[0], nil
+}
+
+// TopAccounts returns the users owning the most contacts, trash left out.
+func (r *StatsRepository) TopAccounts(db *gorm.DB, limit int) ([]entity.TopAccount, error) {
+	var accounts []entity.TopAccount
+	err := db.Raw(`
+SELECT u.id AS user_id, u.name, count(DISTINCT c.id) AS contacts, count(a.id) AS addresses
+FROM users u
+JOIN contacts c ON c.user_id = u.id AND c.deleted_at IS NULL
+LEFT JOIN addresses a ON a.contact_id = c.id AND a.deleted_at IS NULL
+GROUP BY u.id, u.name
+ORDER BY contacts DESC, addresses DESC, u.id
+LIMIT ?`, limit).Scan(&accounts).Error
+	return accounts, err
+}
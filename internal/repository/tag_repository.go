@@ -0,0 +1,64 @@
+package repository
+
+import (
+	"go-rest-scaffold/internal/entity"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type TagRepository struct {
+	Repository[entity.Tag]
+	Log *logrus.Logger
+}
+
+func NewTagRepository(log *logrus.Logger) *TagRepository {
+	return &TagRepository{
+		Log: log,
+	}
+}
+
+func (r *TagRepository) FindByIdAndUserId(db *gorm.DB, tag *entity.Tag, id string, userId string) error {
+	return db.Where("id = ? AND user_id = ?", id, userId).Take(tag).Error
+}
+
+// FindAllByUserId returns the tags of a user in the order of their names.
+func (r *TagRepository) FindAllByUserId(db *gorm.DB, userId string) ([]entity.Tag, error) {
+	var tags []entity.Tag
+	err := db.Where("user_id = ?", userId).Order("name, id").Find(&tags).Error
+	return tags, err
+}
+
+// FindAllByContactId returns the tags assigned to a contact in the order of their names.
+func (r *TagRepository) FindAllByContactId(db *gorm.DB, contactId string) ([]entity.Tag, error) {
+	var tags []entity.Tag
+	err := db.Joins("JOIN contact_tags ON contact_tags.tag_id = tags.id").
+		Where("contact_tags.contact_id = ?", contactId).Order("tags.name, tags.id").Find(&tags).Error
+	return tags, err
+}
+
+// CountByName counts the tags of the user other than excludeId named name, ignoring case.
+func (r *TagRepository) CountByName(db *gorm.DB, userId string, name string, excludeId string) (int64, error) {
+	return r.Count(db, "user_id = ? AND lower(name) = lower(?) AND id <> ?", userId, name, excludeId)
+}
+
+// Assign assigns the tag to the contact and reports whether it was not assigned yet.
+func (r *TagRepository) Assign(db *gorm.DB, contactId string, tagId string) (bool, error) {
+	result := db.Clauses(clause.OnConflict{DoNothing: true}).Create(&entity.ContactTag{ContactId: contactId, TagId: tagId})
+	return result.RowsAffected > 0, result.Error
+}
+
+// Unassign takes the tag off the contact and reports whether it was assigned.
+func (r *TagRepository) Unassign(db *gorm.DB, contactId string, tagId string) (bool, error) {
+	result := db.Where("contact_id = ? AND tag_id = ?", contactId, tagId).Delete(&entity.ContactTag{})
+	return result.RowsAffected > 0, result.Error
+}
+
+// DeleteWithAssignments deletes a tag after taking it off every contact.
+func (r *TagRepository) DeleteWithAssignments(db *gorm.DB, tag *entity.Tag) error {
+	if err := db.Where("tag_id = ?", tag.ID).Delete(&entity.ContactTag{}).Error; err != nil {
+		return err
+	}
+	return db.Delete(tag).Error
+}
@@ -0,0 +1,106 @@
+package repository
+
+import (
+	"go-rest-scaffold/internal/entity"
+	"go-rest-scaffold/internal/model"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// trashQuery lists the soft-deleted contacts and addresses of a user as trash items.
+// Addresses of a deleted contact stay untouched and go away with it, so only the
+// addresses deleted themselves are listed.
+const trashQuery = `
+SELECT 'contact' AS type, c.id, '' AS contact_id,
+       trim(concat_ws(' ', c.first_name, c.last_name)) AS title, c.deleted_at
+FROM contacts c
+WHERE c.user_id = ? AND c.deleted_at IS NOT NULL
+UNION ALL
+SELECT 'address' AS type, a.id, a.contact_id,
+       concat_ws(', ', nullif(a.street, ''), nullif(a.city, ''), nullif(a.country, '')) AS title, a.deleted_at
+FROM addresses a
+JOIN contacts c ON c.id = a.contact_id
+WHERE c.user_id = ? AND a.deleted_at IS NOT NULL`
+
+type TrashRepository struct {
+	Log *logrus.Logger
+}
+
+func NewTrashRepository(log *logrus.Logger) *TrashRepository {
+	return &TrashRepository{
+		Log: log,
+	}
+}
+
+// Search returns a page of the user's trash, most recently deleted first.
+func (r *TrashRepository) Search(db *gorm.DB, request *model.SearchTrashRequest) ([]entity.TrashItem, int64, error) {
+	query := db.Table("(?) AS trash", db.Raw(trashQuery, request.UserId, request.UserId))
+	if request.Type != "" {
+		query = query.Where("type = ?", request.Type)
+	}
+
+	var items []entity.TrashItem
+	if err := query.Session(&gorm.Session{}).Order("deleted_at DESC, id").
+		Offset((request.Page - 1) * request.Size).Limit(request.Size).Find(&items).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var total int64 = 0
+	if err := query.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	return items, total, nil
+}
+
+// FindPurgedAvatars returns the id and avatar of the contacts with an avatar that
+// Purge with the same arguments deletes.
+func (r *TrashRepository) FindPurgedAvatars(db *gorm.DB, userId string, deletedBefore time.Time) ([]entity.Contact, error) {
+	query := db.Unscoped().Select("id", "avatar_id").Where("deleted_at < ? AND avatar_id <> ''", deletedBefore)
+	if userId != "" {
+		query = query.Where("user_id = ?", userId)
+	}
+	var contacts []entity.Contact
+	err := query.Find(&contacts).Error
+	return contacts, err
+}
+
+// Purge deletes for good the contacts and addresses that went to the trash before
+// deletedBefore, together with the remaining addresses, the notes, tag assignments,
+// emails and phone numbers of the purged contacts. An empty userId purges the trash
+// of every user. It returns how many contacts and addresses were deleted.
+func (r *TrashRepository) Purge(db *gorm.DB, userId string, deletedBefore time.Time) (int64, error) {
+	owned := db.Unscoped().Model(&entity.Contact{}).Select("id")
+	if userId != "" {
+		owned = owned.Where("user_id = ?", userId)
+	}
+	purged := owned.Session(&gorm.Session{}).Where("deleted_at < ?", deletedBefore)
+
+	addresses := db.Unscoped().
+		Where("contact_id IN (?) AND deleted_at < ?", owned, deletedBefore).
+		Or("contact_id IN (?)", purged).
+		Delete(&entity.Address{})
+	if addresses.Error != nil {
+		return 0, addresses.Error
+	}
+
+	for _, rows := range []any{&entity.ContactTag{}, &entity.Note{}, &entity.ContactEmail{}, &entity.ContactPhone{}} {
+		if err := db.Where("contact_id IN (?)", purged).Delete(rows).Error; err != nil {
+			return 0, err
+		}
+	}
+
+	// MySQL cannot delete from a table it selects from, so the contacts are matched directly
+	contacts := db.Unscoped().Where("deleted_at < ?", deletedBefore)
+	if userId != "" {
+		contacts = contacts.Where("user_id = ?", userId)
+	}
+	contacts = contacts.Delete(&entity.Contact{})
+	if contacts.Error != nil {
+		return 0, contacts.Error
+	}
+
+	return addresses.RowsAffected + contacts.RowsAffected, nil
+}
@@ -18,10 +18,69 @@ func NewUserRepository(log *logrus.Logger) *UserRepository {
 	}
 }
 
-func (r *UserRepository) FindByToken(db *gorm.DB, user *entity.User, token string) error {
-	return db.Where("token = ?", token).First(user).Error
+// FindByIdShared is FindById with concurrent lookups of the same user collapsed into one query.
+func (r *UserRepository) FindByIdShared(db *gorm.DB, user *entity.User, id string) error {
+	return r.Shared(db, id, user, func(db *gorm.DB, result *entity.User) error {
+		return r.FindById(db, result, id)
+	})
 }
 
-func (r *UserRepository) FindByRefreshToken(db *gorm.DB, user *entity.User, refreshToken string) error {
-	return db.Where("refresh_token = ?", refreshToken).First(user).Error
+// FindByEmail finds the user with the email address, ignoring case.
+func (r *UserRepository) FindByEmail(db *gorm.DB, user *entity.User, email string) error {
+	return db.Where("lower(email) = lower(?)", email).Take(user).Error
+}
+
+// FindIdsDeletedBefore returns up to limit users who deleted their account before deletedBefore.
+func (r *UserRepository) FindIdsDeletedBefore(db *gorm.DB, deletedBefore int64, limit int) ([]string, error) {
+	var ids []string
+	err := db.Model(new(entity.User)).Where("deleted_at < ?", deletedBefore).Order("deleted_at").Limit(limit).Pluck("id", &ids).Error
+	return ids, err
+}
+
+// FindContactAvatars returns the id and avatar of the contacts of a user with an
+// avatar, those in the trash included.
+func (r *UserRepository) FindContactAvatars(db *gorm.DB, userId string) ([]entity.Contact, error) {
+	var contacts []entity.Contact
+	err := db.Unscoped().Select("id", "avatar_id").Where("user_id = ? AND avatar_id <> ''", userId).Find(&contacts).Error
+	return contacts, err
+}
+
+// Purge deletes for good a user and every row they own. Audit log entries of the
+// user are kept, but anonymized: their changes and IP are cleared.
+func (r *UserRepository) Purge(db *gorm.DB, userId string) error {
+	contacts := db.Unscoped().Model(&entity.Contact{}).Select("id").Where("user_id = ?", userId)
+	webhooks := db.Model(&entity.Webhook{}).Select("id").Where("user_id = ?", userId)
+
+	if err := db.Unscoped().Where("contact_id IN (?)", contacts).Delete(&entity.Address{}).Error; err != nil {
+		return err
+	}
+	for _, rows := range []any{&entity.ContactTag{}, &entity.Note{}, &entity.ContactEmail{}, &entity.ContactPhone{}} {
+		if err := db.Where("contact_id IN (?)", contacts).Delete(rows).Error; err != nil {
+			return err
+		}
+	}
+	if err := db.Where("webhook_id IN (?)", webhooks).Delete(&entity.WebhookDelivery{}).Error; err != nil {
+		return err
+	}
+
+	owned := []any{&entity.Reminder{}, &entity.Contact{}, &entity.Tag{}, &entity.ContactChange{}, &entity.ContactImport{}, &entity.Webhook{}, &entity.APIKey{},
+		&entity.Session{}, &entity.PasswordReset{}, &entity.MagicLink{}, &entity.Passkey{}, &entity.WebAuthnChallenge{}, &entity.LoginEvent{},
+		&entity.ExperimentAssignment{}, &entity.UserActivity{}, &entity.DebugCapture{}}
+	for _, rows := range owned {
+		if err := db.Unscoped().Where("user_id = ?", userId).Delete(rows).Error; err != nil {
+			return err
+		}
+	}
+
+	if err := db.Model(&entity.AuditLog{}).Where("user_id = ?", userId).
+		Updates(map[string]any{"changes": "{}", "ip": ""}).Error; err != nil {
+		return err
+	}
+
+	return db.Where("id = ?", userId).Delete(&entity.User{}).Error
+}
+
+// CountByEmail counts the users other than excludeId with the email address, ignoring case.
+func (r *UserRepository) CountByEmail(db *gorm.DB, email string, excludeId string) (int64, error) {
+	return r.Count(db, "lower(email) = lower(?) AND id <> ?", email, excludeId)
 }
@@ -0,0 +1,119 @@
+package repository
+
+import (
+	"go-rest-scaffold/internal/entity"
+	"go-rest-scaffold/internal/model"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+type WebhookRepository struct {
+	Repository[entity.Webhook]
+	Log *logrus.Logger
+}
+
+func NewWebhookRepository(log *logrus.Logger) *WebhookRepository {
+	return &WebhookRepository{
+		Log: log,
+	}
+}
+
+func (r *WebhookRepository) FindByIdAndUserId(db *gorm.DB, webhook *entity.Webhook, id string, userId string) error {
+	return db.Where("id = ? AND user_id = ?", id, userId).Take(webhook).Error
+}
+
+func (r *WebhookRepository) FindAllByUserId(db *gorm.DB, userId string) ([]entity.Webhook, error) {
+	var webhooks []entity.Webhook
+	if err := db.Where("user_id = ?", userId).Order("created_at").Find(&webhooks).Error; err != nil {
+		return nil, err
+	}
+	return webhooks, nil
+}
+
+// FindActiveByUserId returns the webhooks of a user that currently receive events.
+func (r *WebhookRepository) FindActiveByUserId(db *gorm.DB, userId string) ([]entity.Webhook, error) {
+	var webhooks []entity.Webhook
+	if err := db.Where("user_id = ? AND active", userId).Find(&webhooks).Error; err != nil {
+		return nil, err
+	}
+	return webhooks, nil
+}
+
+type WebhookDeliveryRepository struct {
+	Repository[entity.WebhookDelivery]
+	Log *logrus.Logger
+}
+
+func NewWebhookDeliveryRepository(log *logrus.Logger) *WebhookDeliveryRepository {
+	return &WebhookDeliveryRepository{
+		Log: log,
+	}
+}
+
+func (r *WebhookDeliveryRepository) FindByIdAndWebhookId(db *gorm.DB, delivery *entity.WebhookDelivery, id string, webhookId string) error {
+	return db.Where("id = ? AND webhook_id = ?", id, webhookId).Take(delivery).Error
+}
+
+func (r *WebhookDeliveryRepository) CountByWebhookIdAndEventId(db *gorm.DB, webhookId string, eventId string) (int64, error) {
+	return r.Count(db, "webhook_id = ? AND event_id = ?", webhookId, eventId)
+}
+
+// CountSucceeded counts the successful attempts to deliver the event to the webhook.
+func (r *WebhookDeliveryRepository) CountSucceeded(db *gorm.DB, webhookId string, eventId string) (int64, error) {
+	return r.Count(db, "webhook_id = ? AND event_id = ? AND status = ?", webhookId, eventId, model.WebhookDeliverySucceeded)
+}
+
+// FindDueRetries returns up to limit failed attempts whose retry is due at now, the
+// longest due first.
+func (r *WebhookDeliveryRepository) FindDueRetries(db *gorm.DB, now int64, limit int) ([]entity.WebhookDelivery, error) {
+	var deliveries []entity.WebhookDelivery
+	err := db.Where("next_attempt_at <= ?", now).Order("next_attempt_at").Limit(limit).Find(&deliveries).Error
+	return deliveries, err
+}
+
+// ClaimRetry clears the retry of a failed attempt unless another run cleared it since
+// it was read, and reports whether it did, so an attempt is retried once.
+func (r *WebhookDeliveryRepository) ClaimRetry(db *gorm.DB, delivery *entity.WebhookDelivery) (bool, error) {
+	result := db.Model(&entity.WebhookDelivery{}).Where("id = ? AND next_attempt_at = ?", delivery.ID, *delivery.NextAttemptAt).
+		Update("next_attempt_at", nil)
+	return result.RowsAffected == 1, result.Error
+}
+
+// FindUndeliveredFailures returns the latest failed attempt of every event that failed
+// between from and to and has no successful attempt, in the order they failed.
+func (r *WebhookDeliveryRepository) FindUndeliveredFailures(db *gorm.DB, webhookId string, from int64, to int64, limit int) ([]entity.WebhookDelivery, error) {
+	var deliveries []entity.WebhookDelivery
+	err := db.Raw(`SELECT * FROM (
+			SELECT d.*, row_number() OVER (PARTITION BY d.event_id ORDER BY d.created_at DESC) AS attempt FROM webhook_deliveries d
+			WHERE d.webhook_id = ? AND d.status = ? AND d.created_at >= ? AND d.created_at < ?
+			AND NOT EXISTS (SELECT 1 FROM webhook_deliveries s WHERE s.webhook_id = d.webhook_id AND s.event_id = d.event_id AND s.status = ?)
+		) latest WHERE attempt = 1 ORDER BY created_at LIMIT ?`, webhookId, model.WebhookDeliveryFailed, from, to, model.WebhookDeliverySucceeded, limit).
+		Scan(&deliveries).Error
+	return deliveries, err
+}
+
+func (r *WebhookDeliveryRepository) Search(db *gorm.DB, request *model.SearchWebhookDeliveryRequest) ([]entity.WebhookDelivery, int64, error) {
+	var deliveries []entity.WebhookDelivery
+	if err := db.Scopes(r.FilterWebhookDelivery(request)).Order("created_at DESC").
+		Offset((request.Page - 1) * request.Size).Limit(request.Size).Find(&deliveries).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var total int64 = 0
+	if err := db.Model(&entity.WebhookDelivery{}).Scopes(r.FilterWebhookDelivery(request)).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	return deliveries, total, nil
+}
+
+func (r *WebhookDeliveryRepository) FilterWebhookDelivery(request *model.SearchWebhookDeliveryRequest) func(tx *gorm.DB) *gorm.DB {
+	return func(tx *gorm.DB) *gorm.DB {
+		tx = tx.Where("webhook_id = ?", request.WebhookId)
+		if status := request.Status; status != "" {
+			tx = tx.Where("status = ?", status)
+		}
+		return tx
+	}
+}
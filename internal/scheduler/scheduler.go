@@ -0,0 +1,98 @@
+// Package scheduler runs recurring tasks (trash purge, token cleanup, statistics
+// aggregation, ...) on cron schedules.
+package scheduler
+
+import (
+	"context"
+	"go-rest-scaffold/internal/metrics"
+	"sync/atomic"
+	"time"
+
+	"github.com/robfig/cron/v3"
+	"github.com/sirupsen/logrus"
+)
+
+// Task is the work of a job. It returns how many items it processed, for the logs
+// and the metrics.
+type Task func(ctx context.Context) (int64, error)
+
+type job struct {
+	name     string
+	schedule cron.Schedule
+	task     Task
+}
+
+// Scheduler runs every job on its schedule, in UTC. A run that is due while the
+// previous run of the same job is still going is skipped, so runs never overlap.
+type Scheduler struct {
+	Log  *logrus.Logger
+	jobs []*job
+}
+
+func New(log *logrus.Logger) *Scheduler {
+	return &Scheduler{
+		Log: log,
+	}
+}
+
+// Add schedules task as the job name. spec is a standard cron expression with five
+// fields ("0 3 * * *") or a descriptor ("@hourly", "@every 15m").
+func (s *Scheduler) Add(name string, spec string, task Task) error {
+	schedule, err := cron.ParseStandard(spec)
+	if err != nil {
+		return err
+	}
+
+	s.jobs = append(s.jobs, &job{name: name, schedule: schedule, task: task})
+	return nil
+}
+
+// Run runs the jobs until ctx is done, then waits for the runs in progress. It is
+// meant to run on the leader only, which keeps the runs of the instances apart; runs
+// for different regions, each with its own ctx, do not hold each other back.
+func (s *Scheduler) Run(ctx context.Context) {
+	runner := cron.New(cron.WithLocation(time.UTC))
+	for _, job := range s.jobs {
+		running := new(atomic.Bool)
+		runner.Schedule(job.schedule, cron.FuncJob(func() {
+			s.run(ctx, job, running)
+		}))
+	}
+
+	runner.Start()
+	<-ctx.Done()
+	<-runner.Stop().Done()
+}
+
+func (s *Scheduler) run(ctx context.Context, job *job, running *atomic.Bool) {
+	log := s.Log.WithContext(ctx).WithField("job", job.name)
+	if !running.CompareAndSwap(false, true) {
+		metrics.ScheduledJobRuns.WithLabelValues(job.name, "skipped").Inc()
+		log.Warn("Skipped scheduled job, its previous run is still going")
+		return
+	}
+	defer running.Store(false)
+
+	defer func() {
+		if r := recover(); r != nil {
+			metrics.ScheduledJobRuns.WithLabelValues(job.name, "failure").Inc()
+			log.Errorf("Scheduled job panicked : %+v", r)
+		}
+	}()
+
+	start := time.Now()
+	items, err := job.task(ctx)
+	metrics.ScheduledJobSeconds.WithLabelValues(job.name).Observe(time.Since(start).Seconds())
+	if err != nil {
+		metrics.ScheduledJobRuns.WithLabelValues(job.name, "failure").Inc()
+		log.WithError(err).Error("Scheduled job failed")
+		return
+	}
+
+	metrics.ScheduledJobRuns.WithLabelValues(job.name, "success").Inc()
+	metrics.ScheduledJobItems.WithLabelValues(job.name).Add(float64(items))
+	metrics.ScheduledJobLastSuccess.WithLabelValues(job.name).SetToCurrentTime()
+	if items > 0 {
+		log.Infof("Scheduled job processed %d items", items)
+	}
+}
@@ -0,0 +1,19 @@
+// Package tracing starts the OpenTelemetry spans of the application. Spans go to the
+// global tracer provider, which exports nothing until tracing is configured, so
+// instrumented code costs next to nothing while tracing is off.
+package tracing
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// InstrumentationName names the tracer of the application code.
+const InstrumentationName = "go-rest-scaffold"
+
+// Start starts a span named name, a child of the span of ctx if there is one.
+func Start(ctx context.Context, name string, options ...trace.SpanStartOption) (context.Context, trace.Span) {
+	return otel.Tracer(InstrumentationName).Start(ctx, name, options...)
+}
@@ -0,0 +1,307 @@
+package usecase
+
+import (
+	"context"
+	"database/sql"
+	"go-rest-scaffold/internal/entity"
+	"go-rest-scaffold/internal/event"
+	"go-rest-scaffold/internal/idgen"
+	"go-rest-scaffold/internal/model"
+	"go-rest-scaffold/internal/model/converter"
+	"go-rest-scaffold/internal/repository"
+	"go-rest-scaffold/internal/tracing"
+	"strings"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// AccountUseCase moves a whole account between instances of the service.
+type AccountUseCase struct {
+	TxManager          TxManager
+	Log                *logrus.Logger
+	Validate           *validator.Validate
+	UserRepository     *repository.UserRepository
+	ContactRepository  *repository.ContactRepository
+	AddressRepository  *repository.AddressRepository
+	ReminderRepository *repository.ReminderRepository
+	WebhookRepository  *repository.WebhookRepository
+	EventBus           *event.Bus
+	IDs                *idgen.Generators
+}
+
+func NewAccountUseCase(txManager TxManager, logger *logrus.Logger, validate *validator.Validate, userRepository *repository.UserRepository,
+	contactRepository *repository.ContactRepository, addressRepository *repository.AddressRepository,
+	reminderRepository *repository.ReminderRepository, webhookRepository *repository.WebhookRepository, eventBus *event.Bus,
+	ids *idgen.Generators) *AccountUseCase {
+	return &AccountUseCase{
+		TxManager:          txManager,
+		Log:                logger,
+		Validate:           validate,
+		UserRepository:     userRepository,
+		ContactRepository:  contactRepository,
+		AddressRepository:  addressRepository,
+		ReminderRepository: reminderRepository,
+		WebhookRepository:  webhookRepository,
+		EventBus:           eventBus,
+		IDs:                ids,
+	}
+}
+
+func (c *AccountUseCase) Export(ctx context.Context, request *model.ExportAccountRequest) (*model.AccountArchive, error) {
+	ctx, span := tracing.Start(ctx, "AccountUseCase.Export")
+	defer span.End()
+
+	// a single snapshot keeps the archive consistent while the account keeps changing
+	tx := c.TxManager.Begin(ctx, &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+	defer tx.Rollback()
+
+	if err := c.Validate.Struct(request); err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to validate request")
+		return nil, fiber.ErrBadRequest
+	}
+
+	user := new(entity.User)
+	if err := c.UserRepository.FindById(tx, user, request.UserId); err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to find user")
+		return nil, fiber.ErrNotFound
+	}
+
+	contacts, err := c.ContactRepository.FindAllByUserId(tx, user.ID)
+	if err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to find contacts")
+		return nil, fiber.ErrInternalServerError
+	}
+
+	reminders, err := c.ReminderRepository.FindAllByUserId(tx, user.ID)
+	if err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to find reminders")
+		return nil, fiber.ErrInternalServerError
+	}
+
+	webhooks, err := c.WebhookRepository.FindAllByUserId(tx, user.ID)
+	if err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to find webhooks")
+		return nil, fiber.ErrInternalServerError
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to commit transaction")
+		return nil, fiber.ErrInternalServerError
+	}
+
+	// reminders of contacts in the trash would point at contacts the archive leaves out
+	exported := make(map[string]bool, len(contacts))
+	for _, contact := range contacts {
+		exported[contact.ID] = true
+	}
+	kept := reminders[:0]
+	for _, reminder := range reminders {
+		if exported[reminder.ContactId] {
+			kept = append(kept, reminder)
+		}
+	}
+
+	return converter.AccountToArchive(user, contacts, kept, webhooks, time.Now().UnixMilli()), nil
+}
+
+// Import adds the content of an archive to the current account in a single
+// transaction. Preserved IDs must not exist on this instance yet, remapped ones
+// are generated, so the same archive can be imported more than once.
+func (c *AccountUseCase) Import(ctx context.Context, request *model.ImportAccountRequest) (*model.ImportAccountResponse, error) {
+	ctx, span := tracing.Start(ctx, "AccountUseCase.Import")
+	defer span.End()
+
+	tx := c.TxManager.Begin(ctx)
+	defer tx.Rollback()
+
+	if err := c.Validate.Struct(request); err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to validate archive")
+		return nil, err
+	}
+
+	var idErr error
+	newId := func(entity string, id string) string {
+		if request.IDs != model.ImportRemapIds {
+			return id
+		}
+		generated, err := c.IDs.NewID(ctx, entity)
+		if err != nil && idErr == nil {
+			idErr = err
+		}
+		return generated
+	}
+
+	archive := request.Archive
+	contactIds := make(map[string]string, len(archive.Contacts))
+	var contacts []entity.Contact
+	var addresses []entity.Address
+	for _, item := range archive.Contacts {
+		if _, ok := contactIds[item.ID]; ok {
+			return nil, fiber.NewError(fiber.StatusBadRequest, "contact "+item.ID+" appears more than once in the archive")
+		}
+		contactIds[item.ID] = newId(idgen.Contact, item.ID)
+
+		// archives of older versions of the service carry a single email and phone
+		emails, phones, err := toContactChannels(item.Email, item.Emails, item.Phone, item.Phones)
+		if err != nil {
+			return nil, fiber.NewError(fiber.StatusBadRequest, "contact "+item.ID+" has more than one primary email or phone")
+		}
+
+		contacts = append(contacts, entity.Contact{
+			ID:        contactIds[item.ID],
+			FirstName: item.FirstName,
+			LastName:  item.LastName,
+			Emails:    emails,
+			Phones:    phones,
+			UserId:    request.UserId,
+			CreatedAt: item.CreatedAt,
+			UpdatedAt: item.UpdatedAt,
+		})
+		for _, address := range item.Addresses {
+			addresses = append(addresses, entity.Address{
+				ID:         newId(idgen.Address, address.ID),
+				ContactId:  contactIds[item.ID],
+				Street:     address.Street,
+				City:       address.City,
+				Province:   address.Province,
+				PostalCode: address.PostalCode,
+				Country:    address.Country,
+				CreatedAt:  address.CreatedAt,
+				UpdatedAt:  address.UpdatedAt,
+			})
+		}
+	}
+
+	reminders := make([]entity.Reminder, len(archive.Reminders))
+	for i, item := range archive.Reminders {
+		contactId, ok := contactIds[item.ContactId]
+		if !ok {
+			return nil, fiber.NewError(fiber.StatusBadRequest, "reminder "+item.ID+" is about contact "+item.ContactId+" which is not in the archive")
+		}
+
+		reminders[i] = entity.Reminder{
+			ID:        newId(idgen.Reminder, item.ID),
+			UserId:    request.UserId,
+			ContactId: contactId,
+			Note:      item.Note,
+			LocalTime: item.LocalTime,
+			Timezone:  item.Timezone,
+			RemindAt:  item.RemindAt,
+			Channels:  strings.Join(item.Channels, ","),
+			Status:    item.Status,
+			Error:     item.Error,
+			SentAt:    item.SentAt,
+			CreatedAt: item.CreatedAt,
+			UpdatedAt: item.UpdatedAt,
+		}
+	}
+
+	webhooks := make([]entity.Webhook, len(archive.Webhooks))
+	for i, item := range archive.Webhooks {
+		webhooks[i] = entity.Webhook{
+			ID:         newId(idgen.Webhook, item.ID),
+			UserId:     request.UserId,
+			URL:        item.URL,
+			EventTypes: strings.Join(item.EventTypes, ","),
+			Active:     item.Active,
+			CreatedAt:  item.CreatedAt,
+			UpdatedAt:  item.UpdatedAt,
+		}
+	}
+
+	if idErr != nil {
+		c.Log.WithContext(ctx).WithError(idErr).Error("failed to generate ids")
+		return nil, fiber.ErrInternalServerError
+	}
+
+	if request.IDs == model.ImportPreserveIds {
+		if err := c.checkIdsAvailable(tx, contacts, addresses, reminders, webhooks); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := c.ContactRepository.CreateInBatches(tx, contacts); err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to import contacts")
+		return nil, fiber.ErrInternalServerError
+	}
+
+	if err := c.AddressRepository.CreateInBatches(tx, addresses); err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to import addresses")
+		return nil, fiber.ErrInternalServerError
+	}
+
+	if err := c.ReminderRepository.CreateInBatches(tx, reminders); err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to import reminders")
+		return nil, fiber.ErrInternalServerError
+	}
+
+	if err := c.WebhookRepository.CreateInBatches(tx, webhooks); err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to import webhooks")
+		return nil, fiber.ErrInternalServerError
+	}
+
+	response := &model.ImportAccountResponse{
+		IDs:       request.IDs,
+		Contacts:  len(contacts),
+		Addresses: len(addresses),
+		Reminders: len(reminders),
+		Webhooks:  len(webhooks),
+	}
+	event, err := c.EventBus.Record(ctx, tx, model.EventAccountImported, "users/"+request.UserId, request.UserId, response)
+	if err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to record event")
+		return nil, fiber.ErrInternalServerError
+	}
+
+	if err := commit(ctx, tx); err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to commit transaction")
+		return nil, fiber.ErrInternalServerError
+	}
+
+	c.EventBus.Deliver(ctx, event)
+
+	return response, nil
+}
+
+// checkIdsAvailable returns fiber.ErrConflict when an ID to preserve is already taken on this instance.
+func (c *AccountUseCase) checkIdsAvailable(tx *gorm.DB, contacts []entity.Contact, addresses []entity.Address,
+	reminders []entity.Reminder, webhooks []entity.Webhook) error {
+	checks := []struct {
+		resource string
+		count    func(db *gorm.DB, ids []string) (int64, error)
+		ids      []string
+	}{
+		{"contact", c.ContactRepository.CountByIds, make([]string, len(contacts))},
+		{"address", c.AddressRepository.CountByIds, make([]string, len(addresses))},
+		{"reminder", c.ReminderRepository.CountByIds, make([]string, len(reminders))},
+		{"webhook", c.WebhookRepository.CountByIds, make([]string, len(webhooks))},
+	}
+	for i := range contacts {
+		checks[0].ids[i] = contacts[i].ID
+	}
+	for i := range addresses {
+		checks[1].ids[i] = addresses[i].ID
+	}
+	for i := range reminders {
+		checks[2].ids[i] = reminders[i].ID
+	}
+	for i := range webhooks {
+		checks[3].ids[i] = webhooks[i].ID
+	}
+
+	for _, check := range checks {
+		total, err := check.count(tx, check.ids)
+		if err != nil {
+			c.Log.WithContext(tx.Statement.Context).WithError(err).Errorf("failed to count %s ids", check.resource)
+			return fiber.ErrInternalServerError
+		}
+		if total > 0 {
+			return fiber.NewError(fiber.StatusConflict, "some "+check.resource+" ids of the archive already exist, import with ids=remap instead")
+		}
+	}
+	return nil
+}
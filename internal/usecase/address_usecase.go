@@ -3,132 +3,258 @@ package usecase
 import (
 	"context"
 	"go-rest-scaffold/internal/entity"
+	"go-rest-scaffold/internal/event"
+	"go-rest-scaffold/internal/idgen"
 	"go-rest-scaffold/internal/model"
 	"go-rest-scaffold/internal/model/converter"
 	"go-rest-scaffold/internal/repository"
+	"go-rest-scaffold/internal/tracing"
 
 	"github.com/go-playground/validator/v10"
 	"github.com/gofiber/fiber/v2"
-	"github.com/google/uuid"
 	"github.com/sirupsen/logrus"
-	"gorm.io/gorm"
 )
 
 type AddressUseCase struct {
-	DB                *gorm.DB
+	TxManager         TxManager
 	Log               *logrus.Logger
 	Validate          *validator.Validate
 	AddressRepository *repository.AddressRepository
 	ContactRepository *repository.ContactRepository
+	EventBus          *event.Bus
+	AuditLog          *AuditLogUseCase
+	IDs               *idgen.Generators
 }
 
-func NewAddressUseCase(db *gorm.DB, logger *logrus.Logger, validate *validator.Validate,
-	contactRepository *repository.ContactRepository, addressRepository *repository.AddressRepository) *AddressUseCase {
+func NewAddressUseCase(txManager TxManager, logger *logrus.Logger, validate *validator.Validate,
+	contactRepository *repository.ContactRepository, addressRepository *repository.AddressRepository,
+	eventBus *event.Bus, auditLog *AuditLogUseCase, ids *idgen.Generators) *AddressUseCase {
 	return &AddressUseCase{
-		DB:                db,
+		TxManager:         txManager,
 		Log:               logger,
 		Validate:          validate,
 		ContactRepository: contactRepository,
 		AddressRepository: addressRepository,
+		EventBus:          eventBus,
+		AuditLog:          auditLog,
+		IDs:               ids,
 	}
 }
 
 func (c *AddressUseCase) Create(ctx context.Context, request *model.CreateAddressRequest) (*model.AddressResponse, error) {
-	tx := c.DB.WithContext(ctx).Begin()
+	ctx, span := tracing.Start(ctx, "AddressUseCase.Create")
+	defer span.End()
+
+	tx := c.TxManager.Begin(ctx)
 	defer tx.Rollback()
 
 	if err := c.Validate.Struct(request); err != nil {
-		c.Log.WithError(err).Error("failed to validate request body")
+		c.Log.WithContext(ctx).WithError(err).Error("failed to validate request body")
 		return nil, fiber.ErrBadRequest
 	}
 
 	contact := new(entity.Contact)
 	if err := c.ContactRepository.FindByIdAndUserId(tx, contact, request.ContactId, request.UserId); err != nil {
-		c.Log.WithError(err).Error("failed to find contact")
+		c.Log.WithContext(ctx).WithError(err).Error("failed to find contact")
 		return nil, fiber.ErrNotFound
 	}
 
+	id, err := c.IDs.NewID(ctx, idgen.Address)
+	if err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to generate address id")
+		return nil, fiber.ErrInternalServerError
+	}
+
 	address := &entity.Address{
-		ID:         uuid.NewString(),
+		ID:         id,
 		ContactId:  contact.ID,
 		Street:     request.Street,
 		City:       request.City,
 		Province:   request.Province,
 		PostalCode: request.PostalCode,
 		Country:    request.Country,
+		Version:    1,
 	}
 
 	if err := c.AddressRepository.Create(tx, address); err != nil {
-		c.Log.WithError(err).Error("failed to create address")
+		c.Log.WithContext(ctx).WithError(err).Error("failed to create address")
 		return nil, fiber.ErrInternalServerError
 	}
 
-	if err := tx.Commit().Error; err != nil {
-		c.Log.WithError(err).Error("failed to commit transaction")
+	if err := c.AuditLog.Record(ctx, tx, model.AuditCreate, model.AuditAddress, address.ID, nil, converter.AddressToResponse(address)); err != nil {
+		return nil, err
+	}
+
+	response := converter.AddressToResponse(address)
+	event, err := c.EventBus.Record(ctx, tx, model.EventAddressCreated, "contacts/"+contact.ID+"/addresses/"+address.ID, contact.UserId, response)
+	if err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to record event")
 		return nil, fiber.ErrInternalServerError
 	}
 
-	return converter.AddressToResponse(address), nil
+	if err := commit(ctx, tx); err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to commit transaction")
+		return nil, fiber.ErrInternalServerError
+	}
+
+	c.EventBus.Deliver(ctx, event)
+
+	return response, nil
 }
 
 func (c *AddressUseCase) Update(ctx context.Context, request *model.UpdateAddressRequest) (*model.AddressResponse, error) {
-	tx := c.DB.WithContext(ctx).Begin()
+	ctx, span := tracing.Start(ctx, "AddressUseCase.Update")
+	defer span.End()
+
+	tx := c.TxManager.Begin(ctx)
 	defer tx.Rollback()
 
 	if err := c.Validate.Struct(request); err != nil {
-		c.Log.WithError(err).Error("failed to validate request body")
+		c.Log.WithContext(ctx).WithError(err).Error("failed to validate request body")
 		return nil, fiber.ErrBadRequest
 	}
 
 	contact := new(entity.Contact)
 	if err := c.ContactRepository.FindByIdAndUserId(tx, contact, request.ContactId, request.UserId); err != nil {
-		c.Log.WithError(err).Error("failed to find contact")
+		c.Log.WithContext(ctx).WithError(err).Error("failed to find contact")
 		return nil, fiber.ErrNotFound
 	}
 
 	address := new(entity.Address)
 	if err := c.AddressRepository.FindByIdAndContactId(tx, address, request.ID, contact.ID); err != nil {
-		c.Log.WithError(err).Error("failed to find address")
+		c.Log.WithContext(ctx).WithError(err).Error("failed to find address")
 		return nil, fiber.ErrNotFound
 	}
 
+	if err := checkVersion("address", request.Version, address.Version); err != nil {
+		return nil, err
+	}
+
+	before := converter.AddressToResponse(address)
 	address.Street = request.Street
 	address.City = request.City
 	address.Province = request.Province
 	address.PostalCode = request.PostalCode
 	address.Country = request.Country
+	address.Version++
 
-	if err := c.AddressRepository.Update(tx, address); err != nil {
-		c.Log.WithError(err).Error("failed to update address")
+	updated, err := c.AddressRepository.UpdateVersion(tx, address, before.Version)
+	if err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to update address")
 		return nil, fiber.ErrInternalServerError
 	}
+	if !updated {
+		return nil, errConcurrentChange("address")
+	}
 
-	if err := tx.Commit().Error; err != nil {
-		c.Log.WithError(err).Error("failed to commit transaction")
+	if err := c.AuditLog.Record(ctx, tx, model.AuditUpdate, model.AuditAddress, address.ID, before, converter.AddressToResponse(address)); err != nil {
+		return nil, err
+	}
+
+	response := converter.AddressToResponse(address)
+	event, err := c.EventBus.Record(ctx, tx, model.EventAddressUpdated, "contacts/"+contact.ID+"/addresses/"+address.ID, contact.UserId, response)
+	if err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to record event")
 		return nil, fiber.ErrInternalServerError
 	}
 
-	return converter.AddressToResponse(address), nil
+	if err := commit(ctx, tx); err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to commit transaction")
+		return nil, fiber.ErrInternalServerError
+	}
+
+	c.EventBus.Deliver(ctx, event)
+
+	return response, nil
+}
+
+func (c *AddressUseCase) Patch(ctx context.Context, request *model.PatchAddressRequest) (*model.AddressResponse, error) {
+	ctx, span := tracing.Start(ctx, "AddressUseCase.Patch")
+	defer span.End()
+
+	tx := c.TxManager.Begin(ctx)
+	defer tx.Rollback()
+
+	if err := c.Validate.Struct(request); err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to validate request body")
+		return nil, fiber.ErrBadRequest
+	}
+
+	contact := new(entity.Contact)
+	if err := c.ContactRepository.FindByIdAndUserId(tx, contact, request.ContactId, request.UserId); err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to find contact")
+		return nil, fiber.ErrNotFound
+	}
+
+	address := new(entity.Address)
+	if err := c.AddressRepository.FindByIdAndContactId(tx, address, request.ID, contact.ID); err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to find address")
+		return nil, fiber.ErrNotFound
+	}
+
+	if err := checkVersion("address", request.Version, address.Version); err != nil {
+		return nil, err
+	}
+
+	before := converter.AddressToResponse(address)
+	request.Street.Apply(&address.Street)
+	request.City.Apply(&address.City)
+	request.Province.Apply(&address.Province)
+	request.PostalCode.Apply(&address.PostalCode)
+	request.Country.Apply(&address.Country)
+	address.Version++
+
+	updated, err := c.AddressRepository.UpdateVersion(tx, address, before.Version)
+	if err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to update address")
+		return nil, fiber.ErrInternalServerError
+	}
+	if !updated {
+		return nil, errConcurrentChange("address")
+	}
+
+	if err := c.AuditLog.Record(ctx, tx, model.AuditUpdate, model.AuditAddress, address.ID, before, converter.AddressToResponse(address)); err != nil {
+		return nil, err
+	}
+
+	response := converter.AddressToResponse(address)
+	event, err := c.EventBus.Record(ctx, tx, model.EventAddressUpdated, "contacts/"+contact.ID+"/addresses/"+address.ID, contact.UserId, response)
+	if err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to record event")
+		return nil, fiber.ErrInternalServerError
+	}
+
+	if err := commit(ctx, tx); err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to commit transaction")
+		return nil, fiber.ErrInternalServerError
+	}
+
+	c.EventBus.Deliver(ctx, event)
+
+	return response, nil
 }
 
 func (c *AddressUseCase) Get(ctx context.Context, request *model.GetAddressRequest) (*model.AddressResponse, error) {
-	tx := c.DB.WithContext(ctx).Begin()
+	ctx, span := tracing.Start(ctx, "AddressUseCase.Get")
+	defer span.End()
+
+	tx := c.TxManager.Begin(ctx, readOnly)
 	defer tx.Rollback()
 
 	contact := new(entity.Contact)
 	if err := c.ContactRepository.FindByIdAndUserId(tx, contact, request.ContactId, request.UserId); err != nil {
-		c.Log.WithError(err).Error("failed to find contact")
+		c.Log.WithContext(ctx).WithError(err).Error("failed to find contact")
 		return nil, fiber.ErrNotFound
 	}
 
 	address := new(entity.Address)
 	if err := c.AddressRepository.FindByIdAndContactId(tx, address, request.ID, request.ContactId); err != nil {
-		c.Log.WithError(err).Error("failed to find address")
+		c.Log.WithContext(ctx).WithError(err).Error("failed to find address")
 		return nil, fiber.ErrNotFound
 	}
 
 	if err := tx.Commit().Error; err != nil {
-		c.Log.WithError(err).Error("failed to commit transaction")
+		c.Log.WithContext(ctx).WithError(err).Error("failed to commit transaction")
 		return nil, fiber.ErrInternalServerError
 	}
 
@@ -136,52 +262,75 @@ func (c *AddressUseCase) Get(ctx context.Context, request *model.GetAddressReque
 }
 
 func (c *AddressUseCase) Delete(ctx context.Context, request *model.DeleteAddressRequest) error {
-	tx := c.DB.WithContext(ctx).Begin()
+	ctx, span := tracing.Start(ctx, "AddressUseCase.Delete")
+	defer span.End()
+
+	tx := c.TxManager.Begin(ctx)
 	defer tx.Rollback()
 
 	contact := new(entity.Contact)
 	if err := c.ContactRepository.FindByIdAndUserId(tx, contact, request.ContactId, request.UserId); err != nil {
-		c.Log.WithError(err).Error("failed to find contact")
+		c.Log.WithContext(ctx).WithError(err).Error("failed to find contact")
 		return fiber.ErrNotFound
 	}
 
 	address := new(entity.Address)
 	if err := c.AddressRepository.FindByIdAndContactId(tx, address, request.ID, request.ContactId); err != nil {
-		c.Log.WithError(err).Error("failed to find address")
+		c.Log.WithContext(ctx).WithError(err).Error("failed to find address")
 		return fiber.ErrNotFound
 	}
 
+	if err := checkVersion("address", request.Version, address.Version); err != nil {
+		return err
+	}
+
 	if err := c.AddressRepository.Delete(tx, address); err != nil {
-		c.Log.WithError(err).Error("failed to delete address")
+		c.Log.WithContext(ctx).WithError(err).Error("failed to delete address")
 		return fiber.ErrInternalServerError
 	}
 
-	if err := tx.Commit().Error; err != nil {
-		c.Log.WithError(err).Error("failed to commit transaction")
+	if err := c.AuditLog.Record(ctx, tx, model.AuditDelete, model.AuditAddress, address.ID, converter.AddressToResponse(address), nil); err != nil {
+		return err
+	}
+
+	event, err := c.EventBus.Record(ctx, tx, model.EventAddressDeleted, "contacts/"+contact.ID+"/addresses/"+address.ID, contact.UserId,
+		converter.AddressToResponse(address))
+	if err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to record event")
 		return fiber.ErrInternalServerError
 	}
 
+	if err := commit(ctx, tx); err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to commit transaction")
+		return fiber.ErrInternalServerError
+	}
+
+	c.EventBus.Deliver(ctx, event)
+
 	return nil
 }
 
 func (c *AddressUseCase) List(ctx context.Context, request *model.ListAddressRequest) ([]model.AddressResponse, error) {
-	tx := c.DB.WithContext(ctx).Begin()
+	ctx, span := tracing.Start(ctx, "AddressUseCase.List")
+	defer span.End()
+
+	tx := c.TxManager.Begin(ctx, readOnly)
 	defer tx.Rollback()
 
 	contact := new(entity.Contact)
 	if err := c.ContactRepository.FindByIdAndUserId(tx, contact, request.ContactId, request.UserId); err != nil {
-		c.Log.WithError(err).Error("failed to find contact")
+		c.Log.WithContext(ctx).WithError(err).Error("failed to find contact")
 		return nil, fiber.ErrNotFound
 	}
 
 	addresses, err := c.AddressRepository.FindAllByContactId(tx, contact.ID)
 	if err != nil {
-		c.Log.WithError(err).Error("failed to find addresses")
+		c.Log.WithContext(ctx).WithError(err).Error("failed to find addresses")
 		return nil, fiber.ErrInternalServerError
 	}
 
 	if err := tx.Commit().Error; err != nil {
-		c.Log.WithError(err).Error("failed to commit transaction")
+		c.Log.WithContext(ctx).WithError(err).Error("failed to commit transaction")
 		return nil, fiber.ErrInternalServerError
 	}
 
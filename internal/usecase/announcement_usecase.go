@@ -0,0 +1,250 @@
+package usecase
+
+import (
+	"context"
+	"go-rest-scaffold/internal/entity"
+	"go-rest-scaffold/internal/idgen"
+	"go-rest-scaffold/internal/model"
+	"go-rest-scaffold/internal/model/converter"
+	"go-rest-scaffold/internal/repository"
+	"go-rest-scaffold/internal/tracing"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"github.com/sirupsen/logrus"
+)
+
+// AnnouncementUseCase manages announcements, which are shared by every user and
+// therefore always live in the home region.
+type AnnouncementUseCase struct {
+	TxManager              TxManager
+	Log                    *logrus.Logger
+	Validate               *validator.Validate
+	AnnouncementRepository *repository.AnnouncementRepository
+	AuditLog               *AuditLogUseCase
+	IDs                    *idgen.Generators
+}
+
+func NewAnnouncementUseCase(txManager TxManager, logger *logrus.Logger, validate *validator.Validate,
+	announcementRepository *repository.AnnouncementRepository, auditLog *AuditLogUseCase, ids *idgen.Generators) *AnnouncementUseCase {
+	return &AnnouncementUseCase{
+		TxManager:              txManager,
+		Log:                    logger,
+		Validate:               validate,
+		AnnouncementRepository: announcementRepository,
+		AuditLog:               auditLog,
+		IDs:                    ids,
+	}
+}
+
+func (c *AnnouncementUseCase) Create(ctx context.Context, request *model.CreateAnnouncementRequest) (*model.AnnouncementResponse, error) {
+	ctx, span := tracing.Start(ctx, "AnnouncementUseCase.Create")
+	defer span.End()
+
+	tx := c.TxManager.Begin(model.WithHomeRegion(ctx))
+	defer tx.Rollback()
+
+	if err := c.Validate.Struct(request); err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to validate request body")
+		return nil, err
+	}
+
+	id, err := c.IDs.NewID(model.WithHomeRegion(ctx), idgen.Announcement)
+	if err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to generate announcement id")
+		return nil, fiber.ErrInternalServerError
+	}
+
+	announcement := &entity.Announcement{
+		ID:       id,
+		Title:    request.Title,
+		Body:     request.Body,
+		Level:    request.Level,
+		StartsAt: request.StartsAt,
+		EndsAt:   request.EndsAt,
+	}
+	if announcement.StartsAt == 0 {
+		announcement.StartsAt = time.Now().UnixMilli()
+	}
+
+	if err := c.AnnouncementRepository.Create(tx, announcement); err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to create announcement")
+		return nil, fiber.ErrInternalServerError
+	}
+
+	if err := c.AuditLog.Record(ctx, tx, model.AuditCreate, model.AuditAnnouncement, announcement.ID, nil, converter.AnnouncementToResponse(announcement)); err != nil {
+		return nil, err
+	}
+
+	if err := commit(ctx, tx); err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to commit transaction")
+		return nil, fiber.ErrInternalServerError
+	}
+
+	return converter.AnnouncementToResponse(announcement), nil
+}
+
+func (c *AnnouncementUseCase) Update(ctx context.Context, request *model.UpdateAnnouncementRequest) (*model.AnnouncementResponse, error) {
+	ctx, span := tracing.Start(ctx, "AnnouncementUseCase.Update")
+	defer span.End()
+
+	tx := c.TxManager.Begin(model.WithHomeRegion(ctx))
+	defer tx.Rollback()
+
+	if err := c.Validate.Struct(request); err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to validate request body")
+		return nil, err
+	}
+
+	announcement := new(entity.Announcement)
+	if err := c.AnnouncementRepository.FindById(tx, announcement, request.ID); err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to find announcement")
+		return nil, fiber.ErrNotFound
+	}
+
+	before := converter.AnnouncementToResponse(announcement)
+	announcement.Title = request.Title
+	announcement.Body = request.Body
+	announcement.Level = request.Level
+	announcement.StartsAt = request.StartsAt
+	announcement.EndsAt = request.EndsAt
+	if announcement.StartsAt == 0 {
+		announcement.StartsAt = time.Now().UnixMilli()
+	}
+
+	if err := c.AnnouncementRepository.Update(tx, announcement); err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to update announcement")
+		return nil, fiber.ErrInternalServerError
+	}
+
+	if err := c.AuditLog.Record(ctx, tx, model.AuditUpdate, model.AuditAnnouncement, announcement.ID, before, converter.AnnouncementToResponse(announcement)); err != nil {
+		return nil, err
+	}
+
+	if err := commit(ctx, tx); err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to commit transaction")
+		return nil, fiber.ErrInternalServerError
+	}
+
+	return converter.AnnouncementToResponse(announcement), nil
+}
+
+func (c *AnnouncementUseCase) Get(ctx context.Context, request *model.GetAnnouncementRequest) (*model.AnnouncementResponse, error) {
+	ctx, span := tracing.Start(ctx, "AnnouncementUseCase.Get")
+	defer span.End()
+
+	tx := c.TxManager.Begin(model.WithHomeRegion(ctx))
+	defer tx.Rollback()
+
+	if err := c.Validate.Struct(request); err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to validate request body")
+		return nil, fiber.ErrBadRequest
+	}
+
+	announcement := new(entity.Announcement)
+	if err := c.AnnouncementRepository.FindById(tx, announcement, request.ID); err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to find announcement")
+		return nil, fiber.ErrNotFound
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to commit transaction")
+		return nil, fiber.ErrInternalServerError
+	}
+
+	return converter.AnnouncementToResponse(announcement), nil
+}
+
+func (c *AnnouncementUseCase) Delete(ctx context.Context, request *model.DeleteAnnouncementRequest) error {
+	ctx, span := tracing.Start(ctx, "AnnouncementUseCase.Delete")
+	defer span.End()
+
+	tx := c.TxManager.Begin(model.WithHomeRegion(ctx))
+	defer tx.Rollback()
+
+	if err := c.Validate.Struct(request); err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to validate request body")
+		return fiber.ErrBadRequest
+	}
+
+	announcement := new(entity.Announcement)
+	if err := c.AnnouncementRepository.FindById(tx, announcement, request.ID); err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to find announcement")
+		return fiber.ErrNotFound
+	}
+
+	if err := c.AnnouncementRepository.Delete(tx, announcement); err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to delete announcement")
+		return fiber.ErrInternalServerError
+	}
+
+	if err := c.AuditLog.Record(ctx, tx, model.AuditDelete, model.AuditAnnouncement, announcement.ID, converter.AnnouncementToResponse(announcement), nil); err != nil {
+		return err
+	}
+
+	if err := commit(ctx, tx); err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to commit transaction")
+		return fiber.ErrInternalServerError
+	}
+
+	return nil
+}
+
+func (c *AnnouncementUseCase) Search(ctx context.Context, request *model.SearchAnnouncementRequest) ([]model.AnnouncementResponse, int64, error) {
+	ctx, span := tracing.Start(ctx, "AnnouncementUseCase.Search")
+	defer span.End()
+
+	tx := c.TxManager.Begin(model.WithHomeRegion(ctx))
+	defer tx.Rollback()
+
+	if err := c.Validate.Struct(request); err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to validate request body")
+		return nil, 0, err
+	}
+
+	announcements, total, err := c.AnnouncementRepository.Search(tx, request)
+	if err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to search announcements")
+		return nil, 0, fiber.ErrInternalServerError
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to commit transaction")
+		return nil, 0, fiber.ErrInternalServerError
+	}
+
+	responses := make([]model.AnnouncementResponse, len(announcements))
+	for i, announcement := range announcements {
+		responses[i] = *converter.AnnouncementToResponse(&announcement)
+	}
+
+	return responses, total, nil
+}
+
+// Active returns the announcements clients should display right now.
+func (c *AnnouncementUseCase) Active(ctx context.Context) ([]model.AnnouncementResponse, error) {
+	ctx, span := tracing.Start(ctx, "AnnouncementUseCase.Active")
+	defer span.End()
+
+	tx := c.TxManager.Begin(model.WithHomeRegion(ctx))
+	defer tx.Rollback()
+
+	announcements, err := c.AnnouncementRepository.FindActive(tx, time.Now().UnixMilli())
+	if err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to find active announcements")
+		return nil, fiber.ErrInternalServerError
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to commit transaction")
+		return nil, fiber.ErrInternalServerError
+	}
+
+	responses := make([]model.AnnouncementResponse, len(announcements))
+	for i, announcement := range announcements {
+		responses[i] = *converter.AnnouncementToResponse(&announcement)
+	}
+
+	return responses, nil
+}
@@ -0,0 +1,253 @@
+package usecase
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"go-rest-scaffold/internal/entity"
+	"go-rest-scaffold/internal/model"
+	"go-rest-scaffold/internal/model/converter"
+	"go-rest-scaffold/internal/repository"
+	"go-rest-scaffold/internal/signing"
+	"go-rest-scaffold/internal/tracing"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+// apiKeyPrefix starts every API key, so leaked keys are easy to recognise in logs and
+// by secret scanners.
+const apiKeyPrefix = "sk_"
+
+// apiKeyUseResolution is how precisely the last use of a key is recorded.
+const apiKeyUseResolution = time.Minute
+
+var errAPIKeyScopes = fiber.NewError(fiber.StatusForbidden, "api key cannot have scopes the session does not have")
+
+// APIKeyUseCase manages the API keys service-to-service callers authenticate with
+// instead of a user session, and checks the keys they send.
+type APIKeyUseCase struct {
+	TxManager        TxManager
+	Log              *logrus.Logger
+	Validate         *validator.Validate
+	APIKeyRepository *repository.APIKeyRepository
+	UserRepository   *repository.UserRepository
+	AuditLog         *AuditLogUseCase
+	// Signer derives the secrets keys sign requests with, nil when signing is off.
+	Signer *signing.Signer
+	// Regions users can live in, the home region first. Empty for a single database.
+	Regions []string
+}
+
+func NewAPIKeyUseCase(txManager TxManager, logger *logrus.Logger, validate *validator.Validate, apiKeyRepository *repository.APIKeyRepository,
+	userRepository *repository.UserRepository, auditLog *AuditLogUseCase, signer *signing.Signer, regions []string) *APIKeyUseCase {
+	return &APIKeyUseCase{
+		TxManager:        txManager,
+		Log:              logger,
+		Validate:         validate,
+		APIKeyRepository: apiKeyRepository,
+		UserRepository:   userRepository,
+		AuditLog:         auditLog,
+		Signer:           signer,
+		Regions:          regions,
+	}
+}
+
+// Create issues a new key for the user. The response is the only place the key, and
+// the secret it signs requests with, are ever shown.
+func (c *APIKeyUseCase) Create(ctx context.Context, request *model.CreateAPIKeyRequest) (*model.APIKeyResponse, error) {
+	ctx, span := tracing.Start(ctx, "APIKeyUseCase.Create")
+	defer span.End()
+
+	tx := c.TxManager.Begin(ctx)
+	defer tx.Rollback()
+
+	if err := c.Validate.Struct(request); err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to validate request body")
+		return nil, err
+	}
+
+	// a limited session can only hand its own limits on, never lift them
+	if request.CallerScopes != nil {
+		if len(request.Scopes) == 0 {
+			return nil, errAPIKeyScopes
+		}
+		for _, scope := range request.Scopes {
+			if !slices.Contains(request.CallerScopes, scope) {
+				return nil, errAPIKeyScopes
+			}
+		}
+	}
+
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to generate api key")
+		return nil, fiber.ErrInternalServerError
+	}
+	key := apiKeyPrefix + base64.RawURLEncoding.EncodeToString(secret)
+	prefix := key[:len(apiKeyPrefix)+8]
+	// like session tokens, keys outside of the home region name it so they can be routed
+	if region := model.RegionFrom(ctx); region != "" {
+		key = region + "." + key
+	}
+
+	apiKey := &entity.APIKey{
+		ID:      uuid.NewString(),
+		UserId:  request.UserId,
+		Name:    request.Name,
+		Prefix:  prefix,
+		KeyHash: hashToken(key),
+		Scopes:  strings.Join(request.Scopes, " "),
+	}
+
+	if err := c.APIKeyRepository.Create(tx, apiKey); err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to create api key")
+		return nil, fiber.ErrInternalServerError
+	}
+
+	if err := c.AuditLog.Record(ctx, tx, model.AuditCreate, model.AuditAPIKey, apiKey.ID, nil, converter.APIKeyToResponse(apiKey)); err != nil {
+		return nil, err
+	}
+
+	if err := commit(ctx, tx); err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to commit transaction")
+		return nil, fiber.ErrInternalServerError
+	}
+
+	response := converter.APIKeyToResponse(apiKey)
+	response.Key = key
+	if c.Signer != nil {
+		response.SigningSecret = c.Signer.KeySecret(apiKey.ID)
+	}
+	return response, nil
+}
+
+// List returns every key of the user, revoked ones included.
+func (c *APIKeyUseCase) List(ctx context.Context, request *model.ListAPIKeyRequest) ([]model.APIKeyResponse, error) {
+	ctx, span := tracing.Start(ctx, "APIKeyUseCase.List")
+	defer span.End()
+
+	tx := c.TxManager.Begin(ctx)
+	defer tx.Rollback()
+
+	if err := c.Validate.Struct(request); err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to validate request body")
+		return nil, fiber.ErrBadRequest
+	}
+
+	apiKeys, err := c.APIKeyRepository.FindAllByUserId(tx, request.UserId)
+	if err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to find api keys")
+		return nil, fiber.ErrInternalServerError
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to commit transaction")
+		return nil, fiber.ErrInternalServerError
+	}
+
+	responses := make([]model.APIKeyResponse, len(apiKeys))
+	for i, apiKey := range apiKeys {
+		responses[i] = *converter.APIKeyToResponse(&apiKey)
+	}
+
+	return responses, nil
+}
+
+// Revoke stops a key from authenticating. The key stays listed as revoked; revoking it
+// again changes nothing.
+func (c *APIKeyUseCase) Revoke(ctx context.Context, request *model.RevokeAPIKeyRequest) (*model.APIKeyResponse, error) {
+	ctx, span := tracing.Start(ctx, "APIKeyUseCase.Revoke")
+	defer span.End()
+
+	tx := c.TxManager.Begin(ctx)
+	defer tx.Rollback()
+
+	if err := c.Validate.Struct(request); err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to validate request body")
+		return nil, fiber.ErrBadRequest
+	}
+
+	apiKey := new(entity.APIKey)
+	if err := c.APIKeyRepository.FindByIdAndUserId(tx, apiKey, request.ID, request.UserId); err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to find api key")
+		return nil, fiber.ErrNotFound
+	}
+
+	if apiKey.RevokedAt == nil {
+		before := converter.APIKeyToResponse(apiKey)
+		now := time.Now().UnixMilli()
+		apiKey.RevokedAt = &now
+		if err := c.APIKeyRepository.Update(tx, apiKey); err != nil {
+			c.Log.WithContext(ctx).WithError(err).Error("failed to revoke api key")
+			return nil, fiber.ErrInternalServerError
+		}
+
+		if err := c.AuditLog.Record(ctx, tx, model.AuditUpdate, model.AuditAPIKey, apiKey.ID, before, converter.APIKeyToResponse(apiKey)); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := commit(ctx, tx); err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to commit transaction")
+		return nil, fiber.ErrInternalServerError
+	}
+
+	return converter.APIKeyToResponse(apiKey), nil
+}
+
+// Verify authenticates a request by its API key, as the user owning the key.
+func (c *APIKeyUseCase) Verify(ctx context.Context, request *model.VerifyAPIKeyRequest) (*model.Auth, error) {
+	ctx, span := tracing.Start(ctx, "APIKeyUseCase.Verify")
+	defer span.End()
+
+	region, ok := tokenRegion(c.Regions, request.Key)
+	if !ok {
+		c.Log.WithContext(ctx).Warn("API key of unknown region")
+		return nil, fiber.ErrNotFound
+	}
+
+	tx := c.TxManager.Begin(model.WithRegion(ctx, region))
+	defer tx.Rollback()
+
+	if err := c.Validate.Struct(request); err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to validate request")
+		return nil, fiber.ErrBadRequest
+	}
+
+	apiKey := new(entity.APIKey)
+	if err := c.APIKeyRepository.FindUnrevokedByKeyHash(tx, apiKey, hashToken(request.Key)); err != nil {
+		c.Log.WithContext(ctx).WithError(err).Warn("failed to find api key")
+		return nil, fiber.ErrNotFound
+	}
+
+	user := new(entity.User)
+	if err := c.UserRepository.FindById(tx, user, apiKey.UserId); err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to find user of api key")
+		return nil, fiber.ErrNotFound
+	}
+
+	if user.Region != region {
+		c.Log.WithContext(ctx).Warnf("API key of user %s used outside of their home region", user.ID)
+		return nil, fiber.ErrNotFound
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to commit transaction")
+		return nil, fiber.ErrInternalServerError
+	}
+
+	// recording the use is bookkeeping, so failing to do so does not refuse the request
+	now := time.Now()
+	db := c.TxManager.DB(model.WithRegion(ctx, region))
+	if err := c.APIKeyRepository.TouchLastUsed(db, apiKey.ID, now.UnixMilli(), now.Add(-apiKeyUseResolution).UnixMilli()); err != nil {
+		c.Log.WithContext(ctx).WithError(err).Warn("failed to record api key use")
+	}
+
+	return &model.Auth{ID: user.ID, Role: user.Role, Region: user.Region, APIKeyId: apiKey.ID, Scopes: scopesOf(apiKey.Scopes)}, nil
+}
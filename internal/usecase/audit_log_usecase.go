@@ -0,0 +1,158 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"go-rest-scaffold/internal/entity"
+	"go-rest-scaffold/internal/model"
+	"go-rest-scaffold/internal/model/converter"
+	"go-rest-scaffold/internal/repository"
+	"go-rest-scaffold/internal/tracing"
+	"reflect"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+type AuditLogUseCase struct {
+	TxManager          TxManager
+	Log                *logrus.Logger
+	Validate           *validator.Validate
+	AuditLogRepository *repository.AuditLogRepository
+}
+
+func NewAuditLogUseCase(txManager TxManager, logger *logrus.Logger, validate *validator.Validate,
+	auditLogRepository *repository.AuditLogRepository) *AuditLogUseCase {
+	return &AuditLogUseCase{
+		TxManager:          txManager,
+		Log:                logger,
+		Validate:           validate,
+		AuditLogRepository: auditLogRepository,
+	}
+}
+
+// Record is the hook mutating use cases call inside their transaction, so the entry is
+// committed, or rolled back, with the change itself. before and after are the response
+// models of the entity, nil for a created or deleted one; only differing fields are kept.
+// A nil use case is valid and records nothing, which keeps use cases usable in isolation.
+func (c *AuditLogUseCase) Record(ctx context.Context, tx *gorm.DB, action string, entityType string, entityId string, before any, after any) error {
+	ctx, span := tracing.Start(ctx, "AuditLogUseCase.Record")
+	defer span.End()
+
+	if c == nil {
+		return nil
+	}
+
+	changes, err := auditDiff(before, after)
+	if err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to diff audited entity")
+		return fiber.ErrInternalServerError
+	}
+
+	content, err := json.Marshal(changes)
+	if err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to marshal audit changes")
+		return fiber.ErrInternalServerError
+	}
+
+	auditLog := &entity.AuditLog{
+		ID:             uuid.NewString(),
+		UserId:         model.ActorFrom(ctx),
+		Action:         action,
+		EntityType:     entityType,
+		EntityId:       entityId,
+		Changes:        string(content),
+		IP:             model.ClientIPFrom(ctx),
+		ImpersonatorId: model.ImpersonatorFrom(ctx),
+	}
+
+	if err := c.AuditLogRepository.Create(tx, auditLog); err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to create audit log")
+		return fiber.ErrInternalServerError
+	}
+
+	return nil
+}
+
+func (c *AuditLogUseCase) Search(ctx context.Context, request *model.SearchAuditLogRequest) ([]model.AuditLogResponse, int64, error) {
+	ctx, span := tracing.Start(ctx, "AuditLogUseCase.Search")
+	defer span.End()
+
+	tx := c.TxManager.Begin(ctx)
+	defer tx.Rollback()
+
+	if err := c.Validate.Struct(request); err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to validate request body")
+		return nil, 0, err
+	}
+
+	if request.From > 0 && request.To > 0 && request.From >= request.To {
+		c.Log.WithContext(ctx).Warnf("Empty audit log range : %d - %d", request.From, request.To)
+		return nil, 0, fiber.ErrBadRequest
+	}
+
+	auditLogs, total, err := c.AuditLogRepository.Search(tx, request)
+	if err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to search audit logs")
+		return nil, 0, fiber.ErrInternalServerError
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to commit transaction")
+		return nil, 0, fiber.ErrInternalServerError
+	}
+
+	responses := make([]model.AuditLogResponse, len(auditLogs))
+	for i, auditLog := range auditLogs {
+		responses[i] = *converter.AuditLogToResponse(&auditLog)
+	}
+
+	return responses, total, nil
+}
+
+// auditDiff compares the JSON forms of before and after field by field.
+func auditDiff(before any, after any) (map[string]model.AuditChange, error) {
+	beforeFields, err := auditFields(before)
+	if err != nil {
+		return nil, err
+	}
+	afterFields, err := auditFields(after)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := make(map[string]model.AuditChange)
+	for name, value := range beforeFields {
+		if other, ok := afterFields[name]; !ok || !reflect.DeepEqual(value, other) {
+			changes[name] = model.AuditChange{Before: value, After: afterFields[name]}
+		}
+	}
+	for name, value := range afterFields {
+		if _, ok := beforeFields[name]; !ok {
+			changes[name] = model.AuditChange{After: value}
+		}
+	}
+	return changes, nil
+}
+
+func auditFields(value any) (map[string]any, error) {
+	result := make(map[string]any)
+	if value == nil {
+		return result, nil
+	}
+	if reflected := reflect.ValueOf(value); reflected.Kind() == reflect.Pointer && reflected.IsNil() {
+		return result, nil
+	}
+
+	content, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(content, &result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
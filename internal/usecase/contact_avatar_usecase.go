@@ -0,0 +1,301 @@
+package usecase
+
+import (
+	"context"
+	"go-rest-scaffold/internal/entity"
+	"go-rest-scaffold/internal/event"
+	"go-rest-scaffold/internal/gateway/storage"
+	"go-rest-scaffold/internal/imaging"
+	"go-rest-scaffold/internal/model"
+	"go-rest-scaffold/internal/model/converter"
+	"go-rest-scaffold/internal/repository"
+	"go-rest-scaffold/internal/tracing"
+	"strconv"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// avatarSizes are the sizes every avatar is stored in, largest first.
+var avatarSizes = []int{model.AvatarLarge, model.AvatarMedium, model.AvatarSmall}
+
+// ContactAvatarOptions tunes the avatars of contacts.
+type ContactAvatarOptions struct {
+	// MaxBytes is the largest picture accepted.
+	MaxBytes int
+	// URLTTL is how long the URLs of the pictures stay valid at least. URLs are
+	// signed for twice as long and stay the same for URLTTL, so clients can cache
+	// the pictures.
+	URLTTL time.Duration
+}
+
+// ContactAvatarUseCase keeps the avatars of contacts: uploaded pictures scaled to
+// the sizes of model.ContactAvatar, kept in a storage.Store and read through signed URLs.
+type ContactAvatarUseCase struct {
+	TxManager         TxManager
+	Log               *logrus.Logger
+	Validate          *validator.Validate
+	ContactRepository *repository.ContactRepository
+	EventBus          *event.Bus
+	AuditLog          *AuditLogUseCase
+	Store             storage.Store
+	Options           ContactAvatarOptions
+}
+
+func NewContactAvatarUseCase(txManager TxManager, logger *logrus.Logger, validate *validator.Validate,
+	contactRepository *repository.ContactRepository, eventBus *event.Bus, auditLog *AuditLogUseCase, store storage.Store,
+	options ContactAvatarOptions) *ContactAvatarUseCase {
+	return &ContactAvatarUseCase{
+		TxManager:         txManager,
+		Log:               logger,
+		Validate:          validate,
+		ContactRepository: contactRepository,
+		EventBus:          eventBus,
+		AuditLog:          auditLog,
+		Store:             store,
+		Options:           options,
+	}
+}
+
+// Upload replaces the avatar of a contact. The pictures of the new avatar are stored
+// under a new ID before the contact names it, and those of the old one are deleted
+// once the change is committed, so readers never see a missing picture. The new
+// pictures are deleted again when the contact is not changed.
+func (c *ContactAvatarUseCase) Upload(ctx context.Context, request *model.UploadContactAvatarRequest) (*model.ContactResponse, error) {
+	ctx, span := tracing.Start(ctx, "ContactAvatarUseCase.Upload")
+	defer span.End()
+
+	if err := c.Validate.Struct(request); err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to validate request")
+		return nil, fiber.NewError(fiber.StatusBadRequest, "send the picture as the file field of a multipart/form-data body")
+	}
+	if len(request.Data) > c.Options.MaxBytes {
+		return nil, fiber.NewError(fiber.StatusRequestEntityTooLarge, "the picture is larger than "+strconv.Itoa(c.Options.MaxBytes)+" bytes")
+	}
+
+	// scaled before the transaction, which would otherwise be held open meanwhile
+	pictures, err := avatarPictures(request.Data)
+	if err != nil {
+		c.Log.WithContext(ctx).WithError(err).Warn("failed to read avatar")
+		return nil, fiber.NewError(fiber.StatusBadRequest, "the picture must be a JPEG, PNG or GIF image of at most "+
+			strconv.Itoa(imaging.MaxPixels)+" pixels")
+	}
+
+	// stored before the transaction as well, uploads to S3 being slow too
+	avatarId := uuid.NewString()
+	if !model.IsDryRun(ctx) {
+		for i, size := range avatarSizes {
+			if err := c.Store.Put(ctx, avatarKey(request.ContactId, avatarId, size), "image/jpeg", pictures[i]); err != nil {
+				c.Log.WithContext(ctx).WithError(err).Error("failed to store avatar")
+				c.deletePictures(ctx, request.ContactId, avatarId)
+				return nil, fiber.ErrInternalServerError
+			}
+		}
+	}
+
+	response, event, previous, err := c.upload(ctx, request, avatarId)
+	if err != nil {
+		if !model.IsDryRun(ctx) {
+			c.deletePictures(ctx, request.ContactId, avatarId)
+		}
+		return nil, err
+	}
+
+	c.EventBus.Deliver(ctx, event)
+	if !model.IsDryRun(ctx) {
+		c.deletePictures(ctx, request.ContactId, previous)
+	}
+
+	c.SignURLs(ctx, response)
+	return response, nil
+}
+
+// upload points the contact of request at avatarId, whose pictures are stored, and
+// returns it with the event to deliver and the ID of the avatar it had.
+func (c *ContactAvatarUseCase) upload(ctx context.Context, request *model.UploadContactAvatarRequest, avatarId string) (*model.ContactResponse, *model.CloudEvent, string, error) {
+	tx := c.TxManager.Begin(ctx)
+	defer tx.Rollback()
+
+	contact := new(entity.Contact)
+	if err := c.ContactRepository.FindByIdAndUserId(tx, contact, request.ContactId, request.UserId); err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to find contact")
+		return nil, nil, "", fiber.ErrNotFound
+	}
+
+	if err := checkVersion("contact", request.Version, contact.Version); err != nil {
+		return nil, nil, "", err
+	}
+
+	previous := contact.AvatarId
+	response, event, err := c.change(ctx, tx, contact, avatarId)
+	if err != nil {
+		return nil, nil, "", err
+	}
+
+	if err := commit(ctx, tx); err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to upload avatar")
+		return nil, nil, "", fiber.ErrInternalServerError
+	}
+	return response, event, previous, nil
+}
+
+// Delete removes the avatar of a contact, if it has one.
+func (c *ContactAvatarUseCase) Delete(ctx context.Context, request *model.DeleteContactAvatarRequest) (*model.ContactResponse, error) {
+	ctx, span := tracing.Start(ctx, "ContactAvatarUseCase.Delete")
+	defer span.End()
+
+	tx := c.TxManager.Begin(ctx)
+	defer tx.Rollback()
+
+	if err := c.Validate.Struct(request); err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to validate request")
+		return nil, fiber.ErrBadRequest
+	}
+
+	contact := new(entity.Contact)
+	if err := c.ContactRepository.FindByIdAndUserId(tx, contact, request.ContactId, request.UserId); err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to find contact")
+		return nil, fiber.ErrNotFound
+	}
+
+	if err := checkVersion("contact", request.Version, contact.Version); err != nil {
+		return nil, err
+	}
+
+	if contact.AvatarId == "" {
+		return converter.ContactToResponse(contact), nil
+	}
+
+	previous := contact.AvatarId
+	response, event, err := c.change(ctx, tx, contact, "")
+	if err != nil {
+		return nil, err
+	}
+
+	if err := commit(ctx, tx); err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to delete avatar")
+		return nil, fiber.ErrInternalServerError
+	}
+
+	c.EventBus.Deliver(ctx, event)
+	if !model.IsDryRun(ctx) {
+		c.deletePictures(ctx, contact.ID, previous)
+	}
+
+	return response, nil
+}
+
+// change points contact at avatarId in tx as a change of the contact, and returns it
+// with the event to deliver once tx is committed.
+func (c *ContactAvatarUseCase) change(ctx context.Context, tx *gorm.DB, contact *entity.Contact, avatarId string) (*model.ContactResponse, *model.CloudEvent, error) {
+	before := converter.ContactToResponse(contact)
+	contact.AvatarId = avatarId
+	contact.Version++
+
+	updated, err := c.ContactRepository.UpdateVersion(tx, contact, before.Version)
+	if err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to update contact")
+		return nil, nil, fiber.ErrInternalServerError
+	}
+	if !updated {
+		return nil, nil, errConcurrentChange("contact")
+	}
+
+	if err := c.AuditLog.Record(ctx, tx, model.AuditUpdate, model.AuditContact, contact.ID, before, converter.ContactToResponse(contact)); err != nil {
+		return nil, nil, err
+	}
+
+	event, err := c.EventBus.Record(ctx, tx, model.EventContactUpdated, "contacts/"+contact.ID, contact.UserId, converter.ContactToResponse(contact))
+	if err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to record event")
+		return nil, nil, fiber.ErrInternalServerError
+	}
+
+	// the event carries the response as it is now, without the URLs signed into it later
+	return converter.ContactToResponse(contact), event, nil
+}
+
+// SignURLs fills in the URLs of the avatars of contacts. Avatars whose URLs cannot
+// be signed are left with their ID only.
+func (c *ContactAvatarUseCase) SignURLs(ctx context.Context, contacts ...*model.ContactResponse) {
+	if c == nil {
+		return
+	}
+
+	signedAt := time.Now().Truncate(c.Options.URLTTL)
+	for _, contact := range contacts {
+		avatar := contact.Avatar
+		if avatar == nil {
+			continue
+		}
+
+		urls := make([]string, len(avatarSizes))
+		for i, size := range avatarSizes {
+			url, err := c.Store.URL(avatarKey(contact.ID, avatar.ID, size), signedAt, 2*c.Options.URLTTL)
+			if err != nil {
+				c.Log.WithContext(ctx).WithError(err).Warn("failed to sign avatar url")
+				urls = nil
+				break
+			}
+			urls[i] = url
+		}
+		if urls != nil {
+			avatar.Large, avatar.Medium, avatar.Small = urls[0], urls[1], urls[2]
+			avatar.ExpiresAt = signedAt.Add(2 * c.Options.URLTTL).UnixMilli()
+		}
+	}
+}
+
+// DeletePictures deletes the pictures of the avatars of contacts deleted for good.
+func (c *ContactAvatarUseCase) DeletePictures(ctx context.Context, contacts []entity.Contact) {
+	if c == nil || model.IsDryRun(ctx) {
+		return
+	}
+
+	for _, contact := range contacts {
+		c.deletePictures(ctx, contact.ID, contact.AvatarId)
+	}
+}
+
+// deletePictures deletes the pictures of an avatar. Failures only leave pictures
+// nothing names behind, so they are logged and otherwise ignored.
+func (c *ContactAvatarUseCase) deletePictures(ctx context.Context, contactId string, avatarId string) {
+	if avatarId == "" {
+		return
+	}
+
+	keys := make([]string, len(avatarSizes))
+	for i, size := range avatarSizes {
+		keys[i] = avatarKey(contactId, avatarId, size)
+	}
+	if err := c.Store.Delete(context.WithoutCancel(ctx), keys...); err != nil {
+		c.Log.WithContext(ctx).WithError(err).Warnf("Failed to delete the pictures of avatar %s", avatarId)
+	}
+}
+
+// avatarPictures returns data scaled to each of avatarSizes, as JPEG.
+func avatarPictures(data []byte) ([][]byte, error) {
+	img, err := imaging.Decode(data)
+	if err != nil {
+		return nil, err
+	}
+
+	pictures := make([][]byte, len(avatarSizes))
+	for i, size := range avatarSizes {
+		// every size but the largest is scaled from the one before, which is much faster
+		thumbnail := imaging.Thumbnail(img, size)
+		if pictures[i], err = imaging.EncodeJPEG(thumbnail); err != nil {
+			return nil, err
+		}
+		img = thumbnail
+	}
+	return pictures, nil
+}
+
+func avatarKey(contactId string, avatarId string, size int) string {
+	return "avatars/" + contactId + "/" + avatarId + "/" + strconv.Itoa(size) + ".jpg"
+}
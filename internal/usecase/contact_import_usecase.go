@@ -0,0 +1,463 @@
+package usecase
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go-rest-scaffold/internal/entity"
+	"go-rest-scaffold/internal/gateway/lock"
+	"go-rest-scaffold/internal/metrics"
+	"go-rest-scaffold/internal/model"
+	"go-rest-scaffold/internal/model/converter"
+	"go-rest-scaffold/internal/repository"
+	"go-rest-scaffold/internal/tracing"
+	"io"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// contactImportMaxErrors is how many row errors an import keeps; Failed counts them all.
+	contactImportMaxErrors = 100
+	// contactImportProgressRows is how many rows a background import imports between
+	// two saves of its progress.
+	contactImportProgressRows = 100
+	// contactImportStaleAfter is how long a running import may go without saving its
+	// progress before its run is taken for dead and the import is run again.
+	contactImportStaleAfter = 5 * time.Minute
+	// contactImportBatchSize is how many imports a run of the job takes.
+	contactImportBatchSize = 10
+	// contactImportLockTTL is how long the import lock of a user is held without being
+	// extended, which a background import does whenever it saves its progress.
+	contactImportLockTTL = contactImportStaleAfter
+)
+
+// utf8BOM starts the CSV files of spreadsheets saving UTF-8.
+var utf8BOM = []byte("\xef\xbb\xbf")
+
+// ContactImportOptions tunes the CSV import of contacts.
+type ContactImportOptions struct {
+	// AsyncRows is how many rows a file may have to be imported while the request
+	// waits; larger files are queued for the contact-import job.
+	AsyncRows int
+	// Retention is how long a finished background import can be looked up.
+	Retention time.Duration
+	// FormatErrors turns the validation errors of a row into its messages, the whole
+	// and per field.
+	FormatErrors func(err validator.ValidationErrors) (string, map[string]string)
+}
+
+type ContactImportUseCase struct {
+	TxManager               TxManager
+	Log                     *logrus.Logger
+	Validate                *validator.Validate
+	ContactImportRepository *repository.ContactImportRepository
+	Contacts                *ContactUseCase
+	// Locker runs the imports of a user one at a time, on every instance.
+	Locker  *lock.Locker
+	Options ContactImportOptions
+}
+
+func NewContactImportUseCase(txManager TxManager, logger *logrus.Logger, validate *validator.Validate,
+	contactImportRepository *repository.ContactImportRepository, contacts *ContactUseCase, locker *lock.Locker,
+	options ContactImportOptions) *ContactImportUseCase {
+	return &ContactImportUseCase{
+		TxManager:               txManager,
+		Log:                     logger,
+		Validate:                validate,
+		ContactImportRepository: contactImportRepository,
+		Contacts:                contacts,
+		Locker:                  locker,
+		Options:                 options,
+	}
+}
+
+// contactImportLock names the lock the imports of a user take.
+func contactImportLock(userId string) string {
+	return "contact-import:" + userId
+}
+
+// unlock releases held, which only expires otherwise, so failures are logged only.
+func (c *ContactImportUseCase) unlock(ctx context.Context, held *lock.Lock) {
+	if err := held.Unlock(context.WithoutCancel(ctx)); err != nil {
+		c.Log.WithContext(ctx).WithError(err).Warn("Failed to release contact import lock")
+	}
+}
+
+// contactFile is a parsed CSV file of contacts.
+type contactFile struct {
+	// columns is the column of each mapped contact field.
+	columns map[string]int
+	rows    [][]string
+	// lines is the line each row starts on, for the row errors.
+	lines []int
+}
+
+// parseContactFile reads the header and rows of data and maps the columns to contact
+// fields: by mapping, else by a header naming the field, case and separators aside.
+// Columns mapped to no field are ignored.
+func parseContactFile(data []byte, mapping map[string]string) (*contactFile, error) {
+	if !utf8.Valid(data) {
+		return nil, fiber.NewError(fiber.StatusBadRequest, "the file must be UTF-8 encoded")
+	}
+
+	reader := csv.NewReader(bytes.NewReader(bytes.TrimPrefix(data, utf8BOM)))
+	// rows shorter than the header leave the missing fields empty
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, fiber.NewError(fiber.StatusBadRequest, "the file is empty, it needs a header row")
+	}
+	if err != nil {
+		return nil, fiber.NewError(fiber.StatusBadRequest, "invalid CSV: "+err.Error())
+	}
+
+	for column, field := range mapping {
+		if !slices.Contains(header, column) {
+			return nil, fiber.NewError(fiber.StatusBadRequest, fmt.Sprintf("mapping names column %q, which the file does not have", column))
+		}
+		if field != "" && !slices.Contains(model.ContactImportFields, field) {
+			return nil, fiber.NewError(fiber.StatusBadRequest, fmt.Sprintf("column %q is mapped to %q, which is not one of: %s",
+				column, field, strings.Join(model.ContactImportFields, " ")))
+		}
+	}
+
+	file := &contactFile{columns: make(map[string]int)}
+	for i, column := range header {
+		field, mapped := mapping[column]
+		if !mapped {
+			field = strings.NewReplacer(" ", "_", "-", "_").Replace(strings.ToLower(strings.TrimSpace(column)))
+			if !slices.Contains(model.ContactImportFields, field) {
+				continue
+			}
+		}
+		if field == "" {
+			continue
+		}
+		if _, taken := file.columns[field]; taken {
+			return nil, fiber.NewError(fiber.StatusBadRequest, "more than one column is mapped to "+field)
+		}
+		file.columns[field] = i
+	}
+	if _, found := file.columns["first_name"]; !found {
+		return nil, fiber.NewError(fiber.StatusBadRequest, "no column is mapped to first_name, name it in the header or the mapping")
+	}
+
+	for {
+		row, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return file, nil
+		}
+		if err != nil {
+			return nil, fiber.NewError(fiber.StatusBadRequest, "invalid CSV: "+err.Error())
+		}
+
+		line, _ := reader.FieldPos(0)
+		file.rows = append(file.rows, row)
+		file.lines = append(file.lines, line)
+	}
+}
+
+// request returns the contact of row i of the file.
+func (f *contactFile) request(userId string, i int) *model.CreateContactRequest {
+	value := func(field string) string {
+		column, found := f.columns[field]
+		if !found || column >= len(f.rows[i]) {
+			return ""
+		}
+		return model.UnescapeCSVCell(strings.TrimSpace(f.rows[i][column]))
+	}
+
+	return &model.CreateContactRequest{
+		UserId:    userId,
+		FirstName: value("first_name"),
+		LastName:  value("last_name"),
+		Email:     value("email"),
+		Phone:     value("phone"),
+	}
+}
+
+// Import creates a contact from every row of a CSV file. A row that fails is reported
+// with its line and the others are imported still, each in a transaction of its own.
+// Files of up to AsyncRows rows, and dry runs, are imported at once; larger files are
+// queued as pending for the contact-import job and polled with Get.
+func (c *ContactImportUseCase) Import(ctx context.Context, request *model.ImportContactRequest) (*model.ContactImportResponse, error) {
+	ctx, span := tracing.Start(ctx, "ContactImportUseCase.Import")
+	defer span.End()
+
+	if err := c.Validate.Struct(request); err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("error validating request")
+		return nil, fiber.NewError(fiber.StatusBadRequest, "the CSV file is missing")
+	}
+
+	file, err := parseContactFile(request.Data, request.Mapping)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(file.rows) <= c.Options.AsyncRows || model.IsDryRun(ctx) {
+		// waits for the other imports of the user, background ones included
+		held, err := c.Locker.Lock(ctx, contactImportLock(request.UserId), contactImportLockTTL)
+		if err != nil {
+			c.Log.WithContext(ctx).WithError(err).Error("error locking contact imports")
+			return nil, fiber.ErrInternalServerError
+		}
+		defer c.unlock(ctx, held)
+
+		response := &model.ContactImportResponse{
+			Status:    model.ContactImportCompleted,
+			TotalRows: len(file.rows),
+			Errors:    []model.ContactImportRowError{},
+		}
+		if err := c.importRows(ctx, request.UserId, file, response, nil); err != nil {
+			return nil, err
+		}
+		return response, nil
+	}
+
+	mapping, err := json.Marshal(request.Mapping)
+	if err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("error encoding import mapping")
+		return nil, fiber.ErrInternalServerError
+	}
+
+	contactImport := &entity.ContactImport{
+		ID:        uuid.NewString(),
+		UserId:    request.UserId,
+		Status:    model.ContactImportPending,
+		Data:      string(request.Data),
+		Mapping:   string(mapping),
+		TotalRows: len(file.rows),
+		Errors:    "[]",
+	}
+
+	tx := c.TxManager.Begin(ctx)
+	defer tx.Rollback()
+
+	if err := c.ContactImportRepository.Create(tx, contactImport); err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("error queueing contact import")
+		return nil, fiber.ErrInternalServerError
+	}
+
+	if err := commit(ctx, tx); err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("error queueing contact import")
+		return nil, fiber.ErrInternalServerError
+	}
+
+	return converter.ContactImportToResponse(contactImport), nil
+}
+
+// Get returns the status of a queued import.
+func (c *ContactImportUseCase) Get(ctx context.Context, request *model.GetContactImportRequest) (*model.ContactImportResponse, error) {
+	ctx, span := tracing.Start(ctx, "ContactImportUseCase.Get")
+	defer span.End()
+
+	if err := c.Validate.Struct(request); err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("error validating request")
+		return nil, fiber.ErrBadRequest
+	}
+
+	contactImport := new(entity.ContactImport)
+	if err := c.ContactImportRepository.FindByIdAndUserId(c.TxManager.DB(ctx), contactImport, request.ID, request.UserId); err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("error getting contact import")
+		return nil, fiber.ErrNotFound
+	}
+
+	return converter.ContactImportToResponse(contactImport), nil
+}
+
+// RunImports is the contact-import job: it runs the queued imports, oldest first, and
+// the ones whose run died, from the last row they saved. It also deletes the imports
+// finished longer than Retention ago. It returns how many contacts it imported.
+func (c *ContactImportUseCase) RunImports(ctx context.Context) (int64, error) {
+	ctx, span := tracing.Start(ctx, "ContactImportUseCase.RunImports")
+	defer span.End()
+
+	now := time.Now()
+	if _, err := c.ContactImportRepository.DeleteFinishedBefore(c.TxManager.DB(ctx), now.Add(-c.Options.Retention).UnixMilli()); err != nil {
+		return 0, err
+	}
+
+	imports, err := c.ContactImportRepository.FindRunnable(c.TxManager.DB(ctx), now.Add(-contactImportStaleAfter).UnixMilli(), contactImportBatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	var imported int64
+	for i := range imports {
+		if ctx.Err() != nil {
+			break
+		}
+
+		count, err := c.run(ctx, &imports[i])
+		imported += count
+		if err != nil {
+			return imported, err
+		}
+	}
+	return imported, nil
+}
+
+// run claims contactImport and imports its rows from the first one not processed yet.
+// An import is left for a later run while another import of its user runs.
+func (c *ContactImportUseCase) run(ctx context.Context, contactImport *entity.ContactImport) (int64, error) {
+	held, err := c.Locker.TryLock(ctx, contactImportLock(contactImport.UserId), contactImportLockTTL)
+	if errors.Is(err, lock.ErrNotObtained) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	defer c.unlock(ctx, held)
+
+	readAt := contactImport.UpdatedAt
+	contactImport.UpdatedAt = time.Now().UnixMilli()
+	claimed, err := c.ContactImportRepository.Claim(c.TxManager.DB(ctx), contactImport, readAt)
+	if err != nil || !claimed {
+		return 0, err
+	}
+	contactImport.Status = model.ContactImportRunning
+
+	// the contacts are created by the user who uploaded the file
+	ctx = model.WithActor(ctx, contactImport.UserId)
+	log := c.Log.WithContext(ctx).WithField("import", contactImport.ID)
+
+	progress := converter.ContactImportToResponse(contactImport)
+	imported := progress.Imported
+
+	var mapping map[string]string
+	if err := json.Unmarshal([]byte(contactImport.Mapping), &mapping); err != nil {
+		return 0, err
+	}
+
+	file, err := parseContactFile([]byte(contactImport.Data), mapping)
+	if err != nil {
+		// the file was parsed when it was queued, so this is not the user's fault
+		log.WithError(err).Error("Failed to parse queued contact import")
+		progress.Status = model.ContactImportFailed
+		progress.Error = "the file could not be read anymore, upload it again"
+	} else {
+		save := func() error {
+			if err := held.Extend(ctx); err != nil {
+				return err
+			}
+			return c.saveProgress(ctx, contactImport, progress)
+		}
+		if err := c.importRows(ctx, contactImport.UserId, file, progress, save); err != nil {
+			return int64(progress.Imported - imported), err
+		}
+		progress.Status = model.ContactImportCompleted
+	}
+
+	completedAt := time.Now().UnixMilli()
+	progress.CompletedAt = &completedAt
+	if err := c.saveProgress(ctx, contactImport, progress); err != nil {
+		return int64(progress.Imported - imported), err
+	}
+
+	log.Infof("Imported %d of %d contacts, %d failed", progress.Imported, progress.TotalRows, progress.Failed)
+	return int64(progress.Imported - imported), nil
+}
+
+// saveProgress saves progress as the state of contactImport, dropping the file once
+// the import finished.
+func (c *ContactImportUseCase) saveProgress(ctx context.Context, contactImport *entity.ContactImport, progress *model.ContactImportResponse) error {
+	errs, err := json.Marshal(progress.Errors)
+	if err != nil {
+		return err
+	}
+
+	contactImport.Status = progress.Status
+	contactImport.ProcessedRows = progress.ProcessedRows
+	contactImport.Imported = progress.Imported
+	contactImport.Failed = progress.Failed
+	contactImport.Errors = string(errs)
+	contactImport.Error = progress.Error
+	contactImport.CompletedAt = progress.CompletedAt
+	contactImport.UpdatedAt = time.Now().UnixMilli()
+	if progress.CompletedAt != nil {
+		contactImport.Data = ""
+	}
+	return c.ContactImportRepository.SaveProgress(c.TxManager.DB(ctx), contactImport)
+}
+
+// importRows imports the rows of file after the ProcessedRows of progress and counts
+// them in progress, calling save, when set, every contactImportProgressRows rows.
+func (c *ContactImportUseCase) importRows(ctx context.Context, userId string, file *contactFile, progress *model.ContactImportResponse,
+	save func() error) error {
+	for i := progress.ProcessedRows; i < len(file.rows); i++ {
+		if err := c.importRow(ctx, file.request(userId, i)); err != nil {
+			progress.Failed++
+			if len(progress.Errors) < contactImportMaxErrors {
+				progress.Errors = append(progress.Errors, c.rowError(file.lines[i], err))
+			}
+		} else {
+			progress.Imported++
+		}
+
+		progress.ProcessedRows++
+		if save != nil && progress.ProcessedRows%contactImportProgressRows == 0 {
+			if err := save(); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// importRow creates the contact of a row as Create would.
+func (c *ContactImportUseCase) importRow(ctx context.Context, request *model.CreateContactRequest) (err error) {
+	defer func() {
+		if !model.IsDryRun(ctx) {
+			metrics.ContactCreations.WithLabelValues(metrics.Outcome(err)).Inc()
+		}
+	}()
+
+	// validated here first, as create only tells that the row is invalid
+	if err := c.Validate.Struct(request); err != nil {
+		return err
+	}
+
+	tx := c.TxManager.Begin(ctx)
+	defer tx.Rollback()
+
+	_, event, err := c.Contacts.create(ctx, tx, request)
+	if err != nil {
+		return err
+	}
+
+	if err := commit(ctx, tx); err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("error importing contact")
+		return fiber.ErrInternalServerError
+	}
+
+	c.Contacts.EventBus.Deliver(ctx, event)
+	return nil
+}
+
+// rowError is the error of the row on line that failed with err.
+func (c *ContactImportUseCase) rowError(line int, err error) model.ContactImportRowError {
+	var validationErrors validator.ValidationErrors
+	if errors.As(err, &validationErrors) {
+		message, fields := c.Options.FormatErrors(validationErrors)
+		return model.ContactImportRowError{Row: line, Errors: message, Fields: fields}
+	}
+
+	var fiberError *fiber.Error
+	if !errors.As(err, &fiberError) {
+		fiberError = fiber.ErrInternalServerError
+	}
+	return model.ContactImportRowError{Row: line, Errors: fiberError.Message}
+}
@@ -0,0 +1,168 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"go-rest-scaffold/internal/entity"
+	"go-rest-scaffold/internal/gateway/search"
+	"go-rest-scaffold/internal/model"
+	"go-rest-scaffold/internal/repository"
+	"go-rest-scaffold/internal/tracing"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// ContactSearchOptions tunes the search index of contacts.
+type ContactSearchOptions struct {
+	// BatchSize is how many contacts an import or Reindex saves at a time.
+	BatchSize int
+}
+
+// ContactSearchUseCase mirrors the contacts into a search index. Changes reach the
+// index through the event bus right after they are committed; the index is a copy,
+// so a change it misses is repaired by the next change of the contact or a Reindex.
+type ContactSearchUseCase struct {
+	TxManager         TxManager
+	Log               *logrus.Logger
+	ContactRepository *repository.ContactRepository
+	Index             search.Index
+	Options           ContactSearchOptions
+}
+
+func NewContactSearchUseCase(txManager TxManager, logger *logrus.Logger, contactRepository *repository.ContactRepository,
+	index search.Index, options ContactSearchOptions) *ContactSearchUseCase {
+	return &ContactSearchUseCase{
+		TxManager:         txManager,
+		Log:               logger,
+		ContactRepository: contactRepository,
+		Index:             index,
+		Options:           options,
+	}
+}
+
+// EventTypes lists the events that change the indexed contacts.
+func (c *ContactSearchUseCase) EventTypes() []string {
+	return []string{
+		model.EventUserDeleted,
+		model.EventAccountImported,
+		model.EventContactCreated,
+		model.EventContactUpdated,
+		model.EventContactDeleted,
+		model.EventContactRestored,
+	}
+}
+
+// Handle updates the index in the background so a slow index never delays the write
+// that triggered it. The index bounds how long each of its calls takes.
+func (c *ContactSearchUseCase) Handle(ctx context.Context, event *model.CloudEvent) {
+	go func() {
+		ctx := context.WithoutCancel(ctx)
+		if err := c.apply(ctx, event); err != nil {
+			c.Log.WithContext(ctx).WithError(err).Warnf("Failed to update the contact search index for event %s", event.ID)
+		}
+	}()
+}
+
+// apply brings the documents an event touched in line with the database, whatever
+// order concurrent events are applied in.
+func (c *ContactSearchUseCase) apply(ctx context.Context, event *model.CloudEvent) error {
+	ctx, span := tracing.Start(ctx, "ContactSearchUseCase.apply")
+	defer span.End()
+
+	// the change was just committed, which the replicas may not have applied yet
+	db := c.TxManager.DB(model.WithPrimary(ctx))
+
+	switch event.Type {
+	case model.EventUserDeleted:
+		return c.Index.DeleteByUserId(ctx, event.UserId)
+	case model.EventAccountImported:
+		return c.indexUser(ctx, db, event.UserId)
+	}
+
+	// subjects are "contacts/{id}"
+	parts := strings.Split(event.Subject, "/")
+	if len(parts) != 2 || parts[0] != "contacts" {
+		return nil
+	}
+
+	contact := new(entity.Contact)
+	err := c.ContactRepository.FindByIdAndUserId(db, contact, parts[1], event.UserId)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return c.Index.Delete(ctx, event.UserId, []string{parts[1]})
+	}
+	if err != nil {
+		return err
+	}
+	return c.Index.Save(ctx, []search.Document{contactDocument(contact)})
+}
+
+// indexUser saves every contact of a user.
+func (c *ContactSearchUseCase) indexUser(ctx context.Context, db *gorm.DB, userId string) error {
+	afterId := ""
+	for {
+		contacts, err := c.ContactRepository.FindPageByUserIdAfterId(db, userId, afterId, c.Options.BatchSize)
+		if err != nil || len(contacts) == 0 {
+			return err
+		}
+
+		if err := c.Index.Save(ctx, contactDocuments(contacts)); err != nil {
+			return err
+		}
+		afterId = contacts[len(contacts)-1].ID
+	}
+}
+
+// Reindex saves every contact of the database ctx is pinned to, and returns how many
+// it saved. It fills a new index and repairs changes the index missed; documents of
+// contacts deleted meanwhile are left to their next change.
+func (c *ContactSearchUseCase) Reindex(ctx context.Context) (int64, error) {
+	ctx, span := tracing.Start(ctx, "ContactSearchUseCase.Reindex")
+	defer span.End()
+
+	db := c.TxManager.DB(ctx)
+
+	var total int64
+	afterId := ""
+	for {
+		contacts, err := c.ContactRepository.FindPageAfterId(db, afterId, c.Options.BatchSize)
+		if err != nil || len(contacts) == 0 {
+			return total, err
+		}
+
+		if err := c.Index.Save(ctx, contactDocuments(contacts)); err != nil {
+			return total, err
+		}
+
+		total += int64(len(contacts))
+		afterId = contacts[len(contacts)-1].ID
+	}
+}
+
+func contactDocument(contact *entity.Contact) search.Document {
+	document := search.Document{
+		ID:        contact.ID,
+		UserId:    contact.UserId,
+		FirstName: contact.FirstName,
+		LastName:  contact.LastName,
+		Emails:    make([]string, len(contact.Emails)),
+		Phones:    make([]string, len(contact.Phones)),
+		CreatedAt: contact.CreatedAt,
+	}
+	for i, email := range contact.Emails {
+		document.Emails[i] = email.Email
+	}
+	for i, phone := range contact.Phones {
+		document.Phones[i] = phone.Phone
+	}
+	return document
+}
+
+func contactDocuments(contacts []entity.Contact) []search.Document {
+	documents := make([]search.Document, len(contacts))
+	for i := range contacts {
+		documents[i] = contactDocument(&contacts[i])
+	}
+	return documents
+}
@@ -0,0 +1,302 @@
+package usecase
+
+import (
+	"context"
+	"go-rest-scaffold/internal/entity"
+	"go-rest-scaffold/internal/model"
+	"go-rest-scaffold/internal/model/converter"
+	"go-rest-scaffold/internal/repository"
+	"go-rest-scaffold/internal/tracing"
+	"strings"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"github.com/sirupsen/logrus"
+)
+
+// ContactSyncOptions controls the contact sync stream and how long its changes are kept.
+type ContactSyncOptions struct {
+	BatchSize         int
+	PollInterval      time.Duration
+	HeartbeatInterval time.Duration
+	Retention         time.Duration
+	PruneInterval     time.Duration
+}
+
+// ContactSyncCursor is where a contact sync stream starts: with a snapshot followed by
+// the changes after Offset, or with the changes after Offset only.
+type ContactSyncCursor struct {
+	UserId   string
+	Offset   int64
+	Snapshot bool
+}
+
+// ContactSyncSender writes a batch of stream lines to the client. It returns an error
+// once the client is gone, which ends the stream.
+type ContactSyncSender func(events []model.ContactSyncEvent) error
+
+type ContactSyncUseCase struct {
+	TxManager               TxManager
+	Log                     *logrus.Logger
+	Validate                *validator.Validate
+	ContactRepository       *repository.ContactRepository
+	ContactChangeRepository *repository.ContactChangeRepository
+	Options                 ContactSyncOptions
+}
+
+func NewContactSyncUseCase(txManager TxManager, logger *logrus.Logger, validate *validator.Validate, contactRepository *repository.ContactRepository,
+	contactChangeRepository *repository.ContactChangeRepository, options ContactSyncOptions) *ContactSyncUseCase {
+	return &ContactSyncUseCase{
+		TxManager:               txManager,
+		Log:                     logger,
+		Validate:                validate,
+		ContactRepository:       contactRepository,
+		ContactChangeRepository: contactChangeRepository,
+		Options:                 options,
+	}
+}
+
+// EventTypes lists the events that change what the sync stream of a user returns.
+func (c *ContactSyncUseCase) EventTypes() []string {
+	return []string{
+		model.EventAccountImported,
+		model.EventContactCreated,
+		model.EventContactUpdated,
+		model.EventContactDeleted,
+		model.EventContactRestored,
+		model.EventAddressCreated,
+		model.EventAddressUpdated,
+		model.EventAddressDeleted,
+		model.EventAddressRestored,
+	}
+}
+
+// Handle is the event bus subscriber recording the changed contact in the change feed.
+// Address events record their contact, since the stream sends contacts whole.
+func (c *ContactSyncUseCase) Handle(ctx context.Context, event *model.CloudEvent) {
+	ctx, span := tracing.Start(ctx, "ContactSyncUseCase.Handle")
+	defer span.End()
+
+	db := c.TxManager.DB(ctx)
+
+	var err error
+	if event.Type == model.EventAccountImported {
+		err = c.ContactChangeRepository.CreateForUser(db, event.UserId)
+	} else {
+		// subjects are "contacts/{id}" and "contacts/{id}/addresses/{addressId}"
+		parts := strings.Split(event.Subject, "/")
+		if len(parts) < 2 || parts[0] != "contacts" {
+			return
+		}
+		err = c.ContactChangeRepository.Create(db, &entity.ContactChange{UserId: event.UserId, ContactId: parts[1]})
+	}
+	if err != nil {
+		c.Log.WithContext(ctx).WithError(err).Errorf("Failed to record contact change of event %s", event.ID)
+	}
+}
+
+// Open checks where a stream may start. A resumed stream is refused with 410 Gone once
+// its offset is older than the retained changes, since the client then has to start
+// over from a snapshot.
+func (c *ContactSyncUseCase) Open(ctx context.Context, request *model.SyncContactsRequest) (*ContactSyncCursor, error) {
+	ctx, span := tracing.Start(ctx, "ContactSyncUseCase.Open")
+	defer span.End()
+
+	tx := c.TxManager.Begin(ctx)
+	defer tx.Rollback()
+
+	if err := c.Validate.Struct(request); err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to validate request")
+		return nil, fiber.ErrBadRequest
+	}
+
+	head, err := c.ContactChangeRepository.Head(tx)
+	if err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to find the head of the contact changes")
+		return nil, fiber.ErrInternalServerError
+	}
+
+	if !request.Resume {
+		if err := tx.Commit().Error; err != nil {
+			c.Log.WithContext(ctx).WithError(err).Error("failed to commit transaction")
+			return nil, fiber.ErrInternalServerError
+		}
+		return &ContactSyncCursor{UserId: request.UserId, Offset: head, Snapshot: true}, nil
+	}
+
+	if request.Offset > head {
+		return nil, fiber.NewError(fiber.StatusBadRequest, "offset is ahead of the latest change")
+	}
+
+	horizon, err := c.ContactChangeRepository.Horizon(tx)
+	if err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to find the horizon of the contact changes")
+		return nil, fiber.ErrInternalServerError
+	}
+	if request.Offset < horizon {
+		return nil, fiber.NewError(fiber.StatusGone, "offset has expired, start over without an offset")
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to commit transaction")
+		return nil, fiber.ErrInternalServerError
+	}
+
+	return &ContactSyncCursor{UserId: request.UserId, Offset: request.Offset}, nil
+}
+
+// Stream sends the snapshot of cursor, if any, and then every change after its offset
+// as it is recorded, until send fails or ctx is done. At most one batch is read ahead
+// of what the client accepted, so a slow client slows the stream down instead of
+// piling lines up in memory.
+func (c *ContactSyncUseCase) Stream(ctx context.Context, cursor *ContactSyncCursor, send ContactSyncSender) error {
+	ctx, span := tracing.Start(ctx, "ContactSyncUseCase.Stream")
+	defer span.End()
+
+	if cursor.Snapshot {
+		if err := c.streamSnapshot(ctx, cursor, send); err != nil {
+			return err
+		}
+	}
+
+	offset := cursor.Offset
+	lastSent := time.Now()
+	for {
+		events, err := c.changesAfter(ctx, cursor.UserId, offset)
+		if err != nil {
+			c.Log.WithContext(ctx).WithError(err).Error("failed to read contact changes")
+			return err
+		}
+
+		if len(events) > 0 {
+			if err := send(events); err != nil {
+				return err
+			}
+			offset = events[len(events)-1].Offset
+			lastSent = time.Now()
+			if len(events) == c.Options.BatchSize {
+				continue
+			}
+		} else if time.Since(lastSent) >= c.Options.HeartbeatInterval {
+			if err := send([]model.ContactSyncEvent{{Type: model.ContactSyncHeartbeat, Offset: offset}}); err != nil {
+				return err
+			}
+			lastSent = time.Now()
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(c.Options.PollInterval):
+		}
+	}
+}
+
+func (c *ContactSyncUseCase) streamSnapshot(ctx context.Context, cursor *ContactSyncCursor, send ContactSyncSender) error {
+	afterId := ""
+	for {
+		contacts, err := c.ContactRepository.FindPageByUserIdAfterId(c.TxManager.DB(ctx), cursor.UserId, afterId, c.Options.BatchSize)
+		if err != nil {
+			c.Log.WithContext(ctx).WithError(err).Error("failed to read contacts")
+			return err
+		}
+
+		events := make([]model.ContactSyncEvent, len(contacts))
+		for i := range contacts {
+			events[i] = model.ContactSyncEvent{
+				Type:      model.ContactSyncUpsert,
+				Offset:    cursor.Offset,
+				ContactId: contacts[i].ID,
+				Contact:   converter.ContactWithAddressesToResponse(&contacts[i]),
+			}
+		}
+		if len(contacts) < c.Options.BatchSize {
+			events = append(events, model.ContactSyncEvent{Type: model.ContactSyncSnapshotEnd, Offset: cursor.Offset})
+		}
+		if err := send(events); err != nil {
+			return err
+		}
+
+		if len(contacts) < c.Options.BatchSize {
+			return nil
+		}
+		afterId = contacts[len(contacts)-1].ID
+	}
+}
+
+// changesAfter turns the next batch of changes into stream lines. Each line carries the
+// state of its contact at the time it is read; a contact that is gone by then is sent
+// as deleted.
+func (c *ContactSyncUseCase) changesAfter(ctx context.Context, userId string, offset int64) ([]model.ContactSyncEvent, error) {
+	db := c.TxManager.DB(ctx)
+
+	changes, err := c.ContactChangeRepository.FindByUserIdAfter(db, userId, offset, c.Options.BatchSize)
+	if err != nil || len(changes) == 0 {
+		return nil, err
+	}
+
+	ids := make([]string, len(changes))
+	for i, change := range changes {
+		ids[i] = change.ContactId
+	}
+	contacts, err := c.ContactRepository.FindByIdsAndUserId(db, ids, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	byId := make(map[string]*model.ContactResponse, len(contacts))
+	for i := range contacts {
+		byId[contacts[i].ID] = converter.ContactWithAddressesToResponse(&contacts[i])
+	}
+
+	events := make([]model.ContactSyncEvent, len(changes))
+	for i, change := range changes {
+		events[i] = model.ContactSyncEvent{Type: model.ContactSyncDelete, Offset: change.ID, ContactId: change.ContactId}
+		if contact, ok := byId[change.ContactId]; ok {
+			events[i].Type = model.ContactSyncUpsert
+			events[i].Contact = contact
+		}
+	}
+	return events, nil
+}
+
+// RunPruner deletes, every prune interval until ctx is done, the changes older than the
+// retention. It is meant to run on the leader only.
+func (c *ContactSyncUseCase) RunPruner(ctx context.Context) {
+	ctx, span := tracing.Start(ctx, "ContactSyncUseCase.RunPruner")
+	defer span.End()
+
+	ticker := time.NewTicker(c.Options.PruneInterval)
+	defer ticker.Stop()
+
+	for {
+		if pruned, err := c.PruneExpired(ctx); err != nil {
+			c.Log.WithContext(ctx).WithError(err).Error("failed to prune contact changes")
+		} else if pruned > 0 {
+			c.Log.WithContext(ctx).Infof("Pruned %d contact changes", pruned)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// PruneExpired deletes the changes of every user recorded longer ago than the retention.
+func (c *ContactSyncUseCase) PruneExpired(ctx context.Context) (int64, error) {
+	ctx, span := tracing.Start(ctx, "ContactSyncUseCase.PruneExpired")
+	defer span.End()
+
+	tx := c.TxManager.Begin(ctx)
+	defer tx.Rollback()
+
+	pruned, err := c.ContactChangeRepository.DeleteCreatedBefore(tx, time.Now().Add(-c.Options.Retention))
+	if err != nil {
+		return 0, err
+	}
+
+	return pruned, tx.Commit().Error
+}
@@ -2,168 +2,804 @@ package usecase
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"go-rest-scaffold/internal/entity"
+	"go-rest-scaffold/internal/event"
+	"go-rest-scaffold/internal/gateway/search"
+	"go-rest-scaffold/internal/idgen"
+	"go-rest-scaffold/internal/metrics"
 	"go-rest-scaffold/internal/model"
 	"go-rest-scaffold/internal/model/converter"
 	"go-rest-scaffold/internal/repository"
+	"go-rest-scaffold/internal/tracing"
+	"slices"
+	"time"
 
 	"github.com/go-playground/validator/v10"
 	"github.com/gofiber/fiber/v2"
-	"github.com/google/uuid"
 	"github.com/sirupsen/logrus"
 	"gorm.io/gorm"
 )
 
+// ContactOptions tunes the contact endpoints.
+type ContactOptions struct {
+	// SuggestTimeout is the latency budget of a suggestion query.
+	SuggestTimeout time.Duration
+	// ReadCache caches Get and Search, nil to always read the database.
+	ReadCache *ReadCache
+	// SearchIndex serves the searches by name, email or phone, nil to search the database.
+	SearchIndex search.Index
+	// BulkMaxOperations is how many operations a bulk request may carry.
+	BulkMaxOperations int
+	// ExportBatchSize is how many contacts an export reads at a time.
+	ExportBatchSize int
+	// Avatars signs the URLs of the avatars of the contacts returned, nil to leave
+	// avatars with their ID only.
+	Avatars *ContactAvatarUseCase
+}
+
+// ContactExportSender writes a batch of exported contacts to the client. It returns an
+// error once the client is gone, which ends the export.
+type ContactExportSender func(contacts []model.ContactResponse) error
+
+// contactPage is a page of Search as it is cached.
+type contactPage struct {
+	Contacts []model.ContactResponse `json:"contacts"`
+	Total    int64                   `json:"total"`
+}
+
 type ContactUseCase struct {
-	DB                *gorm.DB
+	TxManager         TxManager
 	Log               *logrus.Logger
 	Validate          *validator.Validate
 	ContactRepository *repository.ContactRepository
+	EventBus          *event.Bus
+	AuditLog          *AuditLogUseCase
+	IDs               *idgen.Generators
+	Options           ContactOptions
 }
 
-func NewContactUseCase(db *gorm.DB, logger *logrus.Logger, validate *validator.Validate,
-	contactRepository *repository.ContactRepository) *ContactUseCase {
+func NewContactUseCase(txManager TxManager, logger *logrus.Logger, validate *validator.Validate,
+	contactRepository *repository.ContactRepository, eventBus *event.Bus, auditLog *AuditLogUseCase, ids *idgen.Generators,
+	options ContactOptions) *ContactUseCase {
 	return &ContactUseCase{
-		DB:                db,
+		TxManager:         txManager,
 		Log:               logger,
 		Validate:          validate,
 		ContactRepository: contactRepository,
+		EventBus:          eventBus,
+		AuditLog:          auditLog,
+		IDs:               ids,
+		Options:           options,
 	}
 }
 
-func (c *ContactUseCase) Create(ctx context.Context, request *model.CreateContactRequest) (*model.ContactResponse, error) {
-	tx := c.DB.WithContext(ctx).Begin()
+func (c *ContactUseCase) Create(ctx context.Context, request *model.CreateContactRequest) (response *model.ContactResponse, err error) {
+	ctx, span := tracing.Start(ctx, "ContactUseCase.Create")
+	defer span.End()
+
+	defer func() {
+		if !model.IsDryRun(ctx) {
+			metrics.ContactCreations.WithLabelValues(metrics.Outcome(err)).Inc()
+		}
+	}()
+
+	tx := c.TxManager.Begin(ctx)
 	defer tx.Rollback()
 
+	response, event, err := c.create(ctx, tx, request)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := commit(ctx, tx); err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("error creating contact")
+		return nil, fiber.ErrInternalServerError
+	}
+
+	c.EventBus.Deliver(ctx, event)
+
+	return response, nil
+}
+
+// create creates a contact in tx and returns it with the event to deliver once tx
+// is committed.
+func (c *ContactUseCase) create(ctx context.Context, tx *gorm.DB, request *model.CreateContactRequest) (*model.ContactResponse, *model.CloudEvent, error) {
 	if err := c.Validate.Struct(request); err != nil {
-		c.Log.WithError(err).Error("error validating request body")
-		return nil, fiber.ErrBadRequest
+		c.Log.WithContext(ctx).WithError(err).Error("error validating request body")
+		return nil, nil, fiber.ErrBadRequest
+	}
+
+	emails, phones, err := contactChannels(request.Email, request.Emails, request.Phone, request.Phones)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	id, err := c.IDs.NewID(ctx, idgen.Contact)
+	if err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to generate contact id")
+		return nil, nil, fiber.ErrInternalServerError
 	}
 
 	contact := &entity.Contact{
-		ID:        uuid.New().String(),
+		ID:        id,
 		FirstName: request.FirstName,
 		LastName:  request.LastName,
-		Email:     request.Email,
-		Phone:     request.Phone,
+		Emails:    emails,
+		Phones:    phones,
 		UserId:    request.UserId,
+		Version:   1,
 	}
 
 	if err := c.ContactRepository.Create(tx, contact); err != nil {
-		c.Log.WithError(err).Error("error creating contact")
-		return nil, fiber.ErrInternalServerError
+		c.Log.WithContext(ctx).WithError(err).Error("error creating contact")
+		return nil, nil, fiber.ErrInternalServerError
 	}
 
-	if err := tx.Commit().Error; err != nil {
-		c.Log.WithError(err).Error("error creating contact")
-		return nil, fiber.ErrInternalServerError
+	if err := c.AuditLog.Record(ctx, tx, model.AuditCreate, model.AuditContact, contact.ID, nil, converter.ContactToResponse(contact)); err != nil {
+		return nil, nil, err
 	}
 
-	return converter.ContactToResponse(contact), nil
+	response := converter.ContactToResponse(contact)
+	event, err := c.EventBus.Record(ctx, tx, model.EventContactCreated, "contacts/"+contact.ID, contact.UserId, response)
+	if err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("error recording event")
+		return nil, nil, fiber.ErrInternalServerError
+	}
+
+	return response, event, nil
 }
 
 func (c *ContactUseCase) Update(ctx context.Context, request *model.UpdateContactRequest) (*model.ContactResponse, error) {
-	tx := c.DB.WithContext(ctx).Begin()
+	ctx, span := tracing.Start(ctx, "ContactUseCase.Update")
+	defer span.End()
+
+	tx := c.TxManager.Begin(ctx)
 	defer tx.Rollback()
 
+	response, event, err := c.update(ctx, tx, request)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := commit(ctx, tx); err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("error updating contact")
+		return nil, fiber.ErrInternalServerError
+	}
+
+	c.EventBus.Deliver(ctx, event)
+
+	c.Options.Avatars.SignURLs(ctx, response)
+	return response, nil
+}
+
+// update updates a contact in tx and returns it with the event to deliver once tx
+// is committed.
+func (c *ContactUseCase) update(ctx context.Context, tx *gorm.DB, request *model.UpdateContactRequest) (*model.ContactResponse, *model.CloudEvent, error) {
 	contact := new(entity.Contact)
 	if err := c.ContactRepository.FindByIdAndUserId(tx, contact, request.ID, request.UserId); err != nil {
-		c.Log.WithError(err).Error("error getting contact")
-		return nil, fiber.ErrNotFound
+		c.Log.WithContext(ctx).WithError(err).Error("error getting contact")
+		return nil, nil, fiber.ErrNotFound
 	}
 
 	if err := c.Validate.Struct(request); err != nil {
-		c.Log.WithError(err).Error("error validating request body")
-		return nil, fiber.ErrBadRequest
+		c.Log.WithContext(ctx).WithError(err).Error("error validating request body")
+		return nil, nil, fiber.ErrBadRequest
 	}
 
+	if err := checkVersion("contact", request.Version, contact.Version); err != nil {
+		return nil, nil, err
+	}
+
+	emails, phones, err := contactChannels(request.Email, request.Emails, request.Phone, request.Phones)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	before := converter.ContactToResponse(contact)
 	contact.FirstName = request.FirstName
 	contact.LastName = request.LastName
-	contact.Email = request.Email
-	contact.Phone = request.Phone
+	contact.Emails = emails
+	contact.Phones = phones
+	contact.Version++
+
+	updated, err := c.ContactRepository.UpdateVersion(tx, contact, before.Version)
+	if err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("error updating contact")
+		return nil, nil, fiber.ErrInternalServerError
+	}
+	if !updated {
+		return nil, nil, errConcurrentChange("contact")
+	}
+
+	if err := c.ContactRepository.ReplaceChannels(tx, contact); err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("error updating contact emails and phones")
+		return nil, nil, fiber.ErrInternalServerError
+	}
+
+	if err := c.AuditLog.Record(ctx, tx, model.AuditUpdate, model.AuditContact, contact.ID, before, converter.ContactToResponse(contact)); err != nil {
+		return nil, nil, err
+	}
+
+	response := converter.ContactToResponse(contact)
+	event, err := c.EventBus.Record(ctx, tx, model.EventContactUpdated, "contacts/"+contact.ID, contact.UserId, response)
+	if err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("error recording event")
+		return nil, nil, fiber.ErrInternalServerError
+	}
+
+	return response, event, nil
+}
+
+func (c *ContactUseCase) Patch(ctx context.Context, request *model.PatchContactRequest) (*model.ContactResponse, error) {
+	ctx, span := tracing.Start(ctx, "ContactUseCase.Patch")
+	defer span.End()
+
+	tx := c.TxManager.Begin(ctx)
+	defer tx.Rollback()
+
+	contact := new(entity.Contact)
+	if err := c.ContactRepository.FindByIdAndUserId(tx, contact, request.ID, request.UserId); err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("error getting contact")
+		return nil, fiber.ErrNotFound
+	}
 
-	if err := c.ContactRepository.Update(tx, contact); err != nil {
-		c.Log.WithError(err).Error("error updating contact")
+	if err := c.Validate.Struct(request); err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("error validating request body")
+		return nil, fiber.ErrBadRequest
+	}
+
+	if err := checkVersion("contact", request.Version, contact.Version); err != nil {
+		return nil, err
+	}
+
+	before := converter.ContactToResponse(contact)
+	request.FirstName.Apply(&contact.FirstName)
+	request.LastName.Apply(&contact.LastName)
+	if err := patchChannels(contact, request); err != nil {
+		return nil, err
+	}
+	contact.Version++
+
+	updated, err := c.ContactRepository.UpdateVersion(tx, contact, before.Version)
+	if err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("error updating contact")
 		return nil, fiber.ErrInternalServerError
 	}
+	if !updated {
+		return nil, errConcurrentChange("contact")
+	}
 
-	if err := tx.Commit().Error; err != nil {
-		c.Log.WithError(err).Error("error updating contact")
+	if err := c.ContactRepository.ReplaceChannels(tx, contact); err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("error updating contact emails and phones")
 		return nil, fiber.ErrInternalServerError
 	}
 
-	return converter.ContactToResponse(contact), nil
+	if err := c.AuditLog.Record(ctx, tx, model.AuditUpdate, model.AuditContact, contact.ID, before, converter.ContactToResponse(contact)); err != nil {
+		return nil, err
+	}
+
+	response := converter.ContactToResponse(contact)
+	event, err := c.EventBus.Record(ctx, tx, model.EventContactUpdated, "contacts/"+contact.ID, contact.UserId, response)
+	if err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("error recording event")
+		return nil, fiber.ErrInternalServerError
+	}
+
+	if err := commit(ctx, tx); err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("error updating contact")
+		return nil, fiber.ErrInternalServerError
+	}
+
+	c.EventBus.Deliver(ctx, event)
+
+	c.Options.Avatars.SignURLs(ctx, response)
+	return response, nil
+}
+
+// contactChannels returns the emails and phone numbers of a contact given as in
+// model.CreateContactRequest, which must have at least one email.
+func contactChannels(email string, emails []model.ContactEmail, phone string, phones []model.ContactPhone) ([]entity.ContactEmail, []entity.ContactPhone, error) {
+	contactEmails, contactPhones, err := toContactChannels(email, emails, phone, phones)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(contactEmails) == 0 {
+		return nil, nil, fiber.NewError(fiber.StatusBadRequest, "email is required")
+	}
+	return contactEmails, contactPhones, nil
+}
+
+// toContactChannels converts emails and phones, or the single email and phone
+// given in their place when they are left out.
+func toContactChannels(email string, emails []model.ContactEmail, phone string, phones []model.ContactPhone) ([]entity.ContactEmail, []entity.ContactPhone, error) {
+	if len(emails) == 0 && email != "" {
+		emails = []model.ContactEmail{{Email: email}}
+	}
+	if len(phones) == 0 && phone != "" {
+		phones = []model.ContactPhone{{Phone: phone}}
+	}
+
+	contactEmails, err := toContactEmails(emails)
+	if err != nil {
+		return nil, nil, err
+	}
+	contactPhones, err := toContactPhones(phones)
+	if err != nil {
+		return nil, nil, err
+	}
+	return contactEmails, contactPhones, nil
+}
+
+// toContactEmails converts the emails of a request, of type other when they have
+// none. The first one is primary when none is marked, and marking more than one
+// is refused.
+func toContactEmails(emails []model.ContactEmail) ([]entity.ContactEmail, error) {
+	primary, err := primaryIndex("email", len(emails), func(i int) bool { return emails[i].Primary })
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]entity.ContactEmail, len(emails))
+	for i, email := range emails {
+		result[i] = entity.ContactEmail{Position: i, Type: contactType(email.Type), Email: email.Email, Primary: i == primary}
+	}
+	return result, nil
+}
+
+// toContactPhones converts the phone numbers of a request as toContactEmails does.
+func toContactPhones(phones []model.ContactPhone) ([]entity.ContactPhone, error) {
+	primary, err := primaryIndex("phone", len(phones), func(i int) bool { return phones[i].Primary })
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]entity.ContactPhone, len(phones))
+	for i, phone := range phones {
+		result[i] = entity.ContactPhone{Position: i, Type: contactType(phone.Type), Phone: phone.Phone, Primary: i == primary}
+	}
+	return result, nil
+}
+
+// primaryIndex returns the index of the one of n items marked primary, 0 when none is.
+func primaryIndex(name string, n int, primary func(i int) bool) (int, error) {
+	index := -1
+	for i := range n {
+		if !primary(i) {
+			continue
+		}
+		if index >= 0 {
+			return 0, fiber.NewError(fiber.StatusBadRequest, "only one "+name+" can be primary")
+		}
+		index = i
+	}
+	return max(index, 0), nil
+}
+
+func contactType(value string) string {
+	if value == "" {
+		return model.ContactTypeOther
+	}
+	return value
+}
+
+// patchChannels applies the emails and phone numbers of a patch to contact. Emails
+// and Phones replace all of them, then Email and Phone replace the primary ones,
+// adding them when the contact has none; a null Phone removes the primary phone.
+func patchChannels(contact *entity.Contact, request *model.PatchContactRequest) error {
+	// omitnil lets a null list through the validator, but a contact keeps an email
+	if request.Emails.Present && request.Emails.Value == nil {
+		return fiber.NewError(fiber.StatusBadRequest, "email is required")
+	}
+	if request.Emails.Present {
+		var emails []model.ContactEmail
+		request.Emails.Apply(&emails)
+		contactEmails, err := toContactEmails(emails)
+		if err != nil {
+			return err
+		}
+		contact.Emails = contactEmails
+	}
+	if request.Phones.Present {
+		var phones []model.ContactPhone
+		request.Phones.Apply(&phones)
+		contactPhones, err := toContactPhones(phones)
+		if err != nil {
+			return err
+		}
+		contact.Phones = contactPhones
+	}
+
+	if email := request.Email; email.Present {
+		index := slices.IndexFunc(contact.Emails, func(email entity.ContactEmail) bool { return email.Primary })
+		if index < 0 {
+			contact.Emails = append(contact.Emails, entity.ContactEmail{Type: model.ContactTypeOther, Primary: true})
+			index = len(contact.Emails) - 1
+		}
+		contact.Emails[index].Email = *email.Value
+	}
+
+	if phone := request.Phone; phone.Present {
+		index := slices.IndexFunc(contact.Phones, func(phone entity.ContactPhone) bool { return phone.Primary })
+		switch {
+		case phone.Value == nil || *phone.Value == "":
+			if index >= 0 {
+				contact.Phones = slices.Delete(contact.Phones, index, index+1)
+				if len(contact.Phones) > 0 {
+					contact.Phones[0].Primary = true
+				}
+			}
+		case index < 0:
+			contact.Phones = append(contact.Phones, entity.ContactPhone{Type: model.ContactTypeOther, Phone: *phone.Value, Primary: true})
+		default:
+			contact.Phones[index].Phone = *phone.Value
+		}
+	}
+	return nil
 }
 
 func (c *ContactUseCase) Get(ctx context.Context, request *model.GetContactRequest) (*model.ContactResponse, error) {
-	tx := c.DB.WithContext(ctx).Begin()
-	defer tx.Rollback()
+	ctx, span := tracing.Start(ctx, "ContactUseCase.Get")
+	defer span.End()
 
 	if err := c.Validate.Struct(request); err != nil {
-		c.Log.WithError(err).Error("error validating request body")
+		c.Log.WithContext(ctx).WithError(err).Error("error validating request body")
 		return nil, fiber.ErrBadRequest
 	}
 
-	contact := new(entity.Contact)
-	if err := c.ContactRepository.FindByIdAndUserId(tx, contact, request.ID, request.UserId); err != nil {
-		c.Log.WithError(err).Error("error getting contact")
-		return nil, fiber.ErrNotFound
+	response, err := cacheRead(ctx, c.Options.ReadCache, "contact", contactsNamespace(request.UserId), "contact:"+request.ID,
+		c.Options.ReadCache.contactTTL(), func() (*model.ContactResponse, error) {
+			return c.get(ctx, request)
+		})
+	if err != nil {
+		return nil, err
 	}
 
-	if err := tx.Commit().Error; err != nil {
-		c.Log.WithError(err).Error("error getting contact")
-		return nil, fiber.ErrInternalServerError
+	// signed after the cache, whose entries may outlive the URLs
+	c.Options.Avatars.SignURLs(ctx, response)
+	return response, nil
+}
+
+func (c *ContactUseCase) get(ctx context.Context, request *model.GetContactRequest) (*model.ContactResponse, error) {
+	// outside a transaction, so concurrent reads of the contact share a query
+	contact := new(entity.Contact)
+	if err := c.ContactRepository.FindByIdAndUserIdShared(c.TxManager.DB(ctx), contact, request.ID, request.UserId); err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("error getting contact")
+		return nil, fiber.ErrNotFound
 	}
 
 	return converter.ContactToResponse(contact), nil
 }
 
 func (c *ContactUseCase) Delete(ctx context.Context, request *model.DeleteContactRequest) error {
-	tx := c.DB.WithContext(ctx).Begin()
+	ctx, span := tracing.Start(ctx, "ContactUseCase.Delete")
+	defer span.End()
+
+	tx := c.TxManager.Begin(ctx)
 	defer tx.Rollback()
 
+	event, err := c.delete(ctx, tx, request)
+	if err != nil {
+		return err
+	}
+
+	if err := commit(ctx, tx); err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("error deleting contact")
+		return fiber.ErrInternalServerError
+	}
+
+	c.EventBus.Deliver(ctx, event)
+
+	return nil
+}
+
+// delete deletes a contact in tx and returns the event to deliver once tx is committed.
+func (c *ContactUseCase) delete(ctx context.Context, tx *gorm.DB, request *model.DeleteContactRequest) (*model.CloudEvent, error) {
 	if err := c.Validate.Struct(request); err != nil {
-		c.Log.WithError(err).Error("error validating request body")
-		return fiber.ErrBadRequest
+		c.Log.WithContext(ctx).WithError(err).Error("error validating request body")
+		return nil, fiber.ErrBadRequest
 	}
 
 	contact := new(entity.Contact)
 	if err := c.ContactRepository.FindByIdAndUserId(tx, contact, request.ID, request.UserId); err != nil {
-		c.Log.WithError(err).Error("error getting contact")
-		return fiber.ErrNotFound
+		c.Log.WithContext(ctx).WithError(err).Error("error getting contact")
+		return nil, fiber.ErrNotFound
+	}
+
+	if err := checkVersion("contact", request.Version, contact.Version); err != nil {
+		return nil, err
 	}
 
 	if err := c.ContactRepository.Delete(tx, contact); err != nil {
-		c.Log.WithError(err).Error("error deleting contact")
-		return fiber.ErrInternalServerError
+		c.Log.WithContext(ctx).WithError(err).Error("error deleting contact")
+		return nil, fiber.ErrInternalServerError
 	}
 
-	if err := tx.Commit().Error; err != nil {
-		c.Log.WithError(err).Error("error deleting contact")
-		return fiber.ErrInternalServerError
+	if err := c.AuditLog.Record(ctx, tx, model.AuditDelete, model.AuditContact, contact.ID, converter.ContactToResponse(contact), nil); err != nil {
+		return nil, err
 	}
 
-	return nil
+	event, err := c.EventBus.Record(ctx, tx, model.EventContactDeleted, "contacts/"+contact.ID, contact.UserId, converter.ContactToResponse(contact))
+	if err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("error recording event")
+		return nil, fiber.ErrInternalServerError
+	}
+
+	return event, nil
 }
 
-func (c *ContactUseCase) Search(ctx context.Context, request *model.SearchContactRequest) ([]model.ContactResponse, int64, error) {
-	tx := c.DB.WithContext(ctx).Begin()
+// Export sends every contact of the user, addresses included, ordered by id. The
+// contacts are read ExportBatchSize at a time, each batch after the last id sent, and
+// the next batch is only read once send accepted the previous one, so an export of
+// any size takes the memory of one batch and a slow client slows it down. Contacts
+// changed during a long export are sent as they are when their batch is read.
+func (c *ContactUseCase) Export(ctx context.Context, request *model.ExportContactRequest, send ContactExportSender) error {
+	ctx, span := tracing.Start(ctx, "ContactUseCase.Export")
+	defer span.End()
+
+	if err := c.Validate.Struct(request); err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("error validating request")
+		return fiber.ErrBadRequest
+	}
+
+	afterId := ""
+	for {
+		contacts, err := c.ContactRepository.FindPageByUserIdAfterId(c.TxManager.DB(ctx), request.UserId, afterId, c.Options.ExportBatchSize)
+		if err != nil {
+			c.Log.WithContext(ctx).WithError(err).Error("error reading contacts to export")
+			return err
+		}
+		if len(contacts) == 0 {
+			return nil
+		}
+
+		responses := make([]model.ContactResponse, len(contacts))
+		for i := range contacts {
+			responses[i] = *converter.ContactWithAddressesToResponse(&contacts[i])
+		}
+		if err := send(responses); err != nil {
+			return err
+		}
+
+		if len(contacts) < c.Options.ExportBatchSize {
+			return nil
+		}
+		afterId = contacts[len(contacts)-1].ID
+	}
+}
+
+// Bulk applies the operations of request in order and returns the outcome of each.
+// Every operation runs in a transaction of its own, so one failing leaves the others
+// applied, unless the request is atomic: then they share one transaction, and when
+// one fails none is applied and the others fail with 424.
+func (c *ContactUseCase) Bulk(ctx context.Context, request *model.BulkContactRequest) ([]model.BulkContactResult, error) {
+	ctx, span := tracing.Start(ctx, "ContactUseCase.Bulk")
+	defer span.End()
+
+	if err := c.Validate.Struct(request); err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("error validating request body")
+		return nil, fiber.ErrBadRequest
+	}
+
+	if len(request.Operations) > c.Options.BulkMaxOperations {
+		return nil, fiber.NewError(fiber.StatusBadRequest, fmt.Sprintf("a bulk request takes at most %d operations", c.Options.BulkMaxOperations))
+	}
+
+	var results []model.BulkContactResult
+	if request.Atomic {
+		results = c.bulkAtomic(ctx, request)
+	} else {
+		results = make([]model.BulkContactResult, len(request.Operations))
+		for i := range request.Operations {
+			results[i] = c.bulkOne(ctx, request.UserId, &request.Operations[i])
+		}
+	}
+
+	for _, result := range results {
+		if result.Contact != nil {
+			c.Options.Avatars.SignURLs(ctx, result.Contact)
+		}
+	}
+	return results, nil
+}
+
+// bulkOne applies operation in a transaction of its own.
+func (c *ContactUseCase) bulkOne(ctx context.Context, userId string, operation *model.BulkContactOperation) model.BulkContactResult {
+	tx := c.TxManager.Begin(ctx)
 	defer tx.Rollback()
 
+	response, event, err := c.bulkOperation(ctx, tx, userId, operation)
+	if err == nil {
+		if err = commit(ctx, tx); err != nil {
+			c.Log.WithContext(ctx).WithError(err).Error("error committing bulk operation")
+			err = fiber.ErrInternalServerError
+		}
+	}
+	countCreation(ctx, operation, err)
+
+	if err != nil {
+		return bulkFailure(err)
+	}
+
+	c.EventBus.Deliver(ctx, event)
+	return model.BulkContactResult{Status: fiber.StatusOK, Contact: response}
+}
+
+// bulkAtomic applies every operation of request in one transaction.
+func (c *ContactUseCase) bulkAtomic(ctx context.Context, request *model.BulkContactRequest) []model.BulkContactResult {
+	tx := c.TxManager.Begin(ctx)
+	defer tx.Rollback()
+
+	results := make([]model.BulkContactResult, len(request.Operations))
+	events := make([]*model.CloudEvent, 0, len(request.Operations))
+
+	failed := -1
+	var err error
+	for i := range request.Operations {
+		var response *model.ContactResponse
+		var event *model.CloudEvent
+		response, event, err = c.bulkOperation(ctx, tx, request.UserId, &request.Operations[i])
+		if err != nil {
+			failed = i
+			results[i] = bulkFailure(err)
+			break
+		}
+
+		results[i] = model.BulkContactResult{Status: fiber.StatusOK, Contact: response}
+		events = append(events, event)
+	}
+
+	if failed < 0 {
+		if err = commit(ctx, tx); err != nil {
+			c.Log.WithContext(ctx).WithError(err).Error("error committing bulk operations")
+			err = fiber.ErrInternalServerError
+		}
+	}
+
+	// the operations after the failed one never ran
+	for i := range request.Operations {
+		if failed >= 0 && i > failed {
+			break
+		}
+		countCreation(ctx, &request.Operations[i], err)
+	}
+
+	if err != nil {
+		for i := range results {
+			switch {
+			case failed < 0:
+				results[i] = bulkFailure(err)
+			case i != failed:
+				results[i] = model.BulkContactResult{Status: fiber.StatusFailedDependency, Error: fmt.Sprintf("not applied, operation %d failed", failed)}
+			}
+		}
+		return results
+	}
+
+	for _, event := range events {
+		c.EventBus.Deliver(ctx, event)
+	}
+	return results
+}
+
+// bulkOperation applies operation in tx as its endpoint would, and returns the event
+// to deliver once tx is committed.
+func (c *ContactUseCase) bulkOperation(ctx context.Context, tx *gorm.DB, userId string, operation *model.BulkContactOperation) (*model.ContactResponse, *model.CloudEvent, error) {
+	switch operation.Op {
+	case model.BulkCreate:
+		return c.create(ctx, tx, &model.CreateContactRequest{
+			UserId:    userId,
+			FirstName: operation.FirstName,
+			LastName:  operation.LastName,
+			Email:     operation.Email,
+			Phone:     operation.Phone,
+			Emails:    operation.Emails,
+			Phones:    operation.Phones,
+		})
+	case model.BulkUpdate:
+		return c.update(ctx, tx, &model.UpdateContactRequest{
+			UserId:    userId,
+			ID:        operation.ID,
+			FirstName: operation.FirstName,
+			LastName:  operation.LastName,
+			Email:     operation.Email,
+			Phone:     operation.Phone,
+			Emails:    operation.Emails,
+			Phones:    operation.Phones,
+			Version:   operation.Version,
+		})
+	case model.BulkDelete:
+		event, err := c.delete(ctx, tx, &model.DeleteContactRequest{
+			UserId:  userId,
+			ID:      operation.ID,
+			Version: operation.Version,
+		})
+		return nil, event, err
+	}
+	return nil, nil, fiber.NewError(fiber.StatusBadRequest, "op must be one of: create update delete")
+}
+
+// bulkFailure is the result of an operation that failed with err.
+func bulkFailure(err error) model.BulkContactResult {
+	var fiberError *fiber.Error
+	if !errors.As(err, &fiberError) {
+		fiberError = fiber.ErrInternalServerError
+	}
+	return model.BulkContactResult{Status: fiberError.Code, Error: fiberError.Message}
+}
+
+// countCreation counts a create operation of a bulk request like Create counts its
+// contact.
+func countCreation(ctx context.Context, operation *model.BulkContactOperation, err error) {
+	if operation.Op == model.BulkCreate && !model.IsDryRun(ctx) {
+		metrics.ContactCreations.WithLabelValues(metrics.Outcome(err)).Inc()
+	}
+}
+
+func (c *ContactUseCase) Search(ctx context.Context, request *model.SearchContactRequest) ([]model.ContactResponse, int64, error) {
+	ctx, span := tracing.Start(ctx, "ContactUseCase.Search")
+	defer span.End()
+
 	if err := c.Validate.Struct(request); err != nil {
-		c.Log.WithError(err).Error("error validating request body")
-		return nil, 0, fiber.ErrBadRequest
+		c.Log.WithContext(ctx).WithError(err).Error("error validating request body")
+		return nil, 0, err
+	}
+
+	if err := repository.ContactColumns.CheckFilter(request.Filter); err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("error validating filter")
+		return nil, 0, fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+
+	if err := repository.ContactColumns.CheckSort(request.Sort); err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("error validating sort")
+		return nil, 0, fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+
+	if request.Letter != "" && len(request.Sort) == 0 {
+		// jumping to a letter only makes sense in alphabetical order
+		request.Sort = []model.SortField{{Field: "first_name"}, {Field: "last_name"}}
+	}
+	if len(request.Sort) > 0 && !slices.ContainsFunc(request.Sort, func(field model.SortField) bool { return field.Field == "id" }) {
+		// ties are broken by id, so no contact moves between pages
+		request.Sort = append(slices.Clone(request.Sort), model.SortField{Field: "id"})
+	}
+
+	key := "search:" + cacheKey(request.Name, request.Email, request.Phone, request.Letter, request.Tags, request.Page, request.Size, request.Skip,
+		request.Filter, request.Sort)
+	page, err := cacheRead(ctx, c.Options.ReadCache, "contacts", contactsNamespace(request.UserId), key,
+		c.Options.ReadCache.contactTTL(), func() (*contactPage, error) {
+			return c.search(ctx, request)
+		})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	c.signURLs(ctx, page.Contacts)
+	return page.Contacts, page.Total, nil
+}
+
+func (c *ContactUseCase) search(ctx context.Context, request *model.SearchContactRequest) (*contactPage, error) {
+	if c.Options.SearchIndex != nil && indexable(request) {
+		page, err := c.searchIndex(ctx, request)
+		if err == nil {
+			return page, nil
+		}
+		c.Log.WithContext(ctx).WithError(err).Warn("error searching contact index, searching the database")
 	}
 
+	tx := c.TxManager.Begin(ctx, readOnly)
+	defer tx.Rollback()
+
 	contacts, total, err := c.ContactRepository.Search(tx, request)
 	if err != nil {
-		c.Log.WithError(err).Error("error getting contacts")
-		return nil, 0, fiber.ErrInternalServerError
+		c.Log.WithContext(ctx).WithError(err).Error("error getting contacts")
+		return nil, fiber.ErrInternalServerError
 	}
 
 	if err := tx.Commit().Error; err != nil {
-		c.Log.WithError(err).Error("error getting contacts")
-		return nil, 0, fiber.ErrInternalServerError
+		c.Log.WithContext(ctx).WithError(err).Error("error getting contacts")
+		return nil, fiber.ErrInternalServerError
 	}
 
 	responses := make([]model.ContactResponse, len(contacts))
@@ -171,5 +807,241 @@ func (c *ContactUseCase) Search(ctx context.Context, request *model.SearchContac
 		responses[i] = *converter.ContactToResponse(&contact)
 	}
 
-	return responses, total, nil
+	return &contactPage{Contacts: responses, Total: total}, nil
+}
+
+// indexable reports whether the search index can answer request: a search by name,
+// email or phone in the order of relevance, and not deeper than the index pages.
+func indexable(request *model.SearchContactRequest) bool {
+	if request.Name == "" && request.Email == "" && request.Phone == "" {
+		return false
+	}
+	return request.Filter == nil && request.Letter == "" && len(request.Tags) == 0 && len(request.Sort) == 0 &&
+		request.Offset()+request.Size <= search.MaxResultWindow
+}
+
+// searchIndex finds the page of contacts in the search index and reads them from the
+// database, in the order of the index. Contacts deleted since they were indexed are
+// left out of the page.
+func (c *ContactUseCase) searchIndex(ctx context.Context, request *model.SearchContactRequest) (*contactPage, error) {
+	ids, total, err := c.Options.SearchIndex.Search(ctx, &search.Query{
+		UserId: request.UserId,
+		Name:   request.Name,
+		Email:  request.Email,
+		Phone:  request.Phone,
+		From:   request.Offset(),
+		Size:   request.Size,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	tx := c.TxManager.Begin(ctx, readOnly)
+	defer tx.Rollback()
+
+	contacts, err := c.ContactRepository.FindByIdsAndUserId(tx, ids, request.UserId)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return nil, err
+	}
+
+	byId := make(map[string]*entity.Contact, len(contacts))
+	for i := range contacts {
+		byId[contacts[i].ID] = &contacts[i]
+	}
+	responses := make([]model.ContactResponse, 0, len(ids))
+	for _, id := range ids {
+		if contact, ok := byId[id]; ok {
+			responses = append(responses, *converter.ContactToResponse(contact))
+		}
+	}
+
+	return &contactPage{Contacts: responses, Total: total}, nil
+}
+
+// SearchByCursor pages through the contacts matching the request by keyset rather
+// than offset, so deep pages cost as little as the first one. Pages follow the
+// requested sort, by default the creation order, with the id breaking ties; the
+// returned paging holds the cursors of the next and previous pages, when there are.
+func (c *ContactUseCase) SearchByCursor(ctx context.Context, request *model.SearchContactRequest) ([]model.ContactResponse, *model.PageMetadata, error) {
+	ctx, span := tracing.Start(ctx, "ContactUseCase.SearchByCursor")
+	defer span.End()
+
+	tx := c.TxManager.Begin(ctx, readOnly)
+	defer tx.Rollback()
+
+	if err := c.Validate.Struct(request); err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("error validating request body")
+		return nil, nil, err
+	}
+
+	if err := repository.ContactColumns.CheckFilter(request.Filter); err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("error validating filter")
+		return nil, nil, fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+
+	if err := repository.ContactColumns.CheckSort(request.Sort); err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("error validating sort")
+		return nil, nil, fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+
+	if request.Skip > 0 {
+		return nil, nil, fiber.NewError(fiber.StatusBadRequest, "a cursor cannot be combined with $skip")
+	}
+
+	sort := request.Sort
+	if len(sort) == 0 {
+		if request.Letter != "" {
+			sort = []model.SortField{{Field: "first_name"}, {Field: "last_name"}}
+		} else {
+			sort = []model.SortField{{Field: "created_at"}}
+		}
+	}
+	if !slices.ContainsFunc(sort, func(field model.SortField) bool { return field.Field == "id" }) {
+		sort = append(slices.Clone(sort), model.SortField{Field: "id"})
+	}
+	signature := model.SortSignature(sort)
+
+	var cursor *model.Cursor
+	if request.Cursor != nil && *request.Cursor != "" {
+		var err error
+		cursor, err = model.DecodeCursor(*request.Cursor)
+		if err != nil || len(cursor.Values) != len(sort) {
+			c.Log.WithContext(ctx).WithError(err).Error("error decoding cursor")
+			return nil, nil, fiber.NewError(fiber.StatusBadRequest, model.ErrInvalidCursor.Error())
+		}
+		if cursor.Sort != signature {
+			return nil, nil, fiber.NewError(fiber.StatusBadRequest, "cursor was taken in another sort order")
+		}
+	}
+
+	contacts, err := c.ContactRepository.SearchByCursor(tx, request, sort, cursor)
+	if err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("error getting contacts")
+		return nil, nil, fiber.ErrInternalServerError
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("error getting contacts")
+		return nil, nil, fiber.ErrInternalServerError
+	}
+
+	more := len(contacts) > request.Size
+	if more {
+		contacts = contacts[:request.Size]
+	}
+
+	// going forward there is a next page when a contact is left over, and a previous
+	// one unless this is the first page; going backward it is the other way around
+	backward := cursor != nil && cursor.Before
+	hasNext, hasPrevious := more, cursor != nil
+	if backward {
+		slices.Reverse(contacts)
+		hasNext, hasPrevious = true, more
+	}
+
+	paging := &model.PageMetadata{Size: request.Size}
+	if len(contacts) > 0 {
+		if hasNext {
+			next := &model.Cursor{Sort: signature, Values: repository.ContactSortKey(&contacts[len(contacts)-1], sort)}
+			paging.NextCursor = next.Encode()
+		}
+		if hasPrevious {
+			previous := &model.Cursor{Sort: signature, Values: repository.ContactSortKey(&contacts[0], sort), Before: true}
+			paging.PrevCursor = previous.Encode()
+		}
+	}
+
+	responses := make([]model.ContactResponse, len(contacts))
+	for i, contact := range contacts {
+		responses[i] = *converter.ContactToResponse(&contact)
+	}
+
+	c.signURLs(ctx, responses)
+	return responses, paging, nil
+}
+
+// signURLs signs the URLs of the avatars of contacts.
+func (c *ContactUseCase) signURLs(ctx context.Context, contacts []model.ContactResponse) {
+	for i := range contacts {
+		c.Options.Avatars.SignURLs(ctx, &contacts[i])
+	}
+}
+
+// Suggest returns the contacts matching a typed prefix for autocomplete. A query
+// running over the latency budget is abandoned with no suggestions rather than
+// holding up the user, who is typing the next character anyway.
+func (c *ContactUseCase) Suggest(ctx context.Context, request *model.SuggestContactRequest) ([]model.ContactSuggestionResponse, error) {
+	ctx, span := tracing.Start(ctx, "ContactUseCase.Suggest")
+	defer span.End()
+
+	if err := c.Validate.Struct(request); err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("error validating request")
+		return nil, fiber.ErrBadRequest
+	}
+
+	if c.Options.SuggestTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.Options.SuggestTimeout)
+		defer cancel()
+	}
+
+	contacts, err := c.ContactRepository.Suggest(c.TxManager.DB(ctx), request.UserId, request.Query, request.Limit)
+	if err != nil {
+		if ctx.Err() == context.DeadlineExceeded {
+			c.Log.WithContext(ctx).WithError(err).Warn("contact suggestions ran over the latency budget")
+			return []model.ContactSuggestionResponse{}, nil
+		}
+		c.Log.WithContext(ctx).WithError(err).Error("error getting contact suggestions")
+		return nil, fiber.ErrInternalServerError
+	}
+
+	responses := make([]model.ContactSuggestionResponse, len(contacts))
+	for i, contact := range contacts {
+		responses[i] = *converter.ContactToSuggestion(&contact)
+	}
+
+	return responses, nil
+}
+
+// Index counts the contacts per initial of their first name, with every letter from
+// A to Z present, zero or not, followed by model.ContactIndexOther.
+func (c *ContactUseCase) Index(ctx context.Context, request *model.ContactIndexRequest) ([]model.ContactIndexResponse, error) {
+	ctx, span := tracing.Start(ctx, "ContactUseCase.Index")
+	defer span.End()
+
+	tx := c.TxManager.Begin(ctx)
+	defer tx.Rollback()
+
+	if err := c.Validate.Struct(request); err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("error validating request")
+		return nil, fiber.ErrBadRequest
+	}
+
+	initials, err := c.ContactRepository.CountByInitial(tx, request.UserId)
+	if err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("error counting contacts by initial")
+		return nil, fiber.ErrInternalServerError
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("error counting contacts by initial")
+		return nil, fiber.ErrInternalServerError
+	}
+
+	totals := make(map[string]int64, len(initials))
+	for _, initial := range initials {
+		totals[initial.Letter] = initial.Total
+	}
+
+	responses := make([]model.ContactIndexResponse, 0, 27)
+	for letter := 'A'; letter <= 'Z'; letter++ {
+		responses = append(responses, model.ContactIndexResponse{Letter: string(letter), Count: totals[string(letter)]})
+	}
+	responses = append(responses, model.ContactIndexResponse{Letter: model.ContactIndexOther, Count: totals[model.ContactIndexOther]})
+
+	return responses, nil
 }
@@ -0,0 +1,261 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"go-rest-scaffold/internal/entity"
+	"go-rest-scaffold/internal/model"
+	"go-rest-scaffold/internal/model/converter"
+	"go-rest-scaffold/internal/repository"
+	"go-rest-scaffold/internal/tracing"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+const redacted = "[REDACTED]"
+
+// DebugCaptureOptions controls how captured exchanges are stored.
+type DebugCaptureOptions struct {
+	TTL             time.Duration
+	CleanupInterval time.Duration
+	MaxBodySize     int
+	// RedactFields are JSON object keys whose values are replaced, at any depth.
+	RedactFields []string
+	// RedactHeaders are header names whose values are replaced.
+	RedactHeaders []string
+}
+
+type DebugCaptureUseCase struct {
+	TxManager              TxManager
+	Log                    *logrus.Logger
+	Validate               *validator.Validate
+	DebugCaptureRepository *repository.DebugCaptureRepository
+	Options                DebugCaptureOptions
+
+	redactFields  map[string]struct{}
+	redactHeaders map[string]struct{}
+	lastCleanup   atomic.Int64
+}
+
+func NewDebugCaptureUseCase(txManager TxManager, logger *logrus.Logger, validate *validator.Validate,
+	debugCaptureRepository *repository.DebugCaptureRepository, options DebugCaptureOptions) *DebugCaptureUseCase {
+	useCase := &DebugCaptureUseCase{
+		TxManager:              txManager,
+		Log:                    logger,
+		Validate:               validate,
+		DebugCaptureRepository: debugCaptureRepository,
+		Options:                options,
+		redactFields:           make(map[string]struct{}),
+		redactHeaders:          make(map[string]struct{}),
+	}
+
+	for _, field := range options.RedactFields {
+		useCase.redactFields[strings.ToLower(field)] = struct{}{}
+	}
+	for _, header := range options.RedactHeaders {
+		useCase.redactHeaders[strings.ToLower(header)] = struct{}{}
+	}
+
+	return useCase
+}
+
+// Record redacts and stores a captured exchange, and deletes expired captures at most once per cleanup interval.
+func (c *DebugCaptureUseCase) Record(ctx context.Context, request *model.RecordDebugCaptureRequest) error {
+	ctx, span := tracing.Start(ctx, "DebugCaptureUseCase.Record")
+	defer span.End()
+
+	now := time.Now()
+
+	requestHeaders, err := json.Marshal(c.redactHeaderValues(request.RequestHeaders))
+	if err != nil {
+		return err
+	}
+
+	responseHeaders, err := json.Marshal(c.redactHeaderValues(request.ResponseHeaders))
+	if err != nil {
+		return err
+	}
+
+	capture := &entity.DebugCapture{
+		ID:              uuid.NewString(),
+		UserId:          request.UserId,
+		Method:          request.Method,
+		Path:            c.redactQuery(request.Path, request.Query),
+		Status:          request.Status,
+		DurationMs:      request.DurationMs,
+		RequestHeaders:  string(requestHeaders),
+		RequestBody:     c.redactBody(request.RequestBody, request.RequestType),
+		ResponseHeaders: string(responseHeaders),
+		ResponseBody:    c.redactBody(request.ResponseBody, request.ResponseType),
+		ExpiresAt:       now.Add(c.Options.TTL).UnixMilli(),
+	}
+
+	tx := c.TxManager.Begin(ctx)
+	defer tx.Rollback()
+
+	if err := c.DebugCaptureRepository.Create(tx, capture); err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to create debug capture")
+		return fiber.ErrInternalServerError
+	}
+
+	last := c.lastCleanup.Load()
+	if now.Sub(time.UnixMilli(last)) >= c.Options.CleanupInterval && c.lastCleanup.CompareAndSwap(last, now.UnixMilli()) {
+		deleted, err := c.DebugCaptureRepository.DeleteExpired(tx, now.UnixMilli())
+		if err != nil {
+			c.Log.WithContext(ctx).WithError(err).Error("failed to delete expired debug captures")
+			return fiber.ErrInternalServerError
+		}
+		if deleted > 0 {
+			c.Log.WithContext(ctx).Debugf("Deleted %d expired debug captures", deleted)
+		}
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to commit transaction")
+		return fiber.ErrInternalServerError
+	}
+
+	return nil
+}
+
+func (c *DebugCaptureUseCase) Get(ctx context.Context, request *model.GetDebugCaptureRequest) (*model.DebugCaptureResponse, error) {
+	ctx, span := tracing.Start(ctx, "DebugCaptureUseCase.Get")
+	defer span.End()
+
+	tx := c.TxManager.Begin(ctx)
+	defer tx.Rollback()
+
+	if err := c.Validate.Struct(request); err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to validate request body")
+		return nil, fiber.ErrBadRequest
+	}
+
+	capture := new(entity.DebugCapture)
+	if err := c.DebugCaptureRepository.FindUnexpiredById(tx, capture, request.ID, time.Now().UnixMilli()); err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to find debug capture")
+		return nil, fiber.ErrNotFound
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to commit transaction")
+		return nil, fiber.ErrInternalServerError
+	}
+
+	return converter.DebugCaptureToResponse(capture), nil
+}
+
+func (c *DebugCaptureUseCase) Search(ctx context.Context, request *model.SearchDebugCaptureRequest) ([]model.DebugCaptureResponse, int64, error) {
+	ctx, span := tracing.Start(ctx, "DebugCaptureUseCase.Search")
+	defer span.End()
+
+	tx := c.TxManager.Begin(ctx)
+	defer tx.Rollback()
+
+	if err := c.Validate.Struct(request); err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to validate request body")
+		return nil, 0, err
+	}
+
+	captures, total, err := c.DebugCaptureRepository.Search(tx, request, time.Now().UnixMilli())
+	if err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to search debug captures")
+		return nil, 0, fiber.ErrInternalServerError
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to commit transaction")
+		return nil, 0, fiber.ErrInternalServerError
+	}
+
+	responses := make([]model.DebugCaptureResponse, len(captures))
+	for i, capture := range captures {
+		responses[i] = *converter.DebugCaptureToResponse(&capture)
+	}
+
+	return responses, total, nil
+}
+
+func (c *DebugCaptureUseCase) redactHeaderValues(headers map[string]string) map[string]string {
+	result := make(map[string]string, len(headers))
+	for name, value := range headers {
+		if _, ok := c.redactHeaders[strings.ToLower(name)]; ok {
+			value = redacted
+		}
+		result[name] = value
+	}
+	return result
+}
+
+func (c *DebugCaptureUseCase) redactQuery(path string, query string) string {
+	if query == "" {
+		return path
+	}
+
+	values, err := url.ParseQuery(query)
+	if err != nil {
+		return path + "?" + redacted
+	}
+
+	for key := range values {
+		if _, ok := c.redactFields[strings.ToLower(key)]; ok {
+			values[key] = []string{redacted}
+		}
+	}
+	return path + "?" + values.Encode()
+}
+
+// redactBody keeps JSON bodies with sensitive fields replaced. Other content types
+// cannot be redacted reliably, so only their size is kept.
+func (c *DebugCaptureUseCase) redactBody(body []byte, contentType string) string {
+	if len(body) == 0 {
+		return ""
+	}
+
+	if !strings.HasPrefix(contentType, fiber.MIMEApplicationJSON) {
+		return fmt.Sprintf("[omitted %d bytes of %s]", len(body), contentType)
+	}
+
+	var document any
+	if err := json.Unmarshal(body, &document); err != nil {
+		return fmt.Sprintf("[omitted %d bytes of invalid JSON]", len(body))
+	}
+
+	content, err := json.Marshal(c.redactValue(document))
+	if err != nil {
+		return fmt.Sprintf("[omitted %d bytes]", len(body))
+	}
+
+	if c.Options.MaxBodySize > 0 && len(content) > c.Options.MaxBodySize {
+		return string(content[:c.Options.MaxBodySize]) + "...[truncated]"
+	}
+	return string(content)
+}
+
+func (c *DebugCaptureUseCase) redactValue(value any) any {
+	switch value := value.(type) {
+	case map[string]any:
+		for key, child := range value {
+			if _, ok := c.redactFields[strings.ToLower(key)]; ok {
+				value[key] = redacted
+			} else {
+				value[key] = c.redactValue(child)
+			}
+		}
+		return value
+	case []any:
+		for i, child := range value {
+			value[i] = c.redactValue(child)
+		}
+		return value
+	default:
+		return value
+	}
+}
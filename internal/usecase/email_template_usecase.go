@@ -0,0 +1,278 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"go-rest-scaffold/internal/entity"
+	"go-rest-scaffold/internal/mail"
+	"go-rest-scaffold/internal/model"
+	"go-rest-scaffold/internal/model/converter"
+	"go-rest-scaffold/internal/repository"
+	"go-rest-scaffold/internal/tracing"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// EmailTemplateUseCase resolves the template of an email: the override of the tenant,
+// else the deployment-wide override, else the built-in template of the catalog.
+// Overrides are deployment configuration and live in the home region.
+type EmailTemplateUseCase struct {
+	TxManager               TxManager
+	Log                     *logrus.Logger
+	Validate                *validator.Validate
+	Catalog                 *mail.Catalog
+	EmailTemplateRepository *repository.EmailTemplateRepository
+}
+
+func NewEmailTemplateUseCase(txManager TxManager, logger *logrus.Logger, validate *validator.Validate, catalog *mail.Catalog,
+	emailTemplateRepository *repository.EmailTemplateRepository) *EmailTemplateUseCase {
+	return &EmailTemplateUseCase{
+		TxManager:               txManager,
+		Log:                     logger,
+		Validate:                validate,
+		Catalog:                 catalog,
+		EmailTemplateRepository: emailTemplateRepository,
+	}
+}
+
+func (c *EmailTemplateUseCase) List(ctx context.Context, request *model.ListEmailTemplateRequest) ([]model.EmailTemplateResponse, error) {
+	ctx, span := tracing.Start(ctx, "EmailTemplateUseCase.List")
+	defer span.End()
+
+	tx := c.TxManager.Begin(model.WithHomeRegion(ctx))
+	defer tx.Rollback()
+
+	if err := c.Validate.Struct(request); err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to validate request body")
+		return nil, err
+	}
+
+	overrides, err := c.EmailTemplateRepository.FindByTenants(tx, tenants(request.Tenant))
+	if err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to find email template overrides")
+		return nil, fiber.ErrInternalServerError
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to commit transaction")
+		return nil, fiber.ErrInternalServerError
+	}
+
+	effective := make(map[string]*model.EmailTemplateResponse)
+	for _, override := range overrides {
+		if current, ok := effective[override.Name]; !ok || current.Tenant == "" {
+			effective[override.Name] = converter.EmailTemplateToResponse(&override)
+		}
+	}
+
+	responses := make([]model.EmailTemplateResponse, 0, len(c.Catalog.Names()))
+	for _, name := range c.Catalog.Names() {
+		response, ok := effective[name]
+		if !ok {
+			template, _ := c.Catalog.Default(name)
+			response = converter.MailTemplateToResponse(template)
+		}
+		responses = append(responses, *response)
+	}
+
+	return responses, nil
+}
+
+func (c *EmailTemplateUseCase) Get(ctx context.Context, request *model.GetEmailTemplateRequest) (*model.EmailTemplateResponse, error) {
+	ctx, span := tracing.Start(ctx, "EmailTemplateUseCase.Get")
+	defer span.End()
+
+	tx := c.TxManager.Begin(model.WithHomeRegion(ctx))
+	defer tx.Rollback()
+
+	if err := c.Validate.Struct(request); err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to validate request body")
+		return nil, fiber.ErrBadRequest
+	}
+
+	response, err := c.resolve(tx, request.Tenant, request.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to commit transaction")
+		return nil, fiber.ErrInternalServerError
+	}
+
+	return response, nil
+}
+
+func (c *EmailTemplateUseCase) Update(ctx context.Context, request *model.UpdateEmailTemplateRequest) (*model.EmailTemplateResponse, error) {
+	ctx, span := tracing.Start(ctx, "EmailTemplateUseCase.Update")
+	defer span.End()
+
+	tx := c.TxManager.Begin(model.WithHomeRegion(ctx))
+	defer tx.Rollback()
+
+	if err := c.Validate.Struct(request); err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to validate request body")
+		return nil, err
+	}
+
+	if _, ok := c.Catalog.Default(request.Name); !ok {
+		return nil, fiber.NewError(fiber.StatusNotFound, "unknown email template")
+	}
+
+	draft := mail.Template{Name: request.Name, Subject: request.Subject, HTML: request.HTML, Text: request.Text}
+	if _, err := draft.Render(c.Catalog.Sample(request.Name)); err != nil {
+		c.Log.WithContext(ctx).WithError(err).Warnf("Invalid email template %s", request.Name)
+		return nil, fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+
+	template := new(entity.EmailTemplate)
+	err := c.EmailTemplateRepository.FindByTenantAndName(tx, template, request.Tenant, request.Name)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to find email template")
+		return nil, fiber.ErrInternalServerError
+	}
+
+	template.Subject = request.Subject
+	template.HTML = request.HTML
+	template.Text = request.Text
+	template.Version++
+
+	if err == nil {
+		err = c.EmailTemplateRepository.Update(tx, template)
+	} else {
+		template.Tenant = request.Tenant
+		template.Name = request.Name
+		err = c.EmailTemplateRepository.Create(tx, template)
+	}
+	if err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to save email template")
+		return nil, fiber.ErrInternalServerError
+	}
+
+	if err := commit(ctx, tx); err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to commit transaction")
+		return nil, fiber.ErrInternalServerError
+	}
+
+	return converter.EmailTemplateToResponse(template), nil
+}
+
+// Delete removes the override of the tenant, so the next one in line applies again.
+func (c *EmailTemplateUseCase) Delete(ctx context.Context, request *model.DeleteEmailTemplateRequest) error {
+	ctx, span := tracing.Start(ctx, "EmailTemplateUseCase.Delete")
+	defer span.End()
+
+	tx := c.TxManager.Begin(model.WithHomeRegion(ctx))
+	defer tx.Rollback()
+
+	if err := c.Validate.Struct(request); err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to validate request body")
+		return fiber.ErrBadRequest
+	}
+
+	template := new(entity.EmailTemplate)
+	if err := c.EmailTemplateRepository.FindByTenantAndName(tx, template, request.Tenant, request.Name); err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to find email template")
+		return fiber.ErrNotFound
+	}
+
+	if err := c.EmailTemplateRepository.Delete(tx, template); err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to delete email template")
+		return fiber.ErrInternalServerError
+	}
+
+	if err := commit(ctx, tx); err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to commit transaction")
+		return fiber.ErrInternalServerError
+	}
+
+	return nil
+}
+
+func (c *EmailTemplateUseCase) Preview(ctx context.Context, request *model.PreviewEmailTemplateRequest) (*model.RenderedEmailResponse, error) {
+	ctx, span := tracing.Start(ctx, "EmailTemplateUseCase.Preview")
+	defer span.End()
+
+	tx := c.TxManager.Begin(model.WithHomeRegion(ctx))
+	defer tx.Rollback()
+
+	if err := c.Validate.Struct(request); err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to validate request body")
+		return nil, err
+	}
+
+	template := mail.Template{Name: request.Name, Subject: request.Subject, HTML: request.HTML, Text: request.Text}
+	if template.Subject == "" {
+		response, err := c.resolve(tx, request.Tenant, request.Name)
+		if err != nil {
+			return nil, err
+		}
+		template = mail.Template{Name: response.Name, Subject: response.Subject, HTML: response.HTML, Text: response.Text}
+	} else if _, ok := c.Catalog.Default(request.Name); !ok {
+		return nil, fiber.NewError(fiber.StatusNotFound, "unknown email template")
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to commit transaction")
+		return nil, fiber.ErrInternalServerError
+	}
+
+	data := c.Catalog.Sample(request.Name)
+	for key, value := range request.Data {
+		data[key] = value
+	}
+
+	message, err := template.Render(data)
+	if err != nil {
+		c.Log.WithContext(ctx).WithError(err).Warnf("Failed to render email template %s", request.Name)
+		return nil, fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+
+	return converter.MailMessageToResponse(message), nil
+}
+
+// Render renders the email name for the tenant, for the use cases that send emails.
+func (c *EmailTemplateUseCase) Render(ctx context.Context, tenant string, name string, data map[string]any) (*mail.Message, error) {
+	ctx, span := tracing.Start(ctx, "EmailTemplateUseCase.Render")
+	defer span.End()
+
+	response, err := c.resolve(c.TxManager.DB(model.WithHomeRegion(ctx)), tenant, name)
+	if err != nil {
+		return nil, err
+	}
+
+	template := mail.Template{Name: response.Name, Subject: response.Subject, HTML: response.HTML, Text: response.Text}
+	return template.Render(c.Catalog.Data(data))
+}
+
+func (c *EmailTemplateUseCase) resolve(db *gorm.DB, tenant string, name string) (*model.EmailTemplateResponse, error) {
+	defaultTemplate, ok := c.Catalog.Default(name)
+	if !ok {
+		return nil, fiber.NewError(fiber.StatusNotFound, "unknown email template")
+	}
+
+	for _, candidate := range tenants(tenant) {
+		template := new(entity.EmailTemplate)
+		err := c.EmailTemplateRepository.FindByTenantAndName(db, template, candidate, name)
+		if err == nil {
+			return converter.EmailTemplateToResponse(template), nil
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			c.Log.WithContext(db.Statement.Context).WithError(err).Error("failed to find email template")
+			return nil, fiber.ErrInternalServerError
+		}
+	}
+
+	return converter.MailTemplateToResponse(defaultTemplate), nil
+}
+
+// tenants lists the override owners consulted for tenant, most specific first.
+func tenants(tenant string) []string {
+	if tenant == "" {
+		return []string{""}
+	}
+	return []string{tenant, ""}
+}
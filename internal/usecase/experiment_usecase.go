@@ -0,0 +1,125 @@
+package usecase
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"go-rest-scaffold/internal/entity"
+	"go-rest-scaffold/internal/model"
+	"go-rest-scaffold/internal/repository"
+	"go-rest-scaffold/internal/tracing"
+	"sync"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/sirupsen/logrus"
+)
+
+// maxRecorded bounds the set of assignments remembered as already stored. When it
+// fills up it is simply reset; the insert is idempotent, so forgetting only costs a write.
+const maxRecorded = 100000
+
+type ExperimentUseCase struct {
+	TxManager                      TxManager
+	Log                            *logrus.Logger
+	Experiments                    []model.Experiment
+	ExperimentAssignmentRepository *repository.ExperimentAssignmentRepository
+
+	mutex    sync.Mutex
+	recorded map[string]struct{}
+}
+
+func NewExperimentUseCase(txManager TxManager, logger *logrus.Logger, experiments []model.Experiment,
+	experimentAssignmentRepository *repository.ExperimentAssignmentRepository) *ExperimentUseCase {
+	return &ExperimentUseCase{
+		TxManager:                      txManager,
+		Log:                            logger,
+		Experiments:                    experiments,
+		ExperimentAssignmentRepository: experimentAssignmentRepository,
+		recorded:                       make(map[string]struct{}),
+	}
+}
+
+// Assign returns the variant of every enabled experiment for the user and records
+// assignments that have not been stored yet, so they can be joined with outcomes later.
+func (c *ExperimentUseCase) Assign(ctx context.Context, userId string) (model.Experiments, error) {
+	ctx, span := tracing.Start(ctx, "ExperimentUseCase.Assign")
+	defer span.End()
+
+	experiments := make(model.Experiments, len(c.Experiments))
+	var pending []entity.ExperimentAssignment
+
+	c.mutex.Lock()
+	for _, experiment := range c.Experiments {
+		if !experiment.Enabled || len(experiment.Variants) == 0 {
+			continue
+		}
+
+		variant := AssignVariant(experiment, userId)
+		experiments[experiment.Key] = variant
+
+		if _, ok := c.recorded[userId+":"+experiment.Key]; !ok {
+			pending = append(pending, entity.ExperimentAssignment{
+				UserId:        userId,
+				ExperimentKey: experiment.Key,
+				Variant:       variant,
+			})
+		}
+	}
+	c.mutex.Unlock()
+
+	if len(pending) == 0 {
+		return experiments, nil
+	}
+
+	tx := c.TxManager.Begin(ctx)
+	defer tx.Rollback()
+
+	if err := c.ExperimentAssignmentRepository.Record(tx, pending); err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to record experiment assignments")
+		return nil, fiber.ErrInternalServerError
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to commit transaction")
+		return nil, fiber.ErrInternalServerError
+	}
+
+	c.mutex.Lock()
+	if len(c.recorded)+len(pending) > maxRecorded {
+		c.recorded = make(map[string]struct{})
+	}
+	for _, assignment := range pending {
+		c.recorded[assignment.UserId+":"+assignment.ExperimentKey] = struct{}{}
+	}
+	c.mutex.Unlock()
+
+	return experiments, nil
+}
+
+// AssignVariant deterministically buckets a user into one of the experiment's variants
+// by hashing the experiment key together with the user ID. The same user always lands
+// in the same variant, and different experiments bucket independently.
+func AssignVariant(experiment model.Experiment, userId string) string {
+	total := 0
+	for _, variant := range experiment.Variants {
+		total += variantWeight(variant)
+	}
+
+	sum := sha256.Sum256([]byte(experiment.Key + ":" + userId))
+	bucket := int(binary.BigEndian.Uint64(sum[:8]) % uint64(total))
+
+	for _, variant := range experiment.Variants {
+		bucket -= variantWeight(variant)
+		if bucket < 0 {
+			return variant.Name
+		}
+	}
+	return experiment.Variants[len(experiment.Variants)-1].Name
+}
+
+func variantWeight(variant model.ExperimentVariant) int {
+	if variant.Weight <= 0 {
+		return 1
+	}
+	return variant.Weight
+}
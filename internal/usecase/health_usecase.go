@@ -0,0 +1,97 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"go-rest-scaffold/internal/model"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// HealthCheck reports whether a dependency of the application can be reached.
+type HealthCheck func(ctx context.Context) error
+
+type HealthOptions struct {
+	// Checks by component name, e.g. "database" or "redis".
+	Checks map[string]HealthCheck
+	// Timeout of each check, shorter than the timeout of the probes.
+	Timeout time.Duration
+	// Draining reports whether the server is shutting down. nil when it never does.
+	Draining func() bool
+}
+
+// HealthUseCase answers the liveness and readiness probes of the orchestrator.
+type HealthUseCase struct {
+	Log     *logrus.Logger
+	Options HealthOptions
+}
+
+func NewHealthUseCase(logger *logrus.Logger, options HealthOptions) *HealthUseCase {
+	return &HealthUseCase{
+		Log:     logger,
+		Options: options,
+	}
+}
+
+// Live reports the process as up. It checks no dependency on purpose: a database
+// outage is not fixed by restarting every instance.
+func (c *HealthUseCase) Live(ctx context.Context) *model.HealthResponse {
+	return &model.HealthResponse{Status: model.HealthUp}
+}
+
+// Health runs every check at once and reports each component.
+func (c *HealthUseCase) Health(ctx context.Context) *model.HealthResponse {
+	response := &model.HealthResponse{
+		Status:     model.HealthUp,
+		Components: make(map[string]model.ComponentHealth, len(c.Options.Checks)),
+	}
+
+	var mutex sync.Mutex
+	var wait sync.WaitGroup
+	for name, check := range c.Options.Checks {
+		wait.Go(func() {
+			component := c.check(ctx, name, check)
+
+			mutex.Lock()
+			defer mutex.Unlock()
+			response.Components[name] = component
+			if component.Status != model.HealthUp {
+				response.Status = model.HealthDown
+			}
+		})
+	}
+	wait.Wait()
+
+	return response
+}
+
+// Ready reports whether the instance should get traffic: its dependencies are up
+// and it is not shutting down.
+func (c *HealthUseCase) Ready(ctx context.Context) *model.HealthResponse {
+	response := c.Health(ctx)
+	if c.Options.Draining != nil && c.Options.Draining() {
+		response.Status = model.HealthDown
+		response.Draining = true
+	}
+	return response
+}
+
+func (c *HealthUseCase) check(ctx context.Context, name string, check HealthCheck) model.ComponentHealth {
+	ctx, cancel := context.WithTimeout(ctx, c.Options.Timeout)
+	defer cancel()
+
+	start := time.Now()
+	err := check(ctx)
+	component := model.ComponentHealth{Status: model.HealthUp, LatencyMs: time.Since(start).Milliseconds()}
+	if err != nil {
+		c.Log.WithContext(ctx).WithError(err).Warnf("Health check %s failed", name)
+		component.Status = model.HealthDown
+		component.Error = "unavailable"
+		if errors.Is(err, context.DeadlineExceeded) || ctx.Err() != nil {
+			component.Error = "timeout"
+		}
+	}
+	return component
+}
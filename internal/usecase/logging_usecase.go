@@ -0,0 +1,192 @@
+package usecase
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"go-rest-scaffold/internal/logging"
+	"go-rest-scaffold/internal/model"
+	"go-rest-scaffold/internal/tracing"
+	"os"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"github.com/sirupsen/logrus"
+)
+
+type LoggingUseCase struct {
+	Log        *logrus.Logger
+	Validate   *validator.Validate
+	Levels     *logging.Levels
+	ConfigFile string
+}
+
+func NewLoggingUseCase(logger *logrus.Logger, validate *validator.Validate, levels *logging.Levels, configFile string) *LoggingUseCase {
+	return &LoggingUseCase{
+		Log:        logger,
+		Validate:   validate,
+		Levels:     levels,
+		ConfigFile: configFile,
+	}
+}
+
+func (c *LoggingUseCase) Get(ctx context.Context) *model.LoggingResponse {
+	ctx, span := tracing.Start(ctx, "LoggingUseCase.Get")
+	defer span.End()
+
+	level, components := c.Levels.Get()
+	return toLoggingResponse(level, components)
+}
+
+func (c *LoggingUseCase) Update(ctx context.Context, request *model.UpdateLoggingRequest) (*model.LoggingResponse, error) {
+	ctx, span := tracing.Start(ctx, "LoggingUseCase.Update")
+	defer span.End()
+
+	if err := c.Validate.Struct(request); err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to validate request body")
+		return nil, err
+	}
+
+	level, _ := logrus.ParseLevel(request.Level)
+	components := make(map[string]logrus.Level, len(request.Components))
+	for name, value := range request.Components {
+		components[name], _ = logrus.ParseLevel(value)
+	}
+
+	if request.Persist {
+		if err := c.persist(level, components); err != nil {
+			c.Log.WithContext(ctx).WithError(err).Error("failed to persist log levels")
+			return nil, fiber.ErrInternalServerError
+		}
+	}
+
+	c.Levels.Set(level, components)
+	c.Log.WithContext(ctx).Warnf("Log level changed to %s with components %v", level, request.Components)
+
+	return toLoggingResponse(level, components), nil
+}
+
+// UpdateLevel changes the global level only, keeping the component overrides. The
+// change lasts until the next restart or config reload.
+func (c *LoggingUseCase) UpdateLevel(ctx context.Context, request *model.UpdateLogLevelRequest) (*model.LoggingResponse, error) {
+	ctx, span := tracing.Start(ctx, "LoggingUseCase.UpdateLevel")
+	defer span.End()
+
+	if err := c.Validate.Struct(request); err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to validate request body")
+		return nil, err
+	}
+
+	level, _ := logrus.ParseLevel(request.Level)
+	_, components := c.Levels.Get()
+	c.Levels.Set(level, components)
+	c.Log.WithContext(ctx).Warnf("Log level changed to %s", level)
+
+	return toLoggingResponse(level, components), nil
+}
+
+// persist rewrites only the log block of the config file, keeping every other key and
+// their order untouched. Viper's own WriteConfig would also write values that came from
+// the environment, secrets included.
+func (c *LoggingUseCase) persist(level logrus.Level, components map[string]logrus.Level) error {
+	if c.ConfigFile == "" {
+		return errors.New("no config file to persist to")
+	}
+
+	info, err := os.Stat(c.ConfigFile)
+	if err != nil {
+		return err
+	}
+
+	content, err := os.ReadFile(c.ConfigFile)
+	if err != nil {
+		return err
+	}
+
+	keys, values, err := decodeObject(content)
+	if err != nil {
+		return err
+	}
+
+	logBlock := map[string]any{}
+	if raw, ok := values["log"]; ok {
+		if err := json.Unmarshal(raw, &logBlock); err != nil {
+			return err
+		}
+	} else {
+		keys = append(keys, "log")
+	}
+
+	componentLevels := make(map[string]int, len(components))
+	for name, componentLevel := range components {
+		componentLevels[name] = int(componentLevel)
+	}
+	logBlock["level"] = int(level)
+	logBlock["components"] = componentLevels
+
+	if values["log"], err = json.Marshal(logBlock); err != nil {
+		return err
+	}
+
+	buffer := new(bytes.Buffer)
+	buffer.WriteString("{")
+	for i, key := range keys {
+		if i > 0 {
+			buffer.WriteString(",")
+		}
+		name, _ := json.Marshal(key)
+		buffer.Write(name)
+		buffer.WriteString(":")
+		buffer.Write(values[key])
+	}
+	buffer.WriteString("}")
+
+	indented := new(bytes.Buffer)
+	if err := json.Indent(indented, buffer.Bytes(), "", "  "); err != nil {
+		return err
+	}
+
+	return os.WriteFile(c.ConfigFile, indented.Bytes(), info.Mode())
+}
+
+// decodeObject splits a JSON object into its raw values, remembering the key order.
+func decodeObject(content []byte) ([]string, map[string]json.RawMessage, error) {
+	decoder := json.NewDecoder(bytes.NewReader(content))
+	if token, err := decoder.Token(); err != nil || token != json.Delim('{') {
+		return nil, nil, errors.New("config file is not a JSON object")
+	}
+
+	var keys []string
+	values := map[string]json.RawMessage{}
+	for decoder.More() {
+		token, err := decoder.Token()
+		if err != nil {
+			return nil, nil, err
+		}
+		key := token.(string)
+
+		var value json.RawMessage
+		if err := decoder.Decode(&value); err != nil {
+			return nil, nil, err
+		}
+
+		if _, ok := values[key]; !ok {
+			keys = append(keys, key)
+		}
+		values[key] = value
+	}
+
+	return keys, values, nil
+}
+
+func toLoggingResponse(level logrus.Level, components map[string]logrus.Level) *model.LoggingResponse {
+	response := &model.LoggingResponse{
+		Level:      level.String(),
+		Components: make(map[string]string, len(components)),
+	}
+	for name, componentLevel := range components {
+		response.Components[name] = componentLevel.String()
+	}
+	return response
+}
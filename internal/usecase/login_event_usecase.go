@@ -0,0 +1,102 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"go-rest-scaffold/internal/entity"
+	"go-rest-scaffold/internal/model"
+	"go-rest-scaffold/internal/model/converter"
+	"go-rest-scaffold/internal/repository"
+	"go-rest-scaffold/internal/tracing"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+// LoginEventUseCase keeps the login history of users, so they can spot an access that
+// was not theirs, or someone guessing their password.
+type LoginEventUseCase struct {
+	TxManager            TxManager
+	Log                  *logrus.Logger
+	Validate             *validator.Validate
+	LoginEventRepository *repository.LoginEventRepository
+}
+
+func NewLoginEventUseCase(txManager TxManager, logger *logrus.Logger, validate *validator.Validate,
+	loginEventRepository *repository.LoginEventRepository) *LoginEventUseCase {
+	return &LoginEventUseCase{
+		TxManager:            txManager,
+		Log:                  logger,
+		Validate:             validate,
+		LoginEventRepository: loginEventRepository,
+	}
+}
+
+// Record is the hook logins call once they know the user and the outcome, loginErr
+// being nil for a success. ctx must be in the region of the user. The event is saved
+// on its own, since the transaction of a failed login is rolled back, and failing to
+// save it only loses the event. A nil use case is valid and records nothing.
+func (c *LoginEventUseCase) Record(ctx context.Context, userId string, method string, ip string, device string, userAgent string, loginErr error) {
+	ctx, span := tracing.Start(ctx, "LoginEventUseCase.Record")
+	defer span.End()
+
+	if c == nil || model.IsDryRun(ctx) {
+		return
+	}
+
+	if device == "" {
+		device = describeDevice(userAgent)
+	}
+	event := &entity.LoginEvent{
+		ID:        uuid.NewString(),
+		UserId:    userId,
+		Method:    method,
+		Success:   loginErr == nil,
+		IP:        ip,
+		Device:    device,
+		UserAgent: truncate(userAgent, 500),
+	}
+	if loginErr != nil {
+		event.Reason = fiber.ErrInternalServerError.Message
+		if fiberErr := new(fiber.Error); errors.As(loginErr, &fiberErr) {
+			event.Reason = truncate(fiberErr.Message, 100)
+		}
+	}
+
+	if err := c.LoginEventRepository.Create(c.TxManager.DB(ctx), event); err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to create login event")
+	}
+}
+
+func (c *LoginEventUseCase) List(ctx context.Context, request *model.ListLoginEventRequest) ([]model.LoginEventResponse, int64, error) {
+	ctx, span := tracing.Start(ctx, "LoginEventUseCase.List")
+	defer span.End()
+
+	tx := c.TxManager.Begin(ctx)
+	defer tx.Rollback()
+
+	if err := c.Validate.Struct(request); err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to validate request body")
+		return nil, 0, fiber.ErrBadRequest
+	}
+
+	events, total, err := c.LoginEventRepository.FindAllByUserId(tx, request)
+	if err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to find login events")
+		return nil, 0, fiber.ErrInternalServerError
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to commit transaction")
+		return nil, 0, fiber.ErrInternalServerError
+	}
+
+	responses := make([]model.LoginEventResponse, len(events))
+	for i, event := range events {
+		responses[i] = *converter.LoginEventToResponse(&event)
+	}
+
+	return responses, total, nil
+}
@@ -0,0 +1,274 @@
+package usecase
+
+import (
+	"context"
+	"go-rest-scaffold/internal/entity"
+	"go-rest-scaffold/internal/event"
+	"go-rest-scaffold/internal/idgen"
+	"go-rest-scaffold/internal/model"
+	"go-rest-scaffold/internal/model/converter"
+	"go-rest-scaffold/internal/repository"
+	"go-rest-scaffold/internal/tracing"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"github.com/sirupsen/logrus"
+)
+
+// NoteUseCase keeps the notes of contacts, free text such as the history of calls and
+// meetings with a contact.
+type NoteUseCase struct {
+	TxManager         TxManager
+	Log               *logrus.Logger
+	Validate          *validator.Validate
+	NoteRepository    *repository.NoteRepository
+	ContactRepository *repository.ContactRepository
+	EventBus          *event.Bus
+	AuditLog          *AuditLogUseCase
+	IDs               *idgen.Generators
+}
+
+func NewNoteUseCase(txManager TxManager, logger *logrus.Logger, validate *validator.Validate,
+	contactRepository *repository.ContactRepository, noteRepository *repository.NoteRepository,
+	eventBus *event.Bus, auditLog *AuditLogUseCase, ids *idgen.Generators) *NoteUseCase {
+	return &NoteUseCase{
+		TxManager:         txManager,
+		Log:               logger,
+		Validate:          validate,
+		ContactRepository: contactRepository,
+		NoteRepository:    noteRepository,
+		EventBus:          eventBus,
+		AuditLog:          auditLog,
+		IDs:               ids,
+	}
+}
+
+func (c *NoteUseCase) Create(ctx context.Context, request *model.CreateNoteRequest) (*model.NoteResponse, error) {
+	ctx, span := tracing.Start(ctx, "NoteUseCase.Create")
+	defer span.End()
+
+	tx := c.TxManager.Begin(ctx)
+	defer tx.Rollback()
+
+	if err := c.Validate.Struct(request); err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to validate request body")
+		return nil, err
+	}
+
+	contact := new(entity.Contact)
+	if err := c.ContactRepository.FindByIdAndUserId(tx, contact, request.ContactId, request.UserId); err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to find contact")
+		return nil, fiber.ErrNotFound
+	}
+
+	id, err := c.IDs.NewID(ctx, idgen.Note)
+	if err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to generate note id")
+		return nil, fiber.ErrInternalServerError
+	}
+
+	note := &entity.Note{
+		ID:        id,
+		ContactId: contact.ID,
+		Body:      request.Body,
+	}
+
+	if err := c.NoteRepository.Create(tx, note); err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to create note")
+		return nil, fiber.ErrInternalServerError
+	}
+
+	if err := c.AuditLog.Record(ctx, tx, model.AuditCreate, model.AuditNote, note.ID, nil, converter.NoteToResponse(note)); err != nil {
+		return nil, err
+	}
+
+	response := converter.NoteToResponse(note)
+	event, err := c.EventBus.Record(ctx, tx, model.EventNoteCreated, "contacts/"+contact.ID+"/notes/"+note.ID, contact.UserId, response)
+	if err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to record event")
+		return nil, fiber.ErrInternalServerError
+	}
+
+	if err := commit(ctx, tx); err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to commit transaction")
+		return nil, fiber.ErrInternalServerError
+	}
+
+	c.EventBus.Deliver(ctx, event)
+
+	return response, nil
+}
+
+func (c *NoteUseCase) Update(ctx context.Context, request *model.UpdateNoteRequest) (*model.NoteResponse, error) {
+	ctx, span := tracing.Start(ctx, "NoteUseCase.Update")
+	defer span.End()
+
+	tx := c.TxManager.Begin(ctx)
+	defer tx.Rollback()
+
+	if err := c.Validate.Struct(request); err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to validate request body")
+		return nil, err
+	}
+
+	contact := new(entity.Contact)
+	if err := c.ContactRepository.FindByIdAndUserId(tx, contact, request.ContactId, request.UserId); err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to find contact")
+		return nil, fiber.ErrNotFound
+	}
+
+	note := new(entity.Note)
+	if err := c.NoteRepository.FindByIdAndContactId(tx, note, request.ID, contact.ID); err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to find note")
+		return nil, fiber.ErrNotFound
+	}
+
+	before := converter.NoteToResponse(note)
+	note.Body = request.Body
+
+	if err := c.NoteRepository.Update(tx, note); err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to update note")
+		return nil, fiber.ErrInternalServerError
+	}
+
+	if err := c.AuditLog.Record(ctx, tx, model.AuditUpdate, model.AuditNote, note.ID, before, converter.NoteToResponse(note)); err != nil {
+		return nil, err
+	}
+
+	response := converter.NoteToResponse(note)
+	event, err := c.EventBus.Record(ctx, tx, model.EventNoteUpdated, "contacts/"+contact.ID+"/notes/"+note.ID, contact.UserId, response)
+	if err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to record event")
+		return nil, fiber.ErrInternalServerError
+	}
+
+	if err := commit(ctx, tx); err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to commit transaction")
+		return nil, fiber.ErrInternalServerError
+	}
+
+	c.EventBus.Deliver(ctx, event)
+
+	return response, nil
+}
+
+func (c *NoteUseCase) Get(ctx context.Context, request *model.GetNoteRequest) (*model.NoteResponse, error) {
+	ctx, span := tracing.Start(ctx, "NoteUseCase.Get")
+	defer span.End()
+
+	tx := c.TxManager.Begin(ctx, readOnly)
+	defer tx.Rollback()
+
+	if err := c.Validate.Struct(request); err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to validate request body")
+		return nil, fiber.ErrBadRequest
+	}
+
+	contact := new(entity.Contact)
+	if err := c.ContactRepository.FindByIdAndUserId(tx, contact, request.ContactId, request.UserId); err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to find contact")
+		return nil, fiber.ErrNotFound
+	}
+
+	note := new(entity.Note)
+	if err := c.NoteRepository.FindByIdAndContactId(tx, note, request.ID, contact.ID); err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to find note")
+		return nil, fiber.ErrNotFound
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to commit transaction")
+		return nil, fiber.ErrInternalServerError
+	}
+
+	return converter.NoteToResponse(note), nil
+}
+
+func (c *NoteUseCase) Delete(ctx context.Context, request *model.DeleteNoteRequest) error {
+	ctx, span := tracing.Start(ctx, "NoteUseCase.Delete")
+	defer span.End()
+
+	tx := c.TxManager.Begin(ctx)
+	defer tx.Rollback()
+
+	if err := c.Validate.Struct(request); err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to validate request body")
+		return fiber.ErrBadRequest
+	}
+
+	contact := new(entity.Contact)
+	if err := c.ContactRepository.FindByIdAndUserId(tx, contact, request.ContactId, request.UserId); err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to find contact")
+		return fiber.ErrNotFound
+	}
+
+	note := new(entity.Note)
+	if err := c.NoteRepository.FindByIdAndContactId(tx, note, request.ID, contact.ID); err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to find note")
+		return fiber.ErrNotFound
+	}
+
+	if err := c.NoteRepository.Delete(tx, note); err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to delete note")
+		return fiber.ErrInternalServerError
+	}
+
+	if err := c.AuditLog.Record(ctx, tx, model.AuditDelete, model.AuditNote, note.ID, converter.NoteToResponse(note), nil); err != nil {
+		return err
+	}
+
+	event, err := c.EventBus.Record(ctx, tx, model.EventNoteDeleted, "contacts/"+contact.ID+"/notes/"+note.ID, contact.UserId,
+		converter.NoteToResponse(note))
+	if err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to record event")
+		return fiber.ErrInternalServerError
+	}
+
+	if err := commit(ctx, tx); err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to commit transaction")
+		return fiber.ErrInternalServerError
+	}
+
+	c.EventBus.Deliver(ctx, event)
+
+	return nil
+}
+
+// Search returns a page of the notes of a contact, newest first, and how many notes
+// the contact has.
+func (c *NoteUseCase) Search(ctx context.Context, request *model.SearchNoteRequest) ([]model.NoteResponse, int64, error) {
+	ctx, span := tracing.Start(ctx, "NoteUseCase.Search")
+	defer span.End()
+
+	tx := c.TxManager.Begin(ctx, readOnly)
+	defer tx.Rollback()
+
+	if err := c.Validate.Struct(request); err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to validate request body")
+		return nil, 0, err
+	}
+
+	contact := new(entity.Contact)
+	if err := c.ContactRepository.FindByIdAndUserId(tx, contact, request.ContactId, request.UserId); err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to find contact")
+		return nil, 0, fiber.ErrNotFound
+	}
+
+	notes, total, err := c.NoteRepository.Search(tx, request)
+	if err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to search notes")
+		return nil, 0, fiber.ErrInternalServerError
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to commit transaction")
+		return nil, 0, fiber.ErrInternalServerError
+	}
+
+	responses := make([]model.NoteResponse, len(notes))
+	for i, note := range notes {
+		responses[i] = *converter.NoteToResponse(&note)
+	}
+
+	return responses, total, nil
+}
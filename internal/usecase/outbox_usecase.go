@@ -0,0 +1,135 @@
+package usecase
+
+import (
+	"context"
+	"go-rest-scaffold/internal/model"
+	"go-rest-scaffold/internal/model/converter"
+	"go-rest-scaffold/internal/repository"
+	"go-rest-scaffold/internal/tracing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Broker receives the events relayed from the outbox. An event is only marked
+// published once Publish returned nil, so a broker may see it again after a crash
+// and consumers deduplicate by the event ID.
+type Broker interface {
+	Publish(ctx context.Context, event *model.CloudEvent) error
+}
+
+// OutboxOptions controls how often the relay polls the outbox and how long published
+// events are kept.
+type OutboxOptions struct {
+	BatchSize    int
+	PollInterval time.Duration
+	Retention    time.Duration
+}
+
+type OutboxUseCase struct {
+	TxManager        TxManager
+	Log              *logrus.Logger
+	OutboxRepository *repository.OutboxRepository
+	Broker           Broker
+	Options          OutboxOptions
+}
+
+func NewOutboxUseCase(txManager TxManager, logger *logrus.Logger, outboxRepository *repository.OutboxRepository, broker Broker,
+	options OutboxOptions) *OutboxUseCase {
+	return &OutboxUseCase{
+		TxManager:        txManager,
+		Log:              logger,
+		OutboxRepository: outboxRepository,
+		Broker:           broker,
+		Options:          options,
+	}
+}
+
+// RunRelay publishes, every poll interval until ctx is done, the events recorded in the
+// outbox, and prunes those published longer ago than the retention. It is meant to run
+// on the leader only, which keeps the events of the outbox in order.
+func (c *OutboxUseCase) RunRelay(ctx context.Context) {
+	ctx, span := tracing.Start(ctx, "OutboxUseCase.RunRelay")
+	defer span.End()
+
+	ticker := time.NewTicker(c.Options.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		for {
+			relayed, err := c.Relay(ctx)
+			if err != nil {
+				c.Log.WithContext(ctx).WithError(err).Error("failed to relay outbox")
+			}
+			// a full batch means more events are waiting
+			if err != nil || relayed < c.Options.BatchSize || ctx.Err() != nil {
+				break
+			}
+		}
+
+		if pruned, err := c.PruneExpired(ctx); err != nil {
+			c.Log.WithContext(ctx).WithError(err).Error("failed to prune outbox")
+		} else if pruned > 0 {
+			c.Log.WithContext(ctx).Infof("Pruned %d events from the outbox", pruned)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Relay publishes the oldest batch of unpublished events, in the order they were
+// recorded, and returns how many were published. It stops at the first event the
+// broker refuses, which is retried first on the next relay.
+func (c *OutboxUseCase) Relay(ctx context.Context) (int, error) {
+	ctx, span := tracing.Start(ctx, "OutboxUseCase.Relay")
+	defer span.End()
+
+	// a replica lagging behind would hand out events already published
+	db := c.TxManager.DB(model.WithPrimary(ctx))
+	outboxEvents, err := c.OutboxRepository.FindUnpublished(db, c.Options.BatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	for i := range outboxEvents {
+		outboxEvent := &outboxEvents[i]
+		event, err := converter.OutboxEventToCloudEvent(outboxEvent)
+		if err == nil {
+			err = c.Broker.Publish(ctx, event)
+		}
+		if err != nil {
+			c.Log.WithContext(ctx).WithError(err).Warnf("Failed to publish event %s", outboxEvent.EventId)
+			if err := c.OutboxRepository.RecordFailure(db, outboxEvent.ID, err.Error()); err != nil {
+				return i, err
+			}
+			return i, nil
+		}
+
+		// a crash before this update publishes the event again, never loses it
+		if err := c.OutboxRepository.MarkPublished(db, outboxEvent.ID, time.Now().UnixMilli()); err != nil {
+			return i, err
+		}
+	}
+
+	return len(outboxEvents), nil
+}
+
+// PruneExpired deletes the events published longer ago than the retention.
+func (c *OutboxUseCase) PruneExpired(ctx context.Context) (int64, error) {
+	ctx, span := tracing.Start(ctx, "OutboxUseCase.PruneExpired")
+	defer span.End()
+
+	tx := c.TxManager.Begin(ctx)
+	defer tx.Rollback()
+
+	pruned, err := c.OutboxRepository.DeletePublishedBefore(tx, time.Now().Add(-c.Options.Retention))
+	if err != nil {
+		return 0, err
+	}
+
+	return pruned, tx.Commit().Error
+}
@@ -0,0 +1,446 @@
+package usecase
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"go-rest-scaffold/internal/entity"
+	"go-rest-scaffold/internal/metrics"
+	"go-rest-scaffold/internal/model"
+	"go-rest-scaffold/internal/model/converter"
+	"go-rest-scaffold/internal/repository"
+	"go-rest-scaffold/internal/tracing"
+	"go-rest-scaffold/internal/webauthn"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// PasskeyOptions configures the relying party passkeys are registered for.
+type PasskeyOptions struct {
+	RelyingParty webauthn.RelyingParty
+	// ChallengeTTL is how long a registration or login can take once begun.
+	ChallengeTTL time.Duration
+}
+
+var errPasskeyLogin = fiber.NewError(fiber.StatusUnauthorized, "invalid passkey")
+
+// PasskeyUseCase registers the passkeys of users and logs them in with one. Every
+// ceremony begins by handing out a challenge, stored until the authenticator's answer
+// to it uses it up.
+type PasskeyUseCase struct {
+	TxManager                   TxManager
+	Log                         *logrus.Logger
+	Validate                    *validator.Validate
+	PasskeyRepository           *repository.PasskeyRepository
+	WebAuthnChallengeRepository *repository.WebAuthnChallengeRepository
+	UserRepository              *repository.UserRepository
+	UserUseCase                 *UserUseCase
+	AuditLog                    *AuditLogUseCase
+	Options                     PasskeyOptions
+}
+
+func NewPasskeyUseCase(txManager TxManager, logger *logrus.Logger, validate *validator.Validate, passkeyRepository *repository.PasskeyRepository,
+	webAuthnChallengeRepository *repository.WebAuthnChallengeRepository, userRepository *repository.UserRepository, userUseCase *UserUseCase,
+	auditLog *AuditLogUseCase, options PasskeyOptions) *PasskeyUseCase {
+	return &PasskeyUseCase{
+		TxManager:                   txManager,
+		Log:                         logger,
+		Validate:                    validate,
+		PasskeyRepository:           passkeyRepository,
+		WebAuthnChallengeRepository: webAuthnChallengeRepository,
+		UserRepository:              userRepository,
+		UserUseCase:                 userUseCase,
+		AuditLog:                    auditLog,
+		Options:                     options,
+	}
+}
+
+// BeginRegistration returns the options to create a passkey for the user with. The
+// passkeys the user already has are excluded, so an authenticator is not registered twice.
+func (c *PasskeyUseCase) BeginRegistration(ctx context.Context, request *model.BeginPasskeyRegistrationRequest) (*model.PasskeyCreationOptions, error) {
+	ctx, span := tracing.Start(ctx, "PasskeyUseCase.BeginRegistration")
+	defer span.End()
+
+	tx := c.TxManager.Begin(ctx)
+	defer tx.Rollback()
+
+	if err := c.Validate.Struct(request); err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to validate request body")
+		return nil, fiber.ErrBadRequest
+	}
+
+	user := new(entity.User)
+	if err := c.UserRepository.FindById(tx, user, request.UserId); err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to find user")
+		return nil, fiber.ErrNotFound
+	}
+
+	passkeys, err := c.PasskeyRepository.FindAllByUserId(tx, user.ID)
+	if err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to find passkeys")
+		return nil, fiber.ErrInternalServerError
+	}
+
+	challenge, err := c.newChallenge(tx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := commit(ctx, tx); err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to commit transaction")
+		return nil, fiber.ErrInternalServerError
+	}
+
+	options := &model.PasskeyCreationOptions{
+		Challenge: challenge,
+		RP:        model.PasskeyRelyingParty{ID: c.Options.RelyingParty.ID, Name: c.Options.RelyingParty.Name},
+		User: model.PasskeyUser{
+			ID:          base64.RawURLEncoding.EncodeToString([]byte(user.ID)),
+			Name:        user.ID,
+			DisplayName: user.Name,
+		},
+		Timeout:            c.Options.ChallengeTTL.Milliseconds(),
+		ExcludeCredentials: make([]model.PasskeyCredentialDescriptor, len(passkeys)),
+		// discoverable, so the login does not have to name the user first
+		AuthenticatorSelection: model.PasskeyAuthenticatorSelection{ResidentKey: "required", UserVerification: "required"},
+		Attestation:            "none",
+	}
+	for _, algorithm := range webauthn.Algorithms {
+		options.PubKeyCredParams = append(options.PubKeyCredParams, model.PasskeyCredentialParameter{Type: "public-key", Algorithm: algorithm})
+	}
+	for i, passkey := range passkeys {
+		options.ExcludeCredentials[i] = model.PasskeyCredentialDescriptor{Type: "public-key", ID: passkey.CredentialId}
+	}
+	return options, nil
+}
+
+// Register saves the passkey an authenticator created in answer to BeginRegistration.
+func (c *PasskeyUseCase) Register(ctx context.Context, request *model.RegisterPasskeyRequest) (*model.PasskeyResponse, error) {
+	ctx, span := tracing.Start(ctx, "PasskeyUseCase.Register")
+	defer span.End()
+
+	tx := c.TxManager.Begin(ctx)
+	defer tx.Rollback()
+
+	if err := c.Validate.Struct(request); err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to validate request body")
+		return nil, err
+	}
+
+	clientDataJSON, err1 := base64.RawURLEncoding.DecodeString(request.Credential.Response.ClientDataJSON)
+	attestationObject, err2 := base64.RawURLEncoding.DecodeString(request.Credential.Response.AttestationObject)
+	if err := errors.Join(err1, err2); err != nil {
+		c.Log.WithContext(ctx).WithError(err).Warn("failed to decode passkey registration")
+		return nil, fiber.ErrBadRequest
+	}
+
+	challenge, err := c.useChallenge(tx, clientDataJSON, request.UserId)
+	if err != nil {
+		return nil, err
+	}
+
+	credential, err := c.Options.RelyingParty.VerifyRegistration(challenge, clientDataJSON, attestationObject)
+	if err != nil {
+		c.Log.WithContext(ctx).WithError(err).Warn("failed to verify passkey registration")
+		return nil, fiber.NewError(fiber.StatusBadRequest, "invalid passkey")
+	}
+
+	credentialId := base64.RawURLEncoding.EncodeToString(credential.ID)
+	total, err := c.PasskeyRepository.CountByCredentialId(tx, credentialId)
+	if err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to count passkeys")
+		return nil, fiber.ErrInternalServerError
+	}
+	if total > 0 {
+		return nil, fiber.NewError(fiber.StatusConflict, "passkey is already registered")
+	}
+
+	passkey := &entity.Passkey{
+		ID:           uuid.NewString(),
+		UserId:       request.UserId,
+		Name:         request.Name,
+		CredentialId: credentialId,
+		PublicKey:    credential.PublicKey,
+		SignCount:    int64(credential.SignCount),
+	}
+	if err := c.PasskeyRepository.Create(tx, passkey); err != nil {
+		// registered concurrently since it was counted
+		if errors.Is(err, repository.ErrConflict) {
+			return nil, fiber.NewError(fiber.StatusConflict, "passkey is already registered")
+		}
+		c.Log.WithContext(ctx).WithError(err).Error("failed to create passkey")
+		return nil, fiber.ErrInternalServerError
+	}
+
+	if err := c.AuditLog.Record(ctx, tx, model.AuditCreate, model.AuditPasskey, passkey.ID, nil, converter.PasskeyToResponse(passkey)); err != nil {
+		return nil, err
+	}
+
+	if err := commit(ctx, tx); err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to commit transaction")
+		return nil, fiber.ErrInternalServerError
+	}
+
+	return converter.PasskeyToResponse(passkey), nil
+}
+
+func (c *PasskeyUseCase) List(ctx context.Context, request *model.ListPasskeyRequest) ([]model.PasskeyResponse, error) {
+	ctx, span := tracing.Start(ctx, "PasskeyUseCase.List")
+	defer span.End()
+
+	tx := c.TxManager.Begin(ctx)
+	defer tx.Rollback()
+
+	if err := c.Validate.Struct(request); err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to validate request body")
+		return nil, fiber.ErrBadRequest
+	}
+
+	passkeys, err := c.PasskeyRepository.FindAllByUserId(tx, request.UserId)
+	if err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to find passkeys")
+		return nil, fiber.ErrInternalServerError
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to commit transaction")
+		return nil, fiber.ErrInternalServerError
+	}
+
+	responses := make([]model.PasskeyResponse, len(passkeys))
+	for i, passkey := range passkeys {
+		responses[i] = *converter.PasskeyToResponse(&passkey)
+	}
+	return responses, nil
+}
+
+func (c *PasskeyUseCase) Delete(ctx context.Context, request *model.DeletePasskeyRequest) error {
+	ctx, span := tracing.Start(ctx, "PasskeyUseCase.Delete")
+	defer span.End()
+
+	tx := c.TxManager.Begin(ctx)
+	defer tx.Rollback()
+
+	if err := c.Validate.Struct(request); err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to validate request body")
+		return fiber.ErrBadRequest
+	}
+
+	passkey := new(entity.Passkey)
+	if err := c.PasskeyRepository.FindByIdAndUserId(tx, passkey, request.ID, request.UserId); err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to find passkey")
+		return fiber.ErrNotFound
+	}
+
+	if err := c.PasskeyRepository.Delete(tx, passkey); err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to delete passkey")
+		return fiber.ErrInternalServerError
+	}
+
+	if err := c.AuditLog.Record(ctx, tx, model.AuditDelete, model.AuditPasskey, passkey.ID, converter.PasskeyToResponse(passkey), nil); err != nil {
+		return err
+	}
+
+	if err := commit(ctx, tx); err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to commit transaction")
+		return fiber.ErrInternalServerError
+	}
+
+	return nil
+}
+
+// BeginLogin returns the options to log in with a passkey. Any passkey of the site
+// may answer, the authenticator tells whose it is. Login challenges live in the home
+// region, as the user is not known yet.
+func (c *PasskeyUseCase) BeginLogin(ctx context.Context) (*model.PasskeyRequestOptions, error) {
+	ctx, span := tracing.Start(ctx, "PasskeyUseCase.BeginLogin")
+	defer span.End()
+
+	ctx = model.WithHomeRegion(ctx)
+	tx := c.TxManager.Begin(ctx)
+	defer tx.Rollback()
+
+	// anyone can begin a login, so unanswered challenges are cleared as new ones come
+	if err := c.WebAuthnChallengeRepository.DeleteExpired(tx, time.Now().UnixMilli()); err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to delete expired challenges")
+		return nil, fiber.ErrInternalServerError
+	}
+
+	challenge, err := c.newChallenge(tx, "")
+	if err != nil {
+		return nil, err
+	}
+
+	if err := commit(ctx, tx); err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to commit transaction")
+		return nil, fiber.ErrInternalServerError
+	}
+
+	return &model.PasskeyRequestOptions{
+		Challenge:        challenge,
+		RPID:             c.Options.RelyingParty.ID,
+		Timeout:          c.Options.ChallengeTTL.Milliseconds(),
+		AllowCredentials: []model.PasskeyCredentialDescriptor{},
+		UserVerification: "required",
+	}, nil
+}
+
+// Login logs the user in whose passkey signed the challenge of BeginLogin, with a new
+// session like a password login.
+func (c *PasskeyUseCase) Login(ctx context.Context, request *model.PasskeyLoginRequest) (_ *model.UserResponse, err error) {
+	ctx, span := tracing.Start(ctx, "PasskeyUseCase.Login")
+	defer span.End()
+
+	defer func() { metrics.Logins.WithLabelValues(metrics.Outcome(err)).Inc() }()
+
+	if err := c.Validate.Struct(request); err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to validate request body")
+		return nil, fiber.ErrBadRequest
+	}
+
+	response := request.Credential.Response
+	clientDataJSON, err1 := base64.RawURLEncoding.DecodeString(response.ClientDataJSON)
+	authenticatorData, err2 := base64.RawURLEncoding.DecodeString(response.AuthenticatorData)
+	signature, err3 := base64.RawURLEncoding.DecodeString(response.Signature)
+	userId, err4 := base64.RawURLEncoding.DecodeString(response.UserHandle)
+	if err := errors.Join(err1, err2, err3, err4); err != nil {
+		c.Log.WithContext(ctx).WithError(err).Warn("failed to decode passkey login")
+		return nil, fiber.ErrBadRequest
+	}
+
+	// the challenge is used up whether the answer turns out valid or not
+	homeCtx := model.WithHomeRegion(ctx)
+	challengeTx := c.TxManager.Begin(homeCtx)
+	defer challengeTx.Rollback()
+
+	challenge, err := c.useChallenge(challengeTx, clientDataJSON, "")
+	if err != nil {
+		return nil, err
+	}
+
+	if err := commit(homeCtx, challengeTx); err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to commit transaction")
+		return nil, fiber.ErrInternalServerError
+	}
+
+	region, exists, err := c.UserUseCase.findRegion(ctx, string(userId))
+	if err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to find user region")
+		return nil, fiber.ErrInternalServerError
+	}
+	if !exists {
+		c.Log.WithContext(ctx).Warn("passkey login of unknown user")
+		return nil, errPasskeyLogin
+	}
+
+	ctx = model.WithRegion(ctx, region)
+	tx := c.TxManager.Begin(ctx)
+	defer tx.Rollback()
+
+	defer func() {
+		c.UserUseCase.LoginEvents.Record(ctx, string(userId), model.LoginMethodPasskey, request.IP, request.Device, request.UserAgent, err)
+	}()
+
+	passkey := new(entity.Passkey)
+	if err := c.PasskeyRepository.FindByCredentialIdForUpdate(tx, passkey, request.Credential.ID, string(userId)); err != nil {
+		c.Log.WithContext(ctx).WithError(err).Warn("failed to find passkey")
+		return nil, errPasskeyLogin
+	}
+
+	credential := &webauthn.Credential{PublicKey: passkey.PublicKey, SignCount: uint32(passkey.SignCount)}
+	signCount, err := c.Options.RelyingParty.VerifyAssertion(challenge, credential, clientDataJSON, authenticatorData, signature)
+	if err != nil {
+		c.Log.WithContext(ctx).WithError(err).Warnf("failed to verify passkey %s", passkey.ID)
+		return nil, errPasskeyLogin
+	}
+
+	user := new(entity.User)
+	if err := c.UserRepository.FindById(tx, user, passkey.UserId); err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to find user of passkey")
+		return nil, errPasskeyLogin
+	}
+
+	if user.DeletedAt != nil {
+		c.Log.WithContext(ctx).Warnf("Deleted user %s tried to log in", user.ID)
+		return nil, errPasskeyLogin
+	}
+
+	if c.UserUseCase.Options.VerificationRequired && user.VerifiedAt == nil {
+		c.Log.WithContext(ctx).Warnf("User %s logged in before verifying their email", user.ID)
+		return nil, fiber.NewError(fiber.StatusForbidden, "email address is not verified")
+	}
+
+	now := time.Now().UnixMilli()
+	passkey.SignCount = int64(signCount)
+	passkey.LastUsedAt = &now
+	if err := c.PasskeyRepository.Update(tx, passkey); err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to save passkey")
+		return nil, fiber.ErrInternalServerError
+	}
+
+	session, err := c.UserUseCase.startSession(tx, user, request.Device, request.IP, request.UserAgent, request.Scopes)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to commit transaction")
+		return nil, fiber.ErrInternalServerError
+	}
+
+	return c.UserUseCase.tokenResponse(user, session)
+}
+
+// newChallenge stores a random challenge for userId ("" for a login) and returns it
+// base64url encoded.
+func (c *PasskeyUseCase) newChallenge(tx *gorm.DB, userId string) (string, error) {
+	random := make([]byte, 32)
+	if _, err := rand.Read(random); err != nil {
+		c.Log.WithContext(tx.Statement.Context).WithError(err).Error("failed to generate challenge")
+		return "", fiber.ErrInternalServerError
+	}
+
+	challenge := &entity.WebAuthnChallenge{
+		ID:        base64.RawURLEncoding.EncodeToString(random),
+		UserId:    userId,
+		ExpiresAt: time.Now().Add(c.Options.ChallengeTTL).UnixMilli(),
+	}
+	if err := c.WebAuthnChallengeRepository.Create(tx, challenge); err != nil {
+		c.Log.WithContext(tx.Statement.Context).WithError(err).Error("failed to create challenge")
+		return "", fiber.ErrInternalServerError
+	}
+	return challenge.ID, nil
+}
+
+// useChallenge finds the challenge of userId ("" for a login) the client data answers
+// and deletes it, so it cannot be answered again.
+func (c *PasskeyUseCase) useChallenge(tx *gorm.DB, clientDataJSON []byte, userId string) ([]byte, error) {
+	id, ok := webauthn.Challenge(clientDataJSON)
+	if !ok {
+		c.Log.WithContext(tx.Statement.Context).Warn("passkey response without a challenge")
+		return nil, fiber.ErrBadRequest
+	}
+
+	challenge := new(entity.WebAuthnChallenge)
+	if err := c.WebAuthnChallengeRepository.FindUnexpiredByIdForUpdate(tx, challenge, id, userId, time.Now().UnixMilli()); err != nil {
+		c.Log.WithContext(tx.Statement.Context).WithError(err).Warn("failed to find challenge")
+		return nil, fiber.NewError(fiber.StatusBadRequest, "invalid or expired challenge")
+	}
+
+	if err := c.WebAuthnChallengeRepository.Delete(tx, challenge); err != nil {
+		c.Log.WithContext(tx.Statement.Context).WithError(err).Error("failed to delete challenge")
+		return nil, fiber.ErrInternalServerError
+	}
+
+	decoded, err := base64.RawURLEncoding.DecodeString(challenge.ID)
+	if err != nil {
+		return nil, fiber.ErrInternalServerError
+	}
+	return decoded, nil
+}
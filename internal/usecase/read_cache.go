@@ -0,0 +1,174 @@
+package usecase
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"go-rest-scaffold/internal/metrics"
+	"go-rest-scaffold/internal/model"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+// Cache is where ReadCache keeps serialized responses. Lookups that fail are misses
+// and writes that fail are dropped, so the cache never fails a read.
+type Cache interface {
+	Get(ctx context.Context, key string) ([]byte, bool)
+	// Set stores value under key for ttl, or until it is replaced when ttl is zero.
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration)
+}
+
+// CacheOptions sets how long cached responses live, per entity. Zero turns caching
+// off for the entity.
+type CacheOptions struct {
+	UserTTL    time.Duration
+	ContactTTL time.Duration
+}
+
+// ReadCache caches the responses of the hot reads, UserUseCase.Current and
+// ContactUseCase.Get and Search, and forgets them when their data changes. Entries
+// live in a namespace per user and entity whose key embeds its current version;
+// a change moves the namespace to a new version, which orphans every entry at once
+// and until their TTL. Readers look the version up before loading, so a read racing
+// a change can only store what it loaded under the version it replaced. A nil
+// ReadCache caches nothing.
+type ReadCache struct {
+	Cache   Cache
+	Log     *logrus.Logger
+	Options CacheOptions
+}
+
+func NewReadCache(cache Cache, log *logrus.Logger, options CacheOptions) *ReadCache {
+	return &ReadCache{
+		Cache:   cache,
+		Log:     log,
+		Options: options,
+	}
+}
+
+func userNamespace(userId string) string {
+	return "user:" + userId
+}
+
+func contactsNamespace(userId string) string {
+	return "contacts:" + userId
+}
+
+// cacheRead returns the response cached under key in namespace, or loads it and caches
+// it for ttl. name labels the lookups in the cache metrics.
+func cacheRead[T any](ctx context.Context, c *ReadCache, name string, namespace string, key string, ttl time.Duration,
+	load func() (T, error)) (T, error) {
+	if c == nil || ttl <= 0 {
+		return load()
+	}
+
+	version, found := c.Cache.Get(ctx, namespace+":version")
+	if !found {
+		version = []byte("0")
+	}
+	key = namespace + ":" + string(version) + ":" + key
+
+	if value, found := c.Cache.Get(ctx, key); found {
+		var cached T
+		err := json.Unmarshal(value, &cached)
+		if err == nil {
+			metrics.CacheLookups.WithLabelValues(name, "hit").Inc()
+			return cached, nil
+		}
+		c.Log.WithContext(ctx).WithError(err).Warnf("Failed to decode cache key %s", key)
+	}
+	metrics.CacheLookups.WithLabelValues(name, "miss").Inc()
+
+	result, err := load()
+	if err != nil {
+		return result, err
+	}
+
+	if value, err := json.Marshal(result); err != nil {
+		c.Log.WithContext(ctx).WithError(err).Warnf("Failed to encode cache key %s", key)
+	} else {
+		c.Cache.Set(ctx, key, value, ttl)
+	}
+	return result, nil
+}
+
+// cacheKey hashes the parts of a request that select its response.
+func cacheKey(parts ...any) string {
+	value, _ := json.Marshal(parts)
+	sum := sha256.Sum256(value)
+	return hex.EncodeToString(sum[:16])
+}
+
+func (c *ReadCache) userTTL() time.Duration {
+	if c == nil {
+		return 0
+	}
+	return c.Options.UserTTL
+}
+
+func (c *ReadCache) contactTTL() time.Duration {
+	if c == nil {
+		return 0
+	}
+	return c.Options.ContactTTL
+}
+
+// forget moves the namespaces to a new version.
+func (c *ReadCache) forget(ctx context.Context, namespaces ...string) {
+	if c == nil {
+		return
+	}
+
+	for _, namespace := range namespaces {
+		c.Cache.Set(ctx, namespace+":version", []byte(uuid.NewString()), 0)
+	}
+}
+
+// ForgetUser drops the cached responses describing the user.
+func (c *ReadCache) ForgetUser(ctx context.Context, userId string) {
+	c.forget(ctx, userNamespace(userId))
+}
+
+// ForgetContacts drops the cached contacts and contact listings of the user.
+func (c *ReadCache) ForgetContacts(ctx context.Context, userId string) {
+	c.forget(ctx, contactsNamespace(userId))
+}
+
+// EventTypes lists the events of changes to cached responses.
+func (c *ReadCache) EventTypes() []string {
+	return []string{
+		model.EventUserUpdated,
+		model.EventAccountImported,
+		model.EventContactCreated,
+		model.EventContactUpdated,
+		model.EventContactDeleted,
+		model.EventContactRestored,
+		model.EventContactTagged,
+		model.EventContactUntagged,
+		model.EventAddressCreated,
+		model.EventAddressUpdated,
+		model.EventAddressDeleted,
+		model.EventAddressRestored,
+		model.EventTagUpdated,
+		model.EventTagDeleted,
+	}
+}
+
+// Handle forgets what an event changed. The bus delivers events on the publishing
+// goroutine right after the commit, so the change is visible to the next read.
+func (c *ReadCache) Handle(ctx context.Context, event *model.CloudEvent) {
+	switch {
+	case event.Type == model.EventAccountImported:
+		c.ForgetUser(ctx, event.UserId)
+		c.ForgetContacts(ctx, event.UserId)
+	case strings.HasPrefix(event.Subject, "users/"):
+		c.ForgetUser(ctx, event.UserId)
+	case strings.HasPrefix(event.Subject, "contacts/"), strings.HasPrefix(event.Subject, "tags/"):
+		// a renamed or deleted tag changes the listings filtered by it
+		c.ForgetContacts(ctx, event.UserId)
+	}
+}
@@ -0,0 +1,71 @@
+package usecase
+
+import (
+	"context"
+	"go-rest-scaffold/internal/gateway/readonly"
+	"go-rest-scaffold/internal/model"
+	"go-rest-scaffold/internal/tracing"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"github.com/sirupsen/logrus"
+)
+
+type ReadOnlyUseCase struct {
+	Log      *logrus.Logger
+	Validate *validator.Validate
+	Switch   *readonly.Switch
+}
+
+func NewReadOnlyUseCase(logger *logrus.Logger, validate *validator.Validate, readOnlySwitch *readonly.Switch) *ReadOnlyUseCase {
+	return &ReadOnlyUseCase{
+		Log:      logger,
+		Validate: validate,
+		Switch:   readOnlySwitch,
+	}
+}
+
+func (c *ReadOnlyUseCase) Get(ctx context.Context) *model.ReadOnlyResponse {
+	ctx, span := tracing.Start(ctx, "ReadOnlyUseCase.Get")
+	defer span.End()
+
+	return c.toResponse(c.Switch.State(ctx))
+}
+
+func (c *ReadOnlyUseCase) Update(ctx context.Context, request *model.UpdateReadOnlyRequest) (*model.ReadOnlyResponse, error) {
+	ctx, span := tracing.Start(ctx, "ReadOnlyUseCase.Update")
+	defer span.End()
+
+	if err := c.Validate.Struct(request); err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to validate request body")
+		return nil, err
+	}
+
+	state := readonly.State{Enabled: request.Enabled}
+	if request.Enabled {
+		state.Reason = request.Reason
+		state.Since = time.Now()
+	}
+
+	if err := c.Switch.Set(ctx, state); err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to change read-only mode")
+		return nil, fiber.ErrInternalServerError
+	}
+
+	c.Log.WithContext(ctx).Warnf("Read-only mode changed to %t by %s", request.Enabled, model.ActorFrom(ctx))
+
+	return c.toResponse(c.Switch.State(ctx)), nil
+}
+
+func (c *ReadOnlyUseCase) toResponse(state readonly.State) *model.ReadOnlyResponse {
+	response := &model.ReadOnlyResponse{
+		Enabled:    state.Enabled,
+		Reason:     state.Reason,
+		Configured: c.Switch.Configured.Enabled,
+	}
+	if state.Enabled && !state.Since.IsZero() {
+		response.Since = state.Since.UnixMilli()
+	}
+	return response
+}
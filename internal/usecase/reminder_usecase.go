@@ -0,0 +1,361 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go-rest-scaffold/internal/entity"
+	"go-rest-scaffold/internal/gateway/notify"
+	"go-rest-scaffold/internal/idgen"
+	"go-rest-scaffold/internal/model"
+	"go-rest-scaffold/internal/model/converter"
+	"go-rest-scaffold/internal/repository"
+	"go-rest-scaffold/internal/tracing"
+	"strings"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"github.com/sirupsen/logrus"
+)
+
+// ReminderOptions controls the reminder scheduler.
+type ReminderOptions struct {
+	PollInterval time.Duration
+	BatchSize    int
+}
+
+type ReminderUseCase struct {
+	TxManager          TxManager
+	Log                *logrus.Logger
+	Validate           *validator.Validate
+	ReminderRepository *repository.ReminderRepository
+	ContactRepository  *repository.ContactRepository
+	// Notifiers deliver reminders, keyed by channel.
+	Notifiers map[string]notify.Notifier
+	AuditLog  *AuditLogUseCase
+	IDs       *idgen.Generators
+	Options   ReminderOptions
+}
+
+func NewReminderUseCase(txManager TxManager, logger *logrus.Logger, validate *validator.Validate, reminderRepository *repository.ReminderRepository,
+	contactRepository *repository.ContactRepository, notifiers map[string]notify.Notifier, auditLog *AuditLogUseCase, ids *idgen.Generators,
+	options ReminderOptions) *ReminderUseCase {
+	return &ReminderUseCase{
+		TxManager:          txManager,
+		Log:                logger,
+		Validate:           validate,
+		ReminderRepository: reminderRepository,
+		ContactRepository:  contactRepository,
+		Notifiers:          notifiers,
+		AuditLog:           auditLog,
+		IDs:                ids,
+		Options:            options,
+	}
+}
+
+func (c *ReminderUseCase) Create(ctx context.Context, request *model.CreateReminderRequest) (*model.ReminderResponse, error) {
+	ctx, span := tracing.Start(ctx, "ReminderUseCase.Create")
+	defer span.End()
+
+	tx := c.TxManager.Begin(ctx)
+	defer tx.Rollback()
+
+	if err := c.Validate.Struct(request); err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to validate request body")
+		return nil, err
+	}
+
+	contact := new(entity.Contact)
+	if err := c.ContactRepository.FindByIdAndUserId(tx, contact, request.ContactId, request.UserId); err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to find contact")
+		return nil, fiber.ErrNotFound
+	}
+
+	id, err := c.IDs.NewID(ctx, idgen.Reminder)
+	if err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to generate reminder id")
+		return nil, fiber.ErrInternalServerError
+	}
+
+	reminder := &entity.Reminder{
+		ID:        id,
+		UserId:    request.UserId,
+		ContactId: contact.ID,
+		Note:      request.Note,
+		Channels:  strings.Join(request.Channels, ","),
+	}
+	if err := schedule(reminder, request.LocalTime, request.Timezone); err != nil {
+		return nil, err
+	}
+
+	if err := c.ReminderRepository.Create(tx, reminder); err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to create reminder")
+		return nil, fiber.ErrInternalServerError
+	}
+
+	if err := c.AuditLog.Record(ctx, tx, model.AuditCreate, model.AuditReminder, reminder.ID, nil, converter.ReminderToResponse(reminder)); err != nil {
+		return nil, err
+	}
+
+	if err := commit(ctx, tx); err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to commit transaction")
+		return nil, fiber.ErrInternalServerError
+	}
+
+	return converter.ReminderToResponse(reminder), nil
+}
+
+// Update changes a reminder and schedules it again, even if it was already sent.
+func (c *ReminderUseCase) Update(ctx context.Context, request *model.UpdateReminderRequest) (*model.ReminderResponse, error) {
+	ctx, span := tracing.Start(ctx, "ReminderUseCase.Update")
+	defer span.End()
+
+	tx := c.TxManager.Begin(ctx)
+	defer tx.Rollback()
+
+	if err := c.Validate.Struct(request); err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to validate request body")
+		return nil, err
+	}
+
+	reminder := new(entity.Reminder)
+	if err := c.ReminderRepository.FindByIdAndUserId(tx, reminder, request.ID, request.UserId); err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to find reminder")
+		return nil, fiber.ErrNotFound
+	}
+
+	before := converter.ReminderToResponse(reminder)
+	reminder.Note = request.Note
+	reminder.Channels = strings.Join(request.Channels, ",")
+	if err := schedule(reminder, request.LocalTime, request.Timezone); err != nil {
+		return nil, err
+	}
+
+	if err := c.ReminderRepository.Update(tx, reminder); err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to update reminder")
+		return nil, fiber.ErrInternalServerError
+	}
+
+	if err := c.AuditLog.Record(ctx, tx, model.AuditUpdate, model.AuditReminder, reminder.ID, before, converter.ReminderToResponse(reminder)); err != nil {
+		return nil, err
+	}
+
+	if err := commit(ctx, tx); err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to commit transaction")
+		return nil, fiber.ErrInternalServerError
+	}
+
+	return converter.ReminderToResponse(reminder), nil
+}
+
+func (c *ReminderUseCase) Get(ctx context.Context, request *model.GetReminderRequest) (*model.ReminderResponse, error) {
+	ctx, span := tracing.Start(ctx, "ReminderUseCase.Get")
+	defer span.End()
+
+	tx := c.TxManager.Begin(ctx)
+	defer tx.Rollback()
+
+	if err := c.Validate.Struct(request); err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to validate request body")
+		return nil, fiber.ErrBadRequest
+	}
+
+	reminder := new(entity.Reminder)
+	if err := c.ReminderRepository.FindByIdAndUserId(tx, reminder, request.ID, request.UserId); err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to find reminder")
+		return nil, fiber.ErrNotFound
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to commit transaction")
+		return nil, fiber.ErrInternalServerError
+	}
+
+	return converter.ReminderToResponse(reminder), nil
+}
+
+func (c *ReminderUseCase) Delete(ctx context.Context, request *model.DeleteReminderRequest) error {
+	ctx, span := tracing.Start(ctx, "ReminderUseCase.Delete")
+	defer span.End()
+
+	tx := c.TxManager.Begin(ctx)
+	defer tx.Rollback()
+
+	if err := c.Validate.Struct(request); err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to validate request body")
+		return fiber.ErrBadRequest
+	}
+
+	reminder := new(entity.Reminder)
+	if err := c.ReminderRepository.FindByIdAndUserId(tx, reminder, request.ID, request.UserId); err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to find reminder")
+		return fiber.ErrNotFound
+	}
+
+	if err := c.ReminderRepository.Delete(tx, reminder); err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to delete reminder")
+		return fiber.ErrInternalServerError
+	}
+
+	if err := c.AuditLog.Record(ctx, tx, model.AuditDelete, model.AuditReminder, reminder.ID, converter.ReminderToResponse(reminder), nil); err != nil {
+		return err
+	}
+
+	if err := commit(ctx, tx); err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to commit transaction")
+		return fiber.ErrInternalServerError
+	}
+
+	return nil
+}
+
+func (c *ReminderUseCase) Search(ctx context.Context, request *model.SearchReminderRequest) ([]model.ReminderResponse, int64, error) {
+	ctx, span := tracing.Start(ctx, "ReminderUseCase.Search")
+	defer span.End()
+
+	tx := c.TxManager.Begin(ctx)
+	defer tx.Rollback()
+
+	if err := c.Validate.Struct(request); err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to validate request body")
+		return nil, 0, err
+	}
+
+	reminders, total, err := c.ReminderRepository.Search(tx, request)
+	if err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to search reminders")
+		return nil, 0, fiber.ErrInternalServerError
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to commit transaction")
+		return nil, 0, fiber.ErrInternalServerError
+	}
+
+	responses := make([]model.ReminderResponse, len(reminders))
+	for i, reminder := range reminders {
+		responses[i] = *converter.ReminderToResponse(&reminder)
+	}
+
+	return responses, total, nil
+}
+
+// RunScheduler delivers due reminders every poll interval until ctx is done.
+// It is meant to run on the leader only.
+func (c *ReminderUseCase) RunScheduler(ctx context.Context) {
+	ctx, span := tracing.Start(ctx, "ReminderUseCase.RunScheduler")
+	defer span.End()
+
+	ticker := time.NewTicker(c.Options.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		for {
+			delivered, err := c.DeliverDue(ctx)
+			if err != nil {
+				c.Log.WithContext(ctx).WithError(err).Error("failed to deliver reminders")
+			}
+			if err != nil || delivered < c.Options.BatchSize {
+				break
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// DeliverDue notifies a batch of due reminders through their channels and returns
+// how many were handled. A reminder is delivered at least once: when the status
+// update fails to commit, the next run notifies it again.
+func (c *ReminderUseCase) DeliverDue(ctx context.Context) (int, error) {
+	ctx, span := tracing.Start(ctx, "ReminderUseCase.DeliverDue")
+	defer span.End()
+
+	tx := c.TxManager.Begin(ctx)
+	defer tx.Rollback()
+
+	reminders, err := c.ReminderRepository.FindDue(tx.Preload("Contact"), time.Now().UnixMilli(), c.Options.BatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	for i := range reminders {
+		reminder := &reminders[i]
+		if err := c.notify(ctx, reminder); err != nil {
+			c.Log.WithContext(ctx).WithError(err).Warnf("Failed to deliver reminder %s", reminder.ID)
+			reminder.Status = model.ReminderFailed
+			reminder.Error = err.Error()
+		} else {
+			reminder.Status = model.ReminderSent
+			reminder.Error = ""
+		}
+		reminder.SentAt = time.Now().UnixMilli()
+
+		if err := tx.Omit("Contact").Save(reminder).Error; err != nil {
+			return 0, err
+		}
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return 0, err
+	}
+
+	return len(reminders), nil
+}
+
+func (c *ReminderUseCase) notify(ctx context.Context, reminder *entity.Reminder) error {
+	notification := notify.Notification{
+		UserId:  reminder.UserId,
+		Subject: "reminders/" + reminder.ID,
+		Title:   strings.TrimSpace("Reminder: " + reminder.Contact.FirstName + " " + reminder.Contact.LastName),
+		Body:    reminder.Note,
+		Data:    converter.ReminderToResponse(reminder),
+	}
+
+	var errs []error
+	for _, channel := range strings.Split(reminder.Channels, ",") {
+		notifier, ok := c.Notifiers[channel]
+		if !ok {
+			errs = append(errs, fmt.Errorf("%s: channel is not available", channel))
+			continue
+		}
+		if err := notifier.Notify(ctx, notification); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", channel, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// schedule resolves the local date and time of a reminder in its timezone, UTC when
+// none is given, and makes the reminder pending again.
+func schedule(reminder *entity.Reminder, localTime string, timezone string) error {
+	if timezone == "" {
+		timezone = "UTC"
+	}
+
+	location, err := time.LoadLocation(timezone)
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "timezone is invalid")
+	}
+
+	remindAt, err := time.ParseInLocation(model.ReminderTimeLayout, localTime, location)
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "local_time is invalid")
+	}
+	if !remindAt.After(time.Now()) {
+		return fiber.NewError(fiber.StatusBadRequest, "local_time must be in the future")
+	}
+
+	reminder.LocalTime = localTime
+	reminder.Timezone = timezone
+	reminder.RemindAt = remindAt.UnixMilli()
+	reminder.Status = model.ReminderPending
+	reminder.Error = ""
+	reminder.SentAt = 0
+	return nil
+}
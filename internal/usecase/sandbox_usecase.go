@@ -0,0 +1,242 @@
+package usecase
+
+import (
+	"context"
+	"go-rest-scaffold/internal/entity"
+	"go-rest-scaffold/internal/idgen"
+	"go-rest-scaffold/internal/model"
+	"go-rest-scaffold/internal/repository"
+	"go-rest-scaffold/internal/tracing"
+	"slices"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+// SandboxOptions describes the demo tenant and the limits of a public sandbox.
+type SandboxOptions struct {
+	UserId        string
+	Password      string
+	Name          string
+	ResetInterval time.Duration
+	MaxContacts   int
+	MaxAddresses  int
+}
+
+// SandboxUseCase keeps a public demo deployment in a known state: it wipes all data
+// and seeds the demo tenant on a schedule, and enforces per-user write caps in between.
+type SandboxUseCase struct {
+	TxManager                      TxManager
+	Log                            *logrus.Logger
+	Options                        SandboxOptions
+	UserRepository                 *repository.UserRepository
+	ContactRepository              *repository.ContactRepository
+	AddressRepository              *repository.AddressRepository
+	ExperimentAssignmentRepository *repository.ExperimentAssignmentRepository
+	DebugCaptureRepository         *repository.DebugCaptureRepository
+	IDs                            *idgen.Generators
+}
+
+func NewSandboxUseCase(txManager TxManager, logger *logrus.Logger, options SandboxOptions,
+	userRepository *repository.UserRepository, contactRepository *repository.ContactRepository,
+	addressRepository *repository.AddressRepository, experimentAssignmentRepository *repository.ExperimentAssignmentRepository,
+	debugCaptureRepository *repository.DebugCaptureRepository, ids *idgen.Generators) *SandboxUseCase {
+	return &SandboxUseCase{
+		TxManager:                      txManager,
+		Log:                            logger,
+		Options:                        options,
+		UserRepository:                 userRepository,
+		ContactRepository:              contactRepository,
+		AddressRepository:              addressRepository,
+		ExperimentAssignmentRepository: experimentAssignmentRepository,
+		DebugCaptureRepository:         debugCaptureRepository,
+		IDs:                            ids,
+	}
+}
+
+var sandboxContacts = []struct {
+	contact entity.Contact
+	address entity.Address
+}{
+	{
+		contact: sandboxContact("Eko", "Khannedy", "eko@example.com", "081234567890"),
+		address: entity.Address{Street: "Jalan Belum Jadi", City: "Jakarta", Province: "DKI Jakarta", PostalCode: "10110", Country: "Indonesia"},
+	},
+	{
+		contact: sandboxContact("Budi", "Nugraha", "budi@example.com", "081298765432"),
+		address: entity.Address{Street: "Jalan Asia Afrika 8", City: "Bandung", Province: "Jawa Barat", PostalCode: "40111", Country: "Indonesia"},
+	},
+	{
+		contact: sandboxContact("Jane", "Doe", "jane@example.com", "+14155550100"),
+		address: entity.Address{Street: "1 Market Street", City: "San Francisco", Province: "California", PostalCode: "94105", Country: "United States"},
+	},
+}
+
+func sandboxContact(firstName string, lastName string, email string, phone string) entity.Contact {
+	return entity.Contact{
+		FirstName: firstName,
+		LastName:  lastName,
+		Emails:    []entity.ContactEmail{{Type: model.ContactTypeWork, Email: email, Primary: true}},
+		Phones:    []entity.ContactPhone{{Type: model.ContactTypeMobile, Phone: phone, Primary: true}},
+	}
+}
+
+// Reset deletes every row and seeds the demo tenant again.
+func (c *SandboxUseCase) Reset(ctx context.Context) error {
+	ctx, span := tracing.Start(ctx, "SandboxUseCase.Reset")
+	defer span.End()
+
+	password, err := bcrypt.GenerateFromPassword([]byte(c.Options.Password), bcrypt.DefaultCost)
+	if err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to generate bcrypt hash")
+		return fiber.ErrInternalServerError
+	}
+
+	tx := c.TxManager.Begin(ctx)
+	defer tx.Rollback()
+
+	if err := c.AddressRepository.DeleteAll(tx); err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to delete addresses")
+		return fiber.ErrInternalServerError
+	}
+	if err := c.ContactRepository.DeleteAll(tx); err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to delete contacts")
+		return fiber.ErrInternalServerError
+	}
+	if err := c.ExperimentAssignmentRepository.DeleteAll(tx); err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to delete experiment assignments")
+		return fiber.ErrInternalServerError
+	}
+	if err := c.DebugCaptureRepository.DeleteAll(tx); err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to delete debug captures")
+		return fiber.ErrInternalServerError
+	}
+	if err := c.UserRepository.DeleteAll(tx); err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to delete users")
+		return fiber.ErrInternalServerError
+	}
+
+	// the demo account has no mailbox, so it counts as verified right away
+	verifiedAt := time.Now().UnixMilli()
+	user := &entity.User{
+		ID:         c.Options.UserId,
+		Password:   string(password),
+		Name:       c.Options.Name,
+		Role:       model.RoleUser,
+		VerifiedAt: &verifiedAt,
+	}
+	if err := c.UserRepository.Create(tx, user); err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to create sandbox user")
+		return fiber.ErrInternalServerError
+	}
+
+	contacts := make([]entity.Contact, len(sandboxContacts))
+	addresses := make([]entity.Address, len(sandboxContacts))
+	for i, seed := range sandboxContacts {
+		contactId, err := c.IDs.NewID(ctx, idgen.Contact)
+		if err != nil {
+			c.Log.WithContext(ctx).WithError(err).Error("failed to generate contact id")
+			return fiber.ErrInternalServerError
+		}
+		addressId, err := c.IDs.NewID(ctx, idgen.Address)
+		if err != nil {
+			c.Log.WithContext(ctx).WithError(err).Error("failed to generate address id")
+			return fiber.ErrInternalServerError
+		}
+
+		// creating a contact sets the contact_id of its emails and phones, so they are copied off the seed
+		contacts[i] = seed.contact
+		contacts[i].ID = contactId
+		contacts[i].UserId = user.ID
+		contacts[i].Emails = slices.Clone(seed.contact.Emails)
+		contacts[i].Phones = slices.Clone(seed.contact.Phones)
+
+		addresses[i] = seed.address
+		addresses[i].ID = addressId
+		addresses[i].ContactId = contacts[i].ID
+	}
+
+	if err := c.ContactRepository.CreateInBatches(tx, contacts); err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to create sandbox contacts")
+		return fiber.ErrInternalServerError
+	}
+	if err := c.AddressRepository.CreateInBatches(tx, addresses); err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to create sandbox addresses")
+		return fiber.ErrInternalServerError
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to commit transaction")
+		return fiber.ErrInternalServerError
+	}
+
+	c.Log.WithContext(ctx).Infof("Sandbox reset, seeded demo user %s", user.ID)
+	return nil
+}
+
+// RunResets resets the sandbox immediately and then every reset interval until ctx is done.
+// It is meant to run on the leader only.
+func (c *SandboxUseCase) RunResets(ctx context.Context) {
+	ctx, span := tracing.Start(ctx, "SandboxUseCase.RunResets")
+	defer span.End()
+
+	ticker := time.NewTicker(c.Options.ResetInterval)
+	defer ticker.Stop()
+
+	for {
+		if err := c.Reset(ctx); err != nil {
+			c.Log.WithContext(ctx).WithError(err).Error("failed to reset sandbox")
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// CheckContactQuota returns fiber.ErrTooManyRequests once the user owns as many contacts as the sandbox allows.
+func (c *SandboxUseCase) CheckContactQuota(ctx context.Context, userId string) error {
+	ctx, span := tracing.Start(ctx, "SandboxUseCase.CheckContactQuota")
+	defer span.End()
+
+	return c.checkQuota(ctx, userId, c.Options.MaxContacts, c.ContactRepository.CountByUserId, "contact")
+}
+
+// CheckAddressQuota returns fiber.ErrTooManyRequests once the user owns as many addresses as the sandbox allows.
+func (c *SandboxUseCase) CheckAddressQuota(ctx context.Context, userId string) error {
+	ctx, span := tracing.Start(ctx, "SandboxUseCase.CheckAddressQuota")
+	defer span.End()
+
+	return c.checkQuota(ctx, userId, c.Options.MaxAddresses, c.AddressRepository.CountByUserId, "address")
+}
+
+func (c *SandboxUseCase) checkQuota(ctx context.Context, userId string, limit int,
+	count func(db *gorm.DB, userId string) (int64, error), resource string) error {
+	if limit <= 0 {
+		return nil
+	}
+
+	tx := c.TxManager.Begin(ctx)
+	defer tx.Rollback()
+
+	total, err := count(tx, userId)
+	if err != nil {
+		c.Log.WithContext(ctx).WithError(err).Errorf("failed to count %s", resource)
+		return fiber.ErrInternalServerError
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to commit transaction")
+		return fiber.ErrInternalServerError
+	}
+
+	if total >= int64(limit) {
+		return fiber.NewError(fiber.StatusTooManyRequests, "sandbox "+resource+" limit reached, data is reset periodically")
+	}
+	return nil
+}
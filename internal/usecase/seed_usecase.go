@@ -0,0 +1,74 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go-rest-scaffold/internal/model"
+	"go-rest-scaffold/internal/tracing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/sirupsen/logrus"
+)
+
+// SeedUseCase loads development fixtures through the other use cases, so seeded
+// data goes through the same validation, ID generation, events and audit log as
+// data created through the API.
+type SeedUseCase struct {
+	Log       *logrus.Logger
+	Users     *UserUseCase
+	Contacts  *ContactUseCase
+	Addresses *AddressUseCase
+}
+
+func NewSeedUseCase(logger *logrus.Logger, users *UserUseCase, contacts *ContactUseCase, addresses *AddressUseCase) *SeedUseCase {
+	return &SeedUseCase{
+		Log:       logger,
+		Users:     users,
+		Contacts:  contacts,
+		Addresses: addresses,
+	}
+}
+
+// Seed creates the users of fixtures with their contacts and addresses. A user that
+// already exists is skipped, so seeding twice adds nothing.
+func (c *SeedUseCase) Seed(ctx context.Context, fixtures *model.SeedFixtures) (*model.SeedResponse, error) {
+	ctx, span := tracing.Start(ctx, "SeedUseCase.Seed")
+	defer span.End()
+
+	response := new(model.SeedResponse)
+	for _, user := range fixtures.Users {
+		created, err := c.Users.Create(ctx, &user.RegisterUserRequest)
+		if errors.Is(err, fiber.ErrConflict) {
+			c.Log.WithContext(ctx).Infof("Skipping existing user %s", user.ID)
+			response.SkippedUsers++
+			continue
+		}
+		if err != nil {
+			return response, fmt.Errorf("user %s: %w", user.ID, err)
+		}
+		response.Users++
+
+		// the user's data is created by the user, as through the API
+		userCtx := model.WithActor(model.WithRegion(ctx, created.Region), created.ID)
+		for _, contact := range user.Contacts {
+			contact.UserId = created.ID
+			createdContact, err := c.Contacts.Create(userCtx, &contact.CreateContactRequest)
+			if err != nil {
+				return response, fmt.Errorf("contact %s of user %s: %w", contact.FirstName, user.ID, err)
+			}
+			response.Contacts++
+
+			for _, address := range contact.Addresses {
+				address.UserId = created.ID
+				address.ContactId = createdContact.ID
+				if _, err := c.Addresses.Create(userCtx, &address); err != nil {
+					return response, fmt.Errorf("address of contact %s of user %s: %w", contact.FirstName, user.ID, err)
+				}
+				response.Addresses++
+			}
+		}
+	}
+
+	return response, nil
+}
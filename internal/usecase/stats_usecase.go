@@ -0,0 +1,248 @@
+package usecase
+
+import (
+	"context"
+	"go-rest-scaffold/internal/entity"
+	"go-rest-scaffold/internal/model"
+	"go-rest-scaffold/internal/model/converter"
+	"go-rest-scaffold/internal/repository"
+	"go-rest-scaffold/internal/tracing"
+	"sync"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"github.com/sirupsen/logrus"
+)
+
+type StatsUseCase struct {
+	TxManager              TxManager
+	Log                    *logrus.Logger
+	Validate               *validator.Validate
+	StatsRepository        *repository.StatsRepository
+	UserActivityRepository *repository.UserActivityRepository
+
+	mutex    sync.Mutex
+	recorded map[string]struct{}
+}
+
+func NewStatsUseCase(txManager TxManager, logger *logrus.Logger, validate *validator.Validate, statsRepository *repository.StatsRepository,
+	userActivityRepository *repository.UserActivityRepository) *StatsUseCase {
+	return &StatsUseCase{
+		TxManager:              txManager,
+		Log:                    logger,
+		Validate:               validate,
+		StatsRepository:        statsRepository,
+		UserActivityRepository: userActivityRepository,
+		recorded:               make(map[string]struct{}),
+	}
+}
+
+// RecordActivity marks the user as active today. Each instance writes at most once
+// per user and day, so it is cheap enough to run on every authenticated request.
+func (c *StatsUseCase) RecordActivity(ctx context.Context, userId string) error {
+	ctx, span := tracing.Start(ctx, "StatsUseCase.RecordActivity")
+	defer span.End()
+
+	day := today()
+	key := userId + ":" + day.Format(model.StatsDayLayout)
+
+	c.mutex.Lock()
+	_, ok := c.recorded[key]
+	c.mutex.Unlock()
+	if ok {
+		return nil
+	}
+
+	if err := c.UserActivityRepository.Record(c.TxManager.DB(ctx), &entity.UserActivity{UserId: userId, Day: day}); err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to record user activity")
+		return fiber.ErrInternalServerError
+	}
+
+	c.mutex.Lock()
+	if len(c.recorded) >= maxRecorded {
+		c.recorded = make(map[string]struct{})
+	}
+	c.recorded[key] = struct{}{}
+	c.mutex.Unlock()
+
+	return nil
+}
+
+func (c *StatsUseCase) Get(ctx context.Context) (*model.StatsResponse, error) {
+	ctx, span := tracing.Start(ctx, "StatsUseCase.Get")
+	defer span.End()
+
+	tx := c.TxManager.Begin(ctx, readOnly)
+	defer tx.Rollback()
+
+	totals, err := c.StatsRepository.Totals(tx)
+	if err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to count totals")
+		return nil, fiber.ErrInternalServerError
+	}
+
+	day := today()
+	response := &model.StatsResponse{
+		Users:       totals.Users,
+		Contacts:    totals.Contacts,
+		Addresses:   totals.Addresses,
+		Reminders:   totals.Reminders,
+		Webhooks:    totals.Webhooks,
+		GeneratedAt: time.Now().UnixMilli(),
+	}
+	for since, active := range map[time.Time]*int64{
+		day:                    &response.ActiveToday,
+		day.AddDate(0, 0, -6):  &response.ActiveLast7Days,
+		day.AddDate(0, 0, -29): &response.ActiveLast30Days,
+	} {
+		if *active, err = c.StatsRepository.CountActiveSince(tx, since); err != nil {
+			c.Log.WithContext(ctx).WithError(err).Error("failed to count active users")
+			return nil, fiber.ErrInternalServerError
+		}
+	}
+
+	tables, err := c.StatsRepository.Storage(tx)
+	if err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to measure storage")
+		return nil, fiber.ErrInternalServerError
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to commit transaction")
+		return nil, fiber.ErrInternalServerError
+	}
+
+	response.Tables = make([]model.TableStorageResponse, len(tables))
+	for i, table := range tables {
+		response.Tables[i] = *converter.TableStorageToResponse(&table)
+		response.StorageBytes += table.Bytes
+	}
+
+	return response, nil
+}
+
+// Daily returns the statistics of the last request.Days UTC days, today included, oldest first.
+func (c *StatsUseCase) Daily(ctx context.Context, request *model.DailyStatsRequest) ([]model.DailyStatsResponse, error) {
+	ctx, span := tracing.Start(ctx, "StatsUseCase.Daily")
+	defer span.End()
+
+	tx := c.TxManager.Begin(ctx, readOnly)
+	defer tx.Rollback()
+
+	if err := c.Validate.Struct(request); err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to validate request")
+		return nil, err
+	}
+
+	until := today()
+	since := until.AddDate(0, 0, 1-request.Days)
+	// the days aggregated already are read as stored, the others computed
+	days, err := c.StatsRepository.FindDaily(tx, since, until)
+	if err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to find daily statistics")
+		return nil, fiber.ErrInternalServerError
+	}
+	for i, day := range days {
+		if day.Day.Format(model.StatsDayLayout) != since.Format(model.StatsDayLayout) {
+			days = days[:i]
+			break
+		}
+		since = since.AddDate(0, 0, 1)
+	}
+
+	computed, err := c.StatsRepository.Daily(tx, since, until)
+	if err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to compute daily statistics")
+		return nil, fiber.ErrInternalServerError
+	}
+	days = append(days, computed...)
+
+	if err := tx.Commit().Error; err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to commit transaction")
+		return nil, fiber.ErrInternalServerError
+	}
+
+	responses := make([]model.DailyStatsResponse, len(days))
+	for i, day := range days {
+		responses[i] = *converter.DailyStatsToResponse(&day)
+	}
+
+	return responses, nil
+}
+
+// dailyStatsBackfill is how many days the first daily aggregation covers, as many as
+// Daily can return.
+const dailyStatsBackfill = 366
+
+// AggregateDaily stores the statistics of the days that are over since the last
+// aggregation, so that they stay as they were when later changes (purged accounts,
+// contacts deleted for good) would alter the counts, and returns how many days it stored.
+func (c *StatsUseCase) AggregateDaily(ctx context.Context) (int64, error) {
+	ctx, span := tracing.Start(ctx, "StatsUseCase.AggregateDaily")
+	defer span.End()
+
+	tx := c.TxManager.Begin(ctx)
+	defer tx.Rollback()
+
+	until := today().AddDate(0, 0, -1)
+	since := until.AddDate(0, 0, 1-dailyStatsBackfill)
+	latest, err := c.StatsRepository.FindLatestDaily(tx)
+	if err != nil {
+		return 0, err
+	}
+	if latest != nil {
+		// the driver may read the date in another time zone than UTC
+		since = time.Date(latest.Day.Year(), latest.Day.Month(), latest.Day.Day()+1, 0, 0, 0, 0, time.UTC)
+	}
+	if since.After(until) {
+		return 0, nil
+	}
+
+	days, err := c.StatsRepository.Daily(tx, since, until)
+	if err != nil {
+		return 0, err
+	}
+
+	if err := c.StatsRepository.SaveDaily(tx, days); err != nil {
+		return 0, err
+	}
+
+	return int64(len(days)), tx.Commit().Error
+}
+
+func (c *StatsUseCase) TopAccounts(ctx context.Context, request *model.TopAccountsRequest) ([]model.TopAccountResponse, error) {
+	ctx, span := tracing.Start(ctx, "StatsUseCase.TopAccounts")
+	defer span.End()
+
+	tx := c.TxManager.Begin(ctx, readOnly)
+	defer tx.Rollback()
+
+	if err := c.Validate.Struct(request); err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to validate request")
+		return nil, err
+	}
+
+	accounts, err := c.StatsRepository.TopAccounts(tx, request.Limit)
+	if err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to find top accounts")
+		return nil, fiber.ErrInternalServerError
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to commit transaction")
+		return nil, fiber.ErrInternalServerError
+	}
+
+	responses := make([]model.TopAccountResponse, len(accounts))
+	for i, account := range accounts {
+		responses[i] = *converter.TopAccountToResponse(&account)
+	}
+
+	return responses, nil
+}
+
+// today returns the start of the current UTC day.
+func today() time.Time {
+	return time.Now().UTC().Truncate(24 * time.Hour)
+}
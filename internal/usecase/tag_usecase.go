@@ -0,0 +1,392 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"go-rest-scaffold/internal/entity"
+	"go-rest-scaffold/internal/event"
+	"go-rest-scaffold/internal/idgen"
+	"go-rest-scaffold/internal/model"
+	"go-rest-scaffold/internal/model/converter"
+	"go-rest-scaffold/internal/repository"
+	"go-rest-scaffold/internal/tracing"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// errTagExists refuses a tag named like another tag of the user, ignoring case.
+var errTagExists = fiber.NewError(fiber.StatusConflict, "a tag with this name already exists")
+
+// TagUseCase manages the tags of a user and their assignment to contacts. Tag events
+// are published under "tags/{id}" and assignments under "contacts/{id}/tags/{tagId}",
+// so subscribers forget the contact listings filtered by a tag whenever they change.
+type TagUseCase struct {
+	TxManager         TxManager
+	Log               *logrus.Logger
+	Validate          *validator.Validate
+	TagRepository     *repository.TagRepository
+	ContactRepository *repository.ContactRepository
+	EventBus          *event.Bus
+	AuditLog          *AuditLogUseCase
+	IDs               *idgen.Generators
+}
+
+func NewTagUseCase(txManager TxManager, logger *logrus.Logger, validate *validator.Validate, tagRepository *repository.TagRepository,
+	contactRepository *repository.ContactRepository, eventBus *event.Bus, auditLog *AuditLogUseCase, ids *idgen.Generators) *TagUseCase {
+	return &TagUseCase{
+		TxManager:         txManager,
+		Log:               logger,
+		Validate:          validate,
+		TagRepository:     tagRepository,
+		ContactRepository: contactRepository,
+		EventBus:          eventBus,
+		AuditLog:          auditLog,
+		IDs:               ids,
+	}
+}
+
+func (c *TagUseCase) Create(ctx context.Context, request *model.CreateTagRequest) (*model.TagResponse, error) {
+	ctx, span := tracing.Start(ctx, "TagUseCase.Create")
+	defer span.End()
+
+	tx := c.TxManager.Begin(ctx)
+	defer tx.Rollback()
+
+	if err := c.Validate.Struct(request); err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to validate request body")
+		return nil, err
+	}
+
+	if err := c.checkName(ctx, tx, request.UserId, request.Name, ""); err != nil {
+		return nil, err
+	}
+
+	id, err := c.IDs.NewID(ctx, idgen.Tag)
+	if err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to generate tag id")
+		return nil, fiber.ErrInternalServerError
+	}
+
+	tag := &entity.Tag{
+		ID:     id,
+		UserId: request.UserId,
+		Name:   request.Name,
+		Color:  request.Color,
+	}
+
+	if err := c.TagRepository.Create(tx, tag); err != nil {
+		if errors.Is(err, repository.ErrConflict) {
+			return nil, errTagExists
+		}
+		c.Log.WithContext(ctx).WithError(err).Error("failed to create tag")
+		return nil, fiber.ErrInternalServerError
+	}
+
+	if err := c.AuditLog.Record(ctx, tx, model.AuditCreate, model.AuditTag, tag.ID, nil, converter.TagToResponse(tag)); err != nil {
+		return nil, err
+	}
+
+	response := converter.TagToResponse(tag)
+	event, err := c.EventBus.Record(ctx, tx, model.EventTagCreated, "tags/"+tag.ID, tag.UserId, response)
+	if err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to record event")
+		return nil, fiber.ErrInternalServerError
+	}
+
+	if err := commit(ctx, tx); err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to commit transaction")
+		return nil, fiber.ErrInternalServerError
+	}
+
+	c.EventBus.Deliver(ctx, event)
+
+	return response, nil
+}
+
+// Update renames or recolors a tag, which stays assigned to its contacts.
+func (c *TagUseCase) Update(ctx context.Context, request *model.UpdateTagRequest) (*model.TagResponse, error) {
+	ctx, span := tracing.Start(ctx, "TagUseCase.Update")
+	defer span.End()
+
+	tx := c.TxManager.Begin(ctx)
+	defer tx.Rollback()
+
+	if err := c.Validate.Struct(request); err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to validate request body")
+		return nil, err
+	}
+
+	tag := new(entity.Tag)
+	if err := c.TagRepository.FindByIdAndUserId(tx, tag, request.ID, request.UserId); err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to find tag")
+		return nil, fiber.ErrNotFound
+	}
+
+	if err := c.checkName(ctx, tx, request.UserId, request.Name, tag.ID); err != nil {
+		return nil, err
+	}
+
+	before := converter.TagToResponse(tag)
+	tag.Name = request.Name
+	tag.Color = request.Color
+
+	if err := c.TagRepository.Update(tx, tag); err != nil {
+		if errors.Is(err, repository.ErrConflict) {
+			return nil, errTagExists
+		}
+		c.Log.WithContext(ctx).WithError(err).Error("failed to update tag")
+		return nil, fiber.ErrInternalServerError
+	}
+
+	if err := c.AuditLog.Record(ctx, tx, model.AuditUpdate, model.AuditTag, tag.ID, before, converter.TagToResponse(tag)); err != nil {
+		return nil, err
+	}
+
+	response := converter.TagToResponse(tag)
+	event, err := c.EventBus.Record(ctx, tx, model.EventTagUpdated, "tags/"+tag.ID, tag.UserId, response)
+	if err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to record event")
+		return nil, fiber.ErrInternalServerError
+	}
+
+	if err := commit(ctx, tx); err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to commit transaction")
+		return nil, fiber.ErrInternalServerError
+	}
+
+	c.EventBus.Deliver(ctx, event)
+
+	return response, nil
+}
+
+func (c *TagUseCase) Get(ctx context.Context, request *model.GetTagRequest) (*model.TagResponse, error) {
+	ctx, span := tracing.Start(ctx, "TagUseCase.Get")
+	defer span.End()
+
+	tx := c.TxManager.Begin(ctx, readOnly)
+	defer tx.Rollback()
+
+	if err := c.Validate.Struct(request); err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to validate request body")
+		return nil, fiber.ErrBadRequest
+	}
+
+	tag := new(entity.Tag)
+	if err := c.TagRepository.FindByIdAndUserId(tx, tag, request.ID, request.UserId); err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to find tag")
+		return nil, fiber.ErrNotFound
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to commit transaction")
+		return nil, fiber.ErrInternalServerError
+	}
+
+	return converter.TagToResponse(tag), nil
+}
+
+// Delete deletes a tag and takes it off every contact it was assigned to. Contacts
+// are left as they are otherwise.
+func (c *TagUseCase) Delete(ctx context.Context, request *model.DeleteTagRequest) error {
+	ctx, span := tracing.Start(ctx, "TagUseCase.Delete")
+	defer span.End()
+
+	tx := c.TxManager.Begin(ctx)
+	defer tx.Rollback()
+
+	if err := c.Validate.Struct(request); err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to validate request body")
+		return fiber.ErrBadRequest
+	}
+
+	tag := new(entity.Tag)
+	if err := c.TagRepository.FindByIdAndUserId(tx, tag, request.ID, request.UserId); err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to find tag")
+		return fiber.ErrNotFound
+	}
+
+	if err := c.TagRepository.DeleteWithAssignments(tx, tag); err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to delete tag")
+		return fiber.ErrInternalServerError
+	}
+
+	if err := c.AuditLog.Record(ctx, tx, model.AuditDelete, model.AuditTag, tag.ID, converter.TagToResponse(tag), nil); err != nil {
+		return err
+	}
+
+	event, err := c.EventBus.Record(ctx, tx, model.EventTagDeleted, "tags/"+tag.ID, tag.UserId, converter.TagToResponse(tag))
+	if err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to record event")
+		return fiber.ErrInternalServerError
+	}
+
+	if err := commit(ctx, tx); err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to commit transaction")
+		return fiber.ErrInternalServerError
+	}
+
+	c.EventBus.Deliver(ctx, event)
+
+	return nil
+}
+
+func (c *TagUseCase) List(ctx context.Context, request *model.ListTagRequest) ([]model.TagResponse, error) {
+	ctx, span := tracing.Start(ctx, "TagUseCase.List")
+	defer span.End()
+
+	tx := c.TxManager.Begin(ctx, readOnly)
+	defer tx.Rollback()
+
+	if err := c.Validate.Struct(request); err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to validate request body")
+		return nil, fiber.ErrBadRequest
+	}
+
+	tags, err := c.TagRepository.FindAllByUserId(tx, request.UserId)
+	if err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to find tags")
+		return nil, fiber.ErrInternalServerError
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to commit transaction")
+		return nil, fiber.ErrInternalServerError
+	}
+
+	return tagResponses(tags), nil
+}
+
+// ListByContact returns the tags assigned to a contact.
+func (c *TagUseCase) ListByContact(ctx context.Context, request *model.ListContactTagRequest) ([]model.TagResponse, error) {
+	ctx, span := tracing.Start(ctx, "TagUseCase.ListByContact")
+	defer span.End()
+
+	tx := c.TxManager.Begin(ctx, readOnly)
+	defer tx.Rollback()
+
+	if err := c.Validate.Struct(request); err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to validate request body")
+		return nil, fiber.ErrBadRequest
+	}
+
+	contact := new(entity.Contact)
+	if err := c.ContactRepository.FindByIdAndUserId(tx, contact, request.ContactId, request.UserId); err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to find contact")
+		return nil, fiber.ErrNotFound
+	}
+
+	tags, err := c.TagRepository.FindAllByContactId(tx, contact.ID)
+	if err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to find tags")
+		return nil, fiber.ErrInternalServerError
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to commit transaction")
+		return nil, fiber.ErrInternalServerError
+	}
+
+	return tagResponses(tags), nil
+}
+
+// Assign assigns a tag to a contact and returns the tags of the contact. Assigning a
+// tag the contact already has changes nothing.
+func (c *TagUseCase) Assign(ctx context.Context, request *model.AssignTagRequest) ([]model.TagResponse, error) {
+	ctx, span := tracing.Start(ctx, "TagUseCase.Assign")
+	defer span.End()
+
+	return c.assign(ctx, request, true)
+}
+
+// Unassign takes a tag off a contact and returns the tags the contact has left.
+// Taking off a tag the contact does not have changes nothing.
+func (c *TagUseCase) Unassign(ctx context.Context, request *model.AssignTagRequest) ([]model.TagResponse, error) {
+	ctx, span := tracing.Start(ctx, "TagUseCase.Unassign")
+	defer span.End()
+
+	return c.assign(ctx, request, false)
+}
+
+func (c *TagUseCase) assign(ctx context.Context, request *model.AssignTagRequest, assign bool) ([]model.TagResponse, error) {
+	tx := c.TxManager.Begin(ctx)
+	defer tx.Rollback()
+
+	if err := c.Validate.Struct(request); err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to validate request body")
+		return nil, fiber.ErrBadRequest
+	}
+
+	contact := new(entity.Contact)
+	if err := c.ContactRepository.FindByIdAndUserId(tx, contact, request.ContactId, request.UserId); err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to find contact")
+		return nil, fiber.ErrNotFound
+	}
+
+	tag := new(entity.Tag)
+	if err := c.TagRepository.FindByIdAndUserId(tx, tag, request.TagId, request.UserId); err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to find tag")
+		return nil, fiber.ErrNotFound
+	}
+
+	var changed bool
+	var err error
+	eventType := model.EventContactTagged
+	if assign {
+		changed, err = c.TagRepository.Assign(tx, contact.ID, tag.ID)
+	} else {
+		eventType = model.EventContactUntagged
+		changed, err = c.TagRepository.Unassign(tx, contact.ID, tag.ID)
+	}
+	if err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to assign tag")
+		return nil, fiber.ErrInternalServerError
+	}
+
+	var event *model.CloudEvent
+	if changed {
+		event, err = c.EventBus.Record(ctx, tx, eventType, "contacts/"+contact.ID+"/tags/"+tag.ID, contact.UserId, converter.TagToResponse(tag))
+		if err != nil {
+			c.Log.WithContext(ctx).WithError(err).Error("failed to record event")
+			return nil, fiber.ErrInternalServerError
+		}
+	}
+
+	tags, err := c.TagRepository.FindAllByContactId(tx, contact.ID)
+	if err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to find tags")
+		return nil, fiber.ErrInternalServerError
+	}
+
+	if err := commit(ctx, tx); err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to commit transaction")
+		return nil, fiber.ErrInternalServerError
+	}
+
+	c.EventBus.Deliver(ctx, event)
+
+	return tagResponses(tags), nil
+}
+
+// checkName refuses a name another tag of the user than excludeId has, ignoring case.
+func (c *TagUseCase) checkName(ctx context.Context, tx *gorm.DB, userId string, name string, excludeId string) error {
+	total, err := c.TagRepository.CountByName(tx, userId, name, excludeId)
+	if err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to count tags")
+		return fiber.ErrInternalServerError
+	}
+	if total > 0 {
+		return errTagExists
+	}
+	return nil
+}
+
+func tagResponses(tags []entity.Tag) []model.TagResponse {
+	responses := make([]model.TagResponse, len(tags))
+	for i := range tags {
+		responses[i] = *converter.TagToResponse(&tags[i])
+	}
+	return responses
+}
@@ -0,0 +1,261 @@
+package usecase
+
+import (
+	"context"
+	"go-rest-scaffold/internal/entity"
+	"go-rest-scaffold/internal/event"
+	"go-rest-scaffold/internal/model"
+	"go-rest-scaffold/internal/model/converter"
+	"go-rest-scaffold/internal/repository"
+	"go-rest-scaffold/internal/tracing"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"github.com/sirupsen/logrus"
+)
+
+// TrashOptions controls how long deleted resources can be restored.
+type TrashOptions struct {
+	Retention time.Duration
+	// Avatars deletes the pictures of the avatars of purged contacts, nil to leave them.
+	Avatars *ContactAvatarUseCase
+}
+
+type TrashUseCase struct {
+	TxManager         TxManager
+	Log               *logrus.Logger
+	Validate          *validator.Validate
+	TrashRepository   *repository.TrashRepository
+	ContactRepository *repository.ContactRepository
+	AddressRepository *repository.AddressRepository
+	EventBus          *event.Bus
+	Options           TrashOptions
+}
+
+func NewTrashUseCase(txManager TxManager, logger *logrus.Logger, validate *validator.Validate, trashRepository *repository.TrashRepository,
+	contactRepository *repository.ContactRepository, addressRepository *repository.AddressRepository, eventBus *event.Bus,
+	options TrashOptions) *TrashUseCase {
+	return &TrashUseCase{
+		TxManager:         txManager,
+		Log:               logger,
+		Validate:          validate,
+		TrashRepository:   trashRepository,
+		ContactRepository: contactRepository,
+		AddressRepository: addressRepository,
+		EventBus:          eventBus,
+		Options:           options,
+	}
+}
+
+func (c *TrashUseCase) Search(ctx context.Context, request *model.SearchTrashRequest) ([]model.TrashItemResponse, int64, error) {
+	ctx, span := tracing.Start(ctx, "TrashUseCase.Search")
+	defer span.End()
+
+	tx := c.TxManager.Begin(ctx)
+	defer tx.Rollback()
+
+	if err := c.Validate.Struct(request); err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to validate request")
+		return nil, 0, err
+	}
+
+	items, total, err := c.TrashRepository.Search(tx, request)
+	if err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to find trash")
+		return nil, 0, fiber.ErrInternalServerError
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to commit transaction")
+		return nil, 0, fiber.ErrInternalServerError
+	}
+
+	now := time.Now()
+	responses := make([]model.TrashItemResponse, len(items))
+	for i, item := range items {
+		responses[i] = *converter.TrashItemToResponse(&item, c.Options.Retention, now)
+	}
+
+	return responses, total, nil
+}
+
+// Restore takes the listed items out of the trash in a single transaction. An
+// address can only come back once its contact is out of the trash, so a batch
+// may restore a contact and its addresses together as long as the contact comes first.
+func (c *TrashUseCase) Restore(ctx context.Context, request *model.RestoreTrashRequest) (*model.RestoreTrashResponse, error) {
+	ctx, span := tracing.Start(ctx, "TrashUseCase.Restore")
+	defer span.End()
+
+	tx := c.TxManager.Begin(ctx)
+	defer tx.Rollback()
+
+	if err := c.Validate.Struct(request); err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to validate request body")
+		return nil, err
+	}
+
+	var events []*model.CloudEvent
+	for _, item := range request.Items {
+		switch item.Type {
+		case model.TrashContact:
+			contact := new(entity.Contact)
+			if err := c.ContactRepository.FindDeletedByIdAndUserId(tx, contact, item.ID, request.UserId); err != nil {
+				c.Log.WithContext(ctx).WithError(err).Error("failed to find deleted contact")
+				return nil, fiber.NewError(fiber.StatusNotFound, "contact "+item.ID+" is not in the trash")
+			}
+
+			if err := c.ContactRepository.Restore(tx, contact); err != nil {
+				c.Log.WithContext(ctx).WithError(err).Error("failed to restore contact")
+				return nil, fiber.ErrInternalServerError
+			}
+
+			event, err := c.EventBus.Record(ctx, tx, model.EventContactRestored, "contacts/"+contact.ID, contact.UserId, converter.ContactToResponse(contact))
+			if err != nil {
+				c.Log.WithContext(ctx).WithError(err).Error("failed to record event")
+				return nil, fiber.ErrInternalServerError
+			}
+			events = append(events, event)
+		case model.TrashAddress:
+			address := new(entity.Address)
+			if err := c.AddressRepository.FindDeletedByIdAndUserId(tx, address, item.ID, request.UserId); err != nil {
+				c.Log.WithContext(ctx).WithError(err).Error("failed to find deleted address")
+				return nil, fiber.NewError(fiber.StatusNotFound, "address "+item.ID+" is not in the trash")
+			}
+
+			contact := new(entity.Contact)
+			if err := c.ContactRepository.FindByIdAndUserId(tx, contact, address.ContactId, request.UserId); err != nil {
+				c.Log.WithContext(ctx).WithError(err).Error("failed to find contact")
+				return nil, fiber.NewError(fiber.StatusConflict, "contact "+address.ContactId+" of address "+item.ID+" is in the trash")
+			}
+
+			if err := c.AddressRepository.Restore(tx, address); err != nil {
+				c.Log.WithContext(ctx).WithError(err).Error("failed to restore address")
+				return nil, fiber.ErrInternalServerError
+			}
+
+			event, err := c.EventBus.Record(ctx, tx, model.EventAddressRestored, "contacts/"+contact.ID+"/addresses/"+address.ID, contact.UserId,
+				converter.AddressToResponse(address))
+			if err != nil {
+				c.Log.WithContext(ctx).WithError(err).Error("failed to record event")
+				return nil, fiber.ErrInternalServerError
+			}
+			events = append(events, event)
+		}
+	}
+
+	if err := commit(ctx, tx); err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to commit transaction")
+		return nil, fiber.ErrInternalServerError
+	}
+
+	for _, event := range events {
+		c.EventBus.Deliver(ctx, event)
+	}
+
+	return &model.RestoreTrashResponse{Restored: len(request.Items)}, nil
+}
+
+// RestoreContact takes a single contact out of the trash and returns it.
+func (c *TrashUseCase) RestoreContact(ctx context.Context, request *model.RestoreContactRequest) (*model.ContactResponse, error) {
+	ctx, span := tracing.Start(ctx, "TrashUseCase.RestoreContact")
+	defer span.End()
+
+	tx := c.TxManager.Begin(ctx)
+	defer tx.Rollback()
+
+	if err := c.Validate.Struct(request); err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to validate request")
+		return nil, err
+	}
+
+	contact := new(entity.Contact)
+	if err := c.ContactRepository.FindDeletedByIdAndUserId(tx, contact, request.ID, request.UserId); err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to find deleted contact")
+		return nil, fiber.NewError(fiber.StatusNotFound, "contact "+request.ID+" is not in the trash")
+	}
+
+	if err := c.ContactRepository.Restore(tx, contact); err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to restore contact")
+		return nil, fiber.ErrInternalServerError
+	}
+
+	response := converter.ContactToResponse(contact)
+	event, err := c.EventBus.Record(ctx, tx, model.EventContactRestored, "contacts/"+contact.ID, contact.UserId, response)
+	if err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to record event")
+		return nil, fiber.ErrInternalServerError
+	}
+
+	if err := commit(ctx, tx); err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to commit transaction")
+		return nil, fiber.ErrInternalServerError
+	}
+
+	c.EventBus.Deliver(ctx, event)
+
+	return response, nil
+}
+
+// Empty deletes everything in the user's trash for good.
+func (c *TrashUseCase) Empty(ctx context.Context, request *model.EmptyTrashRequest) (*model.EmptyTrashResponse, error) {
+	ctx, span := tracing.Start(ctx, "TrashUseCase.Empty")
+	defer span.End()
+
+	tx := c.TxManager.Begin(ctx)
+	defer tx.Rollback()
+
+	if err := c.Validate.Struct(request); err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to validate request")
+		return nil, err
+	}
+
+	now := time.Now()
+	avatars, err := c.TrashRepository.FindPurgedAvatars(tx, request.UserId, now)
+	if err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to find avatars")
+		return nil, fiber.ErrInternalServerError
+	}
+
+	purged, err := c.TrashRepository.Purge(tx, request.UserId, now)
+	if err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to empty trash")
+		return nil, fiber.ErrInternalServerError
+	}
+
+	if err := commit(ctx, tx); err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to commit transaction")
+		return nil, fiber.ErrInternalServerError
+	}
+
+	c.Options.Avatars.DeletePictures(ctx, avatars)
+	return &model.EmptyTrashResponse{Purged: purged}, nil
+}
+
+// PurgeExpired deletes for good every item of every user that has been in the trash longer
+// than the retention. It is the trash-purge job of the scheduler.
+func (c *TrashUseCase) PurgeExpired(ctx context.Context) (int64, error) {
+	ctx, span := tracing.Start(ctx, "TrashUseCase.PurgeExpired")
+	defer span.End()
+
+	tx := c.TxManager.Begin(ctx)
+	defer tx.Rollback()
+
+	deletedBefore := time.Now().Add(-c.Options.Retention)
+	avatars, err := c.TrashRepository.FindPurgedAvatars(tx, "", deletedBefore)
+	if err != nil {
+		return 0, err
+	}
+
+	purged, err := c.TrashRepository.Purge(tx, "", deletedBefore)
+	if err != nil {
+		return 0, err
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return 0, err
+	}
+
+	c.Options.Avatars.DeletePictures(ctx, avatars)
+	return purged, nil
+}
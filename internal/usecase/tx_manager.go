@@ -0,0 +1,55 @@
+package usecase
+
+import (
+	"context"
+	"database/sql"
+	"go-rest-scaffold/internal/model"
+
+	"gorm.io/gorm"
+	"gorm.io/plugin/dbresolver"
+)
+
+// TxManager hands out the database to the use cases. Every repository call of an
+// operation goes through the one transaction Begin returns, so the operation is a
+// single unit of work; use cases never open transactions on a *gorm.DB themselves,
+// which lets tests inject a manager of their own.
+type TxManager interface {
+	// DB returns the database bound to ctx, outside of any transaction. Its reads go
+	// to a read replica when there are, unless ctx is marked with model.WithPrimary.
+	DB(ctx context.Context) *gorm.DB
+	// Begin starts a transaction bound to ctx. The caller defers its Rollback, which
+	// does nothing once the transaction is committed. Read-only transactions run on a
+	// read replica when there are, unless ctx is marked with model.WithPrimary; any
+	// other runs on the primary.
+	Begin(ctx context.Context, opts ...*sql.TxOptions) *gorm.DB
+}
+
+// readOnly begins the transactions of the operations that only read, which may be
+// served by a read replica.
+var readOnly = &sql.TxOptions{ReadOnly: true}
+
+// GormTxManager is the TxManager of a gorm database.
+type GormTxManager struct {
+	db *gorm.DB
+}
+
+func NewGormTxManager(db *gorm.DB) *GormTxManager {
+	return &GormTxManager{
+		db: db,
+	}
+}
+
+func (m *GormTxManager) DB(ctx context.Context) *gorm.DB {
+	if model.IsPrimary(ctx) {
+		return m.db.WithContext(ctx).Clauses(dbresolver.Write).Session(&gorm.Session{})
+	}
+	return m.db.WithContext(ctx)
+}
+
+func (m *GormTxManager) Begin(ctx context.Context, opts ...*sql.TxOptions) *gorm.DB {
+	db := m.db.WithContext(ctx)
+	if len(opts) > 0 && opts[0] != nil && opts[0].ReadOnly && !model.IsPrimary(ctx) {
+		db = db.Clauses(dbresolver.Read)
+	}
+	return db.Begin(opts...)
+}
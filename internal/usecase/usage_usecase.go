@@ -0,0 +1,155 @@
+package usecase
+
+import (
+	"context"
+	"go-rest-scaffold/internal/gateway/usage"
+	"go-rest-scaffold/internal/model"
+	"go-rest-scaffold/internal/repository"
+	"go-rest-scaffold/internal/tracing"
+	"strconv"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"github.com/sirupsen/logrus"
+)
+
+// UsageOptions controls the metering of requests and the daily quotas.
+type UsageOptions struct {
+	Enabled bool
+	// UserDaily caps the requests of a user per UTC day, 0 for no cap.
+	UserDaily int64
+	// APIKeyDaily caps the requests made with one API key per UTC day, 0 for no cap.
+	APIKeyDaily int64
+}
+
+// UsageUseCase meters the requests of users and their API keys per UTC day and holds
+// them to their daily quotas.
+type UsageUseCase struct {
+	TxManager        TxManager
+	Log              *logrus.Logger
+	Validate         *validator.Validate
+	Meter            *usage.Meter
+	APIKeyRepository *repository.APIKeyRepository
+	Options          UsageOptions
+}
+
+func NewUsageUseCase(txManager TxManager, logger *logrus.Logger, validate *validator.Validate, meter *usage.Meter,
+	apiKeyRepository *repository.APIKeyRepository, options UsageOptions) *UsageUseCase {
+	return &UsageUseCase{
+		TxManager:        txManager,
+		Log:              logger,
+		Validate:         validate,
+		Meter:            meter,
+		APIKeyRepository: apiKeyRepository,
+		Options:          options,
+	}
+}
+
+// Record counts a request against its user and, when made with one, its API key, and
+// returns 429 once either went over its quota today. Requests over quota count too,
+// so a client retrying in a loop stays refused. When the counters cannot be reached
+// the request is allowed, so an outage of Redis does not refuse everyone.
+func (c *UsageUseCase) Record(ctx context.Context, auth *model.Auth) error {
+	ctx, span := tracing.Start(ctx, "UsageUseCase.Record")
+	defer span.End()
+
+	if !c.Options.Enabled {
+		return nil
+	}
+
+	subjects := []string{userSubject(auth.ID)}
+	if auth.APIKeyId != "" {
+		subjects = append(subjects, apiKeySubject(auth.APIKeyId))
+	}
+
+	counts, err := c.Meter.Add(ctx, today(), subjects...)
+	if err != nil {
+		c.Log.WithContext(ctx).WithError(err).Warn("failed to meter request")
+		return nil
+	}
+
+	if c.Options.UserDaily > 0 && counts[0] > c.Options.UserDaily {
+		return fiber.NewError(fiber.StatusTooManyRequests, "daily quota of "+strconv.FormatInt(c.Options.UserDaily, 10)+" requests used up")
+	}
+	if len(counts) > 1 && c.Options.APIKeyDaily > 0 && counts[1] > c.Options.APIKeyDaily {
+		return fiber.NewError(fiber.StatusTooManyRequests, "daily quota of "+strconv.FormatInt(c.Options.APIKeyDaily, 10)+" requests of the API key used up")
+	}
+	return nil
+}
+
+// Get returns the requests the user and each of their API keys that is not revoked
+// made today.
+func (c *UsageUseCase) Get(ctx context.Context, request *model.GetUsageRequest) (*model.UsageResponse, error) {
+	ctx, span := tracing.Start(ctx, "UsageUseCase.Get")
+	defer span.End()
+
+	if err := c.Validate.Struct(request); err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to validate request body")
+		return nil, fiber.ErrBadRequest
+	}
+
+	day := today()
+	response := &model.UsageResponse{
+		Enabled: c.Options.Enabled,
+		Date:    day.Format(model.StatsDayLayout),
+		Reset:   day.Add(24 * time.Hour).Unix(),
+		APIKeys: []model.APIKeyUsageResponse{},
+	}
+	if !c.Options.Enabled {
+		return response, nil
+	}
+
+	apiKeys, err := c.APIKeyRepository.FindAllByUserId(c.TxManager.DB(ctx), request.UserId)
+	if err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to find api keys")
+		return nil, fiber.ErrInternalServerError
+	}
+
+	subjects := []string{userSubject(request.UserId)}
+	for _, apiKey := range apiKeys {
+		if apiKey.RevokedAt == nil {
+			subjects = append(subjects, apiKeySubject(apiKey.ID))
+		}
+	}
+
+	counts, err := c.Meter.Get(ctx, day, subjects...)
+	if err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to read request counts")
+		return nil, fiber.ErrInternalServerError
+	}
+
+	response.Requests = quotaUsage(counts[0], c.Options.UserDaily)
+	counts = counts[1:]
+	for _, apiKey := range apiKeys {
+		if apiKey.RevokedAt != nil {
+			continue
+		}
+		response.APIKeys = append(response.APIKeys, model.APIKeyUsageResponse{
+			ID:       apiKey.ID,
+			Name:     apiKey.Name,
+			Prefix:   apiKey.Prefix,
+			Requests: quotaUsage(counts[0], c.Options.APIKeyDaily),
+		})
+		counts = counts[1:]
+	}
+	return response, nil
+}
+
+func quotaUsage(used int64, limit int64) model.QuotaUsage {
+	usage := model.QuotaUsage{Used: used}
+	if limit > 0 {
+		remaining := max(limit-used, 0)
+		usage.Limit = &limit
+		usage.Remaining = &remaining
+	}
+	return usage
+}
+
+func userSubject(userId string) string {
+	return "user:" + userId
+}
+
+func apiKeySubject(apiKeyId string) string {
+	return "api_key:" + apiKeyId
+}
@@ -0,0 +1,35 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"go-rest-scaffold/internal/model"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+// commit commits tx, unless the request is a dry run, in which case every check has
+// already passed and the transaction is rolled back instead.
+func commit(ctx context.Context, tx *gorm.DB) error {
+	if dryRun := model.DryRunFrom(ctx); dryRun != nil {
+		dryRun.MarkRolledBack()
+		return tx.Rollback().Error
+	}
+	return tx.Commit().Error
+}
+
+// checkVersion refuses a change of an entity whose version is not the one the client
+// read, with 412; expected 0 accepts any version.
+func checkVersion(name string, expected int64, version int64) error {
+	if expected != 0 && expected != version {
+		return fiber.NewError(fiber.StatusPreconditionFailed, fmt.Sprintf("%s is at version %d, not %d", name, version, expected))
+	}
+	return nil
+}
+
+// errConcurrentChange is the error of a change that lost the race with another change
+// of the same version of an entity, which the client retries on the new version.
+func errConcurrentChange(name string) error {
+	return fiber.NewError(fiber.StatusConflict, name+" was changed by another request meanwhile")
+}
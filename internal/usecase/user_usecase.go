@@ -2,262 +2,1667 @@ package usecase
 
 import (
 	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
 	"go-rest-scaffold/internal/entity"
+	"go-rest-scaffold/internal/event"
+	"go-rest-scaffold/internal/gateway/mailer"
+	"go-rest-scaffold/internal/gateway/revocation"
+	"go-rest-scaffold/internal/jwt"
+	"go-rest-scaffold/internal/metrics"
 	"go-rest-scaffold/internal/model"
 	"go-rest-scaffold/internal/model/converter"
+	"go-rest-scaffold/internal/password"
 	"go-rest-scaffold/internal/repository"
+	"go-rest-scaffold/internal/tracing"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
 
 	"github.com/go-playground/validator/v10"
 	"github.com/gofiber/fiber/v2"
 	"github.com/google/uuid"
 	"github.com/sirupsen/logrus"
-	"golang.org/x/crypto/bcrypt"
 	"gorm.io/gorm"
 )
 
+// UserOptions controls passwords, access tokens and the email verification, password
+// reset and magic link emails.
+type UserOptions struct {
+	// PasswordResetURL is the page of the client that asks for the new password. The
+	// reset token is added to it as the token query parameter.
+	PasswordResetURL string
+	PasswordResetTTL time.Duration
+	// MagicLinkURL is the page of the client that exchanges a magic link for tokens.
+	// The login token is added to it as the token query parameter.
+	MagicLinkURL string
+	MagicLinkTTL time.Duration
+	// VerificationURL is where verification links point, GET /api/users/_verify or a
+	// client page calling it; the token is added as the token query parameter.
+	VerificationURL string
+	VerificationTTL time.Duration
+	// VerificationRequired makes registration ask for an email and refuses logins
+	// until the address is verified.
+	VerificationRequired bool
+	// VerificationSecret signs verification tokens, which are not stored.
+	VerificationSecret []byte
+	// PasswordPolicy is checked on every new password.
+	PasswordPolicy password.Policy
+	// PasswordHasher hashes new passwords. Hashes made with another algorithm or
+	// other parameters still verify and are replaced on the next login.
+	PasswordHasher password.Hasher
+	// AccessTokens signs access tokens as JWTs, nil to issue opaque ones.
+	AccessTokens *jwt.Issuer
+	// Revocations lists the sessions whose JWT access tokens are refused before they
+	// expire, nil to keep them valid until then.
+	Revocations *revocation.Store
+	// DeletionGracePeriod is how long a deleted account is kept before it is purged.
+	DeletionGracePeriod time.Duration
+	// DeletionPurgeInterval is how often deleted accounts are looked for.
+	DeletionPurgeInterval time.Duration
+	// ImpersonationTTL is how long the token of an admin impersonating a user is valid.
+	ImpersonationTTL time.Duration
+	// ReadCache caches Current, nil to always read the database.
+	ReadCache *ReadCache
+	// Avatars deletes the pictures of the avatars of the contacts of purged accounts,
+	// nil to leave them.
+	Avatars *ContactAvatarUseCase
+}
+
+// sessionSeenResolution is how precisely the last activity of a session is recorded.
+const sessionSeenResolution = time.Minute
+
 type UserUseCase struct {
-	DB             *gorm.DB
-	Log            *logrus.Logger
-	Validate       *validator.Validate
-	UserRepository *repository.UserRepository
+	TxManager               TxManager
+	Log                     *logrus.Logger
+	Validate                *validator.Validate
+	UserRepository          *repository.UserRepository
+	SessionRepository       *repository.SessionRepository
+	PasswordResetRepository *repository.PasswordResetRepository
+	MagicLinkRepository     *repository.MagicLinkRepository
+	ContactRepository       *repository.ContactRepository
+	APIKeyRepository        *repository.APIKeyRepository
+	EmailTemplateUseCase    *EmailTemplateUseCase
+	MailSender              mailer.Sender
+	EventBus                *event.Bus
+	AuditLog                *AuditLogUseCase
+	LoginEvents             *LoginEventUseCase
+	// Regions users can live in, the home region first. Empty for a single database.
+	Regions []string
+	Options UserOptions
 }
 
-func NewUserUseCase(db *gorm.DB, logger *logrus.Logger, validate *validator.Validate,
-	userRepository *repository.UserRepository) *UserUseCase {
+func NewUserUseCase(txManager TxManager, logger *logrus.Logger, validate *validator.Validate,
+	userRepository *repository.UserRepository, sessionRepository *repository.SessionRepository, passwordResetRepository *repository.PasswordResetRepository,
+	magicLinkRepository *repository.MagicLinkRepository, contactRepository *repository.ContactRepository, apiKeyRepository *repository.APIKeyRepository, emailTemplateUseCase *EmailTemplateUseCase, mailSender mailer.Sender, eventBus *event.Bus, auditLog *AuditLogUseCase,
+	loginEvents *LoginEventUseCase, regions []string, options UserOptions) *UserUseCase {
 	return &UserUseCase{
-		DB:             db,
-		Log:            logger,
-		Validate:       validate,
-		UserRepository: userRepository,
+		TxManager:               txManager,
+		Log:                     logger,
+		Validate:                validate,
+		UserRepository:          userRepository,
+		SessionRepository:       sessionRepository,
+		PasswordResetRepository: passwordResetRepository,
+		MagicLinkRepository:     magicLinkRepository,
+		ContactRepository:       contactRepository,
+		APIKeyRepository:        apiKeyRepository,
+		EmailTemplateUseCase:    emailTemplateUseCase,
+		MailSender:              mailSender,
+		EventBus:                eventBus,
+		AuditLog:                auditLog,
+		LoginEvents:             loginEvents,
+		Regions:                 regions,
+		Options:                 options,
 	}
 }
 
 func (c *UserUseCase) Verify(ctx context.Context, request *model.VerifyUserRequest) (*model.Auth, error) {
-	tx := c.DB.WithContext(ctx).Begin()
+	ctx, span := tracing.Start(ctx, "UserUseCase.Verify")
+	defer span.End()
+
+	if c.Options.AccessTokens != nil && jwt.IsJWT(request.Token) {
+		return c.verifyAccessToken(ctx, request)
+	}
+
+	region, ok := tokenRegion(c.Regions, request.Token)
+	if !ok {
+		c.Log.WithContext(ctx).Warnf("Token of unknown region")
+		return nil, fiber.ErrNotFound
+	}
+
+	tx := c.TxManager.Begin(model.WithRegion(ctx, region))
 	defer tx.Rollback()
 
 	err := c.Validate.Struct(request)
 	if err != nil {
-		c.Log.Warnf("Invalid request body : %+v", err)
+		c.Log.WithContext(ctx).Warnf("Invalid request body : %+v", err)
 		return nil, fiber.ErrBadRequest
 	}
 
+	session := new(entity.Session)
+	if err := c.SessionRepository.FindByToken(tx, session, request.Token); err != nil {
+		c.Log.WithContext(ctx).Warnf("Failed find session by token : %+v", err)
+		return nil, fiber.ErrNotFound
+	}
+
+	now := time.Now()
+	if session.ExpiresAt != nil && now.UnixMilli() >= *session.ExpiresAt {
+		c.Log.WithContext(ctx).Warnf("Token of expired session %s", session.ID)
+		return nil, fiber.ErrNotFound
+	}
+
 	user := new(entity.User)
-	if err := c.UserRepository.FindByToken(tx, user, request.Token); err != nil {
-		c.Log.Warnf("Failed find user by token : %+v", err)
+	if err := c.UserRepository.FindById(tx, user, session.UserId); err != nil {
+		c.Log.WithContext(ctx).Warnf("Failed find user by id : %+v", err)
+		return nil, fiber.ErrNotFound
+	}
+
+	if user.Region != region {
+		c.Log.WithContext(ctx).Warnf("Token of user %s used outside of their home region", user.ID)
 		return nil, fiber.ErrNotFound
 	}
 
 	if err := tx.Commit().Error; err != nil {
-		c.Log.Warnf("Failed commit transaction : %+v", err)
+		c.Log.WithContext(ctx).Warnf("Failed commit transaction : %+v", err)
 		return nil, fiber.ErrInternalServerError
 	}
 
-	return &model.Auth{ID: user.ID}, nil
+	// the last activity is bookkeeping, so failing to record it does not refuse the request
+	db := c.TxManager.DB(model.WithRegion(ctx, region))
+	if err := c.SessionRepository.TouchLastSeen(db, session.ID, now.UnixMilli(), now.Add(-sessionSeenResolution).UnixMilli()); err != nil {
+		c.Log.WithContext(ctx).Warnf("Failed record session activity : %+v", err)
+	}
+
+	return &model.Auth{
+		ID:             user.ID,
+		Role:           user.Role,
+		Region:         user.Region,
+		SessionId:      session.ID,
+		Scopes:         scopesOf(session.Scopes),
+		ImpersonatorId: session.ImpersonatorId,
+	}, nil
 }
 
-func (c *UserUseCase) Create(ctx context.Context, request *model.RegisterUserRequest) (*model.UserResponse, error) {
-	tx := c.DB.WithContext(ctx).Begin()
-	defer tx.Rollback()
+// verifyAccessToken authenticates a JWT access token by its signature, without reading
+// the database. Signing out revokes the session in the revocation list, which is all
+// that refuses its access token before it expires.
+func (c *UserUseCase) verifyAccessToken(ctx context.Context, request *model.VerifyUserRequest) (*model.Auth, error) {
+	if err := c.Validate.Struct(request); err != nil {
+		c.Log.WithContext(ctx).Warnf("Invalid request body : %+v", err)
+		return nil, fiber.ErrBadRequest
+	}
 
-	err := c.Validate.Struct(request)
+	claims, err := c.Options.AccessTokens.Verify(request.Token, time.Now())
 	if err != nil {
-		c.Log.Warnf("Invalid request body : %+v", err)
+		c.Log.WithContext(ctx).Warnf("Failed verify access token : %+v", err)
+		return nil, fiber.ErrNotFound
+	}
+
+	if _, ok := homeRegion(c.Regions, claims.Region); !ok {
+		c.Log.WithContext(ctx).Warnf("Access token of unknown region")
+		return nil, fiber.ErrNotFound
+	}
+
+	if c.Options.Revocations != nil && c.Options.Revocations.IsRevoked(ctx, claims.SessionId) {
+		c.Log.WithContext(ctx).Warnf("Access token of revoked session %s", claims.SessionId)
+		return nil, fiber.ErrNotFound
+	}
+
+	auth := &model.Auth{ID: claims.Subject, Role: claims.Role, Region: claims.Region, SessionId: claims.SessionId, Scopes: scopesOf(claims.Scope)}
+	if claims.Actor != nil {
+		auth.ImpersonatorId = claims.Actor.Subject
+	}
+	return auth, nil
+}
+
+func (c *UserUseCase) Create(ctx context.Context, request *model.RegisterUserRequest) (response *model.UserResponse, err error) {
+	ctx, span := tracing.Start(ctx, "UserUseCase.Create")
+	defer span.End()
+
+	defer func() {
+		if !model.IsDryRun(ctx) {
+			metrics.Registrations.WithLabelValues(metrics.Outcome(err)).Inc()
+		}
+	}()
+
+	if err := c.Validate.Struct(request); err != nil {
+		c.Log.WithContext(ctx).Warnf("Invalid request body : %+v", err)
 		return nil, fiber.ErrBadRequest
 	}
 
-	total, err := c.UserRepository.CountById(tx, request.ID)
+	if err := c.Options.PasswordPolicy.Check(request.Password); err != nil {
+		return nil, fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+
+	region, ok := homeRegion(c.Regions, request.Region)
+	if !ok {
+		return nil, fiber.NewError(fiber.StatusBadRequest, "unknown region "+request.Region)
+	}
+
+	// IDs are unique across regions, since login looks users up by ID alone
+	_, exists, err := c.findRegion(ctx, request.ID)
 	if err != nil {
-		c.Log.Warnf("Failed count user from database : %+v", err)
+		c.Log.WithContext(ctx).Warnf("Failed count user from database : %+v", err)
 		return nil, fiber.ErrInternalServerError
 	}
 
-	if total > 0 {
-		c.Log.Warnf("User already exists : %+v", err)
+	if exists {
+		c.Log.WithContext(ctx).Warnf("User already exists : %+v", request.ID)
 		return nil, fiber.ErrConflict
 	}
 
-	password, err := bcrypt.GenerateFromPassword([]byte(request.Password), bcrypt.DefaultCost)
+	if request.Email != "" {
+		if err := c.checkEmailAvailable(ctx, request.Email, request.ID); err != nil {
+			return nil, err
+		}
+	} else if c.Options.VerificationRequired {
+		return nil, fiber.NewError(fiber.StatusBadRequest, "email is required")
+	}
+
+	ctx = model.WithRegion(ctx, region)
+	tx := c.TxManager.Begin(ctx)
+	defer tx.Rollback()
+
+	hash, err := c.Options.PasswordHasher.Hash(request.Password)
 	if err != nil {
-		c.Log.Warnf("Failed to generate bcrype hash : %+v", err)
+		c.Log.WithContext(ctx).Warnf("Failed to hash password : %+v", err)
 		return nil, fiber.ErrInternalServerError
 	}
 
 	user := &entity.User{
 		ID:       request.ID,
-		Password: string(password),
+		Password: hash,
 		Name:     request.Name,
+		Email:    request.Email,
+		Role:     model.RoleUser,
+		Region:   region,
 	}
 
 	if err := c.UserRepository.Create(tx, user); err != nil {
-		c.Log.Warnf("Failed create user to database : %+v", err)
+		// registered concurrently since it was looked up
+		if errors.Is(err, repository.ErrConflict) {
+			c.Log.WithContext(ctx).Warnf("User already exists : %+v", request.ID)
+			return nil, fiber.ErrConflict
+		}
+		c.Log.WithContext(ctx).Warnf("Failed create user to database : %+v", err)
 		return nil, fiber.ErrInternalServerError
 	}
 
-	if err := tx.Commit().Error; err != nil {
-		c.Log.Warnf("Failed commit transaction : %+v", err)
+	response = converter.UserToResponse(user)
+	event, err := c.EventBus.Record(ctx, tx, model.EventUserRegistered, "users/"+user.ID, user.ID, response)
+	if err != nil {
+		c.Log.WithContext(ctx).Warnf("Failed record event : %+v", err)
 		return nil, fiber.ErrInternalServerError
 	}
 
-	return converter.UserToResponse(user), nil
+	if err := commit(ctx, tx); err != nil {
+		c.Log.WithContext(ctx).Warnf("Failed commit transaction : %+v", err)
+		return nil, fiber.ErrInternalServerError
+	}
+
+	c.EventBus.Deliver(ctx, event)
+	c.sendVerification(ctx, user)
+
+	return response, nil
 }
 
-func (c *UserUseCase) Login(ctx context.Context, request *model.LoginUserRequest) (*model.UserResponse, error) {
-	tx := c.DB.WithContext(ctx).Begin()
-	defer tx.Rollback()
+func (c *UserUseCase) Login(ctx context.Context, request *model.LoginUserRequest) (_ *model.UserResponse, err error) {
+	ctx, span := tracing.Start(ctx, "UserUseCase.Login")
+	defer span.End()
+
+	defer func() { metrics.Logins.WithLabelValues(metrics.Outcome(err)).Inc() }()
 
 	if err := c.Validate.Struct(request); err != nil {
-		c.Log.Warnf("Invalid request body  : %+v", err)
+		c.Log.WithContext(ctx).Warnf("Invalid request body  : %+v", err)
 		return nil, fiber.ErrBadRequest
 	}
 
+	region, _, err := c.findRegion(ctx, request.ID)
+	if err != nil {
+		c.Log.WithContext(ctx).Warnf("Failed find user by id : %+v", err)
+		return nil, fiber.ErrInternalServerError
+	}
+
+	ctx = model.WithRegion(ctx, region)
+	tx := c.TxManager.Begin(ctx)
+	defer tx.Rollback()
+
 	user := new(entity.User)
 	if err := c.UserRepository.FindById(tx, user, request.ID); err != nil {
-		c.Log.Warnf("Failed find user by id : %+v", err)
+		c.Log.WithContext(ctx).Warnf("Failed find user by id : %+v", err)
 		return nil, fiber.ErrUnauthorized
 	}
 
-	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(request.Password)); err != nil {
-		c.Log.Warnf("Failed to compare user password with bcrype hash : %+v", err)
+	defer func() {
+		c.LoginEvents.Record(ctx, user.ID, model.LoginMethodPassword, request.IP, request.Device, request.UserAgent, err)
+	}()
+
+	if user.DeletedAt != nil {
+		c.Log.WithContext(ctx).Warnf("Deleted user %s tried to log in", user.ID)
 		return nil, fiber.ErrUnauthorized
 	}
 
-	user.Token = uuid.New().String()
-	user.RefreshToken = uuid.New().String()
-	if err := c.UserRepository.Update(tx, user); err != nil {
-		c.Log.Warnf("Failed save user : %+v", err)
-		return nil, fiber.ErrInternalServerError
+	if err := c.Options.PasswordHasher.Compare(user.Password, request.Password); err != nil {
+		c.Log.WithContext(ctx).Warnf("Failed to compare user password with hash : %+v", err)
+		return nil, fiber.ErrUnauthorized
+	}
+
+	// the password is only known here, so hashes of an older configuration are
+	// upgraded as their users log in
+	if c.Options.PasswordHasher.NeedsRehash(user.Password) {
+		hash, err := c.Options.PasswordHasher.Hash(request.Password)
+		if err != nil {
+			c.Log.WithContext(ctx).Warnf("Failed to hash password : %+v", err)
+			return nil, fiber.ErrInternalServerError
+		}
+		user.Password = hash
+	}
+
+	if c.Options.VerificationRequired && user.VerifiedAt == nil {
+		c.Log.WithContext(ctx).Warnf("User %s logged in before verifying their email", user.ID)
+		return nil, fiber.NewError(fiber.StatusForbidden, "email address is not verified")
+	}
+
+	session, err := c.startSession(tx, user, request.Device, request.IP, request.UserAgent, request.Scopes)
+	if err != nil {
+		return nil, err
 	}
 
 	if err := tx.Commit().Error; err != nil {
-		c.Log.Warnf("Failed commit transaction : %+v", err)
+		c.Log.WithContext(ctx).Warnf("Failed commit transaction : %+v", err)
 		return nil, fiber.ErrInternalServerError
 	}
 
-	return converter.UserToTokenResponse(user), nil
+	return c.tokenResponse(user, session)
 }
 
 func (c *UserUseCase) RefreshToken(ctx context.Context, request *model.RefreshTokenRequest) (*model.UserResponse, error) {
-	tx := c.DB.WithContext(ctx).Begin()
+	ctx, span := tracing.Start(ctx, "UserUseCase.RefreshToken")
+	defer span.End()
+
+	region, ok := tokenRegion(c.Regions, request.RefreshToken)
+	if !ok {
+		c.Log.WithContext(ctx).Warnf("Refresh token of unknown region")
+		return nil, fiber.ErrUnauthorized
+	}
+
+	tx := c.TxManager.Begin(model.WithRegion(ctx, region))
 	defer tx.Rollback()
 
 	if err := c.Validate.Struct(request); err != nil {
-		c.Log.Warnf("Invalid request body : %+v", err)
+		c.Log.WithContext(ctx).Warnf("Invalid request body : %+v", err)
 		return nil, fiber.ErrBadRequest
 	}
 
+	session := new(entity.Session)
+	if err := c.SessionRepository.FindByRefreshToken(tx, session, request.RefreshToken); err != nil {
+		c.Log.WithContext(ctx).Warnf("Failed find session by refresh token : %+v", err)
+		return nil, fiber.ErrUnauthorized
+	}
+
+	// impersonation ends when its token expires
+	if session.ImpersonatorId != "" {
+		c.Log.WithContext(ctx).Warnf("Refresh of impersonation session %s", session.ID)
+		return nil, fiber.ErrUnauthorized
+	}
+
 	user := new(entity.User)
-	if err := c.UserRepository.FindByRefreshToken(tx, user, request.RefreshToken); err != nil {
-		c.Log.Warnf("Failed find user by refresh token : %+v", err)
+	if err := c.UserRepository.FindById(tx, user, session.UserId); err != nil {
+		c.Log.WithContext(ctx).Warnf("Failed find user by id : %+v", err)
 		return nil, fiber.ErrUnauthorized
 	}
 
-	user.Token = uuid.New().String()
-	user.RefreshToken = uuid.New().String()
+	session.Token = c.newToken(user.Region)
+	session.RefreshToken = c.newToken(user.Region)
+	session.LastSeenAt = time.Now().UnixMilli()
+	if err := c.SessionRepository.Update(tx, session); err != nil {
+		c.Log.WithContext(ctx).Warnf("Failed save session : %+v", err)
+		return nil, fiber.ErrInternalServerError
+	}
 
+	user.Token = session.Token
+	user.RefreshToken = session.RefreshToken
 	if err := c.UserRepository.Update(tx, user); err != nil {
-		c.Log.Warnf("Failed save user : %+v", err)
+		c.Log.WithContext(ctx).Warnf("Failed save user : %+v", err)
 		return nil, fiber.ErrInternalServerError
 	}
 
 	if err := tx.Commit().Error; err != nil {
-		c.Log.Warnf("Failed commit transaction : %+v", err)
+		c.Log.WithContext(ctx).Warnf("Failed commit transaction : %+v", err)
 		return nil, fiber.ErrInternalServerError
 	}
 
-	return converter.UserToTokenResponse(user), nil
+	return c.tokenResponse(user, session)
 }
 
 func (c *UserUseCase) Current(ctx context.Context, request *model.GetUserRequest) (*model.UserResponse, error) {
-	tx := c.DB.WithContext(ctx).Begin()
-	defer tx.Rollback()
+	ctx, span := tracing.Start(ctx, "UserUseCase.Current")
+	defer span.End()
 
 	if err := c.Validate.Struct(request); err != nil {
-		c.Log.Warnf("Invalid request body : %+v", err)
+		c.Log.WithContext(ctx).Warnf("Invalid request body : %+v", err)
 		return nil, fiber.ErrBadRequest
 	}
 
+	return cacheRead(ctx, c.Options.ReadCache, "user", userNamespace(request.ID), "current", c.Options.ReadCache.userTTL(),
+		func() (*model.UserResponse, error) {
+			return c.current(ctx, request)
+		})
+}
+
+func (c *UserUseCase) current(ctx context.Context, request *model.GetUserRequest) (*model.UserResponse, error) {
+	// outside a transaction, so concurrent reads of the user share a query
 	user := new(entity.User)
-	if err := c.UserRepository.FindById(tx, user, request.ID); err != nil {
-		c.Log.Warnf("Failed find user by id : %+v", err)
+	if err := c.UserRepository.FindByIdShared(c.TxManager.DB(ctx), user, request.ID); err != nil {
+		c.Log.WithContext(ctx).Warnf("Failed find user by id : %+v", err)
 		return nil, fiber.ErrNotFound
 	}
 
-	if err := tx.Commit().Error; err != nil {
-		c.Log.Warnf("Failed commit transaction : %+v", err)
-		return nil, fiber.ErrInternalServerError
-	}
-
 	return converter.UserToResponse(user), nil
 }
 
-func (c *UserUseCase) Logout(ctx context.Context, request *model.LogoutUserRequest) (bool, error) {
-	tx := c.DB.WithContext(ctx).Begin()
+func (c *UserUseCase) Logout(ctx context.Context, request *model.LogoutUserRequest) (_ bool, err error) {
+	ctx, span := tracing.Start(ctx, "UserUseCase.Logout")
+	defer span.End()
+
+	defer func() { metrics.Logouts.WithLabelValues(metrics.Outcome(err)).Inc() }()
+
+	tx := c.TxManager.Begin(ctx)
 	defer tx.Rollback()
 
 	if err := c.Validate.Struct(request); err != nil {
-		c.Log.Warnf("Invalid request body : %+v", err)
+		c.Log.WithContext(ctx).Warnf("Invalid request body : %+v", err)
 		return false, fiber.ErrBadRequest
 	}
 
 	user := new(entity.User)
 	if err := c.UserRepository.FindById(tx, user, request.ID); err != nil {
-		c.Log.Warnf("Failed find user by id : %+v", err)
+		c.Log.WithContext(ctx).Warnf("Failed find user by id : %+v", err)
 		return false, fiber.ErrNotFound
 	}
 
 	user.Token = ""
+	user.RefreshToken = ""
 
 	if err := c.UserRepository.Update(tx, user); err != nil {
-		c.Log.Warnf("Failed save user : %+v", err)
+		c.Log.WithContext(ctx).Warnf("Failed save user : %+v", err)
+		return false, fiber.ErrInternalServerError
+	}
+
+	sessionIds, err := c.deleteSessions(tx, user.ID)
+	if err != nil {
+		c.Log.WithContext(ctx).Warnf("Failed delete sessions : %+v", err)
 		return false, fiber.ErrInternalServerError
 	}
 
 	if err := tx.Commit().Error; err != nil {
-		c.Log.Warnf("Failed commit transaction : %+v", err)
+		c.Log.WithContext(ctx).Warnf("Failed commit transaction : %+v", err)
 		return false, fiber.ErrInternalServerError
 	}
 
+	c.revokeAccessTokens(ctx, sessionIds...)
 	return true, nil
 }
 
-func (c *UserUseCase) Update(ctx context.Context, request *model.UpdateUserRequest) (*model.UserResponse, error) {
-	tx := c.DB.WithContext(ctx).Begin()
+// Delete deletes the account of the user. Their contacts go to the trash and they are
+// signed out everywhere at once, their API keys revoked; the account and everything it
+// owns is purged for good by the deletion purger once the grace period is over.
+func (c *UserUseCase) Delete(ctx context.Context, request *model.DeleteUserRequest) (*model.DeleteUserResponse, error) {
+	ctx, span := tracing.Start(ctx, "UserUseCase.Delete")
+	defer span.End()
+
+	tx := c.TxManager.Begin(ctx)
 	defer tx.Rollback()
 
 	if err := c.Validate.Struct(request); err != nil {
-		c.Log.Warnf("Invalid request body : %+v", err)
+		c.Log.WithContext(ctx).Warnf("Invalid request body : %+v", err)
 		return nil, fiber.ErrBadRequest
 	}
 
 	user := new(entity.User)
 	if err := c.UserRepository.FindById(tx, user, request.ID); err != nil {
-		c.Log.Warnf("Failed find user by id : %+v", err)
+		c.Log.WithContext(ctx).Warnf("Failed find user by id : %+v", err)
 		return nil, fiber.ErrNotFound
 	}
 
-	if request.Name != "" {
-		user.Name = request.Name
+	before := converter.UserToResponse(user)
+	now := time.Now()
+	deletedAt := now.UnixMilli()
+	user.DeletedAt = &deletedAt
+	user.Token = ""
+	user.RefreshToken = ""
+
+	if err := c.UserRepository.Update(tx, user); err != nil {
+		c.Log.WithContext(ctx).Warnf("Failed save user : %+v", err)
+		return nil, fiber.ErrInternalServerError
 	}
 
-	if request.Password != "" {
-		password, err := bcrypt.GenerateFromPassword([]byte(request.Password), bcrypt.DefaultCost)
+	if err := c.ContactRepository.DeleteAllByUserId(tx, user.ID); err != nil {
+		c.Log.WithContext(ctx).Warnf("Failed delete contacts : %+v", err)
+		return nil, fiber.ErrInternalServerError
+	}
+
+	if err := c.APIKeyRepository.RevokeAllByUserId(tx, user.ID, deletedAt); err != nil {
+		c.Log.WithContext(ctx).Warnf("Failed revoke api keys : %+v", err)
+		return nil, fiber.ErrInternalServerError
+	}
+
+	sessionIds, err := c.deleteSessions(tx, user.ID)
+	if err != nil {
+		c.Log.WithContext(ctx).Warnf("Failed delete sessions : %+v", err)
+		return nil, fiber.ErrInternalServerError
+	}
+
+	if err := c.AuditLog.Record(ctx, tx, model.AuditDelete, model.AuditUser, user.ID, before, nil); err != nil {
+		return nil, err
+	}
+
+	event, err := c.EventBus.Record(ctx, tx, model.EventUserDeleted, "users/"+user.ID, user.ID, before)
+	if err != nil {
+		c.Log.WithContext(ctx).Warnf("Failed record event : %+v", err)
+		return nil, fiber.ErrInternalServerError
+	}
+
+	if err := commit(ctx, tx); err != nil {
+		c.Log.WithContext(ctx).Warnf("Failed commit transaction : %+v", err)
+		return nil, fiber.ErrInternalServerError
+	}
+
+	c.EventBus.Deliver(ctx, event)
+	c.revokeAccessTokens(ctx, sessionIds...)
+	return &model.DeleteUserResponse{PurgeAt: now.Add(c.Options.DeletionGracePeriod).UnixMilli()}, nil
+}
+
+// RunDeletionPurger purges, every purge interval until ctx is done, the accounts deleted
+// longer than the grace period ago. It is meant to run on the leader only.
+func (c *UserUseCase) RunDeletionPurger(ctx context.Context) {
+	ctx, span := tracing.Start(ctx, "UserUseCase.RunDeletionPurger")
+	defer span.End()
+
+	ticker := time.NewTicker(c.Options.DeletionPurgeInterval)
+	defer ticker.Stop()
+
+	for {
+		if purged, err := c.PurgeDeleted(ctx); err != nil {
+			c.Log.WithContext(ctx).WithError(err).Error("failed to purge deleted accounts")
+		} else if purged > 0 {
+			c.Log.WithContext(ctx).Infof("Purged %d deleted accounts", purged)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// deletionPurgeBatchSize is how many accounts are purged per transaction.
+const deletionPurgeBatchSize = 10
+
+// PurgeDeleted deletes for good every account deleted longer than the grace period ago,
+// one transaction per batch of accounts, and returns how many were purged.
+func (c *UserUseCase) PurgeDeleted(ctx context.Context) (int, error) {
+	ctx, span := tracing.Start(ctx, "UserUseCase.PurgeDeleted")
+	defer span.End()
+
+	deletedBefore := time.Now().Add(-c.Options.DeletionGracePeriod).UnixMilli()
+
+	purged := 0
+	for {
+		count, err := c.purgeDeletedBatch(ctx, deletedBefore)
+		purged += count
+		if err != nil || count < deletionPurgeBatchSize {
+			return purged, err
+		}
+	}
+}
+
+func (c *UserUseCase) purgeDeletedBatch(ctx context.Context, deletedBefore int64) (int, error) {
+	tx := c.TxManager.Begin(ctx)
+	defer tx.Rollback()
+
+	ids, err := c.UserRepository.FindIdsDeletedBefore(tx, deletedBefore, deletionPurgeBatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	var avatars []entity.Contact
+	for _, id := range ids {
+		contacts, err := c.UserRepository.FindContactAvatars(tx, id)
 		if err != nil {
-			c.Log.Warnf("Failed to generate bcrype hash : %+v", err)
-			return nil, fiber.ErrInternalServerError
+			return 0, err
+		}
+		avatars = append(avatars, contacts...)
+
+		if err := c.UserRepository.Purge(tx, id); err != nil {
+			return 0, err
 		}
-		user.Password = string(password)
 	}
 
-	if err := c.UserRepository.Update(tx, user); err != nil {
-		c.Log.Warnf("Failed save user : %+v", err)
+	if err := tx.Commit().Error; err != nil {
+		return 0, err
+	}
+
+	c.Options.Avatars.DeletePictures(ctx, avatars)
+	return len(ids), nil
+}
+
+// PurgeExpiredTokens deletes the password resets, magic links and impersonation
+// sessions that expired, which can no longer be used, and returns how many.
+func (c *UserUseCase) PurgeExpiredTokens(ctx context.Context) (int64, error) {
+	ctx, span := tracing.Start(ctx, "UserUseCase.PurgeExpiredTokens")
+	defer span.End()
+
+	tx := c.TxManager.Begin(ctx)
+	defer tx.Rollback()
+
+	now := time.Now().UnixMilli()
+	var purged int64
+	for _, deleteExpired := range []func(db *gorm.DB, now int64) (int64, error){
+		c.PasswordResetRepository.DeleteExpired,
+		c.MagicLinkRepository.DeleteExpired,
+		c.SessionRepository.DeleteExpired,
+	} {
+		deleted, err := deleteExpired(tx, now)
+		if err != nil {
+			return 0, err
+		}
+		purged += deleted
+	}
+
+	return purged, tx.Commit().Error
+}
+
+// Sessions lists the logins of the user, so they can spot one they do not recognise.
+func (c *UserUseCase) Sessions(ctx context.Context, request *model.ListSessionRequest) ([]model.SessionResponse, error) {
+	ctx, span := tracing.Start(ctx, "UserUseCase.Sessions")
+	defer span.End()
+
+	tx := c.TxManager.Begin(ctx)
+	defer tx.Rollback()
+
+	if err := c.Validate.Struct(request); err != nil {
+		c.Log.WithContext(ctx).Warnf("Invalid request body : %+v", err)
+		return nil, fiber.ErrBadRequest
+	}
+
+	sessions, err := c.SessionRepository.FindAllByUserId(tx, request.UserId)
+	if err != nil {
+		c.Log.WithContext(ctx).Warnf("Failed find sessions : %+v", err)
 		return nil, fiber.ErrInternalServerError
 	}
 
 	if err := tx.Commit().Error; err != nil {
-		c.Log.Warnf("Failed commit transaction : %+v", err)
+		c.Log.WithContext(ctx).Warnf("Failed commit transaction : %+v", err)
 		return nil, fiber.ErrInternalServerError
 	}
 
-	return converter.UserToResponse(user), nil
+	responses := make([]model.SessionResponse, len(sessions))
+	for i, session := range sessions {
+		responses[i] = *converter.SessionToResponse(&session)
+		responses[i].Current = session.ID == request.SessionId
+	}
+
+	return responses, nil
+}
+
+// RevokeSession signs the user out of one session, its access and refresh token
+// stop working at once. The other sessions are left alone.
+func (c *UserUseCase) RevokeSession(ctx context.Context, request *model.RevokeSessionRequest) (bool, error) {
+	ctx, span := tracing.Start(ctx, "UserUseCase.RevokeSession")
+	defer span.End()
+
+	tx := c.TxManager.Begin(ctx)
+	defer tx.Rollback()
+
+	if err := c.Validate.Struct(request); err != nil {
+		c.Log.WithContext(ctx).Warnf("Invalid request body : %+v", err)
+		return false, fiber.ErrBadRequest
+	}
+
+	session := new(entity.Session)
+	if err := c.SessionRepository.FindByIdAndUserId(tx, session, request.ID, request.UserId); err != nil {
+		c.Log.WithContext(ctx).Warnf("Failed find session : %+v", err)
+		return false, fiber.ErrNotFound
+	}
+
+	if err := c.SessionRepository.Delete(tx, session); err != nil {
+		c.Log.WithContext(ctx).Warnf("Failed delete session : %+v", err)
+		return false, fiber.ErrInternalServerError
+	}
+
+	if err := commit(ctx, tx); err != nil {
+		c.Log.WithContext(ctx).Warnf("Failed commit transaction : %+v", err)
+		return false, fiber.ErrInternalServerError
+	}
+
+	c.revokeAccessTokens(ctx, session.ID)
+	return true, nil
+}
+
+// RevokeUserSessions signs a user out everywhere on behalf of an admin, e.g. when the
+// account is compromised. Access tokens already issued are refused at once.
+func (c *UserUseCase) RevokeUserSessions(ctx context.Context, request *model.RevokeUserSessionsRequest) (bool, error) {
+	ctx, span := tracing.Start(ctx, "UserUseCase.RevokeUserSessions")
+	defer span.End()
+
+	if err := c.Validate.Struct(request); err != nil {
+		c.Log.WithContext(ctx).Warnf("Invalid request body : %+v", err)
+		return false, fiber.ErrBadRequest
+	}
+
+	region, exists, err := c.findRegion(ctx, request.UserId)
+	if err != nil {
+		c.Log.WithContext(ctx).Warnf("Failed find user by id : %+v", err)
+		return false, fiber.ErrInternalServerError
+	}
+	if !exists {
+		return false, fiber.ErrNotFound
+	}
+
+	ctx = model.WithRegion(ctx, region)
+	tx := c.TxManager.Begin(ctx)
+	defer tx.Rollback()
+
+	user := new(entity.User)
+	if err := c.UserRepository.FindById(tx, user, request.UserId); err != nil {
+		c.Log.WithContext(ctx).Warnf("Failed find user by id : %+v", err)
+		return false, fiber.ErrNotFound
+	}
+
+	user.Token = ""
+	user.RefreshToken = ""
+
+	if err := c.UserRepository.Update(tx, user); err != nil {
+		c.Log.WithContext(ctx).Warnf("Failed save user : %+v", err)
+		return false, fiber.ErrInternalServerError
+	}
+
+	sessionIds, err := c.deleteSessions(tx, user.ID)
+	if err != nil {
+		c.Log.WithContext(ctx).Warnf("Failed delete sessions : %+v", err)
+		return false, fiber.ErrInternalServerError
+	}
+
+	if err := commit(ctx, tx); err != nil {
+		c.Log.WithContext(ctx).Warnf("Failed commit transaction : %+v", err)
+		return false, fiber.ErrInternalServerError
+	}
+
+	c.revokeAccessTokens(ctx, sessionIds...)
+	return true, nil
+}
+
+// Impersonate issues an admin a short-lived access token acting as another user, e.g.
+// to reproduce what a customer reports. The admin stays named in the session and the
+// token, so changes made with it are audited as theirs, and starting it is audited too.
+// Admins cannot be impersonated, which would hand one admin another's rights.
+func (c *UserUseCase) Impersonate(ctx context.Context, request *model.ImpersonateUserRequest) (*model.ImpersonationResponse, error) {
+	ctx, span := tracing.Start(ctx, "UserUseCase.Impersonate")
+	defer span.End()
+
+	if err := c.Validate.Struct(request); err != nil {
+		c.Log.WithContext(ctx).Warnf("Invalid request body : %+v", err)
+		return nil, fiber.ErrBadRequest
+	}
+
+	if request.UserId == request.AdminId {
+		return nil, fiber.NewError(fiber.StatusBadRequest, "admins cannot impersonate themselves")
+	}
+
+	region, exists, err := c.findRegion(ctx, request.UserId)
+	if err != nil {
+		c.Log.WithContext(ctx).Warnf("Failed find user by id : %+v", err)
+		return nil, fiber.ErrInternalServerError
+	}
+	if !exists {
+		return nil, fiber.ErrNotFound
+	}
+
+	ctx = model.WithRegion(ctx, region)
+	tx := c.TxManager.Begin(ctx)
+	defer tx.Rollback()
+
+	user := new(entity.User)
+	if err := c.UserRepository.FindById(tx, user, request.UserId); err != nil {
+		c.Log.WithContext(ctx).Warnf("Failed find user by id : %+v", err)
+		return nil, fiber.ErrNotFound
+	}
+
+	if user.DeletedAt != nil {
+		return nil, fiber.ErrNotFound
+	}
+	if user.Role == model.RoleAdmin {
+		return nil, fiber.NewError(fiber.StatusForbidden, "admins cannot be impersonated")
+	}
+
+	now := time.Now()
+	expiresAt := now.Add(c.Options.ImpersonationTTL).UnixMilli()
+	session := &entity.Session{
+		ID:             uuid.NewString(),
+		UserId:         user.ID,
+		Token:          c.newToken(user.Region),
+		RefreshToken:   c.newToken(user.Region),
+		Device:         truncate("Impersonation by "+request.AdminId, 100),
+		ImpersonatorId: request.AdminId,
+		ExpiresAt:      &expiresAt,
+		LastSeenAt:     now.UnixMilli(),
+	}
+	if err := c.SessionRepository.Create(tx, session); err != nil {
+		c.Log.WithContext(ctx).Warnf("Failed create session : %+v", err)
+		return nil, fiber.ErrInternalServerError
+	}
+
+	impersonation := map[string]any{"session_id": session.ID, "expires_at": expiresAt}
+	if err := c.AuditLog.Record(ctx, tx, model.AuditImpersonate, model.AuditUser, user.ID, nil, impersonation); err != nil {
+		return nil, err
+	}
+
+	if err := commit(ctx, tx); err != nil {
+		c.Log.WithContext(ctx).Warnf("Failed commit transaction : %+v", err)
+		return nil, fiber.ErrInternalServerError
+	}
+
+	response := &model.ImpersonationResponse{UserId: user.ID, Token: session.Token, ExpiresAt: expiresAt}
+	if c.Options.AccessTokens != nil {
+		response.Token, err = c.Options.AccessTokens.IssueFor(jwt.Claims{
+			Subject:   user.ID,
+			Role:      user.Role,
+			Region:    user.Region,
+			SessionId: session.ID,
+			Actor:     &jwt.Actor{Subject: request.AdminId},
+		}, now, c.Options.ImpersonationTTL)
+		if err != nil {
+			c.Log.WithContext(ctx).Warnf("Failed sign access token : %+v", err)
+			return nil, fiber.ErrInternalServerError
+		}
+	}
+
+	return response, nil
+}
+
+func (c *UserUseCase) Update(ctx context.Context, request *model.UpdateUserRequest) (*model.UserResponse, error) {
+	ctx, span := tracing.Start(ctx, "UserUseCase.Update")
+	defer span.End()
+
+	tx := c.TxManager.Begin(ctx)
+	defer tx.Rollback()
+
+	if err := c.Validate.Struct(request); err != nil {
+		c.Log.WithContext(ctx).Warnf("Invalid request body : %+v", err)
+		return nil, fiber.ErrBadRequest
+	}
+
+	user := new(entity.User)
+	if err := c.UserRepository.FindById(tx, user, request.ID); err != nil {
+		c.Log.WithContext(ctx).Warnf("Failed find user by id : %+v", err)
+		return nil, fiber.ErrNotFound
+	}
+
+	before := converter.UserToResponse(user)
+	if request.Name != "" {
+		user.Name = request.Name
+	}
+
+	emailChanged := false
+	if request.Email != "" && request.Email != user.Email {
+		if err := c.checkEmailAvailable(ctx, request.Email, user.ID); err != nil {
+			return nil, err
+		}
+		user.Email = request.Email
+		user.VerifiedAt = nil
+		emailChanged = true
+	}
+
+	if request.Password != "" {
+		if err := c.Options.PasswordPolicy.Check(request.Password); err != nil {
+			return nil, fiber.NewError(fiber.StatusBadRequest, err.Error())
+		}
+
+		hash, err := c.Options.PasswordHasher.Hash(request.Password)
+		if err != nil {
+			c.Log.WithContext(ctx).Warnf("Failed to hash password : %+v", err)
+			return nil, fiber.ErrInternalServerError
+		}
+		user.Password = hash
+	}
+
+	if err := c.UserRepository.Update(tx, user); err != nil {
+		c.Log.WithContext(ctx).Warnf("Failed save user : %+v", err)
+		return nil, fiber.ErrInternalServerError
+	}
+
+	// the password hash is never logged, so a changed password leaves no diff of its own
+	if err := c.AuditLog.Record(ctx, tx, model.AuditUpdate, model.AuditUser, user.ID, before, converter.UserToResponse(user)); err != nil {
+		return nil, err
+	}
+
+	response := converter.UserToResponse(user)
+	event, err := c.EventBus.Record(ctx, tx, model.EventUserUpdated, "users/"+user.ID, user.ID, response)
+	if err != nil {
+		c.Log.WithContext(ctx).Warnf("Failed record event : %+v", err)
+		return nil, fiber.ErrInternalServerError
+	}
+
+	if err := commit(ctx, tx); err != nil {
+		c.Log.WithContext(ctx).Warnf("Failed commit transaction : %+v", err)
+		return nil, fiber.ErrInternalServerError
+	}
+
+	c.EventBus.Deliver(ctx, event)
+	if emailChanged {
+		c.sendVerification(ctx, user)
+	}
+
+	return response, nil
+}
+
+// VerifyEmail marks the email address of a user as verified with the token of a
+// verification email. A token only verifies the address it was sent to, and using
+// it again after the address was verified is harmless.
+func (c *UserUseCase) VerifyEmail(ctx context.Context, request *model.VerifyEmailRequest) (bool, error) {
+	ctx, span := tracing.Start(ctx, "UserUseCase.VerifyEmail")
+	defer span.End()
+
+	if err := c.Validate.Struct(request); err != nil {
+		c.Log.WithContext(ctx).Warnf("Invalid request : %+v", err)
+		return false, fiber.ErrBadRequest
+	}
+
+	claims, ok := c.parseVerification(request.Token)
+	if !ok {
+		c.Log.WithContext(ctx).Warnf("Invalid or expired verification token")
+		return false, fiber.NewError(fiber.StatusBadRequest, "invalid or expired token")
+	}
+
+	region, exists, err := c.findRegion(ctx, claims.UserId)
+	if err != nil {
+		c.Log.WithContext(ctx).Warnf("Failed find user by id : %+v", err)
+		return false, fiber.ErrInternalServerError
+	}
+	if !exists {
+		return false, fiber.NewError(fiber.StatusBadRequest, "invalid or expired token")
+	}
+
+	ctx = model.WithRegion(ctx, region)
+	tx := c.TxManager.Begin(ctx)
+	defer tx.Rollback()
+
+	user := new(entity.User)
+	if err := c.UserRepository.FindById(tx, user, claims.UserId); err != nil {
+		c.Log.WithContext(ctx).Warnf("Failed find user by id : %+v", err)
+		return false, fiber.ErrInternalServerError
+	}
+
+	if !strings.EqualFold(user.Email, claims.Email) {
+		c.Log.WithContext(ctx).Warnf("Verification token of user %s is for a previous email address", user.ID)
+		return false, fiber.NewError(fiber.StatusBadRequest, "invalid or expired token")
+	}
+
+	if user.VerifiedAt == nil {
+		verifiedAt := time.Now().UnixMilli()
+		user.VerifiedAt = &verifiedAt
+		if err := c.UserRepository.Update(tx, user); err != nil {
+			c.Log.WithContext(ctx).Warnf("Failed save user : %+v", err)
+			return false, fiber.ErrInternalServerError
+		}
+	}
+
+	if err := commit(ctx, tx); err != nil {
+		c.Log.WithContext(ctx).Warnf("Failed commit transaction : %+v", err)
+		return false, fiber.ErrInternalServerError
+	}
+
+	// verifying is no update event, forget the cached user here
+	c.Options.ReadCache.ForgetUser(ctx, user.ID)
+
+	return true, nil
+}
+
+// ResendVerification sends a new verification email to the user with the email
+// address, unless it is verified already. Like ForgotPassword, it answers the same
+// way whether such a user exists or not.
+func (c *UserUseCase) ResendVerification(ctx context.Context, request *model.ResendVerificationRequest) (bool, error) {
+	ctx, span := tracing.Start(ctx, "UserUseCase.ResendVerification")
+	defer span.End()
+
+	if err := c.Validate.Struct(request); err != nil {
+		c.Log.WithContext(ctx).Warnf("Invalid request body : %+v", err)
+		return false, fiber.ErrBadRequest
+	}
+
+	region, exists, err := c.findRegionOf(ctx, func(db *gorm.DB) (int64, error) {
+		return c.UserRepository.CountByEmail(db, request.Email, "")
+	})
+	if err != nil {
+		c.Log.WithContext(ctx).Warnf("Failed find user by email : %+v", err)
+		return false, fiber.ErrInternalServerError
+	}
+	if !exists {
+		return true, nil
+	}
+
+	ctx = model.WithRegion(ctx, region)
+	tx := c.TxManager.Begin(ctx)
+	defer tx.Rollback()
+
+	user := new(entity.User)
+	if err := c.UserRepository.FindByEmail(tx, user, request.Email); err != nil {
+		c.Log.WithContext(ctx).Warnf("Failed find user by email : %+v", err)
+		return false, fiber.ErrInternalServerError
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		c.Log.WithContext(ctx).Warnf("Failed commit transaction : %+v", err)
+		return false, fiber.ErrInternalServerError
+	}
+
+	if user.VerifiedAt == nil {
+		c.sendVerification(ctx, user)
+	}
+	return true, nil
+}
+
+// sendVerification emails user a link verifying their current email address, in the
+// background. Users without an email and dry runs get nothing.
+func (c *UserUseCase) sendVerification(ctx context.Context, user *entity.User) {
+	if user.Email == "" || model.IsDryRun(ctx) {
+		return
+	}
+
+	token, err := c.signVerification(user)
+	if err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to sign verification token")
+		return
+	}
+	go c.sendLink(context.WithoutCancel(ctx), user, "verification", c.Options.VerificationURL, token, c.Options.VerificationTTL)
+}
+
+// ForgotPassword emails a single-use password reset link to the user with the email
+// address. The answer is the same whether such a user exists or not, and the email
+// is sent in the background, so the endpoint does not reveal who has an account.
+func (c *UserUseCase) ForgotPassword(ctx context.Context, request *model.ForgotPasswordRequest) (bool, error) {
+	ctx, span := tracing.Start(ctx, "UserUseCase.ForgotPassword")
+	defer span.End()
+
+	if err := c.Validate.Struct(request); err != nil {
+		c.Log.WithContext(ctx).Warnf("Invalid request body : %+v", err)
+		return false, fiber.ErrBadRequest
+	}
+
+	region, exists, err := c.findRegionOf(ctx, func(db *gorm.DB) (int64, error) {
+		return c.UserRepository.CountByEmail(db, request.Email, "")
+	})
+	if err != nil {
+		c.Log.WithContext(ctx).Warnf("Failed find user by email : %+v", err)
+		return false, fiber.ErrInternalServerError
+	}
+	if !exists {
+		c.Log.WithContext(ctx).Infof("Password reset requested for an unknown email address")
+		return true, nil
+	}
+
+	ctx = model.WithRegion(ctx, region)
+	tx := c.TxManager.Begin(ctx)
+	defer tx.Rollback()
+
+	user := new(entity.User)
+	if err := c.UserRepository.FindByEmail(tx, user, request.Email); err != nil {
+		c.Log.WithContext(ctx).Warnf("Failed find user by email : %+v", err)
+		return false, fiber.ErrInternalServerError
+	}
+
+	token := c.newToken(user.Region)
+	reset := &entity.PasswordReset{
+		ID:        hashToken(token),
+		UserId:    user.ID,
+		ExpiresAt: time.Now().Add(c.Options.PasswordResetTTL).UnixMilli(),
+	}
+	if err := c.PasswordResetRepository.Create(tx, reset); err != nil {
+		c.Log.WithContext(ctx).Warnf("Failed create password reset : %+v", err)
+		return false, fiber.ErrInternalServerError
+	}
+
+	if err := commit(ctx, tx); err != nil {
+		c.Log.WithContext(ctx).Warnf("Failed commit transaction : %+v", err)
+		return false, fiber.ErrInternalServerError
+	}
+
+	if !model.IsDryRun(ctx) {
+		go c.sendLink(context.WithoutCancel(ctx), user, "reset", c.Options.PasswordResetURL, token, c.Options.PasswordResetTTL)
+	}
+
+	return true, nil
+}
+
+// SendMagicLink emails a single-use login link to the user with the email address.
+// Like ForgotPassword, the answer is the same whether such a user exists or not.
+func (c *UserUseCase) SendMagicLink(ctx context.Context, request *model.MagicLinkRequest) (bool, error) {
+	ctx, span := tracing.Start(ctx, "UserUseCase.SendMagicLink")
+	defer span.End()
+
+	if err := c.Validate.Struct(request); err != nil {
+		c.Log.WithContext(ctx).Warnf("Invalid request body : %+v", err)
+		return false, fiber.ErrBadRequest
+	}
+
+	region, exists, err := c.findRegionOf(ctx, func(db *gorm.DB) (int64, error) {
+		return c.UserRepository.CountByEmail(db, request.Email, "")
+	})
+	if err != nil {
+		c.Log.WithContext(ctx).Warnf("Failed find user by email : %+v", err)
+		return false, fiber.ErrInternalServerError
+	}
+	if !exists {
+		c.Log.WithContext(ctx).Infof("Magic link requested for an unknown email address")
+		return true, nil
+	}
+
+	ctx = model.WithRegion(ctx, region)
+	tx := c.TxManager.Begin(ctx)
+	defer tx.Rollback()
+
+	user := new(entity.User)
+	if err := c.UserRepository.FindByEmail(tx, user, request.Email); err != nil {
+		c.Log.WithContext(ctx).Warnf("Failed find user by email : %+v", err)
+		return false, fiber.ErrInternalServerError
+	}
+
+	if user.DeletedAt != nil {
+		c.Log.WithContext(ctx).Infof("Magic link requested for deleted user %s", user.ID)
+		return true, nil
+	}
+
+	token := c.newToken(user.Region)
+	link := &entity.MagicLink{
+		ID:        hashToken(token),
+		UserId:    user.ID,
+		ExpiresAt: time.Now().Add(c.Options.MagicLinkTTL).UnixMilli(),
+	}
+	if err := c.MagicLinkRepository.Create(tx, link); err != nil {
+		c.Log.WithContext(ctx).Warnf("Failed create magic link : %+v", err)
+		return false, fiber.ErrInternalServerError
+	}
+
+	if err := commit(ctx, tx); err != nil {
+		c.Log.WithContext(ctx).Warnf("Failed commit transaction : %+v", err)
+		return false, fiber.ErrInternalServerError
+	}
+
+	if !model.IsDryRun(ctx) {
+		go c.sendLink(context.WithoutCancel(ctx), user, "magic_link", c.Options.MagicLinkURL, token, c.Options.MagicLinkTTL)
+	}
+
+	return true, nil
+}
+
+// ExchangeMagicLink logs the user in with the token of a magic link email. Using a link
+// uses up every pending link of the user, and proves the email address, so it also
+// verifies it.
+func (c *UserUseCase) ExchangeMagicLink(ctx context.Context, request *model.ExchangeMagicLinkRequest) (_ *model.UserResponse, err error) {
+	ctx, span := tracing.Start(ctx, "UserUseCase.ExchangeMagicLink")
+	defer span.End()
+
+	defer func() { metrics.Logins.WithLabelValues(metrics.Outcome(err)).Inc() }()
+
+	if err := c.Validate.Struct(request); err != nil {
+		c.Log.WithContext(ctx).Warnf("Invalid request body : %+v", err)
+		return nil, fiber.ErrBadRequest
+	}
+
+	region, ok := tokenRegion(c.Regions, request.Token)
+	if !ok {
+		c.Log.WithContext(ctx).Warnf("Magic link token of unknown region")
+		return nil, fiber.ErrUnauthorized
+	}
+
+	ctx = model.WithRegion(ctx, region)
+	tx := c.TxManager.Begin(ctx)
+	defer tx.Rollback()
+
+	link := new(entity.MagicLink)
+	if err := c.MagicLinkRepository.FindUnexpiredByIdForUpdate(tx, link, hashToken(request.Token), time.Now().UnixMilli()); err != nil {
+		c.Log.WithContext(ctx).Warnf("Failed find magic link : %+v", err)
+		return nil, fiber.ErrUnauthorized
+	}
+
+	user := new(entity.User)
+	if err := c.UserRepository.FindById(tx, user, link.UserId); err != nil {
+		c.Log.WithContext(ctx).Warnf("Failed find user by id : %+v", err)
+		return nil, fiber.ErrUnauthorized
+	}
+
+	defer func() {
+		c.LoginEvents.Record(ctx, user.ID, model.LoginMethodMagicLink, request.IP, request.Device, request.UserAgent, err)
+	}()
+
+	if user.DeletedAt != nil {
+		c.Log.WithContext(ctx).Warnf("Deleted user %s tried to log in", user.ID)
+		return nil, fiber.ErrUnauthorized
+	}
+
+	if err := c.MagicLinkRepository.DeleteByUserId(tx, user.ID); err != nil {
+		c.Log.WithContext(ctx).Warnf("Failed delete magic links : %+v", err)
+		return nil, fiber.ErrInternalServerError
+	}
+
+	if user.VerifiedAt == nil {
+		now := time.Now().UnixMilli()
+		user.VerifiedAt = &now
+	}
+
+	session, err := c.startSession(tx, user, request.Device, request.IP, request.UserAgent, request.Scopes)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		c.Log.WithContext(ctx).Warnf("Failed commit transaction : %+v", err)
+		return nil, fiber.ErrInternalServerError
+	}
+
+	// the link may have verified the email address
+	c.Options.ReadCache.ForgetUser(ctx, user.ID)
+
+	return c.tokenResponse(user, session)
+}
+
+// sendLink emails user the template name with a link to baseURL carrying token,
+// valid for ttl. It runs in the background, so failures are only logged.
+func (c *UserUseCase) sendLink(ctx context.Context, user *entity.User, name string, baseURL string, token string, ttl time.Duration) {
+	link, err := url.Parse(baseURL)
+	if err != nil {
+		c.Log.WithContext(ctx).WithError(err).Errorf("failed to parse %s link url", name)
+		return
+	}
+	query := link.Query()
+	query.Set("token", token)
+	link.RawQuery = query.Encode()
+
+	message, err := c.EmailTemplateUseCase.Render(ctx, "", name, map[string]any{
+		"Name":      user.Name,
+		"Link":      link.String(),
+		"ExpiresIn": formatDuration(ttl),
+	})
+	if err != nil {
+		c.Log.WithContext(ctx).WithError(err).Errorf("failed to render %s email", name)
+		return
+	}
+
+	if err := c.MailSender.Send(ctx, user.Email, message); err != nil {
+		c.Log.WithContext(ctx).WithError(err).Errorf("Failed to send %s email to user %s", name, user.ID)
+	}
+}
+
+// ResetPassword sets a new password with a token from a password reset email. Every
+// pending reset of the user is used up and the user is signed out everywhere.
+func (c *UserUseCase) ResetPassword(ctx context.Context, request *model.ResetPasswordRequest) (bool, error) {
+	ctx, span := tracing.Start(ctx, "UserUseCase.ResetPassword")
+	defer span.End()
+
+	if err := c.Validate.Struct(request); err != nil {
+		c.Log.WithContext(ctx).Warnf("Invalid request body : %+v", err)
+		return false, fiber.ErrBadRequest
+	}
+
+	if err := c.Options.PasswordPolicy.Check(request.Password); err != nil {
+		return false, fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+
+	region, ok := tokenRegion(c.Regions, request.Token)
+	if !ok {
+		c.Log.WithContext(ctx).Warnf("Password reset token of unknown region")
+		return false, fiber.NewError(fiber.StatusBadRequest, "invalid or expired token")
+	}
+
+	ctx = model.WithRegion(ctx, region)
+	tx := c.TxManager.Begin(ctx)
+	defer tx.Rollback()
+
+	reset := new(entity.PasswordReset)
+	if err := c.PasswordResetRepository.FindUnexpiredByIdForUpdate(tx, reset, hashToken(request.Token), time.Now().UnixMilli()); err != nil {
+		c.Log.WithContext(ctx).Warnf("Failed find password reset : %+v", err)
+		return false, fiber.NewError(fiber.StatusBadRequest, "invalid or expired token")
+	}
+
+	user := new(entity.User)
+	if err := c.UserRepository.FindById(tx, user, reset.UserId); err != nil {
+		c.Log.WithContext(ctx).Warnf("Failed find user by id : %+v", err)
+		return false, fiber.NewError(fiber.StatusBadRequest, "invalid or expired token")
+	}
+
+	if user.DeletedAt != nil {
+		c.Log.WithContext(ctx).Warnf("Deleted user %s tried to reset their password", user.ID)
+		return false, fiber.NewError(fiber.StatusBadRequest, "invalid or expired token")
+	}
+
+	hash, err := c.Options.PasswordHasher.Hash(request.Password)
+	if err != nil {
+		c.Log.WithContext(ctx).Warnf("Failed to hash password : %+v", err)
+		return false, fiber.ErrInternalServerError
+	}
+	user.Password = hash
+	user.Token = ""
+	user.RefreshToken = ""
+
+	if err := c.UserRepository.Update(tx, user); err != nil {
+		c.Log.WithContext(ctx).Warnf("Failed save user : %+v", err)
+		return false, fiber.ErrInternalServerError
+	}
+
+	if err := c.PasswordResetRepository.DeleteByUserId(tx, user.ID); err != nil {
+		c.Log.WithContext(ctx).Warnf("Failed delete password resets : %+v", err)
+		return false, fiber.ErrInternalServerError
+	}
+
+	sessionIds, err := c.deleteSessions(tx, user.ID)
+	if err != nil {
+		c.Log.WithContext(ctx).Warnf("Failed delete sessions : %+v", err)
+		return false, fiber.ErrInternalServerError
+	}
+
+	if err := commit(ctx, tx); err != nil {
+		c.Log.WithContext(ctx).Warnf("Failed commit transaction : %+v", err)
+		return false, fiber.ErrInternalServerError
+	}
+
+	c.revokeAccessTokens(ctx, sessionIds...)
+	return true, nil
+}
+
+// startSession creates a new session of user and saves the user with its tokens.
+func (c *UserUseCase) startSession(tx *gorm.DB, user *entity.User, device string, ip string, userAgent string, scopes []string) (*entity.Session, error) {
+	if device == "" {
+		device = describeDevice(userAgent)
+	}
+	session := &entity.Session{
+		ID:           uuid.NewString(),
+		UserId:       user.ID,
+		Token:        c.newToken(user.Region),
+		RefreshToken: c.newToken(user.Region),
+		Device:       device,
+		IP:           ip,
+		UserAgent:    truncate(userAgent, 500),
+		Scopes:       strings.Join(scopes, " "),
+		LastSeenAt:   time.Now().UnixMilli(),
+	}
+	if err := c.SessionRepository.Create(tx, session); err != nil {
+		c.Log.WithContext(tx.Statement.Context).Warnf("Failed create session : %+v", err)
+		return nil, fiber.ErrInternalServerError
+	}
+
+	// the user keeps the tokens of the latest session for clients reading them from there
+	user.Token = session.Token
+	user.RefreshToken = session.RefreshToken
+	if err := c.UserRepository.Update(tx, user); err != nil {
+		c.Log.WithContext(tx.Statement.Context).Warnf("Failed save user : %+v", err)
+		return nil, fiber.ErrInternalServerError
+	}
+
+	return session, nil
+}
+
+// tokenResponse returns the tokens of session. With JWT access tokens configured the
+// access token is a JWT naming the session, otherwise the opaque token of the session.
+func (c *UserUseCase) tokenResponse(user *entity.User, session *entity.Session) (*model.UserResponse, error) {
+	response := converter.UserToTokenResponse(user)
+	if c.Options.AccessTokens == nil {
+		return response, nil
+	}
+
+	token, err := c.Options.AccessTokens.Issue(jwt.Claims{
+		Subject:   user.ID,
+		Role:      user.Role,
+		Region:    user.Region,
+		SessionId: session.ID,
+		Scope:     session.Scopes,
+	}, time.Now())
+	if err != nil {
+		c.Log.Warnf("Failed sign access token : %+v", err)
+		return nil, fiber.ErrInternalServerError
+	}
+	response.Token = token
+	return response, nil
+}
+
+// deleteSessions deletes every session of a user and returns their IDs, so their access
+// tokens can be revoked once the deletion is committed.
+func (c *UserUseCase) deleteSessions(tx *gorm.DB, userId string) ([]string, error) {
+	sessions, err := c.SessionRepository.FindAllByUserId(tx, userId)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.SessionRepository.DeleteByUserId(tx, userId); err != nil {
+		return nil, err
+	}
+
+	ids := make([]string, len(sessions))
+	for i, session := range sessions {
+		ids[i] = session.ID
+	}
+	return ids, nil
+}
+
+// revokeAccessTokens adds deleted sessions to the revocation list for as long as their
+// JWT access tokens could still be valid. The sessions are already gone, so failing
+// to revoke them only leaves their access tokens working until they expire.
+func (c *UserUseCase) revokeAccessTokens(ctx context.Context, sessionIds ...string) {
+	if c.Options.AccessTokens == nil || c.Options.Revocations == nil || model.IsDryRun(ctx) {
+		return
+	}
+	// a revoked session stays listed for as long as any of its tokens can be valid
+	ttl := max(c.Options.AccessTokens.TTL, c.Options.ImpersonationTTL)
+	if err := c.Options.Revocations.Revoke(ctx, ttl, sessionIds...); err != nil {
+		c.Log.WithContext(ctx).Warnf("Failed revoke access tokens : %+v", err)
+	}
+}
+
+// checkEmailAvailable refuses an email address another user already has, in any region.
+func (c *UserUseCase) checkEmailAvailable(ctx context.Context, email string, userId string) error {
+	_, taken, err := c.findRegionOf(ctx, func(db *gorm.DB) (int64, error) {
+		return c.UserRepository.CountByEmail(db, email, userId)
+	})
+	if err != nil {
+		c.Log.WithContext(ctx).Warnf("Failed count user by email : %+v", err)
+		return fiber.ErrInternalServerError
+	}
+	if taken {
+		c.Log.WithContext(ctx).Warnf("Email already in use")
+		return fiber.NewError(fiber.StatusConflict, "email already in use")
+	}
+	return nil
+}
+
+// homeRegion resolves the region a new user asked for among regions, "" being the
+// home region.
+func homeRegion(regions []string, region string) (string, bool) {
+	if region == "" || (len(regions) > 0 && region == regions[0]) {
+		return "", true
+	}
+	return region, slices.Contains(regions, region)
+}
+
+// findRegion returns the region holding the user with the given ID.
+func (c *UserUseCase) findRegion(ctx context.Context, id string) (string, bool, error) {
+	return c.findRegionOf(ctx, func(db *gorm.DB) (int64, error) {
+		return c.UserRepository.CountById(db, id)
+	})
+}
+
+// findRegionOf returns the first region where count finds a user.
+func (c *UserUseCase) findRegionOf(ctx context.Context, count func(db *gorm.DB) (int64, error)) (string, bool, error) {
+	regions := []string{""}
+	if len(c.Regions) > 0 {
+		regions = append(regions, c.Regions[1:]...)
+	}
+
+	for _, region := range regions {
+		total, err := count(c.TxManager.DB(model.WithRegion(ctx, region)))
+		if err != nil {
+			return "", false, err
+		}
+		if total > 0 {
+			return region, true, nil
+		}
+	}
+	return "", false, nil
+}
+
+// newToken issues a token for a user of region. Tokens outside of the home region
+// are prefixed with it, so the request can be routed before the user is known.
+func (c *UserUseCase) newToken(region string) string {
+	if region == "" {
+		return uuid.NewString()
+	}
+	return region + "." + uuid.NewString()
+}
+
+// scopesOf returns the scopes of a session or API key stored space separated, nil when
+// it is not limited.
+func scopesOf(scopes string) []string {
+	if scopes == "" {
+		return nil
+	}
+	return strings.Fields(scopes)
+}
+
+// tokenRegion returns the region a token or API key was issued in, or false for a
+// region that is not among regions.
+func tokenRegion(regions []string, token string) (string, bool) {
+	region, _, found := strings.Cut(token, ".")
+	if !found || len(regions) == 0 {
+		return "", true
+	}
+	return homeRegion(regions, region)
+}
+
+// describeDevice names the browser and system of a user agent, e.g. "Firefox on
+// Windows", or returns "" when it recognises neither.
+func describeDevice(userAgent string) string {
+	browser := ""
+	for _, candidate := range []struct{ token, name string }{
+		{"Edg/", "Edge"},
+		{"OPR/", "Opera"},
+		{"Firefox/", "Firefox"},
+		{"Chrome/", "Chrome"},
+		{"Safari/", "Safari"},
+	} {
+		if strings.Contains(userAgent, candidate.token) {
+			browser = candidate.name
+			break
+		}
+	}
+
+	system := ""
+	for _, candidate := range []struct{ token, name string }{
+		{"Android", "Android"},
+		{"iPhone", "iOS"},
+		{"iPad", "iPadOS"},
+		{"Windows", "Windows"},
+		{"Mac OS X", "macOS"},
+		{"Linux", "Linux"},
+	} {
+		if strings.Contains(userAgent, candidate.token) {
+			system = candidate.name
+			break
+		}
+	}
+
+	switch {
+	case browser != "" && system != "":
+		return browser + " on " + system
+	case browser != "":
+		return browser
+	default:
+		return system
+	}
+}
+
+// truncate cuts value to at most max bytes without splitting a character.
+func truncate(value string, max int) string {
+	if len(value) <= max {
+		return value
+	}
+	for max > 0 && !utf8.RuneStart(value[max]) {
+		max--
+	}
+	return value[:max]
+}
+
+// hashToken is how single-use tokens are stored, so a leaked table holds no usable token.
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// formatDuration writes a duration for an email, e.g. "1 hour" or "30 minutes".
+func formatDuration(duration time.Duration) string {
+	amount, unit := int64(duration/time.Minute), "minute"
+	switch {
+	case duration >= 24*time.Hour && duration%(24*time.Hour) == 0:
+		amount, unit = int64(duration/(24*time.Hour)), "day"
+	case duration >= time.Hour && duration%time.Hour == 0:
+		amount, unit = int64(duration/time.Hour), "hour"
+	}
+	if amount != 1 {
+		unit += "s"
+	}
+	return strconv.FormatInt(amount, 10) + " " + unit
+}
+
+// verificationClaims is the signed content of a verification token.
+type verificationClaims struct {
+	UserId    string `json:"sub"`
+	Email     string `json:"email"`
+	ExpiresAt int64  `json:"exp"`
+}
+
+// signVerification issues a token proving that whoever holds it received an email at
+// the current address of user. It is HMAC-signed instead of stored.
+func (c *UserUseCase) signVerification(user *entity.User) (string, error) {
+	payload, err := json.Marshal(verificationClaims{
+		UserId:    user.ID,
+		Email:     user.Email,
+		ExpiresAt: time.Now().Add(c.Options.VerificationTTL).Unix(),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	encoded := base64.RawURLEncoding.EncodeToString(payload)
+	return encoded + "." + c.verificationSignature(encoded), nil
+}
+
+// parseVerification returns the claims of a token with a valid signature that has
+// not expired.
+func (c *UserUseCase) parseVerification(token string) (*verificationClaims, bool) {
+	encoded, signature, found := strings.Cut(token, ".")
+	if !found || !hmac.Equal([]byte(signature), []byte(c.verificationSignature(encoded))) {
+		return nil, false
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, false
+	}
+
+	claims := new(verificationClaims)
+	if err := json.Unmarshal(payload, claims); err != nil || time.Now().Unix() > claims.ExpiresAt {
+		return nil, false
+	}
+	return claims, true
+}
+
+func (c *UserUseCase) verificationSignature(encoded string) string {
+	mac := hmac.New(sha256.New, c.Options.VerificationSecret)
+	mac.Write([]byte(encoded))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
 }
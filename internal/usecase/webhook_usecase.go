@@ -0,0 +1,572 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"go-rest-scaffold/internal/entity"
+	"go-rest-scaffold/internal/gateway/webhook"
+	"go-rest-scaffold/internal/idgen"
+	"go-rest-scaffold/internal/metrics"
+	"go-rest-scaffold/internal/model"
+	"go-rest-scaffold/internal/model/converter"
+	"go-rest-scaffold/internal/repository"
+	"go-rest-scaffold/internal/tracing"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"github.com/sirupsen/logrus"
+)
+
+// webhookRetryBatchSize is how many due retries a run of the retry job sends.
+const webhookRetryBatchSize = 100
+
+// WebhookOptions controls webhook deliveries.
+type WebhookOptions struct {
+	// ReplayLimit caps how many events a single replay of failures re-sends.
+	ReplayLimit int
+	// MaxAttempts is how many times an event is sent before its retries give up.
+	MaxAttempts int
+	// RetryBackoff is the wait before the first retry, doubled for every retry after.
+	RetryBackoff time.Duration
+	// MaxRetryBackoff caps the wait between two retries.
+	MaxRetryBackoff time.Duration
+}
+
+// WebhookUseCase manages the webhooks of users and delivers the events of their
+// account to them, signed with the secret of the webhook. Every attempt is stored, so
+// failed deliveries can be inspected and replayed, and failed ones are retried with
+// exponential backoff.
+type WebhookUseCase struct {
+	TxManager                 TxManager
+	Log                       *logrus.Logger
+	Validate                  *validator.Validate
+	WebhookRepository         *repository.WebhookRepository
+	WebhookDeliveryRepository *repository.WebhookDeliveryRepository
+	Sender                    *webhook.Sender
+	AuditLog                  *AuditLogUseCase
+	IDs                       *idgen.Generators
+	Options                   WebhookOptions
+}
+
+func NewWebhookUseCase(txManager TxManager, logger *logrus.Logger, validate *validator.Validate, webhookRepository *repository.WebhookRepository,
+	webhookDeliveryRepository *repository.WebhookDeliveryRepository, sender *webhook.Sender, auditLog *AuditLogUseCase, ids *idgen.Generators,
+	options WebhookOptions) *WebhookUseCase {
+	return &WebhookUseCase{
+		TxManager:                 txManager,
+		Log:                       logger,
+		Validate:                  validate,
+		WebhookRepository:         webhookRepository,
+		WebhookDeliveryRepository: webhookDeliveryRepository,
+		Sender:                    sender,
+		AuditLog:                  auditLog,
+		IDs:                       ids,
+		Options:                   options,
+	}
+}
+
+func (c *WebhookUseCase) Create(ctx context.Context, request *model.CreateWebhookRequest) (*model.WebhookResponse, error) {
+	ctx, span := tracing.Start(ctx, "WebhookUseCase.Create")
+	defer span.End()
+
+	tx := c.TxManager.Begin(ctx)
+	defer tx.Rollback()
+
+	if err := c.Validate.Struct(request); err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to validate request body")
+		return nil, err
+	}
+
+	id, err := c.IDs.NewID(ctx, idgen.Webhook)
+	if err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to generate webhook id")
+		return nil, fiber.ErrInternalServerError
+	}
+
+	secret, err := webhook.NewSecret()
+	if err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to generate webhook secret")
+		return nil, fiber.ErrInternalServerError
+	}
+
+	hook := &entity.Webhook{
+		ID:         id,
+		UserId:     request.UserId,
+		URL:        request.URL,
+		Secret:     secret,
+		EventTypes: strings.Join(request.EventTypes, ","),
+		Active:     true,
+	}
+
+	if err := c.WebhookRepository.Create(tx, hook); err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to create webhook")
+		return nil, fiber.ErrInternalServerError
+	}
+
+	if err := c.AuditLog.Record(ctx, tx, model.AuditCreate, model.AuditWebhook, hook.ID, nil, converter.WebhookToResponse(hook)); err != nil {
+		return nil, err
+	}
+
+	if err := commit(ctx, tx); err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to commit transaction")
+		return nil, fiber.ErrInternalServerError
+	}
+
+	response := converter.WebhookToResponse(hook)
+	response.Secret = hook.Secret
+	return response, nil
+}
+
+// RotateSecret gives the webhook a new secret, which signs its deliveries from then
+// on, and returns it. The old secret stops working at once.
+func (c *WebhookUseCase) RotateSecret(ctx context.Context, request *model.RotateWebhookSecretRequest) (*model.WebhookResponse, error) {
+	ctx, span := tracing.Start(ctx, "WebhookUseCase.RotateSecret")
+	defer span.End()
+
+	tx := c.TxManager.Begin(ctx)
+	defer tx.Rollback()
+
+	if err := c.Validate.Struct(request); err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to validate request body")
+		return nil, fiber.ErrBadRequest
+	}
+
+	hook := new(entity.Webhook)
+	if err := c.WebhookRepository.FindByIdAndUserId(tx, hook, request.ID, request.UserId); err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to find webhook")
+		return nil, fiber.ErrNotFound
+	}
+
+	secret, err := webhook.NewSecret()
+	if err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to generate webhook secret")
+		return nil, fiber.ErrInternalServerError
+	}
+	before := converter.WebhookToResponse(hook)
+	hook.Secret = secret
+
+	if err := c.WebhookRepository.Update(tx, hook); err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to update webhook")
+		return nil, fiber.ErrInternalServerError
+	}
+
+	// the log records that the secret changed, never the secret
+	if err := c.AuditLog.Record(ctx, tx, model.AuditUpdate, model.AuditWebhook, hook.ID, before, converter.WebhookToResponse(hook)); err != nil {
+		return nil, err
+	}
+
+	if err := commit(ctx, tx); err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to commit transaction")
+		return nil, fiber.ErrInternalServerError
+	}
+
+	response := converter.WebhookToResponse(hook)
+	response.Secret = hook.Secret
+	return response, nil
+}
+
+func (c *WebhookUseCase) Update(ctx context.Context, request *model.UpdateWebhookRequest) (*model.WebhookResponse, error) {
+	ctx, span := tracing.Start(ctx, "WebhookUseCase.Update")
+	defer span.End()
+
+	tx := c.TxManager.Begin(ctx)
+	defer tx.Rollback()
+
+	if err := c.Validate.Struct(request); err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to validate request body")
+		return nil, err
+	}
+
+	webhook := new(entity.Webhook)
+	if err := c.WebhookRepository.FindByIdAndUserId(tx, webhook, request.ID, request.UserId); err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to find webhook")
+		return nil, fiber.ErrNotFound
+	}
+
+	before := converter.WebhookToResponse(webhook)
+	webhook.URL = request.URL
+	webhook.EventTypes = strings.Join(request.EventTypes, ",")
+	webhook.Active = request.Active
+
+	if err := c.WebhookRepository.Update(tx, webhook); err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to update webhook")
+		return nil, fiber.ErrInternalServerError
+	}
+
+	if err := c.AuditLog.Record(ctx, tx, model.AuditUpdate, model.AuditWebhook, webhook.ID, before, converter.WebhookToResponse(webhook)); err != nil {
+		return nil, err
+	}
+
+	if err := commit(ctx, tx); err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to commit transaction")
+		return nil, fiber.ErrInternalServerError
+	}
+
+	return converter.WebhookToResponse(webhook), nil
+}
+
+func (c *WebhookUseCase) Get(ctx context.Context, request *model.GetWebhookRequest) (*model.WebhookResponse, error) {
+	ctx, span := tracing.Start(ctx, "WebhookUseCase.Get")
+	defer span.End()
+
+	tx := c.TxManager.Begin(ctx)
+	defer tx.Rollback()
+
+	if err := c.Validate.Struct(request); err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to validate request body")
+		return nil, fiber.ErrBadRequest
+	}
+
+	webhook := new(entity.Webhook)
+	if err := c.WebhookRepository.FindByIdAndUserId(tx, webhook, request.ID, request.UserId); err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to find webhook")
+		return nil, fiber.ErrNotFound
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to commit transaction")
+		return nil, fiber.ErrInternalServerError
+	}
+
+	return converter.WebhookToResponse(webhook), nil
+}
+
+func (c *WebhookUseCase) Delete(ctx context.Context, request *model.DeleteWebhookRequest) error {
+	ctx, span := tracing.Start(ctx, "WebhookUseCase.Delete")
+	defer span.End()
+
+	tx := c.TxManager.Begin(ctx)
+	defer tx.Rollback()
+
+	if err := c.Validate.Struct(request); err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to validate request body")
+		return fiber.ErrBadRequest
+	}
+
+	webhook := new(entity.Webhook)
+	if err := c.WebhookRepository.FindByIdAndUserId(tx, webhook, request.ID, request.UserId); err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to find webhook")
+		return fiber.ErrNotFound
+	}
+
+	if err := c.WebhookRepository.Delete(tx, webhook); err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to delete webhook")
+		return fiber.ErrInternalServerError
+	}
+
+	if err := c.AuditLog.Record(ctx, tx, model.AuditDelete, model.AuditWebhook, webhook.ID, converter.WebhookToResponse(webhook), nil); err != nil {
+		return err
+	}
+
+	if err := commit(ctx, tx); err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to commit transaction")
+		return fiber.ErrInternalServerError
+	}
+
+	return nil
+}
+
+func (c *WebhookUseCase) List(ctx context.Context, request *model.ListWebhookRequest) ([]model.WebhookResponse, error) {
+	ctx, span := tracing.Start(ctx, "WebhookUseCase.List")
+	defer span.End()
+
+	tx := c.TxManager.Begin(ctx)
+	defer tx.Rollback()
+
+	if err := c.Validate.Struct(request); err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to validate request body")
+		return nil, fiber.ErrBadRequest
+	}
+
+	webhooks, err := c.WebhookRepository.FindAllByUserId(tx, request.UserId)
+	if err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to find webhooks")
+		return nil, fiber.ErrInternalServerError
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to commit transaction")
+		return nil, fiber.ErrInternalServerError
+	}
+
+	responses := make([]model.WebhookResponse, len(webhooks))
+	for i, webhook := range webhooks {
+		responses[i] = *converter.WebhookToResponse(&webhook)
+	}
+
+	return responses, nil
+}
+
+func (c *WebhookUseCase) SearchDeliveries(ctx context.Context, request *model.SearchWebhookDeliveryRequest) ([]model.WebhookDeliveryResponse, int64, error) {
+	ctx, span := tracing.Start(ctx, "WebhookUseCase.SearchDeliveries")
+	defer span.End()
+
+	tx := c.TxManager.Begin(ctx)
+	defer tx.Rollback()
+
+	if err := c.Validate.Struct(request); err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to validate request body")
+		return nil, 0, err
+	}
+
+	webhook := new(entity.Webhook)
+	if err := c.WebhookRepository.FindByIdAndUserId(tx, webhook, request.WebhookId, request.UserId); err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to find webhook")
+		return nil, 0, fiber.ErrNotFound
+	}
+
+	deliveries, total, err := c.WebhookDeliveryRepository.Search(tx, request)
+	if err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to search webhook deliveries")
+		return nil, 0, fiber.ErrInternalServerError
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to commit transaction")
+		return nil, 0, fiber.ErrInternalServerError
+	}
+
+	responses := make([]model.WebhookDeliveryResponse, len(deliveries))
+	for i, delivery := range deliveries {
+		responses[i] = *converter.WebhookDeliveryToResponse(&delivery)
+	}
+
+	return responses, total, nil
+}
+
+// Replay sends the event of a delivery again, whatever the outcome of the original, and returns the new attempt.
+func (c *WebhookUseCase) Replay(ctx context.Context, request *model.ReplayWebhookDeliveryRequest) (*model.WebhookDeliveryResponse, error) {
+	ctx, span := tracing.Start(ctx, "WebhookUseCase.Replay")
+	defer span.End()
+
+	tx := c.TxManager.Begin(ctx)
+	defer tx.Rollback()
+
+	if err := c.Validate.Struct(request); err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to validate request body")
+		return nil, fiber.ErrBadRequest
+	}
+
+	webhook := new(entity.Webhook)
+	if err := c.WebhookRepository.FindByIdAndUserId(tx, webhook, request.WebhookId, request.UserId); err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to find webhook")
+		return nil, fiber.ErrNotFound
+	}
+
+	original := new(entity.WebhookDelivery)
+	if err := c.WebhookDeliveryRepository.FindByIdAndWebhookId(tx, original, request.ID, webhook.ID); err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to find webhook delivery")
+		return nil, fiber.ErrNotFound
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to commit transaction")
+		return nil, fiber.ErrInternalServerError
+	}
+
+	delivery, err := c.deliver(ctx, webhook, original.EventId, original.EventType, []byte(original.Payload), original.ID)
+	if err != nil {
+		return nil, fiber.ErrInternalServerError
+	}
+
+	return converter.WebhookDeliveryToResponse(delivery), nil
+}
+
+// ReplayFailed re-sends, in the background, every event that failed to reach the
+// webhook in the time window, and returns how many events are being replayed.
+func (c *WebhookUseCase) ReplayFailed(ctx context.Context, request *model.ReplayFailedWebhookDeliveriesRequest) (*model.ReplayFailedWebhookDeliveriesResponse, error) {
+	ctx, span := tracing.Start(ctx, "WebhookUseCase.ReplayFailed")
+	defer span.End()
+
+	tx := c.TxManager.Begin(ctx)
+	defer tx.Rollback()
+
+	if err := c.Validate.Struct(request); err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to validate request body")
+		return nil, err
+	}
+
+	webhook := new(entity.Webhook)
+	if err := c.WebhookRepository.FindByIdAndUserId(tx, webhook, request.WebhookId, request.UserId); err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to find webhook")
+		return nil, fiber.ErrNotFound
+	}
+
+	failures, err := c.WebhookDeliveryRepository.FindUndeliveredFailures(tx, webhook.ID, request.From, request.To, c.Options.ReplayLimit)
+	if err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to find failed webhook deliveries")
+		return nil, fiber.ErrInternalServerError
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to commit transaction")
+		return nil, fiber.ErrInternalServerError
+	}
+
+	go func() {
+		ctx := context.WithoutCancel(ctx)
+		for _, failure := range failures {
+			// deliver logs its own failures, keep going with the next event
+			_, _ = c.deliver(ctx, webhook, failure.EventId, failure.EventType, []byte(failure.Payload), failure.ID)
+		}
+	}()
+
+	return &model.ReplayFailedWebhookDeliveriesResponse{Replayed: len(failures)}, nil
+}
+
+// Handle is the event bus subscriber delivering an event to the active webhooks of
+// its user. Deliveries run in the background so the write that published the event
+// does not wait on remote endpoints.
+func (c *WebhookUseCase) Handle(ctx context.Context, event *model.CloudEvent) {
+	ctx, span := tracing.Start(ctx, "WebhookUseCase.Handle")
+	defer span.End()
+
+	if event.UserId == "" {
+		return
+	}
+
+	payload, err := json.Marshal(event)
+	if err != nil {
+		c.Log.WithContext(ctx).WithError(err).Errorf("Failed to marshal event %s", event.ID)
+		return
+	}
+
+	go func() {
+		ctx := context.WithoutCancel(ctx)
+
+		webhooks, err := c.WebhookRepository.FindActiveByUserId(c.TxManager.DB(ctx), event.UserId)
+		if err != nil {
+			c.Log.WithContext(ctx).WithError(err).Error("failed to find webhooks")
+			return
+		}
+
+		for _, webhook := range webhooks {
+			if webhook.EventTypes != "" && !slices.Contains(strings.Split(webhook.EventTypes, ","), event.Type) {
+				continue
+			}
+			_, _ = c.deliver(ctx, &webhook, event.ID, event.Type, payload, "")
+		}
+	}()
+}
+
+// RetryDue is the webhook-retry job: it sends again the events whose failed attempt
+// is due for a retry, unless the event was delivered meanwhile or the webhook was
+// turned off, and returns how many it sent.
+func (c *WebhookUseCase) RetryDue(ctx context.Context) (int64, error) {
+	ctx, span := tracing.Start(ctx, "WebhookUseCase.RetryDue")
+	defer span.End()
+
+	// the retries due are read where the attempts are stored
+	db := c.TxManager.DB(model.WithPrimary(ctx))
+	due, err := c.WebhookDeliveryRepository.FindDueRetries(db, time.Now().UnixMilli(), webhookRetryBatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	var retried int64
+	for _, failed := range due {
+		if ctx.Err() != nil {
+			break
+		}
+
+		claimed, err := c.WebhookDeliveryRepository.ClaimRetry(db, &failed)
+		if err != nil {
+			return retried, err
+		}
+		if !claimed {
+			continue
+		}
+
+		hook := new(entity.Webhook)
+		if err := c.WebhookRepository.FindById(db, hook, failed.WebhookId); err != nil || !hook.Active {
+			continue
+		}
+		// a replay may have delivered the event since
+		if succeeded, err := c.WebhookDeliveryRepository.CountSucceeded(db, hook.ID, failed.EventId); err != nil || succeeded > 0 {
+			continue
+		}
+
+		// deliver logs its own failures and schedules the next retry
+		if _, err := c.deliver(ctx, hook, failed.EventId, failed.EventType, []byte(failed.Payload), ""); err == nil {
+			retried++
+		}
+	}
+	return retried, nil
+}
+
+// retryAt returns when the attempt-th failed attempt to send an event is retried,
+// nil once MaxAttempts were made.
+func (c *WebhookUseCase) retryAt(attempt int, failedAt time.Time) *int64 {
+	if attempt >= c.Options.MaxAttempts {
+		return nil
+	}
+
+	backoff := c.Options.RetryBackoff
+	for i := 1; i < attempt && backoff < c.Options.MaxRetryBackoff; i++ {
+		backoff *= 2
+	}
+	backoff = min(backoff, c.Options.MaxRetryBackoff)
+
+	retryAt := failedAt.Add(backoff).UnixMilli()
+	return &retryAt
+}
+
+// deliver makes one attempt to send an event and stores its outcome. A failed
+// attempt is retried later, unless it is a replay, which the user retries.
+func (c *WebhookUseCase) deliver(ctx context.Context, webhook *entity.Webhook, eventId string, eventType string, payload []byte, replayOf string) (*entity.WebhookDelivery, error) {
+	result := c.Sender.Send(ctx, webhook.URL, webhook.Secret, eventId, eventType, payload)
+
+	status := model.WebhookDeliverySucceeded
+	if !result.Succeeded() {
+		status = model.WebhookDeliveryFailed
+	}
+	metrics.WebhookDeliveries.WithLabelValues(eventType, status).Inc()
+
+	// the attempts are numbered from the deliveries just stored, which replicas may lag behind
+	db := c.TxManager.DB(model.WithPrimary(ctx))
+	attempts, err := c.WebhookDeliveryRepository.CountByWebhookIdAndEventId(db, webhook.ID, eventId)
+	if err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to count webhook deliveries")
+		return nil, err
+	}
+
+	id, err := c.IDs.NewID(ctx, idgen.WebhookDelivery)
+	if err != nil {
+		c.Log.WithContext(ctx).WithError(err).Error("failed to generate webhook delivery id")
+		return nil, err
+	}
+
+	delivery := &entity.WebhookDelivery{
+		ID:              id,
+		WebhookId:       webhook.ID,
+		EventId:         eventId,
+		EventType:       eventType,
+		Payload:         string(payload),
+		Attempt:         int(attempts) + 1,
+		Status:          status,
+		ResponseStatus:  result.Status,
+		LatencyMs:       result.Latency.Milliseconds(),
+		ResponseSnippet: strings.ToValidUTF8(result.Snippet, ""),
+		ReplayOf:        replayOf,
+		CreatedAt:       time.Now().UnixMilli(),
+	}
+	if result.Err != nil {
+		delivery.Error = result.Err.Error()
+	}
+	if status == model.WebhookDeliveryFailed && replayOf == "" {
+		delivery.NextAttemptAt = c.retryAt(delivery.Attempt, time.UnixMilli(delivery.CreatedAt))
+	}
+
+	if err := c.WebhookDeliveryRepository.Create(db, delivery); err != nil {
+		c.Log.WithContext(ctx).WithError(err).Errorf("Failed to record delivery of event %s to webhook %s", eventId, webhook.ID)
+		return nil, err
+	}
+
+	if status == model.WebhookDeliveryFailed {
+		c.Log.WithContext(ctx).Warnf("Failed to deliver event %s to webhook %s : status %d %s", eventId, webhook.ID, result.Status, delivery.Error)
+	}
+
+	return delivery, nil
+}
@@ -0,0 +1,258 @@
+// Package webauthn verifies the responses of FIDO2 authenticators, so users can register
+// passkeys and log in with them (Web Authentication Level 2). Attestation statements
+// are not verified, as the API asks for none: a passkey is trusted for being bound to
+// its user, not for its make.
+package webauthn
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"slices"
+)
+
+// COSE algorithms of the keys accepted, in order of preference.
+const (
+	ES256 = -7
+	EdDSA = -8
+	RS256 = -257
+)
+
+// Algorithms are offered to authenticators when registering a passkey.
+var Algorithms = []int{ES256, EdDSA, RS256}
+
+var (
+	ErrInvalid   = errors.New("invalid authenticator response")
+	ErrSignature = errors.New("invalid signature")
+	// ErrCloned is returned when the signature counter of a passkey went backwards,
+	// which means the key was copied to another authenticator.
+	ErrCloned = errors.New("signature counter went backwards")
+)
+
+const (
+	flagUserPresent      = 0x01
+	flagUserVerified     = 0x04
+	flagAttestedCredData = 0x40
+)
+
+// RelyingParty is the site passkeys are registered for. Authenticators bind each
+// passkey to ID, the domain of the site; Origins are the pages allowed to use them.
+type RelyingParty struct {
+	ID      string
+	Name    string
+	Origins []string
+}
+
+// Credential is a registered passkey. PublicKey is its COSE encoded public key.
+type Credential struct {
+	ID        []byte
+	PublicKey []byte
+	SignCount uint32
+}
+
+type clientData struct {
+	Type      string `json:"type"`
+	Challenge string `json:"challenge"`
+	Origin    string `json:"origin"`
+}
+
+type authenticatorData struct {
+	rpIDHash  []byte
+	flags     byte
+	signCount uint32
+	// set when flagAttestedCredData is
+	credentialID []byte
+	publicKey    []byte
+}
+
+// VerifyRegistration checks the response of navigator.credentials.create() to
+// challenge and returns the passkey it registered.
+func (rp *RelyingParty) VerifyRegistration(challenge []byte, clientDataJSON []byte, attestationObject []byte) (*Credential, error) {
+	if err := rp.verifyClientData(clientDataJSON, "webauthn.create", challenge); err != nil {
+		return nil, err
+	}
+
+	decoded, rest, err := decodeCBOR(attestationObject)
+	if err != nil || len(rest) != 0 {
+		return nil, ErrInvalid
+	}
+	attestation, ok := decoded.(map[any]any)
+	if !ok {
+		return nil, ErrInvalid
+	}
+	rawAuthData, ok := attestation["authData"].([]byte)
+	if !ok {
+		return nil, ErrInvalid
+	}
+
+	authData, err := rp.parseAuthenticatorData(rawAuthData)
+	if err != nil {
+		return nil, err
+	}
+	if authData.flags&flagAttestedCredData == 0 {
+		return nil, ErrInvalid
+	}
+	if _, err := parsePublicKey(authData.publicKey); err != nil {
+		return nil, err
+	}
+
+	return &Credential{ID: authData.credentialID, PublicKey: authData.publicKey, SignCount: authData.signCount}, nil
+}
+
+// VerifyAssertion checks the response of navigator.credentials.get() to challenge,
+// signed by credential, and returns the new signature counter of the passkey.
+func (rp *RelyingParty) VerifyAssertion(challenge []byte, credential *Credential, clientDataJSON []byte, rawAuthData []byte, signature []byte) (uint32, error) {
+	if err := rp.verifyClientData(clientDataJSON, "webauthn.get", challenge); err != nil {
+		return 0, err
+	}
+
+	authData, err := rp.parseAuthenticatorData(rawAuthData)
+	if err != nil {
+		return 0, err
+	}
+
+	publicKey, err := parsePublicKey(credential.PublicKey)
+	if err != nil {
+		return 0, err
+	}
+	clientDataHash := sha256.Sum256(clientDataJSON)
+	if !verifySignature(publicKey, append(slices.Clone(rawAuthData), clientDataHash[:]...), signature) {
+		return 0, ErrSignature
+	}
+
+	// authenticators without a counter always send 0
+	if (authData.signCount != 0 || credential.SignCount != 0) && authData.signCount <= credential.SignCount {
+		return 0, ErrCloned
+	}
+	return authData.signCount, nil
+}
+
+// Challenge returns the challenge the client data answers, as the relying party sent
+// it, so the challenge can be looked up before the response is verified.
+func Challenge(clientDataJSON []byte) (string, bool) {
+	data := new(clientData)
+	if err := json.Unmarshal(clientDataJSON, data); err != nil || data.Challenge == "" {
+		return "", false
+	}
+	return data.Challenge, true
+}
+
+func (rp *RelyingParty) verifyClientData(clientDataJSON []byte, expectedType string, challenge []byte) error {
+	data := new(clientData)
+	if err := json.Unmarshal(clientDataJSON, data); err != nil {
+		return ErrInvalid
+	}
+	if data.Type != expectedType || data.Challenge != base64.RawURLEncoding.EncodeToString(challenge) {
+		return ErrInvalid
+	}
+	if !slices.Contains(rp.Origins, data.Origin) {
+		return ErrInvalid
+	}
+	return nil
+}
+
+// parseAuthenticatorData parses the authenticator data and checks it is meant for the
+// relying party, with a present and verified user; a passkey replaces the password, so
+// it must be unlocked by the user's PIN or biometrics.
+func (rp *RelyingParty) parseAuthenticatorData(raw []byte) (*authenticatorData, error) {
+	if len(raw) < 37 {
+		return nil, ErrInvalid
+	}
+	data := &authenticatorData{
+		rpIDHash:  raw[:32],
+		flags:     raw[32],
+		signCount: binary.BigEndian.Uint32(raw[33:37]),
+	}
+
+	rpIDHash := sha256.Sum256([]byte(rp.ID))
+	if !bytes.Equal(data.rpIDHash, rpIDHash[:]) {
+		return nil, ErrInvalid
+	}
+	if data.flags&flagUserPresent == 0 || data.flags&flagUserVerified == 0 {
+		return nil, ErrInvalid
+	}
+
+	if data.flags&flagAttestedCredData != 0 {
+		// AAGUID, then the length of the credential ID, the ID and the COSE key
+		rest := raw[37:]
+		if len(rest) < 18 {
+			return nil, ErrInvalid
+		}
+		idLength := int(binary.BigEndian.Uint16(rest[16:18]))
+		rest = rest[18:]
+		if idLength == 0 || len(rest) < idLength {
+			return nil, ErrInvalid
+		}
+		data.credentialID = slices.Clone(rest[:idLength])
+		rest = rest[idLength:]
+
+		_, extensions, err := decodeCBOR(rest)
+		if err != nil {
+			return nil, ErrInvalid
+		}
+		data.publicKey = slices.Clone(rest[:len(rest)-len(extensions)])
+	}
+
+	return data, nil
+}
+
+// parsePublicKey decodes a COSE key (RFC 9053) of one of Algorithms.
+func parsePublicKey(raw []byte) (crypto.PublicKey, error) {
+	decoded, _, err := decodeCBOR(raw)
+	if err != nil {
+		return nil, ErrInvalid
+	}
+	key, ok := decoded.(map[any]any)
+	if !ok {
+		return nil, ErrInvalid
+	}
+
+	keyType, _ := key[int64(1)].(int64)
+	algorithm, _ := key[int64(3)].(int64)
+	curve, _ := key[int64(-1)].(int64)
+	x, _ := key[int64(-2)].([]byte)
+	y, _ := key[int64(-3)].([]byte)
+
+	switch {
+	case keyType == 2 && algorithm == ES256 && curve == 1 && len(x) == 32 && len(y) == 32:
+		publicKey, err := ecdsa.ParseUncompressedPublicKey(elliptic.P256(), slices.Concat([]byte{4}, x, y))
+		if err != nil {
+			return nil, ErrInvalid
+		}
+		return publicKey, nil
+	case keyType == 1 && algorithm == EdDSA && curve == 6 && len(x) == ed25519.PublicKeySize:
+		return ed25519.PublicKey(x), nil
+	case keyType == 3 && algorithm == RS256:
+		// for RSA keys -1 and -2 are the modulus and the exponent
+		n, _ := key[int64(-1)].([]byte)
+		e := new(big.Int).SetBytes(x)
+		if len(n) < 256 || !e.IsInt64() || e.Int64() < 3 || e.Int64() > 1<<31-1 {
+			return nil, ErrInvalid
+		}
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(e.Int64())}, nil
+	}
+	return nil, ErrInvalid
+}
+
+func verifySignature(publicKey crypto.PublicKey, message []byte, signature []byte) bool {
+	switch publicKey := publicKey.(type) {
+	case *ecdsa.PublicKey:
+		digest := sha256.Sum256(message)
+		return ecdsa.VerifyASN1(publicKey, digest[:], signature)
+	case ed25519.PublicKey:
+		return ed25519.Verify(publicKey, message, signature)
+	case *rsa.PublicKey:
+		digest := sha256.Sum256(message)
+		return rsa.VerifyPKCS1v15(publicKey, crypto.SHA256, digest[:], signature) == nil
+	}
+	return false
+}
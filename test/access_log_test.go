@@ -0,0 +1,101 @@
+package test
+
+import (
+	"bytes"
+	"encoding/json"
+	"go-rest-scaffold/internal/delivery/http/middleware"
+	"go-rest-scaffold/internal/model"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+)
+
+func newAccessLogApp(sampleRate float64) (*fiber.App, *bytes.Buffer) {
+	output := new(bytes.Buffer)
+	accessLogger := logrus.New()
+	accessLogger.SetOutput(output)
+	accessLogger.SetFormatter(&logrus.JSONFormatter{})
+
+	accessLogConfig := viper.New()
+	accessLogConfig.Set("access_log.sample_rate", sampleRate)
+	accessLogConfig.Set("access_log.exclude_paths", []string{"/metrics", "/swagger/*"})
+
+	accessLogApp := fiber.New()
+	accessLogApp.Use(middleware.NewRequestID())
+	accessLogApp.Use(middleware.NewAccessLog(accessLogger, accessLogConfig))
+	accessLogApp.Use(func(ctx *fiber.Ctx) error {
+		ctx.Locals("auth", &model.Auth{ID: "khannedy"})
+		return ctx.Next()
+	})
+	accessLogApp.Get("/api/contacts/:contactId", func(ctx *fiber.Ctx) error {
+		if ctx.Params("contactId") == "missing" {
+			return fiber.ErrNotFound
+		}
+		return ctx.SendString("contact")
+	})
+	accessLogApp.Get("/metrics", func(ctx *fiber.Ctx) error {
+		return ctx.SendString("metrics")
+	})
+	accessLogApp.Get("/swagger/*", func(ctx *fiber.Ctx) error {
+		return ctx.SendString("swagger")
+	})
+	return accessLogApp, output
+}
+
+func accessLogEntries(t *testing.T, output *bytes.Buffer) []map[string]any {
+	var entries []map[string]any
+	for _, line := range strings.Split(strings.TrimSpace(output.String()), "\n") {
+		if line == "" {
+			continue
+		}
+		entry := make(map[string]any)
+		err := json.Unmarshal([]byte(line), &entry)
+		assert.Nil(t, err)
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+func TestAccessLog(t *testing.T) {
+	accessLogApp, output := newAccessLogApp(1)
+
+	for _, path := range []string{"/api/contacts/1", "/metrics", "/swagger/index.html"} {
+		response, err := accessLogApp.Test(httptest.NewRequest(http.MethodGet, path, nil))
+		assert.Nil(t, err)
+		assert.Equal(t, http.StatusOK, response.StatusCode)
+	}
+
+	entries := accessLogEntries(t, output)
+	assert.Equal(t, 1, len(entries))
+	assert.Equal(t, "GET", entries[0]["method"])
+	assert.Equal(t, "/api/contacts/1", entries[0]["path"])
+	assert.Equal(t, "/api/contacts/:contactId", entries[0]["route"])
+	assert.Equal(t, float64(http.StatusOK), entries[0]["status"])
+	assert.Equal(t, "khannedy", entries[0]["user_id"])
+	assert.Equal(t, float64(len("contact")), entries[0]["response_size"])
+	assert.NotEmpty(t, entries[0]["request_id"])
+	assert.Contains(t, entries[0], "latency_ms")
+}
+
+func TestAccessLogSampling(t *testing.T) {
+	accessLogApp, output := newAccessLogApp(0)
+
+	response, err := accessLogApp.Test(httptest.NewRequest(http.MethodGet, "/api/contacts/1", nil))
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+
+	// errors are logged whatever the sample rate
+	response, err = accessLogApp.Test(httptest.NewRequest(http.MethodGet, "/api/contacts/missing", nil))
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusNotFound, response.StatusCode)
+
+	entries := accessLogEntries(t, output)
+	assert.Equal(t, 1, len(entries))
+	assert.Equal(t, float64(http.StatusNotFound), entries[0]["status"])
+}
@@ -0,0 +1,95 @@
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"go-rest-scaffold/internal/entity"
+	"go-rest-scaffold/internal/model"
+	"go-rest-scaffold/internal/usecase"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDeleteCurrentUser(t *testing.T) {
+	TestCreateContact(t)
+
+	user := new(entity.User)
+	err := db.Where("id = ?", "khannedy").First(user).Error
+	assert.Nil(t, err)
+
+	token := user.Token
+
+	request := httptest.NewRequest(http.MethodDelete, "/api/users/_current", nil)
+	request.Header.Set("Accept", "application/json")
+	request.Header.Set("Authorization", token)
+
+	response, err := app.Test(request)
+	assert.Nil(t, err)
+
+	bytes, err := io.ReadAll(response.Body)
+	assert.Nil(t, err)
+
+	responseBody := new(model.WebResponse[model.DeleteUserResponse])
+	err = json.Unmarshal(bytes, responseBody)
+	assert.Nil(t, err)
+
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+	assert.Greater(t, responseBody.Data.PurgeAt, time.Now().Add(24*time.Hour).UnixMilli())
+
+	err = db.Where("id = ?", user.ID).First(user).Error
+	assert.Nil(t, err)
+	assert.NotNil(t, user.DeletedAt)
+
+	var contacts int64
+	err = db.Model(&entity.Contact{}).Where("user_id = ?", user.ID).Count(&contacts).Error
+	assert.Nil(t, err)
+	assert.Equal(t, int64(0), contacts)
+
+	request = httptest.NewRequest(http.MethodGet, "/api/users/_current", nil)
+	request.Header.Set("Accept", "application/json")
+	request.Header.Set("Authorization", token)
+
+	response, err = app.Test(request)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusUnauthorized, response.StatusCode)
+
+	request = httptest.NewRequest(http.MethodPost, "/api/users/_login", strings.NewReader(`{"id": "khannedy", "password": "rahasia"}`))
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("Accept", "application/json")
+
+	response, err = app.Test(request)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusUnauthorized, response.StatusCode)
+}
+
+func TestPurgeDeletedUsers(t *testing.T) {
+	TestDeleteCurrentUser(t)
+
+	purged, err := newPasswordUserUseCase(usecase.UserOptions{DeletionGracePeriod: 0}).PurgeDeleted(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, 1, purged)
+
+	var users int64
+	err = db.Model(&entity.User{}).Where("id = ?", "khannedy").Count(&users).Error
+	assert.Nil(t, err)
+	assert.Equal(t, int64(0), users)
+
+	var contacts int64
+	err = db.Unscoped().Model(&entity.Contact{}).Where("user_id = ?", "khannedy").Count(&contacts).Error
+	assert.Nil(t, err)
+	assert.Equal(t, int64(0), contacts)
+}
+
+func TestPurgeDeletedUsersWithinGracePeriod(t *testing.T) {
+	TestDeleteCurrentUser(t)
+
+	purged, err := newPasswordUserUseCase(usecase.UserOptions{DeletionGracePeriod: time.Hour}).PurgeDeleted(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, 0, purged)
+}
@@ -0,0 +1,114 @@
+package test
+
+import (
+	"encoding/json"
+	"go-rest-scaffold/internal/entity"
+	"go-rest-scaffold/internal/model"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func ExportAccount(t *testing.T, user *entity.User) []byte {
+	request := httptest.NewRequest(http.MethodGet, "/api/users/_current/_export", nil)
+	request.Header.Set("Accept", "application/json")
+	request.Header.Set("Authorization", user.Token)
+
+	response, err := app.Test(request)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+	assert.Contains(t, response.Header.Get("Content-Disposition"), "attachment")
+
+	bytes, err := io.ReadAll(response.Body)
+	assert.Nil(t, err)
+	return bytes
+}
+
+func ImportAccount(t *testing.T, user *entity.User, archive []byte, ids string) *http.Response {
+	request := httptest.NewRequest(http.MethodPost, "/api/users/_current/_import?ids="+ids, strings.NewReader(string(archive)))
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("Accept", "application/json")
+	request.Header.Set("Authorization", user.Token)
+
+	response, err := app.Test(request)
+	assert.Nil(t, err)
+	return response
+}
+
+func TestExportAccount(t *testing.T) {
+	TestCreateAddress(t)
+
+	user := new(entity.User)
+	err := db.Where("id = ?", "khannedy").First(user).Error
+	assert.Nil(t, err)
+
+	contact := GetFirstContact(t, user)
+
+	archive := new(model.AccountArchive)
+	err = json.Unmarshal(ExportAccount(t, user), archive)
+	assert.Nil(t, err)
+
+	assert.Equal(t, model.AccountArchiveFormat, archive.Format)
+	assert.Equal(t, model.AccountArchiveVersion, archive.Version)
+	assert.Equal(t, user.ID, archive.User.ID)
+	assert.Equal(t, 1, len(archive.Contacts))
+	assert.Equal(t, contact.ID, archive.Contacts[0].ID)
+	assert.Equal(t, 1, len(archive.Contacts[0].Addresses))
+}
+
+func TestImportAccountPreservingIds(t *testing.T) {
+	TestCreateAddress(t)
+
+	user := new(entity.User)
+	err := db.Where("id = ?", "khannedy").First(user).Error
+	assert.Nil(t, err)
+
+	contact := GetFirstContact(t, user)
+	archive := ExportAccount(t, user)
+
+	// the IDs are still taken until the original data is gone
+	response := ImportAccount(t, user, archive, model.ImportPreserveIds)
+	assert.Equal(t, http.StatusConflict, response.StatusCode)
+
+	ClearAddresses()
+	ClearContact()
+
+	response = ImportAccount(t, user, archive, model.ImportPreserveIds)
+	bytes, err := io.ReadAll(response.Body)
+	assert.Nil(t, err)
+
+	responseBody := new(model.WebResponse[model.ImportAccountResponse])
+	err = json.Unmarshal(bytes, responseBody)
+	assert.Nil(t, err)
+
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+	assert.Equal(t, 1, responseBody.Data.Contacts)
+	assert.Equal(t, 1, responseBody.Data.Addresses)
+
+	imported := GetFirstContact(t, user)
+	assert.Equal(t, contact.ID, imported.ID)
+	assert.Equal(t, contact.CreatedAt, imported.CreatedAt)
+}
+
+func TestImportAccountRemappingIds(t *testing.T) {
+	TestCreateAddress(t)
+
+	user := new(entity.User)
+	err := db.Where("id = ?", "khannedy").First(user).Error
+	assert.Nil(t, err)
+
+	response := ImportAccount(t, user, ExportAccount(t, user), model.ImportRemapIds)
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+
+	var contacts []entity.Contact
+	err = db.Preload("Addresses").Where("user_id = ?", user.ID).Find(&contacts).Error
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(contacts))
+	assert.NotEqual(t, contacts[0].ID, contacts[1].ID)
+	assert.Equal(t, 1, len(contacts[0].Addresses))
+	assert.Equal(t, 1, len(contacts[1].Addresses))
+}
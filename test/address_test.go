@@ -272,6 +272,74 @@ func TestUpdateAddressFailed(t *testing.T) {
 	assert.Equal(t, http.StatusBadRequest, response.StatusCode)
 }
 
+func TestPatchAddress(t *testing.T) {
+	TestCreateAddress(t)
+
+	user := GetFirstUser(t)
+	contact := GetFirstContact(t, user)
+	address := GetFirstAddress(t, contact)
+
+	request := httptest.NewRequest(http.MethodPatch, "/api/contacts/"+contact.ID+"/addresses/"+address.ID, strings.NewReader(`{"postal_code": "40115"}`))
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("Accept", "application/json")
+	request.Header.Set("Authorization", user.Token)
+
+	response, err := app.Test(request)
+	assert.Nil(t, err)
+
+	bytes, err := io.ReadAll(response.Body)
+	assert.Nil(t, err)
+
+	responseBody := new(model.WebResponse[model.AddressResponse])
+	err = json.Unmarshal(bytes, responseBody)
+	assert.Nil(t, err)
+
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+	assert.Equal(t, "40115", responseBody.Data.PostalCode)
+	assert.Equal(t, address.Street, responseBody.Data.Street)
+	assert.Equal(t, address.City, responseBody.Data.City)
+	assert.Equal(t, address.Province, responseBody.Data.Province)
+	assert.Equal(t, address.Country, responseBody.Data.Country)
+
+	request = httptest.NewRequest(http.MethodPatch, "/api/contacts/"+contact.ID+"/addresses/"+address.ID, strings.NewReader(`{"province": null}`))
+	request.Header.Set("Content-Type", "application/merge-patch+json")
+	request.Header.Set("Accept", "application/json")
+	request.Header.Set("Authorization", user.Token)
+
+	response, err = app.Test(request)
+	assert.Nil(t, err)
+
+	bytes, err = io.ReadAll(response.Body)
+	assert.Nil(t, err)
+
+	responseBody = new(model.WebResponse[model.AddressResponse])
+	err = json.Unmarshal(bytes, responseBody)
+	assert.Nil(t, err)
+
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+	assert.Equal(t, "", responseBody.Data.Province)
+	assert.Equal(t, "40115", responseBody.Data.PostalCode)
+	assert.Equal(t, address.Street, responseBody.Data.Street)
+}
+
+func TestPatchAddressFailed(t *testing.T) {
+	TestCreateAddress(t)
+
+	user := GetFirstUser(t)
+	contact := GetFirstContact(t, user)
+	address := GetFirstAddress(t, contact)
+
+	request := httptest.NewRequest(http.MethodPatch, "/api/contacts/"+contact.ID+"/addresses/"+address.ID, strings.NewReader(`{"postal_code": "4011540115401154011540115"}`))
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("Accept", "application/json")
+	request.Header.Set("Authorization", user.Token)
+
+	response, err := app.Test(request)
+	assert.Nil(t, err)
+
+	assert.Equal(t, http.StatusBadRequest, response.StatusCode)
+}
+
 func TestDeleteAddress(t *testing.T) {
 	TestCreateAddress(t)
 
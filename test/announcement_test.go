@@ -0,0 +1,111 @@
+package test
+
+import (
+	"encoding/json"
+	"go-rest-scaffold/internal/entity"
+	"go-rest-scaffold/internal/model"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCreateAnnouncement(t *testing.T) {
+	ClearAll()
+	TestLogin(t)
+
+	user := new(entity.User)
+	err := db.Where("id = ?", "khannedy").First(user).Error
+	assert.Nil(t, err)
+
+	err = db.Model(user).Update("role", model.RoleAdmin).Error
+	assert.Nil(t, err)
+
+	requestBody := model.CreateAnnouncementRequest{
+		Title: "Scheduled maintenance",
+		Body:  "The API will be read-only on Sunday from 02:00 to 03:00 UTC.",
+		Level: "warning",
+	}
+	bodyJson, err := json.Marshal(requestBody)
+	assert.Nil(t, err)
+
+	request := httptest.NewRequest(http.MethodPost, "/api/admin/announcements", strings.NewReader(string(bodyJson)))
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("Accept", "application/json")
+	request.Header.Set("Authorization", user.Token)
+
+	response, err := app.Test(request)
+	assert.Nil(t, err)
+
+	bytes, err := io.ReadAll(response.Body)
+	assert.Nil(t, err)
+
+	responseBody := new(model.WebResponse[model.AnnouncementResponse])
+	err = json.Unmarshal(bytes, responseBody)
+	assert.Nil(t, err)
+
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+	assert.Equal(t, requestBody.Title, responseBody.Data.Title)
+	assert.Equal(t, requestBody.Level, responseBody.Data.Level)
+	assert.NotZero(t, responseBody.Data.StartsAt)
+	assert.Zero(t, responseBody.Data.EndsAt)
+	assert.Equal(t, "khannedy", responseBody.Data.CreatedBy)
+	assert.Equal(t, "khannedy", responseBody.Data.UpdatedBy)
+}
+
+func TestCreateAnnouncementForbidden(t *testing.T) {
+	ClearAll()
+	TestLogin(t)
+
+	user := new(entity.User)
+	err := db.Where("id = ?", "khannedy").First(user).Error
+	assert.Nil(t, err)
+
+	request := httptest.NewRequest(http.MethodPost, "/api/admin/announcements", strings.NewReader(`{"title":"Hello","level":"info"}`))
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("Accept", "application/json")
+	request.Header.Set("Authorization", user.Token)
+
+	response, err := app.Test(request)
+	assert.Nil(t, err)
+
+	assert.Equal(t, http.StatusForbidden, response.StatusCode)
+}
+
+func TestActiveAnnouncements(t *testing.T) {
+	ClearAll()
+
+	now := time.Now().UnixMilli()
+	announcements := []entity.Announcement{
+		{ID: "5b0f3b9e-46a1-4b43-9d4a-1a4c3a0e0001", Title: "New feature", Level: "info", StartsAt: now - 1000},
+		{ID: "5b0f3b9e-46a1-4b43-9d4a-1a4c3a0e0002", Title: "Outage", Level: "critical", StartsAt: now - 1000, EndsAt: now + 60000},
+		{ID: "5b0f3b9e-46a1-4b43-9d4a-1a4c3a0e0003", Title: "Scheduled", Level: "warning", StartsAt: now + 60000},
+		{ID: "5b0f3b9e-46a1-4b43-9d4a-1a4c3a0e0004", Title: "Expired", Level: "warning", StartsAt: now - 60000, EndsAt: now - 1000},
+	}
+	for _, announcement := range announcements {
+		err := db.Create(&announcement).Error
+		assert.Nil(t, err)
+	}
+
+	request := httptest.NewRequest(http.MethodGet, "/api/announcements/active", nil)
+	request.Header.Set("Accept", "application/json")
+
+	response, err := app.Test(request)
+	assert.Nil(t, err)
+
+	bytes, err := io.ReadAll(response.Body)
+	assert.Nil(t, err)
+
+	responseBody := new(model.WebResponse[[]model.AnnouncementResponse])
+	err = json.Unmarshal(bytes, responseBody)
+	assert.Nil(t, err)
+
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+	assert.Equal(t, 2, len(responseBody.Data))
+	assert.Equal(t, "Outage", responseBody.Data[0].Title)
+	assert.Equal(t, "New feature", responseBody.Data[1].Title)
+}
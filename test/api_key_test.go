@@ -0,0 +1,116 @@
+package test
+
+import (
+	"encoding/json"
+	"go-rest-scaffold/internal/entity"
+	"go-rest-scaffold/internal/model"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAPIKey(t *testing.T) {
+	ClearAll()
+	TestLogin(t)
+
+	user := new(entity.User)
+	err := db.Where("id = ?", "khannedy").First(user).Error
+	assert.Nil(t, err)
+
+	request := httptest.NewRequest(http.MethodPost, "/api/api-keys", strings.NewReader(`{"name":"billing service"}`))
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("Accept", "application/json")
+	request.Header.Set("Authorization", user.Token)
+
+	response, err := app.Test(request)
+	assert.Nil(t, err)
+
+	bytes, err := io.ReadAll(response.Body)
+	assert.Nil(t, err)
+
+	created := new(model.WebResponse[model.APIKeyResponse])
+	err = json.Unmarshal(bytes, created)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+	assert.Equal(t, "billing service", created.Data.Name)
+	assert.True(t, strings.HasPrefix(created.Data.Key, created.Data.Prefix))
+
+	// only the hash of the key is stored
+	apiKey := new(entity.APIKey)
+	err = db.Where("id = ?", created.Data.ID).Take(apiKey).Error
+	assert.Nil(t, err)
+	assert.NotEqual(t, created.Data.Key, apiKey.KeyHash)
+
+	request = httptest.NewRequest(http.MethodGet, "/api/users/_current", nil)
+	request.Header.Set("Accept", "application/json")
+	request.Header.Set("X-API-Key", created.Data.Key)
+
+	response, err = app.Test(request)
+	assert.Nil(t, err)
+
+	bytes, err = io.ReadAll(response.Body)
+	assert.Nil(t, err)
+
+	current := new(model.WebResponse[model.UserResponse])
+	err = json.Unmarshal(bytes, current)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+	assert.Equal(t, "khannedy", current.Data.ID)
+
+	// a key cannot mint more keys
+	request = httptest.NewRequest(http.MethodPost, "/api/api-keys", strings.NewReader(`{"name":"another"}`))
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("Accept", "application/json")
+	request.Header.Set("X-API-Key", created.Data.Key)
+
+	response, err = app.Test(request)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusForbidden, response.StatusCode)
+
+	request = httptest.NewRequest(http.MethodDelete, "/api/api-keys/"+created.Data.ID, nil)
+	request.Header.Set("Accept", "application/json")
+	request.Header.Set("Authorization", user.Token)
+
+	response, err = app.Test(request)
+	assert.Nil(t, err)
+
+	bytes, err = io.ReadAll(response.Body)
+	assert.Nil(t, err)
+
+	revoked := new(model.WebResponse[model.APIKeyResponse])
+	err = json.Unmarshal(bytes, revoked)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+	assert.NotZero(t, revoked.Data.RevokedAt)
+	assert.Empty(t, revoked.Data.Key)
+
+	request = httptest.NewRequest(http.MethodGet, "/api/users/_current", nil)
+	request.Header.Set("Accept", "application/json")
+	request.Header.Set("X-API-Key", created.Data.Key)
+
+	response, err = app.Test(request)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusUnauthorized, response.StatusCode)
+
+	request = httptest.NewRequest(http.MethodGet, "/api/api-keys", nil)
+	request.Header.Set("Accept", "application/json")
+	request.Header.Set("Authorization", user.Token)
+
+	response, err = app.Test(request)
+	assert.Nil(t, err)
+
+	bytes, err = io.ReadAll(response.Body)
+	assert.Nil(t, err)
+
+	listed := new(model.WebResponse[[]model.APIKeyResponse])
+	err = json.Unmarshal(bytes, listed)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+	assert.Len(t, listed.Data, 1)
+	assert.NotZero(t, listed.Data[0].LastUsedAt)
+	assert.Empty(t, listed.Data[0].Key)
+}
@@ -0,0 +1,94 @@
+package test
+
+import (
+	"encoding/json"
+	"go-rest-scaffold/internal/model"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// eventTypes returns the types of the Event variables of the model, read from its
+// source so an event added there without documenting it fails the test.
+func eventTypes(t *testing.T) []string {
+	file, err := parser.ParseFile(token.NewFileSet(), "../internal/model/event_model.go", nil, 0)
+	assert.Nil(t, err)
+
+	var types []string
+	ast.Inspect(file, func(node ast.Node) bool {
+		spec, ok := node.(*ast.ValueSpec)
+		if !ok {
+			return true
+		}
+		for i, name := range spec.Names {
+			if !name.IsExported() || !strings.HasPrefix(name.Name, "Event") || i >= len(spec.Values) {
+				continue
+			}
+			call, ok := spec.Values[i].(*ast.CallExpr)
+			if !ok || len(call.Args) != 2 {
+				continue
+			}
+			if ident, ok := call.Fun.(*ast.Ident); !ok || ident.Name != "EventType" {
+				continue
+			}
+			resource, err := strconv.Unquote(call.Args[0].(*ast.BasicLit).Value)
+			assert.Nil(t, err)
+			action, err := strconv.Unquote(call.Args[1].(*ast.BasicLit).Value)
+			assert.Nil(t, err)
+			types = append(types, model.EventType(resource, action))
+		}
+		return false
+	})
+	return types
+}
+
+func TestAsyncAPIDocumentsEveryEvent(t *testing.T) {
+	types := eventTypes(t)
+	assert.Contains(t, types, model.EventReminderDue)
+
+	request := httptest.NewRequest(http.MethodGet, "/asyncapi.json", nil)
+	response, err := app.Test(request)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+
+	bytes, err := io.ReadAll(response.Body)
+	assert.Nil(t, err)
+
+	document := struct {
+		Channels map[string]struct {
+			Messages map[string]struct {
+				Name string `json:"name"`
+				Ref  string `json:"$ref"`
+			} `json:"messages"`
+		} `json:"channels"`
+		Components struct {
+			Messages map[string]struct {
+				Name string `json:"name"`
+			} `json:"messages"`
+		} `json:"components"`
+	}{}
+	assert.Nil(t, json.Unmarshal(bytes, &document))
+
+	// every event reaches webhooks and the event stream, whatever its resource
+	for _, channel := range []string{"webhooks", "events"} {
+		var names []string
+		for _, message := range document.Channels[channel].Messages {
+			name := message.Name
+			if message.Ref != "" {
+				name = document.Components.Messages[strings.TrimPrefix(message.Ref, "#/components/messages/")].Name
+			}
+			names = append(names, name)
+		}
+		for _, eventType := range types {
+			assert.Contains(t, names, eventType, "channel %s", channel)
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package test
+
+import (
+	"encoding/json"
+	"go-rest-scaffold/internal/entity"
+	"go-rest-scaffold/internal/model"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestListAuditLogs(t *testing.T) {
+	TestUpdateContact(t)
+
+	user := new(entity.User)
+	err := db.Where("id = ?", "khannedy").First(user).Error
+	assert.Nil(t, err)
+	err = db.Model(user).Update("role", model.RoleAdmin).Error
+	assert.Nil(t, err)
+
+	request := httptest.NewRequest(http.MethodGet, "/api/admin/audit-logs?entity_type=contact&user_id="+user.ID, nil)
+	request.Header.Set("Accept", "application/json")
+	request.Header.Set("Authorization", user.Token)
+
+	response, err := app.Test(request)
+	assert.Nil(t, err)
+
+	bytes, err := io.ReadAll(response.Body)
+	assert.Nil(t, err)
+
+	responseBody := new(model.WebResponse[[]model.AuditLogResponse])
+	err = json.Unmarshal(bytes, responseBody)
+	assert.Nil(t, err)
+
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+	assert.Equal(t, int64(2), responseBody.Paging.TotalItem)
+	assert.Equal(t, 2, len(responseBody.Data))
+
+	entries := make(map[string]model.AuditLogResponse)
+	for _, entry := range responseBody.Data {
+		entries[entry.Action] = entry
+	}
+
+	update := entries[model.AuditUpdate]
+	assert.Equal(t, model.AuditContact, update.EntityType)
+	assert.Equal(t, user.ID, update.UserId)
+	assert.NotEmpty(t, update.IP)
+	assert.Equal(t, "Eko Kurniawan", update.Changes["first_name"].Before)
+	assert.Equal(t, "Eko", update.Changes["first_name"].After)
+	assert.NotContains(t, update.Changes, "id")
+
+	create := entries[model.AuditCreate]
+	assert.Equal(t, update.EntityId, create.EntityId)
+	assert.Nil(t, create.Changes["first_name"].Before)
+	assert.Equal(t, "Eko Kurniawan", create.Changes["first_name"].After)
+}
+
+func TestListAuditLogsDateRange(t *testing.T) {
+	TestCreateContact(t)
+
+	user := new(entity.User)
+	err := db.Where("id = ?", "khannedy").First(user).Error
+	assert.Nil(t, err)
+	err = db.Model(user).Update("role", model.RoleAdmin).Error
+	assert.Nil(t, err)
+
+	from := strconv.FormatInt(time.Now().Add(time.Hour).UnixMilli(), 10)
+	request := httptest.NewRequest(http.MethodGet, "/api/admin/audit-logs?from="+from, nil)
+	request.Header.Set("Accept", "application/json")
+	request.Header.Set("Authorization", user.Token)
+
+	response, err := app.Test(request)
+	assert.Nil(t, err)
+
+	bytes, err := io.ReadAll(response.Body)
+	assert.Nil(t, err)
+
+	responseBody := new(model.WebResponse[[]model.AuditLogResponse])
+	err = json.Unmarshal(bytes, responseBody)
+	assert.Nil(t, err)
+
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+	assert.Equal(t, int64(0), responseBody.Paging.TotalItem)
+}
+
+func TestListAuditLogsForbidden(t *testing.T) {
+	TestLogin(t)
+
+	user := new(entity.User)
+	err := db.Where("id = ?", "khannedy").First(user).Error
+	assert.Nil(t, err)
+
+	request := httptest.NewRequest(http.MethodGet, "/api/admin/audit-logs", nil)
+	request.Header.Set("Accept", "application/json")
+	request.Header.Set("Authorization", user.Token)
+
+	response, err := app.Test(request)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusForbidden, response.StatusCode)
+}
@@ -0,0 +1,126 @@
+package test
+
+import (
+	"encoding/json"
+	"fmt"
+	"go-rest-scaffold/internal/entity"
+	"go-rest-scaffold/internal/model"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func bulkContacts(t *testing.T, user *entity.User, body string) (*http.Response, []model.BulkContactResult) {
+	request := httptest.NewRequest(http.MethodPost, "/api/contacts/_bulk", strings.NewReader(body))
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("Accept", "application/json")
+	request.Header.Set("Authorization", user.Token)
+
+	response, err := app.Test(request)
+	assert.Nil(t, err)
+
+	bytes, err := io.ReadAll(response.Body)
+	assert.Nil(t, err)
+
+	responseBody := new(model.WebResponse[[]model.BulkContactResult])
+	err = json.Unmarshal(bytes, responseBody)
+	assert.Nil(t, err)
+
+	return response, responseBody.Data
+}
+
+func countContacts(t *testing.T, user *entity.User) int64 {
+	var total int64
+	err := db.Model(new(entity.Contact)).Where("user_id = ?", user.ID).Count(&total).Error
+	assert.Nil(t, err)
+	return total
+}
+
+func TestBulkContacts(t *testing.T) {
+	ClearAll()
+	TestLogin(t)
+	user := GetFirstUser(t)
+	CreateContacts(user, 2)
+
+	contacts := []entity.Contact{}
+	err := db.Where("user_id = ?", user.ID).Order("id").Find(&contacts).Error
+	assert.Nil(t, err)
+
+	response, results := bulkContacts(t, user, fmt.Sprintf(`{"operations": [
+		{"op": "create", "first_name": "Eko", "email": "eko@example.com"},
+		{"op": "update", "id": %q, "version": 1, "first_name": "Budi", "email": "budi@example.com"},
+		{"op": "update", "id": %q, "version": 7, "first_name": "Joko", "email": "joko@example.com"},
+		{"op": "delete", "id": %q},
+		{"op": "rename", "id": %q}
+	]}`, contacts[0].ID, contacts[1].ID, contacts[1].ID, contacts[1].ID))
+
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+	assert.Len(t, results, 5)
+	assert.Equal(t, http.StatusOK, results[0].Status)
+	assert.Equal(t, "Eko", results[0].Contact.FirstName)
+	assert.Equal(t, http.StatusOK, results[1].Status)
+	assert.Equal(t, "Budi", results[1].Contact.FirstName)
+	assert.Equal(t, int64(2), results[1].Contact.Version)
+	assert.Equal(t, http.StatusPreconditionFailed, results[2].Status)
+	assert.NotEmpty(t, results[2].Error)
+	assert.Equal(t, http.StatusOK, results[3].Status)
+	assert.Equal(t, http.StatusBadRequest, results[4].Status)
+
+	// the failed operations did not keep the others from being applied
+	assert.Equal(t, int64(2), countContacts(t, user))
+}
+
+func TestBulkContactsAtomic(t *testing.T) {
+	ClearAll()
+	TestLogin(t)
+	user := GetFirstUser(t)
+	CreateContacts(user, 1)
+	contact := GetFirstContact(t, user)
+
+	response, results := bulkContacts(t, user, fmt.Sprintf(`{"atomic": true, "operations": [
+		{"op": "create", "first_name": "Eko", "email": "eko@example.com"},
+		{"op": "delete", "id": %q},
+		{"op": "create", "email": "nameless@example.com"},
+		{"op": "create", "first_name": "Joko", "email": "joko@example.com"}
+	]}`, contact.ID))
+
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+	assert.Len(t, results, 4)
+	assert.Equal(t, http.StatusFailedDependency, results[0].Status)
+	assert.Equal(t, http.StatusFailedDependency, results[1].Status)
+	assert.Equal(t, http.StatusBadRequest, results[2].Status)
+	assert.Equal(t, http.StatusFailedDependency, results[3].Status)
+	assert.Equal(t, int64(1), countContacts(t, user))
+
+	response, results = bulkContacts(t, user, fmt.Sprintf(`{"atomic": true, "operations": [
+		{"op": "create", "first_name": "Eko", "email": "eko@example.com"},
+		{"op": "delete", "id": %q}
+	]}`, contact.ID))
+
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+	assert.Equal(t, http.StatusOK, results[0].Status)
+	assert.Equal(t, http.StatusOK, results[1].Status)
+	assert.Equal(t, int64(1), countContacts(t, user))
+}
+
+func TestBulkContactsTooMany(t *testing.T) {
+	ClearAll()
+	TestLogin(t)
+	user := GetFirstUser(t)
+
+	operations := make([]string, 101)
+	for i := range operations {
+		operations[i] = `{"op": "create", "first_name": "Eko", "email": "eko@example.com"}`
+	}
+
+	response, _ := bulkContacts(t, user, `{"operations": [`+strings.Join(operations, ",")+`]}`)
+	assert.Equal(t, http.StatusBadRequest, response.StatusCode)
+
+	response, _ = bulkContacts(t, user, `{"operations": []}`)
+	assert.Equal(t, http.StatusBadRequest, response.StatusCode)
+	assert.Equal(t, int64(0), countContacts(t, user))
+}
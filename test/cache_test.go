@@ -0,0 +1,54 @@
+package test
+
+import (
+	"go-rest-scaffold/internal/delivery/http/middleware"
+	"go-rest-scaffold/internal/gateway/cdn"
+	"go-rest-scaffold/internal/model"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCacheControlHeaders(t *testing.T) {
+	cacheConfig := viper.New()
+	cacheConfig.Set("cache.enabled", true)
+	cacheConfig.Set("cache.max_age", 0)
+	cacheConfig.Set("cache.shared_max_age", 60)
+
+	cacheApp := fiber.New()
+	cacheApp.Get("/api/contacts/:contactId", middleware.NewCacheControl(cacheConfig)(middleware.ContactKeys), func(ctx *fiber.Ctx) error {
+		return ctx.SendString("ok")
+	})
+
+	request := httptest.NewRequest(http.MethodGet, "/api/contacts/abc", nil)
+	response, err := cacheApp.Test(request)
+	assert.Nil(t, err)
+
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+	assert.Equal(t, "public, max-age=0, s-maxage=60", response.Header.Get("Cache-Control"))
+	assert.Equal(t, "Authorization, X-API-Key", response.Header.Get("Vary"))
+	assert.Equal(t, "contact-abc", response.Header.Get("Surrogate-Key"))
+	assert.Equal(t, "contact-abc", response.Header.Get("Cache-Tag"))
+	assert.Equal(t, "max-age=60", response.Header.Get("Surrogate-Control"))
+}
+
+func TestSurrogateKeysFromEvents(t *testing.T) {
+	contactEvent := model.NewCloudEvent("test", model.EventContactUpdated, "contacts/abc", "khannedy", nil)
+	assert.Equal(t, []string{cdn.ContactKey("abc"), cdn.ContactsKey("khannedy")}, cdn.Keys(contactEvent))
+
+	addressEvent := model.NewCloudEvent("test", model.EventAddressDeleted, "contacts/abc/addresses/def", "khannedy", nil)
+	assert.Equal(t, []string{cdn.ContactKey("abc")}, cdn.Keys(addressEvent))
+
+	taggedEvent := model.NewCloudEvent("test", model.EventContactTagged, "contacts/abc/tags/ghi", "khannedy", nil)
+	assert.Equal(t, []string{cdn.ContactsKey("khannedy")}, cdn.Keys(taggedEvent))
+
+	tagEvent := model.NewCloudEvent("test", model.EventTagUpdated, "tags/ghi", "khannedy", nil)
+	assert.Equal(t, []string{cdn.ContactsKey("khannedy")}, cdn.Keys(tagEvent))
+
+	userEvent := model.NewCloudEvent("test", model.EventUserUpdated, "users/khannedy", "khannedy", nil)
+	assert.Equal(t, []string{cdn.UserKey("khannedy")}, cdn.Keys(userEvent))
+}
@@ -0,0 +1,328 @@
+package test
+
+import (
+	"bytes"
+	"encoding/json"
+	"go-rest-scaffold/internal/config"
+	"go-rest-scaffold/internal/entity"
+	"go-rest-scaffold/internal/model"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"io/fs"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// picture returns a PNG of width by height pixels.
+func picture(t *testing.T, width int, height int) []byte {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for x := 0; x < width; x++ {
+		for y := 0; y < height; y++ {
+			img.Set(x, y, color.RGBA{R: uint8(x), G: uint8(y), B: 128, A: 255})
+		}
+	}
+
+	data := new(bytes.Buffer)
+	assert.Nil(t, png.Encode(data, img))
+	return data.Bytes()
+}
+
+func uploadAvatar(t *testing.T, user *entity.User, contactId string, data []byte) (*http.Response, *model.ContactResponse) {
+	body := new(bytes.Buffer)
+	form := multipart.NewWriter(body)
+	part, err := form.CreateFormFile("file", "avatar.png")
+	assert.Nil(t, err)
+	_, err = part.Write(data)
+	assert.Nil(t, err)
+	assert.Nil(t, form.Close())
+
+	request := httptest.NewRequest(http.MethodPut, "/api/contacts/"+contactId+"/avatar", body)
+	request.Header.Set("Content-Type", form.FormDataContentType())
+	request.Header.Set("Accept", "application/json")
+	request.Header.Set("Authorization", user.Token)
+
+	response, err := app.Test(request)
+	assert.Nil(t, err)
+
+	bytes, err := io.ReadAll(response.Body)
+	assert.Nil(t, err)
+
+	responseBody := new(model.WebResponse[*model.ContactResponse])
+	err = json.Unmarshal(bytes, responseBody)
+	assert.Nil(t, err)
+	return response, responseBody.Data
+}
+
+// readPicture reads a signed URL of the disk storage.
+func readPicture(t *testing.T, signedURL string) *http.Response {
+	parsed, err := url.Parse(signedURL)
+	assert.Nil(t, err)
+
+	response, err := app.Test(httptest.NewRequest(http.MethodGet, parsed.RequestURI(), nil))
+	assert.Nil(t, err)
+	return response
+}
+
+func TestUploadContactAvatar(t *testing.T) {
+	TestLogin(t)
+	user := GetFirstUser(t)
+	CreateContacts(user, 1)
+	contact := GetFirstContact(t, user)
+
+	response, uploaded := uploadAvatar(t, user, contact.ID, picture(t, 300, 200))
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+	assert.Equal(t, `"2.`+strconv.FormatInt(uploaded.Avatar.ExpiresAt, 10)+`"`, response.Header.Get("ETag"))
+	assert.NotNil(t, uploaded.Avatar)
+	assert.NotEmpty(t, uploaded.Avatar.ID)
+	assert.NotEmpty(t, uploaded.Avatar.Small)
+	assert.NotEmpty(t, uploaded.Avatar.Medium)
+	assert.NotEmpty(t, uploaded.Avatar.Large)
+	assert.True(t, uploaded.Avatar.ExpiresAt > 0)
+
+	request := httptest.NewRequest(http.MethodGet, "/api/contacts/"+contact.ID, nil)
+	request.Header.Set("Accept", "application/json")
+	request.Header.Set("Authorization", user.Token)
+
+	response, err := app.Test(request)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+
+	bytes, err := io.ReadAll(response.Body)
+	assert.Nil(t, err)
+
+	responseBody := new(model.WebResponse[*model.ContactResponse])
+	err = json.Unmarshal(bytes, responseBody)
+	assert.Nil(t, err)
+	assert.Equal(t, uploaded.Avatar.ID, responseBody.Data.Avatar.ID)
+	assert.NotEmpty(t, responseBody.Data.Avatar.Small)
+
+	for signedURL, size := range map[string]int{
+		responseBody.Data.Avatar.Small:  model.AvatarSmall,
+		responseBody.Data.Avatar.Medium: model.AvatarMedium,
+		responseBody.Data.Avatar.Large:  model.AvatarLarge,
+	} {
+		response := readPicture(t, signedURL)
+		assert.Equal(t, http.StatusOK, response.StatusCode)
+		assert.Equal(t, "image/jpeg", response.Header.Get("Content-Type"))
+		assert.Contains(t, response.Header.Get("Cache-Control"), "private")
+
+		img, err := jpeg.Decode(response.Body)
+		assert.Nil(t, err)
+		assert.Equal(t, image.Rect(0, 0, size, size), img.Bounds())
+	}
+}
+
+func TestUploadContactAvatarReplacesPictures(t *testing.T) {
+	TestLogin(t)
+	user := GetFirstUser(t)
+	CreateContacts(user, 1)
+	contact := GetFirstContact(t, user)
+
+	_, first := uploadAvatar(t, user, contact.ID, picture(t, 100, 100))
+	response, second := uploadAvatar(t, user, contact.ID, picture(t, 80, 120))
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+	assert.NotEqual(t, first.Avatar.ID, second.Avatar.ID)
+
+	assert.Equal(t, http.StatusNotFound, readPicture(t, first.Avatar.Small).StatusCode)
+	assert.Equal(t, http.StatusOK, readPicture(t, second.Avatar.Small).StatusCode)
+}
+
+func TestUploadContactAvatarNotPicture(t *testing.T) {
+	TestLogin(t)
+	user := GetFirstUser(t)
+	CreateContacts(user, 1)
+	contact := GetFirstContact(t, user)
+
+	response, _ := uploadAvatar(t, user, contact.ID, []byte("first_name,last_name\nEko,Khannedy\n"))
+	assert.Equal(t, http.StatusBadRequest, response.StatusCode)
+
+	updated := new(entity.Contact)
+	err := db.Where("id = ?", contact.ID).Take(updated).Error
+	assert.Nil(t, err)
+	assert.Equal(t, "", updated.AvatarId)
+	assert.Equal(t, contact.Version, updated.Version)
+}
+
+func TestReadPictureTamperedURL(t *testing.T) {
+	TestLogin(t)
+	user := GetFirstUser(t)
+	CreateContacts(user, 1)
+	contact := GetFirstContact(t, user)
+
+	_, uploaded := uploadAvatar(t, user, contact.ID, picture(t, 100, 100))
+
+	parsed, err := url.Parse(uploaded.Avatar.Small)
+	assert.Nil(t, err)
+	query := parsed.Query()
+	query.Set("expires", "9999999999")
+	parsed.RawQuery = query.Encode()
+	assert.Equal(t, http.StatusForbidden, readPicture(t, parsed.String()).StatusCode)
+}
+
+func TestDeleteContactAvatar(t *testing.T) {
+	TestLogin(t)
+	user := GetFirstUser(t)
+	CreateContacts(user, 1)
+	contact := GetFirstContact(t, user)
+
+	_, uploaded := uploadAvatar(t, user, contact.ID, picture(t, 100, 100))
+
+	request := httptest.NewRequest(http.MethodDelete, "/api/contacts/"+contact.ID+"/avatar", nil)
+	request.Header.Set("Accept", "application/json")
+	request.Header.Set("Authorization", user.Token)
+	request.Header.Set("If-Match", `"2"`)
+
+	response, err := app.Test(request)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+	assert.Equal(t, `"3"`, response.Header.Get("ETag"))
+
+	bytes, err := io.ReadAll(response.Body)
+	assert.Nil(t, err)
+
+	responseBody := new(model.WebResponse[*model.ContactResponse])
+	err = json.Unmarshal(bytes, responseBody)
+	assert.Nil(t, err)
+	assert.Nil(t, responseBody.Data.Avatar)
+
+	assert.Equal(t, http.StatusNotFound, readPicture(t, uploaded.Avatar.Small).StatusCode)
+}
+
+func TestEmptyTrashDeletesAvatar(t *testing.T) {
+	TestLogin(t)
+	user := GetFirstUser(t)
+	CreateContacts(user, 1)
+	contact := GetFirstContact(t, user)
+
+	_, uploaded := uploadAvatar(t, user, contact.ID, picture(t, 100, 100))
+
+	err := db.Delete(contact).Error
+	assert.Nil(t, err)
+
+	request := httptest.NewRequest(http.MethodDelete, "/api/trash", nil)
+	request.Header.Set("Accept", "application/json")
+	request.Header.Set("Authorization", user.Token)
+
+	response, err := app.Test(request)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+
+	assert.Equal(t, http.StatusNotFound, readPicture(t, uploaded.Avatar.Small).StatusCode)
+}
+
+func TestUploadContactAvatarVersionMismatch(t *testing.T) {
+	TestLogin(t)
+	user := GetFirstUser(t)
+	CreateContacts(user, 1)
+	contact := GetFirstContact(t, user)
+
+	body := new(bytes.Buffer)
+	form := multipart.NewWriter(body)
+	part, err := form.CreateFormFile("file", "avatar.png")
+	assert.Nil(t, err)
+	_, err = part.Write(picture(t, 100, 100))
+	assert.Nil(t, err)
+	assert.Nil(t, form.Close())
+
+	request := httptest.NewRequest(http.MethodPut, "/api/contacts/"+contact.ID+"/avatar", body)
+	request.Header.Set("Content-Type", form.FormDataContentType())
+	request.Header.Set("Accept", "application/json")
+	request.Header.Set("Authorization", user.Token)
+	request.Header.Set("If-Match", `"7"`)
+
+	response, err := app.Test(request)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusPreconditionFailed, response.StatusCode)
+
+	// the pictures stored before the version was checked are deleted again
+	var pictures []string
+	_ = filepath.WalkDir(filepath.Join(viperConfig.GetString("storage.disk.dir"), "avatars", contact.ID), func(path string, entry fs.DirEntry, err error) error {
+		if err == nil && !entry.IsDir() {
+			pictures = append(pictures, path)
+		}
+		return nil
+	})
+	assert.Empty(t, pictures)
+}
+
+func TestGetContactRenewsAvatarURLs(t *testing.T) {
+	TestLogin(t)
+	user := GetFirstUser(t)
+	CreateContacts(user, 1)
+	contact := GetFirstContact(t, user)
+	uploadAvatar(t, user, contact.ID, picture(t, 100, 100))
+
+	// URLs signed for a second, in a second application
+	urlTTL := viperConfig.Get("storage.url_ttl")
+	viperConfig.Set("storage.url_ttl", 1)
+	t.Cleanup(func() { viperConfig.Set("storage.url_ttl", urlTTL) })
+	shortApp := config.NewFiber(viperConfig, log)
+	config.Bootstrap(&config.BootstrapConfig{
+		DB:       db,
+		App:      shortApp,
+		Log:      log,
+		Validate: validate,
+		Config:   viperConfig,
+		SkipJobs: true,
+	})
+
+	get := func(tag string) (*http.Response, *model.ContactResponse) {
+		request := httptest.NewRequest(http.MethodGet, "/api/contacts/"+contact.ID, nil)
+		request.Header.Set("Accept", "application/json")
+		request.Header.Set("Authorization", user.Token)
+		request.Header.Set("If-None-Match", tag)
+
+		response, err := shortApp.Test(request)
+		assert.Nil(t, err)
+		if response.StatusCode != http.StatusOK {
+			return response, nil
+		}
+
+		bytes, err := io.ReadAll(response.Body)
+		assert.Nil(t, err)
+
+		responseBody := new(model.WebResponse[*model.ContactResponse])
+		assert.Nil(t, json.Unmarshal(bytes, responseBody))
+		return response, responseBody.Data
+	}
+
+	response, read := get("")
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+	tag := response.Header.Get("ETag")
+
+	// revalidating while the URLs stay the same answers 304
+	assert.Eventually(t, func() bool {
+		response, _ := get("")
+		response, _ = get(response.Header.Get("ETag"))
+		return response.StatusCode == http.StatusNotModified
+	}, 5*time.Second, 10*time.Millisecond)
+
+	// once they are signed anew, the contact is sent again with them
+	assert.Eventually(t, func() bool {
+		response, renewed := get(tag)
+		return response.StatusCode == http.StatusOK && response.Header.Get("ETag") != tag &&
+			renewed.Avatar.Small != read.Avatar.Small && renewed.Avatar.ExpiresAt > read.Avatar.ExpiresAt
+	}, 5*time.Second, 100*time.Millisecond)
+
+	// the renewed tag still names the version for If-Match
+	response, _ = get("")
+	request := httptest.NewRequest(http.MethodDelete, "/api/contacts/"+contact.ID+"/avatar", nil)
+	request.Header.Set("Accept", "application/json")
+	request.Header.Set("Authorization", user.Token)
+	request.Header.Set("If-Match", response.Header.Get("ETag"))
+
+	response, err := app.Test(request)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+}
@@ -0,0 +1,168 @@
+package test
+
+import (
+	"encoding/json"
+	"go-rest-scaffold/internal/entity"
+	"go-rest-scaffold/internal/model"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func sendContact(t *testing.T, user *entity.User, method string, path string, contentType string, body string) (*http.Response, *model.ContactResponse) {
+	request := httptest.NewRequest(method, path, strings.NewReader(body))
+	request.Header.Set("Content-Type", contentType)
+	request.Header.Set("Accept", "application/json")
+	request.Header.Set("Authorization", user.Token)
+
+	response, err := app.Test(request)
+	assert.Nil(t, err)
+
+	bytes, err := io.ReadAll(response.Body)
+	assert.Nil(t, err)
+
+	responseBody := new(model.WebResponse[*model.ContactResponse])
+	err = json.Unmarshal(bytes, responseBody)
+	assert.Nil(t, err)
+	return response, responseBody.Data
+}
+
+func TestCreateContactWithEmailsAndPhones(t *testing.T) {
+	TestLogin(t)
+	user := GetFirstUser(t)
+
+	response, contact := sendContact(t, user, http.MethodPost, "/api/contacts", "application/json", `{
+		"first_name": "Eko",
+		"emails": [{"type": "work", "email": "eko@work.example.com"}, {"type": "home", "email": "eko@example.com", "primary": true}],
+		"phones": [{"type": "mobile", "phone": "081234567890"}, {"phone": "0215550100"}]
+	}`)
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+	assert.Equal(t, "eko@example.com", contact.Email)
+	assert.Equal(t, "081234567890", contact.Phone)
+	assert.Equal(t, []model.ContactEmail{
+		{Type: model.ContactTypeWork, Email: "eko@work.example.com"},
+		{Type: model.ContactTypeHome, Email: "eko@example.com", Primary: true},
+	}, contact.Emails)
+	assert.Equal(t, []model.ContactPhone{
+		{Type: model.ContactTypeMobile, Phone: "081234567890", Primary: true},
+		{Type: model.ContactTypeOther, Phone: "0215550100"},
+	}, contact.Phones)
+
+	// the shorthands create a single primary email and phone
+	response, contact = sendContact(t, user, http.MethodPost, "/api/contacts", "application/json",
+		`{"first_name": "Budi", "email": "budi@example.com", "phone": "089898989"}`)
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+	assert.Equal(t, []model.ContactEmail{{Type: model.ContactTypeOther, Email: "budi@example.com", Primary: true}}, contact.Emails)
+	assert.Equal(t, []model.ContactPhone{{Type: model.ContactTypeOther, Phone: "089898989", Primary: true}}, contact.Phones)
+}
+
+func TestCreateContactWithEmailsFailed(t *testing.T) {
+	TestLogin(t)
+	user := GetFirstUser(t)
+
+	for _, body := range []string{
+		`{"first_name": "Eko"}`,
+		`{"first_name": "Eko", "emails": [{"email": "a@example.com", "primary": true}, {"email": "b@example.com", "primary": true}]}`,
+		`{"first_name": "Eko", "emails": [{"email": "a@example.com"}, {"email": "a@example.com"}]}`,
+		`{"first_name": "Eko", "emails": [{"type": "office", "email": "a@example.com"}]}`,
+		`{"first_name": "Eko", "email": "a@example.com", "phones": [{"type": "mobile"}]}`,
+	} {
+		response, _ := sendContact(t, user, http.MethodPost, "/api/contacts", "application/json", body)
+		assert.Equal(t, http.StatusBadRequest, response.StatusCode, body)
+	}
+}
+
+func TestUpdateContactEmails(t *testing.T) {
+	TestLogin(t)
+	user := GetFirstUser(t)
+	CreateContacts(user, 1)
+	contact := GetFirstContact(t, user)
+
+	response, updated := sendContact(t, user, http.MethodPut, "/api/contacts/"+contact.ID, "application/json", `{
+		"first_name": "Eko",
+		"emails": [{"type": "home", "email": "eko@example.com"}, {"type": "work", "email": "eko@work.example.com", "primary": true}]
+	}`)
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+	assert.Equal(t, "eko@work.example.com", updated.Email)
+	assert.Equal(t, "", updated.Phone)
+	assert.Equal(t, 2, len(updated.Emails))
+	assert.Equal(t, 0, len(updated.Phones))
+
+	contact = GetFirstContact(t, user)
+	assert.Equal(t, "eko@work.example.com", contact.PrimaryEmail())
+	assert.Equal(t, 2, len(contact.Emails))
+	assert.Equal(t, 0, len(contact.Phones))
+}
+
+func TestPatchContactEmails(t *testing.T) {
+	TestLogin(t)
+	user := GetFirstUser(t)
+	CreateContacts(user, 1)
+	contact := GetFirstContact(t, user)
+
+	// the email shorthand replaces the primary email and keeps its type
+	response, patched := sendContact(t, user, http.MethodPatch, "/api/contacts/"+contact.ID, model.MIMEMergePatchJSON, `{"email": "eko@example.com"}`)
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+	assert.Equal(t, []model.ContactEmail{{Type: model.ContactTypeWork, Email: "eko@example.com", Primary: true}}, patched.Emails)
+	assert.Equal(t, contact.PrimaryPhone(), patched.Phone)
+
+	response, patched = sendContact(t, user, http.MethodPatch, "/api/contacts/"+contact.ID, model.MIMEMergePatchJSON,
+		`{"phones": [{"type": "home", "phone": "0215550100"}, {"type": "mobile", "phone": "081234567890", "primary": true}]}`)
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+	assert.Equal(t, "081234567890", patched.Phone)
+	assert.Equal(t, 2, len(patched.Phones))
+
+	// clearing the primary phone makes the first one left primary
+	response, patched = sendContact(t, user, http.MethodPatch, "/api/contacts/"+contact.ID, model.MIMEMergePatchJSON, `{"phone": null}`)
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+	assert.Equal(t, []model.ContactPhone{{Type: model.ContactTypeHome, Phone: "0215550100", Primary: true}}, patched.Phones)
+
+	contact = GetFirstContact(t, user)
+	assert.Equal(t, "eko@example.com", contact.PrimaryEmail())
+	assert.Equal(t, "0215550100", contact.PrimaryPhone())
+}
+
+func TestSearchContactByAnyEmail(t *testing.T) {
+	TestLogin(t)
+	user := GetFirstUser(t)
+
+	response, contact := sendContact(t, user, http.MethodPost, "/api/contacts", "application/json", `{
+		"first_name": "Eko",
+		"emails": [{"email": "eko@example.com"}, {"email": "khannedy@work.example.com"}],
+		"phones": [{"phone": "081234567890"}, {"phone": "0215550100"}]
+	}`)
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+	CreateContacts(user, 2)
+
+	found := searchContactsByTag(t, user, "email=work.example")
+	assert.Equal(t, 1, len(found))
+	assert.Equal(t, contact.ID, found[0].ID)
+	assert.Equal(t, "eko@example.com", found[0].Email)
+	assert.Equal(t, 2, len(found[0].Emails))
+
+	found = searchContactsByTag(t, user, "phone=555")
+	assert.Equal(t, 1, len(found))
+	assert.Equal(t, contact.ID, found[0].ID)
+
+	// suggestions match any email and return the primary one
+	request := httptest.NewRequest(http.MethodGet, "/api/contacts/_suggest?q=khannedy", nil)
+	request.Header.Set("Accept", "application/json")
+	request.Header.Set("Authorization", user.Token)
+
+	suggestResponse, err := app.Test(request)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, suggestResponse.StatusCode)
+
+	bytes, err := io.ReadAll(suggestResponse.Body)
+	assert.Nil(t, err)
+
+	suggestions := new(model.WebResponse[[]model.ContactSuggestionResponse])
+	err = json.Unmarshal(bytes, suggestions)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(suggestions.Data))
+	assert.Equal(t, "eko@example.com", suggestions.Data[0].Email)
+}
@@ -0,0 +1,309 @@
+package test
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"go-rest-scaffold/internal/config"
+	"go-rest-scaffold/internal/entity"
+	"go-rest-scaffold/internal/gateway/lock"
+	"go-rest-scaffold/internal/model"
+	"go-rest-scaffold/internal/repository"
+	"go-rest-scaffold/internal/usecase"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func importContacts(t *testing.T, user *entity.User, file string, mapping string) (*http.Response, *model.ContactImportResponse) {
+	body := new(bytes.Buffer)
+	form := multipart.NewWriter(body)
+	part, err := form.CreateFormFile("file", "contacts.csv")
+	assert.Nil(t, err)
+	_, err = part.Write([]byte(file))
+	assert.Nil(t, err)
+	if mapping != "" {
+		assert.Nil(t, form.WriteField("mapping", mapping))
+	}
+	assert.Nil(t, form.Close())
+
+	request := httptest.NewRequest(http.MethodPost, "/api/contacts/_import", body)
+	request.Header.Set("Content-Type", form.FormDataContentType())
+	request.Header.Set("Accept", "application/json")
+	request.Header.Set("Authorization", user.Token)
+
+	response, err := app.Test(request)
+	assert.Nil(t, err)
+
+	bytes, err := io.ReadAll(response.Body)
+	assert.Nil(t, err)
+
+	responseBody := new(model.WebResponse[*model.ContactImportResponse])
+	err = json.Unmarshal(bytes, responseBody)
+	assert.Nil(t, err)
+
+	return response, responseBody.Data
+}
+
+func TestImportContacts(t *testing.T) {
+	ClearAll()
+	TestLogin(t)
+	user := GetFirstUser(t)
+
+	file := "\xef\xbb\xbfGiven Name,Last Name,EMAIL,Notes\n" +
+		"Eko,Khannedy,eko@example.com,friend\n" +
+		"Budi,,not-an-email,\n" +
+		",Nameless,nameless@example.com,\n" +
+		"Joko,Susilo,joko@example.com\n"
+	response, result := importContacts(t, user, file, `{"Given Name": "first_name", "Notes": ""}`)
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+	assert.Equal(t, model.ContactImportCompleted, result.Status)
+	assert.Empty(t, result.ID)
+	assert.Equal(t, 4, result.TotalRows)
+	assert.Equal(t, 2, result.Imported)
+	assert.Equal(t, 2, result.Failed)
+	assert.Len(t, result.Errors, 2)
+	assert.Equal(t, 3, result.Errors[0].Row)
+	assert.Equal(t, "email must be a valid email", result.Errors[0].Fields["email"])
+	assert.Equal(t, 4, result.Errors[1].Row)
+	assert.Equal(t, "first_name is required", result.Errors[1].Fields["first_name"])
+
+	contact := new(entity.Contact)
+	err := db.Preload("Emails").Where("user_id = ? AND first_name = ?", user.ID, "Eko").Take(contact).Error
+	assert.Nil(t, err)
+	assert.Equal(t, "Khannedy", contact.LastName)
+	assert.Equal(t, "eko@example.com", contact.PrimaryEmail())
+	assert.Equal(t, user.ID, contact.CreatedBy)
+	assert.Equal(t, int64(2), countContacts(t, user))
+}
+
+func TestImportContactsFailed(t *testing.T) {
+	ClearAll()
+	TestLogin(t)
+	user := GetFirstUser(t)
+
+	for _, test := range []struct {
+		file    string
+		mapping string
+	}{
+		{file: ""},
+		{file: "last_name,email\nKhannedy,eko@example.com\n"},
+		{file: "first_name,name\nEko,Eko\n", mapping: `{"name": "first_name"}`},
+		{file: "first_name\nEko\n", mapping: `{"missing": "last_name"}`},
+		{file: "first_name,age\nEko,30\n", mapping: `{"age": "birthday"}`},
+		{file: "first_name\nEko\n", mapping: `["first_name"]`},
+		{file: "first_name\n\"Eko\n"},
+		{file: "first_name\n\xff\n"},
+	} {
+		response, _ := importContacts(t, user, test.file, test.mapping)
+		assert.Equal(t, http.StatusBadRequest, response.StatusCode, test.file)
+	}
+	assert.Equal(t, int64(0), countContacts(t, user))
+}
+
+// newContactImportUseCase returns a contact import use case of its own, whose background
+// imports the test runs.
+func newContactImportUseCase(locker *lock.Locker, options usecase.ContactImportOptions) *usecase.ContactImportUseCase {
+	contacts := usecase.NewContactUseCase(txManager, log, validate, repository.NewContactRepository(log), nil,
+		usecase.NewAuditLogUseCase(txManager, log, validate, repository.NewAuditLogRepository(log)), nil, config.NewContactOptions(viperConfig, nil, nil, nil))
+	return usecase.NewContactImportUseCase(txManager, log, validate, repository.NewContactImportRepository(log), contacts, locker, options)
+}
+
+func TestImportContactsQueued(t *testing.T) {
+	ClearAll()
+	TestLogin(t)
+	user := GetFirstUser(t)
+
+	options := config.NewContactImportOptions(viperConfig)
+	options.AsyncRows = 2
+	useCase := newContactImportUseCase(lock.NewLocker(nil, log), options)
+
+	file := "first_name,email\nEko,eko@example.com\nBudi,budi@example.com\nJoko,joko\n"
+	ctx := model.WithActor(context.Background(), user.ID)
+	queued, err := useCase.Import(ctx, &model.ImportContactRequest{UserId: user.ID, Data: []byte(file)})
+	assert.Nil(t, err)
+	assert.Equal(t, model.ContactImportPending, queued.Status)
+	assert.NotEmpty(t, queued.ID)
+	assert.Equal(t, 3, queued.TotalRows)
+	assert.Equal(t, int64(0), countContacts(t, user))
+
+	imported, err := useCase.RunImports(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, int64(2), imported)
+
+	request := httptest.NewRequest(http.MethodGet, "/api/contacts/_import/"+queued.ID, nil)
+	request.Header.Set("Accept", "application/json")
+	request.Header.Set("Authorization", user.Token)
+
+	response, err := app.Test(request)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+
+	bytes, err := io.ReadAll(response.Body)
+	assert.Nil(t, err)
+
+	responseBody := new(model.WebResponse[*model.ContactImportResponse])
+	assert.Nil(t, json.Unmarshal(bytes, responseBody))
+	result := responseBody.Data
+	assert.Equal(t, model.ContactImportCompleted, result.Status)
+	assert.Equal(t, 3, result.ProcessedRows)
+	assert.Equal(t, 2, result.Imported)
+	assert.Equal(t, 1, result.Failed)
+	assert.Equal(t, 4, result.Errors[0].Row)
+	assert.NotNil(t, result.CompletedAt)
+	assert.Equal(t, int64(2), countContacts(t, user))
+
+	// the file is dropped once imported
+	contactImport := new(entity.ContactImport)
+	assert.Nil(t, db.Where("id = ?", queued.ID).Take(contactImport).Error)
+	assert.Empty(t, contactImport.Data)
+}
+
+func TestImportContactsOneAtATime(t *testing.T) {
+	ClearAll()
+	TestLogin(t)
+	user := GetFirstUser(t)
+
+	locker := lock.NewLocker(nil, log)
+	useCase := newContactImportUseCase(locker, config.NewContactImportOptions(viperConfig))
+	ctx := model.WithActor(context.Background(), user.ID)
+
+	// another import of the user is running
+	held, err := locker.Lock(ctx, "contact-import:"+user.ID, time.Minute)
+	assert.Nil(t, err)
+
+	done := make(chan error)
+	go func() {
+		_, err := useCase.Import(ctx, &model.ImportContactRequest{UserId: user.ID, Data: []byte("first_name,email\nEko,eko@example.com\n")})
+		done <- err
+	}()
+
+	select {
+	case err := <-done:
+		t.Fatalf("import ran while another one held the lock: %v", err)
+	case <-time.After(300 * time.Millisecond):
+	}
+	assert.Equal(t, int64(0), countContacts(t, user))
+
+	assert.Nil(t, held.Unlock(ctx))
+	assert.Nil(t, <-done)
+	assert.Equal(t, int64(1), countContacts(t, user))
+}
+
+func TestImportContactsQueuedWaitsForLock(t *testing.T) {
+	ClearAll()
+	TestLogin(t)
+	user := GetFirstUser(t)
+
+	locker := lock.NewLocker(nil, log)
+	options := config.NewContactImportOptions(viperConfig)
+	options.AsyncRows = 1
+	useCase := newContactImportUseCase(locker, options)
+	ctx := model.WithActor(context.Background(), user.ID)
+
+	held, err := locker.Lock(ctx, "contact-import:"+user.ID, time.Minute)
+	assert.Nil(t, err)
+
+	queued, err := useCase.Import(ctx, &model.ImportContactRequest{UserId: user.ID,
+		Data: []byte("first_name,email\nBudi,budi@example.com\nJoko,joko@example.com\n")})
+	assert.Nil(t, err)
+	assert.Equal(t, model.ContactImportPending, queued.Status)
+
+	// left pending for a later run, instead of being claimed
+	imported, err := useCase.RunImports(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, int64(0), imported)
+
+	contactImport := new(entity.ContactImport)
+	assert.Nil(t, db.Where("id = ?", queued.ID).Take(contactImport).Error)
+	assert.Equal(t, model.ContactImportPending, contactImport.Status)
+
+	assert.Nil(t, held.Unlock(ctx))
+	imported, err = useCase.RunImports(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, int64(2), imported)
+	assert.Equal(t, int64(2), countContacts(t, user))
+}
+
+func TestExportContactsCSV(t *testing.T) {
+	ClearAll()
+	TestLogin(t)
+	user := GetFirstUser(t)
+	CreateContacts(user, 3)
+
+	request := httptest.NewRequest(http.MethodGet, "/api/contacts/_export?format=csv", nil)
+	request.Header.Set("Authorization", user.Token)
+
+	response, err := app.Test(request)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+	assert.Equal(t, "text/csv", response.Header.Get("Content-Type"))
+	assert.Equal(t, `attachment; filename="contacts.csv"`, response.Header.Get("Content-Disposition"))
+
+	exported, err := io.ReadAll(response.Body)
+	assert.Nil(t, err)
+
+	rows, err := csv.NewReader(bytes.NewReader(exported)).ReadAll()
+	assert.Nil(t, err)
+	assert.Len(t, rows, 4)
+	assert.Equal(t, []string{"id", "first_name", "last_name", "email", "phone", "created_at", "updated_at"}, rows[0])
+	assert.Equal(t, "Contact", rows[1][1])
+
+	// an export imports back as is
+	_, result := importContacts(t, user, string(exported), "")
+	assert.Equal(t, 3, result.Imported)
+	assert.Equal(t, int64(6), countContacts(t, user))
+}
+
+func TestExportContactsCSVEscapesFormulas(t *testing.T) {
+	ClearAll()
+	TestLogin(t)
+	user := GetFirstUser(t)
+
+	request := httptest.NewRequest(http.MethodPost, "/api/contacts",
+		strings.NewReader(`{"first_name":"=HYPERLINK(\"http://evil.example\")","last_name":"@SUM(A1)","email":"eko@example.com","phone":"+6281234"}`))
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("Accept", "application/json")
+	request.Header.Set("Authorization", user.Token)
+
+	response, err := app.Test(request)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+
+	request = httptest.NewRequest(http.MethodGet, "/api/contacts/_export?format=csv", nil)
+	request.Header.Set("Authorization", user.Token)
+
+	response, err = app.Test(request)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+
+	exported, err := io.ReadAll(response.Body)
+	assert.Nil(t, err)
+
+	rows, err := csv.NewReader(bytes.NewReader(exported)).ReadAll()
+	assert.Nil(t, err)
+	assert.Len(t, rows, 2)
+	assert.Equal(t, `'=HYPERLINK("http://evil.example")`, rows[1][1])
+	assert.Equal(t, "'@SUM(A1)", rows[1][2])
+	assert.Equal(t, "eko@example.com", rows[1][3])
+	assert.Equal(t, "'+6281234", rows[1][4])
+
+	// the quotes are removed again on import
+	_, result := importContacts(t, user, string(exported), "")
+	assert.Equal(t, 1, result.Imported)
+
+	contacts := []entity.Contact{}
+	assert.Nil(t, db.Where("user_id = ?", user.ID).Find(&contacts).Error)
+	assert.Len(t, contacts, 2)
+	for _, contact := range contacts {
+		assert.Equal(t, `=HYPERLINK("http://evil.example")`, contact.FirstName)
+		assert.Equal(t, "@SUM(A1)", contact.LastName)
+	}
+}
@@ -0,0 +1,113 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"go-rest-scaffold/internal/entity"
+	"go-rest-scaffold/internal/model"
+	"go-rest-scaffold/internal/repository"
+	"go-rest-scaffold/internal/usecase"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var errStopSync = errors.New("stop sync")
+
+func newContactSyncUseCase() *usecase.ContactSyncUseCase {
+	return usecase.NewContactSyncUseCase(txManager, log, validate, repository.NewContactRepository(log), repository.NewContactChangeRepository(log),
+		usecase.ContactSyncOptions{
+			BatchSize:         2,
+			PollInterval:      10 * time.Millisecond,
+			HeartbeatInterval: time.Minute,
+		})
+}
+
+// collectSync streams until stop returns true for a line, then ends the stream.
+func collectSync(t *testing.T, useCase *usecase.ContactSyncUseCase, cursor *usecase.ContactSyncCursor, stop func(event model.ContactSyncEvent) bool) []model.ContactSyncEvent {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var events []model.ContactSyncEvent
+	err := useCase.Stream(ctx, cursor, func(batch []model.ContactSyncEvent) error {
+		for _, event := range batch {
+			events = append(events, event)
+			if stop(event) {
+				return errStopSync
+			}
+		}
+		return nil
+	})
+	assert.Equal(t, errStopSync, err)
+	return events
+}
+
+func TestSyncContacts(t *testing.T) {
+	TestCreateContact(t)
+
+	user := GetFirstUser(t)
+	CreateContacts(user, 3)
+
+	useCase := newContactSyncUseCase()
+	cursor, err := useCase.Open(context.Background(), &model.SyncContactsRequest{UserId: user.ID})
+	assert.Nil(t, err)
+	assert.True(t, cursor.Snapshot)
+
+	events := collectSync(t, useCase, cursor, func(event model.ContactSyncEvent) bool {
+		return event.Type == model.ContactSyncSnapshotEnd
+	})
+	assert.Len(t, events, 5)
+	for _, event := range events[:4] {
+		assert.Equal(t, model.ContactSyncUpsert, event.Type)
+		assert.Equal(t, event.ContactId, event.Contact.ID)
+	}
+	snapshotEnd := events[4]
+	assert.Equal(t, cursor.Offset, snapshotEnd.Offset)
+
+	// a change after the snapshot is streamed when resuming from its end
+	contact := GetFirstContact(t, user)
+	request := httptest.NewRequest(http.MethodDelete, "/api/contacts/"+contact.ID, nil)
+	request.Header.Set("Accept", "application/json")
+	request.Header.Set("Authorization", user.Token)
+
+	response, err := app.Test(request)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+
+	cursor, err = useCase.Open(context.Background(), &model.SyncContactsRequest{UserId: user.ID, Resume: true, Offset: snapshotEnd.Offset})
+	assert.Nil(t, err)
+	assert.False(t, cursor.Snapshot)
+
+	events = collectSync(t, useCase, cursor, func(event model.ContactSyncEvent) bool {
+		return true
+	})
+	assert.Equal(t, model.ContactSyncDelete, events[0].Type)
+	assert.Equal(t, contact.ID, events[0].ContactId)
+	assert.Greater(t, events[0].Offset, snapshotEnd.Offset)
+}
+
+func TestSyncContactsExpiredOffset(t *testing.T) {
+	TestCreateContact(t)
+	ClearContactChanges()
+
+	user := new(entity.User)
+	err := db.Where("id = ?", "khannedy").First(user).Error
+	assert.Nil(t, err)
+
+	request := httptest.NewRequest(http.MethodGet, "/api/contacts/_sync?offset=0", nil)
+	request.Header.Set("Authorization", user.Token)
+
+	response, err := app.Test(request)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusGone, response.StatusCode)
+
+	request = httptest.NewRequest(http.MethodGet, "/api/contacts/_sync?offset=abc", nil)
+	request.Header.Set("Authorization", user.Token)
+
+	response, err = app.Test(request)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusBadRequest, response.StatusCode)
+}
@@ -7,8 +7,10 @@ import (
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
@@ -98,7 +100,7 @@ func TestGetConnect(t *testing.T) {
 	assert.Nil(t, err)
 
 	contact := new(entity.Contact)
-	err = db.Where("user_id = ?", user.ID).First(contact).Error
+	err = db.Preload("Emails").Preload("Phones").Where("user_id = ?", user.ID).First(contact).Error
 	assert.Nil(t, err)
 
 	request := httptest.NewRequest(http.MethodGet, "/api/contacts/"+contact.ID, nil)
@@ -119,8 +121,8 @@ func TestGetConnect(t *testing.T) {
 	assert.Equal(t, contact.ID, responseBody.Data.ID)
 	assert.Equal(t, contact.FirstName, responseBody.Data.FirstName)
 	assert.Equal(t, contact.LastName, responseBody.Data.LastName)
-	assert.Equal(t, contact.Email, responseBody.Data.Email)
-	assert.Equal(t, contact.Phone, responseBody.Data.Phone)
+	assert.Equal(t, contact.PrimaryEmail(), responseBody.Data.Email)
+	assert.Equal(t, contact.PrimaryPhone(), responseBody.Data.Phone)
 	assert.Equal(t, contact.CreatedAt, responseBody.Data.CreatedAt)
 	assert.Equal(t, contact.UpdatedAt, responseBody.Data.UpdatedAt)
 }
@@ -192,6 +194,11 @@ func TestUpdateContact(t *testing.T) {
 	assert.NotNil(t, responseBody.Data.ID)
 	assert.NotNil(t, responseBody.Data.CreatedAt)
 	assert.NotNil(t, responseBody.Data.UpdatedAt)
+
+	err = db.Where("id = ?", contact.ID).First(contact).Error
+	assert.Nil(t, err)
+	assert.Equal(t, user.ID, contact.CreatedBy)
+	assert.Equal(t, user.ID, contact.UpdatedBy)
 }
 
 func TestUpdateContactFailed(t *testing.T) {
@@ -266,6 +273,58 @@ func TestUpdateContactNotFound(t *testing.T) {
 	assert.Equal(t, http.StatusNotFound, response.StatusCode)
 }
 
+func TestPatchContact(t *testing.T) {
+	TestCreateContact(t)
+
+	user := GetFirstUser(t)
+	contact := GetFirstContact(t, user)
+
+	request := httptest.NewRequest(http.MethodPatch, "/api/contacts/"+contact.ID, strings.NewReader(`{"last_name": "Budiman", "phone": null}`))
+	request.Header.Set("Content-Type", "application/merge-patch+json")
+	request.Header.Set("Accept", "application/json")
+	request.Header.Set("Authorization", user.Token)
+
+	response, err := app.Test(request)
+	assert.Nil(t, err)
+
+	bytes, err := io.ReadAll(response.Body)
+	assert.Nil(t, err)
+
+	responseBody := new(model.WebResponse[model.ContactResponse])
+	err = json.Unmarshal(bytes, responseBody)
+	assert.Nil(t, err)
+
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+	assert.Equal(t, contact.FirstName, responseBody.Data.FirstName)
+	assert.Equal(t, "Budiman", responseBody.Data.LastName)
+	assert.Equal(t, contact.PrimaryEmail(), responseBody.Data.Email)
+	assert.Equal(t, "", responseBody.Data.Phone)
+
+	err = db.Preload("Phones").Where("id = ?", contact.ID).First(contact).Error
+	assert.Nil(t, err)
+	assert.Equal(t, "", contact.PrimaryPhone())
+}
+
+func TestPatchContactFailed(t *testing.T) {
+	TestCreateContact(t)
+
+	user := GetFirstUser(t)
+	contact := GetFirstContact(t, user)
+
+	// the first name and emails cannot be cleared
+	for _, body := range []string{`{"first_name": null}`, `{"email": null}`, `{"email": "not-an-email"}`, `{"emails": null}`, `{"emails": []}`,
+		`{"emails": [{"email": "not-an-email"}]}`} {
+		request := httptest.NewRequest(http.MethodPatch, "/api/contacts/"+contact.ID, strings.NewReader(body))
+		request.Header.Set("Content-Type", "application/merge-patch+json")
+		request.Header.Set("Accept", "application/json")
+		request.Header.Set("Authorization", user.Token)
+
+		response, err := app.Test(request)
+		assert.Nil(t, err)
+		assert.Equal(t, http.StatusBadRequest, response.StatusCode, body)
+	}
+}
+
 func TestDeleteContact(t *testing.T) {
 	TestCreateContact(t)
 
@@ -411,3 +470,440 @@ func TestSearchContactWithFilter(t *testing.T) {
 	assert.Equal(t, 1, responseBody.Paging.Page)
 	assert.Equal(t, 10, responseBody.Paging.Size)
 }
+
+func TestSearchContactWithFilterOperators(t *testing.T) {
+	TestLogin(t)
+
+	user := GetFirstUser(t)
+	CreateContacts(user, 20)
+
+	// contacts 10 to 19 are created an hour later
+	err := db.Model(new(entity.Contact)).Where("user_id = ? AND last_name LIKE ?", user.ID, "1_").
+		Update("created_at", time.Now().Add(time.Hour).UnixMilli()).Error
+	assert.Nil(t, err)
+
+	search := func(query string) (*http.Response, *model.WebResponse[[]model.ContactResponse]) {
+		request := httptest.NewRequest(http.MethodGet, "/api/contacts?size=20&"+query, nil)
+		request.Header.Set("Accept", "application/json")
+		request.Header.Set("Authorization", user.Token)
+
+		response, err := app.Test(request)
+		assert.Nil(t, err)
+
+		bytes, err := io.ReadAll(response.Body)
+		assert.Nil(t, err)
+
+		responseBody := new(model.WebResponse[[]model.ContactResponse])
+		err = json.Unmarshal(bytes, responseBody)
+		assert.Nil(t, err)
+		return response, responseBody
+	}
+
+	response, responseBody := search("email[suffix]=" + url.QueryEscape("3@example.com"))
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+	assert.Equal(t, int64(2), responseBody.Paging.TotalItem)
+
+	response, responseBody = search("last_name[prefix]=1&last_name[ne]=1")
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+	assert.Equal(t, int64(10), responseBody.Paging.TotalItem)
+
+	later := url.QueryEscape(time.Now().Add(30 * time.Minute).UTC().Format(time.RFC3339))
+	response, responseBody = search("created_at[gte]=" + later)
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+	assert.Equal(t, int64(10), responseBody.Paging.TotalItem)
+
+	response, responseBody = search("created_at[lt]=" + later + "&phone[like]=080000001")
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+	assert.Equal(t, int64(1), responseBody.Paging.TotalItem)
+
+	response, responseBody = search("created_at[gte]=2000-01-01")
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+	assert.Equal(t, int64(20), responseBody.Paging.TotalItem)
+
+	for _, query := range []string{"password[eq]=x", "user_id[eq]=khannedy", "email[regex]=x", "created_at[like]=2024", "created_at[gte]=yesterday"} {
+		response, _ = search(query)
+		assert.Equal(t, http.StatusBadRequest, response.StatusCode, query)
+	}
+}
+
+func TestExportContacts(t *testing.T) {
+	TestLogin(t)
+
+	user := GetFirstUser(t)
+	CreateContacts(user, 20)
+
+	// contacts in the trash are not exported
+	trashed := GetFirstContact(t, user)
+	request := httptest.NewRequest(http.MethodDelete, "/api/contacts/"+trashed.ID, nil)
+	request.Header.Set("Accept", "application/json")
+	request.Header.Set("Authorization", user.Token)
+
+	response, err := app.Test(request)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+
+	request = httptest.NewRequest(http.MethodGet, "/api/contacts/_export?format=ndjson", nil)
+	request.Header.Set("Authorization", user.Token)
+
+	response, err = app.Test(request)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+	assert.Equal(t, "application/x-ndjson", response.Header.Get("Content-Type"))
+	assert.Equal(t, `attachment; filename="contacts.ndjson"`, response.Header.Get("Content-Disposition"))
+
+	bytes, err := io.ReadAll(response.Body)
+	assert.Nil(t, err)
+
+	lines := strings.Split(strings.TrimSuffix(string(bytes), "\n"), "\n")
+	assert.Len(t, lines, 19)
+
+	previous := ""
+	for _, line := range lines {
+		contact := new(model.ContactResponse)
+		assert.Nil(t, json.Unmarshal([]byte(line), contact))
+		assert.NotEqual(t, trashed.ID, contact.ID)
+		assert.Greater(t, contact.ID, previous)
+		previous = contact.ID
+	}
+}
+
+func TestExportContactsUnsupportedFormat(t *testing.T) {
+	TestLogin(t)
+
+	user := GetFirstUser(t)
+
+	request := httptest.NewRequest(http.MethodGet, "/api/contacts/_export?format=xml", nil)
+	request.Header.Set("Accept", "application/json")
+	request.Header.Set("Authorization", user.Token)
+
+	response, err := app.Test(request)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusBadRequest, response.StatusCode)
+}
+
+func TestSearchContactWithSort(t *testing.T) {
+	TestLogin(t)
+
+	user := GetFirstUser(t)
+	CreateContacts(user, 20)
+
+	request := httptest.NewRequest(http.MethodGet, "/api/contacts?sort=first_name,-last_name&size=3", nil)
+	request.Header.Set("Accept", "application/json")
+	request.Header.Set("Authorization", user.Token)
+
+	response, err := app.Test(request)
+	assert.Nil(t, err)
+
+	bytes, err := io.ReadAll(response.Body)
+	assert.Nil(t, err)
+
+	responseBody := new(model.WebResponse[[]model.ContactResponse])
+	err = json.Unmarshal(bytes, responseBody)
+	assert.Nil(t, err)
+
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+	assert.Equal(t, 3, len(responseBody.Data))
+	assert.Equal(t, "9", responseBody.Data[0].LastName)
+	assert.Equal(t, "8", responseBody.Data[1].LastName)
+	assert.Equal(t, "7", responseBody.Data[2].LastName)
+}
+
+func TestSearchContactWithInvalidSort(t *testing.T) {
+	TestLogin(t)
+
+	user := GetFirstUser(t)
+
+	for _, sort := range []string{"password", "user_id", "last_name,,email", "-email,email", "last_name;DROP TABLE contacts"} {
+		request := httptest.NewRequest(http.MethodGet, "/api/contacts?sort="+url.QueryEscape(sort), nil)
+		request.Header.Set("Accept", "application/json")
+		request.Header.Set("Authorization", user.Token)
+
+		response, err := app.Test(request)
+		assert.Nil(t, err)
+		assert.Equal(t, http.StatusBadRequest, response.StatusCode, sort)
+	}
+}
+
+func TestSearchContactPageSizeTooLarge(t *testing.T) {
+	TestLogin(t)
+
+	user := new(entity.User)
+	err := db.Where("id = ?", "khannedy").First(user).Error
+	assert.Nil(t, err)
+
+	request := httptest.NewRequest(http.MethodGet, "/api/contacts?page=1&size=1000000", nil)
+	request.Header.Set("Accept", "application/json")
+	request.Header.Set("Authorization", user.Token)
+
+	response, err := app.Test(request)
+	assert.Nil(t, err)
+
+	bytes, err := io.ReadAll(response.Body)
+	assert.Nil(t, err)
+
+	responseBody := new(model.WebResponse[[]model.ContactResponse])
+	err = json.Unmarshal(bytes, responseBody)
+	assert.Nil(t, err)
+
+	assert.Equal(t, http.StatusBadRequest, response.StatusCode)
+	assert.Equal(t, "size exceeds the maximum page size", responseBody.Errors)
+}
+
+func TestCreateContactDryRun(t *testing.T) {
+	TestLogin(t)
+
+	user := new(entity.User)
+	err := db.Where("id = ?", "khannedy").First(user).Error
+	assert.Nil(t, err)
+
+	requestBody := model.CreateContactRequest{
+		FirstName: "Eko Kurniawan",
+		LastName:  "Khannedy",
+		Email:     "eko@example.com",
+		Phone:     "088888888888",
+	}
+	bodyJson, err := json.Marshal(requestBody)
+	assert.Nil(t, err)
+
+	request := httptest.NewRequest(http.MethodPost, "/api/contacts", strings.NewReader(string(bodyJson)))
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("Accept", "application/json")
+	request.Header.Set("Authorization", user.Token)
+	request.Header.Set("X-Dry-Run", "true")
+
+	response, err := app.Test(request)
+	assert.Nil(t, err)
+
+	bytes, err := io.ReadAll(response.Body)
+	assert.Nil(t, err)
+
+	responseBody := new(model.WebResponse[model.ContactResponse])
+	err = json.Unmarshal(bytes, responseBody)
+	assert.Nil(t, err)
+
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+	assert.Equal(t, "true", response.Header.Get("X-Dry-Run"))
+	assert.Equal(t, requestBody.FirstName, responseBody.Data.FirstName)
+
+	var total int64
+	err = db.Model(&entity.Contact{}).Where("user_id = ?", user.ID).Count(&total).Error
+	assert.Nil(t, err)
+	assert.Equal(t, int64(0), total)
+}
+
+func TestSuggestContacts(t *testing.T) {
+	TestCreateContact(t)
+
+	user := new(entity.User)
+	err := db.Where("id = ?", "khannedy").First(user).Error
+	assert.Nil(t, err)
+
+	CreateContacts(user, 5)
+
+	request := httptest.NewRequest(http.MethodGet, "/api/contacts/_suggest?q=KHA", nil)
+	request.Header.Set("Accept", "application/json")
+	request.Header.Set("Authorization", user.Token)
+
+	response, err := app.Test(request)
+	assert.Nil(t, err)
+
+	bytes, err := io.ReadAll(response.Body)
+	assert.Nil(t, err)
+
+	responseBody := new(model.WebResponse[[]model.ContactSuggestionResponse])
+	err = json.Unmarshal(bytes, responseBody)
+	assert.Nil(t, err)
+
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+	assert.Equal(t, 1, len(responseBody.Data))
+	assert.Equal(t, "Eko Kurniawan Khannedy", responseBody.Data[0].Name)
+	assert.Equal(t, "eko@example.com", responseBody.Data[0].Email)
+}
+
+func TestSuggestContactsWildcards(t *testing.T) {
+	ClearAll()
+	TestLogin(t)
+	user := GetFirstUser(t)
+
+	for _, name := range []string{"john_doe", "johnxdoe", `john\doe`} {
+		err := db.Create(&entity.Contact{ID: uuid.NewString(), FirstName: name, UserId: user.ID}).Error
+		assert.Nil(t, err)
+	}
+
+	// wildcards in the query match themselves only
+	for query, expected := range map[string]string{"john_": "john_doe", `john\`: `john\doe`, "johnx": "johnxdoe"} {
+		request := httptest.NewRequest(http.MethodGet, "/api/contacts/_suggest?q="+url.QueryEscape(query), nil)
+		request.Header.Set("Accept", "application/json")
+		request.Header.Set("Authorization", user.Token)
+
+		response, err := app.Test(request)
+		assert.Nil(t, err)
+		assert.Equal(t, http.StatusOK, response.StatusCode)
+
+		bytes, err := io.ReadAll(response.Body)
+		assert.Nil(t, err)
+
+		responseBody := new(model.WebResponse[[]model.ContactSuggestionResponse])
+		err = json.Unmarshal(bytes, responseBody)
+		assert.Nil(t, err)
+
+		if assert.Equal(t, 1, len(responseBody.Data), query) {
+			assert.Equal(t, expected, responseBody.Data[0].Name)
+		}
+	}
+}
+
+func TestSuggestContactsWithoutQuery(t *testing.T) {
+	TestLogin(t)
+
+	user := new(entity.User)
+	err := db.Where("id = ?", "khannedy").First(user).Error
+	assert.Nil(t, err)
+
+	request := httptest.NewRequest(http.MethodGet, "/api/contacts/_suggest", nil)
+	request.Header.Set("Accept", "application/json")
+	request.Header.Set("Authorization", user.Token)
+
+	response, err := app.Test(request)
+	assert.Nil(t, err)
+
+	assert.Equal(t, http.StatusBadRequest, response.StatusCode)
+}
+
+func TestContactIndex(t *testing.T) {
+	TestCreateContact(t)
+
+	user := new(entity.User)
+	err := db.Where("id = ?", "khannedy").First(user).Error
+	assert.Nil(t, err)
+
+	CreateContacts(user, 3)
+
+	request := httptest.NewRequest(http.MethodGet, "/api/contacts/_index", nil)
+	request.Header.Set("Accept", "application/json")
+	request.Header.Set("Authorization", user.Token)
+
+	response, err := app.Test(request)
+	assert.Nil(t, err)
+
+	bytes, err := io.ReadAll(response.Body)
+	assert.Nil(t, err)
+
+	responseBody := new(model.WebResponse[[]model.ContactIndexResponse])
+	err = json.Unmarshal(bytes, responseBody)
+	assert.Nil(t, err)
+
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+	assert.Equal(t, 27, len(responseBody.Data))
+	assert.Equal(t, model.ContactIndexResponse{Letter: "C", Count: 3}, responseBody.Data[2])
+	assert.Equal(t, model.ContactIndexResponse{Letter: "E", Count: 1}, responseBody.Data[4])
+	assert.Equal(t, model.ContactIndexResponse{Letter: "#", Count: 0}, responseBody.Data[26])
+}
+
+func TestSearchContactByLetter(t *testing.T) {
+	TestCreateContact(t)
+
+	user := new(entity.User)
+	err := db.Where("id = ?", "khannedy").First(user).Error
+	assert.Nil(t, err)
+
+	CreateContacts(user, 3)
+
+	request := httptest.NewRequest(http.MethodGet, "/api/contacts?letter=e", nil)
+	request.Header.Set("Accept", "application/json")
+	request.Header.Set("Authorization", user.Token)
+
+	response, err := app.Test(request)
+	assert.Nil(t, err)
+
+	bytes, err := io.ReadAll(response.Body)
+	assert.Nil(t, err)
+
+	responseBody := new(model.WebResponse[[]model.ContactResponse])
+	err = json.Unmarshal(bytes, responseBody)
+	assert.Nil(t, err)
+
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+	assert.Equal(t, int64(1), responseBody.Paging.TotalItem)
+	assert.Equal(t, "Eko Kurniawan", responseBody.Data[0].FirstName)
+}
+
+func searchContactPage(t *testing.T, user *entity.User, query string) *model.WebResponse[[]model.ContactResponse] {
+	request := httptest.NewRequest(http.MethodGet, "/api/contacts?"+query, nil)
+	request.Header.Set("Accept", "application/json")
+	request.Header.Set("Authorization", user.Token)
+
+	response, err := app.Test(request)
+	assert.Nil(t, err)
+
+	bytes, err := io.ReadAll(response.Body)
+	assert.Nil(t, err)
+
+	responseBody := new(model.WebResponse[[]model.ContactResponse])
+	err = json.Unmarshal(bytes, responseBody)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+
+	return responseBody
+}
+
+func TestSearchContactWithCursor(t *testing.T) {
+	TestLogin(t)
+
+	user := new(entity.User)
+	err := db.Where("id = ?", "khannedy").First(user).Error
+	assert.Nil(t, err)
+
+	CreateContacts(user, 12)
+
+	first := searchContactPage(t, user, "cursor=&size=5&name=Contact")
+	assert.Equal(t, 5, len(first.Data))
+	assert.NotEmpty(t, first.Paging.NextCursor)
+	assert.Empty(t, first.Paging.PrevCursor)
+
+	second := searchContactPage(t, user, "size=5&name=Contact&cursor="+first.Paging.NextCursor)
+	assert.Equal(t, 5, len(second.Data))
+	assert.NotEmpty(t, second.Paging.PrevCursor)
+
+	last := searchContactPage(t, user, "size=5&name=Contact&cursor="+second.Paging.NextCursor)
+	assert.Equal(t, 2, len(last.Data))
+	assert.Empty(t, last.Paging.NextCursor)
+
+	seen := map[string]bool{}
+	for _, page := range [][]model.ContactResponse{first.Data, second.Data, last.Data} {
+		for _, contact := range page {
+			seen[contact.ID] = true
+		}
+	}
+	assert.Equal(t, 12, len(seen))
+
+	back := searchContactPage(t, user, "size=5&name=Contact&cursor="+last.Paging.PrevCursor)
+	assert.Equal(t, second.Data, back.Data)
+	assert.Equal(t, second.Paging.PrevCursor, back.Paging.PrevCursor)
+
+	back = searchContactPage(t, user, "size=5&name=Contact&cursor="+back.Paging.PrevCursor)
+	assert.Equal(t, first.Data, back.Data)
+	assert.Empty(t, back.Paging.PrevCursor)
+}
+
+func TestSearchContactWithInvalidCursor(t *testing.T) {
+	TestLogin(t)
+
+	user := new(entity.User)
+	err := db.Where("id = ?", "khannedy").First(user).Error
+	assert.Nil(t, err)
+
+	CreateContacts(user, 3)
+
+	first := searchContactPage(t, user, "cursor=&size=2")
+
+	for _, query := range []string{"cursor=not-a-cursor", "letter=C&cursor=" + first.Paging.NextCursor} {
+		request := httptest.NewRequest(http.MethodGet, "/api/contacts?"+query, nil)
+		request.Header.Set("Accept", "application/json")
+		request.Header.Set("Authorization", user.Token)
+
+		response, err := app.Test(request)
+		assert.Nil(t, err)
+		assert.Equal(t, http.StatusBadRequest, response.StatusCode, query)
+	}
+}
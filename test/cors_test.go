@@ -0,0 +1,84 @@
+package test
+
+import (
+	"go-rest-scaffold/internal/config"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCORSPreflight(t *testing.T) {
+	request := httptest.NewRequest(http.MethodOptions, "/api/contacts", nil)
+	request.Header.Set("Origin", "http://localhost:5173")
+	request.Header.Set("Access-Control-Request-Method", http.MethodPost)
+	request.Header.Set("Access-Control-Request-Headers", "Authorization, Idempotency-Key")
+
+	response, err := app.Test(request)
+	assert.Nil(t, err)
+
+	// config.json is for development, where any origin is allowed
+	assert.Equal(t, http.StatusNoContent, response.StatusCode)
+	assert.Equal(t, "*", response.Header.Get("Access-Control-Allow-Origin"))
+	assert.Contains(t, response.Header.Get("Access-Control-Allow-Methods"), http.MethodPost)
+	assert.Contains(t, response.Header.Get("Access-Control-Allow-Headers"), "Idempotency-Key")
+	assert.Equal(t, "600", response.Header.Get("Access-Control-Max-Age"))
+}
+
+func TestCORSAllowedOrigins(t *testing.T) {
+	corsConfig := viper.New()
+	corsConfig.Set("app.environment", "production")
+	corsConfig.Set("cors.allow_origins", []string{"https://app.example.com"})
+	corsConfig.Set("cors.expose_headers", []string{"ETag", "API-Version"})
+	corsConfig.Set("cors.allow_credentials", true)
+
+	corsApp := fiber.New()
+	corsApp.Use(config.NewCORS(corsConfig, log))
+	corsApp.Get("/api/ping", func(ctx *fiber.Ctx) error {
+		ctx.Set(fiber.HeaderETag, `"1"`)
+		return ctx.SendString("pong")
+	})
+
+	request := httptest.NewRequest(http.MethodGet, "/api/ping", nil)
+	request.Header.Set("Origin", "https://app.example.com")
+
+	response, err := corsApp.Test(request)
+	assert.Nil(t, err)
+
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+	assert.Equal(t, "https://app.example.com", response.Header.Get("Access-Control-Allow-Origin"))
+	assert.Equal(t, "true", response.Header.Get("Access-Control-Allow-Credentials"))
+	assert.True(t, strings.Contains(response.Header.Get("Access-Control-Expose-Headers"), "ETag"))
+
+	request = httptest.NewRequest(http.MethodGet, "/api/ping", nil)
+	request.Header.Set("Origin", "https://evil.example.org")
+
+	response, err = corsApp.Test(request)
+	assert.Nil(t, err)
+
+	assert.Empty(t, response.Header.Get("Access-Control-Allow-Origin"))
+}
+
+func TestCORSProductionWithoutOrigins(t *testing.T) {
+	corsConfig := viper.New()
+	corsConfig.Set("app.environment", "production")
+
+	corsApp := fiber.New()
+	corsApp.Use(config.NewCORS(corsConfig, log))
+	corsApp.Get("/api/ping", func(ctx *fiber.Ctx) error {
+		return ctx.SendString("pong")
+	})
+
+	request := httptest.NewRequest(http.MethodGet, "/api/ping", nil)
+	request.Header.Set("Origin", "https://app.example.com")
+
+	response, err := corsApp.Test(request)
+	assert.Nil(t, err)
+
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+	assert.Empty(t, response.Header.Get("Access-Control-Allow-Origin"))
+}
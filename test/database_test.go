@@ -0,0 +1,61 @@
+package test
+
+import (
+	"bytes"
+	"go-rest-scaffold/internal/config"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+func TestMigrateSQLite(t *testing.T) {
+	t.Setenv("DB_NAME", filepath.Join(t.TempDir(), "scaffold.db"))
+
+	sqliteConfig := viper.New()
+	sqliteConfig.Set("database.driver", "sqlite")
+
+	err := config.MigrateUp(sqliteConfig, log)
+	assert.Nil(t, err)
+
+	output := new(bytes.Buffer)
+	err = config.MigrationStatus(sqliteConfig, log, output)
+	assert.Nil(t, err)
+	assert.True(t, strings.Contains(output.String(), ", 0 pending"))
+
+	err = config.MigrateDown(sqliteConfig, log)
+	assert.Nil(t, err)
+
+	output.Reset()
+	err = config.MigrationStatus(sqliteConfig, log, output)
+	assert.Nil(t, err)
+	assert.True(t, strings.Contains(output.String(), ", 1 pending"))
+}
+
+func TestDatabasePreparedStatements(t *testing.T) {
+	assert.Equal(t, viperConfig.GetBool("database.prepare_stmt"), db.PrepareStmt)
+	if !db.PrepareStmt {
+		t.Skip("database.prepare_stmt is disabled")
+	}
+
+	preparedDB, ok := db.ConnPool.(*gorm.PreparedStmtDB)
+	assert.True(t, ok)
+
+	// a query run twice is prepared once
+	query := "SELECT count(*) FROM users WHERE id = ? -- prepared statement test"
+	for range 2 {
+		var total int64
+		assert.Nil(t, db.Raw(query, "khannedy").Scan(&total).Error)
+	}
+
+	var prepared int
+	for _, sql := range preparedDB.Stmts.Keys() {
+		if strings.Contains(sql, "prepared statement test") {
+			prepared++
+		}
+	}
+	assert.Equal(t, 1, prepared)
+}
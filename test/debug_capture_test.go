@@ -0,0 +1,70 @@
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"go-rest-scaffold/internal/entity"
+	"go-rest-scaffold/internal/model"
+	"go-rest-scaffold/internal/repository"
+	"go-rest-scaffold/internal/usecase"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDebugCaptureIsRedacted(t *testing.T) {
+	ClearAll()
+	TestLogin(t)
+
+	user := new(entity.User)
+	err := db.Where("id = ?", "khannedy").First(user).Error
+	assert.Nil(t, err)
+
+	err = db.Model(user).Update("role", model.RoleAdmin).Error
+	assert.Nil(t, err)
+
+	debugCaptureUseCase := usecase.NewDebugCaptureUseCase(txManager, log, validate, repository.NewDebugCaptureRepository(log), usecase.DebugCaptureOptions{
+		TTL:           time.Hour,
+		RedactFields:  []string{"email"},
+		RedactHeaders: []string{"Authorization"},
+	})
+
+	err = debugCaptureUseCase.Record(context.Background(), &model.RecordDebugCaptureRequest{
+		UserId:          user.ID,
+		Method:          http.MethodPost,
+		Path:            "/api/contacts",
+		Query:           "email=eko@example.com",
+		Status:          http.StatusOK,
+		RequestHeaders:  map[string]string{"Authorization": user.Token},
+		RequestBody:     []byte(`{"first_name":"Eko","email":"eko@example.com"}`),
+		RequestType:     "application/json",
+		ResponseHeaders: map[string]string{},
+	})
+	assert.Nil(t, err)
+
+	request := httptest.NewRequest(http.MethodGet, "/api/admin/captures?user_id="+user.ID, nil)
+	request.Header.Set("Accept", "application/json")
+	request.Header.Set("Authorization", user.Token)
+
+	response, err := app.Test(request)
+	assert.Nil(t, err)
+
+	bytes, err := io.ReadAll(response.Body)
+	assert.Nil(t, err)
+
+	responseBody := new(model.WebResponse[[]model.DebugCaptureResponse])
+	err = json.Unmarshal(bytes, responseBody)
+	assert.Nil(t, err)
+
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+	assert.Equal(t, 1, len(responseBody.Data))
+	assert.Equal(t, "[REDACTED]", responseBody.Data[0].RequestHeaders["Authorization"])
+	assert.True(t, strings.Contains(responseBody.Data[0].RequestBody, `"first_name":"Eko"`))
+	assert.False(t, strings.Contains(responseBody.Data[0].RequestBody, "eko@example.com"))
+	assert.False(t, strings.Contains(responseBody.Data[0].Path, "eko@example.com"))
+}
@@ -0,0 +1,74 @@
+package test
+
+import (
+	"encoding/json"
+	"go-rest-scaffold/internal/buildinfo"
+	"go-rest-scaffold/internal/model"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSecurityTxt(t *testing.T) {
+	request := httptest.NewRequest(http.MethodGet, "/.well-known/security.txt", nil)
+
+	response, err := app.Test(request)
+	assert.Nil(t, err)
+
+	bytes, err := io.ReadAll(response.Body)
+	assert.Nil(t, err)
+
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+	assert.True(t, strings.Contains(string(bytes), "Contact: "))
+	assert.True(t, strings.Contains(string(bytes), "Expires: "))
+}
+
+func TestApiMetadata(t *testing.T) {
+	request := httptest.NewRequest(http.MethodGet, "/api/_meta", nil)
+	request.Header.Set("Accept", "application/json")
+
+	response, err := app.Test(request)
+	assert.Nil(t, err)
+
+	bytes, err := io.ReadAll(response.Body)
+	assert.Nil(t, err)
+
+	responseBody := new(model.WebResponse[model.APIMetadataResponse])
+	err = json.Unmarshal(bytes, responseBody)
+	assert.Nil(t, err)
+
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+	assert.Equal(t, []model.APIVersion{
+		{Name: "v1", BasePath: "/api/v1", Default: true},
+		{Name: "v2", BasePath: "/api/v2"},
+	}, responseBody.Data.Versions)
+	assert.Contains(t, responseBody.Data.Endpoints, model.EndpointResponse{Method: http.MethodGet, Path: "/api/v1/contacts"})
+	assert.Contains(t, responseBody.Data.Endpoints, model.EndpointResponse{Method: http.MethodGet, Path: "/api/v2/contacts"})
+}
+
+func TestVersion(t *testing.T) {
+	request := httptest.NewRequest(http.MethodGet, "/version", nil)
+	request.Header.Set("Accept", "application/json")
+
+	response, err := app.Test(request)
+	assert.Nil(t, err)
+
+	bytes, err := io.ReadAll(response.Body)
+	assert.Nil(t, err)
+
+	responseBody := new(model.WebResponse[model.BuildInfoResponse])
+	err = json.Unmarshal(bytes, responseBody)
+	assert.Nil(t, err)
+
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+	assert.Equal(t, buildinfo.Version, responseBody.Data.Version)
+	assert.Equal(t, buildinfo.Commit, responseBody.Data.Commit)
+	assert.NotEmpty(t, responseBody.Data.GoVersion)
+
+	_, metrics := scrapeMetrics(t)
+	assert.True(t, strings.Contains(metrics, `build_info{build_time="`+buildinfo.BuildTime+`",commit="`+buildinfo.Commit+`"`))
+}
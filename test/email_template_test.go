@@ -0,0 +1,121 @@
+package test
+
+import (
+	"encoding/json"
+	"go-rest-scaffold/internal/entity"
+	"go-rest-scaffold/internal/model"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPreviewEmailTemplateOverride(t *testing.T) {
+	ClearAll()
+	TestLogin(t)
+
+	user := new(entity.User)
+	err := db.Where("id = ?", "khannedy").First(user).Error
+	assert.Nil(t, err)
+
+	err = db.Model(user).Update("role", model.RoleAdmin).Error
+	assert.Nil(t, err)
+
+	requestBody := model.UpdateEmailTemplateRequest{
+		Tenant:  "acme",
+		Subject: "Welcome to Acme, {{.Name}}",
+		HTML:    "<p>Confirm at <a href=\"{{.Link}}\">{{.Link}}</a></p>",
+		Text:    "Confirm at {{.Link}}",
+	}
+	bodyJson, err := json.Marshal(requestBody)
+	assert.Nil(t, err)
+
+	request := httptest.NewRequest(http.MethodPut, "/api/admin/email-templates/verification", strings.NewReader(string(bodyJson)))
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("Accept", "application/json")
+	request.Header.Set("Authorization", user.Token)
+
+	response, err := app.Test(request)
+	assert.Nil(t, err)
+
+	bytes, err := io.ReadAll(response.Body)
+	assert.Nil(t, err)
+
+	templateBody := new(model.WebResponse[model.EmailTemplateResponse])
+	err = json.Unmarshal(bytes, templateBody)
+	assert.Nil(t, err)
+
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+	assert.Equal(t, model.EmailTemplateSourceOverride, templateBody.Data.Source)
+	assert.Equal(t, 1, templateBody.Data.Version)
+
+	request = httptest.NewRequest(http.MethodPost, "/api/admin/email-templates/verification/preview", strings.NewReader(`{"tenant":"acme","data":{"Name":"Budi"}}`))
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("Accept", "application/json")
+	request.Header.Set("Authorization", user.Token)
+
+	response, err = app.Test(request)
+	assert.Nil(t, err)
+
+	bytes, err = io.ReadAll(response.Body)
+	assert.Nil(t, err)
+
+	previewBody := new(model.WebResponse[model.RenderedEmailResponse])
+	err = json.Unmarshal(bytes, previewBody)
+	assert.Nil(t, err)
+
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+	assert.Equal(t, "Welcome to Acme, Budi", previewBody.Data.Subject)
+	assert.Equal(t, "Confirm at https://example.com/verify?token=sample", previewBody.Data.Text)
+
+	// other tenants keep the built-in template
+	request = httptest.NewRequest(http.MethodGet, "/api/admin/email-templates/verification?tenant=globex", nil)
+	request.Header.Set("Accept", "application/json")
+	request.Header.Set("Authorization", user.Token)
+
+	response, err = app.Test(request)
+	assert.Nil(t, err)
+
+	bytes, err = io.ReadAll(response.Body)
+	assert.Nil(t, err)
+
+	templateBody = new(model.WebResponse[model.EmailTemplateResponse])
+	err = json.Unmarshal(bytes, templateBody)
+	assert.Nil(t, err)
+
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+	assert.Equal(t, model.EmailTemplateSourceEmbedded, templateBody.Data.Source)
+}
+
+func TestUpdateEmailTemplateInvalid(t *testing.T) {
+	ClearAll()
+	TestLogin(t)
+
+	user := new(entity.User)
+	err := db.Where("id = ?", "khannedy").First(user).Error
+	assert.Nil(t, err)
+
+	err = db.Model(user).Update("role", model.RoleAdmin).Error
+	assert.Nil(t, err)
+
+	requestBody := model.UpdateEmailTemplateRequest{
+		Subject: "Reset your password",
+		HTML:    "<p>{{.Lnk}}</p>",
+		Text:    "{{.Link}}",
+	}
+	bodyJson, err := json.Marshal(requestBody)
+	assert.Nil(t, err)
+
+	request := httptest.NewRequest(http.MethodPut, "/api/admin/email-templates/reset", strings.NewReader(string(bodyJson)))
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("Accept", "application/json")
+	request.Header.Set("Authorization", user.Token)
+
+	response, err := app.Test(request)
+	assert.Nil(t, err)
+
+	assert.Equal(t, http.StatusBadRequest, response.StatusCode)
+}
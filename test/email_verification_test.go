@@ -0,0 +1,80 @@
+package test
+
+import (
+	"context"
+	"go-rest-scaffold/internal/config"
+	"go-rest-scaffold/internal/model"
+	"go-rest-scaffold/internal/repository"
+	"go-rest-scaffold/internal/usecase"
+	"net/url"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func newVerifyingUserUseCase(sender recordingSender) *usecase.UserUseCase {
+	emailTemplateUseCase := usecase.NewEmailTemplateUseCase(txManager, log, validate, config.NewMailCatalog(viperConfig, log), repository.NewEmailTemplateRepository(log))
+	return usecase.NewUserUseCase(txManager, log, validate, repository.NewUserRepository(log), repository.NewSessionRepository(log), repository.NewPasswordResetRepository(log), nil, nil, nil,
+		emailTemplateUseCase, sender, nil, nil, nil, nil, usecase.UserOptions{
+			VerificationURL:      "https://example.com/verify",
+			VerificationTTL:      24 * time.Hour,
+			VerificationRequired: true,
+			VerificationSecret:   []byte("test-secret"),
+		})
+}
+
+func TestVerifyEmail(t *testing.T) {
+	ClearAll()
+
+	sender := make(recordingSender, 1)
+	userUseCase := newVerifyingUserUseCase(sender)
+
+	_, err := userUseCase.Create(context.Background(), &model.RegisterUserRequest{
+		ID:       "khannedy",
+		Password: "rahasia",
+		Name:     "Eko Khannedy",
+		Email:    "khannedy@example.com",
+	})
+	assert.Nil(t, err)
+
+	var email sentMail
+	select {
+	case email = <-sender:
+	case <-time.After(5 * time.Second):
+		t.Fatal("verification email was not sent")
+	}
+	assert.Equal(t, "khannedy@example.com", email.to)
+
+	_, err = userUseCase.Login(context.Background(), &model.LoginUserRequest{ID: "khannedy", Password: "rahasia"})
+	assert.Equal(t, fiber.StatusForbidden, err.(*fiber.Error).Code)
+
+	token, err := url.QueryUnescape(regexp.MustCompile(`token=([^\s&]+)`).FindStringSubmatch(email.message.Text)[1])
+	assert.Nil(t, err)
+
+	_, err = userUseCase.VerifyEmail(context.Background(), &model.VerifyEmailRequest{Token: token + "x"})
+	assert.NotNil(t, err)
+
+	verified, err := userUseCase.VerifyEmail(context.Background(), &model.VerifyEmailRequest{Token: token})
+	assert.Nil(t, err)
+	assert.True(t, verified)
+
+	response, err := userUseCase.Login(context.Background(), &model.LoginUserRequest{ID: "khannedy", Password: "rahasia"})
+	assert.Nil(t, err)
+	assert.NotEmpty(t, response.Token)
+}
+
+func TestRegisterWithoutEmailWhenVerificationRequired(t *testing.T) {
+	ClearAll()
+
+	userUseCase := newVerifyingUserUseCase(make(recordingSender, 1))
+
+	_, err := userUseCase.Create(context.Background(), &model.RegisterUserRequest{
+		ID:       "khannedy",
+		Password: "rahasia",
+		Name:     "Eko Khannedy",
+	})
+	assert.Equal(t, fiber.StatusBadRequest, err.(*fiber.Error).Code)
+}
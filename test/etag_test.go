@@ -0,0 +1,137 @@
+package test
+
+import (
+	"go-rest-scaffold/internal/delivery/http/middleware"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestContactETag(t *testing.T) {
+	ClearAll()
+	TestLogin(t)
+	user := GetFirstUser(t)
+	CreateContacts(user, 1)
+	contact := GetFirstContact(t, user)
+
+	request := httptest.NewRequest(http.MethodGet, "/api/contacts/"+contact.ID, nil)
+	request.Header.Set("Accept", "application/json")
+	request.Header.Set("Authorization", user.Token)
+
+	response, err := app.Test(request)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+	assert.Equal(t, `"1"`, response.Header.Get("ETag"))
+
+	request = httptest.NewRequest(http.MethodGet, "/api/contacts/"+contact.ID, nil)
+	request.Header.Set("Accept", "application/json")
+	request.Header.Set("Authorization", user.Token)
+	request.Header.Set("If-None-Match", `"1"`)
+
+	response, err = app.Test(request)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusNotModified, response.StatusCode)
+
+	update := func(ifMatch string) *http.Response {
+		request := httptest.NewRequest(http.MethodPut, "/api/contacts/"+contact.ID, strings.NewReader(`{"first_name":"Eko","email":"eko@example.com"}`))
+		request.Header.Set("Content-Type", "application/json")
+		request.Header.Set("Accept", "application/json")
+		request.Header.Set("Authorization", user.Token)
+		request.Header.Set("If-Match", ifMatch)
+
+		response, err := app.Test(request)
+		assert.Nil(t, err)
+		return response
+	}
+
+	response = update(`"1"`)
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+	assert.Equal(t, `"2"`, response.Header.Get("ETag"))
+
+	// the first change moved the contact past the version the second one read
+	assert.Equal(t, http.StatusPreconditionFailed, update(`"1"`).StatusCode)
+	assert.Equal(t, http.StatusPreconditionFailed, update(`W/"2"`).StatusCode)
+	assert.Equal(t, http.StatusOK, update("*").StatusCode)
+
+	request = httptest.NewRequest(http.MethodGet, "/api/contacts/"+contact.ID, nil)
+	request.Header.Set("Accept", "application/json")
+	request.Header.Set("Authorization", user.Token)
+	request.Header.Set("If-None-Match", `"2"`)
+
+	response, err = app.Test(request)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+	assert.Equal(t, `"3"`, response.Header.Get("ETag"))
+
+	request = httptest.NewRequest(http.MethodDelete, "/api/contacts/"+contact.ID, nil)
+	request.Header.Set("Accept", "application/json")
+	request.Header.Set("Authorization", user.Token)
+	request.Header.Set("If-Match", `"2"`)
+
+	response, err = app.Test(request)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusPreconditionFailed, response.StatusCode)
+
+	request.Header.Set("If-Match", `"3"`)
+	response, err = app.Test(request)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+}
+
+func TestAddressETag(t *testing.T) {
+	ClearAll()
+	TestLogin(t)
+	user := GetFirstUser(t)
+	CreateContacts(user, 1)
+	contact := GetFirstContact(t, user)
+	CreateAddresses(t, contact, 1)
+	address := GetFirstAddress(t, contact)
+
+	patch := func(ifMatch string) *http.Response {
+		request := httptest.NewRequest(http.MethodPatch, "/api/contacts/"+contact.ID+"/addresses/"+address.ID, strings.NewReader(`{"city":"Bandung"}`))
+		request.Header.Set("Content-Type", "application/json")
+		request.Header.Set("Accept", "application/json")
+		request.Header.Set("Authorization", user.Token)
+		request.Header.Set("If-Match", ifMatch)
+
+		response, err := app.Test(request)
+		assert.Nil(t, err)
+		return response
+	}
+
+	response := patch(`"1"`)
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+	assert.Equal(t, `"2"`, response.Header.Get("ETag"))
+	assert.Equal(t, http.StatusPreconditionFailed, patch(`"1"`).StatusCode)
+
+	request := httptest.NewRequest(http.MethodGet, "/api/contacts/"+contact.ID+"/addresses/"+address.ID, nil)
+	request.Header.Set("Accept", "application/json")
+	request.Header.Set("Authorization", user.Token)
+	request.Header.Set("If-None-Match", `W/"2"`)
+
+	response, err := app.Test(request)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusNotModified, response.StatusCode)
+}
+
+func TestRequireIfMatch(t *testing.T) {
+	conditionalApp := fiber.New()
+	conditionalApp.Put("/api/contacts/:contactId", middleware.NewRequireIfMatch(true), func(ctx *fiber.Ctx) error {
+		return ctx.SendString("updated")
+	})
+
+	response, err := conditionalApp.Test(httptest.NewRequest(http.MethodPut, "/api/contacts/1", nil))
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusPreconditionRequired, response.StatusCode)
+
+	request := httptest.NewRequest(http.MethodPut, "/api/contacts/1", nil)
+	request.Header.Set("If-Match", `"1"`)
+
+	response, err = conditionalApp.Test(request)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+}
@@ -0,0 +1,119 @@
+package test
+
+import (
+	"bufio"
+	"go-rest-scaffold/internal/model"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// openEventStream connects to /events and waits until the stream is open.
+func openEventStream(t *testing.T, address string, token string, lastEventId string) (*http.Response, *bufio.Reader) {
+	request, err := http.NewRequest(http.MethodGet, "http://"+address+"/events?access_token="+token, nil)
+	assert.Nil(t, err)
+	if lastEventId != "" {
+		request.Header.Set("Last-Event-ID", lastEventId)
+	}
+
+	response, err := http.DefaultClient.Do(request)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+	assert.Equal(t, "text/event-stream", response.Header.Get("Content-Type"))
+	return response, bufio.NewReader(response.Body)
+}
+
+// readServerSentEvent returns the fields of the next event of the stream, skipping comments.
+func readServerSentEvent(t *testing.T, reader *bufio.Reader) map[string]string {
+	fields := make(map[string]string)
+	for {
+		line, err := reader.ReadString('\n')
+		assert.Nil(t, err)
+		line = strings.TrimSuffix(line, "\n")
+
+		if line == "" {
+			if len(fields) > 0 {
+				return fields
+			}
+			continue
+		}
+		if !strings.HasPrefix(line, ":") {
+			name, value, _ := strings.Cut(line, ": ")
+			fields[name] = value
+		}
+	}
+}
+
+func createContactNamed(t *testing.T, token string, firstName string) {
+	request := httptest.NewRequest(http.MethodPost, "/api/contacts", strings.NewReader(`{"first_name":"`+firstName+`","email":"eko@example.com"}`))
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("Accept", "application/json")
+	request.Header.Set("Authorization", token)
+	response, err := app.Test(request)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+}
+
+func TestEventStream(t *testing.T) {
+	ClearAll()
+	TestRegister(t)
+	token := loginWithScopes(t, "null")
+	address := listen(t)
+
+	response, reader := openEventStream(t, address, token, "")
+	createContactNamed(t, token, "Eko")
+
+	first := readServerSentEvent(t, reader)
+	assert.Equal(t, model.EventContactCreated, first["event"])
+	assert.NotEmpty(t, first["id"])
+	assert.Contains(t, first["data"], `"first_name":"Eko"`)
+	response.Body.Close()
+
+	// missed while disconnected
+	createContactNamed(t, token, "Budi")
+
+	response, reader = openEventStream(t, address, token, first["id"])
+	resumed := readServerSentEvent(t, reader)
+	assert.Equal(t, model.EventContactCreated, resumed["event"])
+	assert.Contains(t, resumed["data"], `"first_name":"Budi"`)
+	response.Body.Close()
+
+	response, reader = openEventStream(t, address, token, "forgotten")
+	assert.Equal(t, "resync", readServerSentEvent(t, reader)["event"])
+	response.Body.Close()
+}
+
+func TestEventStreamScopes(t *testing.T) {
+	ClearAll()
+	TestRegister(t)
+	address := listen(t)
+
+	token := loginWithScopes(t, `["webhooks:read"]`)
+	request, err := http.NewRequest(http.MethodGet, "http://"+address+"/events", nil)
+	assert.Nil(t, err)
+	request.Header.Set("Authorization", token)
+	response, err := http.DefaultClient.Do(request)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusForbidden, response.StatusCode)
+
+	// contact events are left out without contacts:read
+	token = loginWithScopes(t, `["addresses:read","contacts:write"]`)
+	response, reader := openEventStream(t, address, token, "")
+	defer response.Body.Close()
+	createContactNamed(t, token, "Eko")
+
+	contact := new(struct{ ID string })
+	assert.Nil(t, db.Table("contacts").Select("id").Take(contact).Error)
+	request = httptest.NewRequest(http.MethodPost, "/api/contacts/"+contact.ID+"/addresses", strings.NewReader(`{"street":"Jalan"}`))
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("Accept", "application/json")
+	request.Header.Set("Authorization", loginWithScopes(t, "null"))
+	response, err = app.Test(request)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+
+	assert.Equal(t, model.EventAddressCreated, readServerSentEvent(t, reader)["event"])
+}
@@ -0,0 +1,39 @@
+package test
+
+import (
+	"go-rest-scaffold/internal/model"
+	"go-rest-scaffold/internal/usecase"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAssignVariantIsDeterministic(t *testing.T) {
+	experiment := model.Experiment{
+		Key:      "contact_search",
+		Enabled:  true,
+		Variants: []model.ExperimentVariant{{Name: "control"}, {Name: "treatment"}},
+	}
+
+	variant := usecase.AssignVariant(experiment, "khannedy")
+	for i := 0; i < 10; i++ {
+		assert.Equal(t, variant, usecase.AssignVariant(experiment, "khannedy"))
+	}
+}
+
+func TestAssignVariantFollowsWeights(t *testing.T) {
+	experiment := model.Experiment{
+		Key:      "contact_search",
+		Enabled:  true,
+		Variants: []model.ExperimentVariant{{Name: "control", Weight: 90}, {Name: "treatment", Weight: 10}},
+	}
+
+	counts := map[string]int{}
+	for i := 0; i < 10000; i++ {
+		counts[usecase.AssignVariant(experiment, "user-"+strconv.Itoa(i))]++
+	}
+
+	assert.InDelta(t, 9000, counts["control"], 300)
+	assert.InDelta(t, 1000, counts["treatment"], 300)
+}
@@ -0,0 +1,65 @@
+package test
+
+import (
+	"go-rest-scaffold/internal/delivery/http/middleware"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+)
+
+func newFaultApp(rules []map[string]any) *fiber.App {
+	faultConfig := viper.New()
+	faultConfig.Set("fault_injection.enabled", true)
+	faultConfig.Set("fault_injection.rules", rules)
+
+	faultApp := fiber.New()
+	faultApp.Use(middleware.NewFaultInjection(faultConfig, log))
+	faultApp.Get("/api/contacts", func(ctx *fiber.Ctx) error {
+		return ctx.SendString("ok")
+	})
+	faultApp.Get("/api/users/_current", func(ctx *fiber.Ctx) error {
+		return ctx.SendString("ok")
+	})
+	return faultApp
+}
+
+func TestFaultInjectionError(t *testing.T) {
+	faultApp := newFaultApp([]map[string]any{
+		{"method": "GET", "path": "/api/contacts*", "error_status": 503, "error_probability": 1},
+	})
+
+	response, err := faultApp.Test(httptest.NewRequest(http.MethodGet, "/api/contacts", nil))
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusServiceUnavailable, response.StatusCode)
+	assert.Equal(t, "error", response.Header.Get("X-Fault-Injected"))
+
+	response, err = faultApp.Test(httptest.NewRequest(http.MethodGet, "/api/users/_current", nil))
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+}
+
+func TestFaultInjectionLatency(t *testing.T) {
+	faultApp := newFaultApp([]map[string]any{
+		{"path": "/api/contacts", "latency_ms": 200, "latency_probability": 1},
+	})
+
+	start := time.Now()
+	response, err := faultApp.Test(httptest.NewRequest(http.MethodGet, "/api/contacts", nil))
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+	assert.GreaterOrEqual(t, time.Since(start), 200*time.Millisecond)
+}
+
+func TestFaultInjectionDrop(t *testing.T) {
+	faultApp := newFaultApp([]map[string]any{
+		{"path": "/api/contacts", "drop_probability": 1},
+	})
+
+	_, err := faultApp.Test(httptest.NewRequest(http.MethodGet, "/api/contacts", nil))
+	assert.NotNil(t, err)
+}
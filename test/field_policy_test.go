@@ -0,0 +1,71 @@
+package test
+
+import (
+	"encoding/json"
+	"go-rest-scaffold/internal/config"
+	"go-rest-scaffold/internal/delivery/http/middleware"
+	"go-rest-scaffold/internal/model"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func newFieldPolicyApp(role string) *fiber.App {
+	policy := middleware.FieldPolicy{
+		model.RoleUser: {"model.ContactResponse": {"phone"}, "model.AddressResponse": {"postal_code"}},
+	}
+
+	fieldPolicyApp := fiber.New()
+	fieldPolicyApp.Use(func(ctx *fiber.Ctx) error {
+		ctx.Locals("auth", &model.Auth{ID: "khannedy", Role: role})
+		return ctx.Next()
+	})
+	fieldPolicyApp.Use(middleware.NewFieldPolicy(config.NewOpenAPIDocument(log), policy, log))
+	fieldPolicyApp.Get("/api/contacts/:contactId", func(ctx *fiber.Ctx) error {
+		return ctx.JSON(model.WebResponse[*model.ContactResponse]{Data: &model.ContactResponse{
+			ID:        ctx.Params("contactId"),
+			FirstName: "Eko",
+			Phone:     "08000000",
+			Addresses: []model.AddressResponse{{ID: "home", City: "Jakarta", PostalCode: "12345"}},
+		}})
+	})
+	return fieldPolicyApp
+}
+
+func getFilteredContact(t *testing.T, role string) map[string]any {
+	request := httptest.NewRequest(http.MethodGet, "/api/contacts/abc", nil)
+	response, err := newFieldPolicyApp(role).Test(request)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+
+	bytes, err := io.ReadAll(response.Body)
+	assert.Nil(t, err)
+
+	responseBody := make(map[string]any)
+	err = json.Unmarshal(bytes, &responseBody)
+	assert.Nil(t, err)
+	return responseBody["data"].(map[string]any)
+}
+
+func TestFieldPolicyHidesFields(t *testing.T) {
+	contact := getFilteredContact(t, model.RoleUser)
+
+	assert.Equal(t, "Eko", contact["first_name"])
+	assert.NotContains(t, contact, "phone")
+
+	address := contact["addresses"].([]any)[0].(map[string]any)
+	assert.Equal(t, "Jakarta", address["city"])
+	assert.NotContains(t, address, "postal_code")
+}
+
+func TestFieldPolicyOtherRole(t *testing.T) {
+	contact := getFilteredContact(t, model.RoleAdmin)
+
+	assert.Equal(t, "08000000", contact["phone"])
+	address := contact["addresses"].([]any)[0].(map[string]any)
+	assert.Equal(t, "12345", address["postal_code"])
+}
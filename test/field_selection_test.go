@@ -0,0 +1,78 @@
+package test
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFieldSelection(t *testing.T) {
+	ClearAll()
+	TestLogin(t)
+	user := GetFirstUser(t)
+	CreateContacts(user, 3)
+	contact := GetFirstContact(t, user)
+
+	request := httptest.NewRequest(http.MethodGet, "/api/contacts?fields=first_name,email&size=2", nil)
+	request.Header.Set("Accept", "application/json")
+	request.Header.Set("Authorization", user.Token)
+
+	response, err := app.Test(request)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+
+	bytes, err := io.ReadAll(response.Body)
+	assert.Nil(t, err)
+
+	list := new(struct {
+		Data   []map[string]any `json:"data"`
+		Paging map[string]any   `json:"paging"`
+	})
+	err = json.Unmarshal(bytes, list)
+	assert.Nil(t, err)
+
+	assert.Len(t, list.Data, 2)
+	for _, item := range list.Data {
+		assert.Len(t, item, 3)
+		assert.Contains(t, item, "id")
+		assert.Contains(t, item, "first_name")
+		assert.Contains(t, item, "email")
+	}
+	assert.Equal(t, float64(3), list.Paging["total_item"])
+
+	request = httptest.NewRequest(http.MethodGet, "/api/contacts/"+contact.ID+"?fields=phone", nil)
+	request.Header.Set("Accept", "application/json")
+	request.Header.Set("Authorization", user.Token)
+
+	response, err = app.Test(request)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+
+	bytes, err = io.ReadAll(response.Body)
+	assert.Nil(t, err)
+
+	single := new(struct {
+		Data map[string]any `json:"data"`
+	})
+	err = json.Unmarshal(bytes, single)
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]any{"id": contact.ID, "phone": contact.PrimaryPhone()}, single.Data)
+}
+
+func TestFieldSelectionUnknownField(t *testing.T) {
+	ClearAll()
+	TestLogin(t)
+	user := GetFirstUser(t)
+
+	request := httptest.NewRequest(http.MethodGet, "/api/contacts?fields=first_name,password", nil)
+	request.Header.Set("Accept", "application/json")
+	request.Header.Set("Authorization", user.Token)
+
+	response, err := app.Test(request)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusBadRequest, response.StatusCode)
+}
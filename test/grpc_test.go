@@ -0,0 +1,171 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"go-rest-scaffold/internal/config"
+	grpcdelivery "go-rest-scaffold/internal/delivery/grpc"
+	"go-rest-scaffold/internal/delivery/grpc/pb"
+	"go-rest-scaffold/internal/usecase"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	reflectionpb "google.golang.org/grpc/reflection/grpc_reflection_v1"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// dialGRPC serves the gRPC services of a second bootstrap in memory and connects to them.
+func dialGRPC(t *testing.T) *grpc.ClientConn {
+	viperConfig.Set("grpc.port", 50051)
+	t.Cleanup(func() { viperConfig.Set("grpc.port", 0) })
+
+	application := config.Bootstrap(&config.BootstrapConfig{
+		DB:       db,
+		App:      config.NewFiber(viperConfig, log),
+		Log:      log,
+		Validate: validate,
+		Config:   viperConfig,
+		SkipJobs: true,
+	})
+	assert.NotNil(t, application.GRPCServer)
+
+	listener := bufconn.Listen(1024 * 1024)
+	go application.GRPCServer.Serve(listener)
+	t.Cleanup(application.GRPCServer.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufconn",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	assert.Nil(t, err)
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+func TestGRPCServices(t *testing.T) {
+	ClearAll()
+	TestRegister(t)
+
+	conn := dialGRPC(t)
+	users := pb.NewUserServiceClient(conn)
+	contacts := pb.NewContactServiceClient(conn)
+	addresses := pb.NewAddressServiceClient(conn)
+
+	_, err := users.Current(context.Background(), &pb.CurrentUserRequest{})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "authorization", loginWithScopes(t, "null"))
+
+	user, err := users.Current(ctx, &pb.CurrentUserRequest{})
+	assert.Nil(t, err)
+	assert.Equal(t, "khannedy", user.Id)
+
+	contact, err := contacts.Create(ctx, &pb.CreateContactRequest{FirstName: "Eko", LastName: "Khannedy", Email: "eko@example.com"})
+	assert.Nil(t, err)
+	assert.NotEmpty(t, contact.Id)
+
+	_, err = contacts.Create(ctx, &pb.CreateContactRequest{Email: "eko@example.com"})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	address, err := addresses.Create(ctx, &pb.CreateAddressRequest{ContactId: contact.Id, Street: "Jalan", Country: "Indonesia"})
+	assert.Nil(t, err)
+
+	listed, err := addresses.List(ctx, &pb.ListAddressRequest{ContactId: contact.Id})
+	assert.Nil(t, err)
+	assert.Len(t, listed.Addresses, 1)
+	assert.Equal(t, address.Id, listed.Addresses[0].Id)
+
+	found, err := contacts.Search(ctx, &pb.SearchContactRequest{Name: "Eko"})
+	assert.Nil(t, err)
+	assert.Len(t, found.Contacts, 1)
+	assert.Equal(t, int64(1), found.Paging.TotalItem)
+
+	_, err = contacts.Delete(ctx, &pb.DeleteContactRequest{Id: contact.Id})
+	assert.Nil(t, err)
+
+	_, err = contacts.Get(ctx, &pb.GetContactRequest{Id: contact.Id})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+}
+
+func TestGRPCScopes(t *testing.T) {
+	ClearAll()
+	TestRegister(t)
+
+	conn := dialGRPC(t)
+	contacts := pb.NewContactServiceClient(conn)
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "authorization", loginWithScopes(t, `["contacts:read"]`))
+
+	_, err := contacts.Search(ctx, &pb.SearchContactRequest{})
+	assert.Nil(t, err)
+
+	_, err = contacts.Create(ctx, &pb.CreateContactRequest{FirstName: "Eko"})
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+
+	_, err = pb.NewUserServiceClient(conn).Current(ctx, &pb.CurrentUserRequest{})
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+}
+
+func TestGRPCHealth(t *testing.T) {
+	conn := dialGRPC(t)
+	health := healthpb.NewHealthClient(conn)
+
+	// answered without credentials, once the checks ran
+	assert.Eventually(t, func() bool {
+		response, err := health.Check(context.Background(), &healthpb.HealthCheckRequest{Service: "scaffold.v1.ContactService"})
+		return err == nil && response.Status == healthpb.HealthCheckResponse_SERVING
+	}, 5*time.Second, 50*time.Millisecond)
+
+	response, err := health.Check(context.Background(), &healthpb.HealthCheckRequest{})
+	assert.Nil(t, err)
+	assert.Equal(t, healthpb.HealthCheckResponse_SERVING, response.Status)
+
+	_, err = health.Check(context.Background(), &healthpb.HealthCheckRequest{Service: "scaffold.v1.Unknown"})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+}
+
+func TestGRPCHealthNotReady(t *testing.T) {
+	useCase := usecase.NewHealthUseCase(log, usecase.HealthOptions{
+		Checks: map[string]usecase.HealthCheck{
+			"database": func(ctx context.Context) error { return errors.New("connection refused") },
+		},
+		Timeout: time.Second,
+	})
+	server := grpcdelivery.NewHealthServer(useCase, log)
+	server.Update(context.Background())
+
+	for _, service := range []string{"", "scaffold.v1.UserService", "scaffold.v1.ContactService", "scaffold.v1.AddressService"} {
+		response, err := server.Check(context.Background(), &healthpb.HealthCheckRequest{Service: service})
+		assert.Nil(t, err)
+		assert.Equal(t, healthpb.HealthCheckResponse_NOT_SERVING, response.Status, service)
+	}
+}
+
+func TestGRPCReflection(t *testing.T) {
+	conn := dialGRPC(t)
+
+	stream, err := reflectionpb.NewServerReflectionClient(conn).ServerReflectionInfo(context.Background())
+	assert.Nil(t, err)
+	assert.Nil(t, stream.Send(&reflectionpb.ServerReflectionRequest{
+		MessageRequest: &reflectionpb.ServerReflectionRequest_ListServices{},
+	}))
+
+	response, err := stream.Recv()
+	assert.Nil(t, err)
+
+	var services []string
+	for _, service := range response.GetListServicesResponse().GetService() {
+		services = append(services, service.Name)
+	}
+	assert.Contains(t, services, "scaffold.v1.ContactService")
+	assert.Contains(t, services, "grpc.health.v1.Health")
+	assert.Nil(t, stream.CloseSend())
+}
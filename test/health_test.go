@@ -0,0 +1,92 @@
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	delivery "go-rest-scaffold/internal/delivery/http"
+	"go-rest-scaffold/internal/model"
+	"go-rest-scaffold/internal/usecase"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func probe(t *testing.T, probeApp *fiber.App, path string) (*http.Response, *model.HealthResponse) {
+	response, err := probeApp.Test(httptest.NewRequest(http.MethodGet, path, nil))
+	assert.Nil(t, err)
+
+	bytes, err := io.ReadAll(response.Body)
+	assert.Nil(t, err)
+
+	responseBody := new(model.HealthResponse)
+	err = json.Unmarshal(bytes, responseBody)
+	assert.Nil(t, err)
+
+	return response, responseBody
+}
+
+func TestProbes(t *testing.T) {
+	response, responseBody := probe(t, app, "/livez")
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+	assert.Equal(t, model.HealthUp, responseBody.Status)
+
+	for _, path := range []string{"/readyz", "/healthz"} {
+		response, responseBody = probe(t, app, path)
+		assert.Equal(t, http.StatusOK, response.StatusCode)
+		assert.Equal(t, "no-store", response.Header.Get("Cache-Control"))
+		assert.Equal(t, model.HealthUp, responseBody.Status)
+		assert.Equal(t, model.HealthUp, responseBody.Components["database"].Status)
+	}
+}
+
+func TestProbesDown(t *testing.T) {
+	healthUseCase := usecase.NewHealthUseCase(log, usecase.HealthOptions{
+		Checks: map[string]usecase.HealthCheck{
+			"database": func(ctx context.Context) error { return nil },
+			"redis":    func(ctx context.Context) error { return errors.New("dial tcp 10.0.0.3:6379: connection refused") },
+			"queue": func(ctx context.Context) error {
+				<-ctx.Done()
+				return ctx.Err()
+			},
+		},
+		Timeout: 50 * time.Millisecond,
+	})
+	healthController := delivery.NewHealthController(healthUseCase, log)
+	probeApp := fiber.New()
+	probeApp.Get("/livez", healthController.Live)
+	probeApp.Get("/readyz", healthController.Ready)
+
+	response, responseBody := probe(t, probeApp, "/readyz")
+	assert.Equal(t, http.StatusServiceUnavailable, response.StatusCode)
+	assert.Equal(t, model.HealthDown, responseBody.Status)
+	assert.Equal(t, model.HealthUp, responseBody.Components["database"].Status)
+	assert.Equal(t, "unavailable", responseBody.Components["redis"].Error)
+	assert.Equal(t, "timeout", responseBody.Components["queue"].Error)
+
+	// a broken dependency is no reason to restart
+	response, _ = probe(t, probeApp, "/livez")
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+}
+
+func TestProbesDraining(t *testing.T) {
+	healthController := delivery.NewHealthController(usecase.NewHealthUseCase(log, usecase.HealthOptions{
+		Timeout:  50 * time.Millisecond,
+		Draining: func() bool { return true },
+	}), log)
+	probeApp := fiber.New()
+	probeApp.Get("/readyz", healthController.Ready)
+	probeApp.Get("/healthz", healthController.Health)
+
+	response, responseBody := probe(t, probeApp, "/readyz")
+	assert.Equal(t, http.StatusServiceUnavailable, response.StatusCode)
+	assert.True(t, responseBody.Draining)
+
+	response, _ = probe(t, probeApp, "/healthz")
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+}
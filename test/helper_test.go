@@ -7,11 +7,28 @@ import (
 
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
 )
 
 func ClearAll() {
+	ClearAuditLogs()
+	ClearReminders()
+	ClearContactChanges()
+	ClearContactImports()
+	ClearOutbox()
+	ClearDailyStats()
 	ClearAddresses()
+	ClearTags()
+	ClearNotes()
 	ClearContact()
+	ClearExperimentAssignments()
+	ClearDebugCaptures()
+	ClearAnnouncements()
+	ClearEmailTemplates()
+	ClearWebhookDeliveries()
+	ClearWebhooks()
+	ClearAPIKeys()
+	ClearSessions()
 	ClearUsers()
 }
 
@@ -23,27 +40,143 @@ func ClearUsers() {
 }
 
 func ClearContact() {
-	err := db.Where("id is not null").Delete(&entity.Contact{}).Error
+	err := db.Unscoped().Where("id is not null").Delete(&entity.Contact{}).Error
 	if err != nil {
 		log.Fatalf("Failed clear contact data : %+v", err)
 	}
 }
 
 func ClearAddresses() {
-	err := db.Where("id is not null").Delete(&entity.Address{}).Error
+	err := db.Unscoped().Where("id is not null").Delete(&entity.Address{}).Error
 	if err != nil {
 		log.Fatalf("Failed clear address data : %+v", err)
 	}
 }
 
+func ClearTags() {
+	err := db.Where("contact_id is not null").Delete(&entity.ContactTag{}).Error
+	if err != nil {
+		log.Fatalf("Failed clear contact tag data : %+v", err)
+	}
+	err = db.Where("id is not null").Delete(&entity.Tag{}).Error
+	if err != nil {
+		log.Fatalf("Failed clear tag data : %+v", err)
+	}
+}
+
+func ClearNotes() {
+	err := db.Where("id is not null").Delete(&entity.Note{}).Error
+	if err != nil {
+		log.Fatalf("Failed clear note data : %+v", err)
+	}
+}
+
+func ClearExperimentAssignments() {
+	err := db.Where("user_id is not null").Delete(&entity.ExperimentAssignment{}).Error
+	if err != nil {
+		log.Fatalf("Failed clear experiment assignment data : %+v", err)
+	}
+}
+
+func ClearDebugCaptures() {
+	err := db.Where("id is not null").Delete(&entity.DebugCapture{}).Error
+	if err != nil {
+		log.Fatalf("Failed clear debug capture data : %+v", err)
+	}
+}
+
+func ClearAnnouncements() {
+	err := db.Where("id is not null").Delete(&entity.Announcement{}).Error
+	if err != nil {
+		log.Fatalf("Failed clear announcement data : %+v", err)
+	}
+}
+
+func ClearEmailTemplates() {
+	err := db.Where("name is not null").Delete(&entity.EmailTemplate{}).Error
+	if err != nil {
+		log.Fatalf("Failed clear email template data : %+v", err)
+	}
+}
+
+func ClearWebhookDeliveries() {
+	err := db.Where("id is not null").Delete(&entity.WebhookDelivery{}).Error
+	if err != nil {
+		log.Fatalf("Failed clear webhook delivery data : %+v", err)
+	}
+}
+
+func ClearWebhooks() {
+	err := db.Where("id is not null").Delete(&entity.Webhook{}).Error
+	if err != nil {
+		log.Fatalf("Failed clear webhook data : %+v", err)
+	}
+}
+
+func ClearSessions() {
+	err := db.Where("id is not null").Delete(&entity.Session{}).Error
+	if err != nil {
+		log.Fatalf("Failed clear session data : %+v", err)
+	}
+}
+
+func ClearAPIKeys() {
+	err := db.Where("id is not null").Delete(&entity.APIKey{}).Error
+	if err != nil {
+		log.Fatalf("Failed clear api key data : %+v", err)
+	}
+}
+
+func ClearReminders() {
+	err := db.Where("id is not null").Delete(&entity.Reminder{}).Error
+	if err != nil {
+		log.Fatalf("Failed clear reminder data : %+v", err)
+	}
+}
+
+func ClearAuditLogs() {
+	err := db.Where("id is not null").Delete(&entity.AuditLog{}).Error
+	if err != nil {
+		log.Fatalf("Failed clear audit log data : %+v", err)
+	}
+}
+
+func ClearContactChanges() {
+	err := db.Where("id is not null").Delete(&entity.ContactChange{}).Error
+	if err != nil {
+		log.Fatalf("Failed clear contact change data : %+v", err)
+	}
+}
+
+func ClearOutbox() {
+	err := db.Where("id is not null").Delete(&entity.OutboxEvent{}).Error
+	if err != nil {
+		log.Fatalf("Failed clear outbox data : %+v", err)
+	}
+}
+
+func ClearContactImports() {
+	err := db.Where("id is not null").Delete(&entity.ContactImport{}).Error
+	if err != nil {
+		log.Fatalf("Failed clear contact import data : %+v", err)
+	}
+}
+
+func ClearDailyStats() {
+	err := db.Where("day is not null").Delete(&entity.DailyStats{}).Error
+	if err != nil {
+		log.Fatalf("Failed clear daily stats data : %+v", err)
+	}
+}
+
 func CreateContacts(user *entity.User, total int) {
 	for i := 0; i < total; i++ {
 		contact := &entity.Contact{
 			ID:        uuid.NewString(),
 			FirstName: "Contact",
 			LastName:  strconv.Itoa(i),
-			Email:     "contact" + strconv.Itoa(i) + "@example.com",
-			Phone:     "08000000" + strconv.Itoa(i),
+			Emails:    []entity.ContactEmail{{Type: "work", Email: "contact" + strconv.Itoa(i) + "@example.com", Primary: true}},
+			Phones:    []entity.ContactPhone{{Type: "mobile", Phone: "08000000" + strconv.Itoa(i), Primary: true}},
 			UserId:    user.ID,
 		}
 		err := db.Create(contact).Error
@@ -78,7 +211,11 @@ func GetFirstUser(t *testing.T) *entity.User {
 
 func GetFirstContact(t *testing.T, user *entity.User) *entity.Contact {
 	contact := new(entity.Contact)
-	err := db.Where("user_id = ?", user.ID).First(contact).Error
+	err := db.Preload("Emails", func(db *gorm.DB) *gorm.DB {
+		return db.Order("position")
+	}).Preload("Phones", func(db *gorm.DB) *gorm.DB {
+		return db.Order("position")
+	}).Where("user_id = ?", user.ID).First(contact).Error
 	assert.Nil(t, err)
 	return contact
 }
@@ -0,0 +1,72 @@
+package test
+
+import (
+	"encoding/json"
+	"go-rest-scaffold/internal/entity"
+	"go-rest-scaffold/internal/model"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func createContactIdempotently(t *testing.T, user *entity.User, key string, body string) (*http.Response, *model.ContactResponse) {
+	request := httptest.NewRequest(http.MethodPost, "/api/contacts", strings.NewReader(body))
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("Accept", "application/json")
+	request.Header.Set("Authorization", user.Token)
+	request.Header.Set("Idempotency-Key", key)
+
+	response, err := app.Test(request)
+	assert.Nil(t, err)
+
+	bytes, err := io.ReadAll(response.Body)
+	assert.Nil(t, err)
+
+	responseBody := new(model.WebResponse[model.ContactResponse])
+	_ = json.Unmarshal(bytes, responseBody)
+	return response, &responseBody.Data
+}
+
+func TestIdempotencyKey(t *testing.T) {
+	ClearAll()
+	TestLogin(t)
+	user := GetFirstUser(t)
+
+	key := uuid.NewString()
+	body := `{"first_name":"Eko","email":"eko@example.com"}`
+
+	response, first := createContactIdempotently(t, user, key, body)
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+	assert.Empty(t, response.Header.Get("Idempotent-Replayed"))
+
+	// the retry gets the first response and creates nothing
+	response, retry := createContactIdempotently(t, user, key, body)
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+	assert.Equal(t, "true", response.Header.Get("Idempotent-Replayed"))
+	assert.Equal(t, first.ID, retry.ID)
+
+	var total int64
+	assert.Nil(t, db.Model(new(entity.Contact)).Where("user_id = ?", user.ID).Count(&total).Error)
+	assert.Equal(t, int64(1), total)
+
+	response, _ = createContactIdempotently(t, user, key, `{"first_name":"Joko","email":"joko@example.com"}`)
+	assert.Equal(t, http.StatusUnprocessableEntity, response.StatusCode)
+
+	// refusals are replayed too, keys are not
+	key = uuid.NewString()
+	response, _ = createContactIdempotently(t, user, key, `{"email":"eko@example.com"}`)
+	assert.Equal(t, http.StatusBadRequest, response.StatusCode)
+
+	response, _ = createContactIdempotently(t, user, key, `{"email":"eko@example.com"}`)
+	assert.Equal(t, http.StatusBadRequest, response.StatusCode)
+	assert.Equal(t, "true", response.Header.Get("Idempotent-Replayed"))
+
+	response, second := createContactIdempotently(t, user, uuid.NewString(), body)
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+	assert.NotEqual(t, first.ID, second.ID)
+}
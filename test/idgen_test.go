@@ -0,0 +1,94 @@
+package test
+
+import (
+	"context"
+	"go-rest-scaffold/internal/idgen"
+	"go-rest-scaffold/internal/model"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIDGeneratorUUIDv7(t *testing.T) {
+	generator, err := idgen.New(idgen.UUIDv7, idgen.Contact, db)
+	assert.Nil(t, err)
+
+	id, err := generator.NewID(context.Background())
+	assert.Nil(t, err)
+
+	parsed, err := uuid.Parse(id)
+	assert.Nil(t, err)
+	assert.Equal(t, uuid.Version(7), parsed.Version())
+}
+
+func TestIDGeneratorULIDSortsByTime(t *testing.T) {
+	generator, err := idgen.New(idgen.ULID, idgen.Contact, db)
+	assert.Nil(t, err)
+
+	first, err := generator.NewID(context.Background())
+	assert.Nil(t, err)
+	time.Sleep(2 * time.Millisecond)
+	second, err := generator.NewID(context.Background())
+	assert.Nil(t, err)
+
+	assert.Len(t, first, 26)
+	assert.Regexp(t, "^[0-9A-HJKMNP-TV-Z]{26}$", first)
+	assert.Less(t, first, second)
+}
+
+func TestIDGeneratorSequence(t *testing.T) {
+	if db.Dialector.Name() != "postgres" {
+		t.Skip("sequences need postgres")
+	}
+
+	generator, err := idgen.New(idgen.Sequence, idgen.Contact, db)
+	assert.Nil(t, err)
+
+	first, err := generator.NewID(context.Background())
+	assert.Nil(t, err)
+	second, err := generator.NewID(context.Background())
+	assert.Nil(t, err)
+
+	firstValue, err := strconv.ParseInt(first, 10, 64)
+	assert.Nil(t, err)
+	secondValue, err := strconv.ParseInt(second, 10, 64)
+	assert.Nil(t, err)
+	assert.Greater(t, secondValue, firstValue)
+}
+
+func TestIDGeneratorUnknownStrategy(t *testing.T) {
+	_, err := idgen.New("snowflake", idgen.Contact, db)
+	assert.NotNil(t, err)
+}
+
+func TestIDGeneratorsDefault(t *testing.T) {
+	var generators *idgen.Generators
+
+	id, err := generators.NewID(context.Background(), idgen.Contact)
+	assert.Nil(t, err)
+
+	parsed, err := uuid.Parse(id)
+	assert.Nil(t, err)
+	assert.Equal(t, uuid.Version(4), parsed.Version())
+}
+
+func TestRequestsAcceptIDsOfEveryStrategy(t *testing.T) {
+	for _, strategy := range []string{idgen.UUIDv4, idgen.UUIDv7, idgen.ULID} {
+		generator, err := idgen.New(strategy, idgen.Contact, db)
+		assert.Nil(t, err)
+
+		id, err := generator.NewID(context.Background())
+		assert.Nil(t, err)
+		assert.Nil(t, validate.Struct(&model.GetContactRequest{UserId: "khannedy", ID: id}), strategy)
+	}
+
+	// the decimal values of sequences
+	assert.Nil(t, validate.Struct(&model.GetContactRequest{UserId: "khannedy", ID: "42"}))
+
+	for _, id := range []string{"", "contact-1", "-42", "8ZZZZZZZZZZZZZZZZZZZZZZZZZ", "01ARZ3NDEKTSV4RRFFQ69G5FAU"} {
+		assert.NotNil(t, validate.Struct(&model.GetContactRequest{UserId: "khannedy", ID: id}), id)
+	}
+}
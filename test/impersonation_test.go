@@ -0,0 +1,142 @@
+package test
+
+import (
+	"encoding/json"
+	"go-rest-scaffold/internal/entity"
+	"go-rest-scaffold/internal/model"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// loginAdminWithCustomer logs khannedy in as an admin and creates the user eko.
+func loginAdminWithCustomer(t *testing.T) *entity.User {
+	TestLogin(t)
+
+	admin := new(entity.User)
+	err := db.Where("id = ?", "khannedy").First(admin).Error
+	assert.Nil(t, err)
+	err = db.Model(admin).Update("role", model.RoleAdmin).Error
+	assert.Nil(t, err)
+
+	err = db.Create(&entity.User{ID: "eko", Name: "Eko", Password: "-", Role: model.RoleUser}).Error
+	assert.Nil(t, err)
+
+	return admin
+}
+
+func impersonate(t *testing.T, admin *entity.User, userId string) (*http.Response, *model.ImpersonationResponse) {
+	request := httptest.NewRequest(http.MethodPost, "/api/admin/users/"+userId+"/_impersonate", nil)
+	request.Header.Set("Accept", "application/json")
+	request.Header.Set("Authorization", admin.Token)
+
+	response, err := app.Test(request)
+	assert.Nil(t, err)
+
+	bytes, err := io.ReadAll(response.Body)
+	assert.Nil(t, err)
+
+	responseBody := new(model.WebResponse[*model.ImpersonationResponse])
+	err = json.Unmarshal(bytes, responseBody)
+	assert.Nil(t, err)
+
+	return response, responseBody.Data
+}
+
+func TestImpersonate(t *testing.T) {
+	admin := loginAdminWithCustomer(t)
+
+	response, impersonation := impersonate(t, admin, "eko")
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+	assert.Equal(t, "eko", impersonation.UserId)
+	assert.NotEmpty(t, impersonation.Token)
+	assert.Greater(t, impersonation.ExpiresAt, time.Now().UnixMilli())
+
+	request := httptest.NewRequest(http.MethodPatch, "/api/users/_current", strings.NewReader(`{"name":"Eko Kurniawan"}`))
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("Accept", "application/json")
+	request.Header.Set("Authorization", impersonation.Token)
+
+	response, err := app.Test(request)
+	assert.Nil(t, err)
+
+	bytes, err := io.ReadAll(response.Body)
+	assert.Nil(t, err)
+
+	updated := new(model.WebResponse[model.UserResponse])
+	err = json.Unmarshal(bytes, updated)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+	assert.Equal(t, "eko", updated.Data.ID)
+	assert.Equal(t, "Eko Kurniawan", updated.Data.Name)
+
+	var auditLogs []entity.AuditLog
+	err = db.Where("entity_type = ? AND entity_id = ?", model.AuditUser, "eko").Find(&auditLogs).Error
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(auditLogs))
+
+	entries := make(map[string]entity.AuditLog)
+	for _, entry := range auditLogs {
+		entries[entry.Action] = entry
+	}
+	assert.Equal(t, admin.ID, entries[model.AuditImpersonate].UserId)
+	assert.Empty(t, entries[model.AuditImpersonate].ImpersonatorId)
+	assert.Equal(t, "eko", entries[model.AuditUpdate].UserId)
+	assert.Equal(t, admin.ID, entries[model.AuditUpdate].ImpersonatorId)
+}
+
+func TestImpersonateCannotChangePassword(t *testing.T) {
+	admin := loginAdminWithCustomer(t)
+
+	_, impersonation := impersonate(t, admin, "eko")
+
+	request := httptest.NewRequest(http.MethodPatch, "/api/users/_current", strings.NewReader(`{"password":"rahasialagi"}`))
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("Accept", "application/json")
+	request.Header.Set("Authorization", impersonation.Token)
+
+	response, err := app.Test(request)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusForbidden, response.StatusCode)
+}
+
+func TestImpersonateExpired(t *testing.T) {
+	admin := loginAdminWithCustomer(t)
+
+	_, impersonation := impersonate(t, admin, "eko")
+	err := db.Model(&entity.Session{}).Where("user_id = ?", "eko").Update("expires_at", time.Now().UnixMilli()).Error
+	assert.Nil(t, err)
+
+	request := httptest.NewRequest(http.MethodGet, "/api/users/_current", nil)
+	request.Header.Set("Accept", "application/json")
+	request.Header.Set("Authorization", impersonation.Token)
+
+	response, err := app.Test(request)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusUnauthorized, response.StatusCode)
+}
+
+func TestImpersonateAdmin(t *testing.T) {
+	admin := loginAdminWithCustomer(t)
+	err := db.Model(&entity.User{}).Where("id = ?", "eko").Update("role", model.RoleAdmin).Error
+	assert.Nil(t, err)
+
+	response, _ := impersonate(t, admin, "eko")
+	assert.Equal(t, http.StatusForbidden, response.StatusCode)
+}
+
+func TestImpersonateForbidden(t *testing.T) {
+	TestLogin(t)
+
+	user := new(entity.User)
+	err := db.Where("id = ?", "khannedy").First(user).Error
+	assert.Nil(t, err)
+
+	response, _ := impersonate(t, user, "khannedy")
+	assert.Equal(t, http.StatusForbidden, response.StatusCode)
+}
@@ -2,6 +2,7 @@ package test
 
 import (
 	"go-rest-scaffold/internal/config"
+	"go-rest-scaffold/internal/usecase"
 
 	"github.com/go-playground/validator/v10"
 	"github.com/gofiber/fiber/v2"
@@ -14,6 +15,8 @@ var app *fiber.App
 
 var db *gorm.DB
 
+var txManager usecase.TxManager
+
 var viperConfig *viper.Viper
 
 var log *logrus.Logger
@@ -22,10 +25,13 @@ var validate *validator.Validate
 
 func init() {
 	viperConfig = config.NewViper()
+	// the webhook endpoints of the tests listen on the loopback address
+	viperConfig.Set("webhook.allowed_networks", []string{"127.0.0.1"})
 	log = config.NewLogger(viperConfig)
 	validate = config.NewValidator(viperConfig)
-	app = config.NewFiber(viperConfig)
+	app = config.NewFiber(viperConfig, log)
 	db = config.NewDatabase(viperConfig, log)
+	txManager = usecase.NewGormTxManager(db)
 
 	config.Bootstrap(&config.BootstrapConfig{
 		DB:       db,
@@ -0,0 +1,109 @@
+package test
+
+import (
+	"go-rest-scaffold/internal/config"
+	"go-rest-scaffold/internal/model"
+	"strconv"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+var jsonEncoders = []string{config.JSONEncoderStandard, config.JSONEncoderGoJSON, config.JSONEncoderSonic}
+
+func largeContactListResponse(total int) model.WebResponse[[]model.ContactResponse] {
+	contacts := make([]model.ContactResponse, total)
+	for i := range contacts {
+		contacts[i] = model.ContactResponse{
+			ID:        uuid.NewString(),
+			FirstName: "Contact <" + strconv.Itoa(i) + ">",
+			LastName:  "Khannedy & Co",
+			Email:     "contact" + strconv.Itoa(i) + "@example.com",
+			Phone:     "08000000" + strconv.Itoa(i),
+			CreatedAt: 1700000000000 + int64(i),
+			UpdatedAt: 1700000000000 + int64(i),
+			Addresses: []model.AddressResponse{{
+				ID:         uuid.NewString(),
+				Street:     "Jalan Belum Jadi",
+				City:       "Jakarta",
+				Province:   "DKI Jakarta",
+				PostalCode: "2131323",
+				Country:    "Indonesia",
+			}},
+		}
+	}
+
+	return model.WebResponse[[]model.ContactResponse]{
+		Data:   contacts,
+		Paging: &model.PageMetadata{Page: 1, Size: total, TotalItem: int64(total), TotalPage: 1},
+	}
+}
+
+func TestJSONEncodersProduceIdenticalOutput(t *testing.T) {
+	response := largeContactListResponse(100)
+
+	standardMarshal, _, err := config.NewJSONEncoder(config.JSONEncoderStandard)
+	assert.Nil(t, err)
+	expected, err := standardMarshal(response)
+	assert.Nil(t, err)
+
+	for _, name := range jsonEncoders {
+		marshal, unmarshal, err := config.NewJSONEncoder(name)
+		assert.Nil(t, err)
+
+		actual, err := marshal(response)
+		assert.Nil(t, err)
+		assert.Equal(t, string(expected), string(actual), name)
+
+		decoded := new(model.WebResponse[[]model.ContactResponse])
+		err = unmarshal(actual, decoded)
+		assert.Nil(t, err)
+		assert.Equal(t, response, *decoded, name)
+	}
+}
+
+func BenchmarkJSONEncoders(b *testing.B) {
+	response := largeContactListResponse(1000)
+
+	for _, name := range jsonEncoders {
+		marshal, _, err := config.NewJSONEncoder(name)
+		if err != nil {
+			b.Fatal(err)
+		}
+
+		b.Run(name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if _, err := marshal(response); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkJSONDecoders(b *testing.B) {
+	standardMarshal, _, _ := config.NewJSONEncoder(config.JSONEncoderStandard)
+	payload, err := standardMarshal(largeContactListResponse(1000))
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	for _, name := range jsonEncoders {
+		_, unmarshal, err := config.NewJSONEncoder(name)
+		if err != nil {
+			b.Fatal(err)
+		}
+
+		b.Run(name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				decoded := new(model.WebResponse[[]model.ContactResponse])
+				if err := unmarshal(payload, decoded); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,107 @@
+package test
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	delivery "go-rest-scaffold/internal/delivery/http"
+	"go-rest-scaffold/internal/jwt"
+	"go-rest-scaffold/internal/model"
+	"go-rest-scaffold/internal/usecase"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJWTAccessToken(t *testing.T) {
+	ClearAll()
+	TestRegister(t)
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.Nil(t, err)
+	issuer := jwt.NewRS256("go-rest-scaffold", time.Minute, key)
+
+	userUseCase := newPasswordUserUseCase(usecase.UserOptions{AccessTokens: issuer})
+
+	response, err := userUseCase.Login(context.Background(), &model.LoginUserRequest{ID: "khannedy", Password: "rahasia"})
+	assert.Nil(t, err)
+	assert.True(t, jwt.IsJWT(response.Token))
+
+	// verified without the database, so it keeps working after the users are gone
+	ClearAll()
+	auth, err := userUseCase.Verify(context.Background(), &model.VerifyUserRequest{Token: response.Token})
+	assert.Nil(t, err)
+	assert.Equal(t, "khannedy", auth.ID)
+	assert.Equal(t, model.RoleUser, auth.Role)
+	assert.NotEmpty(t, auth.SessionId)
+
+	_, err = userUseCase.Verify(context.Background(), &model.VerifyUserRequest{Token: response.Token + "x"})
+	assert.NotNil(t, err)
+
+	// a token signed by another key or expired is refused
+	otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.Nil(t, err)
+	forged, err := jwt.NewRS256("go-rest-scaffold", time.Minute, otherKey).Issue(jwt.Claims{Subject: "khannedy"}, time.Now())
+	assert.Nil(t, err)
+	_, err = userUseCase.Verify(context.Background(), &model.VerifyUserRequest{Token: forged})
+	assert.NotNil(t, err)
+
+	expired, err := issuer.Issue(jwt.Claims{Subject: "khannedy"}, time.Now().Add(-2*time.Minute))
+	assert.Nil(t, err)
+	_, err = userUseCase.Verify(context.Background(), &model.VerifyUserRequest{Token: expired})
+	assert.NotNil(t, err)
+
+	jwks := issuer.JWKS()
+	assert.Len(t, jwks.Keys, 1)
+	assert.Equal(t, jwt.KeyID(&key.PublicKey), jwks.Keys[0].KeyID)
+	assert.Equal(t, base64.RawURLEncoding.EncodeToString(key.N.Bytes()), jwks.Keys[0].N)
+}
+
+func TestJWKSWithoutJWT(t *testing.T) {
+	request := httptest.NewRequest(http.MethodGet, "/.well-known/jwks.json", nil)
+
+	response, err := app.Test(request)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusNotFound, response.StatusCode)
+}
+
+func TestOpenIDConfiguration(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.Nil(t, err)
+
+	discoveryApp := fiber.New()
+	controller := delivery.NewDiscoveryController(discoveryApp, viperConfig, jwt.NewRS256("https://auth.example.com", time.Minute, key), log)
+	discoveryApp.Get("/.well-known/openid-configuration", controller.OpenIDConfiguration)
+
+	request := httptest.NewRequest(http.MethodGet, "http://api.example.com/.well-known/openid-configuration", nil)
+
+	response, err := discoveryApp.Test(request)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+
+	bytes, err := io.ReadAll(response.Body)
+	assert.Nil(t, err)
+
+	configuration := new(model.OpenIDConfigurationResponse)
+	assert.Nil(t, json.Unmarshal(bytes, configuration))
+	assert.Equal(t, "https://auth.example.com", configuration.Issuer)
+	assert.Equal(t, "http://api.example.com/.well-known/jwks.json", configuration.JWKSURI)
+	assert.Equal(t, "http://api.example.com/api/users/_login", configuration.TokenEndpoint)
+	assert.Equal(t, []string{jwt.RS256}, configuration.IDTokenSigningAlgValuesSupported)
+	assert.Contains(t, configuration.SubjectTypesSupported, "public")
+}
+
+func TestOpenIDConfigurationWithoutJWT(t *testing.T) {
+	request := httptest.NewRequest(http.MethodGet, "/.well-known/openid-configuration", nil)
+
+	response, err := app.Test(request)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusNotFound, response.StatusCode)
+}
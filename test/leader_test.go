@@ -0,0 +1,188 @@
+package test
+
+import (
+	"context"
+	"go-rest-scaffold/internal/config"
+	"go-rest-scaffold/internal/gateway/lock"
+	"hash/fnv"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+)
+
+const leaderTTL = 300 * time.Millisecond
+
+// leaderBackend is a backend leadership is held in.
+type leaderBackend struct {
+	name string
+	// client is the Redis client of the redis backend, nil for the database.
+	client *redis.Client
+}
+
+// leaderBackends returns the backends test/ reaches: the database, and Redis when
+// redis.address is configured.
+func leaderBackends() []leaderBackend {
+	backends := []leaderBackend{{name: db.Dialector.Name()}}
+	if viperConfig.GetString("redis.address") != "" {
+		backends = append(backends, leaderBackend{name: "redis", client: config.NewRedis(viperConfig, log)})
+	}
+	return backends
+}
+
+func (b leaderBackend) elector() *lock.LeaderElector {
+	return lock.NewLeaderElector(lock.NewLocker(b.client, log), db, log, leaderTTL)
+}
+
+// sole reports whether every instance leads, as they do with a SQLite database.
+func (b leaderBackend) sole() bool {
+	return b.name == "sqlite"
+}
+
+// lose takes leadership of name away from whoever holds it, as an expired Redis
+// key or a dropped database session does.
+func (b leaderBackend) lose(t *testing.T, name string) {
+	ctx := context.Background()
+	switch b.name {
+	case "redis":
+		assert.Nil(t, b.client.Del(ctx, "lock:leader:"+name).Err())
+	case "mysql":
+		var id int64
+		assert.Nil(t, db.Raw("SELECT COALESCE(IS_USED_LOCK(?), 0)", "leader:"+name).Scan(&id).Error)
+		assert.NotZero(t, id)
+		assert.Nil(t, db.Exec("KILL "+strconv.FormatInt(id, 10)).Error)
+	default:
+		// the advisory lock of a bigint key is listed as its two halves
+		hash := fnv.New64a()
+		_, _ = hash.Write([]byte("leader:" + name))
+		key := hash.Sum64()
+
+		var pid int64
+		assert.Nil(t, db.Raw("SELECT pid FROM pg_locks WHERE locktype = 'advisory' AND granted AND objsubid = 1 AND classid::bigint = ? AND objid::bigint = ?",
+			int64(key>>32), int64(uint32(key))).Scan(&pid).Error)
+		assert.NotZero(t, pid)
+		assert.Nil(t, db.Exec("SELECT pg_terminate_backend(?)", pid).Error)
+	}
+}
+
+// leader runs elector for name until the test ends, and returns how many times it
+// became leader and whether it leads now.
+func leader(t *testing.T, elector *lock.LeaderElector, name string, task func(ctx context.Context)) (*atomic.Int64, *atomic.Bool) {
+	var terms atomic.Int64
+	var leading atomic.Bool
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		elector.Run(ctx, name, func(ctx context.Context) {
+			terms.Add(1)
+			leading.Store(true)
+			defer leading.Store(false)
+			task(ctx)
+		})
+	}()
+
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+	return &terms, &leading
+}
+
+func TestLeaderElectorAcquire(t *testing.T) {
+	for _, backend := range leaderBackends() {
+		t.Run(backend.name, func(t *testing.T) {
+			name := "test-" + uuid.NewString()
+
+			_, first := leader(t, backend.elector(), name, func(ctx context.Context) { <-ctx.Done() })
+			assert.Eventually(t, first.Load, 5*time.Second, 10*time.Millisecond)
+
+			_, second := leader(t, backend.elector(), name, func(ctx context.Context) { <-ctx.Done() })
+			if backend.sole() {
+				assert.Eventually(t, second.Load, 5*time.Second, 10*time.Millisecond)
+				return
+			}
+
+			// a second instance keeps campaigning without taking over
+			assert.Never(t, second.Load, 2*leaderTTL, 10*time.Millisecond)
+			assert.True(t, first.Load())
+		})
+	}
+}
+
+func TestLeaderElectorRenew(t *testing.T) {
+	for _, backend := range leaderBackends() {
+		t.Run(backend.name, func(t *testing.T) {
+			name := "test-" + uuid.NewString()
+
+			var lost atomic.Bool
+			release := make(chan struct{})
+			terms, leading := leader(t, backend.elector(), name, func(ctx context.Context) {
+				select {
+				case <-ctx.Done():
+					lost.Store(true)
+				case <-release:
+				}
+			})
+			assert.Eventually(t, leading.Load, 5*time.Second, 10*time.Millisecond)
+
+			// renewed for several times the TTL, the lock never expires under the task
+			time.Sleep(4 * leaderTTL)
+			assert.False(t, lost.Load())
+			assert.Equal(t, int64(1), terms.Load())
+
+			// a task returning releases leadership, which is campaigned for again
+			close(release)
+			assert.Eventually(t, func() bool { return terms.Load() >= 2 }, 5*time.Second, 10*time.Millisecond)
+			assert.False(t, lost.Load())
+		})
+	}
+}
+
+func TestLeaderElectorHandOver(t *testing.T) {
+	for _, backend := range leaderBackends() {
+		t.Run(backend.name, func(t *testing.T) {
+			if backend.sole() {
+				t.Skip("a SQLite database has a single instance, which always leads")
+			}
+			name := "test-" + uuid.NewString()
+
+			// the first leader stops campaigning once it lost, so the second one takes over
+			firstCtx, stopFirst := context.WithCancel(context.Background())
+			lost := make(chan struct{})
+			first := backend.elector()
+			done := make(chan struct{})
+			var firstLeading atomic.Bool
+			go func() {
+				defer close(done)
+				first.Run(firstCtx, name, func(ctx context.Context) {
+					firstLeading.Store(true)
+					<-ctx.Done()
+					stopFirst()
+					close(lost)
+				})
+			}()
+			t.Cleanup(func() {
+				stopFirst()
+				<-done
+			})
+			assert.Eventually(t, firstLeading.Load, 5*time.Second, 10*time.Millisecond)
+
+			_, second := leader(t, backend.elector(), name, func(ctx context.Context) { <-ctx.Done() })
+			assert.Never(t, second.Load, leaderTTL, 10*time.Millisecond)
+
+			backend.lose(t, name)
+			select {
+			case <-lost:
+			case <-time.After(5 * time.Second):
+				t.Fatal("the first leader did not notice it lost leadership")
+			}
+			assert.Eventually(t, second.Load, 5*time.Second, 10*time.Millisecond)
+		})
+	}
+}
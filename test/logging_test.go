@@ -0,0 +1,148 @@
+package test
+
+import (
+	"encoding/json"
+	"go-rest-scaffold/internal/entity"
+	"go-rest-scaffold/internal/model"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUpdateLogging(t *testing.T) {
+	ClearAll()
+	TestLogin(t)
+
+	user := new(entity.User)
+	err := db.Where("id = ?", "khannedy").First(user).Error
+	assert.Nil(t, err)
+
+	err = db.Model(user).Update("role", model.RoleAdmin).Error
+	assert.Nil(t, err)
+
+	requestBody := model.UpdateLoggingRequest{
+		Level:      "trace",
+		Components: map[string]string{"gorm": "warn"},
+	}
+	bodyJson, err := json.Marshal(requestBody)
+	assert.Nil(t, err)
+
+	request := httptest.NewRequest(http.MethodPut, "/api/admin/logging", strings.NewReader(string(bodyJson)))
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("Accept", "application/json")
+	request.Header.Set("Authorization", user.Token)
+
+	response, err := app.Test(request)
+	assert.Nil(t, err)
+
+	bytes, err := io.ReadAll(response.Body)
+	assert.Nil(t, err)
+
+	responseBody := new(model.WebResponse[model.LoggingResponse])
+	err = json.Unmarshal(bytes, responseBody)
+	assert.Nil(t, err)
+
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+	assert.Equal(t, "trace", responseBody.Data.Level)
+	assert.Equal(t, "warning", responseBody.Data.Components["gorm"])
+
+	// restore the levels from config.json
+	request = httptest.NewRequest(http.MethodPut, "/api/admin/logging", strings.NewReader(`{"level":"trace"}`))
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("Accept", "application/json")
+	request.Header.Set("Authorization", user.Token)
+
+	response, err = app.Test(request)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+}
+
+func TestUpdateLoggingForbidden(t *testing.T) {
+	ClearAll()
+	TestLogin(t)
+
+	user := new(entity.User)
+	err := db.Where("id = ?", "khannedy").First(user).Error
+	assert.Nil(t, err)
+
+	request := httptest.NewRequest(http.MethodPut, "/api/admin/logging", strings.NewReader(`{"level":"debug"}`))
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("Accept", "application/json")
+	request.Header.Set("Authorization", user.Token)
+
+	response, err := app.Test(request)
+	assert.Nil(t, err)
+
+	assert.Equal(t, http.StatusForbidden, response.StatusCode)
+}
+
+func TestUpdateLogLevel(t *testing.T) {
+	ClearAll()
+	TestLogin(t)
+
+	user := new(entity.User)
+	err := db.Where("id = ?", "khannedy").First(user).Error
+	assert.Nil(t, err)
+
+	err = db.Model(user).Update("role", model.RoleAdmin).Error
+	assert.Nil(t, err)
+
+	request := httptest.NewRequest(http.MethodPut, "/api/admin/logging", strings.NewReader(`{"level":"trace","components":{"gorm":"warn"}}`))
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("Accept", "application/json")
+	request.Header.Set("Authorization", user.Token)
+
+	response, err := app.Test(request)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+
+	request = httptest.NewRequest(http.MethodPut, "/api/admin/log-level", strings.NewReader(`{"level":"debug"}`))
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("Accept", "application/json")
+	request.Header.Set("Authorization", user.Token)
+
+	response, err = app.Test(request)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+
+	request = httptest.NewRequest(http.MethodGet, "/api/admin/logging", nil)
+	request.Header.Set("Accept", "application/json")
+	request.Header.Set("Authorization", user.Token)
+
+	response, err = app.Test(request)
+	assert.Nil(t, err)
+
+	bytes, err := io.ReadAll(response.Body)
+	assert.Nil(t, err)
+
+	responseBody := new(model.WebResponse[model.LoggingResponse])
+	err = json.Unmarshal(bytes, responseBody)
+	assert.Nil(t, err)
+
+	// the component overrides are kept
+	assert.Equal(t, "debug", responseBody.Data.Level)
+	assert.Equal(t, "warning", responseBody.Data.Components["gorm"])
+
+	// restore the levels from config.json
+	request = httptest.NewRequest(http.MethodPut, "/api/admin/logging", strings.NewReader(`{"level":"trace"}`))
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("Accept", "application/json")
+	request.Header.Set("Authorization", user.Token)
+
+	response, err = app.Test(request)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+
+	request = httptest.NewRequest(http.MethodPut, "/api/admin/log-level", strings.NewReader(`{"level":"loud"}`))
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("Accept", "application/json")
+	request.Header.Set("Authorization", user.Token)
+
+	response, err = app.Test(request)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusBadRequest, response.StatusCode)
+}
@@ -0,0 +1,73 @@
+package test
+
+import (
+	"encoding/json"
+	"go-rest-scaffold/internal/model"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestListLoginEvents(t *testing.T) {
+	TestLoginWrongPassword(t)
+
+	request := httptest.NewRequest(http.MethodPost, "/api/users/_login", strings.NewReader(`{"id":"khannedy","password":"rahasia","device":"Eko's laptop"}`))
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("Accept", "application/json")
+
+	response, err := app.Test(request)
+	assert.Nil(t, err)
+
+	bytes, err := io.ReadAll(response.Body)
+	assert.Nil(t, err)
+
+	loggedIn := new(model.WebResponse[model.UserResponse])
+	err = json.Unmarshal(bytes, loggedIn)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+
+	request = httptest.NewRequest(http.MethodGet, "/api/users/_current/logins?size=1", nil)
+	request.Header.Set("Accept", "application/json")
+	request.Header.Set("Authorization", loggedIn.Data.Token)
+
+	response, err = app.Test(request)
+	assert.Nil(t, err)
+
+	bytes, err = io.ReadAll(response.Body)
+	assert.Nil(t, err)
+
+	responseBody := new(model.WebResponse[[]model.LoginEventResponse])
+	err = json.Unmarshal(bytes, responseBody)
+	assert.Nil(t, err)
+
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+	assert.Equal(t, int64(2), responseBody.Paging.TotalItem)
+	assert.Equal(t, 1, len(responseBody.Data))
+	assert.True(t, responseBody.Data[0].Success)
+	assert.Equal(t, model.LoginMethodPassword, responseBody.Data[0].Method)
+	assert.Equal(t, "Eko's laptop", responseBody.Data[0].Device)
+	assert.NotEmpty(t, responseBody.Data[0].IP)
+
+	request = httptest.NewRequest(http.MethodGet, "/api/users/_current/logins?size=1&page=2", nil)
+	request.Header.Set("Accept", "application/json")
+	request.Header.Set("Authorization", loggedIn.Data.Token)
+
+	response, err = app.Test(request)
+	assert.Nil(t, err)
+
+	bytes, err = io.ReadAll(response.Body)
+	assert.Nil(t, err)
+
+	responseBody = new(model.WebResponse[[]model.LoginEventResponse])
+	err = json.Unmarshal(bytes, responseBody)
+	assert.Nil(t, err)
+
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+	assert.Equal(t, 1, len(responseBody.Data))
+	assert.False(t, responseBody.Data[0].Success)
+	assert.Equal(t, "Unauthorized", responseBody.Data[0].Reason)
+}
@@ -0,0 +1,115 @@
+package test
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"go-rest-scaffold/internal/config"
+	"go-rest-scaffold/internal/entity"
+	"go-rest-scaffold/internal/model"
+	"go-rest-scaffold/internal/repository"
+	"go-rest-scaffold/internal/usecase"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMagicLink(t *testing.T) {
+	TestLogin(t)
+
+	err := db.Exec("UPDATE users SET email = ?, verified_at = NULL WHERE id = ?", "khannedy@example.com", "khannedy").Error
+	assert.Nil(t, err)
+
+	sender := make(recordingSender, 1)
+	emailTemplateUseCase := usecase.NewEmailTemplateUseCase(txManager, log, validate, config.NewMailCatalog(viperConfig, log), repository.NewEmailTemplateRepository(log))
+	userUseCase := usecase.NewUserUseCase(txManager, log, validate, repository.NewUserRepository(log), repository.NewSessionRepository(log), repository.NewPasswordResetRepository(log),
+		repository.NewMagicLinkRepository(log), nil, nil, emailTemplateUseCase, sender, nil, nil, nil, nil, usecase.UserOptions{
+			MagicLinkURL: "https://example.com/magic-link",
+			MagicLinkTTL: 15 * time.Minute,
+		})
+
+	sent, err := userUseCase.SendMagicLink(context.Background(), &model.MagicLinkRequest{Email: "KHANNEDY@example.com"})
+	assert.Nil(t, err)
+	assert.True(t, sent)
+
+	var email sentMail
+	select {
+	case email = <-sender:
+	case <-time.After(5 * time.Second):
+		t.Fatal("magic link email was not sent")
+	}
+	assert.Equal(t, "khannedy@example.com", email.to)
+	assert.Contains(t, email.message.Text, "15 minutes")
+
+	token := regexp.MustCompile(`token=([^\s&]+)`).FindStringSubmatch(email.message.Text)[1]
+
+	request := httptest.NewRequest(http.MethodPost, "/api/users/_magic-link/_exchange", strings.NewReader(`{"token":"`+token+`"}`))
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("Accept", "application/json")
+
+	response, err := app.Test(request)
+	assert.Nil(t, err)
+
+	bytes, err := io.ReadAll(response.Body)
+	assert.Nil(t, err)
+
+	responseBody := new(model.WebResponse[model.UserResponse])
+	err = json.Unmarshal(bytes, responseBody)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+	assert.NotEmpty(t, responseBody.Data.Token)
+	assert.NotEmpty(t, responseBody.Data.RefreshToken)
+
+	user := new(entity.User)
+	err = db.Where("id = ?", "khannedy").First(user).Error
+	assert.Nil(t, err)
+	assert.NotNil(t, user.VerifiedAt)
+
+	// the token is used up
+	request = httptest.NewRequest(http.MethodPost, "/api/users/_magic-link/_exchange", strings.NewReader(`{"token":"`+token+`"}`))
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("Accept", "application/json")
+
+	response, err = app.Test(request)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusUnauthorized, response.StatusCode)
+}
+
+func TestMagicLinkExpired(t *testing.T) {
+	TestLogin(t)
+
+	hash := sha256.Sum256([]byte("expired-token"))
+	err := db.Create(&entity.MagicLink{
+		ID:        hex.EncodeToString(hash[:]),
+		UserId:    "khannedy",
+		ExpiresAt: time.Now().Add(-time.Minute).UnixMilli(),
+	}).Error
+	assert.Nil(t, err)
+
+	request := httptest.NewRequest(http.MethodPost, "/api/users/_magic-link/_exchange", strings.NewReader(`{"token":"expired-token"}`))
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("Accept", "application/json")
+
+	response, err := app.Test(request)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusUnauthorized, response.StatusCode)
+}
+
+func TestMagicLinkUnknownEmail(t *testing.T) {
+	ClearAll()
+
+	request := httptest.NewRequest(http.MethodPost, "/api/users/_magic-link", strings.NewReader(`{"email":"nobody@example.com"}`))
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("Accept", "application/json")
+
+	response, err := app.Test(request)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+}
@@ -0,0 +1,81 @@
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"go-rest-scaffold/api"
+	"go-rest-scaffold/internal/gateway/messaging"
+	"go-rest-scaffold/internal/model"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// recordingProducer keeps the messages produced to it.
+type recordingProducer struct {
+	messages []*messaging.Message
+}
+
+func (p *recordingProducer) Produce(ctx context.Context, message *messaging.Message) error {
+	p.messages = append(p.messages, message)
+	return nil
+}
+
+func TestPublishEvents(t *testing.T) {
+	producer := new(recordingProducer)
+	publisher := messaging.NewPublisher(producer, map[string]string{
+		"contact": "contacts",
+		"address": "contacts",
+	}, "events")
+
+	contact := model.NewCloudEvent("test", model.EventContactCreated, "contacts/1", "khannedy", &model.ContactResponse{ID: "1"})
+	err := publisher.Publish(context.Background(), contact)
+	assert.Nil(t, err)
+
+	user := model.NewCloudEvent("test", model.EventUserDeleted, "users/khannedy", "khannedy", &model.UserResponse{ID: "khannedy"})
+	err = publisher.Publish(context.Background(), user)
+	assert.Nil(t, err)
+
+	assert.Equal(t, 2, len(producer.messages))
+	assert.Equal(t, "contacts", producer.messages[0].Topic)
+	assert.Equal(t, "khannedy", producer.messages[0].Key)
+	assert.Equal(t, contact.ID, producer.messages[0].ID)
+	assert.Equal(t, "application/cloudevents+json", producer.messages[0].Headers["content-type"])
+	assert.Equal(t, model.EventContactCreated, producer.messages[0].Headers["ce_type"])
+	// resources without a topic go to the default one
+	assert.Equal(t, "events", producer.messages[1].Topic)
+
+	published := new(model.CloudEvent)
+	err = json.Unmarshal(producer.messages[0].Value, published)
+	assert.Nil(t, err)
+	assert.Equal(t, contact.ID, published.ID)
+	assert.Equal(t, "contacts/1", published.Subject)
+}
+
+func TestAsyncAPIDocumentsEvents(t *testing.T) {
+	document := new(struct {
+		Components struct {
+			Messages map[string]struct {
+				Name string `json:"name"`
+			} `json:"messages"`
+		} `json:"components"`
+	})
+	err := json.Unmarshal(api.AsyncAPI, document)
+	assert.Nil(t, err)
+
+	documented := make(map[string]bool)
+	for _, message := range document.Components.Messages {
+		documented[message.Name] = true
+	}
+
+	for _, eventType := range []string{
+		model.EventUserRegistered, model.EventUserUpdated, model.EventUserDeleted, model.EventAccountImported,
+		model.EventContactCreated, model.EventContactUpdated, model.EventContactDeleted, model.EventContactRestored,
+		model.EventAddressCreated, model.EventAddressUpdated, model.EventAddressDeleted, model.EventAddressRestored,
+		model.EventContactTagged, model.EventContactUntagged,
+		model.EventTagCreated, model.EventTagUpdated, model.EventTagDeleted,
+		model.EventNoteCreated, model.EventNoteUpdated, model.EventNoteDeleted,
+	} {
+		assert.True(t, documented[eventType], eventType)
+	}
+}
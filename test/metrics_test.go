@@ -0,0 +1,136 @@
+package test
+
+import (
+	"context"
+	"go-rest-scaffold/internal/delivery/http/middleware"
+	"go-rest-scaffold/internal/gateway/lock"
+	"go-rest-scaffold/internal/metrics"
+	"go-rest-scaffold/internal/model"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+// scrapeMetrics reads /metrics as an admin.
+func scrapeMetrics(t *testing.T) (*http.Response, string) {
+	TestLogin(t)
+	user := GetFirstUser(t)
+	err := db.Model(user).Update("role", model.RoleAdmin).Error
+	assert.Nil(t, err)
+
+	request := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+	request.Header.Set("Authorization", user.Token)
+
+	response, err := app.Test(request)
+	assert.Nil(t, err)
+
+	bytes, err := io.ReadAll(response.Body)
+	assert.Nil(t, err)
+	return response, string(bytes)
+}
+
+func TestBusinessEventMetrics(t *testing.T) {
+	TestRegister(t)
+	TestRegisterDuplicate(t)
+
+	response, body := scrapeMetrics(t)
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+	assert.True(t, strings.Contains(body, `user_registrations_total{outcome="success"}`))
+	assert.True(t, strings.Contains(body, `user_registrations_total{outcome="conflict"}`))
+}
+
+func TestMetricsOutcome(t *testing.T) {
+	assert.Equal(t, metrics.OutcomeSuccess, metrics.Outcome(nil))
+	assert.Equal(t, metrics.OutcomeInvalid, metrics.Outcome(fiber.ErrBadRequest))
+	assert.Equal(t, metrics.OutcomeUnauthorized, metrics.Outcome(fiber.ErrUnauthorized))
+	assert.Equal(t, metrics.OutcomeConflict, metrics.Outcome(fiber.ErrConflict))
+	assert.Equal(t, metrics.OutcomeError, metrics.Outcome(io.EOF))
+}
+
+func TestSlowQueryMetrics(t *testing.T) {
+	if db.Dialector.Name() != "postgres" {
+		t.Skip("pg_sleep needs postgres")
+	}
+
+	err := db.Exec("SELECT pg_sleep(0.3)").Error
+	assert.Nil(t, err)
+
+	response, body := scrapeMetrics(t)
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+	assert.True(t, strings.Contains(body, `db_slow_queries_total{operation="select"}`))
+}
+
+func TestMetricsRequireAdmin(t *testing.T) {
+	ClearAll()
+	TestLogin(t)
+	user := GetFirstUser(t)
+
+	request := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+	response, err := app.Test(request)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusUnauthorized, response.StatusCode)
+
+	request = httptest.NewRequest(http.MethodGet, "/metrics", nil)
+	request.Header.Set("Authorization", user.Token)
+	response, err = app.Test(request)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusForbidden, response.StatusCode)
+}
+
+func TestMetricsToken(t *testing.T) {
+	metricsApp := fiber.New()
+	metrics := func(ctx *fiber.Ctx) error {
+		return ctx.SendString("metrics")
+	}
+	refused := func(ctx *fiber.Ctx) error {
+		return fiber.ErrUnauthorized
+	}
+	metricsApp.Get("/metrics", middleware.NewMetricsToken("scrape-token")(metrics), refused)
+	metricsApp.Get("/unconfigured", middleware.NewMetricsToken("")(metrics), refused)
+
+	for _, test := range []struct {
+		path          string
+		authorization string
+		status        int
+	}{
+		{"/metrics", "Bearer scrape-token", http.StatusOK},
+		{"/metrics", "Bearer other-token", http.StatusUnauthorized},
+		{"/metrics", "scrape-token", http.StatusUnauthorized},
+		{"/metrics", "", http.StatusUnauthorized},
+		{"/unconfigured", "Bearer ", http.StatusUnauthorized},
+	} {
+		request := httptest.NewRequest(http.MethodGet, test.path, nil)
+		if test.authorization != "" {
+			request.Header.Set("Authorization", test.authorization)
+		}
+
+		response, err := metricsApp.Test(request)
+		assert.Nil(t, err)
+		assert.Equal(t, test.status, response.StatusCode, test.path+" "+test.authorization)
+	}
+}
+
+func TestLockMetricsByKind(t *testing.T) {
+	acquired := metrics.LockAcquisitions.WithLabelValues("test-kind", "acquired")
+	before := testutil.ToFloat64(acquired)
+
+	locker := lock.NewLocker(nil, log)
+	for _, userId := range []string{"khannedy", "budi"} {
+		held, err := locker.Lock(context.Background(), "test-kind:"+userId, time.Second)
+		assert.Nil(t, err)
+		assert.Nil(t, held.Unlock(context.Background()))
+	}
+	assert.Equal(t, before+2, testutil.ToFloat64(acquired))
+
+	// the locks of every user share their series
+	_, body := scrapeMetrics(t)
+	assert.NotContains(t, body, "test-kind:")
+	assert.Contains(t, body, `lock_wait_seconds_count{kind="test-kind"}`)
+}
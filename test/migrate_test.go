@@ -0,0 +1,29 @@
+package test
+
+import (
+	"bytes"
+	"go-rest-scaffold/internal/config"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMigrateUp(t *testing.T) {
+	err := config.MigrateUp(viperConfig, log)
+	assert.Nil(t, err)
+
+	output := new(bytes.Buffer)
+	err = config.MigrationStatus(viperConfig, log, output)
+	assert.Nil(t, err)
+	assert.True(t, strings.Contains(output.String(), ", 0 pending"))
+	assert.False(t, strings.Contains(output.String(), "dirty"))
+}
+
+func TestRunCommandUsage(t *testing.T) {
+	err := config.RunCommand(viperConfig, log, []string{"migrate"}, new(bytes.Buffer))
+	assert.NotNil(t, err)
+
+	err = config.RunCommand(viperConfig, log, []string{"migrate", "sideways"}, new(bytes.Buffer))
+	assert.NotNil(t, err)
+}
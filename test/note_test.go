@@ -0,0 +1,188 @@
+package test
+
+import (
+	"encoding/json"
+	"go-rest-scaffold/internal/entity"
+	"go-rest-scaffold/internal/model"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func createNote(t *testing.T, user *entity.User, contact *entity.Contact, body string) *model.NoteResponse {
+	request := httptest.NewRequest(http.MethodPost, "/api/contacts/"+contact.ID+"/notes", strings.NewReader(`{"body":"`+body+`"}`))
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("Accept", "application/json")
+	request.Header.Set("Authorization", user.Token)
+
+	response, err := app.Test(request)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+
+	bytes, err := io.ReadAll(response.Body)
+	assert.Nil(t, err)
+
+	responseBody := new(model.WebResponse[*model.NoteResponse])
+	err = json.Unmarshal(bytes, responseBody)
+	assert.Nil(t, err)
+	return responseBody.Data
+}
+
+func TestCreateNote(t *testing.T) {
+	TestCreateContact(t)
+
+	user := GetFirstUser(t)
+	contact := GetFirstContact(t, user)
+
+	note := createNote(t, user, contact, "Called about the renewal")
+	assert.NotEmpty(t, note.ID)
+	assert.Equal(t, "Called about the renewal", note.Body)
+	assert.NotZero(t, note.CreatedAt)
+	assert.Equal(t, note.CreatedAt, note.UpdatedAt)
+}
+
+func TestCreateNoteFailed(t *testing.T) {
+	TestCreateContact(t)
+
+	user := GetFirstUser(t)
+	contact := GetFirstContact(t, user)
+
+	request := httptest.NewRequest(http.MethodPost, "/api/contacts/"+contact.ID+"/notes", strings.NewReader(`{"body":""}`))
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("Accept", "application/json")
+	request.Header.Set("Authorization", user.Token)
+
+	response, err := app.Test(request)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusBadRequest, response.StatusCode)
+
+	request = httptest.NewRequest(http.MethodPost, "/api/contacts/"+uuid.NewString()+"/notes", strings.NewReader(`{"body":"Hello"}`))
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("Accept", "application/json")
+	request.Header.Set("Authorization", user.Token)
+
+	response, err = app.Test(request)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusNotFound, response.StatusCode)
+}
+
+func TestUpdateNote(t *testing.T) {
+	TestCreateContact(t)
+
+	user := GetFirstUser(t)
+	contact := GetFirstContact(t, user)
+	note := createNote(t, user, contact, "Called about the renewal")
+
+	request := httptest.NewRequest(http.MethodPut, "/api/contacts/"+contact.ID+"/notes/"+note.ID, strings.NewReader(`{"body":"Renewal signed"}`))
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("Accept", "application/json")
+	request.Header.Set("Authorization", user.Token)
+
+	response, err := app.Test(request)
+	assert.Nil(t, err)
+
+	bytes, err := io.ReadAll(response.Body)
+	assert.Nil(t, err)
+
+	responseBody := new(model.WebResponse[model.NoteResponse])
+	err = json.Unmarshal(bytes, responseBody)
+	assert.Nil(t, err)
+
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+	assert.Equal(t, "Renewal signed", responseBody.Data.Body)
+	assert.Equal(t, note.CreatedAt, responseBody.Data.CreatedAt)
+}
+
+func TestGetNote(t *testing.T) {
+	TestCreateContact(t)
+
+	user := GetFirstUser(t)
+	contact := GetFirstContact(t, user)
+	note := createNote(t, user, contact, "Called about the renewal")
+
+	request := httptest.NewRequest(http.MethodGet, "/api/contacts/"+contact.ID+"/notes/"+note.ID, nil)
+	request.Header.Set("Accept", "application/json")
+	request.Header.Set("Authorization", user.Token)
+
+	response, err := app.Test(request)
+	assert.Nil(t, err)
+
+	bytes, err := io.ReadAll(response.Body)
+	assert.Nil(t, err)
+
+	responseBody := new(model.WebResponse[model.NoteResponse])
+	err = json.Unmarshal(bytes, responseBody)
+	assert.Nil(t, err)
+
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+	assert.Equal(t, note.ID, responseBody.Data.ID)
+	assert.Equal(t, note.Body, responseBody.Data.Body)
+}
+
+func TestDeleteNote(t *testing.T) {
+	TestCreateContact(t)
+
+	user := GetFirstUser(t)
+	contact := GetFirstContact(t, user)
+	note := createNote(t, user, contact, "Called about the renewal")
+
+	request := httptest.NewRequest(http.MethodDelete, "/api/contacts/"+contact.ID+"/notes/"+note.ID, nil)
+	request.Header.Set("Accept", "application/json")
+	request.Header.Set("Authorization", user.Token)
+
+	response, err := app.Test(request)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+
+	request = httptest.NewRequest(http.MethodGet, "/api/contacts/"+contact.ID+"/notes/"+note.ID, nil)
+	request.Header.Set("Accept", "application/json")
+	request.Header.Set("Authorization", user.Token)
+
+	response, err = app.Test(request)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusNotFound, response.StatusCode)
+}
+
+func TestListNotes(t *testing.T) {
+	TestCreateContact(t)
+
+	user := GetFirstUser(t)
+	contact := GetFirstContact(t, user)
+	for i := 0; i < 3; i++ {
+		note := &entity.Note{
+			ID:        uuid.NewString(),
+			ContactId: contact.ID,
+			Body:      "Note " + strconv.Itoa(i),
+			CreatedAt: int64(1000 + i),
+		}
+		err := db.Create(note).Error
+		assert.Nil(t, err)
+	}
+
+	request := httptest.NewRequest(http.MethodGet, "/api/contacts/"+contact.ID+"/notes?page=1&size=2", nil)
+	request.Header.Set("Accept", "application/json")
+	request.Header.Set("Authorization", user.Token)
+
+	response, err := app.Test(request)
+	assert.Nil(t, err)
+
+	bytes, err := io.ReadAll(response.Body)
+	assert.Nil(t, err)
+
+	responseBody := new(model.WebResponse[[]model.NoteResponse])
+	err = json.Unmarshal(bytes, responseBody)
+	assert.Nil(t, err)
+
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+	assert.Equal(t, 2, len(responseBody.Data))
+	assert.Equal(t, "Note 2", responseBody.Data[0].Body)
+	assert.Equal(t, "Note 1", responseBody.Data[1].Body)
+	assert.Equal(t, int64(3), responseBody.Paging.TotalItem)
+	assert.Equal(t, int64(2), responseBody.Paging.TotalPage)
+}
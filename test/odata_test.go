@@ -0,0 +1,281 @@
+package test
+
+import (
+	"encoding/json"
+	"go-rest-scaffold/internal/config"
+	"go-rest-scaffold/internal/delivery/http/odata"
+	"go-rest-scaffold/internal/entity"
+	"go-rest-scaffold/internal/model"
+	"go-rest-scaffold/internal/repository"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+// condition is the filter node of a comparison.
+func condition(field string, operator string, value any) *model.FilterNode {
+	return &model.FilterNode{Operator: operator, Field: field, Value: value}
+}
+
+func TestParseFilter(t *testing.T) {
+	for _, test := range []struct {
+		filter   string
+		expected *model.FilterNode
+	}{
+		{"first_name eq 'Eko'", condition("first_name", model.FilterEq, "Eko")},
+		{"created_at ge 1700000000000", condition("created_at", model.FilterGe, int64(1700000000000))},
+		{"score lt -1.5", condition("score", model.FilterLt, -1.5)},
+		{"last_name ne null", condition("last_name", model.FilterNe, nil)},
+		{"verified eq true", condition("verified", model.FilterEq, true)},
+		{"contains(first_name,'o''Neil')", condition("first_name", model.FilterContains, "o'Neil")},
+		{"StartsWith(email, 'eko')", condition("email", model.FilterStartsWith, "eko")},
+		// and binds tighter than or
+		{"a eq 1 or b eq 2 and c eq 3", &model.FilterNode{Operator: model.FilterOr, Children: []*model.FilterNode{
+			condition("a", model.FilterEq, int64(1)),
+			{Operator: model.FilterAnd, Children: []*model.FilterNode{condition("b", model.FilterEq, int64(2)), condition("c", model.FilterEq, int64(3))}},
+		}}},
+		{"(a eq 1 or b eq 2) and c eq 3", &model.FilterNode{Operator: model.FilterAnd, Children: []*model.FilterNode{
+			{Operator: model.FilterOr, Children: []*model.FilterNode{condition("a", model.FilterEq, int64(1)), condition("b", model.FilterEq, int64(2))}},
+			condition("c", model.FilterEq, int64(3)),
+		}}},
+		// not binds tighter than and
+		{"not a eq 1 and b eq 2", &model.FilterNode{Operator: model.FilterAnd, Children: []*model.FilterNode{
+			{Operator: model.FilterNot, Children: []*model.FilterNode{condition("a", model.FilterEq, int64(1))}},
+			condition("b", model.FilterEq, int64(2)),
+		}}},
+		// operators are left associative and keywords case insensitive
+		{"a EQ 1 OR b eq 2 Or c eq 3", &model.FilterNode{Operator: model.FilterOr, Children: []*model.FilterNode{
+			{Operator: model.FilterOr, Children: []*model.FilterNode{condition("a", model.FilterEq, int64(1)), condition("b", model.FilterEq, int64(2))}},
+			condition("c", model.FilterEq, int64(3)),
+		}}},
+	} {
+		node, err := odata.ParseFilter(test.filter)
+		assert.Nil(t, err, test.filter)
+		assert.Equal(t, test.expected, node, test.filter)
+	}
+}
+
+func TestParseFilterErrors(t *testing.T) {
+	for _, filter := range []string{
+		"",
+		"first_name",
+		"first_name eq",
+		"first_name like 'Eko'",
+		"first_name eq Eko",
+		"first_name eq 'Eko",
+		"first_name eq 1.2.3",
+		"first_name eq 'Eko' and",
+		"first_name eq 'Eko' first_name",
+		"first_name eq 'Eko'; DROP TABLE users",
+		"first_name eq 'Eko' -- comment",
+		"(first_name eq 'Eko'",
+		"first_name eq 'Eko')",
+		"contains(first_name, 1)",
+		"contains(first_name 'Eko')",
+		"contains('Eko', first_name)",
+		"not",
+	} {
+		_, err := odata.ParseFilter(filter)
+		assert.NotNil(t, err, filter)
+	}
+}
+
+// placeholder matches the placeholders of every dialect.
+var placeholder = regexp.MustCompile(`\$\d+|\?`)
+
+// filterSQL returns the query finding the contacts matching node, with ? for its
+// placeholders, its values and the error of building it.
+func filterSQL(node *model.FilterNode, sort ...model.SortField) (string, []any, error) {
+	tx := db.Session(&gorm.Session{DryRun: true}).Model(new(entity.Contact)).
+		Scopes(repository.FilterSpecification(node, repository.ContactColumns), repository.SortSpecification(sort, repository.ContactColumns)).
+		Find(&[]entity.Contact{})
+	return placeholder.ReplaceAllString(tx.Statement.SQL.String(), "?"), tx.Statement.Vars, tx.Error
+}
+
+func TestFilterSpecification(t *testing.T) {
+	for _, test := range []struct {
+		node *model.FilterNode
+		sql  string
+		vars []any
+	}{
+		{condition("first_name", model.FilterEq, "Eko"), "first_name = ?", []any{"Eko"}},
+		{condition("last_name", model.FilterNe, "Eko"), "last_name <> ?", []any{"Eko"}},
+		{condition("created_at", model.FilterGe, int64(1700000000000)), "created_at >= ?", []any{int64(1700000000000)}},
+		{condition("updated_at", model.FilterLt, int64(1700000000000)), "updated_at < ?", []any{int64(1700000000000)}},
+		{condition("last_name", model.FilterEq, nil), "last_name IS NULL", nil},
+		{condition("last_name", model.FilterNe, nil), "last_name IS NOT NULL", nil},
+		{condition("first_name", model.FilterContains, "Eko"), "first_name LIKE ?", []any{"%Eko%"}},
+		{condition("first_name", model.FilterStartsWith, "Eko"), "first_name LIKE ?", []any{"Eko%"}},
+		{condition("first_name", model.FilterEndsWith, "Eko"), "first_name LIKE ?", []any{"%Eko"}},
+		// wildcards in the value are escaped
+		{condition("first_name", model.FilterContains, `50%_off\`), "first_name LIKE ?", []any{`%50\%\_off\\%`}},
+		// values are bound, so quotes cannot end a literal
+		{condition("first_name", model.FilterEq, "x' OR '1'='1"), "first_name = ?", []any{"x' OR '1'='1"}},
+		{&model.FilterNode{Operator: model.FilterOr, Children: []*model.FilterNode{
+			condition("first_name", model.FilterEq, "a"),
+			{Operator: model.FilterAnd, Children: []*model.FilterNode{condition("last_name", model.FilterEq, "b"), condition("id", model.FilterEq, "c")}},
+		}}, "(first_name = ?) OR ((last_name = ?) AND (id = ?))", []any{"a", "b", "c"}},
+		{&model.FilterNode{Operator: model.FilterNot, Children: []*model.FilterNode{
+			{Operator: model.FilterOr, Children: []*model.FilterNode{condition("first_name", model.FilterEq, "a"), condition("last_name", model.FilterEq, "b")}},
+		}}, "NOT ((first_name = ?) OR (last_name = ?))", []any{"a", "b"}},
+		// public fields are mapped to their column
+		{condition("email", model.FilterEq, "eko@example.com"),
+			"coalesce((SELECT email FROM contact_emails WHERE contact_emails.contact_id = contacts.id AND is_primary), '') = ?", []any{"eko@example.com"}},
+	} {
+		sql, vars, err := filterSQL(test.node)
+		assert.Nil(t, err, test.sql)
+		assert.Contains(t, sql, test.sql)
+		assert.Equal(t, test.vars, vars, test.sql)
+	}
+}
+
+func TestFilterSpecificationColumns(t *testing.T) {
+	for _, node := range []*model.FilterNode{
+		condition("password", model.FilterEq, "rahasia"),
+		condition("user_id", model.FilterEq, "khannedy"),
+		condition("first_name; DROP TABLE users; --", model.FilterEq, "x"),
+		condition("1=1 OR first_name", model.FilterEq, "x"),
+		{Operator: model.FilterAnd, Children: []*model.FilterNode{
+			condition("first_name", model.FilterEq, "Eko"),
+			{Operator: model.FilterNot, Children: []*model.FilterNode{condition("password", model.FilterEq, "rahasia")}},
+		}},
+	} {
+		assert.NotNil(t, repository.ContactColumns.CheckFilter(node))
+
+		sql, _, err := filterSQL(node)
+		assert.NotNil(t, err)
+		assert.NotContains(t, sql, "password")
+		assert.NotContains(t, sql, "DROP")
+	}
+
+	sql, _, err := filterSQL(nil, model.SortField{Field: "last_name", Desc: true}, model.SortField{Field: "id"})
+	assert.Nil(t, err)
+	assert.Contains(t, sql, "ORDER BY last_name DESC,id")
+
+	for _, field := range []string{"password", "first_name; DROP TABLE users", "(SELECT 1)"} {
+		sort := []model.SortField{{Field: field}}
+		assert.NotNil(t, repository.ContactColumns.CheckSort(sort))
+
+		_, _, err := filterSQL(nil, sort...)
+		assert.NotNil(t, err, field)
+	}
+
+	// an unknown operator is refused rather than ignored
+	_, _, err = filterSQL(condition("first_name", "like", "Eko"))
+	assert.NotNil(t, err)
+}
+
+func TestFilterSpecificationWildcards(t *testing.T) {
+	ClearAll()
+	TestLogin(t)
+	user := GetFirstUser(t)
+
+	for _, name := range []string{"john_doe", "johnxdoe", "50% off", "500 off", `back\slash`} {
+		err := db.Create(&entity.Contact{ID: uuid.NewString(), FirstName: name, UserId: user.ID}).Error
+		assert.Nil(t, err)
+	}
+
+	// wildcards in the value match themselves only
+	for _, test := range []struct {
+		node     *model.FilterNode
+		expected []string
+	}{
+		{condition("first_name", model.FilterContains, "n_d"), []string{"john_doe"}},
+		{condition("first_name", model.FilterStartsWith, "50%"), []string{"50% off"}},
+		{condition("first_name", model.FilterEndsWith, `\slash`), []string{`back\slash`}},
+		{condition("first_name", model.FilterContains, "nxd"), []string{"johnxdoe"}},
+	} {
+		var contacts []entity.Contact
+		err := db.Where("user_id = ?", user.ID).Scopes(repository.FilterSpecification(test.node, repository.ContactColumns)).Find(&contacts).Error
+		assert.Nil(t, err)
+
+		var names []string
+		for _, contact := range contacts {
+			names = append(names, contact.FirstName)
+		}
+		assert.Equal(t, test.expected, names, test.node.Value)
+	}
+}
+
+// newODataApp bootstraps a second application with OData enabled.
+func newODataApp(t *testing.T) *fiber.App {
+	viperConfig.Set("odata.enabled", true)
+	t.Cleanup(func() { viperConfig.Set("odata.enabled", false) })
+
+	odataApp := config.NewFiber(viperConfig, log)
+	config.Bootstrap(&config.BootstrapConfig{
+		DB:       db,
+		App:      odataApp,
+		Log:      log,
+		Validate: validate,
+		Config:   viperConfig,
+		SkipJobs: true,
+	})
+	return odataApp
+}
+
+func TestListContactsOData(t *testing.T) {
+	ClearAll()
+	TestLogin(t)
+	user := GetFirstUser(t)
+	CreateContacts(user, 12)
+
+	odataApp := newODataApp(t)
+	list := func(query url.Values) (int, []string) {
+		request := httptest.NewRequest(http.MethodGet, "/api/contacts?"+query.Encode(), nil)
+		request.Header.Set("Accept", "application/json")
+		request.Header.Set("Authorization", user.Token)
+
+		response, err := odataApp.Test(request)
+		assert.Nil(t, err)
+
+		bytes, err := io.ReadAll(response.Body)
+		assert.Nil(t, err)
+
+		responseBody := new(model.WebResponse[[]model.ContactResponse])
+		assert.Nil(t, json.Unmarshal(bytes, responseBody))
+
+		var lastNames []string
+		for _, contact := range responseBody.Data {
+			lastNames = append(lastNames, contact.LastName)
+		}
+		return response.StatusCode, lastNames
+	}
+
+	for _, test := range []struct {
+		query    url.Values
+		expected []string
+	}{
+		{url.Values{"$filter": {"startswith(last_name,'1')"}, "$orderby": {"last_name"}}, []string{"1", "10", "11"}},
+		// and binds tighter than or
+		{url.Values{"$filter": {"last_name eq '1' or last_name eq '2' and first_name eq 'Nobody'"}}, []string{"1"}},
+		{url.Values{"$filter": {"(last_name eq '1' or last_name eq '2') and first_name eq 'Contact'"}, "$orderby": {"last_name desc"}}, []string{"2", "1"}},
+		{url.Values{"$filter": {"not startswith(last_name,'1')"}, "$orderby": {"last_name desc"}, "$top": {"2"}}, []string{"9", "8"}},
+		// an injected condition is only a value that matches nothing
+		{url.Values{"$filter": {"last_name eq '1'' OR ''1''=''1'"}}, nil},
+	} {
+		status, lastNames := list(test.query)
+		assert.Equal(t, http.StatusOK, status, test.query.Encode())
+		assert.Equal(t, test.expected, lastNames, test.query.Encode())
+	}
+
+	for _, query := range []url.Values{
+		{"$filter": {"password eq 'rahasia'"}},
+		{"$filter": {"user_id eq 'khannedy'"}},
+		{"$filter": {"last_name eq '1'; DELETE FROM contacts"}},
+		{"$filter": {"last_name eq"}},
+		{"$orderby": {"password desc"}},
+	} {
+		status, _ := list(query)
+		assert.Equal(t, http.StatusBadRequest, status, query.Encode())
+	}
+	assert.Equal(t, int64(12), countContacts(t, user))
+}
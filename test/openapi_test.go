@@ -0,0 +1,89 @@
+package test
+
+import (
+	"encoding/json"
+	"go-rest-scaffold/internal/config"
+	"go-rest-scaffold/internal/delivery/http/middleware"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func newOpenAPIApp() *fiber.App {
+	openAPIApp := fiber.New()
+	openAPIApp.Use(middleware.NewOpenAPIValidation(config.NewOpenAPIDocument(log), true))
+	openAPIApp.All("/api/*", func(ctx *fiber.Ctx) error {
+		return ctx.SendString("ok")
+	})
+	return openAPIApp
+}
+
+func TestOpenAPIValidationBody(t *testing.T) {
+	openAPIApp := newOpenAPIApp()
+
+	request := httptest.NewRequest(http.MethodPost, "/api/contacts", strings.NewReader(`{"last_name": 5}`))
+	request.Header.Set("Content-Type", "application/json")
+	response, err := openAPIApp.Test(request)
+	assert.Nil(t, err)
+
+	bytes, err := io.ReadAll(response.Body)
+	assert.Nil(t, err)
+
+	responseBody := make(map[string]any)
+	err = json.Unmarshal(bytes, &responseBody)
+	assert.Nil(t, err)
+
+	assert.Equal(t, http.StatusBadRequest, response.StatusCode)
+	assert.Equal(t, "first_name is required; last_name must be a string", responseBody["errors"])
+	assert.Equal(t, map[string]any{
+		"first_name": "first_name is required",
+		"last_name":  "last_name must be a string",
+	}, responseBody["fields"])
+}
+
+func TestOpenAPIValidationQuery(t *testing.T) {
+	openAPIApp := newOpenAPIApp()
+
+	request := httptest.NewRequest(http.MethodGet, "/api/contacts?size=ten", nil)
+	response, err := openAPIApp.Test(request)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusBadRequest, response.StatusCode)
+
+	request = httptest.NewRequest(http.MethodGet, "/api/contacts?size=10&unknown=1", nil)
+	response, err = openAPIApp.Test(request)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+}
+
+func TestOpenAPIValidationNestedBody(t *testing.T) {
+	openAPIApp := newOpenAPIApp()
+
+	request := httptest.NewRequest(http.MethodPost, "/api/trash/_restore", strings.NewReader(`{"items": [{"type": "note", "id": "abc"}]}`))
+	request.Header.Set("Content-Type", "application/json")
+	response, err := openAPIApp.Test(request)
+	assert.Nil(t, err)
+
+	bytes, err := io.ReadAll(response.Body)
+	assert.Nil(t, err)
+
+	responseBody := make(map[string]any)
+	err = json.Unmarshal(bytes, &responseBody)
+	assert.Nil(t, err)
+
+	assert.Equal(t, http.StatusBadRequest, response.StatusCode)
+	assert.Equal(t, "items[0].type must be one of: contact address", responseBody["errors"])
+}
+
+func TestOpenAPIValidationUndocumentedRoute(t *testing.T) {
+	openAPIApp := newOpenAPIApp()
+
+	request := httptest.NewRequest(http.MethodPost, "/api/not-documented", strings.NewReader(`not json`))
+	response, err := openAPIApp.Test(request)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+}
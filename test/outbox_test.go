@@ -0,0 +1,118 @@
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"go-rest-scaffold/internal/entity"
+	"go-rest-scaffold/internal/event"
+	"go-rest-scaffold/internal/model"
+	"go-rest-scaffold/internal/repository"
+	"go-rest-scaffold/internal/usecase"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// recordingBroker keeps the events relayed to it, or refuses them while failing.
+type recordingBroker struct {
+	events  []*model.CloudEvent
+	failing bool
+}
+
+func (b *recordingBroker) Publish(ctx context.Context, event *model.CloudEvent) error {
+	if b.failing {
+		return errors.New("broker unavailable")
+	}
+	b.events = append(b.events, event)
+	return nil
+}
+
+func TestOutboxRelay(t *testing.T) {
+	ClearAll()
+	TestRegister(t)
+
+	outboxRepository := repository.NewOutboxRepository(log)
+	bus := event.NewBus("test", log)
+	bus.Outbox = outboxRepository
+
+	var delivered []*model.CloudEvent
+	bus.Subscribe(func(ctx context.Context, event *model.CloudEvent) {
+		delivered = append(delivered, event)
+	})
+
+	contactUseCase := usecase.NewContactUseCase(txManager, log, validate, repository.NewContactRepository(log), bus,
+		nil, nil, usecase.ContactOptions{})
+	contact, err := contactUseCase.Create(context.Background(), &model.CreateContactRequest{
+		UserId:    "khannedy",
+		FirstName: "Eko",
+		Email:     "eko@example.com",
+	})
+	assert.Nil(t, err)
+
+	// the event is saved with the contact and still delivered in-process
+	var outboxEvents []entity.OutboxEvent
+	err = db.Find(&outboxEvents).Error
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(outboxEvents))
+	assert.Equal(t, model.EventContactCreated, outboxEvents[0].Type)
+	assert.Nil(t, outboxEvents[0].PublishedAt)
+	assert.Equal(t, 1, len(delivered))
+	assert.Equal(t, delivered[0].ID, outboxEvents[0].EventId)
+
+	broker := &recordingBroker{failing: true}
+	outboxUseCase := usecase.NewOutboxUseCase(txManager, log, outboxRepository, broker, usecase.OutboxOptions{BatchSize: 10})
+
+	relayed, err := outboxUseCase.Relay(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, 0, relayed)
+
+	outboxEvent := new(entity.OutboxEvent)
+	err = db.Take(outboxEvent, outboxEvents[0].ID).Error
+	assert.Nil(t, err)
+	assert.Equal(t, 1, outboxEvent.Attempts)
+	assert.Equal(t, "broker unavailable", outboxEvent.LastError)
+	assert.Nil(t, outboxEvent.PublishedAt)
+
+	broker.failing = false
+	relayed, err = outboxUseCase.Relay(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, 1, relayed)
+	assert.Equal(t, 1, len(broker.events))
+	assert.Equal(t, outboxEvents[0].EventId, broker.events[0].ID)
+	assert.Equal(t, "contacts/"+contact.ID, broker.events[0].Subject)
+
+	data := new(model.ContactResponse)
+	err = json.Unmarshal(*broker.events[0].Data.(*json.RawMessage), data)
+	assert.Nil(t, err)
+	assert.Equal(t, contact.ID, data.ID)
+
+	err = db.Take(outboxEvent, outboxEvents[0].ID).Error
+	assert.Nil(t, err)
+	assert.NotNil(t, outboxEvent.PublishedAt)
+
+	// published events are not relayed again
+	relayed, err = outboxUseCase.Relay(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, 0, relayed)
+	assert.Equal(t, 1, len(broker.events))
+}
+
+func TestOutboxRollback(t *testing.T) {
+	ClearAll()
+
+	outboxRepository := repository.NewOutboxRepository(log)
+	bus := event.NewBus("test", log)
+	bus.Outbox = outboxRepository
+
+	tx := txManager.Begin(context.Background())
+	_, err := bus.Record(context.Background(), tx, model.EventContactCreated, "contacts/1", "khannedy", nil)
+	assert.Nil(t, err)
+	tx.Rollback()
+
+	// an event of a change rolled back never reaches the outbox
+	var total int64
+	err = db.Model(&entity.OutboxEvent{}).Count(&total).Error
+	assert.Nil(t, err)
+	assert.Equal(t, int64(0), total)
+}
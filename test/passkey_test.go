@@ -0,0 +1,266 @@
+package test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"go-rest-scaffold/internal/entity"
+	"go-rest-scaffold/internal/model"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// softAuthenticator plays a FIDO2 authenticator holding one ES256 passkey, for the
+// relying party of config.json.
+type softAuthenticator struct {
+	key          *ecdsa.PrivateKey
+	credentialId []byte
+	signCount    uint32
+}
+
+const passkeyOrigin = "http://localhost:3000"
+
+func newSoftAuthenticator(t *testing.T) *softAuthenticator {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.Nil(t, err)
+	credentialId := make([]byte, 16)
+	_, err = rand.Read(credentialId)
+	assert.Nil(t, err)
+	return &softAuthenticator{key: key, credentialId: credentialId}
+}
+
+func (a *softAuthenticator) authenticatorData(flags byte) []byte {
+	rpIdHash := sha256.Sum256([]byte("localhost"))
+	data := append(rpIdHash[:], flags)
+	return binary.BigEndian.AppendUint32(data, a.signCount)
+}
+
+// create answers navigator.credentials.create() with attestation "none".
+func (a *softAuthenticator) create(challenge string) string {
+	point, _ := a.key.PublicKey.Bytes()
+	coseKey := cborMap(
+		cborInt(1), cborInt(2), // kty: EC2
+		cborInt(3), cborInt(-7), // alg: ES256
+		cborInt(-1), cborInt(1), // crv: P-256
+		cborInt(-2), cborBytes(point[1:33]),
+		cborInt(-3), cborBytes(point[33:]),
+	)
+
+	authData := a.authenticatorData(0x45)
+	authData = append(authData, make([]byte, 16)...)
+	authData = binary.BigEndian.AppendUint16(authData, uint16(len(a.credentialId)))
+	authData = append(authData, a.credentialId...)
+	authData = append(authData, coseKey...)
+
+	attestationObject := cborMap(
+		cborText("fmt"), cborText("none"),
+		cborText("attStmt"), cborMap(),
+		cborText("authData"), cborBytes(authData),
+	)
+	clientData := `{"type":"webauthn.create","challenge":"` + challenge + `","origin":"` + passkeyOrigin + `"}`
+
+	return `{"id":"` + encodeBase64URL(a.credentialId) + `","response":{"clientDataJSON":"` + encodeBase64URL([]byte(clientData)) +
+		`","attestationObject":"` + encodeBase64URL(attestationObject) + `"}}`
+}
+
+// get answers navigator.credentials.get() for the user.
+func (a *softAuthenticator) get(t *testing.T, challenge string, userId string) string {
+	a.signCount++
+	authData := a.authenticatorData(0x05)
+	clientData := []byte(`{"type":"webauthn.get","challenge":"` + challenge + `","origin":"` + passkeyOrigin + `"}`)
+
+	clientDataHash := sha256.Sum256(clientData)
+	digest := sha256.Sum256(append(authData, clientDataHash[:]...))
+	signature, err := ecdsa.SignASN1(rand.Reader, a.key, digest[:])
+	assert.Nil(t, err)
+
+	return `{"id":"` + encodeBase64URL(a.credentialId) + `","response":{"clientDataJSON":"` + encodeBase64URL(clientData) +
+		`","authenticatorData":"` + encodeBase64URL(authData) + `","signature":"` + encodeBase64URL(signature) +
+		`","userHandle":"` + encodeBase64URL([]byte(userId)) + `"}}`
+}
+
+func encodeBase64URL(data []byte) string {
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func cborHead(major byte, argument uint64) []byte {
+	switch {
+	case argument < 24:
+		return []byte{major<<5 | byte(argument)}
+	case argument < 1<<8:
+		return []byte{major<<5 | 24, byte(argument)}
+	default:
+		return binary.BigEndian.AppendUint16([]byte{major<<5 | 25}, uint16(argument))
+	}
+}
+
+func cborInt(value int64) []byte {
+	if value < 0 {
+		return cborHead(1, uint64(-1-value))
+	}
+	return cborHead(0, uint64(value))
+}
+
+func cborBytes(value []byte) []byte {
+	return append(cborHead(2, uint64(len(value))), value...)
+}
+
+func cborText(value string) []byte {
+	return append(cborHead(3, uint64(len(value))), value...)
+}
+
+func cborMap(keysAndValues ...[]byte) []byte {
+	encoded := cborHead(5, uint64(len(keysAndValues)/2))
+	for _, item := range keysAndValues {
+		encoded = append(encoded, item...)
+	}
+	return encoded
+}
+
+func beginPasskey(t *testing.T, path string, token string) string {
+	request := httptest.NewRequest(http.MethodPost, path, nil)
+	request.Header.Set("Accept", "application/json")
+	if token != "" {
+		request.Header.Set("Authorization", token)
+	}
+
+	response, err := app.Test(request)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+
+	bytes, err := io.ReadAll(response.Body)
+	assert.Nil(t, err)
+
+	responseBody := new(model.WebResponse[struct {
+		Challenge string `json:"challenge"`
+	}])
+	err = json.Unmarshal(bytes, responseBody)
+	assert.Nil(t, err)
+	assert.NotEmpty(t, responseBody.Data.Challenge)
+
+	return responseBody.Data.Challenge
+}
+
+func loginWithPasskey(t *testing.T, authenticator *softAuthenticator) *http.Response {
+	challenge := beginPasskey(t, "/api/users/_passkey-login/_begin", "")
+
+	request := httptest.NewRequest(http.MethodPost, "/api/users/_passkey-login",
+		strings.NewReader(`{"credential":`+authenticator.get(t, challenge, "khannedy")+`}`))
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("Accept", "application/json")
+
+	response, err := app.Test(request)
+	assert.Nil(t, err)
+	return response
+}
+
+func TestPasskey(t *testing.T) {
+	TestLogin(t)
+
+	user := new(entity.User)
+	err := db.Where("id = ?", "khannedy").First(user).Error
+	assert.Nil(t, err)
+
+	authenticator := newSoftAuthenticator(t)
+	challenge := beginPasskey(t, "/api/users/_current/passkeys/_begin", user.Token)
+
+	request := httptest.NewRequest(http.MethodPost, "/api/users/_current/passkeys",
+		strings.NewReader(`{"name":"laptop","credential":`+authenticator.create(challenge)+`}`))
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("Accept", "application/json")
+	request.Header.Set("Authorization", user.Token)
+
+	response, err := app.Test(request)
+	assert.Nil(t, err)
+
+	bytes, err := io.ReadAll(response.Body)
+	assert.Nil(t, err)
+
+	registered := new(model.WebResponse[model.PasskeyResponse])
+	err = json.Unmarshal(bytes, registered)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+	assert.Equal(t, "laptop", registered.Data.Name)
+
+	response = loginWithPasskey(t, authenticator)
+
+	bytes, err = io.ReadAll(response.Body)
+	assert.Nil(t, err)
+
+	loggedIn := new(model.WebResponse[model.UserResponse])
+	err = json.Unmarshal(bytes, loggedIn)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+	assert.NotEmpty(t, loggedIn.Data.Token)
+
+	passkey := new(entity.Passkey)
+	err = db.Where("id = ?", registered.Data.ID).Take(passkey).Error
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1), passkey.SignCount)
+	assert.NotNil(t, passkey.LastUsedAt)
+
+	// an authenticator whose counter went backwards is a clone
+	authenticator.signCount = 0
+	response = loginWithPasskey(t, authenticator)
+	assert.Equal(t, http.StatusUnauthorized, response.StatusCode)
+}
+
+func TestPasskeyWrongKey(t *testing.T) {
+	TestLogin(t)
+
+	user := new(entity.User)
+	err := db.Where("id = ?", "khannedy").First(user).Error
+	assert.Nil(t, err)
+
+	authenticator := newSoftAuthenticator(t)
+	challenge := beginPasskey(t, "/api/users/_current/passkeys/_begin", user.Token)
+
+	request := httptest.NewRequest(http.MethodPost, "/api/users/_current/passkeys",
+		strings.NewReader(`{"name":"laptop","credential":`+authenticator.create(challenge)+`}`))
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("Accept", "application/json")
+	request.Header.Set("Authorization", user.Token)
+
+	response, err := app.Test(request)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+
+	// the same credential ID signing with another key
+	impostor := newSoftAuthenticator(t)
+	impostor.credentialId = authenticator.credentialId
+	response = loginWithPasskey(t, impostor)
+	assert.Equal(t, http.StatusUnauthorized, response.StatusCode)
+}
+
+func TestPasskeyChallengeUsedOnce(t *testing.T) {
+	TestLogin(t)
+
+	user := new(entity.User)
+	err := db.Where("id = ?", "khannedy").First(user).Error
+	assert.Nil(t, err)
+
+	authenticator := newSoftAuthenticator(t)
+	challenge := beginPasskey(t, "/api/users/_current/passkeys/_begin", user.Token)
+	credential := authenticator.create(challenge)
+
+	for _, status := range []int{http.StatusOK, http.StatusBadRequest} {
+		request := httptest.NewRequest(http.MethodPost, "/api/users/_current/passkeys",
+			strings.NewReader(`{"name":"laptop","credential":`+credential+`}`))
+		request.Header.Set("Content-Type", "application/json")
+		request.Header.Set("Accept", "application/json")
+		request.Header.Set("Authorization", user.Token)
+
+		response, err := app.Test(request)
+		assert.Nil(t, err)
+		assert.Equal(t, status, response.StatusCode)
+	}
+}
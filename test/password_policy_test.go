@@ -0,0 +1,73 @@
+package test
+
+import (
+	"context"
+	"go-rest-scaffold/internal/entity"
+	"go-rest-scaffold/internal/model"
+	"go-rest-scaffold/internal/password"
+	"go-rest-scaffold/internal/repository"
+	"go-rest-scaffold/internal/usecase"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func newPasswordUserUseCase(options usecase.UserOptions) *usecase.UserUseCase {
+	return usecase.NewUserUseCase(txManager, log, validate, repository.NewUserRepository(log), repository.NewSessionRepository(log),
+		repository.NewPasswordResetRepository(log), nil, nil, nil, nil, nil, nil, nil, nil, nil, options)
+}
+
+func TestRegisterPasswordPolicy(t *testing.T) {
+	ClearAll()
+
+	common, err := password.CommonPasswords(strings.NewReader("Rahasia2024\n"))
+	assert.Nil(t, err)
+
+	userUseCase := newPasswordUserUseCase(usecase.UserOptions{
+		PasswordPolicy: password.Policy{MinLength: 8, RequireDigit: true, Common: common},
+	})
+
+	for _, weak := range []string{"rahasia", "rahasiaku", "password123", "rahasia2024"} {
+		_, err = userUseCase.Create(context.Background(), &model.RegisterUserRequest{
+			ID:       "khannedy",
+			Password: weak,
+			Name:     "Eko Khannedy",
+		})
+		assert.Equal(t, fiber.StatusBadRequest, err.(*fiber.Error).Code, weak)
+	}
+
+	_, err = userUseCase.Create(context.Background(), &model.RegisterUserRequest{
+		ID:       "khannedy",
+		Password: "rahasia2025",
+		Name:     "Eko Khannedy",
+	})
+	assert.Nil(t, err)
+}
+
+func TestLoginRehashesPassword(t *testing.T) {
+	ClearAll()
+	TestRegister(t) // hashed with bcrypt
+
+	argon2id := password.Hasher{
+		Algorithm: password.Argon2id,
+		Argon2:    password.Argon2Params{Memory: 16 * 1024, Iterations: 1, Parallelism: 1, SaltLength: 16, KeyLength: 32},
+	}
+	userUseCase := newPasswordUserUseCase(usecase.UserOptions{PasswordHasher: argon2id})
+
+	_, err := userUseCase.Login(context.Background(), &model.LoginUserRequest{ID: "khannedy", Password: "rahasia"})
+	assert.Nil(t, err)
+
+	user := new(entity.User)
+	err = db.Where("id = ?", "khannedy").Take(user).Error
+	assert.Nil(t, err)
+	assert.True(t, strings.HasPrefix(user.Password, "$argon2id$"))
+	assert.False(t, argon2id.NeedsRehash(user.Password))
+
+	// the upgraded hash still logs in, with either configuration
+	_, err = userUseCase.Login(context.Background(), &model.LoginUserRequest{ID: "khannedy", Password: "rahasia"})
+	assert.Nil(t, err)
+	_, err = newPasswordUserUseCase(usecase.UserOptions{}).Login(context.Background(), &model.LoginUserRequest{ID: "khannedy", Password: "rahasia"})
+	assert.Nil(t, err)
+}
@@ -0,0 +1,144 @@
+package test
+
+import (
+	"context"
+	"go-rest-scaffold/internal/config"
+	"go-rest-scaffold/internal/mail"
+	"go-rest-scaffold/internal/model"
+	"go-rest-scaffold/internal/repository"
+	"go-rest-scaffold/internal/usecase"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type sentMail struct {
+	to      string
+	message *mail.Message
+}
+
+type recordingSender chan sentMail
+
+func (s recordingSender) Send(ctx context.Context, to string, message *mail.Message) error {
+	s <- sentMail{to: to, message: message}
+	return nil
+}
+
+// requestPasswordReset logs in khannedy, asks for a password reset email and returns
+// the token it links to.
+func requestPasswordReset(t *testing.T) string {
+	TestLogin(t)
+
+	err := db.Exec("UPDATE users SET email = ? WHERE id = ?", "khannedy@example.com", "khannedy").Error
+	assert.Nil(t, err)
+
+	sender := make(recordingSender, 1)
+	emailTemplateUseCase := usecase.NewEmailTemplateUseCase(txManager, log, validate, config.NewMailCatalog(viperConfig, log), repository.NewEmailTemplateRepository(log))
+	userUseCase := usecase.NewUserUseCase(txManager, log, validate, repository.NewUserRepository(log), repository.NewSessionRepository(log), repository.NewPasswordResetRepository(log), nil, nil, nil,
+		emailTemplateUseCase, sender, nil, nil, nil, nil, usecase.UserOptions{
+			PasswordResetURL: "https://example.com/reset-password",
+			PasswordResetTTL: time.Hour,
+		})
+
+	sent, err := userUseCase.ForgotPassword(context.Background(), &model.ForgotPasswordRequest{Email: "KHANNEDY@example.com"})
+	assert.Nil(t, err)
+	assert.True(t, sent)
+
+	var email sentMail
+	select {
+	case email = <-sender:
+	case <-time.After(5 * time.Second):
+		t.Fatal("password reset email was not sent")
+	}
+	assert.Equal(t, "khannedy@example.com", email.to)
+	assert.Contains(t, email.message.Text, "1 hour")
+
+	return regexp.MustCompile(`token=([^\s&]+)`).FindStringSubmatch(email.message.Text)[1]
+}
+
+func resetPassword(token string, password string) (*http.Response, error) {
+	request := httptest.NewRequest(http.MethodPost, "/api/users/_reset-password", strings.NewReader(`{"token":"`+token+`","password":"`+password+`"}`))
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("Accept", "application/json")
+	return app.Test(request)
+}
+
+func TestResetPassword(t *testing.T) {
+	token := requestPasswordReset(t)
+
+	request := httptest.NewRequest(http.MethodPost, "/api/users/_reset-password", strings.NewReader(`{"token":"`+token+`","password":"baru"}`))
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("Accept", "application/json")
+
+	response, err := app.Test(request)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+
+	// the token is used up
+	request = httptest.NewRequest(http.MethodPost, "/api/users/_reset-password", strings.NewReader(`{"token":"`+token+`","password":"lagi"}`))
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("Accept", "application/json")
+
+	response, err = app.Test(request)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusBadRequest, response.StatusCode)
+
+	request = httptest.NewRequest(http.MethodPost, "/api/users/_login", strings.NewReader(`{"id":"khannedy","password":"baru"}`))
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("Accept", "application/json")
+
+	response, err = app.Test(request)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+}
+
+func TestResetPasswordConcurrently(t *testing.T) {
+	token := requestPasswordReset(t)
+
+	// the reset is locked by the first request, so the token sets a single password
+	var succeeded atomic.Int64
+	var wg sync.WaitGroup
+	for _, password := range []string{"baru", "lagi", "lain"} {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			response, err := resetPassword(token, password)
+			assert.Nil(t, err)
+			if response.StatusCode == http.StatusOK {
+				succeeded.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+	assert.LessOrEqual(t, succeeded.Load(), int64(1))
+}
+
+func TestResetPasswordDeletedUser(t *testing.T) {
+	token := requestPasswordReset(t)
+
+	err := db.Exec("UPDATE users SET deleted_at = ? WHERE id = ?", time.Now().UnixMilli(), "khannedy").Error
+	assert.Nil(t, err)
+
+	response, err := resetPassword(token, "baru")
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusBadRequest, response.StatusCode)
+}
+
+func TestForgotPasswordUnknownEmail(t *testing.T) {
+	ClearAll()
+
+	request := httptest.NewRequest(http.MethodPost, "/api/users/_forgot-password", strings.NewReader(`{"email":"nobody@example.com"}`))
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("Accept", "application/json")
+
+	response, err := app.Test(request)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+}
@@ -0,0 +1,115 @@
+package test
+
+import (
+	"encoding/json"
+	"go-rest-scaffold/internal/delivery/http/middleware"
+	"go-rest-scaffold/internal/entity"
+	"go-rest-scaffold/internal/gateway/ratelimit"
+	"go-rest-scaffold/internal/model"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRateLimitHeaders(t *testing.T) {
+	rateLimitConfig := viper.New()
+	rateLimitConfig.Set("rate_limit.enabled", true)
+	rateLimitConfig.Set("rate_limit.window", 60)
+	rateLimitConfig.Set("rate_limit.ip.limit", 2)
+
+	rateLimit := middleware.NewRateLimit(ratelimit.NewLimiter(nil, log), rateLimitConfig, log)
+	rateLimitApp := fiber.New()
+	rateLimitApp.Use(rateLimit("ip", middleware.ClientIPKey))
+	rateLimitApp.Get("/api/ping", func(ctx *fiber.Ctx) error {
+		return ctx.SendString("pong")
+	})
+
+	for _, remaining := range []string{"1", "0"} {
+		response, err := rateLimitApp.Test(httptest.NewRequest(http.MethodGet, "/api/ping", nil))
+		assert.Nil(t, err)
+
+		assert.Equal(t, http.StatusOK, response.StatusCode)
+		assert.Equal(t, "2", response.Header.Get("RateLimit-Limit"))
+		assert.Equal(t, remaining, response.Header.Get("RateLimit-Remaining"))
+		assert.NotEmpty(t, response.Header.Get("RateLimit-Reset"))
+	}
+
+	response, err := rateLimitApp.Test(httptest.NewRequest(http.MethodGet, "/api/ping", nil))
+	assert.Nil(t, err)
+
+	assert.Equal(t, http.StatusTooManyRequests, response.StatusCode)
+	assert.Equal(t, "0", response.Header.Get("RateLimit-Remaining"))
+	assert.Equal(t, response.Header.Get("RateLimit-Reset"), response.Header.Get("Retry-After"))
+}
+
+func TestRateLimitPolicyRefills(t *testing.T) {
+	rateLimitConfig := viper.New()
+	rateLimitConfig.Set("rate_limit.enabled", true)
+	rateLimitConfig.Set("rate_limit.window", 60)
+	rateLimitConfig.Set("rate_limit.bulk.limit", 2)
+	rateLimitConfig.Set("rate_limit.bulk.window", 1)
+
+	rateLimit := middleware.NewRateLimit(ratelimit.NewLimiter(nil, log), rateLimitConfig, log)
+	rateLimitApp := fiber.New()
+	rateLimitApp.Get("/api/contacts/_export", rateLimit("bulk", middleware.UserOrIPKey), func(ctx *fiber.Ctx) error {
+		return ctx.SendString("exported")
+	})
+
+	// the bucket lets a burst of the limit through at once
+	for range 2 {
+		response, err := rateLimitApp.Test(httptest.NewRequest(http.MethodGet, "/api/contacts/_export", nil))
+		assert.Nil(t, err)
+		assert.Equal(t, http.StatusOK, response.StatusCode)
+		assert.Equal(t, "2", response.Header.Get("X-RateLimit-Limit"))
+	}
+
+	response, err := rateLimitApp.Test(httptest.NewRequest(http.MethodGet, "/api/contacts/_export", nil))
+	assert.Nil(t, err)
+
+	assert.Equal(t, http.StatusTooManyRequests, response.StatusCode)
+	assert.Equal(t, "0", response.Header.Get("X-RateLimit-Remaining"))
+	reset, err := strconv.ParseInt(response.Header.Get("X-RateLimit-Reset"), 10, 64)
+	assert.Nil(t, err)
+	assert.InDelta(t, time.Now().Unix(), reset, 2)
+
+	// and refills at the limit per window of the policy, 2 per second here
+	time.Sleep(600 * time.Millisecond)
+
+	response, err = rateLimitApp.Test(httptest.NewRequest(http.MethodGet, "/api/contacts/_export", nil))
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+}
+
+func TestGetRateLimit(t *testing.T) {
+	ClearAll()
+	TestLogin(t)
+
+	user := new(entity.User)
+	err := db.Where("id = ?", "khannedy").First(user).Error
+	assert.Nil(t, err)
+
+	request := httptest.NewRequest(http.MethodGet, "/api/users/_current/rate-limit", nil)
+	request.Header.Set("Accept", "application/json")
+	request.Header.Set("Authorization", user.Token)
+
+	response, err := app.Test(request)
+	assert.Nil(t, err)
+
+	bytes, err := io.ReadAll(response.Body)
+	assert.Nil(t, err)
+
+	responseBody := new(model.WebResponse[model.RateLimitResponse])
+	err = json.Unmarshal(bytes, responseBody)
+	assert.Nil(t, err)
+
+	// rate limiting is disabled in config.json
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+	assert.False(t, responseBody.Data.Enabled)
+}
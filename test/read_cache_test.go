@@ -0,0 +1,84 @@
+package test
+
+import (
+	"context"
+	"go-rest-scaffold/internal/event"
+	"go-rest-scaffold/internal/model"
+	"go-rest-scaffold/internal/repository"
+	"go-rest-scaffold/internal/usecase"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// memoryCache is a usecase.Cache in a map, ignoring TTLs.
+type memoryCache struct {
+	mutex   sync.Mutex
+	entries map[string][]byte
+}
+
+func (m *memoryCache) Get(ctx context.Context, key string) ([]byte, bool) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	value, found := m.entries[key]
+	return value, found
+}
+
+func (m *memoryCache) Set(ctx context.Context, key string, value []byte, ttl time.Duration) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.entries[key] = value
+}
+
+func TestReadCache(t *testing.T) {
+	ClearAll()
+	TestRegister(t)
+
+	eventBus := event.NewBus("test", log)
+	readCache := usecase.NewReadCache(&memoryCache{entries: map[string][]byte{}}, log, usecase.CacheOptions{ContactTTL: time.Minute})
+	eventBus.Subscribe(readCache.Handle, readCache.EventTypes()...)
+
+	counting := &countingTxManager{TxManager: txManager}
+	contactUseCase := usecase.NewContactUseCase(counting, log, validate, repository.NewContactRepository(log), eventBus,
+		nil, nil, usecase.ContactOptions{ReadCache: readCache})
+
+	ctx := context.Background()
+	created, err := contactUseCase.Create(ctx, &model.CreateContactRequest{UserId: "khannedy", FirstName: "Eko", Email: "eko@example.com"})
+	assert.Nil(t, err)
+
+	getRequest := &model.GetContactRequest{UserId: "khannedy", ID: created.ID}
+	searchRequest := &model.SearchContactRequest{UserId: "khannedy", Page: 1, Size: 10}
+
+	counting.begun, counting.read = 0, 0
+	for range 2 {
+		contact, err := contactUseCase.Get(ctx, getRequest)
+		assert.Nil(t, err)
+		assert.Equal(t, "Eko", contact.FirstName)
+
+		contacts, total, err := contactUseCase.Search(ctx, searchRequest)
+		assert.Nil(t, err)
+		assert.Equal(t, int64(1), total)
+		assert.Equal(t, "Eko", contacts[0].FirstName)
+	}
+	// the second round is served from the cache
+	assert.Equal(t, 1, counting.begun)
+	assert.Equal(t, 1, counting.read)
+
+	_, err = contactUseCase.Update(ctx, &model.UpdateContactRequest{UserId: "khannedy", ID: created.ID, FirstName: "Khannedy", Email: "eko@example.com"})
+	assert.Nil(t, err)
+
+	counting.begun, counting.read = 0, 0
+	contact, err := contactUseCase.Get(ctx, getRequest)
+	assert.Nil(t, err)
+	assert.Equal(t, "Khannedy", contact.FirstName)
+
+	contacts, _, err := contactUseCase.Search(ctx, searchRequest)
+	assert.Nil(t, err)
+	assert.Equal(t, "Khannedy", contacts[0].FirstName)
+	assert.Equal(t, 1, counting.begun)
+	assert.Equal(t, 1, counting.read)
+}
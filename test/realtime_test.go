@@ -0,0 +1,84 @@
+package test
+
+import (
+	"encoding/json"
+	"go-rest-scaffold/internal/metrics"
+	"go-rest-scaffold/internal/model"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fasthttp/websocket"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+// listen serves the app on a real connection, which WebSocket handshakes need.
+func listen(t *testing.T) string {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Nil(t, err)
+	go app.Listener(listener)
+	t.Cleanup(func() { listener.Close() })
+	return listener.Addr().String()
+}
+
+func TestRealtimeEvents(t *testing.T) {
+	ClearAll()
+	TestRegister(t)
+	token := loginWithScopes(t, "null")
+	address := listen(t)
+
+	connections := testutil.ToFloat64(metrics.RealtimeClients)
+	conn, _, err := websocket.DefaultDialer.Dial("ws://"+address+"/ws?access_token="+token, nil)
+	assert.Nil(t, err)
+	defer conn.Close()
+	assert.Eventually(t, func() bool {
+		return testutil.ToFloat64(metrics.RealtimeClients) > connections
+	}, time.Second, 10*time.Millisecond)
+
+	request := httptest.NewRequest(http.MethodPost, "/api/contacts", strings.NewReader(`{"first_name":"Eko","email":"eko@example.com"}`))
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("Accept", "application/json")
+	request.Header.Set("Authorization", token)
+	response, err := app.Test(request)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+
+	assert.Nil(t, conn.SetReadDeadline(time.Now().Add(5*time.Second)))
+	_, message, err := conn.ReadMessage()
+	assert.Nil(t, err)
+
+	event := new(struct {
+		Type   string                `json:"type"`
+		UserId string                `json:"userid"`
+		Data   model.ContactResponse `json:"data"`
+	})
+	assert.Nil(t, json.Unmarshal(message, event))
+	assert.Equal(t, model.EventContactCreated, event.Type)
+	assert.Equal(t, "khannedy", event.UserId)
+	assert.Equal(t, "Eko", event.Data.FirstName)
+}
+
+func TestRealtimeRequiresAuth(t *testing.T) {
+	ClearAll()
+	TestRegister(t)
+	address := listen(t)
+
+	_, response, err := websocket.DefaultDialer.Dial("ws://"+address+"/ws", nil)
+	assert.NotNil(t, err)
+	assert.Equal(t, http.StatusUnauthorized, response.StatusCode)
+
+	token := loginWithScopes(t, `["addresses:read"]`)
+	_, response, err = websocket.DefaultDialer.Dial("ws://"+address+"/ws", http.Header{"Authorization": {token}})
+	assert.NotNil(t, err)
+	assert.Equal(t, http.StatusForbidden, response.StatusCode)
+
+	request := httptest.NewRequest(http.MethodGet, "/ws", nil)
+	request.Header.Set("Authorization", token)
+	response, err = app.Test(request)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusUpgradeRequired, response.StatusCode)
+}
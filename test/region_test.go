@@ -0,0 +1,63 @@
+package test
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"go-rest-scaffold/internal/model"
+	"go-rest-scaffold/internal/region"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+func TestRegionRouter(t *testing.T) {
+	connection, err := db.DB()
+	assert.Nil(t, err)
+
+	router := region.NewRouter("eu", map[string]*sql.DB{"eu": connection})
+	assert.Equal(t, []string{"eu"}, router.Regions())
+
+	routed, err := gorm.Open(postgres.New(postgres.Config{Conn: router}), &gorm.Config{})
+	assert.Nil(t, err)
+
+	var one int
+	err = routed.WithContext(context.Background()).Raw("SELECT 1").Scan(&one).Error
+	assert.Nil(t, err)
+	assert.Equal(t, 1, one)
+
+	err = routed.WithContext(model.WithRegion(context.Background(), "eu")).Raw("SELECT 1").Scan(&one).Error
+	assert.Nil(t, err)
+
+	err = routed.WithContext(model.WithRegion(context.Background(), "us")).Raw("SELECT 1").Scan(&one).Error
+	assert.ErrorIs(t, err, region.ErrUnknownRegion)
+
+	tx := routed.WithContext(model.WithRegion(context.Background(), "us")).Begin()
+	assert.ErrorIs(t, tx.Error, region.ErrUnknownRegion)
+}
+
+func TestRegisterUnknownRegion(t *testing.T) {
+	ClearAll()
+	requestBody := model.RegisterUserRequest{
+		ID:       "khannedy",
+		Password: "rahasia",
+		Name:     "Eko Khannedy",
+		Region:   "mars",
+	}
+
+	bodyJson, err := json.Marshal(requestBody)
+	assert.Nil(t, err)
+
+	request := httptest.NewRequest(http.MethodPost, "/api/users", strings.NewReader(string(bodyJson)))
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("Accept", "application/json")
+
+	response, err := app.Test(request)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusBadRequest, response.StatusCode)
+}
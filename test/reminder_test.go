@@ -0,0 +1,147 @@
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"go-rest-scaffold/internal/entity"
+	"go-rest-scaffold/internal/gateway/notify"
+	"go-rest-scaffold/internal/model"
+	"go-rest-scaffold/internal/repository"
+	"go-rest-scaffold/internal/usecase"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCreateReminder(t *testing.T) {
+	ClearAll()
+	TestCreateContact(t)
+
+	user := new(entity.User)
+	err := db.Where("id = ?", "khannedy").First(user).Error
+	assert.Nil(t, err)
+
+	contact := GetFirstContact(t, user)
+
+	location, err := time.LoadLocation("Asia/Jakarta")
+	assert.Nil(t, err)
+	localTime := time.Now().In(location).Add(48 * time.Hour).Format(model.ReminderTimeLayout)
+
+	requestBody := model.CreateReminderRequest{
+		ContactId: contact.ID,
+		Note:      "Call about the contract",
+		LocalTime: localTime,
+		Timezone:  "Asia/Jakarta",
+		Channels:  []string{"in_app", "email"},
+	}
+	bodyJson, err := json.Marshal(requestBody)
+	assert.Nil(t, err)
+
+	request := httptest.NewRequest(http.MethodPost, "/api/reminders", strings.NewReader(string(bodyJson)))
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("Accept", "application/json")
+	request.Header.Set("Authorization", user.Token)
+
+	response, err := app.Test(request)
+	assert.Nil(t, err)
+
+	bytes, err := io.ReadAll(response.Body)
+	assert.Nil(t, err)
+
+	responseBody := new(model.WebResponse[model.ReminderResponse])
+	err = json.Unmarshal(bytes, responseBody)
+	assert.Nil(t, err)
+
+	expected, err := time.ParseInLocation(model.ReminderTimeLayout, localTime, location)
+	assert.Nil(t, err)
+
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+	assert.Equal(t, model.ReminderPending, responseBody.Data.Status)
+	assert.Equal(t, expected.UnixMilli(), responseBody.Data.RemindAt)
+	assert.Equal(t, []string{"in_app", "email"}, responseBody.Data.Channels)
+}
+
+func TestCreateReminderInPast(t *testing.T) {
+	ClearAll()
+	TestCreateContact(t)
+
+	user := new(entity.User)
+	err := db.Where("id = ?", "khannedy").First(user).Error
+	assert.Nil(t, err)
+
+	contact := GetFirstContact(t, user)
+
+	requestBody := model.CreateReminderRequest{
+		ContactId: contact.ID,
+		LocalTime: "2020-01-01T09:00",
+		Channels:  []string{"in_app"},
+	}
+	bodyJson, err := json.Marshal(requestBody)
+	assert.Nil(t, err)
+
+	request := httptest.NewRequest(http.MethodPost, "/api/reminders", strings.NewReader(string(bodyJson)))
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("Accept", "application/json")
+	request.Header.Set("Authorization", user.Token)
+
+	response, err := app.Test(request)
+	assert.Nil(t, err)
+
+	assert.Equal(t, http.StatusBadRequest, response.StatusCode)
+}
+
+type recordingNotifier struct {
+	notifications []notify.Notification
+}
+
+func (n *recordingNotifier) Notify(ctx context.Context, notification notify.Notification) error {
+	n.notifications = append(n.notifications, notification)
+	return nil
+}
+
+func TestDeliverDueReminders(t *testing.T) {
+	ClearAll()
+	TestCreateContact(t)
+
+	user := new(entity.User)
+	err := db.Where("id = ?", "khannedy").First(user).Error
+	assert.Nil(t, err)
+
+	contact := GetFirstContact(t, user)
+
+	reminder := &entity.Reminder{
+		ID:        "7d5b4b43-7f2e-4e07-9d43-55b1b8a70001",
+		UserId:    user.ID,
+		ContactId: contact.ID,
+		Note:      "Send the proposal",
+		LocalTime: "2020-01-01T09:00",
+		Timezone:  "UTC",
+		RemindAt:  time.Now().Add(-time.Minute).UnixMilli(),
+		Channels:  "in_app",
+		Status:    model.ReminderPending,
+	}
+	err = db.Create(reminder).Error
+	assert.Nil(t, err)
+
+	notifier := new(recordingNotifier)
+	reminderUseCase := usecase.NewReminderUseCase(txManager, log, validate, repository.NewReminderRepository(log), repository.NewContactRepository(log),
+		map[string]notify.Notifier{notify.ChannelInApp: notifier}, nil, nil, usecase.ReminderOptions{PollInterval: time.Minute, BatchSize: 10})
+
+	delivered, err := reminderUseCase.DeliverDue(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, 1, delivered)
+
+	assert.Equal(t, 1, len(notifier.notifications))
+	assert.Equal(t, "Reminder: Eko Kurniawan Khannedy", notifier.notifications[0].Title)
+	assert.Equal(t, "Send the proposal", notifier.notifications[0].Body)
+
+	err = db.Where("id = ?", reminder.ID).Take(reminder).Error
+	assert.Nil(t, err)
+	assert.Equal(t, model.ReminderSent, reminder.Status)
+	assert.NotZero(t, reminder.SentAt)
+}
@@ -0,0 +1,60 @@
+package test
+
+import (
+	"context"
+	"database/sql"
+	"go-rest-scaffold/internal/config"
+	"go-rest-scaffold/internal/entity"
+	"go-rest-scaffold/internal/model"
+	"go-rest-scaffold/internal/usecase"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+func TestReadReplicas(t *testing.T) {
+	primary := filepath.Join(t.TempDir(), "primary.db")
+	replica := filepath.Join(t.TempDir(), "replica.db")
+
+	sqliteConfig := viper.New()
+	sqliteConfig.Set("database.driver", "sqlite")
+	sqliteConfig.Set("database.replicas", []string{"DB_REPLICA"})
+
+	// the replica is a database of its own here, so it never sees what is written
+	for _, name := range []string{replica, primary} {
+		t.Setenv("DB_NAME", name)
+		assert.Nil(t, config.MigrateUp(sqliteConfig, log))
+	}
+	t.Setenv("DB_REPLICA_NAME", replica)
+
+	replicated := usecase.NewGormTxManager(config.NewDatabase(sqliteConfig, log))
+	ctx := context.Background()
+
+	err := replicated.DB(ctx).Create(&entity.User{ID: "khannedy", Name: "Eko", Password: "-", Role: model.RoleUser}).Error
+	assert.Nil(t, err)
+
+	countUsers := func(db *gorm.DB) int64 {
+		var users int64
+		assert.Nil(t, db.Model(&entity.User{}).Count(&users).Error)
+		return users
+	}
+	readOnly := &sql.TxOptions{ReadOnly: true}
+
+	tx := replicated.Begin(ctx, readOnly)
+	assert.Equal(t, int64(0), countUsers(tx))
+	tx.Rollback()
+	assert.Equal(t, int64(0), countUsers(replicated.DB(ctx)))
+
+	tx = replicated.Begin(ctx)
+	assert.Equal(t, int64(1), countUsers(tx))
+	tx.Rollback()
+
+	ctx = model.WithPrimary(ctx)
+	tx = replicated.Begin(ctx, readOnly)
+	assert.Equal(t, int64(1), countUsers(tx))
+	tx.Rollback()
+	assert.Equal(t, int64(1), countUsers(replicated.DB(ctx)))
+}
@@ -0,0 +1,155 @@
+package test
+
+import (
+	"context"
+	"go-rest-scaffold/internal/entity"
+	"go-rest-scaffold/internal/model"
+	"go-rest-scaffold/internal/repository"
+	"regexp"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func TestRepositoryErrors(t *testing.T) {
+	ClearAll()
+	TestRegister(t)
+
+	userRepository := repository.NewUserRepository(log)
+
+	err := userRepository.Create(db, &entity.User{ID: "khannedy", Name: "Eko", Password: "-", Role: model.RoleUser})
+	assert.ErrorIs(t, err, repository.ErrConflict)
+
+	err = userRepository.FindById(db, new(entity.User), "unknown")
+	assert.ErrorIs(t, err, repository.ErrNotFound)
+
+	total, err := userRepository.Count(db, "id IN ?", []string{"khannedy", "unknown"})
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1), total)
+}
+
+func TestRepositorySharedOutsideTransaction(t *testing.T) {
+	contactRepository := repository.NewContactRepository(log)
+
+	started, release := make(chan struct{}), make(chan struct{})
+	first := make(chan *entity.Contact)
+	go func() {
+		contact := new(entity.Contact)
+		_ = contactRepository.Shared(db, "shared", contact, func(db *gorm.DB, result *entity.Contact) error {
+			close(started)
+			<-release
+			result.FirstName = "first"
+			return nil
+		})
+		first <- contact
+	}()
+	<-started
+
+	// a lookup in a transaction runs on its own, instead of waiting for the first one
+	// and getting what another transaction saw
+	tx := db.Begin()
+	defer tx.Rollback()
+	done := make(chan *entity.Contact)
+	go func() {
+		contact := new(entity.Contact)
+		_ = contactRepository.Shared(tx, "shared", contact, func(db *gorm.DB, result *entity.Contact) error {
+			result.FirstName = "in transaction"
+			return nil
+		})
+		done <- contact
+	}()
+	select {
+	case contact := <-done:
+		assert.Equal(t, "in transaction", contact.FirstName)
+	case <-time.After(time.Second):
+		t.Fatal("lookup in a transaction waited for the one outside")
+	}
+
+	// a lookup outside a transaction shares the result of the first one
+	shared := make(chan *entity.Contact)
+	go func() {
+		contact := new(entity.Contact)
+		_ = contactRepository.Shared(db, "shared", contact, func(db *gorm.DB, result *entity.Contact) error {
+			result.FirstName = "second"
+			return nil
+		})
+		shared <- contact
+	}()
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	assert.Equal(t, "first", (<-first).FirstName)
+	assert.Equal(t, "first", (<-shared).FirstName)
+}
+
+func TestRepositorySharedSeesOwnWrites(t *testing.T) {
+	ClearAll()
+	TestLogin(t)
+	user := GetFirstUser(t)
+	CreateContacts(user, 1)
+	contact := GetFirstContact(t, user)
+
+	contactRepository := repository.NewContactRepository(log)
+	tx := db.Begin()
+	defer tx.Rollback()
+	assert.Nil(t, tx.Model(contact).Update("first_name", "Renamed").Error)
+
+	found := new(entity.Contact)
+	assert.Nil(t, contactRepository.FindByIdAndUserIdShared(tx, found, contact.ID, user.ID))
+	assert.Equal(t, "Renamed", found.FirstName)
+}
+
+// contactInsert matches the INSERTs of contacts in every dialect.
+var contactInsert = regexp.MustCompile("^INSERT INTO .contacts. ")
+
+// insertCounter counts the INSERTs of contacts gorm runs.
+type insertCounter struct {
+	logger.Interface
+	inserts int
+}
+
+func (l *insertCounter) Trace(ctx context.Context, begin time.Time, fc func() (sql string, rowsAffected int64), err error) {
+	if sql, _ := fc(); contactInsert.MatchString(sql) {
+		l.inserts++
+	}
+	l.Interface.Trace(ctx, begin, fc, err)
+}
+
+func TestRepositoryCreateInBatches(t *testing.T) {
+	ClearAll()
+	TestLogin(t)
+	user := GetFirstUser(t)
+	contactRepository := repository.NewContactRepository(log)
+
+	// without a batch size of its own, the session has database.batch_size
+	assert.Equal(t, viperConfig.GetInt("database.batch_size"), db.CreateBatchSize)
+
+	var created int64
+	for _, test := range []struct {
+		batchSize int
+		total     int
+		inserts   int
+	}{
+		{0, 5, 1},
+		{2, 5, 3},
+		{5, 5, 1},
+		{2, 0, 0},
+	} {
+		contacts := make([]entity.Contact, test.total)
+		for i := range contacts {
+			contacts[i] = entity.Contact{ID: uuid.NewString(), FirstName: "Contact", LastName: strconv.Itoa(i), UserId: user.ID}
+		}
+
+		counter := &insertCounter{Interface: db.Logger}
+		session := db.Session(&gorm.Session{Logger: counter, CreateBatchSize: test.batchSize})
+		assert.Nil(t, contactRepository.CreateInBatches(session, contacts))
+		assert.Equal(t, test.inserts, counter.inserts, "%d contacts in batches of %d", test.total, test.batchSize)
+
+		created += int64(test.total)
+		assert.Equal(t, created, countContacts(t, user))
+	}
+}
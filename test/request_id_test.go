@@ -0,0 +1,83 @@
+package test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"go-rest-scaffold/internal/logging"
+	"go-rest-scaffold/internal/model"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequestIDGenerated(t *testing.T) {
+	request := httptest.NewRequest(http.MethodGet, "/api/users/_current", nil)
+	request.Header.Set("Accept", "application/json")
+
+	response, err := app.Test(request)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusUnauthorized, response.StatusCode)
+
+	requestId := response.Header.Get("X-Request-ID")
+	_, err = uuid.Parse(requestId)
+	assert.Nil(t, err)
+
+	bytes, err := io.ReadAll(response.Body)
+	assert.Nil(t, err)
+
+	responseBody := make(map[string]any)
+	err = json.Unmarshal(bytes, &responseBody)
+	assert.Nil(t, err)
+	assert.Equal(t, requestId, responseBody["request_id"])
+}
+
+func TestRequestIDPropagated(t *testing.T) {
+	request := httptest.NewRequest(http.MethodGet, "/api/users/_current", nil)
+	request.Header.Set("Accept", "application/json")
+	request.Header.Set("X-Request-ID", "lb-7f3a9c")
+
+	response, err := app.Test(request)
+	assert.Nil(t, err)
+	assert.Equal(t, "lb-7f3a9c", response.Header.Get("X-Request-ID"))
+
+	// an ID that would not fit in a log line is replaced
+	request = httptest.NewRequest(http.MethodGet, "/api/users/_current", nil)
+	request.Header.Set("Accept", "application/json")
+	request.Header.Set("X-Request-ID", strings.Repeat("a", 200))
+
+	response, err = app.Test(request)
+	assert.Nil(t, err)
+	_, err = uuid.Parse(response.Header.Get("X-Request-ID"))
+	assert.Nil(t, err)
+}
+
+func TestRequestIDLogged(t *testing.T) {
+	output := new(bytes.Buffer)
+	logger := logrus.New()
+	logger.SetOutput(output)
+	logger.SetFormatter(&logrus.JSONFormatter{})
+	logger.AddHook(logging.RequestIDHook{})
+
+	logger.WithContext(model.WithRequestID(context.Background(), "lb-7f3a9c")).Error("failed to find contact")
+	logger.WithContext(context.Background()).Error("failed to purge trash")
+
+	lines := strings.Split(strings.TrimSpace(output.String()), "\n")
+	assert.Equal(t, 2, len(lines))
+
+	entry := make(map[string]any)
+	err := json.Unmarshal([]byte(lines[0]), &entry)
+	assert.Nil(t, err)
+	assert.Equal(t, "lb-7f3a9c", entry[logging.RequestIDField])
+
+	entry = make(map[string]any)
+	err = json.Unmarshal([]byte(lines[1]), &entry)
+	assert.Nil(t, err)
+	assert.NotContains(t, entry, logging.RequestIDField)
+}
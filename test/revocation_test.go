@@ -0,0 +1,52 @@
+package test
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"go-rest-scaffold/internal/gateway/revocation"
+	"go-rest-scaffold/internal/jwt"
+	"go-rest-scaffold/internal/model"
+	"go-rest-scaffold/internal/usecase"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLogoutRevokesAccessToken(t *testing.T) {
+	ClearAll()
+	TestRegister(t)
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.Nil(t, err)
+
+	userUseCase := newPasswordUserUseCase(usecase.UserOptions{
+		AccessTokens: jwt.NewRS256("go-rest-scaffold", time.Minute, key),
+		Revocations:  revocation.NewStore(nil, 5*time.Second, log),
+	})
+
+	response, err := userUseCase.Login(context.Background(), &model.LoginUserRequest{ID: "khannedy", Password: "rahasia"})
+	assert.Nil(t, err)
+
+	_, err = userUseCase.Verify(context.Background(), &model.VerifyUserRequest{Token: response.Token})
+	assert.Nil(t, err)
+
+	_, err = userUseCase.Logout(context.Background(), &model.LogoutUserRequest{ID: "khannedy"})
+	assert.Nil(t, err)
+
+	// refused at once, although the token has not expired
+	_, err = userUseCase.Verify(context.Background(), &model.VerifyUserRequest{Token: response.Token})
+	assert.NotNil(t, err)
+}
+
+func TestRevocationStoreExpires(t *testing.T) {
+	store := revocation.NewStore(nil, time.Second, log)
+
+	assert.False(t, store.IsRevoked(context.Background(), "session"))
+	assert.Nil(t, store.Revoke(context.Background(), 50*time.Millisecond, "session"))
+	assert.True(t, store.IsRevoked(context.Background(), "session"))
+
+	time.Sleep(100 * time.Millisecond)
+	assert.False(t, store.IsRevoked(context.Background(), "session"))
+}
@@ -0,0 +1,67 @@
+package test
+
+import (
+	"context"
+	"go-rest-scaffold/internal/entity"
+	"go-rest-scaffold/internal/repository"
+	"go-rest-scaffold/internal/usecase"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func newSandboxUseCase(options usecase.SandboxOptions) *usecase.SandboxUseCase {
+	return usecase.NewSandboxUseCase(txManager, log, options, repository.NewUserRepository(log),
+		repository.NewContactRepository(log), repository.NewAddressRepository(log),
+		repository.NewExperimentAssignmentRepository(log), repository.NewDebugCaptureRepository(log), nil)
+}
+
+func TestSandboxReset(t *testing.T) {
+	TestCreateContact(t)
+
+	sandboxUseCase := newSandboxUseCase(usecase.SandboxOptions{
+		UserId:        "demo",
+		Password:      "demo",
+		Name:          "Demo User",
+		ResetInterval: time.Hour,
+	})
+
+	err := sandboxUseCase.Reset(context.Background())
+	assert.Nil(t, err)
+
+	var users int64
+	err = db.Model(&entity.User{}).Count(&users).Error
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1), users)
+
+	var contacts int64
+	err = db.Model(&entity.Contact{}).Where("user_id = ?", "demo").Count(&contacts).Error
+	assert.Nil(t, err)
+	assert.Equal(t, int64(3), contacts)
+
+	ClearAll()
+}
+
+func TestSandboxContactQuota(t *testing.T) {
+	sandboxUseCase := newSandboxUseCase(usecase.SandboxOptions{
+		UserId:        "demo",
+		Password:      "demo",
+		Name:          "Demo User",
+		ResetInterval: time.Hour,
+		MaxContacts:   3,
+	})
+
+	err := sandboxUseCase.Reset(context.Background())
+	assert.Nil(t, err)
+
+	err = sandboxUseCase.CheckContactQuota(context.Background(), "demo")
+	assert.NotNil(t, err)
+	assert.Equal(t, fiber.StatusTooManyRequests, err.(*fiber.Error).Code)
+
+	err = sandboxUseCase.CheckAddressQuota(context.Background(), "demo")
+	assert.Nil(t, err)
+
+	ClearAll()
+}
@@ -0,0 +1,114 @@
+package test
+
+import (
+	"context"
+	"go-rest-scaffold/internal/entity"
+	"go-rest-scaffold/internal/metrics"
+	"go-rest-scaffold/internal/model"
+	"go-rest-scaffold/internal/repository"
+	"go-rest-scaffold/internal/scheduler"
+	"go-rest-scaffold/internal/usecase"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSchedulerSkipsOverlappingRuns(t *testing.T) {
+	var runs atomic.Int64
+	release := make(chan struct{})
+	jobScheduler := scheduler.New(log)
+	err := jobScheduler.Add("test-overlap", "@every 1s", func(ctx context.Context) (int64, error) {
+		runs.Add(1)
+		<-release
+		return 1, nil
+	})
+	assert.Nil(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		jobScheduler.Run(ctx)
+	}()
+
+	// the first run is still going when the next one is due
+	skipped := metrics.ScheduledJobRuns.WithLabelValues("test-overlap", "skipped")
+	assert.Eventually(t, func() bool {
+		return testutil.ToFloat64(skipped) >= 1
+	}, 5*time.Second, 50*time.Millisecond)
+	assert.Equal(t, int64(1), runs.Load())
+
+	cancel()
+	close(release)
+	<-done
+
+	assert.Equal(t, float64(1), testutil.ToFloat64(metrics.ScheduledJobRuns.WithLabelValues("test-overlap", "success")))
+	assert.Equal(t, float64(1), testutil.ToFloat64(metrics.ScheduledJobItems.WithLabelValues("test-overlap")))
+}
+
+func TestSchedulerInvalidSchedule(t *testing.T) {
+	jobScheduler := scheduler.New(log)
+	err := jobScheduler.Add("test-invalid", "every hour", func(ctx context.Context) (int64, error) {
+		return 0, nil
+	})
+	assert.NotNil(t, err)
+}
+
+func TestPurgeExpiredTokens(t *testing.T) {
+	ClearAll()
+	TestRegister(t)
+
+	now := time.Now().UnixMilli()
+	err := db.Create(&[]entity.PasswordReset{
+		{ID: "expired", UserId: "khannedy", ExpiresAt: now - 1000},
+		{ID: "pending", UserId: "khannedy", ExpiresAt: now + 60000},
+	}).Error
+	assert.Nil(t, err)
+	err = db.Create(&entity.MagicLink{ID: "expired", UserId: "khannedy", ExpiresAt: now - 1000}).Error
+	assert.Nil(t, err)
+
+	userUseCase := usecase.NewUserUseCase(txManager, log, validate, repository.NewUserRepository(log), repository.NewSessionRepository(log),
+		repository.NewPasswordResetRepository(log), repository.NewMagicLinkRepository(log), nil, nil, nil, nil, nil, nil, nil, nil, usecase.UserOptions{})
+	purged, err := userUseCase.PurgeExpiredTokens(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, int64(2), purged)
+
+	var resets []entity.PasswordReset
+	err = db.Find(&resets).Error
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(resets))
+	assert.Equal(t, "pending", resets[0].ID)
+}
+
+func TestAggregateDailyStats(t *testing.T) {
+	ClearAll()
+	TestRegister(t)
+
+	// registered two days ago, which the aggregation keeps after the user is gone
+	twoDaysAgo := time.Now().UTC().Truncate(24*time.Hour).AddDate(0, 0, -2)
+	err := db.Model(&entity.User{}).Where("id = ?", "khannedy").Update("created_at", twoDaysAgo.Add(time.Hour).UnixMilli()).Error
+	assert.Nil(t, err)
+
+	statsUseCase := usecase.NewStatsUseCase(txManager, log, validate, repository.NewStatsRepository(log), repository.NewUserActivityRepository(log))
+	aggregated, err := statsUseCase.AggregateDaily(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, int64(366), aggregated)
+
+	// the days that are over are only aggregated once
+	aggregated, err = statsUseCase.AggregateDaily(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, int64(0), aggregated)
+
+	ClearUsers()
+
+	days, err := statsUseCase.Daily(context.Background(), &model.DailyStatsRequest{Days: 3})
+	assert.Nil(t, err)
+	assert.Equal(t, 3, len(days))
+	assert.Equal(t, twoDaysAgo.Format(model.StatsDayLayout), days[0].Day)
+	assert.Equal(t, int64(1), days[0].Registrations)
+	assert.Equal(t, int64(0), days[1].Registrations)
+	assert.Equal(t, time.Now().UTC().Format(model.StatsDayLayout), days[2].Day)
+}
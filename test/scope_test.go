@@ -0,0 +1,127 @@
+package test
+
+import (
+	"encoding/json"
+	"go-rest-scaffold/internal/model"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func loginWithScopes(t *testing.T, scopes string) string {
+	request := httptest.NewRequest(http.MethodPost, "/api/users/_login", strings.NewReader(`{"id":"khannedy","password":"rahasia","scopes":`+scopes+`}`))
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("Accept", "application/json")
+
+	response, err := app.Test(request)
+	assert.Nil(t, err)
+
+	bytes, err := io.ReadAll(response.Body)
+	assert.Nil(t, err)
+
+	responseBody := new(model.WebResponse[model.UserResponse])
+	err = json.Unmarshal(bytes, responseBody)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+
+	return responseBody.Data.Token
+}
+
+func TestScopedSession(t *testing.T) {
+	ClearAll()
+	TestRegister(t)
+
+	token := loginWithScopes(t, `["contacts:read"]`)
+
+	request := httptest.NewRequest(http.MethodGet, "/api/contacts", nil)
+	request.Header.Set("Accept", "application/json")
+	request.Header.Set("Authorization", token)
+
+	response, err := app.Test(request)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+
+	request = httptest.NewRequest(http.MethodPost, "/api/contacts", strings.NewReader(`{"first_name":"Eko"}`))
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("Accept", "application/json")
+	request.Header.Set("Authorization", token)
+
+	response, err = app.Test(request)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusForbidden, response.StatusCode)
+
+	request = httptest.NewRequest(http.MethodGet, "/api/users/_current", nil)
+	request.Header.Set("Accept", "application/json")
+	request.Header.Set("Authorization", token)
+
+	response, err = app.Test(request)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusForbidden, response.StatusCode)
+}
+
+func TestScopedSessionUnknownScope(t *testing.T) {
+	ClearAll()
+	TestRegister(t)
+
+	request := httptest.NewRequest(http.MethodPost, "/api/users/_login", strings.NewReader(`{"id":"khannedy","password":"rahasia","scopes":["contacts:delete"]}`))
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("Accept", "application/json")
+
+	response, err := app.Test(request)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusBadRequest, response.StatusCode)
+}
+
+func TestScopedAPIKey(t *testing.T) {
+	ClearAll()
+	TestRegister(t)
+
+	token := loginWithScopes(t, `["contacts:read","account:write"]`)
+
+	// a limited session cannot create a key with more scopes than its own
+	request := httptest.NewRequest(http.MethodPost, "/api/api-keys", strings.NewReader(`{"name":"reader","scopes":["contacts:write"]}`))
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("Accept", "application/json")
+	request.Header.Set("Authorization", token)
+
+	response, err := app.Test(request)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusForbidden, response.StatusCode)
+
+	request = httptest.NewRequest(http.MethodPost, "/api/api-keys", strings.NewReader(`{"name":"reader","scopes":["contacts:read"]}`))
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("Accept", "application/json")
+	request.Header.Set("Authorization", token)
+
+	response, err = app.Test(request)
+	assert.Nil(t, err)
+
+	bytes, err := io.ReadAll(response.Body)
+	assert.Nil(t, err)
+
+	created := new(model.WebResponse[model.APIKeyResponse])
+	err = json.Unmarshal(bytes, created)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+	assert.Equal(t, []string{"contacts:read"}, created.Data.Scopes)
+
+	request = httptest.NewRequest(http.MethodGet, "/api/contacts", nil)
+	request.Header.Set("Accept", "application/json")
+	request.Header.Set("X-API-Key", created.Data.Key)
+
+	response, err = app.Test(request)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+
+	request = httptest.NewRequest(http.MethodGet, "/api/reminders", nil)
+	request.Header.Set("Accept", "application/json")
+	request.Header.Set("X-API-Key", created.Data.Key)
+
+	response, err = app.Test(request)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusForbidden, response.StatusCode)
+}
@@ -0,0 +1,190 @@
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"go-rest-scaffold/internal/event"
+	"go-rest-scaffold/internal/gateway/search"
+	"go-rest-scaffold/internal/model"
+	"go-rest-scaffold/internal/repository"
+	"go-rest-scaffold/internal/usecase"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// memoryIndex is a search index in memory, matching names by prefix of either name.
+type memoryIndex struct {
+	mutex     sync.Mutex
+	documents map[string]search.Document
+	searches  int
+	down      bool
+}
+
+func newMemoryIndex() *memoryIndex {
+	return &memoryIndex{documents: make(map[string]search.Document)}
+}
+
+func (i *memoryIndex) Save(ctx context.Context, documents []search.Document) error {
+	i.mutex.Lock()
+	defer i.mutex.Unlock()
+	for _, document := range documents {
+		i.documents[document.ID] = document
+	}
+	return nil
+}
+
+func (i *memoryIndex) Delete(ctx context.Context, userId string, ids []string) error {
+	i.mutex.Lock()
+	defer i.mutex.Unlock()
+	for _, id := range ids {
+		delete(i.documents, id)
+	}
+	return nil
+}
+
+func (i *memoryIndex) DeleteByUserId(ctx context.Context, userId string) error {
+	i.mutex.Lock()
+	defer i.mutex.Unlock()
+	for id, document := range i.documents {
+		if document.UserId == userId {
+			delete(i.documents, id)
+		}
+	}
+	return nil
+}
+
+func (i *memoryIndex) Search(ctx context.Context, query *search.Query) ([]string, int64, error) {
+	i.mutex.Lock()
+	defer i.mutex.Unlock()
+	if i.down {
+		return nil, 0, errors.New("index unavailable")
+	}
+	i.searches++
+
+	var ids []string
+	name := strings.ToLower(query.Name)
+	for id, document := range i.documents {
+		if document.UserId == query.UserId && (strings.HasPrefix(strings.ToLower(document.FirstName), name) ||
+			strings.HasPrefix(strings.ToLower(document.LastName), name)) {
+			ids = append(ids, id)
+		}
+	}
+	slices.Sort(ids)
+	return ids, int64(len(ids)), nil
+}
+
+func (i *memoryIndex) size() int {
+	i.mutex.Lock()
+	defer i.mutex.Unlock()
+	return len(i.documents)
+}
+
+func TestSearchContactIndex(t *testing.T) {
+	ClearAll()
+	TestRegister(t)
+
+	index := newMemoryIndex()
+	contactRepository := repository.NewContactRepository(log)
+	contactSearchUseCase := usecase.NewContactSearchUseCase(txManager, log, contactRepository, index, usecase.ContactSearchOptions{BatchSize: 1})
+	eventBus := event.NewBus("test", log)
+	eventBus.Subscribe(contactSearchUseCase.Handle, contactSearchUseCase.EventTypes()...)
+	auditLogUseCase := usecase.NewAuditLogUseCase(txManager, log, validate, repository.NewAuditLogRepository(log))
+	contactUseCase := usecase.NewContactUseCase(txManager, log, validate, contactRepository, eventBus, auditLogUseCase, nil,
+		usecase.ContactOptions{SearchIndex: index})
+
+	ctx := model.WithActor(context.Background(), "khannedy")
+	eko, err := contactUseCase.Create(ctx, &model.CreateContactRequest{UserId: "khannedy", FirstName: "Eko", LastName: "Khannedy", Email: "eko@example.com"})
+	assert.Nil(t, err)
+	_, err = contactUseCase.Create(ctx, &model.CreateContactRequest{UserId: "khannedy", FirstName: "Budi", LastName: "Nugraha", Email: "budi@example.com"})
+	assert.Nil(t, err)
+	assert.Eventually(t, func() bool { return index.size() == 2 }, time.Second, 10*time.Millisecond)
+
+	contacts, total, err := contactUseCase.Search(ctx, &model.SearchContactRequest{UserId: "khannedy", Name: "khan", Page: 1, Size: 10})
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1), total)
+	assert.Equal(t, 1, len(contacts))
+	assert.Equal(t, eko.ID, contacts[0].ID)
+	assert.Equal(t, 1, index.searches)
+
+	// listings without a search term stay in the database
+	_, total, err = contactUseCase.Search(ctx, &model.SearchContactRequest{UserId: "khannedy", Page: 1, Size: 10})
+	assert.Nil(t, err)
+	assert.Equal(t, int64(2), total)
+	assert.Equal(t, 1, index.searches)
+
+	err = contactUseCase.Delete(ctx, &model.DeleteContactRequest{UserId: "khannedy", ID: eko.ID})
+	assert.Nil(t, err)
+	assert.Eventually(t, func() bool { return index.size() == 1 }, time.Second, 10*time.Millisecond)
+
+	// an unavailable index falls back to searching the database
+	index.mutex.Lock()
+	index.down = true
+	index.mutex.Unlock()
+	contacts, total, err = contactUseCase.Search(ctx, &model.SearchContactRequest{UserId: "khannedy", Name: "Budi", Page: 1, Size: 10})
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1), total)
+	assert.Equal(t, "Budi", contacts[0].FirstName)
+
+	// a reindex restores what the index lost
+	index = newMemoryIndex()
+	contactSearchUseCase.Index = index
+	indexed, err := contactSearchUseCase.Reindex(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1), indexed)
+	assert.Equal(t, 1, index.size())
+}
+
+func TestElasticsearchRequests(t *testing.T) {
+	var paths []string
+	var bodies []string
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		body, _ := io.ReadAll(request.Body)
+		paths = append(paths, request.Method+" "+request.URL.String())
+		bodies = append(bodies, string(body))
+
+		switch request.URL.Path {
+		case "/contacts/_bulk":
+			_, _ = writer.Write([]byte(`{"errors":false,"items":[]}`))
+		case "/contacts/_search":
+			_, _ = writer.Write([]byte(`{"hits":{"total":{"value":12},"hits":[{"_id":"2"},{"_id":"1"}]}}`))
+		default:
+			writer.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	index := search.NewElasticsearch(server.Client(), server.URL+"/", "contacts", "", "")
+	err := index.Save(context.Background(), []search.Document{{ID: "1", UserId: "khannedy", FirstName: "Eko"}})
+	assert.Nil(t, err)
+
+	ids, total, err := index.Search(context.Background(), &search.Query{UserId: "khannedy", Email: "Eko*", Size: 10})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"2", "1"}, ids)
+	assert.Equal(t, int64(12), total)
+
+	assert.Equal(t, []string{"POST /contacts/_bulk", "POST /contacts/_search?routing=khannedy"}, paths)
+	lines := strings.Split(strings.TrimSuffix(bodies[0], "\n"), "\n")
+	assert.Equal(t, 2, len(lines))
+	assert.JSONEq(t, `{"index":{"_id":"1","routing":"khannedy"}}`, lines[0])
+
+	query := new(struct {
+		Query struct {
+			Bool struct {
+				Must []map[string]map[string]map[string]string `json:"must"`
+			} `json:"bool"`
+		} `json:"query"`
+	})
+	err = json.Unmarshal([]byte(bodies[1]), query)
+	assert.Nil(t, err)
+	// emails match in lower case with the pattern characters escaped
+	assert.Equal(t, `*eko\**`, query.Query.Bool.Must[0]["wildcard"]["email"]["value"])
+}
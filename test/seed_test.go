@@ -0,0 +1,64 @@
+package test
+
+import (
+	"bytes"
+	"go-rest-scaffold/internal/config"
+	"go-rest-scaffold/internal/entity"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSeed(t *testing.T) {
+	ClearAll()
+
+	output := new(bytes.Buffer)
+	err := config.Seed(viperConfig, log, "../db/seeds/development.yaml", output)
+	assert.Nil(t, err)
+	assert.Contains(t, output.String(), "seeded 2 users, 3 contacts and 3 addresses")
+
+	var contacts int64
+	err = db.Model(&entity.Contact{}).Where("user_id = ?", "joko").Count(&contacts).Error
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1), contacts)
+
+	// seeding again leaves the existing users alone
+	output.Reset()
+	err = config.Seed(viperConfig, log, "../db/seeds/development.yaml", output)
+	assert.Nil(t, err)
+	assert.Contains(t, output.String(), "seeded 0 users, 0 contacts and 0 addresses")
+	assert.Contains(t, output.String(), "skipped 2 existing users")
+}
+
+func TestSeedJSON(t *testing.T) {
+	ClearAll()
+
+	file := filepath.Join(t.TempDir(), "fixtures.json")
+	err := os.WriteFile(file, []byte(`{"users":[{"id":"eko","password":"rahasia","name":"Eko","contacts":[{"first_name":"Budi","email":"budi@example.com"}]}]}`), 0o600)
+	assert.Nil(t, err)
+
+	output := new(bytes.Buffer)
+	err = config.Seed(viperConfig, log, file, output)
+	assert.Nil(t, err)
+	assert.Contains(t, output.String(), "seeded 1 users, 1 contacts and 0 addresses")
+}
+
+func TestSeedUnknownField(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "fixtures.json")
+	err := os.WriteFile(file, []byte(`{"users":[{"id":"eko","pasword":"rahasia","name":"Eko"}]}`), 0o600)
+	assert.Nil(t, err)
+
+	err = config.Seed(viperConfig, log, file, new(bytes.Buffer))
+	assert.NotNil(t, err)
+}
+
+func TestSeedProduction(t *testing.T) {
+	viperConfig.Set("app.environment", "production")
+	defer viperConfig.Set("app.environment", "development")
+
+	err := config.Seed(viperConfig, log, "../db/seeds/development.yaml", new(bytes.Buffer))
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "refusing to seed")
+}
@@ -0,0 +1,145 @@
+package test
+
+import (
+	"go-rest-scaffold/internal/config"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"strconv"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestServerProcess is the server started by startServer, run in a process of its
+// own as it is stopped and restarted with signals. It does nothing in the tests.
+func TestServerProcess(t *testing.T) {
+	port := os.Getenv("SERVER_TEST_PORT")
+	if port == "" {
+		return
+	}
+
+	serverApp := fiber.New()
+	serverApp.Get("/pid", func(ctx *fiber.Ctx) error {
+		return ctx.SendString(strconv.Itoa(os.Getpid()))
+	})
+	serverApp.Get("/slow", func(ctx *fiber.Ctx) error {
+		time.Sleep(2 * time.Second)
+		return ctx.SendString(strconv.Itoa(os.Getpid()))
+	})
+
+	serverConfig := viper.New()
+	serverConfig.Set("web.port", port)
+	serverConfig.Set("web.graceful_restart", os.Getenv("SERVER_TEST_GRACEFUL_RESTART") == "true")
+	serverConfig.Set("web.shutdown_timeout", 10)
+
+	assert.Nil(t, config.NewServer(serverApp, serverConfig, log).Run())
+}
+
+// startServer runs TestServerProcess in a new process, and returns it with the
+// address it serves.
+func startServer(t *testing.T, gracefulRestart bool) (*exec.Cmd, string) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Nil(t, err)
+	port := listener.Addr().(*net.TCPAddr).Port
+	assert.Nil(t, listener.Close())
+
+	cmd := exec.Command(os.Args[0], "-test.run=^TestServerProcess$")
+	cmd.Env = append(os.Environ(), "SERVER_TEST_PORT="+strconv.Itoa(port),
+		"SERVER_TEST_GRACEFUL_RESTART="+strconv.FormatBool(gracefulRestart))
+	assert.Nil(t, cmd.Start())
+	t.Cleanup(func() { _ = cmd.Process.Kill() })
+
+	address := "127.0.0.1:" + strconv.Itoa(port)
+	assert.Eventually(t, func() bool {
+		_, err := serverGet(address, "/pid")
+		return err == nil
+	}, 30*time.Second, 50*time.Millisecond)
+	return cmd, address
+}
+
+// serverGet requests path of address on a connection of its own, and returns the
+// PID of the process that answered.
+func serverGet(address string, path string) (int, error) {
+	client := &http.Client{Transport: &http.Transport{DisableKeepAlives: true}, Timeout: 10 * time.Second}
+	response, err := client.Get("http://" + address + path)
+	if err != nil {
+		return 0, err
+	}
+	defer response.Body.Close()
+
+	body, err := io.ReadAll(response.Body)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.Atoi(string(body))
+}
+
+// slowRequest starts a request that takes two seconds, and returns the PID that
+// answers it, or 0 when it fails.
+func slowRequest(address string) <-chan int {
+	answered := make(chan int, 1)
+	go func() {
+		pid, _ := serverGet(address, "/slow")
+		answered <- pid
+	}()
+	// give the request time to reach the handler
+	time.Sleep(200 * time.Millisecond)
+	return answered
+}
+
+func TestServerDrainsOnShutdown(t *testing.T) {
+	cmd, address := startServer(t, false)
+	slow := slowRequest(address)
+
+	assert.Nil(t, cmd.Process.Signal(syscall.SIGTERM))
+
+	// new connections are refused while the request in flight is answered
+	assert.Eventually(t, func() bool {
+		_, err := serverGet(address, "/pid")
+		return err != nil
+	}, 5*time.Second, 50*time.Millisecond)
+	assert.Equal(t, cmd.Process.Pid, <-slow)
+	assert.Nil(t, cmd.Wait())
+}
+
+func TestServerGracefulRestart(t *testing.T) {
+	cmd, address := startServer(t, true)
+	slow := slowRequest(address)
+
+	assert.Nil(t, cmd.Process.Signal(syscall.SIGHUP))
+
+	// the socket is handed over, so every request is answered while the processes swap
+	var upgraded int
+	assert.Eventually(t, func() bool {
+		pid, err := serverGet(address, "/pid")
+		assert.Nil(t, err)
+		if pid != cmd.Process.Pid {
+			upgraded = pid
+		}
+		return upgraded != 0
+	}, 30*time.Second, 20*time.Millisecond)
+
+	// the old process answers the request in flight before it exits
+	assert.Equal(t, cmd.Process.Pid, <-slow)
+	assert.Nil(t, cmd.Wait())
+
+	pid, err := serverGet(address, "/pid")
+	assert.Nil(t, err)
+	assert.Equal(t, upgraded, pid)
+
+	upgradedProcess, err := os.FindProcess(upgraded)
+	assert.Nil(t, err)
+	t.Cleanup(func() { _ = upgradedProcess.Kill() })
+	assert.Nil(t, upgradedProcess.Signal(syscall.SIGTERM))
+	assert.Eventually(t, func() bool {
+		_, err := serverGet(address, "/pid")
+		return err != nil
+	}, 15*time.Second, 50*time.Millisecond)
+}
@@ -0,0 +1,100 @@
+package test
+
+import (
+	"encoding/json"
+	"go-rest-scaffold/internal/model"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func login(t *testing.T, userAgent string) *model.UserResponse {
+	request := httptest.NewRequest(http.MethodPost, "/api/users/_login", strings.NewReader(`{"id":"khannedy","password":"rahasia"}`))
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("Accept", "application/json")
+	request.Header.Set("User-Agent", userAgent)
+
+	response, err := app.Test(request)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+
+	bytes, err := io.ReadAll(response.Body)
+	assert.Nil(t, err)
+
+	responseBody := new(model.WebResponse[model.UserResponse])
+	err = json.Unmarshal(bytes, responseBody)
+	assert.Nil(t, err)
+	return &responseBody.Data
+}
+
+func TestRevokeSession(t *testing.T) {
+	ClearAll()
+	TestRegister(t)
+
+	laptop := login(t, "Mozilla/5.0 (Macintosh; Intel Mac OS X 14_5) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.5 Safari/605.1.15")
+	phone := login(t, "Mozilla/5.0 (Linux; Android 14) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/126.0 Mobile Safari/537.36")
+
+	request := httptest.NewRequest(http.MethodGet, "/api/users/_sessions", nil)
+	request.Header.Set("Accept", "application/json")
+	request.Header.Set("Authorization", laptop.Token)
+
+	response, err := app.Test(request)
+	assert.Nil(t, err)
+
+	bytes, err := io.ReadAll(response.Body)
+	assert.Nil(t, err)
+
+	sessions := new(model.WebResponse[[]model.SessionResponse])
+	err = json.Unmarshal(bytes, sessions)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+	assert.Len(t, sessions.Data, 2)
+
+	var current, other model.SessionResponse
+	for _, session := range sessions.Data {
+		if session.Current {
+			current = session
+		} else {
+			other = session
+		}
+	}
+	assert.Equal(t, "Safari on macOS", current.Device)
+	assert.Equal(t, "Chrome on Android", other.Device)
+
+	request = httptest.NewRequest(http.MethodDelete, "/api/users/_sessions/"+other.ID, nil)
+	request.Header.Set("Accept", "application/json")
+	request.Header.Set("Authorization", laptop.Token)
+
+	response, err = app.Test(request)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+
+	// the revoked session is signed out, the other one is not
+	request = httptest.NewRequest(http.MethodGet, "/api/users/_current", nil)
+	request.Header.Set("Accept", "application/json")
+	request.Header.Set("Authorization", phone.Token)
+
+	response, err = app.Test(request)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusUnauthorized, response.StatusCode)
+
+	request = httptest.NewRequest(http.MethodPost, "/api/users/refresh-token", strings.NewReader(`{"refresh_token":"`+phone.RefreshToken+`"}`))
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("Accept", "application/json")
+
+	response, err = app.Test(request)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusUnauthorized, response.StatusCode)
+
+	request = httptest.NewRequest(http.MethodGet, "/api/users/_current", nil)
+	request.Header.Set("Accept", "application/json")
+	request.Header.Set("Authorization", laptop.Token)
+
+	response, err = app.Test(request)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+}
@@ -0,0 +1,83 @@
+package test
+
+import (
+	"go-rest-scaffold/internal/delivery/http/middleware"
+	"go-rest-scaffold/internal/gateway/replay"
+	"go-rest-scaffold/internal/model"
+	"go-rest-scaffold/internal/signing"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func newSignatureApp(signer *signing.Signer) *fiber.App {
+	signatureApp := fiber.New()
+	signatureApp.Use(func(ctx *fiber.Ctx) error {
+		auth := &model.Auth{ID: "khannedy", APIKeyId: ctx.Get("X-Test-API-Key")}
+		ctx.Locals("auth", auth)
+		return ctx.Next()
+	})
+	signatureApp.Delete("/api/trash", middleware.NewRequireSignature(signer, replay.NewStore(nil, log)), func(ctx *fiber.Ctx) error {
+		return ctx.SendString("emptied")
+	})
+	return signatureApp
+}
+
+func signedRequest(signature string, body string) *http.Request {
+	request := httptest.NewRequest(http.MethodDelete, "/api/trash?force=true", strings.NewReader(body))
+	request.Header.Set("X-Test-API-Key", "key-1")
+	if signature != "" {
+		request.Header.Set(signing.Header, signature)
+	}
+	return request
+}
+
+func TestRequestSignature(t *testing.T) {
+	signer := signing.NewSigner([]byte(strings.Repeat("s", 32)), 5*time.Minute)
+	signatureApp := newSignatureApp(signer)
+	signature := signing.Sign(signer.KeySecret("key-1"), time.Now(), http.MethodDelete, "/api/trash?force=true", []byte(`{}`))
+
+	response, err := signatureApp.Test(signedRequest(signature, `{}`))
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+
+	// the same request sent again
+	response, err = signatureApp.Test(signedRequest(signature, `{}`))
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusUnauthorized, response.StatusCode)
+}
+
+func TestRequestSignatureInvalid(t *testing.T) {
+	signer := signing.NewSigner([]byte(strings.Repeat("s", 32)), 5*time.Minute)
+	signatureApp := newSignatureApp(signer)
+	secret := signer.KeySecret("key-1")
+
+	for _, request := range []*http.Request{
+		signedRequest("", `{}`),
+		signedRequest(signing.Sign(secret, time.Now(), http.MethodDelete, "/api/trash?force=true", []byte(`{}`)), `{"all":true}`),
+		signedRequest(signing.Sign(secret, time.Now(), http.MethodDelete, "/api/trash", []byte(`{}`)), `{}`),
+		signedRequest(signing.Sign(signer.KeySecret("key-2"), time.Now(), http.MethodDelete, "/api/trash?force=true", []byte(`{}`)), `{}`),
+		signedRequest(signing.Sign(secret, time.Now().Add(-10*time.Minute), http.MethodDelete, "/api/trash?force=true", []byte(`{}`)), `{}`),
+	} {
+		response, err := signatureApp.Test(request)
+		assert.Nil(t, err)
+		assert.Equal(t, http.StatusUnauthorized, response.StatusCode)
+	}
+}
+
+func TestRequestSignatureNotRequired(t *testing.T) {
+	// sessions sign nothing, nor does anyone while signing is off
+	request := httptest.NewRequest(http.MethodDelete, "/api/trash", nil)
+	response, err := newSignatureApp(signing.NewSigner([]byte(strings.Repeat("s", 32)), 5*time.Minute)).Test(request)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+
+	response, err = newSignatureApp(nil).Test(signedRequest("", `{}`))
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+}
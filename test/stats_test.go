@@ -0,0 +1,107 @@
+package test
+
+import (
+	"encoding/json"
+	"go-rest-scaffold/internal/entity"
+	"go-rest-scaffold/internal/model"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm/clause"
+)
+
+// RecordActivityToday stores the activity directly, since the running app remembers
+// users it already recorded today even after the tables are cleared.
+func RecordActivityToday(t *testing.T, user *entity.User) {
+	activity := &entity.UserActivity{UserId: user.ID, Day: time.Now().UTC().Truncate(24 * time.Hour)}
+	err := db.Clauses(clause.OnConflict{DoNothing: true}).Create(activity).Error
+	assert.Nil(t, err)
+}
+
+func TestGetStats(t *testing.T) {
+	TestCreateAddress(t)
+
+	user := new(entity.User)
+	err := db.Where("id = ?", "khannedy").First(user).Error
+	assert.Nil(t, err)
+	err = db.Model(user).Update("role", model.RoleAdmin).Error
+	assert.Nil(t, err)
+	RecordActivityToday(t, user)
+
+	request := httptest.NewRequest(http.MethodGet, "/api/admin/stats", nil)
+	request.Header.Set("Accept", "application/json")
+	request.Header.Set("Authorization", user.Token)
+
+	response, err := app.Test(request)
+	assert.Nil(t, err)
+
+	bytes, err := io.ReadAll(response.Body)
+	assert.Nil(t, err)
+
+	responseBody := new(model.WebResponse[model.StatsResponse])
+	err = json.Unmarshal(bytes, responseBody)
+	assert.Nil(t, err)
+
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+	assert.Equal(t, int64(1), responseBody.Data.Users)
+	assert.Equal(t, int64(1), responseBody.Data.ActiveToday)
+	assert.Equal(t, int64(1), responseBody.Data.Contacts)
+	assert.Equal(t, int64(1), responseBody.Data.Addresses)
+	if db.Dialector.Name() != "sqlite" {
+		// SQLite keeps no table sizes
+		assert.NotZero(t, responseBody.Data.StorageBytes)
+	}
+}
+
+func TestGetDailyStats(t *testing.T) {
+	TestCreateContact(t)
+
+	user := new(entity.User)
+	err := db.Where("id = ?", "khannedy").First(user).Error
+	assert.Nil(t, err)
+	err = db.Model(user).Update("role", model.RoleAdmin).Error
+	assert.Nil(t, err)
+	RecordActivityToday(t, user)
+
+	request := httptest.NewRequest(http.MethodGet, "/api/admin/stats/daily?days=7", nil)
+	request.Header.Set("Accept", "application/json")
+	request.Header.Set("Authorization", user.Token)
+
+	response, err := app.Test(request)
+	assert.Nil(t, err)
+
+	bytes, err := io.ReadAll(response.Body)
+	assert.Nil(t, err)
+
+	responseBody := new(model.WebResponse[[]model.DailyStatsResponse])
+	err = json.Unmarshal(bytes, responseBody)
+	assert.Nil(t, err)
+
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+	assert.Equal(t, 7, len(responseBody.Data))
+	todayStats := responseBody.Data[6]
+	assert.Equal(t, int64(1), todayStats.Registrations)
+	assert.Equal(t, int64(1), todayStats.ActiveUsers)
+	assert.Equal(t, int64(1), todayStats.ContactsCreated)
+}
+
+func TestGetStatsForbidden(t *testing.T) {
+	TestLogin(t)
+
+	user := new(entity.User)
+	err := db.Where("id = ?", "khannedy").First(user).Error
+	assert.Nil(t, err)
+
+	request := httptest.NewRequest(http.MethodGet, "/api/admin/stats", nil)
+	request.Header.Set("Accept", "application/json")
+	request.Header.Set("Authorization", user.Token)
+
+	response, err := app.Test(request)
+	assert.Nil(t, err)
+
+	assert.Equal(t, http.StatusForbidden, response.StatusCode)
+}
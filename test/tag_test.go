@@ -0,0 +1,195 @@
+package test
+
+import (
+	"encoding/json"
+	"go-rest-scaffold/internal/entity"
+	"go-rest-scaffold/internal/model"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func createTag(t *testing.T, user *entity.User, name string) *model.TagResponse {
+	request := httptest.NewRequest(http.MethodPost, "/api/tags", strings.NewReader(`{"name":"`+name+`","color":"#1e90ff"}`))
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("Accept", "application/json")
+	request.Header.Set("Authorization", user.Token)
+
+	response, err := app.Test(request)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+
+	bytes, err := io.ReadAll(response.Body)
+	assert.Nil(t, err)
+
+	responseBody := new(model.WebResponse[*model.TagResponse])
+	err = json.Unmarshal(bytes, responseBody)
+	assert.Nil(t, err)
+	return responseBody.Data
+}
+
+func assignTag(t *testing.T, user *entity.User, method string, contactId string, tagId string) []model.TagResponse {
+	request := httptest.NewRequest(method, "/api/contacts/"+contactId+"/tags/"+tagId, nil)
+	request.Header.Set("Accept", "application/json")
+	request.Header.Set("Authorization", user.Token)
+
+	response, err := app.Test(request)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+
+	bytes, err := io.ReadAll(response.Body)
+	assert.Nil(t, err)
+
+	responseBody := new(model.WebResponse[[]model.TagResponse])
+	err = json.Unmarshal(bytes, responseBody)
+	assert.Nil(t, err)
+	return responseBody.Data
+}
+
+func searchContactsByTag(t *testing.T, user *entity.User, query string) []model.ContactResponse {
+	request := httptest.NewRequest(http.MethodGet, "/api/contacts?"+query, nil)
+	request.Header.Set("Accept", "application/json")
+	request.Header.Set("Authorization", user.Token)
+
+	response, err := app.Test(request)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+
+	bytes, err := io.ReadAll(response.Body)
+	assert.Nil(t, err)
+
+	responseBody := new(model.WebResponse[[]model.ContactResponse])
+	err = json.Unmarshal(bytes, responseBody)
+	assert.Nil(t, err)
+	return responseBody.Data
+}
+
+func TestCreateTag(t *testing.T) {
+	TestLogin(t)
+	user := GetFirstUser(t)
+
+	tag := createTag(t, user, "Customer")
+	assert.NotEmpty(t, tag.ID)
+	assert.Equal(t, "Customer", tag.Name)
+	assert.Equal(t, "#1e90ff", tag.Color)
+
+	request := httptest.NewRequest(http.MethodPost, "/api/tags", strings.NewReader(`{"name":"customer"}`))
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("Accept", "application/json")
+	request.Header.Set("Authorization", user.Token)
+
+	response, err := app.Test(request)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusConflict, response.StatusCode)
+}
+
+func TestCreateTagInvalidColor(t *testing.T) {
+	TestLogin(t)
+	user := GetFirstUser(t)
+
+	request := httptest.NewRequest(http.MethodPost, "/api/tags", strings.NewReader(`{"name":"Customer","color":"blue"}`))
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("Accept", "application/json")
+	request.Header.Set("Authorization", user.Token)
+
+	response, err := app.Test(request)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusBadRequest, response.StatusCode)
+}
+
+func TestAssignTag(t *testing.T) {
+	TestLogin(t)
+	user := GetFirstUser(t)
+	CreateContacts(user, 1)
+	contact := GetFirstContact(t, user)
+
+	tag := createTag(t, user, "Customer")
+
+	tags := assignTag(t, user, http.MethodPut, contact.ID, tag.ID)
+	assert.Equal(t, 1, len(tags))
+	assert.Equal(t, tag.ID, tags[0].ID)
+
+	// assigning again changes nothing
+	tags = assignTag(t, user, http.MethodPut, contact.ID, tag.ID)
+	assert.Equal(t, 1, len(tags))
+
+	request := httptest.NewRequest(http.MethodGet, "/api/contacts/"+contact.ID+"/tags", nil)
+	request.Header.Set("Accept", "application/json")
+	request.Header.Set("Authorization", user.Token)
+
+	response, err := app.Test(request)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+
+	tags = assignTag(t, user, http.MethodDelete, contact.ID, tag.ID)
+	assert.Equal(t, 0, len(tags))
+}
+
+func TestAssignTagNotFound(t *testing.T) {
+	TestLogin(t)
+	user := GetFirstUser(t)
+	CreateContacts(user, 1)
+	contact := GetFirstContact(t, user)
+
+	request := httptest.NewRequest(http.MethodPut, "/api/contacts/"+contact.ID+"/tags/"+uuid.NewString(), nil)
+	request.Header.Set("Accept", "application/json")
+	request.Header.Set("Authorization", user.Token)
+
+	response, err := app.Test(request)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusNotFound, response.StatusCode)
+}
+
+func TestSearchContactWithTag(t *testing.T) {
+	TestLogin(t)
+	user := GetFirstUser(t)
+	CreateContacts(user, 3)
+
+	contacts := make([]entity.Contact, 0)
+	err := db.Where("user_id = ?", user.ID).Order("last_name").Find(&contacts).Error
+	assert.Nil(t, err)
+
+	customer := createTag(t, user, "Customer")
+	vip := createTag(t, user, "VIP")
+	assignTag(t, user, http.MethodPut, contacts[0].ID, customer.ID)
+	assignTag(t, user, http.MethodPut, contacts[1].ID, customer.ID)
+	assignTag(t, user, http.MethodPut, contacts[1].ID, vip.ID)
+
+	assert.Equal(t, 2, len(searchContactsByTag(t, user, "tag=customer")))
+
+	found := searchContactsByTag(t, user, "tag=Customer&tag=VIP")
+	assert.Equal(t, 1, len(found))
+	assert.Equal(t, contacts[1].ID, found[0].ID)
+
+	assert.Equal(t, 0, len(searchContactsByTag(t, user, "tag=unknown")))
+}
+
+func TestDeleteTag(t *testing.T) {
+	TestLogin(t)
+	user := GetFirstUser(t)
+	CreateContacts(user, 1)
+	contact := GetFirstContact(t, user)
+
+	tag := createTag(t, user, "Customer")
+	assignTag(t, user, http.MethodPut, contact.ID, tag.ID)
+
+	request := httptest.NewRequest(http.MethodDelete, "/api/tags/"+tag.ID, nil)
+	request.Header.Set("Accept", "application/json")
+	request.Header.Set("Authorization", user.Token)
+
+	response, err := app.Test(request)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+
+	var count int64
+	err = db.Model(&entity.ContactTag{}).Where("tag_id = ?", tag.ID).Count(&count).Error
+	assert.Nil(t, err)
+	assert.Equal(t, int64(0), count)
+
+	assert.Equal(t, 0, len(searchContactsByTag(t, user, "tag=Customer")))
+}
@@ -0,0 +1,90 @@
+package test
+
+import (
+	"encoding/json"
+	"go-rest-scaffold/internal/config"
+	"go-rest-scaffold/internal/delivery/http/middleware"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequestTimeout(t *testing.T) {
+	timeoutConfig := viper.New()
+	timeoutConfig.Set("timeout.default", 1)
+	timeoutConfig.Set("timeout.bulk", 0)
+
+	timeout := middleware.NewTimeout(timeoutConfig)
+	timeoutApp := fiber.New(fiber.Config{ErrorHandler: config.NewErrorHandler()})
+	timeoutApp.Use(timeout("default"))
+	// a use case waiting on a query that outlives the deadline
+	wait := func(ctx *fiber.Ctx) error {
+		<-ctx.UserContext().Done()
+		return fiber.ErrInternalServerError
+	}
+	timeoutApp.Get("/api/contacts", wait)
+	timeoutApp.Get("/api/contacts/_export", timeout("bulk"), func(ctx *fiber.Ctx) error {
+		// the bulk group has no timeout, so the default one no longer applies
+		if _, ok := ctx.UserContext().Deadline(); ok {
+			return fiber.ErrInternalServerError
+		}
+		return ctx.SendString("exported")
+	})
+
+	response, err := timeoutApp.Test(httptest.NewRequest(http.MethodGet, "/api/contacts", nil), 5000)
+	assert.Nil(t, err)
+
+	bytes, err := io.ReadAll(response.Body)
+	assert.Nil(t, err)
+
+	problem := make(map[string]any)
+	err = json.Unmarshal(bytes, &problem)
+	assert.Nil(t, err)
+
+	assert.Equal(t, http.StatusRequestTimeout, response.StatusCode)
+	assert.Equal(t, config.MIMEApplicationProblemJSON, response.Header.Get("Content-Type"))
+	assert.Equal(t, float64(http.StatusRequestTimeout), problem["status"])
+	assert.Equal(t, "Request Timeout", problem["title"])
+
+	response, err = timeoutApp.Test(httptest.NewRequest(http.MethodGet, "/api/contacts/_export", nil), 5000)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+}
+
+func TestRequestBodyTooLarge(t *testing.T) {
+	limitApp := fiber.New(fiber.Config{BodyLimit: 16, ErrorHandler: config.NewErrorHandler()})
+	limitApp.Post("/api/contacts", func(ctx *fiber.Ctx) error {
+		return ctx.SendStatus(fiber.StatusOK)
+	})
+
+	// the body is refused while the server reads it, which app.Test reports as an error
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Nil(t, err)
+	go func() {
+		_ = limitApp.Listener(listener)
+	}()
+	defer limitApp.Shutdown()
+
+	response, err := http.Post("http://"+listener.Addr().String()+"/api/contacts", "application/json",
+		strings.NewReader(`{"first_name":"Eko","email":"eko@example.com"}`))
+	assert.Nil(t, err)
+	defer response.Body.Close()
+
+	bytes, err := io.ReadAll(response.Body)
+	assert.Nil(t, err)
+
+	problem := make(map[string]any)
+	err = json.Unmarshal(bytes, &problem)
+	assert.Nil(t, err)
+
+	assert.Equal(t, http.StatusRequestEntityTooLarge, response.StatusCode)
+	assert.Equal(t, config.MIMEApplicationProblemJSON, response.Header.Get("Content-Type"))
+	assert.Equal(t, float64(http.StatusRequestEntityTooLarge), problem["status"])
+}
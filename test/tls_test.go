@@ -0,0 +1,92 @@
+package test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"go-rest-scaffold/internal/config"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTLSFromCertificateFiles(t *testing.T) {
+	certFile, keyFile := writeCertificate(t)
+
+	tlsConfig := viper.New()
+	tlsConfig.Set("web.port", 8443)
+	tlsConfig.Set("web.tls.cert_file", certFile)
+	tlsConfig.Set("web.tls.key_file", keyFile)
+	tlsConfig.Set("web.tls.redirect_port", 8080)
+
+	serverTLS, redirect := config.NewTLS(tlsConfig, log)
+	assert.NotNil(t, serverTLS)
+	assert.NotNil(t, redirect)
+
+	tlsApp := fiber.New(fiber.Config{DisableStartupMessage: true})
+	tlsApp.Get("/api/ping", func(ctx *fiber.Ctx) error {
+		return ctx.SendString(ctx.Protocol())
+	})
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Nil(t, err)
+	go tlsApp.Listener(tls.NewListener(listener, serverTLS))
+	defer tlsApp.Shutdown()
+
+	client := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{InsecureSkipVerify: true}}}
+	response, err := client.Get("https://" + listener.Addr().String() + "/api/ping")
+	assert.Nil(t, err)
+	defer response.Body.Close()
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+	assert.NotNil(t, response.TLS)
+
+	recorder := httptest.NewRecorder()
+	redirect.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "http://api.example.com:8080/api/contacts?page=2", nil))
+	assert.Equal(t, http.StatusPermanentRedirect, recorder.Code)
+	assert.Equal(t, "https://api.example.com:8443/api/contacts?page=2", recorder.Header().Get("Location"))
+}
+
+func TestTLSOffByDefault(t *testing.T) {
+	serverTLS, redirect := config.NewTLS(viperConfig, log)
+	assert.Nil(t, serverTLS)
+	assert.Nil(t, redirect)
+}
+
+// writeCertificate writes a self-signed certificate for localhost and its key to a
+// temporary directory.
+func writeCertificate(t *testing.T) (string, string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.Nil(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "localhost"},
+		DNSNames:     []string{"localhost"},
+		IPAddresses:  []net.IP{net.IPv4(127, 0, 0, 1)},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	certificate, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	assert.Nil(t, err)
+	encodedKey, err := x509.MarshalECPrivateKey(key)
+	assert.Nil(t, err)
+
+	dir := t.TempDir()
+	certFile, keyFile := filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem")
+	assert.Nil(t, os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certificate}), 0o600))
+	assert.Nil(t, os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: encodedKey}), 0o600))
+	return certFile, keyFile
+}
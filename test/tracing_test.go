@@ -0,0 +1,66 @@
+package test
+
+import (
+	"go-rest-scaffold/internal/delivery/http/middleware"
+	"go-rest-scaffold/internal/tracing"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestTracing(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	previousProvider, previousPropagator := otel.GetTracerProvider(), otel.GetTextMapPropagator()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter)))
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	defer otel.SetTracerProvider(previousProvider)
+	defer otel.SetTextMapPropagator(previousPropagator)
+
+	tracingApp := fiber.New()
+	tracingApp.Use(middleware.NewTracing(true))
+	tracingApp.Get("/api/contacts/:contactId", func(ctx *fiber.Ctx) error {
+		_, span := tracing.Start(ctx.UserContext(), "ContactUseCase.Get")
+		span.End()
+		return fiber.ErrNotFound
+	})
+
+	request := httptest.NewRequest(http.MethodGet, "/api/contacts/1", nil)
+	request.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+
+	response, err := tracingApp.Test(request)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusNotFound, response.StatusCode)
+
+	spans := exporter.GetSpans()
+	assert.Equal(t, 2, len(spans))
+
+	useCase, server := spans[0], spans[1]
+	assert.Equal(t, "GET /api/contacts/:contactId", server.Name)
+	assert.Equal(t, trace.SpanKindServer, server.SpanKind)
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", server.SpanContext.TraceID().String())
+	assert.Equal(t, "00f067aa0ba902b7", server.Parent.SpanID().String())
+	assert.Contains(t, server.Attributes, attribute.Int("http.response.status_code", http.StatusNotFound))
+	assert.Equal(t, server.SpanContext.SpanID(), useCase.Parent.SpanID())
+}
+
+func TestTracingDisabled(t *testing.T) {
+	tracingApp := fiber.New()
+	tracingApp.Use(middleware.NewTracing(false))
+	tracingApp.Get("/api/ping", func(ctx *fiber.Ctx) error {
+		assert.False(t, trace.SpanFromContext(ctx.UserContext()).SpanContext().IsValid())
+		return ctx.SendString("pong")
+	})
+
+	response, err := tracingApp.Test(httptest.NewRequest(http.MethodGet, "/api/ping", nil))
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+}
@@ -0,0 +1,221 @@
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"go-rest-scaffold/internal/entity"
+	"go-rest-scaffold/internal/model"
+	"go-rest-scaffold/internal/repository"
+	"go-rest-scaffold/internal/usecase"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestListAndRestoreTrash(t *testing.T) {
+	TestDeleteContact(t)
+
+	user := new(entity.User)
+	err := db.Where("id = ?", "khannedy").First(user).Error
+	assert.Nil(t, err)
+
+	contact := new(entity.Contact)
+	err = db.Unscoped().Where("user_id = ?", user.ID).First(contact).Error
+	assert.Nil(t, err)
+
+	request := httptest.NewRequest(http.MethodGet, "/api/trash", nil)
+	request.Header.Set("Accept", "application/json")
+	request.Header.Set("Authorization", user.Token)
+
+	response, err := app.Test(request)
+	assert.Nil(t, err)
+
+	bytes, err := io.ReadAll(response.Body)
+	assert.Nil(t, err)
+
+	listBody := new(model.WebResponse[[]model.TrashItemResponse])
+	err = json.Unmarshal(bytes, listBody)
+	assert.Nil(t, err)
+
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+	assert.Equal(t, 1, len(listBody.Data))
+	assert.Equal(t, model.TrashContact, listBody.Data[0].Type)
+	assert.Equal(t, contact.ID, listBody.Data[0].ID)
+	assert.Equal(t, 30, listBody.Data[0].DaysUntilPurge)
+
+	requestBody := model.RestoreTrashRequest{
+		Items: []model.TrashItemRequest{{Type: model.TrashContact, ID: contact.ID}},
+	}
+	bodyJson, err := json.Marshal(requestBody)
+	assert.Nil(t, err)
+
+	request = httptest.NewRequest(http.MethodPost, "/api/trash/_restore", strings.NewReader(string(bodyJson)))
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("Accept", "application/json")
+	request.Header.Set("Authorization", user.Token)
+
+	response, err = app.Test(request)
+	assert.Nil(t, err)
+
+	bytes, err = io.ReadAll(response.Body)
+	assert.Nil(t, err)
+
+	restoreBody := new(model.WebResponse[model.RestoreTrashResponse])
+	err = json.Unmarshal(bytes, restoreBody)
+	assert.Nil(t, err)
+
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+	assert.Equal(t, 1, restoreBody.Data.Restored)
+
+	err = db.Where("id = ?", contact.ID).First(contact).Error
+	assert.Nil(t, err)
+}
+
+func TestRestoreAddressOfDeletedContact(t *testing.T) {
+	TestCreateAddress(t)
+
+	user := new(entity.User)
+	err := db.Where("id = ?", "khannedy").First(user).Error
+	assert.Nil(t, err)
+
+	contact := GetFirstContact(t, user)
+	address := GetFirstAddress(t, contact)
+
+	err = db.Delete(address).Error
+	assert.Nil(t, err)
+	err = db.Delete(contact).Error
+	assert.Nil(t, err)
+
+	requestBody := model.RestoreTrashRequest{
+		Items: []model.TrashItemRequest{{Type: model.TrashAddress, ID: address.ID}},
+	}
+	bodyJson, err := json.Marshal(requestBody)
+	assert.Nil(t, err)
+
+	request := httptest.NewRequest(http.MethodPost, "/api/trash/_restore", strings.NewReader(string(bodyJson)))
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("Accept", "application/json")
+	request.Header.Set("Authorization", user.Token)
+
+	response, err := app.Test(request)
+	assert.Nil(t, err)
+
+	assert.Equal(t, http.StatusConflict, response.StatusCode)
+}
+
+func TestEmptyTrash(t *testing.T) {
+	TestCreateAddress(t)
+
+	user := new(entity.User)
+	err := db.Where("id = ?", "khannedy").First(user).Error
+	assert.Nil(t, err)
+
+	contact := GetFirstContact(t, user)
+	err = db.Delete(contact).Error
+	assert.Nil(t, err)
+
+	request := httptest.NewRequest(http.MethodDelete, "/api/trash", nil)
+	request.Header.Set("Accept", "application/json")
+	request.Header.Set("Authorization", user.Token)
+
+	response, err := app.Test(request)
+	assert.Nil(t, err)
+
+	bytes, err := io.ReadAll(response.Body)
+	assert.Nil(t, err)
+
+	responseBody := new(model.WebResponse[model.EmptyTrashResponse])
+	err = json.Unmarshal(bytes, responseBody)
+	assert.Nil(t, err)
+
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+	assert.Equal(t, int64(2), responseBody.Data.Purged)
+
+	var total int64
+	err = db.Unscoped().Model(&entity.Address{}).Where("contact_id = ?", contact.ID).Count(&total).Error
+	assert.Nil(t, err)
+	assert.Equal(t, int64(0), total)
+}
+
+func TestPurgeExpiredTrash(t *testing.T) {
+	TestCreateContact(t)
+
+	user := new(entity.User)
+	err := db.Where("id = ?", "khannedy").First(user).Error
+	assert.Nil(t, err)
+
+	contact := GetFirstContact(t, user)
+	err = db.Unscoped().Model(contact).Update("deleted_at", time.Now().Add(-31*24*time.Hour)).Error
+	assert.Nil(t, err)
+
+	trashUseCase := usecase.NewTrashUseCase(txManager, log, validate, repository.NewTrashRepository(log), repository.NewContactRepository(log),
+		repository.NewAddressRepository(log), nil, usecase.TrashOptions{Retention: 30 * 24 * time.Hour})
+
+	purged, err := trashUseCase.PurgeExpired(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1), purged)
+
+	err = db.Unscoped().Where("id = ?", contact.ID).First(contact).Error
+	assert.NotNil(t, err)
+}
+
+func TestListAndRestoreDeletedContact(t *testing.T) {
+	TestDeleteContact(t)
+
+	user := new(entity.User)
+	err := db.Where("id = ?", "khannedy").First(user).Error
+	assert.Nil(t, err)
+
+	contact := new(entity.Contact)
+	err = db.Unscoped().Where("user_id = ?", user.ID).First(contact).Error
+	assert.Nil(t, err)
+
+	request := httptest.NewRequest(http.MethodGet, "/api/contacts/_trash", nil)
+	request.Header.Set("Accept", "application/json")
+	request.Header.Set("Authorization", user.Token)
+
+	response, err := app.Test(request)
+	assert.Nil(t, err)
+
+	bytes, err := io.ReadAll(response.Body)
+	assert.Nil(t, err)
+
+	listBody := new(model.WebResponse[[]model.TrashItemResponse])
+	err = json.Unmarshal(bytes, listBody)
+	assert.Nil(t, err)
+
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+	assert.Equal(t, 1, len(listBody.Data))
+	assert.Equal(t, contact.ID, listBody.Data[0].ID)
+
+	request = httptest.NewRequest(http.MethodPost, "/api/contacts/"+contact.ID+"/_restore", nil)
+	request.Header.Set("Accept", "application/json")
+	request.Header.Set("Authorization", user.Token)
+
+	response, err = app.Test(request)
+	assert.Nil(t, err)
+
+	bytes, err = io.ReadAll(response.Body)
+	assert.Nil(t, err)
+
+	restoreBody := new(model.WebResponse[model.ContactResponse])
+	err = json.Unmarshal(bytes, restoreBody)
+	assert.Nil(t, err)
+
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+	assert.Equal(t, contact.ID, restoreBody.Data.ID)
+	assert.Equal(t, contact.FirstName, restoreBody.Data.FirstName)
+
+	request = httptest.NewRequest(http.MethodPost, "/api/contacts/"+contact.ID+"/_restore", nil)
+	request.Header.Set("Accept", "application/json")
+	request.Header.Set("Authorization", user.Token)
+
+	response, err = app.Test(request)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusNotFound, response.StatusCode)
+}
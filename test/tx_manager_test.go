@@ -0,0 +1,58 @@
+package test
+
+import (
+	"context"
+	"database/sql"
+	"go-rest-scaffold/internal/entity"
+	"go-rest-scaffold/internal/model"
+	"go-rest-scaffold/internal/repository"
+	"go-rest-scaffold/internal/usecase"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+// countingTxManager counts the transactions a use case begins, and in read the
+// sessions it reads from outside of one.
+type countingTxManager struct {
+	usecase.TxManager
+	begun int
+	read  int
+}
+
+func (m *countingTxManager) Begin(ctx context.Context, opts ...*sql.TxOptions) *gorm.DB {
+	m.begun++
+	return m.TxManager.Begin(ctx, opts...)
+}
+
+func (m *countingTxManager) DB(ctx context.Context) *gorm.DB {
+	m.read++
+	return m.TxManager.DB(ctx)
+}
+
+func TestTxManager(t *testing.T) {
+	ClearAll()
+	TestRegister(t)
+
+	counting := &countingTxManager{TxManager: txManager}
+	auditLogUseCase := usecase.NewAuditLogUseCase(counting, log, validate, repository.NewAuditLogRepository(log))
+	contactUseCase := usecase.NewContactUseCase(counting, log, validate, repository.NewContactRepository(log), nil,
+		auditLogUseCase, nil, usecase.ContactOptions{})
+
+	ctx := model.WithActor(context.Background(), "khannedy")
+	response, err := contactUseCase.Create(ctx, &model.CreateContactRequest{
+		UserId:    "khannedy",
+		FirstName: "Eko",
+		Email:     "eko@example.com",
+	})
+	assert.Nil(t, err)
+
+	// the contact and its audit log entry are written in the same transaction
+	assert.Equal(t, 1, counting.begun)
+
+	var auditLogs int64
+	err = db.Model(&entity.AuditLog{}).Where("entity_type = ? AND entity_id = ?", model.AuditContact, response.ID).Count(&auditLogs).Error
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1), auditLogs)
+}
@@ -0,0 +1,97 @@
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"go-rest-scaffold/internal/delivery/http/middleware"
+	"go-rest-scaffold/internal/entity"
+	"go-rest-scaffold/internal/gateway/usage"
+	"go-rest-scaffold/internal/model"
+	"go-rest-scaffold/internal/repository"
+	"go-rest-scaffold/internal/usecase"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQuota(t *testing.T) {
+	ClearAll()
+	TestLogin(t)
+
+	user := GetFirstUser(t)
+	apiKey := &entity.APIKey{ID: "2f1f3f4e-6a4b-4c36-9d55-1a2b3c4d5e6f", UserId: user.ID, Name: "billing service", Prefix: "sk_test", KeyHash: "hash"}
+	err := db.Create(apiKey).Error
+	assert.Nil(t, err)
+
+	useCase := usecase.NewUsageUseCase(txManager, log, validate, usage.NewMeter(nil, log), repository.NewAPIKeyRepository(log),
+		usecase.UsageOptions{Enabled: true, UserDaily: 3, APIKeyDaily: 2})
+
+	quotaApp := fiber.New()
+	quotaApp.Use(func(ctx *fiber.Ctx) error {
+		ctx.Locals("auth", &model.Auth{ID: user.ID, APIKeyId: ctx.Get("X-Test-API-Key")})
+		return ctx.Next()
+	})
+	quotaApp.Use(middleware.NewQuota(useCase))
+	quotaApp.Get("/api/ping", func(ctx *fiber.Ctx) error {
+		return ctx.SendString("pong")
+	})
+
+	// the key runs out of its quota first, then the session uses up the rest of the user's
+	statuses := []int{http.StatusOK, http.StatusOK, http.StatusTooManyRequests}
+	for _, status := range statuses {
+		request := httptest.NewRequest(http.MethodGet, "/api/ping", nil)
+		request.Header.Set("X-Test-API-Key", apiKey.ID)
+
+		response, err := quotaApp.Test(request)
+		assert.Nil(t, err)
+		assert.Equal(t, status, response.StatusCode)
+	}
+
+	response, err := quotaApp.Test(httptest.NewRequest(http.MethodGet, "/api/ping", nil))
+	assert.Nil(t, err)
+
+	assert.Equal(t, http.StatusTooManyRequests, response.StatusCode)
+	assert.NotEmpty(t, response.Header.Get("Retry-After"))
+
+	usageResponse, err := useCase.Get(context.Background(), &model.GetUsageRequest{UserId: user.ID})
+	assert.Nil(t, err)
+
+	assert.True(t, usageResponse.Enabled)
+	assert.Equal(t, int64(4), usageResponse.Requests.Used)
+	assert.Equal(t, int64(3), *usageResponse.Requests.Limit)
+	assert.Equal(t, int64(0), *usageResponse.Requests.Remaining)
+	assert.Len(t, usageResponse.APIKeys, 1)
+	assert.Equal(t, apiKey.ID, usageResponse.APIKeys[0].ID)
+	assert.Equal(t, int64(3), usageResponse.APIKeys[0].Requests.Used)
+	assert.Equal(t, int64(0), *usageResponse.APIKeys[0].Requests.Remaining)
+}
+
+func TestGetUsage(t *testing.T) {
+	ClearAll()
+	TestLogin(t)
+
+	user := GetFirstUser(t)
+
+	request := httptest.NewRequest(http.MethodGet, "/api/users/_current/usage", nil)
+	request.Header.Set("Accept", "application/json")
+	request.Header.Set("Authorization", user.Token)
+
+	response, err := app.Test(request)
+	assert.Nil(t, err)
+
+	bytes, err := io.ReadAll(response.Body)
+	assert.Nil(t, err)
+
+	responseBody := new(model.WebResponse[model.UsageResponse])
+	err = json.Unmarshal(bytes, responseBody)
+	assert.Nil(t, err)
+
+	// quotas are disabled in config.json
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+	assert.False(t, responseBody.Data.Enabled)
+	assert.NotEmpty(t, responseBody.Data.Date)
+}
@@ -0,0 +1,97 @@
+package test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAPIVersions(t *testing.T) {
+	ClearAll()
+	TestRegister(t)
+	user := login(t, "versioning")
+
+	for path, version := range map[string]string{
+		"/api/users/_current":    "v1",
+		"/api/v1/users/_current": "v1",
+		"/api/v2/users/_current": "v2",
+	} {
+		request := httptest.NewRequest(http.MethodGet, path, nil)
+		request.Header.Set("Accept", "application/json")
+		request.Header.Set("Authorization", user.Token)
+
+		response, err := app.Test(request)
+		assert.Nil(t, err)
+		assert.Equal(t, http.StatusOK, response.StatusCode, path)
+		assert.Equal(t, version, response.Header.Get("API-Version"), path)
+	}
+
+	// the unversioned paths serve the version the header asks for
+	request := httptest.NewRequest(http.MethodGet, "/api/users/_current", nil)
+	request.Header.Set("Accept", "application/json")
+	request.Header.Set("Authorization", user.Token)
+	request.Header.Set("API-Version", "2")
+
+	response, err := app.Test(request)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+	assert.Equal(t, "v2", response.Header.Get("API-Version"))
+	assert.Contains(t, response.Header.Get("Vary"), "API-Version")
+
+	request = httptest.NewRequest(http.MethodGet, "/api/users/_current", nil)
+	request.Header.Set("Accept", "application/json")
+	request.Header.Set("Authorization", user.Token)
+	request.Header.Set("API-Version", "v9")
+
+	response, err = app.Test(request)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusBadRequest, response.StatusCode)
+
+	request = httptest.NewRequest(http.MethodGet, "/api/v9/users/_current", nil)
+	request.Header.Set("Accept", "application/json")
+	request.Header.Set("Authorization", user.Token)
+
+	response, err = app.Test(request)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusNotFound, response.StatusCode)
+}
+
+func TestDeprecatedEndpoints(t *testing.T) {
+	ClearAll()
+	TestRegister(t)
+	user := login(t, "versioning")
+
+	request := httptest.NewRequest(http.MethodPost, "/api/v1/users/refresh-token", strings.NewReader(`{"refresh_token":"`+user.RefreshToken+`"}`))
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("Accept", "application/json")
+
+	response, err := app.Test(request)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+	assert.Equal(t, "@1792022400", response.Header.Get("Deprecation"))
+	assert.Equal(t, "Thu, 15 Apr 2027 00:00:00 GMT", response.Header.Get("Sunset"))
+	assert.Equal(t, `</api/v2/users/_refresh>; rel="successor-version"`, response.Header.Get("Link"))
+
+	// v2 only serves the successor
+	user = login(t, "versioning")
+	request = httptest.NewRequest(http.MethodPost, "/api/v2/users/refresh-token", strings.NewReader(`{"refresh_token":"`+user.RefreshToken+`"}`))
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("Accept", "application/json")
+	request.Header.Set("Authorization", user.Token)
+
+	response, err = app.Test(request)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusNotFound, response.StatusCode)
+
+	request = httptest.NewRequest(http.MethodPost, "/api/v2/users/_refresh", strings.NewReader(`{"refresh_token":"`+user.RefreshToken+`"}`))
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("Accept", "application/json")
+
+	response, err = app.Test(request)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+	assert.Empty(t, response.Header.Get("Deprecation"))
+}
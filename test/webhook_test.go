@@ -0,0 +1,316 @@
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"go-rest-scaffold/internal/config"
+	"go-rest-scaffold/internal/entity"
+	"go-rest-scaffold/internal/gateway/webhook"
+	"go-rest-scaffold/internal/model"
+	"go-rest-scaffold/internal/repository"
+	"go-rest-scaffold/internal/usecase"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWebhookDeliveryReplay(t *testing.T) {
+	ClearAll()
+	TestLogin(t)
+
+	user := new(entity.User)
+	err := db.Where("id = ?", "khannedy").First(user).Error
+	assert.Nil(t, err)
+
+	// the endpoint rejects the first delivery and accepts the replay
+	var received atomic.Int32
+	endpoint := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if received.Add(1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			_, _ = w.Write([]byte("try again later"))
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer endpoint.Close()
+
+	requestBody := model.CreateWebhookRequest{
+		URL:        endpoint.URL,
+		EventTypes: []string{model.EventContactCreated},
+	}
+	bodyJson, err := json.Marshal(requestBody)
+	assert.Nil(t, err)
+
+	request := httptest.NewRequest(http.MethodPost, "/api/webhooks", strings.NewReader(string(bodyJson)))
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("Accept", "application/json")
+	request.Header.Set("Authorization", user.Token)
+
+	response, err := app.Test(request)
+	assert.Nil(t, err)
+
+	bytes, err := io.ReadAll(response.Body)
+	assert.Nil(t, err)
+
+	webhookBody := new(model.WebResponse[model.WebhookResponse])
+	err = json.Unmarshal(bytes, webhookBody)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+
+	request = httptest.NewRequest(http.MethodPost, "/api/contacts", strings.NewReader(`{"first_name":"Eko","email":"eko@example.com"}`))
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("Accept", "application/json")
+	request.Header.Set("Authorization", user.Token)
+
+	response, err = app.Test(request)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+
+	failed := new(entity.WebhookDelivery)
+	assert.Eventually(t, func() bool {
+		return db.Where("webhook_id = ?", webhookBody.Data.ID).Take(failed).Error == nil
+	}, 5*time.Second, 50*time.Millisecond)
+
+	assert.Equal(t, model.WebhookDeliveryFailed, failed.Status)
+	assert.Equal(t, http.StatusServiceUnavailable, failed.ResponseStatus)
+	assert.Equal(t, "try again later", failed.ResponseSnippet)
+	assert.Equal(t, 1, failed.Attempt)
+
+	request = httptest.NewRequest(http.MethodPost, "/api/webhooks/"+webhookBody.Data.ID+"/deliveries/"+failed.ID+"/replay", nil)
+	request.Header.Set("Accept", "application/json")
+	request.Header.Set("Authorization", user.Token)
+
+	response, err = app.Test(request)
+	assert.Nil(t, err)
+
+	bytes, err = io.ReadAll(response.Body)
+	assert.Nil(t, err)
+
+	deliveryBody := new(model.WebResponse[model.WebhookDeliveryResponse])
+	err = json.Unmarshal(bytes, deliveryBody)
+	assert.Nil(t, err)
+
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+	assert.Equal(t, model.WebhookDeliverySucceeded, deliveryBody.Data.Status)
+	assert.Equal(t, 2, deliveryBody.Data.Attempt)
+	assert.Equal(t, failed.ID, deliveryBody.Data.ReplayOf)
+	assert.Equal(t, failed.EventId, deliveryBody.Data.EventId)
+
+	request = httptest.NewRequest(http.MethodGet, "/api/webhooks/"+webhookBody.Data.ID+"/deliveries?status=failed", nil)
+	request.Header.Set("Accept", "application/json")
+	request.Header.Set("Authorization", user.Token)
+
+	response, err = app.Test(request)
+	assert.Nil(t, err)
+
+	bytes, err = io.ReadAll(response.Body)
+	assert.Nil(t, err)
+
+	deliveriesBody := new(model.WebResponse[[]model.WebhookDeliveryResponse])
+	err = json.Unmarshal(bytes, deliveriesBody)
+	assert.Nil(t, err)
+
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+	assert.Equal(t, int64(1), deliveriesBody.Paging.TotalItem)
+	assert.Equal(t, failed.ID, deliveriesBody.Data[0].ID)
+}
+
+func TestReplayFailedWebhookDeliveriesInvalidWindow(t *testing.T) {
+	ClearAll()
+	TestLogin(t)
+
+	user := new(entity.User)
+	err := db.Where("id = ?", "khannedy").First(user).Error
+	assert.Nil(t, err)
+
+	webhook := &entity.Webhook{ID: "0b6b1fb4-5c6e-4d59-9d8e-3f8f1d6f0001", UserId: user.ID, URL: "https://example.com/hook", Active: true}
+	err = db.Create(webhook).Error
+	assert.Nil(t, err)
+
+	request := httptest.NewRequest(http.MethodPost, "/api/webhooks/"+webhook.ID+"/deliveries/_replay", strings.NewReader(`{"from":2000,"to":1000}`))
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("Accept", "application/json")
+	request.Header.Set("Authorization", user.Token)
+
+	response, err := app.Test(request)
+	assert.Nil(t, err)
+
+	assert.Equal(t, http.StatusBadRequest, response.StatusCode)
+}
+
+func TestWebhookDeliverySignedAndRetried(t *testing.T) {
+	ClearAll()
+	TestLogin(t)
+
+	user := new(entity.User)
+	err := db.Where("id = ?", "khannedy").First(user).Error
+	assert.Nil(t, err)
+
+	// the endpoint rejects the first attempt and accepts the retry, checking the signature of both
+	var received, signed atomic.Int32
+	var secret atomic.Value
+	endpoint := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		timestamp, _ := strconv.ParseInt(r.Header.Get("Webhook-Timestamp"), 10, 64)
+		if expected, err := webhook.Sign(secret.Load().(string), r.Header.Get("Webhook-Id"), time.Unix(timestamp, 0), body); err == nil &&
+			expected == r.Header.Get("Webhook-Signature") {
+			signed.Add(1)
+		}
+		if received.Add(1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer endpoint.Close()
+
+	request := httptest.NewRequest(http.MethodPost, "/api/webhooks", strings.NewReader(`{"url":"`+endpoint.URL+`","event_types":["`+model.EventContactCreated+`"]}`))
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("Accept", "application/json")
+	request.Header.Set("Authorization", user.Token)
+
+	response, err := app.Test(request)
+	assert.Nil(t, err)
+
+	bytes, err := io.ReadAll(response.Body)
+	assert.Nil(t, err)
+
+	webhookBody := new(model.WebResponse[model.WebhookResponse])
+	err = json.Unmarshal(bytes, webhookBody)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+	assert.True(t, strings.HasPrefix(webhookBody.Data.Secret, "whsec_"))
+	secret.Store(webhookBody.Data.Secret)
+
+	request = httptest.NewRequest(http.MethodPost, "/api/contacts", strings.NewReader(`{"first_name":"Eko","email":"eko@example.com"}`))
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("Accept", "application/json")
+	request.Header.Set("Authorization", user.Token)
+
+	response, err = app.Test(request)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+
+	failed := new(entity.WebhookDelivery)
+	assert.Eventually(t, func() bool {
+		return db.Where("webhook_id = ?", webhookBody.Data.ID).Take(failed).Error == nil
+	}, 5*time.Second, 50*time.Millisecond)
+
+	assert.Equal(t, model.WebhookDeliveryFailed, failed.Status)
+	assert.NotNil(t, failed.NextAttemptAt)
+
+	// make the retry due now instead of after the backoff
+	err = db.Model(failed).Update("next_attempt_at", time.Now().UnixMilli()-1).Error
+	assert.Nil(t, err)
+
+	useCase := usecase.NewWebhookUseCase(txManager, log, validate, repository.NewWebhookRepository(log), repository.NewWebhookDeliveryRepository(log),
+		config.NewWebhookSender(viperConfig, log), nil, config.NewIDGenerators(viperConfig, db, log), config.NewWebhookOptions(viperConfig))
+
+	retried, err := useCase.RetryDue(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1), retried)
+
+	retry := new(entity.WebhookDelivery)
+	err = db.Where("webhook_id = ? AND attempt = ?", webhookBody.Data.ID, 2).Take(retry).Error
+	assert.Nil(t, err)
+	assert.Equal(t, model.WebhookDeliverySucceeded, retry.Status)
+	assert.Nil(t, retry.NextAttemptAt)
+	assert.Equal(t, int32(2), signed.Load())
+
+	// the retry is claimed, so the next run has nothing to send
+	retried, err = useCase.RetryDue(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, int64(0), retried)
+}
+
+func TestRotateWebhookSecret(t *testing.T) {
+	ClearAll()
+	TestLogin(t)
+
+	user := new(entity.User)
+	err := db.Where("id = ?", "khannedy").First(user).Error
+	assert.Nil(t, err)
+
+	hook := &entity.Webhook{ID: "0b6b1fb4-5c6e-4d59-9d8e-3f8f1d6f0002", UserId: user.ID, URL: "https://example.com/hook", Active: true}
+	err = db.Create(hook).Error
+	assert.Nil(t, err)
+
+	request := httptest.NewRequest(http.MethodPost, "/api/webhooks/"+hook.ID+"/_rotate-secret", nil)
+	request.Header.Set("Accept", "application/json")
+	request.Header.Set("Authorization", user.Token)
+
+	response, err := app.Test(request)
+	assert.Nil(t, err)
+
+	bytes, err := io.ReadAll(response.Body)
+	assert.Nil(t, err)
+
+	responseBody := new(model.WebResponse[model.WebhookResponse])
+	err = json.Unmarshal(bytes, responseBody)
+	assert.Nil(t, err)
+
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+	assert.True(t, strings.HasPrefix(responseBody.Data.Secret, "whsec_"))
+
+	err = db.Take(hook, "id = ?", hook.ID).Error
+	assert.Nil(t, err)
+	assert.Equal(t, responseBody.Data.Secret, hook.Secret)
+}
+
+func TestWebhookSenderRefusesPrivateAddresses(t *testing.T) {
+	var received atomic.Int32
+	endpoint := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received.Add(1)
+		_, _ = w.Write([]byte("internal"))
+	}))
+	defer endpoint.Close()
+	port := endpoint.URL[strings.LastIndex(endpoint.URL, ":"):]
+
+	sender := webhook.NewSender(time.Second, 1024, "test", nil)
+	for _, url := range []string{
+		endpoint.URL,
+		"http://localhost" + port,
+		"http://[::1]" + port,
+		"http://0.0.0.0" + port,
+		"http://10.0.0.1/hook",
+		"http://192.168.1.1/hook",
+		"http://169.254.169.254/latest/meta-data/",
+	} {
+		result := sender.Send(context.Background(), url, "", "event", model.EventContactCreated, []byte("{}"))
+		assert.ErrorIs(t, result.Err, webhook.ErrForbiddenAddress, url)
+		assert.Empty(t, result.Snippet, url)
+	}
+	assert.Equal(t, int32(0), received.Load())
+
+	// allowed networks are reached
+	allowed := webhook.NewSender(time.Second, 1024, "test", []netip.Prefix{netip.MustParsePrefix("127.0.0.0/8")})
+	result := allowed.Send(context.Background(), endpoint.URL, "", "event", model.EventContactCreated, []byte("{}"))
+	assert.Nil(t, result.Err)
+	assert.Equal(t, "internal", result.Snippet)
+}
+
+func TestWebhookSenderDoesNotFollowRedirects(t *testing.T) {
+	var redirected atomic.Int32
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		redirected.Add(1)
+	}))
+	defer target.Close()
+
+	endpoint := httptest.NewServer(http.RedirectHandler(target.URL, http.StatusTemporaryRedirect))
+	defer endpoint.Close()
+
+	sender := webhook.NewSender(time.Second, 1024, "test", []netip.Prefix{netip.MustParsePrefix("127.0.0.0/8")})
+	result := sender.Send(context.Background(), endpoint.URL, "", "event", model.EventContactCreated, []byte("{}"))
+	assert.Nil(t, result.Err)
+	assert.Equal(t, http.StatusTemporaryRedirect, result.Status)
+	assert.False(t, result.Succeeded())
+	assert.Equal(t, int32(0), redirected.Load())
+}